@@ -2,6 +2,7 @@ package objconv
 
 import (
 	"reflect"
+	"strings"
 	"sync"
 )
 
@@ -30,6 +31,7 @@ func Install(typ reflect.Type, adapter Adapter) {
 
 	adapterMutex.Lock()
 	adapterStore[typ] = adapter
+	delete(generatedAdapters, typ)
 	adapterMutex.Unlock()
 
 	// We have to clear the struct cache because it may now have become invalid.
@@ -37,7 +39,52 @@ func Install(typ reflect.Type, adapter Adapter) {
 	// it's unlikely that any encoding or decoding operations are taking place
 	// at this time so there should be no performance impact of clearing the
 	// cache.
-	structCache.clear()
+	clearStructCaches()
+}
+
+// InstallGeneric adds an adapter factory for all instantiations of the generic
+// type that typ is an instantiation of.
+//
+// Adapters installed with Install are keyed by fully instantiated types, which
+// doesn't work for user-defined generic containers (like Set[T] or Result[T])
+// since the program may use any number of instantiations. The factory is
+// called the first time each instantiation is encoded or decoded, it receives
+// the instantiated type and returns the adapter to use for it:
+//
+//	objconv.InstallGeneric(reflect.TypeOf(Set[int]{}), func(t reflect.Type) objconv.Adapter {
+//		return objconv.Adapter{Encode: encodeSet, Decode: decodeSet}
+//	})
+//
+// Adapters installed with Install for a specific instantiation take precedence
+// over the factory.
+//
+// The function panics if typ is not an instantiation of a generic type or if
+// factory is nil.
+func InstallGeneric(typ reflect.Type, factory func(reflect.Type) Adapter) {
+	origin, ok := genericOrigin(typ)
+
+	if !ok {
+		panic("objconv: " + typ.String() + " is not an instantiation of a generic type")
+	}
+
+	if factory == nil {
+		panic("objconv: the adapter factory of a generic type cannot be nil")
+	}
+
+	adapterMutex.Lock()
+	genericStore[origin] = factory
+
+	// Adapters previously generated for this generic type must be produced
+	// again by the new factory.
+	for t := range generatedAdapters {
+		if o, _ := genericOrigin(t); o == origin {
+			delete(adapterStore, t)
+			delete(generatedAdapters, t)
+		}
+	}
+	adapterMutex.Unlock()
+
+	clearStructCaches()
 }
 
 // AdapterOf returns the adapter for typ, setting ok to true if one was found,
@@ -45,11 +92,66 @@ func Install(typ reflect.Type, adapter Adapter) {
 func AdapterOf(typ reflect.Type) (a Adapter, ok bool) {
 	adapterMutex.RLock()
 	a, ok = adapterStore[typ]
+	n := len(genericStore)
 	adapterMutex.RUnlock()
+
+	if !ok && n != 0 {
+		a, ok = genericAdapterOf(typ)
+	}
+
 	return
 }
 
+func genericAdapterOf(typ reflect.Type) (a Adapter, ok bool) {
+	origin, isGeneric := genericOrigin(typ)
+
+	if !isGeneric {
+		return
+	}
+
+	adapterMutex.RLock()
+	factory := genericStore[origin]
+	adapterMutex.RUnlock()
+
+	if factory == nil {
+		return
+	}
+
+	a = factory(typ)
+
+	if a.Encode == nil || a.Decode == nil {
+		panic("objconv: the adapter factory of " + origin + " returned an adapter with nil functions for " + typ.String())
+	}
+
+	adapterMutex.Lock()
+	if _, exists := adapterStore[typ]; !exists {
+		adapterStore[typ] = a
+		generatedAdapters[typ] = struct{}{}
+	}
+	a = adapterStore[typ]
+	adapterMutex.Unlock()
+
+	return a, true
+}
+
+// genericOrigin returns a key identifying the generic type that typ is an
+// instantiation of. The reflect package doesn't expose generic types, the key
+// is made of the package path and the name of the type without its type
+// arguments.
+func genericOrigin(typ reflect.Type) (string, bool) {
+	name := typ.Name()
+	i := strings.IndexByte(name, '[')
+
+	if i <= 0 {
+		return "", false
+	}
+
+	return typ.PkgPath() + "." + name[:i], true
+}
+
 var (
-	adapterMutex sync.RWMutex
-	adapterStore = make(map[reflect.Type]Adapter)
+	adapterMutex      sync.RWMutex
+	adapterStore      = make(map[reflect.Type]Adapter)
+	genericStore      = make(map[string]func(reflect.Type) Adapter)
+	generatedAdapters = make(map[reflect.Type]struct{})
 )
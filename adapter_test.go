@@ -0,0 +1,51 @@
+package objconv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// adaptedName has an adapter encoding it in upper case, its pointer type also
+// implements encoding.TextMarshaler which must not be used.
+type adaptedName struct{ s string }
+
+func (n *adaptedName) MarshalText() ([]byte, error) { return []byte(n.s), nil }
+
+func (n *adaptedName) UnmarshalText(b []byte) error { n.s = string(b); return nil }
+
+func init() {
+	Install(reflect.TypeOf(adaptedName{}), Adapter{
+		Encode: func(e Encoder, v reflect.Value) error {
+			return e.Encode(strings.ToUpper(v.Interface().(adaptedName).s))
+		},
+		Decode: func(d Decoder, v reflect.Value) error {
+			var s string
+			err := d.Decode(&s)
+			v.Set(reflect.ValueOf(adaptedName{strings.ToLower(s)}))
+			return err
+		},
+	})
+}
+
+func TestAdapterOfPointerElem(t *testing.T) {
+	e := NewValueEmitter()
+
+	if err := (Encoder{Emitter: e}).Encode(&adaptedName{"hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := e.Value(); v != "HELLO" {
+		t.Errorf("the adapter of the element type was not used to encode a pointer: %#v", v)
+	}
+
+	var n *adaptedName
+
+	if err := NewDecoder(NewValueParser("WORLD")).Decode(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if n == nil || n.s != "world" {
+		t.Errorf("the adapter of the element type was not used to decode a pointer: %#v", n)
+	}
+}
@@ -0,0 +1,27 @@
+package list
+
+import (
+	"container/list"
+	"reflect"
+
+	"github.com/segmentio/objconv"
+)
+
+func decodeList(d objconv.Decoder, to reflect.Value) (err error) {
+	var l *list.List
+
+	if to.IsValid() {
+		l = to.Addr().Interface().(*list.List).Init()
+	} else {
+		l = list.New()
+	}
+
+	return d.DecodeArray(func(d objconv.Decoder) (err error) {
+		var v interface{}
+
+		if err = d.Decode(&v); err == nil {
+			l.PushBack(v)
+		}
+		return
+	})
+}
@@ -0,0 +1,27 @@
+package list
+
+import (
+	"container/list"
+	"reflect"
+
+	"github.com/segmentio/objconv"
+)
+
+func encodeList(e objconv.Encoder, v reflect.Value) error {
+	var l *list.List
+
+	if v.CanAddr() {
+		l = v.Addr().Interface().(*list.List)
+	} else {
+		x := v.Interface().(list.List)
+		l = &x
+	}
+
+	x := l.Front()
+
+	return e.EncodeArray(l.Len(), func(e objconv.Encoder) (err error) {
+		err = e.Encode(x.Value)
+		x = x.Next()
+		return
+	})
+}
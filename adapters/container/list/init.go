@@ -0,0 +1,24 @@
+package list
+
+import (
+	"container/list"
+	"reflect"
+
+	"github.com/segmentio/objconv"
+)
+
+func init() {
+	objconv.Install(reflect.TypeOf(list.List{}), ListAdapter())
+}
+
+// ListAdapter returns the adapter to encode and decode list.List values.
+//
+// Lists are represented as arrays of their element values. The types of the
+// elements cannot be known when decoding, they are decoded as generic values
+// (like decoding into an interface{}).
+func ListAdapter() objconv.Adapter {
+	return objconv.Adapter{
+		Encode: encodeList,
+		Decode: decodeList,
+	}
+}
@@ -0,0 +1,55 @@
+package list
+
+import (
+	"container/list"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/objconv/json"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []interface{}
+		json   string
+	}{
+		{"empty", []interface{}{}, `[]`},
+		{"values", []interface{}{"a", int64(1), true}, `["a",1,true]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := list.New()
+			for _, v := range test.values {
+				in.PushBack(v)
+			}
+
+			b, err := json.Marshal(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(b) != test.json {
+				t.Errorf("bad output: %s", b)
+			}
+
+			// The decoder must discard the existing elements of the list.
+			out := list.New()
+			out.PushBack("stale")
+
+			if err := json.Unmarshal(b, out); err != nil {
+				t.Fatal(err)
+			}
+
+			values := []interface{}{}
+			for e := out.Front(); e != nil; e = e.Next() {
+				values = append(values, e.Value)
+			}
+
+			if !reflect.DeepEqual(values, test.values) {
+				t.Errorf("bad values: %#v", values)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package ring
+
+import (
+	"container/ring"
+	"reflect"
+
+	"github.com/segmentio/objconv"
+)
+
+func decodeRing(d objconv.Decoder, to reflect.Value) (err error) {
+	var a []interface{}
+	var r *ring.Ring
+
+	if err = d.Decode(&a); err != nil {
+		return
+	}
+
+	if len(a) != 0 {
+		r = ring.New(len(a))
+
+		for _, v := range a {
+			r.Value = v
+			r = r.Next()
+		}
+	}
+
+	if to.IsValid() {
+		to.Set(reflect.ValueOf(r))
+	}
+	return
+}
@@ -0,0 +1,6 @@
+// Package ring provides adapters for types in the standard container/ring
+// package.
+//
+// The types and functions in this package aren't usually used direction and
+// instead are used implicitly by installing adapters on objconv.
+package ring
@@ -0,0 +1,22 @@
+package ring
+
+import (
+	"container/ring"
+	"reflect"
+
+	"github.com/segmentio/objconv"
+)
+
+func encodeRing(e objconv.Encoder, v reflect.Value) error {
+	r := v.Interface().(*ring.Ring)
+
+	if r == nil {
+		return e.Encode(nil)
+	}
+
+	return e.EncodeArray(r.Len(), func(e objconv.Encoder) (err error) {
+		err = e.Encode(r.Value)
+		r = r.Next()
+		return
+	})
+}
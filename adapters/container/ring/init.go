@@ -0,0 +1,28 @@
+package ring
+
+import (
+	"container/ring"
+	"reflect"
+
+	"github.com/segmentio/objconv"
+)
+
+func init() {
+	objconv.Install(reflect.TypeOf((*ring.Ring)(nil)), RingAdapter())
+}
+
+// RingAdapter returns the adapter to encode and decode *ring.Ring values.
+//
+// Rings are represented as arrays of their element values, starting with the
+// element that the pointer refers to, and nil rings as null values. The types
+// of the elements cannot be known when decoding, they are decoded as generic
+// values (like decoding into an interface{}).
+//
+// The adapter is installed for pointers because the elements of a ring are
+// linked to each other, a ring.Ring value cannot be copied.
+func RingAdapter() objconv.Adapter {
+	return objconv.Adapter{
+		Encode: encodeRing,
+		Decode: decodeRing,
+	}
+}
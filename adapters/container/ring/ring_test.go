@@ -0,0 +1,74 @@
+package ring
+
+import (
+	"container/ring"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/objconv/json"
+)
+
+func TestRoundTrip(t *testing.T) {
+	in := ring.New(3)
+	for _, v := range []interface{}{"a", int64(1), true} {
+		in.Value = v
+		in = in.Next()
+	}
+
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(b); s != `["a",1,true]` {
+		t.Errorf("bad output: %s", s)
+	}
+
+	var out *ring.Ring
+
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	values := []interface{}{}
+	out.Do(func(v interface{}) { values = append(values, v) })
+
+	if !reflect.DeepEqual(values, []interface{}{"a", int64(1), true}) {
+		t.Errorf("bad values: %#v", values)
+	}
+}
+
+func TestNil(t *testing.T) {
+	b, err := json.Marshal((*ring.Ring)(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(b); s != `null` {
+		t.Errorf("bad output: %s", s)
+	}
+
+	for _, s := range []string{`null`, `[]`} {
+		r := ring.New(1)
+
+		if err := json.Unmarshal([]byte(s), &r); err != nil {
+			t.Fatal(err)
+		}
+
+		if r != nil {
+			t.Errorf("%s was not decoded as a nil ring: %v", s, r)
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	// A zero ring.Ring is a ring of one element holding a nil value.
+	b, err := json.Marshal(&ring.Ring{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(b); s != `[null]` {
+		t.Errorf("bad output: %s", s)
+	}
+}
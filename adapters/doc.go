@@ -6,7 +6,13 @@
 package adapters
 
 import (
+	_ "github.com/segmentio/objconv/adapters/container/list"
+	_ "github.com/segmentio/objconv/adapters/container/ring"
+	_ "github.com/segmentio/objconv/adapters/io/fs"
 	_ "github.com/segmentio/objconv/adapters/net"
 	_ "github.com/segmentio/objconv/adapters/net/mail"
 	_ "github.com/segmentio/objconv/adapters/net/url"
+	_ "github.com/segmentio/objconv/adapters/regexp"
+	_ "github.com/segmentio/objconv/adapters/sync"
+	_ "github.com/segmentio/objconv/adapters/sync/atomic"
 )
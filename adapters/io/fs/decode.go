@@ -0,0 +1,116 @@
+package fs
+
+import (
+	"io/fs"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+func decodeFileMode(d objconv.Decoder, to reflect.Value) (err error) {
+	var v interface{}
+	var m fs.FileMode
+
+	if err = d.Decode(&v); err != nil {
+		return
+	}
+
+	switch x := v.(type) {
+	case int64:
+		m, err = fromUnixMode(uint64(x), x < 0)
+	case uint64:
+		m, err = fromUnixMode(x, false)
+	case objconv.Number:
+		var n int64
+		if n, err = x.Int64(); err == nil {
+			m, err = fromUnixMode(uint64(n), n < 0)
+		}
+	case string:
+		m, err = parseFileMode(x)
+	default:
+		err = objutil.Errorf(objutil.ErrType, "objconv: cannot decode a file mode from a value of type %T", v)
+	}
+
+	if err == nil && to.IsValid() {
+		to.SetUint(uint64(m))
+	}
+	return
+}
+
+func parseFileMode(s string) (fs.FileMode, error) {
+	if len(s) >= 10 && !isOctal(s) {
+		return parseSymbolic(s)
+	}
+
+	u, err := strconv.ParseUint(strings.TrimPrefix(s, "0o"), 8, 32)
+
+	if err != nil {
+		return 0, objutil.Errorf(objutil.ErrSyntax, "objconv: invalid file mode: %q", s)
+	}
+
+	return fromUnixMode(u, false)
+}
+
+func parseSymbolic(s string) (fs.FileMode, error) {
+	const types = "dalTLDpSugct?" // same as fs.FileMode.String
+	const perms = "rwxrwxrwx"
+
+	var m fs.FileMode
+	t, p := s[:len(s)-9], s[len(s)-9:]
+
+	if t != "-" {
+		for _, c := range t {
+			i := strings.IndexRune(types, c)
+
+			if i < 0 {
+				return 0, objutil.Errorf(objutil.ErrSyntax, "objconv: invalid file mode: %q", s)
+			}
+
+			m |= 1 << uint(32-1-i)
+		}
+	}
+
+	for i, c := range p {
+		switch byte(c) {
+		case perms[i]:
+			m |= 1 << uint(9-1-i)
+		case '-':
+		default:
+			return 0, objutil.Errorf(objutil.ErrSyntax, "objconv: invalid file mode: %q", s)
+		}
+	}
+
+	return m, nil
+}
+
+func fromUnixMode(u uint64, negative bool) (fs.FileMode, error) {
+	if negative || u > 07777 {
+		return 0, objutil.Errorf(objutil.ErrRange, "objconv: file mode out of range: %#o", u)
+	}
+
+	m := fs.FileMode(u & 0777)
+
+	if u&04000 != 0 {
+		m |= fs.ModeSetuid
+	}
+	if u&02000 != 0 {
+		m |= fs.ModeSetgid
+	}
+	if u&01000 != 0 {
+		m |= fs.ModeSticky
+	}
+
+	return m, nil
+}
+
+func isOctal(s string) bool {
+	for _, c := range strings.TrimPrefix(s, "0o") {
+		if c < '0' || c > '7' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+package fs
+
+import (
+	"io/fs"
+	"reflect"
+	"strconv"
+
+	"github.com/segmentio/objconv"
+)
+
+func encodeOctal(e objconv.Encoder, v reflect.Value) error {
+	m := fs.FileMode(v.Uint())
+	return e.Encode("0" + strconv.FormatUint(uint64(unixMode(m)), 8))
+}
+
+func encodeSymbolic(e objconv.Encoder, v reflect.Value) error {
+	return e.Encode(fs.FileMode(v.Uint()).String())
+}
+
+// unixMode converts m to the Unix permission bits.
+func unixMode(m fs.FileMode) uint32 {
+	u := uint32(m.Perm())
+
+	if m&fs.ModeSetuid != 0 {
+		u |= 04000
+	}
+	if m&fs.ModeSetgid != 0 {
+		u |= 02000
+	}
+	if m&fs.ModeSticky != 0 {
+		u |= 01000
+	}
+
+	return u
+}
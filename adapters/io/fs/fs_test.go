@@ -0,0 +1,96 @@
+package fs
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/objconv"
+)
+
+func TestFileModeAdapter(t *testing.T) {
+	tests := []struct {
+		format Format
+		mode   fs.FileMode
+		value  string
+	}{
+		{Octal, 0644, "0644"},
+		{Octal, 0755 | fs.ModeSetuid | fs.ModeSticky, "05755"},
+		{Symbolic, 0644, "-rw-r--r--"},
+		{Symbolic, 0755 | fs.ModeDir, "drwxr-xr-x"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			a := FileModeAdapter(test.format)
+			e := objconv.NewValueEmitter()
+
+			if err := a.Encode(objconv.Encoder{Emitter: e}, reflect.ValueOf(test.mode)); err != nil {
+				t.Fatal(err)
+			}
+
+			if v := e.Value(); v != test.value {
+				t.Errorf("bad value: %#v", v)
+			}
+
+			var m fs.FileMode
+
+			if err := a.Decode(objconv.Decoder{Parser: objconv.NewValueParser(test.value)}, reflect.ValueOf(&m).Elem()); err != nil {
+				t.Fatal(err)
+			}
+
+			if m != test.mode {
+				t.Errorf("bad mode: %v", m)
+			}
+		})
+	}
+}
+
+func TestDecodeFileMode(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		mode fs.FileMode
+	}{
+		{"0o600", 0600},
+		{int64(0644), 0644},
+		{uint64(02750), 0750 | fs.ModeSetgid},
+	}
+
+	for _, test := range tests {
+		for _, useNumber := range []bool{false, true} {
+			var m fs.FileMode
+
+			d := objconv.Decoder{Parser: objconv.NewValueParser(test.in), UseNumber: useNumber}
+
+			if err := d.Decode(&m); err != nil {
+				t.Errorf("%#v: %v", test.in, err)
+			} else if m != test.mode {
+				t.Errorf("%#v: bad mode: %v", test.in, m)
+			}
+		}
+	}
+}
+
+func TestDecodeFileModeErrors(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		kind error
+	}{
+		{"0648", objconv.ErrSyntax},
+		{"rw-r--r--", objconv.ErrSyntax},
+		{"-rw-r--r-z", objconv.ErrSyntax},
+		{"Xrw-r--r--", objconv.ErrSyntax},
+		{int64(-1), objconv.ErrRange},
+		{int64(010000), objconv.ErrRange},
+		{true, objconv.ErrType},
+	}
+
+	for _, test := range tests {
+		var m fs.FileMode
+
+		if err := objconv.NewDecoder(objconv.NewValueParser(test.in)).Decode(&m); !errors.Is(err, test.kind) {
+			t.Errorf("%#v: bad error: %v", test.in, err)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package fs
+
+import (
+	"io/fs"
+	"reflect"
+
+	"github.com/segmentio/objconv"
+)
+
+func init() {
+	objconv.Install(reflect.TypeOf(fs.FileMode(0)), FileModeAdapter(Octal))
+}
+
+// Format is an enumeration of the representations of file modes.
+type Format int
+
+const (
+	// Octal represents file modes as strings of octal Unix permissions, like
+	// "0644". The file type bits are not encoded.
+	Octal Format = iota
+
+	// Symbolic represents file modes as returned by fs.FileMode.String, like
+	// "drwxr-xr-x".
+	Symbolic
+)
+
+// FileModeAdapter returns the adapter to encode and decode fs.FileMode values
+// in the given format.
+//
+// The adapter installed by the package uses the Octal format, programs that
+// prefer the symbolic representation can install their own:
+//
+//	objconv.Install(reflect.TypeOf(fs.FileMode(0)), fs.FileModeAdapter(fs.Symbolic))
+//
+// Regardless of the format, the decoder accepts both representations, as well
+// as integers which are interpreted as Unix permissions.
+func FileModeAdapter(format Format) objconv.Adapter {
+	encode := encodeOctal
+
+	if format == Symbolic {
+		encode = encodeSymbolic
+	}
+
+	return objconv.Adapter{
+		Encode: encode,
+		Decode: decodeFileMode,
+	}
+}
@@ -1,13 +1,13 @@
 package net
 
 import (
-	"errors"
 	"net"
 	"reflect"
 	"strconv"
 	"strings"
 
 	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
 )
 
 func decodeTCPAddr(d objconv.Decoder, to reflect.Value) (err error) {
@@ -79,7 +79,7 @@ func decodeIPAddr(d objconv.Decoder, to reflect.Value) (err error) {
 	}
 
 	if a.IP = net.ParseIP(s); a.IP == nil {
-		err = errors.New("objconv: bad IP address: " + s)
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv: bad IP address: %s", s)
 		return
 	}
 
@@ -98,7 +98,7 @@ func decodeIP(d objconv.Decoder, to reflect.Value) (err error) {
 	}
 
 	if ip = net.ParseIP(s); ip == nil {
-		err = errors.New("objconv: bad IP address: " + s)
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv: bad IP address: %s", s)
 		return
 	}
 
@@ -121,14 +121,14 @@ func parseNetAddr(s string) (ip net.IP, port int, zone string, err error) {
 			h, zone = h[:off], h[off+1:]
 		}
 		if ip = net.ParseIP(h); ip == nil {
-			err = errors.New("objconv: bad IP address: " + s)
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv: bad IP address: %s", s)
 			return
 		}
 	}
 
 	if len(p) != 0 {
 		if port, err = strconv.Atoi(p); err != nil || port < 0 || port > 65535 {
-			err = errors.New("objconv: bad port number: " + s)
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv: bad port number: %s", s)
 			return
 		}
 	}
@@ -1,11 +1,11 @@
 package mail
 
 import (
-	"errors"
 	"net/mail"
 	"reflect"
 
 	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
 )
 
 func decodeAddress(d objconv.Decoder, to reflect.Value) (err error) {
@@ -17,7 +17,7 @@ func decodeAddress(d objconv.Decoder, to reflect.Value) (err error) {
 	}
 
 	if a, err = mail.ParseAddress(s); err != nil {
-		err = errors.New("objconv: bad email address: " + err.Error())
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv: bad email address: %s", err)
 		return
 	}
 
@@ -36,7 +36,7 @@ func decodeAddressList(d objconv.Decoder, to reflect.Value) (err error) {
 	}
 
 	if l, err = mail.ParseAddressList(s); err != nil {
-		err = errors.New("objconv: bad email address list: " + err.Error())
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv: bad email address list: %s", err)
 		return
 	}
 
@@ -1,11 +1,11 @@
 package url
 
 import (
-	"errors"
 	"net/url"
 	"reflect"
 
 	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
 )
 
 func decodeURL(d objconv.Decoder, to reflect.Value) (err error) {
@@ -17,7 +17,7 @@ func decodeURL(d objconv.Decoder, to reflect.Value) (err error) {
 	}
 
 	if u, err = url.Parse(s); err != nil {
-		err = errors.New("objconv: bad URL: " + err.Error())
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv: bad URL: %s", err)
 		return
 	}
 
@@ -32,11 +32,11 @@ func decodeQuery(d objconv.Decoder, to reflect.Value) (err error) {
 	var s string
 
 	if err = d.Decode(&s); err != nil {
-
+		return
 	}
 
 	if v, err = url.ParseQuery(s); err != nil {
-		err = errors.New("objconv: bad URL values: " + err.Error())
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv: bad URL values: %s", err)
 		return
 	}
 
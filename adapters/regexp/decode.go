@@ -0,0 +1,28 @@
+package regexp
+
+import (
+	"reflect"
+	"regexp"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+func decodeRegexp(d objconv.Decoder, to reflect.Value) (err error) {
+	var r *regexp.Regexp
+	var s string
+
+	if err = d.Decode(&s); err != nil {
+		return
+	}
+
+	if r, err = regexp.Compile(s); err != nil {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv: bad regular expression: %s", err)
+		return
+	}
+
+	if to.IsValid() {
+		to.Set(reflect.ValueOf(*r))
+	}
+	return
+}
@@ -0,0 +1,13 @@
+package regexp
+
+import (
+	"reflect"
+	"regexp"
+
+	"github.com/segmentio/objconv"
+)
+
+func encodeRegexp(e objconv.Encoder, v reflect.Value) error {
+	r := v.Interface().(regexp.Regexp)
+	return e.Encode(r.String())
+}
@@ -0,0 +1,23 @@
+package regexp
+
+import (
+	"reflect"
+	"regexp"
+
+	"github.com/segmentio/objconv"
+)
+
+func init() {
+	objconv.Install(reflect.TypeOf(regexp.Regexp{}), RegexpAdapter())
+}
+
+// RegexpAdapter returns the adapter to encode and decode regexp.Regexp values.
+//
+// Regular expressions are represented as the strings they were compiled from,
+// decoding reports an error if the string is not a valid expression.
+func RegexpAdapter() objconv.Adapter {
+	return objconv.Adapter{
+		Encode: encodeRegexp,
+		Decode: decodeRegexp,
+	}
+}
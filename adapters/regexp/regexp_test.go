@@ -0,0 +1,58 @@
+package regexp
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/json"
+)
+
+func TestRoundTrip(t *testing.T) {
+	in := struct {
+		Ptr *regexp.Regexp
+		Val regexp.Regexp
+	}{
+		Ptr: regexp.MustCompile(`^a+b$`),
+		Val: *regexp.MustCompile(`[0-9]{2}`),
+	}
+
+	b, err := json.Marshal(&in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(b); s != `{"Ptr":"^a+b$","Val":"[0-9]{2}"}` {
+		t.Errorf("bad output: %s", s)
+	}
+
+	var out struct {
+		Ptr *regexp.Regexp
+		Val regexp.Regexp
+	}
+
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Ptr == nil || out.Ptr.String() != `^a+b$` || !out.Ptr.MatchString("aab") {
+		t.Errorf("bad pointer: %v", out.Ptr)
+	}
+
+	if out.Val.String() != `[0-9]{2}` || !out.Val.MatchString("42") {
+		t.Errorf("bad value: %v", &out.Val)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	var r *regexp.Regexp
+
+	if err := json.Unmarshal([]byte(`"a(b"`), &r); !errors.Is(err, objconv.ErrSyntax) {
+		t.Error("bad error:", err)
+	}
+
+	if r != nil {
+		t.Errorf("an invalid expression was decoded: %v", r)
+	}
+}
@@ -0,0 +1,84 @@
+package atomic
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/segmentio/objconv/json"
+)
+
+type values struct {
+	Bool   atomic.Bool
+	Int32  atomic.Int32
+	Int64  atomic.Int64
+	Uint32 atomic.Uint32
+	Uint64 atomic.Uint64
+	Value  atomic.Value
+	Ptr    atomic.Pointer[string]
+	Nil    atomic.Pointer[string]
+}
+
+func TestRoundTrip(t *testing.T) {
+	s := "hello"
+
+	var in values
+	in.Bool.Store(true)
+	in.Int32.Store(-32)
+	in.Int64.Store(-64)
+	in.Uint32.Store(32)
+	in.Uint64.Store(64)
+	in.Value.Store("world")
+	in.Ptr.Store(&s)
+
+	b, err := json.Marshal(&in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const expect = `{"Bool":true,"Int32":-32,"Int64":-64,"Uint32":32,"Uint64":64,"Value":"world","Ptr":"hello","Nil":null}`
+
+	if string(b) != expect {
+		t.Errorf("bad output: %s", b)
+	}
+
+	var out values
+	out.Nil.Store(&s)
+
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	switch {
+	case !out.Bool.Load():
+		t.Error("bad bool")
+	case out.Int32.Load() != -32:
+		t.Error("bad int32:", out.Int32.Load())
+	case out.Int64.Load() != -64:
+		t.Error("bad int64:", out.Int64.Load())
+	case out.Uint32.Load() != 32:
+		t.Error("bad uint32:", out.Uint32.Load())
+	case out.Uint64.Load() != 64:
+		t.Error("bad uint64:", out.Uint64.Load())
+	case out.Value.Load() != "world":
+		t.Errorf("bad value: %#v", out.Value.Load())
+	case out.Ptr.Load() == nil || *out.Ptr.Load() != "hello":
+		t.Errorf("bad pointer: %v", out.Ptr.Load())
+	case out.Nil.Load() != nil:
+		t.Errorf("null was not decoded as a nil pointer: %v", out.Nil.Load())
+	}
+}
+
+func TestValueNull(t *testing.T) {
+	var v struct {
+		Value atomic.Value
+	}
+	v.Value.Store(42)
+
+	if err := json.Unmarshal([]byte(`{"Value":null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if x := v.Value.Load(); x != 42 {
+		t.Errorf("decoding null modified the value: %#v", x)
+	}
+}
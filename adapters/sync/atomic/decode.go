@@ -0,0 +1,74 @@
+package atomic
+
+import (
+	"reflect"
+	"sync/atomic"
+
+	"github.com/segmentio/objconv"
+)
+
+func decodeBool(d objconv.Decoder, to reflect.Value) (err error) {
+	var x bool
+
+	if err = d.Decode(&x); err == nil && to.IsValid() {
+		to.Addr().Interface().(*atomic.Bool).Store(x)
+	}
+	return
+}
+
+func decodeInt32(d objconv.Decoder, to reflect.Value) (err error) {
+	var x int32
+
+	if err = d.Decode(&x); err == nil && to.IsValid() {
+		to.Addr().Interface().(*atomic.Int32).Store(x)
+	}
+	return
+}
+
+func decodeInt64(d objconv.Decoder, to reflect.Value) (err error) {
+	var x int64
+
+	if err = d.Decode(&x); err == nil && to.IsValid() {
+		to.Addr().Interface().(*atomic.Int64).Store(x)
+	}
+	return
+}
+
+func decodeUint32(d objconv.Decoder, to reflect.Value) (err error) {
+	var x uint32
+
+	if err = d.Decode(&x); err == nil && to.IsValid() {
+		to.Addr().Interface().(*atomic.Uint32).Store(x)
+	}
+	return
+}
+
+func decodeUint64(d objconv.Decoder, to reflect.Value) (err error) {
+	var x uint64
+
+	if err = d.Decode(&x); err == nil && to.IsValid() {
+		to.Addr().Interface().(*atomic.Uint64).Store(x)
+	}
+	return
+}
+
+func decodeValue(d objconv.Decoder, to reflect.Value) (err error) {
+	var x interface{}
+
+	if err = d.Decode(&x); err == nil && x != nil && to.IsValid() {
+		to.Addr().Interface().(*atomic.Value).Store(x)
+	}
+	return
+}
+
+func decodePointer(d objconv.Decoder, to reflect.Value, typ reflect.Type) (err error) {
+	// Decoding into a pointer to the *T type that the atomic.Pointer holds lets
+	// null values be decoded as nil pointers.
+	load, _ := reflect.PtrTo(typ).MethodByName("Load")
+	x := reflect.New(load.Type.Out(0))
+
+	if err = d.Decode(x.Interface()); err == nil && to.IsValid() {
+		to.Addr().MethodByName("Store").Call([]reflect.Value{x.Elem()})
+	}
+	return
+}
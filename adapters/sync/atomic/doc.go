@@ -0,0 +1,6 @@
+// Package atomic provides adapters for types in the standard sync/atomic
+// package.
+//
+// The types and functions in this package aren't usually used direction and
+// instead are used implicitly by installing adapters on objconv.
+package atomic
@@ -0,0 +1,48 @@
+package atomic
+
+import (
+	"reflect"
+	"sync/atomic"
+
+	"github.com/segmentio/objconv"
+)
+
+func encodeBool(e objconv.Encoder, v reflect.Value) error {
+	return e.Encode(pointerTo(v).(*atomic.Bool).Load())
+}
+
+func encodeInt32(e objconv.Encoder, v reflect.Value) error {
+	return e.Encode(pointerTo(v).(*atomic.Int32).Load())
+}
+
+func encodeInt64(e objconv.Encoder, v reflect.Value) error {
+	return e.Encode(pointerTo(v).(*atomic.Int64).Load())
+}
+
+func encodeUint32(e objconv.Encoder, v reflect.Value) error {
+	return e.Encode(pointerTo(v).(*atomic.Uint32).Load())
+}
+
+func encodeUint64(e objconv.Encoder, v reflect.Value) error {
+	return e.Encode(pointerTo(v).(*atomic.Uint64).Load())
+}
+
+func encodeValue(e objconv.Encoder, v reflect.Value) error {
+	return e.Encode(pointerTo(v).(*atomic.Value).Load())
+}
+
+func encodePointer(e objconv.Encoder, v reflect.Value) error {
+	return e.Encode(reflect.ValueOf(pointerTo(v)).MethodByName("Load").Call(nil)[0].Interface())
+}
+
+// pointerTo returns a pointer to the value of v, the methods of atomic types
+// all have pointer receivers but the values passed to adapters are not always
+// addressable.
+func pointerTo(v reflect.Value) interface{} {
+	if !v.CanAddr() {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		v = p.Elem()
+	}
+	return v.Addr().Interface()
+}
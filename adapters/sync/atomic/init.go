@@ -0,0 +1,86 @@
+package atomic
+
+import (
+	"reflect"
+	"sync/atomic"
+
+	"github.com/segmentio/objconv"
+)
+
+func init() {
+	objconv.Install(typeOf((*atomic.Bool)(nil)), BoolAdapter())
+	objconv.Install(typeOf((*atomic.Int32)(nil)), Int32Adapter())
+	objconv.Install(typeOf((*atomic.Int64)(nil)), Int64Adapter())
+	objconv.Install(typeOf((*atomic.Uint32)(nil)), Uint32Adapter())
+	objconv.Install(typeOf((*atomic.Uint64)(nil)), Uint64Adapter())
+	objconv.Install(typeOf((*atomic.Value)(nil)), ValueAdapter())
+	objconv.InstallGeneric(typeOf((*atomic.Pointer[int])(nil)), PointerAdapter)
+}
+
+// BoolAdapter returns the adapter to encode and decode atomic.Bool values.
+func BoolAdapter() objconv.Adapter {
+	return objconv.Adapter{
+		Encode: encodeBool,
+		Decode: decodeBool,
+	}
+}
+
+// Int32Adapter returns the adapter to encode and decode atomic.Int32 values.
+func Int32Adapter() objconv.Adapter {
+	return objconv.Adapter{
+		Encode: encodeInt32,
+		Decode: decodeInt32,
+	}
+}
+
+// Int64Adapter returns the adapter to encode and decode atomic.Int64 values.
+func Int64Adapter() objconv.Adapter {
+	return objconv.Adapter{
+		Encode: encodeInt64,
+		Decode: decodeInt64,
+	}
+}
+
+// Uint32Adapter returns the adapter to encode and decode atomic.Uint32 values.
+func Uint32Adapter() objconv.Adapter {
+	return objconv.Adapter{
+		Encode: encodeUint32,
+		Decode: decodeUint32,
+	}
+}
+
+// Uint64Adapter returns the adapter to encode and decode atomic.Uint64 values.
+func Uint64Adapter() objconv.Adapter {
+	return objconv.Adapter{
+		Encode: encodeUint64,
+		Decode: decodeUint64,
+	}
+}
+
+// ValueAdapter returns the adapter to encode and decode atomic.Value values.
+//
+// The dynamic type of the stored values cannot be known when decoding, so they
+// are decoded as generic values (like decoding into an interface{}). Decoding
+// a null value leaves the atomic.Value unchanged.
+func ValueAdapter() objconv.Adapter {
+	return objconv.Adapter{
+		Encode: encodeValue,
+		Decode: decodeValue,
+	}
+}
+
+// PointerAdapter returns the adapter to encode and decode values of typ,
+// which must be an instantiation of atomic.Pointer. The pointers are encoded
+// as the values they point to, or null if they are nil.
+func PointerAdapter(typ reflect.Type) objconv.Adapter {
+	return objconv.Adapter{
+		Encode: encodePointer,
+		Decode: func(d objconv.Decoder, to reflect.Value) error {
+			return decodePointer(d, to, typ)
+		},
+	}
+}
+
+func typeOf(p interface{}) reflect.Type {
+	return reflect.TypeOf(p).Elem()
+}
@@ -0,0 +1,30 @@
+package sync
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/segmentio/objconv"
+)
+
+func decodeMap(d objconv.Decoder, to reflect.Value) (err error) {
+	var m map[interface{}]interface{}
+
+	if err = d.Decode(&m); err != nil {
+		return
+	}
+
+	if to.IsValid() {
+		p := to.Addr().Interface().(*sync.Map)
+
+		p.Range(func(key, _ interface{}) bool {
+			p.Delete(key)
+			return true
+		})
+
+		for k, v := range m {
+			p.Store(k, v)
+		}
+	}
+	return
+}
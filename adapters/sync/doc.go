@@ -0,0 +1,5 @@
+// Package sync provides adapters for types in the standard sync package.
+//
+// The types and functions in this package aren't usually used direction and
+// instead are used implicitly by installing adapters on objconv.
+package sync
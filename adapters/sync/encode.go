@@ -0,0 +1,31 @@
+package sync
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/segmentio/objconv"
+)
+
+func encodeMap(e objconv.Encoder, v reflect.Value) error {
+	m := make(map[interface{}]interface{})
+
+	pointerTo(v).(*sync.Map).Range(func(key, value interface{}) bool {
+		m[key] = value
+		return true
+	})
+
+	return e.Encode(m)
+}
+
+// pointerTo returns a pointer to the value of v, the methods of sync types
+// all have pointer receivers but the values passed to adapters are not always
+// addressable.
+func pointerTo(v reflect.Value) interface{} {
+	if !v.CanAddr() {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		v = p.Elem()
+	}
+	return v.Addr().Interface()
+}
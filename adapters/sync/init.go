@@ -0,0 +1,35 @@
+package sync
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/segmentio/objconv"
+)
+
+func init() {
+	objconv.Install(reflect.TypeOf((*sync.Map)(nil)).Elem(), MapAdapter())
+}
+
+// MapAdapter returns the adapter to encode and decode sync.Map values.
+//
+// The maps are represented like regular Go maps.
+func MapAdapter() objconv.Adapter {
+	return objconv.Adapter{
+		Encode: encodeMap,
+		Decode: decodeMap,
+	}
+}
+
+// IgnoreLocks configures objconv to skip struct fields of type sync.Mutex,
+// sync.RWMutex, sync.WaitGroup, and sync.Once, which carry no data and would
+// otherwise be encoded as empty objects.
+//
+// The package doesn't call this function on initialization, programs that
+// want struct fields of these types to be ignored must call it explicitly.
+func IgnoreLocks() {
+	objconv.IgnoreType(reflect.TypeOf((*sync.Mutex)(nil)).Elem())
+	objconv.IgnoreType(reflect.TypeOf((*sync.RWMutex)(nil)).Elem())
+	objconv.IgnoreType(reflect.TypeOf((*sync.WaitGroup)(nil)).Elem())
+	objconv.IgnoreType(reflect.TypeOf((*sync.Once)(nil)).Elem())
+}
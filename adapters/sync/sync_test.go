@@ -0,0 +1,70 @@
+package sync
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/segmentio/objconv/json"
+)
+
+func TestMap(t *testing.T) {
+	var m sync.Map
+	m.Store("a", "1")
+	m.Store("b", "2")
+
+	b, err := json.Marshal(&m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v struct {
+		M sync.Map
+	}
+	v.M.Store("c", "3")
+
+	if err := json.Unmarshal([]byte(`{"M":`+string(b)+`}`), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	v.M.Range(func(key, value interface{}) bool {
+		if x, _ := m.Load(key); x != value {
+			t.Errorf("bad value for %v: %#v != %#v", key, x, value)
+		}
+		n++
+		return true
+	})
+
+	if n != 2 {
+		t.Errorf("bad number of entries: %d", n)
+	}
+}
+
+func TestIgnoreLocks(t *testing.T) {
+	IgnoreLocks()
+
+	type counter struct {
+		Mu sync.RWMutex
+		Wg sync.WaitGroup
+		N  int
+	}
+
+	b, err := json.Marshal(&counter{N: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(b); s != `{"N":1}` {
+		t.Errorf("bad output: %s", s)
+	}
+
+	var c counter
+
+	if err := json.Unmarshal([]byte(`{"Mu":{},"Wg":{},"N":2}`), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.N != 2 {
+		t.Errorf("bad value: %d", c.N)
+	}
+}
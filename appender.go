@@ -0,0 +1,118 @@
+package objconv
+
+import (
+	"reflect"
+	"sync"
+)
+
+// textAppender and binaryAppender have the signatures of the
+// encoding.TextAppender and encoding.BinaryAppender interfaces of Go 1.24,
+// they are matched by their methods so programs built with older versions of
+// Go can use them too.
+type textAppender interface {
+	AppendText(b []byte) ([]byte, error)
+}
+
+type binaryAppender interface {
+	AppendBinary(b []byte) ([]byte, error)
+}
+
+var (
+	textAppenderInterface   = elemTypeOf((*textAppender)(nil))
+	binaryAppenderInterface = elemTypeOf((*binaryAppender)(nil))
+)
+
+// isTextMarshaler returns true if values of type t have a text representation,
+// either via encoding.TextMarshaler or encoding.TextAppender.
+func isTextMarshaler(t reflect.Type) bool {
+	return t.Implements(textMarshalerInterface) || t.Implements(textAppenderInterface)
+}
+
+// isBinaryMarshaler returns true if values of type t have a binary
+// representation, either via encoding.BinaryMarshaler or
+// encoding.BinaryAppender.
+func isBinaryMarshaler(t reflect.Type) bool {
+	return t.Implements(binaryMarshalerInterface) || t.Implements(binaryAppenderInterface)
+}
+
+// The transientEmitter interface may be implemented by emitters which don't
+// retain the strings and byte slices they receive after their methods return,
+// because they copy or write them right away. Encoders reuse the buffers that
+// values are appended to when marshaled with such emitters.
+type transientEmitter interface {
+	// TransientEmitter returns true if the emitter doesn't retain values.
+	TransientEmitter() bool
+}
+
+func isTransientEmitter(emitter Emitter) bool {
+	e, _ := emitter.(transientEmitter)
+	return e != nil && e.TransientEmitter()
+}
+
+// appendBuffer is the type of the buffers that values are appended to.
+type appendBuffer struct {
+	b []byte
+}
+
+// Buffers that grew larger than this size are not put back in the pool, so a
+// few large values don't keep the memory of the program high.
+const maxAppendBufferSize = 4096
+
+var appendBufferPool = sync.Pool{
+	New: func() interface{} { return &appendBuffer{b: make([]byte, 0, 128)} },
+}
+
+// release puts the buffer back in the pool, b is the result of appending to it.
+func (buf *appendBuffer) release(b []byte) {
+	if b != nil {
+		if cap(b) > maxAppendBufferSize {
+			return
+		}
+		buf.b = b[:0]
+	}
+	appendBufferPool.Put(buf)
+}
+
+// encodeTextAppender encodes v as a string. The buffer that the text is
+// appended to is recycled when the emitter is transient, otherwise v appends
+// to a nil slice so the emitter gets memory that it can retain.
+func (e Encoder) encodeTextAppender(v textAppender) (err error) {
+	var b []byte
+
+	if !isTransientEmitter(e.Emitter) {
+		if b, err = v.AppendText(nil); err == nil {
+			err = e.Emitter.EmitString(stringNoCopy(b))
+		}
+		return
+	}
+
+	buf := appendBufferPool.Get().(*appendBuffer)
+
+	if b, err = v.AppendText(buf.b[:0]); err == nil {
+		err = e.Emitter.EmitString(stringNoCopy(b))
+	}
+
+	buf.release(b)
+	return
+}
+
+// encodeBinaryAppender encodes v as bytes, see encodeTextAppender.
+func (e Encoder) encodeBinaryAppender(v binaryAppender) (err error) {
+	var b []byte
+
+	if !isTransientEmitter(e.Emitter) {
+		if b, err = v.AppendBinary(nil); err == nil {
+			err = e.Emitter.EmitBytes(b)
+		}
+		return
+	}
+
+	buf := appendBufferPool.Get().(*appendBuffer)
+
+	if b, err = v.AppendBinary(buf.b[:0]); err == nil {
+		err = e.Emitter.EmitBytes(b)
+	}
+
+	buf.release(b)
+	return
+}
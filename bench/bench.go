@@ -0,0 +1,287 @@
+// Package bench provides a harness to measure the performance of objconv
+// codecs on a corpus of documents.
+//
+// Programs can use it to compare the wire formats on their own data before
+// choosing one, the results usually differ a lot from synthetic benchmarks
+// because they depend on the shape of the values being serialized.
+package bench
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/segmentio/objconv"
+)
+
+// A Document is a value of a corpus.
+type Document struct {
+	Name  string      // name of the document, usually the file it was loaded from
+	Value interface{} // the decoded value of the document
+}
+
+// A Corpus is a list of documents that benchmarks replay through codecs.
+type Corpus []Document
+
+// LoadCorpus loads all documents in dir.
+//
+// The codec used to decode each file is looked up in the global objconv
+// registry from the file extension (the .json extension selects the "json"
+// codec for example), files that have no registered codec are ignored.
+func LoadCorpus(dir string) (Corpus, error) {
+	var corpus Corpus
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		codec, ok := objconv.Lookup(strings.TrimPrefix(filepath.Ext(path), "."))
+		if !ok {
+			return nil
+		}
+
+		doc, err := loadDocument(path, codec)
+		if err != nil {
+			return fmt.Errorf("objconv/bench: loading %s: %s", path, err)
+		}
+
+		corpus = append(corpus, doc)
+		return nil
+	})
+
+	return corpus, err
+}
+
+func loadDocument(path string, codec objconv.Codec) (doc Document, err error) {
+	var f *os.File
+
+	if f, err = os.Open(path); err != nil {
+		return
+	}
+	defer f.Close()
+
+	doc.Name = path
+	err = codec.NewDecoder(f).Decode(&doc.Value)
+	return
+}
+
+// Config carries the configuration of benchmark runs.
+type Config struct {
+	// Duration is the minimum amount of time spent measuring each operation,
+	// defaults to one second.
+	Duration time.Duration
+
+	// When set, the CPU profile of the benchmark is written to CPUProfile.
+	CPUProfile io.Writer
+
+	// When set, the heap profile is written to MemProfile at the end of the
+	// benchmark.
+	MemProfile io.Writer
+}
+
+// Measure carries the measures of an operation repeated over a corpus.
+type Measure struct {
+	N          int           // number of passes over the corpus
+	Duration   time.Duration // total time spent running the operation
+	Bytes      int64         // number of bytes processed per pass
+	Allocs     uint64        // total number of memory allocations
+	AllocBytes uint64        // total number of bytes allocated
+}
+
+// NsPerPass returns the average number of nanoseconds spent on a pass over the
+// corpus.
+func (m Measure) NsPerPass() int64 {
+	if m.N == 0 {
+		return 0
+	}
+	return m.Duration.Nanoseconds() / int64(m.N)
+}
+
+// Throughput returns the number of bytes per second that were processed.
+func (m Measure) Throughput() float64 {
+	if m.Duration <= 0 {
+		return 0
+	}
+	return float64(m.Bytes) * float64(m.N) / m.Duration.Seconds()
+}
+
+// AllocsPerPass returns the average number of memory allocations made during
+// a pass over the corpus.
+func (m Measure) AllocsPerPass() uint64 {
+	if m.N == 0 {
+		return 0
+	}
+	return m.Allocs / uint64(m.N)
+}
+
+// AllocBytesPerPass returns the average number of bytes allocated during a pass
+// over the corpus.
+func (m Measure) AllocBytesPerPass() uint64 {
+	if m.N == 0 {
+		return 0
+	}
+	return m.AllocBytes / uint64(m.N)
+}
+
+// Result is the result of running a codec benchmark on a corpus.
+type Result struct {
+	Name   string  // name of the codec
+	Size   int64   // size of the corpus encoded with the codec
+	Encode Measure // measures of encoding the corpus
+	Decode Measure // measures of decoding the corpus
+}
+
+// Run measures the performance of codec on corpus, name is the name that the
+// codec is reported with.
+func Run(corpus Corpus, name string, codec objconv.Codec, config Config) (res Result, err error) {
+	if config.Duration <= 0 {
+		config.Duration = time.Second
+	}
+
+	if config.CPUProfile != nil {
+		if err = pprof.StartCPUProfile(config.CPUProfile); err != nil {
+			return
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	res.Name = name
+	docs := make([][]byte, len(corpus))
+
+	// The first pass over the corpus validates that the codec supports all
+	// documents and captures their encoded representation for the decoding
+	// benchmark.
+	for i, doc := range corpus {
+		b := &bytes.Buffer{}
+
+		if err = codec.NewEncoder(b).Encode(doc.Value); err != nil {
+			err = fmt.Errorf("objconv/bench: encoding %s with %s: %s", doc.Name, name, err)
+			return
+		}
+
+		docs[i] = b.Bytes()
+		res.Size += int64(b.Len())
+	}
+
+	buf := &bytes.Buffer{}
+	enc := codec.NewEncoder(buf)
+
+	if res.Encode, err = measure(config.Duration, res.Size, func() error {
+		for _, doc := range corpus {
+			buf.Reset()
+
+			if err := enc.Encode(doc.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return
+	}
+
+	rd := &bytes.Reader{}
+	dec := codec.NewDecoder(rd)
+
+	if res.Decode, err = measure(config.Duration, res.Size, func() error {
+		for _, doc := range docs {
+			var v interface{}
+			rd.Reset(doc)
+
+			if r, ok := dec.Parser.(interface{ Reset(io.Reader) }); ok {
+				r.Reset(rd)
+			} else {
+				dec = codec.NewDecoder(rd)
+			}
+
+			if err := dec.Decode(&v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return
+	}
+
+	if config.MemProfile != nil {
+		runtime.GC()
+		err = pprof.WriteHeapProfile(config.MemProfile)
+	}
+
+	return
+}
+
+// Compare runs benchmarks of all codecs on corpus and returns the results
+// sorted by codec name.
+func Compare(corpus Corpus, codecs map[string]objconv.Codec, config Config) ([]Result, error) {
+	names := make([]string, 0, len(codecs))
+
+	for name := range codecs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	results := make([]Result, 0, len(names))
+
+	for _, name := range names {
+		res, err := Run(corpus, name, codecs[name], config)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, res)
+	}
+
+	return results, nil
+}
+
+// WriteReport writes a table representation of results to w.
+func WriteReport(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprint(tw, "codec\tsize\tencode ns/pass\tencode MB/s\tencode allocs/pass\tdecode ns/pass\tdecode MB/s\tdecode allocs/pass\t\n")
+
+	for _, res := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f\t%d\t%d\t%.2f\t%d\t\n",
+			res.Name,
+			res.Size,
+			res.Encode.NsPerPass(),
+			res.Encode.Throughput()/1e6,
+			res.Encode.AllocsPerPass(),
+			res.Decode.NsPerPass(),
+			res.Decode.Throughput()/1e6,
+			res.Decode.AllocsPerPass(),
+		)
+	}
+
+	return tw.Flush()
+}
+
+func measure(d time.Duration, size int64, f func() error) (m Measure, err error) {
+	var m0, m1 runtime.MemStats
+
+	runtime.GC()
+	runtime.ReadMemStats(&m0)
+	start := time.Now()
+
+	for m.N == 0 || m.Duration < d {
+		if err = f(); err != nil {
+			return
+		}
+		m.N++
+		m.Duration = time.Since(start)
+	}
+
+	runtime.ReadMemStats(&m1)
+	m.Bytes = size
+	m.Allocs = m1.Mallocs - m0.Mallocs
+	m.AllocBytes = m1.TotalAlloc - m0.TotalAlloc
+	return
+}
@@ -0,0 +1,91 @@
+package bench
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/json"
+	"github.com/segmentio/objconv/msgpack"
+)
+
+func testCorpus(t *testing.T) Corpus {
+	dir, err := ioutil.TempDir("", "objconv-bench")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"a.json":     `{"id":1,"name":"A","tags":["x","y"]}`,
+		"b.json":     `[1,2,3]`,
+		"ignore.txt": `not a document`,
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	corpus, err := LoadCorpus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(corpus) != 2 {
+		t.Fatal("bad number of documents loaded:", len(corpus))
+	}
+
+	return corpus
+}
+
+func TestRun(t *testing.T) {
+	corpus := testCorpus(t)
+
+	res, err := Run(corpus, "json", json.Codec, Config{Duration: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Size != int64(len(`{"id":1,"name":"A","tags":["x","y"]}`)+len(`[1,2,3]`)) {
+		t.Error("bad corpus size:", res.Size)
+	}
+
+	for _, m := range []Measure{res.Encode, res.Decode} {
+		if m.N == 0 || m.NsPerPass() == 0 || m.Throughput() == 0 {
+			t.Errorf("bad measure: %+v", m)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	corpus := testCorpus(t)
+
+	results, err := Compare(corpus, map[string]objconv.Codec{
+		"msgpack": msgpack.Codec,
+		"json":    json.Codec,
+	}, Config{Duration: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 || results[0].Name != "json" || results[1].Name != "msgpack" {
+		t.Fatalf("bad results: %+v", results)
+	}
+
+	b := &bytes.Buffer{}
+
+	if err := WriteReport(b, results); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := b.String(); strings.Count(s, "\n") != 3 || !strings.Contains(s, "msgpack") {
+		t.Error("bad report:", s)
+	}
+}
@@ -0,0 +1,127 @@
+package bench
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/segmentio/objconv"
+)
+
+// Redacted is the value that replaces redacted fields in captured payloads.
+const Redacted = "REDACTED"
+
+// Capture is an implementation of the objconv.Capturer interface which samples
+// the values it receives and writes them to a corpus directory.
+//
+// Samples are written to files named after their sequence number, with the
+// codec name as extension, so directories produced by a Capture value can be
+// loaded with LoadCorpus.
+//
+// It is safe to use a Capture value concurrently from multiple goroutines.
+type Capture struct {
+	// Dir is the directory where samples are written, it must exist.
+	Dir string
+
+	// Name is the name of the codec in the global objconv registry, it is used
+	// as extension of the sample files.
+	Name string
+
+	// Codec used to write the samples.
+	Codec objconv.Codec
+
+	// Every configures the sampling rate, only one out of Every values is
+	// written to the corpus. Zero means that all values are written.
+	Every int
+
+	// Limit is the maximum number of samples written, zero means no limit.
+	Limit int
+
+	// Redact is the list of map keys and struct field names whose values are
+	// replaced with Redacted in the samples.
+	Redact []string
+
+	// When set, this function is called with the errors that occur while
+	// writing samples.
+	Error func(error)
+
+	count   uint64
+	samples uint64
+}
+
+// Samples returns the number of samples written by c.
+func (c *Capture) Samples() int {
+	return int(atomic.LoadUint64(&c.samples))
+}
+
+// Capture satisfies the objconv.Capturer interface.
+func (c *Capture) Capture(v interface{}) {
+	if n := atomic.AddUint64(&c.count, 1); c.Every > 1 && (n-1)%uint64(c.Every) != 0 {
+		return
+	}
+
+	seq := atomic.AddUint64(&c.samples, 1)
+
+	if c.Limit > 0 && seq > uint64(c.Limit) {
+		atomic.AddUint64(&c.samples, ^uint64(0))
+		return
+	}
+
+	if err := c.write(seq, v); err != nil && c.Error != nil {
+		c.Error(err)
+	}
+}
+
+func (c *Capture) write(seq uint64, v interface{}) error {
+	// Values are converted to their generic representation first, it makes
+	// the redaction independent of the types being encoded.
+	e := objconv.NewValueEmitter()
+
+	if err := objconv.NewEncoder(e).Encode(v); err != nil {
+		return fmt.Errorf("objconv/bench: capturing sample %d: %s", seq, err)
+	}
+
+	b := &bytes.Buffer{}
+
+	if err := c.Codec.NewEncoder(b).Encode(c.redact(e.Value())); err != nil {
+		return fmt.Errorf("objconv/bench: encoding sample %d: %s", seq, err)
+	}
+
+	path := filepath.Join(c.Dir, fmt.Sprintf("%06d.%s", seq, c.Name))
+	return ioutil.WriteFile(path, b.Bytes(), 0644)
+}
+
+func (c *Capture) redact(v interface{}) interface{} {
+	if len(c.Redact) == 0 {
+		return v
+	}
+
+	switch x := v.(type) {
+	case []interface{}:
+		for i, elem := range x {
+			x[i] = c.redact(elem)
+		}
+
+	case map[interface{}]interface{}:
+		for key, val := range x {
+			if s, ok := key.(string); ok && c.redacted(s) {
+				x[key] = Redacted
+			} else {
+				x[key] = c.redact(val)
+			}
+		}
+	}
+
+	return v
+}
+
+func (c *Capture) redacted(key string) bool {
+	for _, name := range c.Redact {
+		if name == key {
+			return true
+		}
+	}
+	return false
+}
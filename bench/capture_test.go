@@ -0,0 +1,99 @@
+package bench
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/objconv/json"
+)
+
+type user struct {
+	ID       int    `objconv:"id"`
+	Password string `objconv:"password"`
+}
+
+func TestCaptureEncoder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "objconv-capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &Capture{
+		Dir:    dir,
+		Name:   "json",
+		Codec:  json.Codec,
+		Every:  2,
+		Limit:  2,
+		Redact: []string{"password"},
+		Error:  func(err error) { t.Error(err) },
+	}
+
+	e := json.NewEncoder(ioutil.Discard)
+	e.Capturer = c
+
+	for i := 0; i != 10; i++ {
+		if err := e.Encode(user{ID: i, Password: "secret"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := c.Samples(); n != 2 {
+		t.Error("bad number of samples:", n)
+	}
+
+	corpus, err := LoadCorpus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := []interface{}{}
+	for _, doc := range corpus {
+		values = append(values, doc.Value)
+	}
+
+	expect := []interface{}{
+		map[interface{}]interface{}{"id": int64(0), "password": Redacted},
+		map[interface{}]interface{}{"id": int64(2), "password": Redacted},
+	}
+
+	if !reflect.DeepEqual(values, expect) {
+		t.Errorf("%#v != %#v", expect, values)
+	}
+}
+
+func TestCaptureDecoder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "objconv-capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &Capture{
+		Dir:   dir,
+		Name:  "json",
+		Codec: json.Codec,
+		Error: func(err error) { t.Error(err) },
+	}
+
+	d := json.NewStreamDecoder(strings.NewReader(`[{"id":1,"password":"A"},{"id":2,"password":"B"}]`))
+	d.Capturer = c
+
+	for {
+		var u user
+		if d.Decode(&u) != nil {
+			break
+		}
+	}
+
+	if err := d.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := c.Samples(); n != 2 {
+		t.Error("bad number of samples:", n)
+	}
+}
@@ -0,0 +1,256 @@
+// Package binarylayout implements encoding and decoding of structs as packed
+// binary records with a fixed layout, like the headers of file formats or the
+// frames of device protocols.
+//
+// Fields are written in the order of the struct declaration, without any
+// implicit padding, numbers take the size of their Go type. The layout is
+// refined with `binary` struct tags:
+//
+//	type Header struct {
+//		Magic   [4]byte
+//		Version uint16   `binary:"le"`
+//		Flags   uint8
+//		_       [1]byte
+//		Length  uint32   `binary:"align=4"`
+//		Name    string   `binary:"size=16"`
+//		Payload []byte   `binary:"prefix=2"`
+//	}
+//
+// The tag options are:
+//
+//	be, le      the byte order of the field, overriding the byte order of the
+//	            encoder or decoder, which is big-endian by default
+//	size=N      the number of bytes of strings and byte slices, values are
+//	            padded with zeros and strings have trailing zeros removed when
+//	            decoded
+//	prefix=N    the strings, byte slices and slices are variable-length, and
+//	            are preceded by their length encoded on N bytes (1, 2, 4 or 8)
+//	align=N     zeros are inserted before the field so its offset from the
+//	            beginning of the record is a multiple of N
+//
+// Fields of struct and array types are laid out inline, array elements use the
+// options of the field they belong to. Fields named "_" are fillers, they are
+// written as zeros and skipped when decoding, their size is the one of their
+// type or given by the size option.
+//
+// Fields tagged with `binary:"-"` and unexported fields are not part of the
+// records. Types of platform-dependent sizes like int or uintptr cannot be
+// used, neither can maps, pointers or interfaces.
+package binarylayout
+
+import (
+	"encoding/binary"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+type field struct {
+	name   string
+	index  int
+	typ    reflect.Type
+	filler bool
+	order  binary.ByteOrder // nil to use the byte order of the encoder or decoder
+	size   int              // 0 when not set
+	prefix int              // 0 when not set
+	align  int              // 0 when not set
+	layout *layout          // set when the field is a struct or array of structs
+}
+
+type layout struct {
+	fields []field
+}
+
+var layouts sync.Map // reflect.Type => *layout
+
+// layoutOf returns the layout of records of type t, which must be a struct
+// type.
+func layoutOf(t reflect.Type) (*layout, error) {
+	if l, ok := layouts.Load(t); ok {
+		return l.(*layout), nil
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, objutil.Errorf(objutil.ErrType, "objconv/binarylayout: records must be structs, found %s", t)
+	}
+
+	l := &layout{}
+
+	for i, n := 0, t.NumField(); i != n; i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("binary")
+
+		if tag == "-" || (len(sf.PkgPath) != 0 && sf.Name != "_") {
+			continue
+		}
+
+		f, err := parseField(sf, tag)
+		if err != nil {
+			return nil, objutil.Errorf(objutil.ErrType, "objconv/binarylayout: %s.%s: %s", t, sf.Name, err)
+		}
+
+		if !f.filler {
+			if f.layout, err = checkType(&f, f.typ); err != nil {
+				return nil, err
+			}
+		}
+
+		f.index = i
+		l.fields = append(l.fields, f)
+	}
+
+	v, _ := layouts.LoadOrStore(t, l)
+	return v.(*layout), nil
+}
+
+type tagError string
+
+func (e tagError) Error() string { return string(e) }
+
+func parseField(sf reflect.StructField, tag string) (f field, err error) {
+	f = field{
+		name:   sf.Name,
+		typ:    sf.Type,
+		filler: sf.Name == "_",
+	}
+
+	for len(tag) != 0 {
+		var opt string
+
+		if opt, tag, _ = strings.Cut(tag, ","); len(opt) == 0 {
+			continue
+		}
+
+		key, val, _ := strings.Cut(opt, "=")
+
+		switch key {
+		case "be":
+			f.order = binary.BigEndian
+
+		case "le":
+			f.order = binary.LittleEndian
+
+		case "size":
+			if f.size, err = strconv.Atoi(val); err != nil || f.size <= 0 {
+				return f, tagError("invalid size: " + strconv.Quote(val))
+			}
+
+		case "prefix":
+			switch f.prefix, _ = strconv.Atoi(val); f.prefix {
+			case 1, 2, 4, 8:
+			default:
+				return f, tagError("the prefix must be 1, 2, 4 or 8 bytes: " + strconv.Quote(val))
+			}
+
+		case "align":
+			if f.align, err = strconv.Atoi(val); err != nil || f.align <= 0 {
+				return f, tagError("invalid alignment: " + strconv.Quote(val))
+			}
+
+		default:
+			return f, tagError("unknown tag option: " + strconv.Quote(opt))
+		}
+	}
+
+	if f.size != 0 && f.prefix != 0 {
+		return f, tagError("the size and prefix options cannot be combined")
+	}
+
+	if f.filler && f.size == 0 {
+		var ok bool
+
+		if f.size, ok = fixedSize(f.typ); !ok {
+			return f, tagError("fillers must have a fixed size or a size option")
+		}
+	}
+
+	return f, nil
+}
+
+// checkType verifies that values of type t can be held by the field f, and
+// returns the layout of t when it is a struct (or an array of structs).
+func checkType(f *field, t reflect.Type) (*layout, error) {
+	typeError := func(msg string) error {
+		return objutil.Errorf(objutil.ErrType, "objconv/binarylayout: %s field: %s", f.name, msg)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return nil, nil
+
+	case reflect.String:
+		if f.size == 0 && f.prefix == 0 {
+			return nil, typeError("strings require a size or prefix option")
+		}
+		return nil, nil
+
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if f.size == 0 && f.prefix == 0 {
+				return nil, typeError("byte slices require a size or prefix option")
+			}
+			return nil, nil
+		}
+		if f.prefix == 0 {
+			return nil, typeError("slices require a prefix option")
+		}
+		e := *f
+		e.prefix = 0
+		return checkType(&e, t.Elem())
+
+	case reflect.Array:
+		return checkType(f, t.Elem())
+
+	case reflect.Struct:
+		return layoutOf(t)
+	}
+
+	return nil, typeError("values of type " + t.String() + " have no fixed binary representation")
+}
+
+// fixedSize returns the number of bytes of values of type t, if it does not
+// depend on options.
+func fixedSize(t reflect.Type) (int, bool) {
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return int(t.Size()), true
+
+	case reflect.Array:
+		n, ok := fixedSize(t.Elem())
+		return n * t.Len(), ok
+	}
+
+	return 0, false
+}
+
+// padding returns the number of zeros to insert at offset off for the field f
+// to be aligned.
+func (f *field) padding(off int) int {
+	if f.align == 0 {
+		return 0
+	}
+	return (f.align - off%f.align) % f.align
+}
+
+func (f *field) byteOrder(order binary.ByteOrder) binary.ByteOrder {
+	if f.order != nil {
+		return f.order
+	}
+	return order
+}
+
+func maxLength(prefix int) uint64 {
+	if prefix == 8 {
+		return ^uint64(0)
+	}
+	return 1<<(8*uint(prefix)) - 1
+}
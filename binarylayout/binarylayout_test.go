@@ -0,0 +1,161 @@
+package binarylayout
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+type point struct {
+	X int16
+	Y int16
+}
+
+type header struct {
+	Magic   [4]byte
+	Version uint16 `binary:"le"`
+	Flags   uint8
+	_       [2]byte
+	Length  uint32  `binary:"align=8"`
+	Name    string  `binary:"size=6"`
+	Ratio   float32 `binary:"le"`
+	Valid   bool
+	Origin  point
+	Path    []point       `binary:"prefix=1"`
+	Payload []byte        `binary:"prefix=2"`
+	Timeout time.Duration `binary:"le"`
+	Ignored string        `binary:"-"`
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	h1 := header{
+		Magic:   [4]byte{'O', 'B', 'J', 'C'},
+		Version: 2,
+		Flags:   0x81,
+		Length:  513,
+		Name:    "hello",
+		Ratio:   1.5,
+		Valid:   true,
+		Origin:  point{X: -1, Y: 2},
+		Path:    []point{{X: 3, Y: 4}},
+		Payload: []byte("ab"),
+		Timeout: time.Second,
+	}
+
+	b, err := Marshal(h1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		'O', 'B', 'J', 'C', // magic
+		2, 0, // version (little-endian)
+		0x81, // flags
+		0, 0, // filler
+		0, 0, 0, 0, 0, 0, 0, // padding
+		0, 0, 2, 1, // length (aligned on 8 bytes)
+		'h', 'e', 'l', 'l', 'o', 0, // name
+		0, 0, 0xc0, 0x3f, // ratio (little-endian)
+		1,                // valid
+		0xff, 0xff, 0, 2, // origin
+		1, 0, 3, 0, 4, // path
+		0, 2, 'a', 'b', // payload
+		0, 0xca, 0x9a, 0x3b, 0, 0, 0, 0, // timeout (little-endian)
+	}
+
+	if !reflect.DeepEqual(b, expected) {
+		t.Errorf("bad record:\n%v\n%v", b, expected)
+	}
+
+	var h2 header
+	if err := Unmarshal(b, &h2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(h1, h2) {
+		t.Errorf("%#v != %#v", h1, h2)
+	}
+}
+
+func TestByteOrder(t *testing.T) {
+	type record struct {
+		A uint32
+		B uint32 `binary:"be"`
+	}
+
+	b := &strings.Builder{}
+	e := NewEncoder(b)
+	e.ByteOrder = binary.LittleEndian
+
+	if err := e.Encode([]record{{A: 1, B: 1}, {A: 2, B: 2}}); err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = "\x01\x00\x00\x00\x00\x00\x00\x01\x02\x00\x00\x00\x00\x00\x00\x02"
+
+	if s := b.String(); s != expected {
+		t.Errorf("bad records: %q", s)
+	}
+
+	d := NewDecoder(strings.NewReader(expected))
+	d.ByteOrder = binary.LittleEndian
+
+	var records []record
+	if err := d.Decode(&records); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(records, []record{{A: 1, B: 1}, {A: 2, B: 2}}) {
+		t.Errorf("bad records: %v", records)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	type name struct {
+		Name string `binary:"size=2"`
+	}
+
+	type blob struct {
+		Data []byte `binary:"prefix=4"`
+	}
+
+	if _, err := Marshal(name{Name: "abc"}); !errors.Is(err, objutil.ErrRange) {
+		t.Errorf("bad error: %v", err)
+	}
+
+	if err := Unmarshal([]byte{0, 0, 0, 4, 1}, &blob{}); !errors.Is(err, objutil.ErrSyntax) || !strings.Contains(err.Error(), "Data field") {
+		t.Errorf("bad error: %v", err)
+	}
+
+	d := NewDecoder(strings.NewReader("\x00\x00\x01\x00"))
+	d.MaxLength = 16
+
+	if err := d.Decode(&blob{}); !errors.Is(err, objutil.ErrLimit) {
+		t.Errorf("bad error: %v", err)
+	}
+
+	invalid := []interface{}{
+		struct{ N int }{},
+		struct{ S string }{},
+		struct {
+			S []string `binary:"prefix=1"`
+		}{},
+		struct {
+			S string `binary:"size=1,prefix=1"`
+		}{},
+		struct {
+			N uint8 `binary:"prefix=3"`
+		}{},
+	}
+
+	for _, v := range invalid {
+		if _, err := Marshal(v); !errors.Is(err, objutil.ErrType) {
+			t.Errorf("%T: bad error: %v", v, err)
+		}
+	}
+}
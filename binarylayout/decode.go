@@ -0,0 +1,277 @@
+package binarylayout
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"reflect"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Decoder reads binary records from an input stream.
+type Decoder struct {
+	// ByteOrder is the byte order of numbers which have no be or le tag
+	// option, big-endian when nil.
+	ByteOrder binary.ByteOrder
+
+	// MaxLength limits the lengths read from the prefixes of variable-length
+	// values, which protects programs from allocating large amounts of memory
+	// on malformed input. Zero means no limit.
+	MaxLength int
+
+	r     *bufio.Reader
+	b     []byte
+	off   int // offset in the current record
+	total int // offset in the input
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next record from the input stream into v, which must be a
+// pointer to a struct. When v is a pointer to a slice of structs, all the
+// remaining records of the stream are appended to the slice.
+//
+// The method returns io.EOF when the end of the input is reached, records
+// truncated by the end of the input are syntax errors.
+func (d *Decoder) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return objutil.Errorf(objutil.ErrType, "objconv/binarylayout: cannot decode into a value of type %T", v)
+	}
+
+	if rv = rv.Elem(); rv.Kind() != reflect.Slice {
+		return d.decode(rv)
+	}
+
+	t := rv.Type().Elem()
+
+	for {
+		e := reflect.New(t).Elem()
+
+		switch err := d.decode(e); err {
+		case nil:
+			rv.Set(reflect.Append(rv, e))
+		case io.EOF:
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+func (d *Decoder) decode(v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	l, err := layoutOf(v.Type())
+	if err != nil {
+		return err
+	}
+
+	if _, err := d.r.Peek(1); err != nil {
+		return err
+	}
+
+	order := d.ByteOrder
+
+	if order == nil {
+		order = binary.BigEndian
+	}
+
+	d.off = 0
+	return d.decodeStruct(order, l, v)
+}
+
+// Unmarshal decodes the binary records of b into v, which is a pointer to a
+// struct or to a slice of structs.
+func Unmarshal(b []byte, v interface{}) error {
+	return NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+func (d *Decoder) decodeStruct(order binary.ByteOrder, l *layout, v reflect.Value) error {
+	for i := range l.fields {
+		f := &l.fields[i]
+
+		if _, err := d.read(f, f.padding(d.off)); err != nil {
+			return err
+		}
+
+		if f.filler {
+			if _, err := d.read(f, f.size); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := d.decodeValue(f.byteOrder(order), f, v.Field(f.index)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Decoder) decodeValue(order binary.ByteOrder, f *field, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := d.read(f, 1)
+		if err == nil {
+			v.SetBool(b[0] != 0)
+		}
+		return err
+
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size := int(v.Type().Size())
+		u, err := d.readUint(order, f, size)
+		if err == nil {
+			// Sign-extends the value from its original size.
+			shift := 64 - 8*uint(size)
+			v.SetInt(int64(u<<shift) >> shift)
+		}
+		return err
+
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := d.readUint(order, f, int(v.Type().Size()))
+		if err == nil {
+			v.SetUint(u)
+		}
+		return err
+
+	case reflect.Float32:
+		u, err := d.readUint(order, f, 4)
+		if err == nil {
+			v.SetFloat(float64(math.Float32frombits(uint32(u))))
+		}
+		return err
+
+	case reflect.Float64:
+		u, err := d.readUint(order, f, 8)
+		if err == nil {
+			v.SetFloat(math.Float64frombits(u))
+		}
+		return err
+
+	case reflect.String:
+		b, err := d.readBytes(order, f)
+		if err == nil {
+			if f.size != 0 {
+				b = bytes.TrimRight(b, "\x00")
+			}
+			v.SetString(string(b))
+		}
+		return err
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, err := d.readBytes(order, f)
+			if err == nil {
+				v.SetBytes(append([]byte{}, b...))
+			}
+			return err
+		}
+
+		n, err := d.readLength(order, f)
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+		e := *f
+		e.prefix = 0
+		return d.decodeArray(order, &e, v)
+
+	case reflect.Array:
+		return d.decodeArray(order, f, v)
+
+	case reflect.Struct:
+		return d.decodeStruct(order, f.layout, v)
+	}
+
+	return objutil.Errorf(objutil.ErrType, "objconv/binarylayout: %s field: cannot decode values of type %s", f.name, v.Type())
+}
+
+func (d *Decoder) decodeArray(order binary.ByteOrder, f *field, v reflect.Value) error {
+	for i, n := 0, v.Len(); i != n; i++ {
+		if err := d.decodeValue(order, f, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Decoder) readBytes(order binary.ByteOrder, f *field) ([]byte, error) {
+	if f.size != 0 {
+		return d.read(f, f.size)
+	}
+
+	n, err := d.readLength(order, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.read(f, n)
+}
+
+func (d *Decoder) readLength(order binary.ByteOrder, f *field) (int, error) {
+	off := d.total
+	u, err := d.readUint(order, f, f.prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	if u > math.MaxInt32 || (d.MaxLength != 0 && u > uint64(d.MaxLength)) {
+		return 0, objutil.Errorf(objutil.ErrLimit, "objconv/binarylayout: offset %d: %s field: the length %d exceeds the limit", off, f.name, u)
+	}
+
+	return int(u), nil
+}
+
+func (d *Decoder) readUint(order binary.ByteOrder, f *field, size int) (uint64, error) {
+	b, err := d.read(f, size)
+	if err != nil {
+		return 0, err
+	}
+
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(order.Uint16(b)), nil
+	case 4:
+		return uint64(order.Uint32(b)), nil
+	default:
+		return order.Uint64(b), nil
+	}
+}
+
+// read returns the next n bytes of the input, the slice is only valid until
+// the next call.
+func (d *Decoder) read(f *field, n int) ([]byte, error) {
+	if cap(d.b) < n {
+		d.b = make([]byte, n)
+	}
+
+	b := d.b[:n]
+
+	if _, err := io.ReadFull(d.r, b); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/binarylayout: offset %d: %s field: unexpected end of input", d.total, f.name)
+		}
+		return nil, err
+	}
+
+	d.off += n
+	d.total += n
+	return b, nil
+}
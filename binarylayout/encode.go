@@ -0,0 +1,204 @@
+package binarylayout
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"reflect"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Encoder writes binary records to an output stream.
+type Encoder struct {
+	// ByteOrder is the byte order of numbers which have no be or le tag
+	// option, big-endian when nil.
+	ByteOrder binary.ByteOrder
+
+	w io.Writer
+	b []byte
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes v to the output stream of the encoder. The value is either a
+// struct, written as a single record, or a slice of structs written as a
+// sequence of records.
+func (e *Encoder) Encode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() == reflect.Slice {
+		for i, n := 0, rv.Len(); i != n; i++ {
+			if err := e.encode(reflect.Indirect(rv.Index(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return e.encode(rv)
+}
+
+func (e *Encoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		return objutil.Errorf(objutil.ErrType, "objconv/binarylayout: cannot encode a nil record")
+	}
+
+	l, err := layoutOf(v.Type())
+	if err != nil {
+		return err
+	}
+
+	order := e.ByteOrder
+
+	if order == nil {
+		order = binary.BigEndian
+	}
+
+	if e.b, err = encodeStruct(e.b[:0], 0, order, l, v); err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(e.b)
+	return err
+}
+
+// Marshal returns the binary representation of v, which is either a struct or
+// a slice of structs.
+func Marshal(v interface{}) (b []byte, err error) {
+	buf := &bytes.Buffer{}
+
+	if err = NewEncoder(buf).Encode(v); err == nil {
+		b = buf.Bytes()
+	}
+
+	return
+}
+
+// encodeStruct appends the fields of the struct v to b, start is the offset of
+// the beginning of the record in b.
+func encodeStruct(b []byte, start int, order binary.ByteOrder, l *layout, v reflect.Value) ([]byte, error) {
+	var err error
+
+	for i := range l.fields {
+		f := &l.fields[i]
+		b = appendZeros(b, f.padding(len(b)-start))
+
+		if f.filler {
+			b = appendZeros(b, f.size)
+			continue
+		}
+
+		if b, err = encodeValue(b, start, f.byteOrder(order), f, v.Field(f.index)); err != nil {
+			return b, err
+		}
+	}
+
+	return b, nil
+}
+
+func encodeValue(b []byte, start int, order binary.ByteOrder, f *field, v reflect.Value) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendUint(b, order, int(v.Type().Size()), uint64(v.Int())), nil
+
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return appendUint(b, order, int(v.Type().Size()), v.Uint()), nil
+
+	case reflect.Float32:
+		return appendUint(b, order, 4, uint64(math.Float32bits(float32(v.Float())))), nil
+
+	case reflect.Float64:
+		return appendUint(b, order, 8, math.Float64bits(v.Float())), nil
+
+	case reflect.String:
+		return encodeBytes(b, order, f, v.String())
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return encodeBytes(b, order, f, string(v.Bytes()))
+		}
+
+		n := v.Len()
+
+		if uint64(n) > maxLength(f.prefix) {
+			return b, objutil.Errorf(objutil.ErrRange, "objconv/binarylayout: %s field: %d elements do not fit in a prefix of %d bytes", f.name, n, f.prefix)
+		}
+
+		b = appendUint(b, order, f.prefix, uint64(n))
+		e := *f
+		e.prefix = 0
+		return encodeArray(b, start, order, &e, v)
+
+	case reflect.Array:
+		return encodeArray(b, start, order, f, v)
+
+	case reflect.Struct:
+		return encodeStruct(b, start, order, f.layout, v)
+	}
+
+	return b, objutil.Errorf(objutil.ErrType, "objconv/binarylayout: %s field: cannot encode values of type %s", f.name, v.Type())
+}
+
+func encodeArray(b []byte, start int, order binary.ByteOrder, f *field, v reflect.Value) ([]byte, error) {
+	var err error
+
+	for i, n := 0, v.Len(); i != n && err == nil; i++ {
+		b, err = encodeValue(b, start, order, f, v.Index(i))
+	}
+
+	return b, err
+}
+
+func encodeBytes(b []byte, order binary.ByteOrder, f *field, s string) ([]byte, error) {
+	if f.size != 0 {
+		if len(s) > f.size {
+			return b, objutil.Errorf(objutil.ErrRange, "objconv/binarylayout: %s field: %d bytes do not fit in a field of %d bytes", f.name, len(s), f.size)
+		}
+		return appendZeros(append(b, s...), f.size-len(s)), nil
+	}
+
+	if uint64(len(s)) > maxLength(f.prefix) {
+		return b, objutil.Errorf(objutil.ErrRange, "objconv/binarylayout: %s field: %d bytes do not fit in a prefix of %d bytes", f.name, len(s), f.prefix)
+	}
+
+	return append(appendUint(b, order, f.prefix, uint64(len(s))), s...), nil
+}
+
+func appendUint(b []byte, order binary.ByteOrder, size int, v uint64) []byte {
+	var a [8]byte
+
+	switch size {
+	case 1:
+		a[0] = byte(v)
+	case 2:
+		order.PutUint16(a[:], uint16(v))
+	case 4:
+		order.PutUint32(a[:], uint32(v))
+	case 8:
+		order.PutUint64(a[:], v)
+	}
+
+	return append(b, a[:size]...)
+}
+
+func appendZeros(b []byte, n int) []byte {
+	for i := 0; i != n; i++ {
+		b = append(b, 0)
+	}
+	return b
+}
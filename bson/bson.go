@@ -0,0 +1,59 @@
+package bson
+
+import "encoding/binary"
+
+const ( // element types
+	typeDouble        byte = 0x01
+	typeString        byte = 0x02
+	typeDocument      byte = 0x03
+	typeArray         byte = 0x04
+	typeBinary        byte = 0x05
+	typeUndefined     byte = 0x06
+	typeObjectID      byte = 0x07
+	typeBool          byte = 0x08
+	typeDateTime      byte = 0x09
+	typeNull          byte = 0x0A
+	typeRegex         byte = 0x0B
+	typeDBPointer     byte = 0x0C
+	typeJavaScript    byte = 0x0D
+	typeSymbol        byte = 0x0E
+	typeCodeWithScope byte = 0x0F
+	typeInt32         byte = 0x10
+	typeTimestamp     byte = 0x11
+	typeInt64         byte = 0x12
+	typeDecimal128    byte = 0x13
+	typeMinKey        byte = 0xFF
+	typeMaxKey        byte = 0x7F
+)
+
+const ( // binary subtypes
+	binaryGeneric byte = 0x00
+	binaryOld     byte = 0x02
+)
+
+// MaxDocumentSize is the largest document that parsers accept, it is also the
+// limit enforced by MongoDB servers.
+const MaxDocumentSize = 16 * 1024 * 1024
+
+// The smallest document is made of its length and the terminating zero byte.
+const minDocumentSize = 5
+
+func putInt32(b []byte, v int32) {
+	binary.LittleEndian.PutUint32(b, uint32(v))
+}
+
+func getInt32(b []byte) int32 {
+	return int32(binary.LittleEndian.Uint32(b))
+}
+
+func appendInt32(b []byte, v int32) []byte {
+	return binary.LittleEndian.AppendUint32(b, uint32(v))
+}
+
+func appendInt64(b []byte, v int64) []byte {
+	return binary.LittleEndian.AppendUint64(b, uint64(v))
+}
+
+func getInt64(b []byte) int64 {
+	return int64(binary.LittleEndian.Uint64(b))
+}
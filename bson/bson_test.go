@@ -0,0 +1,282 @@
+package bson
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objtests"
+	"github.com/segmentio/objconv/objutil"
+)
+
+func TestRawMessage(t *testing.T) {
+	objtests.TestRawMessage(t, Codec)
+}
+
+// The examples of the BSON specification.
+var bsonTests = []struct {
+	v interface{}
+	s string
+}{
+	{
+		v: map[string]string{"hello": "world"},
+		s: "\x16\x00\x00\x00\x02hello\x00\x06\x00\x00\x00world\x00\x00",
+	},
+	{
+		v: map[string]interface{}{"BSON": []interface{}{"awesome", 5.05, int64(1986)}},
+		s: "\x31\x00\x00\x00\x04BSON\x00\x26\x00\x00\x00\x020\x00\x08\x00\x00\x00awesome\x00\x011\x00\x33\x33\x33\x33\x33\x33\x14\x40\x102\x00\xc2\x07\x00\x00\x00\x00",
+	},
+}
+
+func TestMarshal(t *testing.T) {
+	for _, test := range bsonTests {
+		b, err := Marshal(test.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.s {
+			t.Errorf("bad output:\n%q\n%q", test.s, b)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	for _, test := range bsonTests {
+		v := reflect.New(reflect.TypeOf(test.v))
+
+		if err := Unmarshal([]byte(test.s), v.Interface()); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(v.Elem().Interface(), test.v) {
+			t.Errorf("bad value: %#v", v.Elem().Interface())
+		}
+	}
+}
+
+type document struct {
+	ID       ObjectID      `objconv:"_id"`
+	Name     string        `objconv:"name"`
+	Price    Decimal128    `objconv:"price"`
+	Count    int           `objconv:"count"`
+	Total    int64         `objconv:"total"`
+	Ratio    float64       `objconv:"ratio"`
+	Enabled  bool          `objconv:"enabled"`
+	Created  time.Time     `objconv:"created"`
+	Timeout  time.Duration `objconv:"timeout"`
+	Data     []byte        `objconv:"data"`
+	Tags     []string      `objconv:"tags"`
+	Labels   map[string]int
+	Parent   *document `objconv:"parent"`
+	Optional *string   `objconv:"optional"`
+}
+
+func TestCodecDocument(t *testing.T) {
+	price, _ := ParseDecimal128("19.99")
+
+	d1 := document{
+		ID:      newObjectID(time.Unix(1500000000, 0), 42),
+		Name:    "Hello World!",
+		Price:   price,
+		Count:   -1,
+		Total:   objutil.Int64Max,
+		Ratio:   0.5,
+		Enabled: true,
+		Created: time.Date(2017, 7, 14, 2, 40, 0, 123000000, time.UTC),
+		Timeout: 5 * time.Second,
+		Data:    []byte{0, 1, 2},
+		Tags:    []string{"A", "B", "C"},
+		Labels:  map[string]int{"answer": 42},
+		Parent:  &document{Name: "parent", Tags: []string{}, Labels: map[string]int{}, Data: []byte{}},
+	}
+
+	b, err := Marshal(d1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var d2 document
+
+	if err := Unmarshal(b, &d2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(d1, d2) {
+		t.Errorf("bad document:\n%#v\n%#v", d1, d2)
+	}
+
+	var v map[string]interface{}
+
+	if err := Unmarshal(b, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if id := v["_id"]; id != d1.ID.Hex() {
+		t.Errorf("object ids must be decoded as strings into empty interfaces: %#v", id)
+	}
+
+	if p := v["price"]; p != "19.99" {
+		t.Errorf("decimals must be decoded as strings into empty interfaces: %#v", p)
+	}
+}
+
+func TestObjectID(t *testing.T) {
+	id := newObjectID(time.Unix(1500000000, 0), 0x010203)
+
+	if s := id.Hex(); len(s) != 24 || s[:8] != "59682f00" || s[18:] != "010203" {
+		t.Error("bad object id:", s)
+	}
+
+	if ts := id.Timestamp(); !ts.Equal(time.Unix(1500000000, 0)) {
+		t.Error("bad timestamp:", ts)
+	}
+
+	x, err := ObjectIDFromHex(id.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if x != id {
+		t.Error("bad object id parsed from its hexadecimal representation:", x)
+	}
+
+	if _, err := ObjectIDFromHex("1234"); !errors.Is(err, objconv.ErrSyntax) {
+		t.Error("bad error:", err)
+	}
+
+	if NewObjectID() == NewObjectID() {
+		t.Error("object ids must be unique")
+	}
+}
+
+func TestDecimal128(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"0", "0"},
+		{"-0", "-0"},
+		{"1", "1"},
+		{"-1.25", "-1.25"},
+		{"0.001", "0.001"},
+		{"0.0000001", "1E-7"},
+		{"12E-3", "0.012"},
+		{"1E3", "1E+3"},
+		{"1.5E+10", "1.5E+10"},
+		{"123456789012345678901234567890.1234", "123456789012345678901234567890.1234"},
+		{"Infinity", "Infinity"},
+		{"-Inf", "-Infinity"},
+		{"NaN", "NaN"},
+	}
+
+	for _, test := range tests {
+		d, err := ParseDecimal128(test.in)
+		if err != nil {
+			t.Errorf("%s: %s", test.in, err)
+			continue
+		}
+		if s := d.String(); s != test.out {
+			t.Errorf("%s: bad decimal: %s", test.in, s)
+		}
+	}
+
+	for _, s := range []string{"", "-", "1.2.3", "1E", "abc"} {
+		if _, err := ParseDecimal128(s); !errors.Is(err, objconv.ErrSyntax) {
+			t.Errorf("%q: bad error: %v", s, err)
+		}
+	}
+
+	for _, s := range []string{"12345678901234567890123456789012345", "1E6112", "1E-6177"} {
+		if _, err := ParseDecimal128(s); !errors.Is(err, objconv.ErrRange) {
+			t.Errorf("%q: bad error: %v", s, err)
+		}
+	}
+
+	// The value of the examples in the specification of the format.
+	if d := NewDecimal128(0x3040000000000000, 1); d.String() != "1" {
+		t.Error("bad decimal:", d)
+	}
+}
+
+func TestStream(t *testing.T) {
+	type record struct{ N int }
+
+	b := &bytes.Buffer{}
+	e := NewStreamEncoder(b)
+
+	for i := 0; i != 3; i++ {
+		if err := e.Encode(record{i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewStreamDecoder(b)
+	n := 0
+
+	for {
+		var r record
+		if d.Decode(&r) != nil {
+			break
+		}
+		if r.N != n {
+			t.Errorf("bad record at index %d: %d", n, r.N)
+		}
+		n++
+	}
+
+	if err := d.Err(); err != nil {
+		t.Error(err)
+	}
+
+	if n != 3 {
+		t.Error("bad number of records:", n)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		kind error
+	}{
+		{42, objconv.ErrType},
+		{"Hello World!", objconv.ErrType},
+		{map[bool]int{true: 1}, objconv.ErrType},
+		{map[string]string{"\x00": ""}, objconv.ErrSyntax},
+		{map[string]uint64{"A": objutil.Uint64Max}, objconv.ErrRange},
+	}
+
+	for _, test := range tests {
+		if _, err := Marshal(test.v); !errors.Is(err, test.kind) {
+			t.Errorf("%#v: bad error: %v", test.v, err)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		s    string
+		kind error
+	}{
+		{"\x05\x00\x00", objconv.ErrSyntax},
+		{"\x04\x00\x00\x00", objconv.ErrSyntax},
+		{"\x05\x00\x00\x00\x01", objconv.ErrSyntax},
+		{"\x0c\x00\x00\x00\x02A\x00\x09\x00\x00\x00\x00", objconv.ErrSyntax},
+		{"\x08\x00\x00\x00\x20A\x00\x00", objconv.ErrSyntax},
+		{"\xff\xff\xff\x7f", objconv.ErrLimit},
+	}
+
+	for _, test := range tests {
+		var v interface{}
+
+		if err := Unmarshal([]byte(test.s), &v); !errors.Is(err, test.kind) {
+			t.Errorf("%q: bad error: %v", test.s, err)
+		}
+	}
+}
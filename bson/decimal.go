@@ -0,0 +1,273 @@
+package bson
+
+import (
+	"math/bits"
+	"strconv"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Decimal128 is a 128 bits decimal floating point number in the IEEE 754-2008
+// format with binary integer significands, which MongoDB uses to represent
+// decimal values exactly.
+//
+// Like object identifiers, decimals are encoded as BSON Decimal128 values by
+// BSON emitters and as their string representation by other emitters, and
+// BSON Decimal128 values are decoded as strings when their destination is not
+// a Decimal128.
+type Decimal128 struct {
+	h, l uint64
+}
+
+const (
+	decimalExponentBias = 6176
+	decimalExponentMin  = -6176
+	decimalExponentMax  = 6111
+	decimalMaxDigits    = 34
+
+	decimalSignBit = uint64(1) << 63
+	decimalInf     = uint64(0x1E) << 58
+	decimalNaN     = uint64(0x1F) << 58
+)
+
+// NewDecimal128 returns the decimal made of the given high and low 64 bits.
+func NewDecimal128(h, l uint64) Decimal128 {
+	return Decimal128{h: h, l: l}
+}
+
+// Bits returns the high and low 64 bits of d.
+func (d Decimal128) Bits() (h, l uint64) {
+	return d.h, d.l
+}
+
+// IsNaN returns true if d is not a number.
+func (d Decimal128) IsNaN() bool {
+	return (d.h>>58)&0x1F == 0x1F
+}
+
+// IsInf returns true if d is an infinity, either positive or negative.
+func (d Decimal128) IsInf() bool {
+	return (d.h>>58)&0x1F == 0x1E
+}
+
+// ParseDecimal128 parses the string representation of a decimal, which
+// follows the syntax of floating point numbers (for example "-1.25" or
+// "12E-3"), or is one of "Infinity", "-Infinity" and "NaN".
+//
+// Numbers that cannot be represented exactly, because they have more than 34
+// significant digits or an exponent out of range, are range errors.
+func ParseDecimal128(s string) (d Decimal128, err error) {
+	var neg bool
+	var h, l uint64
+	var digits, exp, i int
+	var point, seen bool
+
+	in := s
+
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		neg = s[i] == '-'
+		i++
+	}
+
+	switch s[i:] {
+	case "Inf", "inf", "Infinity", "infinity":
+		d.h = decimalInf
+		if neg {
+			d.h |= decimalSignBit
+		}
+		return
+	case "NaN", "nan":
+		d.h = decimalNaN
+		return
+	}
+
+	for ; i < len(s); i++ {
+		c := s[i]
+
+		if c == '.' && !point {
+			point = true
+			continue
+		}
+
+		if c < '0' || c > '9' {
+			break
+		}
+
+		seen = true
+
+		if point {
+			exp--
+		}
+
+		if digits == 0 && c == '0' {
+			continue // leading zeros are not significant
+		}
+
+		if digits++; digits > decimalMaxDigits {
+			return d, objutil.Errorf(objutil.ErrRange, "objconv/bson: %q has more than %d significant digits", in, decimalMaxDigits)
+		}
+
+		h, l = mul10(h, l)
+		h, l = add128(h, l, uint64(c-'0'))
+	}
+
+	if !seen {
+		return d, objutil.Errorf(objutil.ErrSyntax, "objconv/bson: invalid decimal: %q", in)
+	}
+
+	if i < len(s) {
+		if s[i] != 'e' && s[i] != 'E' {
+			return d, objutil.Errorf(objutil.ErrSyntax, "objconv/bson: invalid decimal: %q", in)
+		}
+
+		e, perr := strconv.Atoi(s[i+1:])
+		if perr != nil {
+			return d, objutil.Errorf(objutil.ErrSyntax, "objconv/bson: invalid decimal exponent: %q", in)
+		}
+
+		exp += e
+	}
+
+	if exp < decimalExponentMin || exp > decimalExponentMax {
+		return d, objutil.Errorf(objutil.ErrRange, "objconv/bson: the exponent of %q is out of range", in)
+	}
+
+	d.h = h | uint64(exp+decimalExponentBias)<<49
+	d.l = l
+
+	if neg {
+		d.h |= decimalSignBit
+	}
+
+	return
+}
+
+// String returns the representation of d, using the scientific notation for
+// numbers with positive or large negative exponents, like the MongoDB tools.
+func (d Decimal128) String() string {
+	return string(d.appendString(nil))
+}
+
+func (d Decimal128) appendString(b []byte) []byte {
+	if d.h&decimalSignBit != 0 && !d.IsNaN() {
+		b = append(b, '-')
+	}
+
+	switch {
+	case d.IsNaN():
+		return append(b, "NaN"...)
+	case d.IsInf():
+		return append(b, "Infinity"...)
+	}
+
+	var exp int
+	var h, l uint64
+
+	if (d.h>>61)&3 == 3 {
+		// The significand of this form is always larger than the maximum
+		// allowed, such numbers are non-canonical and are treated as zero.
+		exp = int((d.h>>47)&0x3FFF) - decimalExponentBias
+	} else {
+		exp = int((d.h>>49)&0x3FFF) - decimalExponentBias
+		h, l = d.h&(1<<49-1), d.l
+	}
+
+	var a [decimalMaxDigits + 5]byte
+	digits := a[:0]
+
+	for h != 0 || l != 0 {
+		var r uint64
+		h, l, r = div10(h, l)
+		digits = append(digits, byte('0'+r))
+	}
+
+	if len(digits) == 0 {
+		digits = append(digits, '0')
+	}
+
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+
+	if adjusted := exp + len(digits) - 1; exp > 0 || adjusted < -6 {
+		b = append(b, digits[0])
+		if len(digits) > 1 {
+			b = append(b, '.')
+			b = append(b, digits[1:]...)
+		}
+		b = append(b, 'E')
+		if adjusted >= 0 {
+			b = append(b, '+')
+		}
+		return strconv.AppendInt(b, int64(adjusted), 10)
+	}
+
+	if exp == 0 {
+		return append(b, digits...)
+	}
+
+	n := len(digits) + exp
+
+	if n > 0 {
+		b = append(b, digits[:n]...)
+		b = append(b, '.')
+		return append(b, digits[n:]...)
+	}
+
+	b = append(b, '0', '.')
+	for ; n < 0; n++ {
+		b = append(b, '0')
+	}
+	return append(b, digits...)
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (d Decimal128) EncodeValue(e objconv.Encoder) error {
+	if x, ok := e.Emitter.(decimalEmitter); ok {
+		return x.EmitDecimal128(d)
+	}
+	return e.Encode(d.String())
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (d *Decimal128) DecodeValue(dec objconv.Decoder) (err error) {
+	if x, ok := dec.Parser.(decimalParser); ok {
+		var found bool
+		if *d, found, err = x.ParseDecimal128(); found || err != nil {
+			return
+		}
+	}
+
+	var s string
+
+	if err = dec.Decode(&s); err == nil {
+		*d, err = ParseDecimal128(s)
+	}
+
+	return
+}
+
+type decimalEmitter interface {
+	EmitDecimal128(Decimal128) error
+}
+
+type decimalParser interface {
+	ParseDecimal128() (Decimal128, bool, error)
+}
+
+func mul10(h, l uint64) (uint64, uint64) {
+	hi, lo := bits.Mul64(l, 10)
+	return h*10 + hi, lo
+}
+
+func add128(h, l, v uint64) (uint64, uint64) {
+	l, c := bits.Add64(l, v, 0)
+	return h + c, l
+}
+
+func div10(h, l uint64) (uint64, uint64, uint64) {
+	qh, r := bits.Div64(0, h, 10)
+	ql, r := bits.Div64(r, l, 10)
+	return qh, ql, r
+}
@@ -0,0 +1,57 @@
+package bson
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/segmentio/objconv"
+)
+
+// NewDecoder returns a new BSON decoder that parses values from r.
+func NewDecoder(r io.Reader) *objconv.Decoder {
+	return objconv.NewDecoder(NewParser(r))
+}
+
+// NewStreamDecoder returns a new BSON stream decoder that parses values from r.
+//
+// BSON streams are sequences of top-level documents, so the Sequence option of
+// the decoder is enabled.
+func NewStreamDecoder(r io.Reader) *objconv.StreamDecoder {
+	d := objconv.NewStreamDecoder(NewParser(r))
+	d.Sequence = true
+	return d
+}
+
+// Unmarshal decodes a BSON representation of v from b, an error is returned
+// if b has data after the value.
+func Unmarshal(b []byte, v interface{}) error {
+	u := unmarshalerPool.Get().(*unmarshaler)
+	u.reset(b)
+
+	err := (objconv.Decoder{Parser: u, DisallowTrailingData: true}).Decode(v)
+
+	u.reset(nil)
+	unmarshalerPool.Put(u)
+	return err
+}
+
+var unmarshalerPool = sync.Pool{
+	New: func() interface{} { return newUnmarshaler() },
+}
+
+type unmarshaler struct {
+	Parser
+	b bytes.Buffer
+}
+
+func newUnmarshaler() *unmarshaler {
+	u := &unmarshaler{}
+	u.r = &u.b
+	return u
+}
+
+func (u *unmarshaler) reset(b []byte) {
+	u.b = *bytes.NewBuffer(b)
+	u.Reset(&u.b)
+}
@@ -0,0 +1,316 @@
+package bson
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Emitter implements a BSON emitter that satisfies the objconv.Emitter
+// interface.
+//
+// BSON documents start with their length, so the emitter builds each document
+// in memory and writes it to the underlying writer once it is complete. Only
+// documents can be written at the top level, top-level arrays are written as
+// sequences of documents, which is the layout of files produced by tools like
+// mongodump.
+type Emitter struct {
+	w io.Writer
+
+	// The document being built.
+	b []byte
+
+	// Key of the next element of the current document, set when map keys are
+	// emitted.
+	k []byte
+
+	// This stack is used to keep track of the documents and arrays being
+	// written. The sback array is the initial backend array for the stack.
+	stack []emitFrame
+	sback [16]emitFrame
+}
+
+type emitFrame struct {
+	start int  // offset of the length of the document in b
+	index int  // index of the next array element
+	array bool // set for arrays, which BSON represents as documents
+	key   bool // set when the next value of a document is a key
+	seq   bool // set for top-level arrays
+}
+
+func NewEmitter(w io.Writer) *Emitter {
+	e := &Emitter{}
+	e.stack = e.sback[:0]
+	e.Reset(w)
+	return e
+}
+
+func (e *Emitter) Reset(w io.Writer) {
+	e.w = w
+	e.b = e.b[:0]
+	e.k = e.k[:0]
+	e.stack = e.stack[:0]
+}
+
+// TransientEmitter satisfies the objconv.transientEmitter interface, values
+// are copied to the document being built when they are emitted.
+func (e *Emitter) TransientEmitter() bool {
+	return true
+}
+
+// EmitRaw satisfies the objconv.RawEmitter interface, b must be a BSON
+// document.
+func (e *Emitter) EmitRaw(b []byte) (err error) {
+	if len(b) < minDocumentSize || int(getInt32(b)) != len(b) || b[len(b)-1] != 0 {
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/bson: raw messages must be documents")
+	}
+
+	if e.isTop() {
+		_, err = e.w.Write(b)
+		return
+	}
+
+	if err = e.element(typeDocument, "a document"); err == nil {
+		e.b = append(e.b, b...)
+	}
+	return
+}
+
+// EmitObjectID writes the BSON ObjectId value id, it is called when values of
+// type ObjectID are encoded.
+func (e *Emitter) EmitObjectID(id ObjectID) (err error) {
+	if err = e.element(typeObjectID, "an object id"); err == nil {
+		e.b = append(e.b, id[:]...)
+	}
+	return
+}
+
+// EmitDecimal128 writes the BSON Decimal128 value d, it is called when values
+// of type Decimal128 are encoded.
+func (e *Emitter) EmitDecimal128(d Decimal128) (err error) {
+	if err = e.element(typeDecimal128, "a decimal"); err == nil {
+		e.b = appendInt64(e.b, int64(d.l))
+		e.b = appendInt64(e.b, int64(d.h))
+	}
+	return
+}
+
+func (e *Emitter) EmitNil() (err error) {
+	return e.element(typeNull, "a nil value")
+}
+
+func (e *Emitter) EmitBool(v bool) (err error) {
+	if err = e.element(typeBool, "a boolean"); err == nil {
+		if v {
+			e.b = append(e.b, 1)
+		} else {
+			e.b = append(e.b, 0)
+		}
+	}
+	return
+}
+
+func (e *Emitter) EmitInt(v int64, _ int) (err error) {
+	if e.isKey() {
+		return e.emitKey(strconv.AppendInt(e.k[:0], v, 10))
+	}
+
+	if v >= math.MinInt32 && v <= math.MaxInt32 {
+		if err = e.element(typeInt32, "an integer"); err == nil {
+			e.b = appendInt32(e.b, int32(v))
+		}
+		return
+	}
+
+	if err = e.element(typeInt64, "an integer"); err == nil {
+		e.b = appendInt64(e.b, v)
+	}
+	return
+}
+
+func (e *Emitter) EmitUint(v uint64, _ int) (err error) {
+	if e.isKey() {
+		return e.emitKey(strconv.AppendUint(e.k[:0], v, 10))
+	}
+
+	if v > objutil.Int64Max {
+		return objutil.Errorf(objutil.ErrRange, "objconv/bson: %d cannot be represented by a signed 64 bits integer", v)
+	}
+
+	return e.EmitInt(int64(v), 64)
+}
+
+func (e *Emitter) EmitFloat(v float64, _ int) (err error) {
+	if err = e.element(typeDouble, "a floating point number"); err == nil {
+		e.b = appendInt64(e.b, int64(math.Float64bits(v)))
+	}
+	return
+}
+
+func (e *Emitter) EmitString(v string) (err error) {
+	if e.isKey() {
+		return e.emitKey(append(e.k[:0], v...))
+	}
+
+	if err = e.element(typeString, "a string"); err == nil {
+		e.b = appendInt32(e.b, int32(len(v)+1))
+		e.b = append(e.b, v...)
+		e.b = append(e.b, 0)
+	}
+	return
+}
+
+func (e *Emitter) EmitBytes(v []byte) (err error) {
+	if err = e.element(typeBinary, "a byte slice"); err == nil {
+		e.b = appendInt32(e.b, int32(len(v)))
+		e.b = append(e.b, binaryGeneric)
+		e.b = append(e.b, v...)
+	}
+	return
+}
+
+// EmitTime writes a BSON UTC datetime, which has a precision of one
+// millisecond.
+func (e *Emitter) EmitTime(v time.Time) (err error) {
+	if err = e.element(typeDateTime, "a time"); err == nil {
+		e.b = appendInt64(e.b, v.UnixMilli())
+	}
+	return
+}
+
+// EmitDuration writes v as a string since BSON has no duration type.
+func (e *Emitter) EmitDuration(v time.Duration) (err error) {
+	if err = e.element(typeString, "a duration"); err == nil {
+		i := len(e.b)
+		e.b = objutil.AppendDuration(append(e.b, 0, 0, 0, 0), v)
+		e.b = append(e.b, 0)
+		putInt32(e.b[i:], int32(len(e.b)-(i+4)))
+	}
+	return
+}
+
+func (e *Emitter) EmitError(v error) (err error) {
+	return e.EmitString(v.Error())
+}
+
+func (e *Emitter) EmitArrayBegin(n int) (err error) {
+	if e.isTop() {
+		e.stack = append(e.stack, emitFrame{seq: true})
+		return
+	}
+
+	if err = e.element(typeArray, "an array"); err == nil {
+		e.begin(true)
+	}
+	return
+}
+
+func (e *Emitter) EmitArrayEnd() (err error) {
+	if f := e.stack[len(e.stack)-1]; f.seq {
+		e.stack = e.stack[:len(e.stack)-1]
+		return
+	}
+	return e.end()
+}
+
+func (e *Emitter) EmitArrayNext() (err error) {
+	return
+}
+
+func (e *Emitter) EmitMapBegin(n int) (err error) {
+	if !e.isTop() {
+		if err = e.element(typeDocument, "a document"); err != nil {
+			return
+		}
+	}
+	e.begin(false)
+	return
+}
+
+func (e *Emitter) EmitMapEnd() (err error) {
+	return e.end()
+}
+
+func (e *Emitter) EmitMapValue() (err error) {
+	e.stack[len(e.stack)-1].key = false
+	return
+}
+
+func (e *Emitter) EmitMapNext() (err error) {
+	e.stack[len(e.stack)-1].key = true
+	return
+}
+
+// isTop returns true if the next value is a top-level value.
+func (e *Emitter) isTop() bool {
+	return len(e.stack) == 0 || e.stack[len(e.stack)-1].seq
+}
+
+// isKey returns true if the next value is the key of a document element.
+func (e *Emitter) isKey() bool {
+	return len(e.stack) != 0 && e.stack[len(e.stack)-1].key
+}
+
+func (e *Emitter) emitKey(k []byte) error {
+	if e.k = k; bytes.IndexByte(k, 0) >= 0 {
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/bson: document keys cannot contain zero bytes: %q", k)
+	}
+	return nil
+}
+
+// element writes the type and the key of the next element of the current
+// document, what describes the value for error messages.
+func (e *Emitter) element(t byte, what string) error {
+	if e.isTop() {
+		return objutil.Errorf(objutil.ErrType, "objconv/bson: cannot encode %s at the top level, only documents can be", what)
+	}
+
+	f := &e.stack[len(e.stack)-1]
+
+	if f.key {
+		return objutil.Errorf(objutil.ErrType, "objconv/bson: cannot encode %s as a document key, keys must be strings or integers", what)
+	}
+
+	e.b = append(e.b, t)
+
+	if f.array {
+		e.b = strconv.AppendInt(e.b, int64(f.index), 10)
+		f.index++
+	} else {
+		e.b = append(e.b, e.k...)
+	}
+
+	e.b = append(e.b, 0)
+	return nil
+}
+
+func (e *Emitter) begin(array bool) {
+	e.stack = append(e.stack, emitFrame{start: len(e.b), array: array, key: !array})
+	e.b = append(e.b, 0, 0, 0, 0)
+}
+
+func (e *Emitter) end() (err error) {
+	i := len(e.stack) - 1
+	f := e.stack[i]
+	e.stack = e.stack[:i]
+	e.b = append(e.b, 0)
+
+	if n := len(e.b) - f.start; n > MaxDocumentSize {
+		err = objutil.Errorf(objutil.ErrLimit, "objconv/bson: the document size of %d bytes exceeds the limit of %d bytes", n, MaxDocumentSize)
+	} else {
+		putInt32(e.b[f.start:], int32(n))
+	}
+
+	if e.isTop() {
+		if err == nil {
+			_, err = e.w.Write(e.b)
+		}
+		e.b = e.b[:0]
+	}
+
+	return
+}
@@ -0,0 +1,22 @@
+package bson
+
+import (
+	"io"
+
+	"github.com/segmentio/objconv"
+)
+
+// Codec for the BSON format.
+var Codec = objconv.Codec{
+	NewEmitter: func(w io.Writer) objconv.Emitter { return NewEmitter(w) },
+	NewParser:  func(r io.Reader) objconv.Parser { return NewParser(r) },
+}
+
+func init() {
+	for _, name := range [...]string{
+		"application/bson",
+		"bson",
+	} {
+		objconv.Register(name, Codec)
+	}
+}
@@ -0,0 +1,119 @@
+package bson
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// ObjectID is the 12 bytes identifier of MongoDB documents, made of a
+// timestamp in seconds, a random value unique to the process, and a counter.
+//
+// Object identifiers are encoded as BSON ObjectId values by BSON emitters, and
+// as their hexadecimal representation by emitters of other formats. When the
+// destination of a BSON ObjectId value is not an ObjectID it is decoded as its
+// hexadecimal representation.
+type ObjectID [12]byte
+
+var (
+	objectIDCounter = randomUint32()
+	objectIDProcess = randomProcessID()
+)
+
+// NewObjectID generates a new object identifier for the current time.
+func NewObjectID() ObjectID {
+	return newObjectID(time.Now(), atomic.AddUint32(&objectIDCounter, 1))
+}
+
+func newObjectID(t time.Time, n uint32) (id ObjectID) {
+	binary.BigEndian.PutUint32(id[:4], uint32(t.Unix()))
+	copy(id[4:9], objectIDProcess[:])
+	id[9] = byte(n >> 16)
+	id[10] = byte(n >> 8)
+	id[11] = byte(n)
+	return
+}
+
+// ObjectIDFromHex parses the hexadecimal representation of an object
+// identifier.
+func ObjectIDFromHex(s string) (id ObjectID, err error) {
+	if len(s) != 2*len(id) {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/bson: invalid object id of length %d: %q", len(s), s)
+		return
+	}
+	if _, err = hex.Decode(id[:], []byte(s)); err != nil {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/bson: invalid object id: %q", s)
+	}
+	return
+}
+
+// Hex returns the hexadecimal representation of id.
+func (id ObjectID) Hex() string {
+	return hex.EncodeToString(id[:])
+}
+
+// String satisfies the fmt.Stringer interface.
+func (id ObjectID) String() string {
+	return id.Hex()
+}
+
+// Timestamp returns the time at which id was generated, with a precision of
+// one second.
+func (id ObjectID) Timestamp() time.Time {
+	return time.Unix(int64(binary.BigEndian.Uint32(id[:4])), 0).UTC()
+}
+
+// IsZero returns true if id is the zero value.
+func (id ObjectID) IsZero() bool {
+	return id == ObjectID{}
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (id ObjectID) EncodeValue(e objconv.Encoder) error {
+	if x, ok := e.Emitter.(objectIDEmitter); ok {
+		return x.EmitObjectID(id)
+	}
+	return e.Encode(id.Hex())
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (id *ObjectID) DecodeValue(d objconv.Decoder) (err error) {
+	if x, ok := d.Parser.(objectIDParser); ok {
+		var found bool
+		if *id, found, err = x.ParseObjectID(); found || err != nil {
+			return
+		}
+	}
+
+	var s string
+
+	if err = d.Decode(&s); err == nil {
+		*id, err = ObjectIDFromHex(s)
+	}
+
+	return
+}
+
+type objectIDEmitter interface {
+	EmitObjectID(ObjectID) error
+}
+
+type objectIDParser interface {
+	ParseObjectID() (ObjectID, bool, error)
+}
+
+func randomUint32() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+	return binary.BigEndian.Uint32(b[:])
+}
+
+func randomProcessID() (b [5]byte) {
+	rand.Read(b[:])
+	return
+}
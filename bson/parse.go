@@ -0,0 +1,533 @@
+package bson
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"math"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Parser implements a BSON parser that satisfies the objconv.Parser
+// interface.
+//
+// The parser loads each top-level document in memory before parsing it, the
+// length of documents is limited to MaxDocumentSize. The input may contain
+// multiple documents, which stream decoders read when they are configured to
+// decode sequences.
+type Parser struct {
+	r   io.Reader
+	b   []byte // the top-level document being parsed
+	i   int    // offset of the first unread byte in b
+	s   []byte // string buffer
+	off int64  // offset in the input of the first byte in b
+
+	// Type and key of the next element of the document being parsed, the key
+	// is only returned by the parser if the document is a map.
+	typ    byte
+	key    []byte
+	hasKey bool
+
+	// This stack is used to keep track of the offsets of the terminating bytes
+	// of the documents being parsed. The sback array is the initial backend
+	// array for the stack.
+	stack []int
+	sback [16]int
+}
+
+func NewParser(r io.Reader) *Parser {
+	p := &Parser{r: r}
+	p.stack = p.sback[:0]
+	return p
+}
+
+func (p *Parser) Reset(r io.Reader) {
+	p.r = r
+	p.b = p.b[:0]
+	p.i = 0
+	p.off = 0
+	p.typ = 0
+	p.key = nil
+	p.hasKey = false
+	p.stack = p.stack[:0]
+}
+
+// Offset returns the number of bytes consumed by the parser.
+func (p *Parser) Offset() int64 {
+	return p.off + int64(p.i)
+}
+
+// Buffered satisfies the objconv.BufferedParser interface.
+func (p *Parser) Buffered() io.Reader {
+	return bytes.NewReader(p.b[p.i:])
+}
+
+// ParseRaw satisfies the objconv.RawParser interface, only documents can be
+// captured as raw messages since the representation of other values is not
+// self-describing.
+func (p *Parser) ParseRaw() (b []byte, err error) {
+	var t objconv.Type
+
+	if t, err = p.ParseType(); err != nil {
+		return
+	}
+
+	if t != objconv.Map {
+		err = objutil.Errorf(objutil.ErrType, "objconv/bson: cannot decode a raw message from a value of type %s, only documents can be", t)
+		return
+	}
+
+	i := p.i
+
+	if err = p.begin(); err != nil {
+		return
+	}
+
+	p.i = p.stack[len(p.stack)-1]
+
+	if err = p.end(); err == nil {
+		b = p.b[i:p.i]
+	}
+
+	return
+}
+
+// ParseObjectID parses the next value if it is a BSON ObjectId, the boolean is
+// false if it is a value of a different type.
+func (p *Parser) ParseObjectID() (id ObjectID, ok bool, err error) {
+	var b []byte
+
+	if p.hasKey || p.typ != typeObjectID {
+		return
+	}
+
+	if b, err = p.read(len(id)); err == nil {
+		copy(id[:], b)
+		ok = true
+		p.typ = 0
+	}
+
+	return
+}
+
+// ParseDecimal128 parses the next value if it is a BSON Decimal128, the
+// boolean is false if it is a value of a different type.
+func (p *Parser) ParseDecimal128() (d Decimal128, ok bool, err error) {
+	if p.hasKey || p.typ != typeDecimal128 {
+		return
+	}
+
+	if d, err = p.parseDecimal128(); err == nil {
+		ok = true
+		p.typ = 0
+	}
+
+	return
+}
+
+func (p *Parser) ParseType() (objconv.Type, error) {
+	if len(p.stack) == 0 {
+		if p.i == len(p.b) {
+			if err := p.load(); err != nil {
+				return objconv.Unknown, err
+			}
+		}
+		return objconv.Map, nil
+	}
+
+	if p.hasKey {
+		return objconv.String, nil
+	}
+
+	switch p.typ {
+	case typeDouble:
+		return objconv.Float, nil
+
+	case typeString, typeJavaScript, typeSymbol, typeObjectID, typeRegex, typeDecimal128:
+		return objconv.String, nil
+
+	case typeDocument:
+		return objconv.Map, nil
+
+	case typeArray:
+		return objconv.Array, nil
+
+	case typeBinary:
+		return objconv.Bytes, nil
+
+	case typeUndefined, typeNull, typeMinKey, typeMaxKey:
+		return objconv.Nil, nil
+
+	case typeBool:
+		return objconv.Bool, nil
+
+	case typeDateTime:
+		return objconv.Time, nil
+
+	case typeInt32, typeInt64:
+		return objconv.Int, nil
+
+	case typeTimestamp:
+		return objconv.Uint, nil
+
+	case typeDBPointer, typeCodeWithScope:
+		return objconv.Unknown, objutil.Errorf(objutil.ErrType, "objconv/bson: elements of the deprecated type 0x%02X are not supported", p.typ)
+	}
+
+	return objconv.Unknown, objutil.Errorf(objutil.ErrSyntax, "objconv/bson: invalid element type 0x%02X", p.typ)
+}
+
+func (p *Parser) ParseNil() (err error) {
+	p.typ = 0
+	return
+}
+
+func (p *Parser) ParseBool() (v bool, err error) {
+	var b []byte
+
+	if b, err = p.read(1); err == nil {
+		v = b[0] != 0
+		p.typ = 0
+	}
+
+	return
+}
+
+func (p *Parser) ParseInt() (v int64, err error) {
+	var b []byte
+
+	if p.typ == typeInt32 {
+		if b, err = p.read(4); err == nil {
+			v = int64(getInt32(b))
+		}
+	} else {
+		if b, err = p.read(8); err == nil {
+			v = getInt64(b)
+		}
+	}
+
+	p.typ = 0
+	return
+}
+
+// ParseUint parses BSON timestamps, which are the only unsigned values.
+func (p *Parser) ParseUint() (v uint64, err error) {
+	var b []byte
+
+	if b, err = p.read(8); err == nil {
+		v = uint64(getInt64(b))
+		p.typ = 0
+	}
+
+	return
+}
+
+func (p *Parser) ParseFloat() (v float64, err error) {
+	var b []byte
+
+	if b, err = p.read(8); err == nil {
+		v = math.Float64frombits(uint64(getInt64(b)))
+		p.typ = 0
+	}
+
+	return
+}
+
+// ParseString parses the keys of documents and the values of elements of types
+// represented as strings by the parser: ObjectId and Decimal128 values are
+// returned in their text form, and regular expressions in the /pattern/options
+// form.
+func (p *Parser) ParseString() (v []byte, err error) {
+	if p.hasKey {
+		v, p.key, p.hasKey = p.key, nil, false
+		return
+	}
+
+	switch p.typ {
+	case typeObjectID:
+		var b []byte
+		if b, err = p.read(12); err != nil {
+			return
+		}
+		if cap(p.s) < 2*len(b) {
+			p.s = make([]byte, 2*len(b))
+		}
+		p.s = p.s[:2*len(b)]
+		hex.Encode(p.s, b)
+		v = p.s
+
+	case typeDecimal128:
+		var d Decimal128
+		if d, err = p.parseDecimal128(); err != nil {
+			return
+		}
+		p.s = d.appendString(p.s[:0])
+		v = p.s
+
+	case typeRegex:
+		var pattern, options []byte
+		if pattern, err = p.cstring(); err != nil {
+			return
+		}
+		if options, err = p.cstring(); err != nil {
+			return
+		}
+		p.s = append(append(p.s[:0], '/'), pattern...)
+		p.s = append(append(p.s, '/'), options...)
+		v = p.s
+
+	default:
+		if v, err = p.parseString(); err != nil {
+			return
+		}
+	}
+
+	p.typ = 0
+	return
+}
+
+func (p *Parser) ParseBytes() (v []byte, err error) {
+	var b []byte
+	var n int32
+
+	if b, err = p.read(5); err != nil {
+		return
+	}
+
+	if n = getInt32(b); n < 0 {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/bson: invalid binary length: %d", n)
+		return
+	}
+
+	subtype := b[4]
+
+	if v, err = p.read(int(n)); err != nil {
+		return
+	}
+
+	// The old binary subtype repeats the length of the data.
+	if subtype == binaryOld {
+		if len(v) < 4 || int(getInt32(v)) != len(v)-4 {
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/bson: invalid length of binary data of subtype 0x02")
+			return
+		}
+		v = v[4:]
+	}
+
+	p.typ = 0
+	return
+}
+
+func (p *Parser) ParseTime() (v time.Time, err error) {
+	var b []byte
+
+	if b, err = p.read(8); err == nil {
+		v = time.UnixMilli(getInt64(b)).UTC()
+		p.typ = 0
+	}
+
+	return
+}
+
+func (p *Parser) ParseDuration() (v time.Duration, err error) {
+	panic("objconv/bson: ParseDuration should never be called because BSON has no duration type, this is likely a bug in the decoder code")
+}
+
+func (p *Parser) ParseError() (v error, err error) {
+	panic("objconv/bson: ParseError should never be called because BSON has no error type, this is likely a bug in the decoder code")
+}
+
+// ParseArrayBegin returns -1 because BSON arrays are documents which don't
+// record the number of elements they contain.
+func (p *Parser) ParseArrayBegin() (n int, err error) {
+	return -1, p.begin()
+}
+
+func (p *Parser) ParseArrayEnd(n int) (err error) {
+	return p.end()
+}
+
+func (p *Parser) ParseArrayNext(n int) (err error) {
+	return p.next(false)
+}
+
+func (p *Parser) ParseMapBegin() (n int, err error) {
+	return -1, p.begin()
+}
+
+func (p *Parser) ParseMapEnd(n int) (err error) {
+	return p.end()
+}
+
+func (p *Parser) ParseMapValue(n int) (err error) {
+	return
+}
+
+func (p *Parser) ParseMapNext(n int) (err error) {
+	return p.next(true)
+}
+
+// load reads the next top-level document of the input.
+func (p *Parser) load() (err error) {
+	p.off += int64(len(p.b))
+	p.b = p.b[:0]
+	p.i = 0
+
+	var h [4]byte
+
+	if _, err = io.ReadFull(p.r, h[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/bson: unexpected end of input")
+		}
+		return
+	}
+
+	n := int(getInt32(h[:]))
+
+	if n < minDocumentSize {
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/bson: invalid document length: %d", n)
+	}
+
+	if n > MaxDocumentSize {
+		return objutil.Errorf(objutil.ErrLimit, "objconv/bson: the document size of %d bytes exceeds the limit of %d bytes", n, MaxDocumentSize)
+	}
+
+	if cap(p.b) < n {
+		p.b = make([]byte, 0, n)
+	}
+
+	b := append(p.b[:0], h[:]...)[:n]
+
+	if _, err = io.ReadFull(p.r, b[4:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/bson: unexpected end of input")
+		}
+		return
+	}
+
+	p.b = b
+	return
+}
+
+// begin starts parsing the embedded document at the current offset.
+func (p *Parser) begin() error {
+	i := p.i
+	b, err := p.read(4)
+	if err != nil {
+		return err
+	}
+
+	n := int(getInt32(b))
+	end := i + n - 1
+
+	if n < minDocumentSize || end >= p.limit() || p.b[end] != 0 {
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/bson: invalid document length at offset %d: %d", p.off+int64(i), n)
+	}
+
+	p.stack = append(p.stack, end)
+	p.typ = 0
+	return nil
+}
+
+func (p *Parser) end() error {
+	i := len(p.stack) - 1
+	p.i = p.stack[i] + 1
+	p.stack = p.stack[:i]
+	return nil
+}
+
+// next parses the type and key of the next element of the current document,
+// returning objconv.End when the end of the document was reached.
+func (p *Parser) next(hasKey bool) (err error) {
+	if p.i == p.stack[len(p.stack)-1] {
+		return objconv.End
+	}
+
+	var b []byte
+
+	if b, err = p.read(1); err != nil {
+		return
+	}
+
+	p.typ = b[0]
+
+	if p.key, err = p.cstring(); err == nil {
+		p.hasKey = hasKey
+	}
+
+	return
+}
+
+func (p *Parser) parseString() (v []byte, err error) {
+	var b []byte
+	var n int32
+
+	if b, err = p.read(4); err != nil {
+		return
+	}
+
+	if n = getInt32(b); n < 1 {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/bson: invalid string length: %d", n)
+		return
+	}
+
+	if b, err = p.read(int(n)); err != nil {
+		return
+	}
+
+	if b[n-1] != 0 {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/bson: string is not terminated by a zero byte")
+		return
+	}
+
+	v = b[:n-1]
+	return
+}
+
+func (p *Parser) parseDecimal128() (d Decimal128, err error) {
+	var b []byte
+
+	if b, err = p.read(16); err == nil {
+		d.l = uint64(getInt64(b[:8]))
+		d.h = uint64(getInt64(b[8:]))
+	}
+
+	return
+}
+
+func (p *Parser) cstring() (v []byte, err error) {
+	b := p.b[p.i:p.limit()]
+	n := bytes.IndexByte(b, 0)
+
+	if n < 0 {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/bson: string is not terminated by a zero byte")
+		return
+	}
+
+	v = b[:n]
+	p.i += n + 1
+	return
+}
+
+// read returns the next n bytes of the current document, the returned slice
+// is only valid until the next document is loaded.
+func (p *Parser) read(n int) (b []byte, err error) {
+	if n > p.limit()-p.i {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/bson: unexpected end of document at offset %d", p.Offset())
+		return
+	}
+	b = p.b[p.i : p.i+n]
+	p.i += n
+	return
+}
+
+// limit returns the offset of the end of the document being parsed.
+func (p *Parser) limit() int {
+	if i := len(p.stack); i != 0 {
+		return p.stack[i-1]
+	}
+	return len(p.b)
+}
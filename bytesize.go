@@ -0,0 +1,158 @@
+package objconv
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// ByteSize is a number of bytes, encoded in human-readable form like "512MiB".
+//
+// Sizes are formatted with the largest binary unit (KiB, MiB, ...) that
+// represents them exactly, so the encoded values always decode to the size
+// they were produced from. Decoding accepts integers as well as strings with
+// binary or decimal units (KB, MB, ...), which makes the type convenient to
+// use in configuration files.
+type ByteSize int64
+
+// Byte size units.
+const (
+	B ByteSize = 1
+
+	KB ByteSize = 1000 * B
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+	EB ByteSize = 1000 * PB
+
+	KiB ByteSize = 1024 * B
+	MiB ByteSize = 1024 * KiB
+	GiB ByteSize = 1024 * MiB
+	TiB ByteSize = 1024 * GiB
+	PiB ByteSize = 1024 * TiB
+	EiB ByteSize = 1024 * PiB
+)
+
+var byteSizeUnits = [...]struct {
+	name string
+	size ByteSize
+}{
+	{"EiB", EiB}, {"PiB", PiB}, {"TiB", TiB}, {"GiB", GiB}, {"MiB", MiB}, {"KiB", KiB},
+	{"EB", EB}, {"PB", PB}, {"TB", TB}, {"GB", GB}, {"MB", MB}, {"KB", KB},
+	{"B", B},
+}
+
+// ParseByteSize parses s as a byte size, which is a number optionally followed
+// by a unit like "KiB" or "MB". Units are case-insensitive and may be separated
+// from the number by spaces, numbers without units are sizes in bytes.
+func ParseByteSize(s string) (ByteSize, error) {
+	t := strings.TrimSpace(s)
+	i := strings.IndexFunc(t, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+	})
+
+	if i < 0 {
+		i = len(t)
+	}
+
+	num, unit := t[:i], strings.TrimSpace(t[i:])
+	size := ByteSize(0)
+
+	if len(unit) == 0 {
+		size = B
+	} else {
+		for _, u := range byteSizeUnits {
+			if strings.EqualFold(unit, u.name) {
+				size = u.size
+				break
+			}
+		}
+	}
+
+	if size == 0 || len(num) == 0 {
+		return 0, objutil.Errorf(objutil.ErrSyntax, "objconv: invalid byte size: %q", s)
+	}
+
+	if !strings.Contains(num, ".") {
+		n, err := strconv.ParseInt(num, 10, 64)
+
+		if err != nil && !errors.Is(err, strconv.ErrRange) {
+			return 0, objutil.Errorf(objutil.ErrSyntax, "objconv: invalid byte size: %q", s)
+		}
+
+		if err != nil || n > math.MaxInt64/int64(size) || n < math.MinInt64/int64(size) {
+			return 0, objutil.Errorf(objutil.ErrRange, "objconv: byte size out of range: %q", s)
+		}
+
+		return ByteSize(n) * size, nil
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+
+	if err != nil {
+		return 0, objutil.Errorf(objutil.ErrSyntax, "objconv: invalid byte size: %q", s)
+	}
+
+	if f *= float64(size); f >= math.MaxInt64 || f <= math.MinInt64 {
+		return 0, objutil.Errorf(objutil.ErrRange, "objconv: byte size out of range: %q", s)
+	}
+
+	return ByteSize(math.Round(f)), nil
+}
+
+// String returns the human-readable representation of b.
+func (b ByteSize) String() string {
+	if b != 0 {
+		for _, u := range byteSizeUnits[:6] {
+			if b%u.size == 0 {
+				return strconv.FormatInt(int64(b/u.size), 10) + u.name
+			}
+		}
+	}
+	return strconv.FormatInt(int64(b), 10) + "B"
+}
+
+// EncodeValue satisfies the ValueEncoder interface.
+func (b ByteSize) EncodeValue(e Encoder) error {
+	return e.Encode(b.String())
+}
+
+// DecodeValue satisfies the ValueDecoder interface, byte sizes can be decoded
+// from integers (as a number of bytes) or strings.
+func (b *ByteSize) DecodeValue(d Decoder) error {
+	var v interface{}
+
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+
+	switch x := v.(type) {
+	case int64:
+		*b = ByteSize(x)
+	case uint64:
+		if x > math.MaxInt64 {
+			return objutil.Errorf(objutil.ErrRange, "objconv: byte size out of range: %d", x)
+		}
+		*b = ByteSize(x)
+	case Number:
+		n, err := x.Int64()
+		if err != nil {
+			return objutil.Errorf(objutil.ErrRange, "objconv: byte size out of range: %s", x)
+		}
+		*b = ByteSize(n)
+	case string:
+		s, err := ParseByteSize(x)
+		if err != nil {
+			return err
+		}
+		*b = s
+	default:
+		return objutil.Errorf(objutil.ErrType, "objconv: cannot decode a byte size from a value of type %T", v)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package objconv
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in  string
+		out ByteSize
+	}{
+		{"0", 0},
+		{"42", 42},
+		{"42B", 42},
+		{"512MiB", 512 * MiB},
+		{"512 mib", 512 * MiB},
+		{"1.5KB", 1500},
+		{"1.5KiB", 1536},
+		{"10GB", 10 * GB},
+		{"-1KiB", -KiB},
+	}
+
+	for _, test := range tests {
+		if s, err := ParseByteSize(test.in); err != nil || s != test.out {
+			t.Errorf("%q: %d != %d (%v)", test.in, s, test.out, err)
+		}
+	}
+
+	if _, err := ParseByteSize("8EiB"); !errors.Is(err, ErrRange) {
+		t.Error("expected a range error but got:", err)
+	}
+
+	for _, s := range []string{"", "MiB", "12XB", "1.2.3", "-"} {
+		if _, err := ParseByteSize(s); !errors.Is(err, ErrSyntax) {
+			t.Errorf("%q: expected a syntax error but got: %v", s, err)
+		}
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	tests := []struct {
+		in  ByteSize
+		out string
+	}{
+		{0, "0B"},
+		{1000, "1000B"},
+		{1024, "1KiB"},
+		{1536, "1536B"},
+		{512 * MiB, "512MiB"},
+		{-2 * GiB, "-2GiB"},
+	}
+
+	for _, test := range tests {
+		if s := test.in.String(); s != test.out {
+			t.Errorf("%d: %q != %q", test.in, s, test.out)
+		}
+	}
+}
+
+func TestByteSizeEncoding(t *testing.T) {
+	type config struct {
+		MaxSize ByteSize
+		MinSize ByteSize
+	}
+
+	e := NewValueEmitter()
+
+	if err := (Encoder{Emitter: e}).Encode(config{MaxSize: 2 * GiB}); err != nil {
+		t.Fatal(err)
+	}
+
+	var c config
+
+	if err := NewDecoder(NewValueParser(e.Value())).Decode(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c != (config{MaxSize: 2 * GiB}) {
+		t.Errorf("bad value: %#v", c)
+	}
+
+	if err := NewDecoder(NewValueParser(map[string]interface{}{"MinSize": 4096})).Decode(&c); err != nil || c.MinSize != 4*KiB {
+		t.Errorf("bad value: %#v (%v)", c, err)
+	}
+}
+
+func TestByteSizeUseNumber(t *testing.T) {
+	var b ByteSize
+
+	if err := (Decoder{Parser: NewValueParser(4096), UseNumber: true}).Decode(&b); err != nil || b != 4*KiB {
+		t.Errorf("bad value: %d (%v)", b, err)
+	}
+}
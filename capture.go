@@ -0,0 +1,32 @@
+package objconv
+
+import "reflect"
+
+// A Capturer receives the top-level values processed by encoders and decoders
+// that it is configured on.
+//
+// Capturers are used to record samples of the payloads that a program works
+// with, for example to build a corpus for benchmarks or fuzzing, see the
+// objconv/bench package for an implementation.
+//
+// The Capture method is called after a value was successfully encoded or
+// decoded, it must not retain v after returning. Capturers that are shared by
+// multiple encoders or decoders must be safe to use concurrently.
+type Capturer interface {
+	Capture(v interface{})
+}
+
+// A FieldRecorder receives the usage of struct fields by the encoders and
+// decoders that it is configured on.
+//
+// Each time a struct value is encoded or decoded, RecordField is called once
+// for every serializable field of the struct, with used set to true if the
+// field was emitted by the encoder or present in the input of the decoder.
+// This is used to find fields that are never populated in large schemas, see
+// the objconv/fieldstats package for an implementation.
+//
+// FieldRecorders that are shared by multiple encoders or decoders must be safe
+// to use concurrently.
+type FieldRecorder interface {
+	RecordField(t reflect.Type, field string, used bool)
+}
@@ -0,0 +1,45 @@
+package objconv
+
+import (
+	"reflect"
+	"testing"
+)
+
+type captureList []interface{}
+
+func (c *captureList) Capture(v interface{}) { *c = append(*c, v) }
+
+type captureWrapper struct{ V interface{} }
+
+func (w captureWrapper) EncodeValue(e Encoder) error { return e.Encode(w.V) }
+
+func TestEncoderCapturer(t *testing.T) {
+	c := &captureList{}
+	e := NewEncoder(&ValueEmitter{})
+	e.Capturer = c
+
+	e.Encode(captureWrapper{[]int{1, 2, 3}})
+	e.Encode(map[string]int{"A": 1})
+
+	expect := captureList{captureWrapper{[]int{1, 2, 3}}, map[string]int{"A": 1}}
+
+	if !reflect.DeepEqual(*c, expect) {
+		t.Errorf("%#v != %#v", expect, *c)
+	}
+}
+
+func TestDecoderCapturer(t *testing.T) {
+	var v map[string][]int
+
+	c := &captureList{}
+	d := NewDecoder(NewValueParser(map[string][]int{"A": {1, 2, 3}}))
+	d.Capturer = c
+
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*c) != 1 || (*c)[0] != &v {
+		t.Errorf("bad captured values: %#v", *c)
+	}
+}
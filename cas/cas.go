@@ -0,0 +1,183 @@
+// Package cas implements a content-addressable store of values encoded with
+// objconv codecs.
+//
+// Values are encoded canonically (map keys are sorted) so that equal values
+// always produce the same bytes, the SHA-256 hash of the encoded bytes is the
+// address of the value in the store. This is useful to cache artifacts derived
+// from inputs, or to deduplicate configuration blobs shared by many programs.
+package cas
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/segmentio/objconv"
+)
+
+// HashSize is the size of hashes in bytes.
+const HashSize = sha256.Size
+
+// Hash is the address of a value in a store.
+type Hash [HashSize]byte
+
+// ParseHash parses the hexadecimal representation of a hash.
+func ParseHash(s string) (h Hash, err error) {
+	if len(s) != 2*HashSize {
+		err = fmt.Errorf("objconv/cas: invalid hash length: %q", s)
+		return
+	}
+	if _, err = hex.Decode(h[:], []byte(s)); err != nil {
+		err = fmt.Errorf("objconv/cas: invalid hash: %q", s)
+	}
+	return
+}
+
+// String returns the hexadecimal representation of h.
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// MarshalText satisfies the encoding.TextMarshaler interface.
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// UnmarshalText satisfies the encoding.TextUnmarshaler interface.
+func (h *Hash) UnmarshalText(b []byte) (err error) {
+	*h, err = ParseHash(string(b))
+	return
+}
+
+// ErrNotFound is returned when loading a hash that doesn't exist in a store.
+var ErrNotFound = errors.New("objconv/cas: object not found")
+
+// Encode returns the canonical encoding of v with codec, and its hash.
+func Encode(codec objconv.Codec, v interface{}) ([]byte, Hash, error) {
+	b := &bytes.Buffer{}
+	e := codec.NewEncoder(b)
+	e.SortMapKeys = true
+
+	if err := e.Encode(v); err != nil {
+		return nil, Hash{}, err
+	}
+
+	return b.Bytes(), sha256.Sum256(b.Bytes()), nil
+}
+
+// Store is a content-addressable store of values.
+//
+// Objects are saved in files named after their hash, the first two characters
+// of the hash are used as a sub-directory to avoid creating directories with
+// too many files.
+type Store struct {
+	// Codec used to encode and decode the stored values.
+	Codec objconv.Codec
+
+	// Dir is the directory where the objects are written.
+	Dir string
+
+	// FS is the file system objects are loaded from, os.DirFS(Dir) is used if
+	// it is nil. Stores with no Dir and a FS are read-only, which is useful to
+	// load objects embedded in programs.
+	FS fs.FS
+}
+
+// Put stores v, returning its hash. Storing a value that already exists in the
+// store has no effect.
+func (s *Store) Put(v interface{}) (Hash, error) {
+	b, h, err := Encode(s.Codec, v)
+	if err != nil {
+		return h, err
+	}
+
+	if s.Dir == "" {
+		return h, errors.New("objconv/cas: the store is read-only")
+	}
+
+	p := filepath.Join(s.Dir, filepath.FromSlash(objectPath(h)))
+
+	if _, err := os.Stat(p); err == nil {
+		return h, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return h, err
+	}
+
+	// Objects are written to a temporary file renamed once complete, so they
+	// can never be observed partially written.
+	f, err := os.CreateTemp(filepath.Dir(p), ".tmp-")
+	if err != nil {
+		return h, err
+	}
+
+	_, err = f.Write(b)
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), p)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	return h, err
+}
+
+// Get loads the value at h into v.
+//
+// The method returns ErrNotFound if the object doesn't exist, and an error if
+// its content doesn't match the hash.
+func (s *Store) Get(h Hash, v interface{}) error {
+	b, err := fs.ReadFile(s.fs(), objectPath(h))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = ErrNotFound
+		}
+		return err
+	}
+
+	if sha256.Sum256(b) != h {
+		return fmt.Errorf("objconv/cas: the content of object %s doesn't match its hash", h)
+	}
+
+	return s.Codec.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+// Has returns true if the object at h exists in the store.
+func (s *Store) Has(h Hash) bool {
+	_, err := fs.Stat(s.fs(), objectPath(h))
+	return err == nil
+}
+
+// Open returns a reader exposing the encoded bytes of the object at h, without
+// verifying its hash.
+func (s *Store) Open(h Hash) (io.ReadCloser, error) {
+	f, err := s.fs().Open(objectPath(h))
+	if err != nil && errors.Is(err, fs.ErrNotExist) {
+		err = ErrNotFound
+	}
+	return f, err
+}
+
+func (s *Store) fs() fs.FS {
+	if s.FS != nil {
+		return s.FS
+	}
+	return os.DirFS(s.Dir)
+}
+
+func objectPath(h Hash) string {
+	x := h.String()
+	return path.Join(x[:2], x[2:])
+}
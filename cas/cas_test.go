@@ -0,0 +1,130 @@
+package cas
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/segmentio/objconv/json"
+)
+
+func TestEncodeCanonical(t *testing.T) {
+	m1 := map[string]interface{}{}
+	m2 := map[string]interface{}{}
+
+	for i := 0; i != 100; i++ {
+		k := string(rune('A' + i%26))
+		m1[k] = i
+	}
+
+	for i := 99; i >= 0; i-- {
+		k := string(rune('A' + i%26))
+		if _, ok := m2[k]; !ok {
+			m2[k] = m1[k]
+		}
+	}
+
+	b1, h1, err := Encode(json.Codec, m1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b2, h2, err := Encode(json.Codec, m2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b1) != string(b2) || h1 != h2 {
+		t.Errorf("equal values produced different encodings:\n%s\n%s", b1, b2)
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "objconv-cas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	type config struct {
+		Name  string
+		Hosts []string
+	}
+
+	s := &Store{Codec: json.Codec, Dir: dir}
+	v1 := config{Name: "A", Hosts: []string{"localhost"}}
+
+	h1, err := s.Put(v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if h, err := s.Put(v1); err != nil || h != h1 {
+		t.Error("storing the same value twice produced a different hash:", h, err)
+	}
+
+	if !s.Has(h1) {
+		t.Error("the object was not found in the store")
+	}
+
+	var v2 config
+	if err := s.Get(h1, &v2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(v1, v2) {
+		t.Errorf("%#v != %#v", v1, v2)
+	}
+
+	if err := s.Get(Hash{}, &v2); err != ErrNotFound {
+		t.Error("expected ErrNotFound but got:", err)
+	}
+}
+
+func TestStoreFS(t *testing.T) {
+	b, h, err := Encode(json.Codec, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := fstest.MapFS{objectPath(h): &fstest.MapFile{Data: b}}
+	s := &Store{Codec: json.Codec, FS: fsys}
+
+	var v string
+	if err := s.Get(h, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if v != "hello" {
+		t.Error("bad value:", v)
+	}
+
+	if _, err := s.Put("world"); err == nil {
+		t.Error("no error returned when writing to a read-only store")
+	}
+
+	fsys[objectPath(h)].Data = []byte(`"oops"`)
+
+	if err := s.Get(h, &v); err == nil {
+		t.Error("no error returned when loading a corrupted object")
+	}
+}
+
+func TestParseHash(t *testing.T) {
+	_, h, _ := Encode(json.Codec, 42)
+
+	p, err := ParseHash(h.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p != h {
+		t.Errorf("%s != %s", h, p)
+	}
+
+	if _, err := ParseHash("abc"); err == nil {
+		t.Error("no error returned when parsing an invalid hash")
+	}
+}
@@ -0,0 +1,248 @@
+package cbor
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/segmentio/objconv"
+)
+
+// CanonicalEmitter implements an emitter of the deterministic encoding of CBOR
+// defined in RFC 8949 section 4.2: integers, lengths and floating point
+// numbers use their shortest form, arrays and maps have definite lengths, and
+// map entries are sorted by the bytewise lexicographic order of the encoding
+// of their keys.
+//
+// Maps and arrays of unknown length are buffered until they are complete, raw
+// values are written as they are.
+type CanonicalEmitter struct {
+	Emitter
+
+	// Stack of the maps and arrays being buffered, frames are reused by the
+	// following values at the same depth.
+	frames []*canonicalFrame
+	depth  int
+}
+
+type canonicalFrame struct {
+	w       io.Writer // writer that the frame gets written to
+	buf     bytes.Buffer
+	next    int // number of calls to EmitArrayNext
+	entries []canonicalEntry
+}
+
+// canonicalEntry holds the offsets of a map entry in the buffer of a frame.
+type canonicalEntry struct {
+	key int
+	val int
+	end int
+}
+
+// NewCanonicalEmitter returns a new emitter that writes the canonical CBOR
+// representation of values to w.
+func NewCanonicalEmitter(w io.Writer) *CanonicalEmitter {
+	e := &CanonicalEmitter{}
+	e.stack = e.sback[:0]
+	e.Reset(w)
+	return e
+}
+
+func (e *CanonicalEmitter) Reset(w io.Writer) {
+	e.Emitter.Reset(w)
+	e.depth = 0
+}
+
+// CanonicalEmitter satisfies the objconv.CanonicalEmitter interface.
+func (e *Emitter) CanonicalEmitter() objconv.Emitter {
+	return NewCanonicalEmitter(e.writer())
+}
+
+// CanonicalEmitter satisfies the objconv.CanonicalEmitter interface, the
+// method returns the emitter itself.
+func (e *CanonicalEmitter) CanonicalEmitter() objconv.Emitter {
+	return e
+}
+
+func (e *Emitter) writer() io.Writer {
+	if e.w == io.Writer(&e.buf) {
+		e.Flush()
+		return e.buf.Writer()
+	}
+	return e.w
+}
+
+func (e *CanonicalEmitter) EmitFloat(v float64, _ int) (err error) {
+	n := 0
+
+	if f := float32(v); float64(f) == v || math.IsNaN(v) {
+		if h, ok := f32tof16bits(math.Float32bits(f)); ok {
+			n = 3
+			e.b[0] = majorByte(majorType7, svFloat16)
+			putUint16(e.b[1:], h)
+		} else {
+			n = 5
+			e.b[0] = majorByte(majorType7, svFloat32)
+			putUint32(e.b[1:], math.Float32bits(f))
+		}
+	} else {
+		n = 9
+		e.b[0] = majorByte(majorType7, svFloat64)
+		putUint64(e.b[1:], math.Float64bits(v))
+	}
+
+	_, err = e.w.Write(e.b[:n])
+	return
+}
+
+func (e *CanonicalEmitter) EmitArrayBegin(n int) (err error) {
+	if n >= 0 {
+		return e.Emitter.EmitArrayBegin(n)
+	}
+	e.stack = append(e.stack, n)
+	e.push()
+	return
+}
+
+func (e *CanonicalEmitter) EmitArrayEnd() (err error) {
+	i := len(e.stack) - 1
+	n := e.stack[i]
+	e.stack = e.stack[:i]
+
+	if n >= 0 {
+		return
+	}
+
+	f := e.pop()
+
+	if f.buf.Len() != 0 {
+		n = f.next + 1
+	} else {
+		n = 0
+	}
+
+	if err = e.emitUint(majorType4, uint64(n)); err != nil {
+		return
+	}
+
+	_, err = e.w.Write(f.buf.Bytes())
+	return
+}
+
+func (e *CanonicalEmitter) EmitArrayNext() (err error) {
+	if e.stack[len(e.stack)-1] < 0 {
+		e.frames[e.depth-1].next++
+	}
+	return
+}
+
+func (e *CanonicalEmitter) EmitMapBegin(n int) (err error) {
+	e.stack = append(e.stack, n)
+	f := e.push()
+	f.entries = append(f.entries, canonicalEntry{})
+	return
+}
+
+func (e *CanonicalEmitter) EmitMapEnd() (err error) {
+	e.stack = e.stack[:len(e.stack)-1]
+
+	f := e.pop()
+	b := f.buf.Bytes()
+
+	if len(b) == 0 {
+		f.entries = f.entries[:0]
+	} else {
+		f.entries[len(f.entries)-1].end = len(b)
+	}
+
+	sort.Slice(f.entries, func(i int, j int) bool {
+		e1, e2 := f.entries[i], f.entries[j]
+		return bytes.Compare(b[e1.key:e1.val], b[e2.key:e2.val]) < 0
+	})
+
+	if err = e.emitUint(majorType5, uint64(len(f.entries))); err != nil {
+		return
+	}
+
+	for _, x := range f.entries {
+		if _, err = e.w.Write(b[x.key:x.end]); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (e *CanonicalEmitter) EmitMapValue() (err error) {
+	f := e.frames[e.depth-1]
+	f.entries[len(f.entries)-1].val = f.buf.Len()
+	return
+}
+
+func (e *CanonicalEmitter) EmitMapNext() (err error) {
+	f := e.frames[e.depth-1]
+	n := f.buf.Len()
+	f.entries[len(f.entries)-1].end = n
+	f.entries = append(f.entries, canonicalEntry{key: n})
+	return
+}
+
+// push starts buffering a map or an array, the values emitted until the call
+// to pop are written to the buffer of the returned frame.
+func (e *CanonicalEmitter) push() *canonicalFrame {
+	if e.depth == len(e.frames) {
+		e.frames = append(e.frames, &canonicalFrame{})
+	}
+
+	f := e.frames[e.depth]
+	f.w, e.w = e.w, &f.buf
+	f.buf.Reset()
+	f.next = 0
+	f.entries = f.entries[:0]
+
+	e.depth++
+	return f
+}
+
+// pop stops buffering the innermost map or array, and restores the writer of
+// the emitter.
+func (e *CanonicalEmitter) pop() *canonicalFrame {
+	e.depth--
+	f := e.frames[e.depth]
+	e.w = f.w
+	return f
+}
+
+// f32tof16bits converts the bits of a single precision floating point number
+// to half precision, ok is false if the conversion would lose precision. All
+// NaN values are converted to the canonical quiet NaN.
+func f32tof16bits(x uint32) (y uint16, ok bool) {
+	s := uint16(x>>16) & 0x8000
+	e := int((x>>23)&0xff) - 127
+	m := x & 0x7fffff
+
+	switch {
+	case e == 128: // Inf or NaN
+		if m != 0 {
+			return 0x7e00, true
+		}
+		return s | 0x7c00, true
+
+	case e == -127 && m == 0: // +/- 0
+		return s, true
+
+	case e >= -14 && e <= 15: // normal
+		if m&0x1fff == 0 {
+			return s | uint16(e+15)<<10 | uint16(m>>13), true
+		}
+
+	case e >= -24 && e < -14: // subnormal
+		m |= 0x800000
+		if n := uint(-(e + 1)); m&(1<<n-1) == 0 {
+			return s | uint16(m>>n), true
+		}
+	}
+
+	return 0, false
+}
@@ -37,10 +37,21 @@ const ( // simple values
 )
 
 const ( // tags
-	tagDateTime  = 0
-	tagTimestamp = 1
+	tagDateTime     = 0
+	tagTimestamp    = 1
+	tagPosBignum    = 2
+	tagNegBignum    = 3
+	tagURI          = 32
+	tagSelfDescribe = 55799
 )
 
+// Heartbeats are encoded as a self-described undefined value, parsers skip them
+// when they are found between top-level values or the elements of a stream.
+var heartbeat = [...]byte{
+	majorType6<<5 | iUint16, tagSelfDescribe >> 8, tagSelfDescribe & 0xFF,
+	majorType7<<5 | svUndefined,
+}
+
 const (
 	intMax   = uint64(objutil.IntMax)
 	int64Max = uint64(objutil.Int64Max)
@@ -1,8 +1,16 @@
 package cbor
 
 import (
+	"bytes"
+	"errors"
+	"math"
+	"math/big"
+	"net/url"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/segmentio/objconv"
 	"github.com/segmentio/objconv/objtests"
 )
 
@@ -14,6 +22,81 @@ func BenchmarkCodec(b *testing.B) {
 	objtests.BenchmarkCodec(b, Codec)
 }
 
+func TestRawMessage(t *testing.T) {
+	objtests.TestRawMessage(t, Codec)
+}
+
+func TestStreamHeartbeat(t *testing.T) {
+	objtests.TestStreamHeartbeat(t, Codec)
+}
+
+func TestSelfDescribedUndefined(t *testing.T) {
+	// Heartbeats are only skipped between top-level values and stream
+	// elements, elsewhere they are undefined values.
+	tests := []struct {
+		b []byte
+		v interface{}
+	}{
+		{ // {"a":55799(undefined),"b":"x"}
+			b: []byte{0xa2, 0x61, 'a', 0xd9, 0xd9, 0xf7, 0xf7, 0x61, 'b', 0x61, 'x'},
+			v: map[string]interface{}{"a": nil, "b": "x"},
+		},
+		{ // {_ "a":55799(undefined),"b":"x"}
+			b: []byte{0xbf, 0x61, 'a', 0xd9, 0xd9, 0xf7, 0xf7, 0x61, 'b', 0x61, 'x', 0xff},
+			v: map[string]interface{}{"a": nil, "b": "x"},
+		},
+		{ // {"a":[_ 55799(undefined)]}
+			b: []byte{0xa1, 0x61, 'a', 0x9f, 0xd9, 0xd9, 0xf7, 0xf7, 0xff},
+			v: map[string]interface{}{"a": []interface{}{nil}},
+		},
+	}
+
+	for _, test := range tests {
+		var v map[string]interface{}
+
+		if err := Unmarshal(test.b, &v); err != nil {
+			t.Errorf("%x: %s", test.b, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(v, test.v) {
+			t.Errorf("%x: bad value: %#v", test.b, v)
+		}
+	}
+}
+
+func TestStreamCloseWithError(t *testing.T) {
+	objtests.TestStreamCloseWithError(t, Codec)
+}
+
+func TestStreamDecodeAt(t *testing.T) {
+	objtests.TestStreamDecodeAt(t, Codec)
+}
+
+func TestParserStringLimit(t *testing.T) {
+	objtests.TestParserStringLimit(t, Codec)
+}
+
+func TestAppend(t *testing.T) {
+	objtests.TestAppend(t, Codec, Append)
+}
+
+func TestAppendAllocs(t *testing.T) {
+	objtests.TestAppendAllocs(t, Append)
+}
+
+func TestEncoderAllocs(t *testing.T) {
+	objtests.TestEncoderAllocs(t, Codec)
+}
+
+func TestTypedEncoderAllocs(t *testing.T) {
+	objtests.TestTypedEncoderAllocs(t, Codec)
+}
+
+func BenchmarkEncoderAllocs(b *testing.B) {
+	objtests.BenchmarkEncoderAllocs(b, Codec)
+}
+
 func TestMajorType(t *testing.T) {
 	m, b := majorType(majorByte(majorType7, 24))
 
@@ -25,3 +108,201 @@ func TestMajorType(t *testing.T) {
 		t.Error("bad info value:", b)
 	}
 }
+
+func TestStandardTags(t *testing.T) {
+	tests := []struct {
+		b []byte
+		v interface{}
+	}{
+		{ // tag 2, 2^64
+			b: []byte{0xc2, 0x49, 0x01, 0, 0, 0, 0, 0, 0, 0, 0},
+			v: new(big.Int).Lsh(big.NewInt(1), 64),
+		},
+		{ // tag 3, -1 - 255
+			b: []byte{0xc3, 0x41, 0xff},
+			v: big.NewInt(-256),
+		},
+		{ // tag 32
+			b: append([]byte{0xd8, 0x20, 0x72}, "http://example.com"...),
+			v: &url.URL{Scheme: "http", Host: "example.com"},
+		},
+		{ // tag 1
+			b: []byte{0xc1, 0x1a, 0x51, 0x4b, 0x67, 0xb0},
+			v: time.Unix(1363896240, 0),
+		},
+		{ // unknown tags are decoded to the type of their content
+			b: []byte{0xd8, 0x64, 0x18, 0x2a},
+			v: uint64(42),
+		},
+		{ // self-described CBOR
+			b: []byte{0xd9, 0xd9, 0xf7, 0xc3, 0x41, 0x00},
+			v: big.NewInt(-1),
+		},
+	}
+
+	for _, test := range tests {
+		var v interface{}
+
+		if err := Unmarshal(test.b, &v); err != nil {
+			t.Errorf("%x: %v", test.b, err)
+			continue
+		}
+
+		if tm, ok := v.(time.Time); ok {
+			if !tm.Equal(test.v.(time.Time)) {
+				t.Errorf("%x: bad time: %v", test.b, tm)
+			}
+		} else if !reflect.DeepEqual(v, test.v) {
+			t.Errorf("%x: bad value: %#v", test.b, v)
+		}
+	}
+}
+
+func TestBignumDecode(t *testing.T) {
+	var n big.Int
+	var i int64
+
+	if err := Unmarshal([]byte{0xc2, 0x42, 0x01, 0x00}, &n); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Unmarshal([]byte{0xc3, 0x42, 0x01, 0x00}, &i); err != nil {
+		t.Fatal(err)
+	}
+
+	if n.Int64() != 256 || i != -257 {
+		t.Errorf("bad bignums: %v %v", &n, i)
+	}
+
+	if err := Unmarshal([]byte{0xc2, 0x01}, &n); err == nil {
+		t.Error("expected an error decoding a bignum which is not a byte string")
+	}
+}
+
+type point struct{ x, y int }
+
+func TestRegisterTag(t *testing.T) {
+	RegisterTag(40000, reflect.TypeOf(point{}), objconv.Adapter{
+		Encode: func(e objconv.Encoder, v reflect.Value) error {
+			p := v.Interface().(point)
+			return e.Encode([]int{p.x, p.y})
+		},
+		Decode: func(d objconv.Decoder, v reflect.Value) error {
+			var a []int
+			if err := d.Decode(&a); err != nil {
+				return err
+			}
+			if len(a) != 2 {
+				return errors.New("points must have two coordinates")
+			}
+			v.Set(reflect.ValueOf(point{a[0], a[1]}))
+			return nil
+		},
+	})
+
+	b, err := Marshal(map[string]interface{}{"p": point{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []byte{0xa1, 0x61, 'p', 0xd9, 0x9c, 0x40, 0x82, 0x01, 0x02}; !reflect.DeepEqual(b, expected) {
+		t.Errorf("bad encoding: %x", b)
+	}
+
+	var v map[string]interface{}
+
+	if err := Unmarshal(b, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if p, ok := v["p"].(point); !ok || p != (point{1, 2}) {
+		t.Errorf("bad decoded value: %#v", v["p"])
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a standard tag did not panic")
+		}
+	}()
+	RegisterTag(tagTimestamp, reflect.TypeOf(point{}), objconv.Adapter{})
+}
+
+func TestConformance(t *testing.T) {
+	objtests.TestConformance(t, Codec)
+}
+
+func TestCanonicalEmitter(t *testing.T) {
+	tests := []struct {
+		v interface{}
+		b []byte
+	}{
+		{
+			v: map[interface{}]interface{}{"aa": 1, "b": 2, 100: 3, -1: 4, 10: 5},
+			b: []byte{0xa5, 0x0a, 0x05, 0x18, 0x64, 0x03, 0x20, 0x04, 0x61, 'b', 0x02, 0x62, 'a', 'a', 0x01},
+		},
+		{
+			v: struct{ B, A map[string]int }{B: map[string]int{"y": 1, "x": 2}},
+			b: []byte{0xa2, 0x61, 'A', 0xa0, 0x61, 'B', 0xa2, 0x61, 'x', 0x02, 0x61, 'y', 0x01},
+		},
+		{
+			v: []float64{1.5, 100000, 0.1, 5.960464477539063e-08, math.NaN(), math.Inf(-1)},
+			b: []byte{
+				0x86,
+				0xf9, 0x3e, 0x00,
+				0xfa, 0x47, 0xc3, 0x50, 0x00,
+				0xfb, 0x3f, 0xb9, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9a,
+				0xf9, 0x00, 0x01,
+				0xf9, 0x7e, 0x00,
+				0xf9, 0xfc, 0x00,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		b := &bytes.Buffer{}
+
+		if err := (&objconv.Encoder{Emitter: NewEmitter(b), Canonical: true}).Encode(test.v); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !bytes.Equal(b.Bytes(), test.b) {
+			t.Errorf("%#v: bad encoding:\n%x\n%x", test.v, b.Bytes(), test.b)
+		}
+	}
+}
+
+func TestCanonicalEmitterIndefiniteLength(t *testing.T) {
+	b := &bytes.Buffer{}
+	e := NewCanonicalEmitter(b)
+
+	for _, f := range []func() error{
+		func() error { return e.EmitArrayBegin(-1) },
+		func() error { return e.EmitMapBegin(-1) },
+		func() error { return e.EmitMapEnd() },
+		func() error { return e.EmitArrayNext() },
+		func() error { return e.EmitArrayBegin(-1) },
+		func() error { return e.EmitArrayEnd() },
+		func() error { return e.EmitArrayEnd() },
+		e.Flush,
+	} {
+		if err := f(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if x := []byte{0x82, 0xa0, 0x80}; !bytes.Equal(b.Bytes(), x) {
+		t.Errorf("bad encoding: %x != %x", b.Bytes(), x)
+	}
+}
+
+func TestTruncatedString(t *testing.T) {
+	var v interface{}
+
+	// The declared length doesn't cause the parser to allocate 4 GB.
+	b := []byte{0x7a, 0xff, 0xff, 0xff, 0xff, 'h', 'e', 'l', 'l', 'o'}
+
+	if err := Unmarshal(b, &v); err == nil {
+		t.Errorf("expected an error decoding a truncated string but got %#v", v)
+	}
+}
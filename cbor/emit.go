@@ -3,9 +3,7 @@ package cbor
 import (
 	"io"
 	"math"
-	"reflect"
 	"time"
-	"unsafe"
 
 	"github.com/segmentio/objconv/objutil"
 )
@@ -16,23 +14,60 @@ type Emitter struct {
 	w io.Writer
 	b [16]byte
 
+	// This array is used as a buffer to format time and duration values
+	// without having to allocate dynamic memory.
+	a [64]byte
+
 	// This stack is used to keep track of the array map lengths being parsed.
 	// The sback array is the initial backend array for the stack.
 	stack []int
 	sback [16]int
+
+	// The emitter writes to this buffer, which gets flushed to the underlying
+	// writer when the encoder has completed writing a value.
+	buf objutil.BufferedWriter
+
+	// Cache of the last formatted time value, batches of records often have
+	// many identical timestamps.
+	times objutil.TimeCache
 }
 
 func NewEmitter(w io.Writer) *Emitter {
-	e := &Emitter{w: w}
+	e := &Emitter{}
 	e.stack = e.sback[:0]
+	e.Reset(w)
 	return e
 }
 
 func (e *Emitter) Reset(w io.Writer) {
-	e.w = w
+	e.buf.Reset(w)
+	e.w = &e.buf
 	e.stack = e.stack[:0]
 }
 
+// Flush writes the buffered output of the emitter to its underlying writer.
+func (e *Emitter) Flush() error {
+	return e.buf.Flush()
+}
+
+// EmitRaw satisfies the objconv.RawEmitter interface.
+func (e *Emitter) EmitRaw(b []byte) (err error) {
+	_, err = e.w.Write(b)
+	return
+}
+
+// TransientEmitter satisfies the objconv.transientEmitter interface, values
+// are written to the output when they are emitted.
+func (e *Emitter) TransientEmitter() bool {
+	return true
+}
+
+// EmitHeartbeat satisfies the objconv.HeartbeatEmitter interface.
+func (e *Emitter) EmitHeartbeat() (err error) {
+	_, err = e.w.Write(heartbeat[:])
+	return
+}
+
 func (e *Emitter) EmitNil() (err error) {
 	e.b[0] = majorByte(majorType7, svNull)
 	_, err = e.w.Write(e.b[:1])
@@ -81,13 +116,9 @@ func (e *Emitter) EmitString(v string) (err error) {
 	if err = e.emitUint(majorType3, uint64(len(v))); err != nil {
 		return
 	}
-	s := *(*reflect.StringHeader)(unsafe.Pointer(&v))
-	b := reflect.SliceHeader{
-		Data: s.Data,
-		Len:  s.Len,
-		Cap:  s.Len,
+	if len(v) != 0 {
+		_, err = writeString(e.w, v)
 	}
-	_, err = e.w.Write(*((*[]byte)(unsafe.Pointer(&b))))
 	return
 }
 
@@ -108,19 +139,11 @@ func (e *Emitter) EmitTime(v time.Time) (err error) {
 		return
 	}
 
-	var a [64]byte
-	var b = v.AppendFormat(a[:0], time.RFC3339Nano)
-
-	if err = e.emitUint(majorType3, uint64(len(b))); err != nil {
-		return
-	}
-
-	_, err = e.w.Write(append(e.b[:0], b...))
-	return
+	return e.emitText(e.times.AppendTime(e.a[:0], v))
 }
 
 func (e *Emitter) EmitDuration(v time.Duration) (err error) {
-	return e.EmitString(string(objutil.AppendDuration(e.b[:0], v)))
+	return e.emitText(objutil.AppendDuration(e.a[:0], v))
 }
 
 func (e *Emitter) EmitError(v error) (err error) {
@@ -187,6 +210,14 @@ func (e *Emitter) EmitMapNext() (err error) {
 	return
 }
 
+func (e *Emitter) emitText(b []byte) (err error) {
+	if err = e.emitUint(majorType3, uint64(len(b))); err != nil {
+		return
+	}
+	_, err = e.w.Write(b)
+	return
+}
+
 func (e *Emitter) emitUint(m byte, v uint64) (err error) {
 	var n int
 
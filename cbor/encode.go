@@ -32,6 +32,22 @@ func Marshal(v interface{}) (b []byte, err error) {
 	return
 }
 
+// Append appends the CBOR representation of v to dst and returns the extended
+// slice, dst is returned unchanged if an error occurs. Unlike Marshal, it
+// doesn't allocate memory when dst has enough capacity.
+func Append(dst []byte, v interface{}) (b []byte, err error) {
+	m := marshalerPool.Get().(*marshaler)
+	m.b.Truncate(0)
+	b = dst
+
+	if err = (objconv.Encoder{Emitter: m}).Encode(v); err == nil {
+		b = append(dst, m.b.Bytes()...)
+	}
+
+	marshalerPool.Put(m)
+	return
+}
+
 var marshalerPool = sync.Pool{
 	New: func() interface{} { return newMarshaler() },
 }
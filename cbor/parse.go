@@ -2,13 +2,13 @@ package cbor
 
 import (
 	"bytes"
-	"errors"
-	"fmt"
+	"context"
 	"io"
 	"math"
 	"time"
 
 	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
 )
 
 type Parser struct {
@@ -21,11 +21,22 @@ type Parser struct {
 	// Last tag loaded while parsing the type of the next available item.
 	tag uint64
 	typ objconv.Type
+	pos int64 // offset of the tagged item
 
 	// This stack is used to keep track of the array map lengths being parsed.
 	// The sback array is the initial backend array for the stack.
 	stack []int
 	sback [16]int
+
+	// Set when the top-level value is an array of indefinite length, which is
+	// how stream encoders write streams of values.
+	stream bool
+
+	// offset in the input of the first byte in b
+	off int64
+
+	// maximum length of strings, zero means no limit
+	maxStringLen int
 }
 
 func NewParser(r io.Reader) *Parser {
@@ -39,27 +50,80 @@ func (p *Parser) Reset(r io.Reader) {
 	p.r = r
 	p.i = 0
 	p.j = 0
+	p.off = 0
 	p.tag = noTag
 	p.stack = p.stack[:0]
+	p.stream = false
+}
+
+// ParseContext satisfies the objconv.ParserV2 interface, the reads from the
+// underlying reader are aborted when ctx is canceled.
+func (p *Parser) ParseContext(ctx context.Context) error {
+	p.r = objutil.WithContext(p.r, ctx)
+	return ctx.Err()
+}
+
+// SetMaxStringLen satisfies the objconv.LimitParser interface, the lengths of
+// strings are verified before they are loaded.
+func (p *Parser) SetMaxStringLen(n int) {
+	p.maxStringLen = n
+}
+
+func (p *Parser) checkStringLen(n uint64) error {
+	if p.maxStringLen > 0 && n > uint64(p.maxStringLen) {
+		return objutil.Errorf(objutil.ErrLimit, "objconv/cbor: the input has a string of %d bytes, which exceeds the limit of %d bytes", n, p.maxStringLen)
+	}
+	return nil
+}
+
+// ParseLengthHint satisfies the objconv.ParserV2 interface, the parser has no
+// hints.
+func (p *Parser) ParseLengthHint() int {
+	return -1
+}
+
+// Offset returns the number of bytes consumed by the parser, tags loaded by
+// ParseType are not counted until the item they apply to has been parsed.
+func (p *Parser) Offset() int64 {
+	if p.tag != noTag {
+		return p.pos
+	}
+	return p.off + int64(p.i)
 }
 
+// Buffered satisfies the objconv.BufferedParser interface.
 func (p *Parser) Buffered() io.Reader {
 	return bytes.NewReader(p.b[p.i:p.j])
 }
 
+// ParseRaw satisfies the objconv.RawParser interface, the value is re-encoded
+// by a CBOR emitter.
+func (p *Parser) ParseRaw() ([]byte, error) {
+	b := &bytes.Buffer{}
+	err := objconv.Transcode(NewEmitter(b), p)
+	return b.Bytes(), err
+}
+
 func (p *Parser) ParseType() (typ objconv.Type, err error) {
 	if p.tag != noTag {
 		typ = p.typ
 		return
 	}
 
-	var t = false
 	var s []byte
 
+	if p.streaming() {
+		if err = p.skipHeartbeats(); err != nil {
+			return
+		}
+	}
+
 	if s, err = p.peek(1); err != nil {
 		return
 	}
 
+	p.pos = p.off + int64(p.i)
+
 	for {
 		switch m, b := majorType(s[0]); m {
 		case majorType0:
@@ -82,25 +146,26 @@ func (p *Parser) ParseType() (typ objconv.Type, err error) {
 
 		case majorType6:
 			var indef bool
-			if t {
-				err = errors.New("objconv/cbor: multiple tags found for a single item")
+			if p.tag != noTag {
+				err = objutil.Errorf(objutil.ErrSyntax, "objconv/cbor: multiple tags found for a single item")
 				return
 			}
 			if p.tag, indef, err = p.parseUint(); err != nil {
 				return
 			}
 			if indef {
-				err = errors.New("objconv/cbor: invalid indefinite length for major type 6")
+				err = objutil.Errorf(objutil.ErrSyntax, "objconv/cbor: invalid indefinite length for major type 6")
 				return
 			}
-			switch p.tag {
-			case tagDateTime, tagTimestamp:
-				typ = objconv.Time
-			default: // unsupported tag, just fallback to use the base type
-				t = true
-				continue
+			if p.tag == tagSelfDescribe { // no semantics, only marks CBOR data
+				p.tag = noTag
+			}
+			// The type of tagged items is the type of their content, unless
+			// the tag is one of the standard tags that the parser converts.
+			if s, err = p.peek(1); err != nil {
+				return
 			}
-			p.typ = typ
+			continue
 
 		default:
 			switch b {
@@ -117,12 +182,31 @@ func (p *Parser) ParseType() (typ objconv.Type, err error) {
 				typ = objconv.Nil // ignore unsupported extensions
 
 			default:
-				err = fmt.Errorf("objconv/cbor: unexpected value in major type 7: %d", b)
+				err = objutil.Errorf(objutil.ErrSyntax, "objconv/cbor: unexpected value in major type 7: %d", b)
+				return
 			}
 		}
 
+		break
+	}
+
+	switch p.tag {
+	case noTag:
 		return
+
+	case tagDateTime, tagTimestamp:
+		typ = objconv.Time
+
+	case tagPosBignum, tagNegBignum:
+		if typ != objconv.Bytes {
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/cbor: the content of bignums must be byte strings, found %s", typ)
+			return
+		}
+		typ = objconv.String // bignums are exposed in their decimal representation
 	}
+
+	p.typ = typ
+	return
 }
 
 func (p *Parser) ParseNil() (err error) {
@@ -152,7 +236,7 @@ func (p *Parser) ParseInt() (v int64, err error) {
 	}
 
 	if indef {
-		err = errors.New("objconv/cbor: invalid indefinite length for major type 1")
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/cbor: invalid indefinite length for major type 1")
 		return
 	}
 
@@ -169,7 +253,7 @@ func (p *Parser) ParseUint() (v uint64, err error) {
 	}
 
 	if indef {
-		err = errors.New("objconv/cbor: invalid indefinite length for major type 0")
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/cbor: invalid indefinite length for major type 0")
 		return
 	}
 
@@ -214,6 +298,9 @@ func (p *Parser) ParseFloat() (v float64, err error) {
 }
 
 func (p *Parser) ParseString() (v []byte, err error) {
+	if p.tag == tagPosBignum || p.tag == tagNegBignum {
+		return p.parseBignum()
+	}
 	if v, err = p.parseBytes(majorType3); err != nil {
 		return
 	}
@@ -251,7 +338,7 @@ func (p *Parser) ParseTime() (v time.Time, err error) {
 				return
 			}
 			if u > int64Max {
-				err = fmt.Errorf("objconv/cbor: cannot decode a time value because %d cannot be represented by a signed 64bits integer", u)
+				err = objutil.Errorf(objutil.ErrRange, "objconv/cbor: cannot decode a time value because %d cannot be represented by a signed 64bits integer", u)
 				return
 			}
 			v = time.Unix(int64(u), 0)
@@ -273,7 +360,7 @@ func (p *Parser) ParseTime() (v time.Time, err error) {
 			v = time.Unix(s, ns)
 
 		default:
-			err = fmt.Errorf("objconv/cbor: cannot decode time value from an item with major type %d", m)
+			err = objutil.Errorf(objutil.ErrType, "objconv/cbor: cannot decode time value from an item with major type %d", m)
 			return
 		}
 	}
@@ -302,19 +389,28 @@ func (p *Parser) ParseArrayBegin() (n int, err error) {
 		n = -1
 	} else {
 		if u > intMax {
-			err = fmt.Errorf("objconv/cbor: array of length %d is greater than what an int can represent", u)
+			err = objutil.Errorf(objutil.ErrLimit, "objconv/cbor: array of length %d is greater than what an int can represent", u)
 			return
 		}
 		n = int(u)
 	}
 
+	if len(p.stack) == 0 {
+		p.stream = n < 0
+	}
+
 	p.stack = append(p.stack, n)
 	p.tag = noTag
 	return
 }
 
 func (p *Parser) ParseArrayEnd(n int) (err error) {
-	p.stack = p.stack[:len(p.stack)-1]
+	if err = p.parseBreak(); err != nil {
+		return
+	}
+	if p.stack = p.stack[:len(p.stack)-1]; len(p.stack) == 0 {
+		p.stream = false
+	}
 	return
 }
 
@@ -322,6 +418,12 @@ func (p *Parser) ParseArrayNext(n int) (err error) {
 	if p.stack[len(p.stack)-1] < 0 {
 		var s []byte
 
+		if p.streaming() {
+			if err = p.skipHeartbeats(); err != nil {
+				return
+			}
+		}
+
 		if s, err = p.peek(1); err != nil {
 			return
 		}
@@ -345,7 +447,7 @@ func (p *Parser) ParseMapBegin() (n int, err error) {
 		n = -1
 	} else {
 		if u > intMax {
-			err = fmt.Errorf("objconv/cbor: map of length %d is greater than what an int can represent", u)
+			err = objutil.Errorf(objutil.ErrLimit, "objconv/cbor: map of length %d is greater than what an int can represent", u)
 			return
 		}
 		n = int(u)
@@ -357,10 +459,33 @@ func (p *Parser) ParseMapBegin() (n int, err error) {
 }
 
 func (p *Parser) ParseMapEnd(n int) (err error) {
+	if err = p.parseBreak(); err != nil {
+		return
+	}
 	p.stack = p.stack[:len(p.stack)-1]
 	return
 }
 
+// parseBreak consumes the break code which terminates arrays and maps of
+// indefinite length.
+func (p *Parser) parseBreak() (err error) {
+	if p.stack[len(p.stack)-1] < 0 {
+		var s []byte
+
+		if s, err = p.peek(1); err != nil {
+			return
+		}
+
+		if s[0] != 0xFF {
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/cbor: expected a break code at the end of a value of indefinite length")
+			return
+		}
+
+		p.i++
+	}
+	return
+}
+
 func (p *Parser) ParseMapValue(n int) (err error) {
 	return
 }
@@ -380,6 +505,30 @@ func (p *Parser) ParseMapNext(n int) (err error) {
 	return
 }
 
+// streaming returns true if the parser is between the top-level values of the
+// input, or between the elements of a stream. Heartbeats are only written at
+// those positions, elsewhere the self-described undefined values that they are
+// made of are regular values.
+func (p *Parser) streaming() bool {
+	return len(p.stack) == 0 || (len(p.stack) == 1 && p.stream)
+}
+
+func (p *Parser) skipHeartbeats() (err error) {
+	var s []byte
+
+	for {
+		if s, err = p.peek(1); err != nil || s[0] != heartbeat[0] {
+			return
+		}
+
+		if s, err = p.peek(len(heartbeat)); err != nil || !bytes.Equal(s, heartbeat[:]) {
+			return
+		}
+
+		p.i += len(heartbeat)
+	}
+}
+
 func (p *Parser) parseUint() (v uint64, indef bool, err error) {
 	var s []byte
 	var n int
@@ -442,7 +591,10 @@ func (p *Parser) parseBytes(m byte) (v []byte, err error) {
 
 	if !indef {
 		if u > intMax {
-			err = fmt.Errorf("objconv/cbor: byte string of length %d is greater than what an int can represent", u)
+			err = objutil.Errorf(objutil.ErrLimit, "objconv/cbor: byte string of length %d is greater than what an int can represent", u)
+			return
+		}
+		if err = p.checkStringLen(u); err != nil {
 			return
 		}
 		return p.load(int(u))
@@ -458,7 +610,11 @@ func (p *Parser) parseBytes(m byte) (v []byte, err error) {
 		}
 
 		if n := uint64(len(s)); u > (intMax - n) {
-			err = fmt.Errorf("objconv/cbor: byte string of length %d is greater than what an int can represent", u+n)
+			err = objutil.Errorf(objutil.ErrLimit, "objconv/cbor: byte string of length %d is greater than what an int can represent", u+n)
+			return
+		}
+
+		if err = p.checkStringLen(uint64(len(p.s)) + u); err != nil {
 			return
 		}
 
@@ -484,7 +640,7 @@ func (p *Parser) parseType7() (b byte, err error) {
 			return
 		}
 		if b = s[1]; b < 32 {
-			err = fmt.Errorf("objconv/cbor: invalid extended simple value in major type 7: %d", b)
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/cbor: invalid extended simple value in major type 7: %d", b)
 			return
 		}
 		p.i += 2
@@ -494,14 +650,7 @@ func (p *Parser) parseType7() (b byte, err error) {
 }
 
 func (p *Parser) load(n int) (b []byte, err error) {
-	i := len(p.s)
-	j := i + n
-
-	if cap(p.s) < j {
-		p.s = make([]byte, j, align(j, 1024))
-	} else {
-		p.s = p.s[:j]
-	}
+	j := len(p.s) + n
 
 	if p.i != p.j {
 		n1 := n
@@ -511,18 +660,21 @@ func (p *Parser) load(n int) (b []byte, err error) {
 			n1 = n2
 		}
 
-		copy(p.s[i:], p.b[p.i:p.i+n1])
+		p.s = append(p.s, p.b[p.i:p.i+n1]...)
 
 		if p.i += n1; p.i == p.j {
+			p.off += int64(p.j)
 			p.i = 0
 			p.j = 0
 		}
-
-		i += n1
 	}
 
-	if i != j {
-		if _, err = io.ReadFull(p.r, p.s[i:]); err != nil {
+	// The length comes from the input, the buffer is grown as the bytes are
+	// read instead of being allocated upfront.
+	if i := len(p.s); i != j {
+		p.s, err = objutil.ReadFull(p.s, p.r, j-i)
+		p.off += int64(len(p.s) - i)
+		if err != nil {
 			return
 		}
 	}
@@ -544,6 +696,7 @@ func (p *Parser) peek(n int) (b []byte, err error) {
 func (p *Parser) fill() (err error) {
 	n := p.j - p.i
 	copy(p.b[:], p.b[p.i:p.j])
+	p.off += int64(p.i)
 	p.i = 0
 	p.j = n
 
@@ -0,0 +1,12 @@
+//go:build purego
+// +build purego
+
+package cbor
+
+import "io"
+
+// writeString writes s to w, the conversion to a byte slice is avoided when w
+// implements io.StringWriter.
+func writeString(w io.Writer, s string) (int, error) {
+	return io.WriteString(w, s)
+}
@@ -0,0 +1,105 @@
+package cbor
+
+import (
+	"math/big"
+	"net/url"
+	"reflect"
+	"sync"
+
+	"github.com/segmentio/objconv"
+)
+
+// RegisterTag associates the semantic tag with the Go type typ.
+//
+// The adapter encodes and decodes the content of the tagged items, it is
+// installed on the objconv package for typ, so it is used by the other formats
+// as well. When the emitter is a CBOR emitter the encoded values are preceded
+// by the tag, and items with the tag are decoded to values of type typ when
+// the destination is an empty interface.
+//
+// The parser handles the standard tags for date and times (0 and 1) and
+// bignums (2 and 3), which cannot be registered. Bignums are decoded as
+// decimal strings, or *big.Int values for empty interfaces, and URIs (tag 32)
+// are decoded to *url.URL values for empty interfaces.
+//
+// The function panics if the tag is one of the tags that the parser handles
+// or if one of the adapter functions is nil. A typical use case is to call it
+// during the initialization phase of a package.
+func RegisterTag(tag uint64, typ reflect.Type, adapter objconv.Adapter) {
+	switch tag {
+	case tagDateTime, tagTimestamp, tagPosBignum, tagNegBignum, tagSelfDescribe:
+		panic("objconv/cbor: the standard tags for times and bignums cannot be registered")
+	}
+
+	encode := adapter.Encode
+
+	objconv.Install(typ, objconv.Adapter{
+		Encode: func(e objconv.Encoder, v reflect.Value) error {
+			if t, ok := e.Emitter.(interface{ EmitTag(uint64) error }); ok {
+				if err := t.EmitTag(tag); err != nil {
+					return err
+				}
+			}
+			return encode(e, v)
+		},
+		Decode: adapter.Decode,
+	})
+
+	tagMutex.Lock()
+	tagTypes[tag] = typ
+	tagMutex.Unlock()
+}
+
+var (
+	tagMutex sync.RWMutex
+	tagTypes = map[uint64]reflect.Type{
+		tagPosBignum: reflect.TypeOf((*big.Int)(nil)),
+		tagNegBignum: reflect.TypeOf((*big.Int)(nil)),
+		tagURI:       reflect.TypeOf((*url.URL)(nil)),
+	}
+)
+
+// EmitTag writes a tag, which applies to the next value written to the
+// emitter.
+func (e *Emitter) EmitTag(tag uint64) error {
+	return e.emitUint(majorType6, tag)
+}
+
+// Tag returns the tag of the next item, it is only valid after a call to
+// ParseType. The boolean is false if the item has no tag.
+func (p *Parser) Tag() (uint64, bool) {
+	return p.tag, p.tag != noTag
+}
+
+// ValueType satisfies the objconv.ValueTypeParser interface, the Go types of
+// tagged items are those registered with RegisterTag.
+func (p *Parser) ValueType() reflect.Type {
+	if p.tag == noTag {
+		return nil
+	}
+	tagMutex.RLock()
+	t := tagTypes[p.tag]
+	tagMutex.RUnlock()
+	return t
+}
+
+// parseBignum parses the content of a tagged bignum, and returns its decimal
+// representation.
+func (p *Parser) parseBignum() ([]byte, error) {
+	neg := p.tag == tagNegBignum
+
+	b, err := p.parseBytes(majorType2)
+	if err != nil {
+		return nil, err
+	}
+
+	n := new(big.Int).SetBytes(b)
+
+	if neg { // the value is -1 - n
+		n.Neg(n.Add(n, big.NewInt(1)))
+	}
+
+	p.tag = noTag
+	p.s = n.Append(p.s[:0], 10)
+	return p.s, nil
+}
@@ -0,0 +1,15 @@
+//go:build !purego
+// +build !purego
+
+package cbor
+
+import (
+	"io"
+	"unsafe"
+)
+
+// writeString writes s to w without copying it to a byte slice, w must not
+// modify the content of the slice that it receives.
+func writeString(w io.Writer, s string) (int, error) {
+	return w.Write(unsafe.Slice(unsafe.StringData(s), len(s)))
+}
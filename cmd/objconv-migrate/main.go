@@ -0,0 +1,97 @@
+// Command objconv-migrate rewrites a stream of documents read from stdin to a
+// new schema, renaming fields and converting their values, and writes the
+// result to stdout.
+//
+//	objconv-migrate -f json -rename mail=email -rename address.zip=postal_code -type age=int < v1.json > v2.json
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/segmentio/objconv"
+	_ "github.com/segmentio/objconv/bson"
+	_ "github.com/segmentio/objconv/cbor"
+	_ "github.com/segmentio/objconv/json"
+	"github.com/segmentio/objconv/migrate"
+	_ "github.com/segmentio/objconv/msgpack"
+	_ "github.com/segmentio/objconv/resp"
+	_ "github.com/segmentio/objconv/toml"
+	_ "github.com/segmentio/objconv/yaml"
+)
+
+var types = map[string]reflect.Type{
+	"bool":   reflect.TypeOf(false),
+	"int":    reflect.TypeOf(int64(0)),
+	"uint":   reflect.TypeOf(uint64(0)),
+	"float":  reflect.TypeOf(float64(0)),
+	"string": reflect.TypeOf(""),
+}
+
+// pairs is a flag value collecting repeated key=value arguments.
+type pairs map[string]string
+
+func (p pairs) String() string {
+	s := make([]string, 0, len(p))
+	for k, v := range p {
+		s = append(s, k+"="+v)
+	}
+	return strings.Join(s, ",")
+}
+
+func (p pairs) Set(s string) error {
+	i := strings.IndexByte(s, '=')
+	if i < 0 {
+		return fmt.Errorf("expected key=value but got %q", s)
+	}
+	p[s[:i]] = s[i+1:]
+	return nil
+}
+
+func main() {
+	var r = bufio.NewReader(os.Stdin)
+	var w = bufio.NewWriter(os.Stdout)
+	var format string
+	var renames = pairs{}
+	var conversions = pairs{}
+
+	flag.StringVar(&format, "f", "json", "The format of the documents")
+	flag.Var(renames, "rename", "Renames a field, as old.path=new_name (may be repeated)")
+	flag.Var(conversions, "type", "Converts the values of a field, as old.path=bool|int|uint|float|string (may be repeated)")
+	flag.Parse()
+
+	if err := run(w, r, format, renames, conversions); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	w.Flush()
+}
+
+func run(w *bufio.Writer, r *bufio.Reader, format string, renames pairs, conversions pairs) error {
+	codec, ok := objconv.Lookup(format)
+	if !ok {
+		return fmt.Errorf("unknown format: %s", format)
+	}
+
+	m, err := migrate.New(nil, nil, renames)
+	if err != nil {
+		return err
+	}
+
+	m.Types = make(map[string]reflect.Type, len(conversions))
+
+	for path, name := range conversions {
+		t, ok := types[name]
+		if !ok {
+			return fmt.Errorf("unknown type for field %s: %s", path, name)
+		}
+		m.Types[path] = t
+	}
+
+	return m.Migrate(w, r, codec)
+}
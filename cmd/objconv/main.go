@@ -11,10 +11,12 @@ import (
 	"strings"
 
 	"github.com/segmentio/objconv"
+	_ "github.com/segmentio/objconv/bson"
 	_ "github.com/segmentio/objconv/cbor"
 	_ "github.com/segmentio/objconv/json"
 	_ "github.com/segmentio/objconv/msgpack"
 	_ "github.com/segmentio/objconv/resp"
+	_ "github.com/segmentio/objconv/toml"
 	_ "github.com/segmentio/objconv/yaml"
 )
 
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"reflect"
+	"strconv"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+type fieldKind int
+
+const (
+	otherKind fieldKind = iota
+	boolKind
+	stringKind
+	bytesKind
+	intKind
+	uintKind
+	floatKind
+	timeKind
+	durationKind
+)
+
+type structType struct {
+	name   string
+	fields []structField
+}
+
+type structField struct {
+	ident     string // name of the field in the struct
+	name      string // name of the field in encoded documents
+	typ       string // name of the type of basic fields
+	kind      fieldKind
+	bits      int
+	omitempty bool
+	omitzero  bool
+}
+
+var basicTypes = map[string]struct {
+	kind fieldKind
+	bits int
+}{
+	"bool":    {boolKind, 0},
+	"string":  {stringKind, 0},
+	"int":     {intKind, 0},
+	"int8":    {intKind, 8},
+	"int16":   {intKind, 16},
+	"int32":   {intKind, 32},
+	"int64":   {intKind, 64},
+	"uint":    {uintKind, 0},
+	"uint8":   {uintKind, 8},
+	"uint16":  {uintKind, 16},
+	"uint32":  {uintKind, 32},
+	"uint64":  {uintKind, 64},
+	"float32": {floatKind, 32},
+	"float64": {floatKind, 64},
+}
+
+func makeStructType(ts *typeSpec) (*structType, error) {
+	name := ts.spec.Name.Name
+
+	if ts.spec.TypeParams != nil {
+		return nil, fmt.Errorf("%s: generic types are not supported", name)
+	}
+
+	st, ok := ts.spec.Type.(*ast.StructType)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct type", name)
+	}
+
+	s := &structType{name: name}
+
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			continue // embedded fields are not serialized by objconv
+		}
+
+		switch f.Type.(type) {
+		case *ast.FuncType, *ast.ChanType:
+			continue
+		}
+
+		var tag objutil.Tag
+
+		if f.Tag != nil {
+			raw, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: malformed struct tag %s", name, f.Tag.Value)
+			}
+			if t := reflect.StructTag(raw).Get("objconv"); len(t) != 0 {
+				tag = objutil.ParseTag(t)
+			} else {
+				tag = objutil.ParseTagJSON(reflect.StructTag(raw).Get("json"))
+			}
+		}
+
+		if tag.Inline {
+			return nil, fmt.Errorf("%s: inline fields are not supported", name)
+		}
+
+		if tag.Encrypt {
+			return nil, fmt.Errorf("%s: encrypted fields are not supported", name)
+		}
+
+		kind, typ, bits := kindOf(f.Type, ts.file)
+
+		for _, id := range f.Names {
+			if !id.IsExported() && !tag.Export {
+				continue
+			}
+
+			field := structField{
+				ident:     id.Name,
+				name:      id.Name,
+				typ:       typ,
+				kind:      kind,
+				bits:      bits,
+				omitempty: tag.Omitempty,
+				omitzero:  tag.Omitzero,
+			}
+
+			if len(tag.Name) != 0 {
+				field.name = tag.Name
+			}
+
+			switch {
+			case field.name == "-":
+			case tag.Chardata:
+				field.name = "#text"
+			case tag.Attr:
+				field.name = "@" + field.name
+			}
+
+			if field.name != "-" {
+				s.fields = append(s.fields, field)
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// kindOf returns the kind of fields of type t, declared in file.
+func kindOf(t ast.Expr, file *ast.File) (fieldKind, string, int) {
+	switch x := t.(type) {
+	case *ast.Ident:
+		if b, ok := basicTypes[x.Name]; ok {
+			return b.kind, x.Name, b.bits
+		}
+
+	case *ast.ArrayType:
+		if id, ok := x.Elt.(*ast.Ident); ok && x.Len == nil && (id.Name == "byte" || id.Name == "uint8") {
+			return bytesKind, "", 0
+		}
+
+	case *ast.SelectorExpr:
+		if id, ok := x.X.(*ast.Ident); ok && importPath(file, id.Name) == "time" {
+			switch x.Sel.Name {
+			case "Time":
+				return timeKind, "", 0
+			case "Duration":
+				return durationKind, "", 0
+			}
+		}
+	}
+	return otherKind, "", 0
+}
+
+// importPath returns the path of the package imported with name in file.
+func importPath(file *ast.File, name string) string {
+	for _, imp := range file.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+
+		if imp.Name != nil {
+			if imp.Name.Name == name {
+				return p
+			}
+		} else if p == name {
+			return p
+		}
+	}
+	return ""
+}
+
+// generator accumulates the source of the generated file.
+type generator struct {
+	bytes.Buffer
+	imports map[string]bool
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.Buffer, format, args...)
+}
+
+func generate(pkg string, structs []*structType) ([]byte, error) {
+	g := &generator{imports: map[string]bool{"github.com/segmentio/objconv": true}}
+
+	for _, s := range structs {
+		g.encodeValue(s)
+		g.decodeValue(s)
+	}
+
+	body := g.Bytes()
+	b := &bytes.Buffer{}
+
+	fmt.Fprintf(b, "// Code generated by objconvgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	if g.imports["reflect"] {
+		fmt.Fprintf(b, "\t\"reflect\"\n\n")
+	}
+	for _, p := range []string{"github.com/segmentio/objconv", "github.com/segmentio/objconv/objutil"} {
+		if g.imports[p] {
+			fmt.Fprintf(b, "\t%q\n", p)
+		}
+	}
+	fmt.Fprintf(b, ")\n")
+	b.Write(body)
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting the generated code: %v", err)
+	}
+	return src, nil
+}
+
+func (g *generator) encodeValue(s *structType) {
+	g.printf("\n// EncodeValue satisfies the objconv.ValueEncoder interface.\n")
+	g.printf("func (v %s) EncodeValue(e objconv.Encoder) error {\n", s.name)
+	g.printf("n := %d\n", countFields(s.fields))
+
+	for _, f := range s.fields {
+		if f.omitempty || f.omitzero {
+			g.printf("if !(%s) {\nn++\n}\n", g.omitCondition(f))
+		}
+	}
+
+	g.printf("i := 0\n")
+	g.printf("return e.EncodeMap(n, func(ke objconv.Encoder, ve objconv.Encoder) error {\n")
+	g.printf("for {\ni++\nswitch i {\n")
+
+	for i, f := range s.fields {
+		g.printf("case %d:\n", i+1)
+
+		if f.omitempty || f.omitzero {
+			g.printf("if %s {\ncontinue\n}\n", g.omitCondition(f))
+		}
+
+		g.printf("if err := ke.Emitter.EmitString(%q); err != nil {\nreturn err\n}\n", f.name)
+
+		if f.kind == otherKind {
+			g.printf("return ve.Encode(v.%s)\n", f.ident)
+			continue
+		}
+
+		g.printf("if err := ve.Emitter.EmitMapValue(); err != nil {\nreturn err\n}\n")
+
+		switch f.kind {
+		case boolKind:
+			g.printf("return ve.Emitter.EmitBool(v.%s)\n", f.ident)
+		case stringKind:
+			g.printf("return ve.Emitter.EmitString(v.%s)\n", f.ident)
+		case bytesKind:
+			g.printf("return ve.Emitter.EmitBytes(v.%s)\n", f.ident)
+		case intKind:
+			g.printf("return ve.Emitter.EmitInt(int64(v.%s), %d)\n", f.ident, f.bits)
+		case uintKind:
+			g.printf("return ve.Emitter.EmitUint(uint64(v.%s), %d)\n", f.ident, f.bits)
+		case floatKind:
+			g.printf("return ve.Emitter.EmitFloat(float64(v.%s), %d)\n", f.ident, f.bits)
+		case timeKind:
+			g.printf("return ve.Emitter.EmitTime(v.%s)\n", f.ident)
+		case durationKind:
+			g.printf("return ve.Emitter.EmitDuration(v.%s)\n", f.ident)
+		}
+	}
+
+	g.printf("default:\nreturn objconv.End\n}\n}\n})\n}\n")
+}
+
+func (g *generator) decodeValue(s *structType) {
+	g.imports["reflect"] = true
+	g.printf("\nvar objconvFieldsOf%s = objconv.NewFieldSet(reflect.TypeOf((*%s)(nil)).Elem()", s.name, s.name)
+	for _, f := range s.fields {
+		g.printf(", %q", f.name)
+	}
+	g.printf(")\n")
+
+	g.printf("\n// DecodeValue satisfies the objconv.ValueDecoder interface.\n")
+	g.printf("func (v *%s) DecodeValue(d objconv.Decoder) error {\n", s.name)
+	g.printf("err := d.DecodeFields(objconvFieldsOf%s, func(i int, vd objconv.Decoder) error {\n", s.name)
+	g.printf("switch i {\n")
+
+	for i, f := range s.fields {
+		g.printf("case %d:\n", i)
+
+		switch {
+		case f.kind == intKind && f.bits != 64:
+			g.imports["github.com/segmentio/objconv/objutil"] = true
+			min, max := boundsOf(f.typ)
+			g.printf("var x int64\nif err := vd.Decode(&x); err != nil {\nreturn err\n}\n")
+			g.printf("if x < int64(%s) || x > int64(%s) {\n", min, max)
+			g.printf("return objutil.CheckInt64Bounds(x, int64(%s), uint64(%s), reflect.TypeOf(v.%s))\n}\n", min, max, f.ident)
+			g.printf("v.%s = %s(x)\nreturn nil\n", f.ident, f.typ)
+
+		case f.kind == floatKind && f.bits == 32:
+			g.printf("var x float64\nif err := vd.Decode(&x); err != nil {\nreturn err\n}\n")
+			g.printf("v.%s = float32(x)\nreturn nil\n", f.ident)
+
+		default:
+			// Pointers to the other basic types are decoded without
+			// reflection by objconv.Decoder.
+			g.printf("return vd.Decode(&v.%s)\n", f.ident)
+		}
+	}
+
+	// Like the decoders of the objconv package, values are reset when they
+	// fail to decode.
+	g.printf("}\nreturn nil\n})\nif err != nil {\n*v = %s{}\n}\nreturn err\n}\n", s.name)
+}
+
+// omitCondition returns the expression which is true when field f must be
+// omitted from the encoded struct.
+func (g *generator) omitCondition(f structField) string {
+	v := "v." + f.ident
+
+	switch f.kind {
+	case boolKind:
+		return "!" + v
+	case stringKind:
+		return v + ` == ""`
+	case intKind, uintKind, floatKind, durationKind:
+		return v + " == 0"
+	case bytesKind:
+		if f.omitempty {
+			return "len(" + v + ") == 0"
+		}
+		return v + " == nil"
+	}
+
+	g.imports["github.com/segmentio/objconv/objutil"] = true
+
+	switch {
+	case f.omitempty && f.omitzero:
+		return "objutil.IsEmpty(" + v + ") || objutil.IsZero(" + v + ")"
+	case f.omitempty:
+		return "objutil.IsEmpty(" + v + ")"
+	default:
+		return "objutil.IsZero(" + v + ")"
+	}
+}
+
+// countFields returns the number of fields which are always encoded.
+func countFields(fields []structField) int {
+	n := 0
+	for _, f := range fields {
+		if !f.omitempty && !f.omitzero {
+			n++
+		}
+	}
+	return n
+}
+
+func boundsOf(typ string) (string, string) {
+	switch typ {
+	case "int8":
+		return "objutil.Int8Min", "objutil.Int8Max"
+	case "int16":
+		return "objutil.Int16Min", "objutil.Int16Max"
+	case "int32":
+		return "objutil.Int32Min", "objutil.Int32Max"
+	default:
+		return "objutil.IntMin", "objutil.IntMax"
+	}
+}
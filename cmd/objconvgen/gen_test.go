@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGolden(t *testing.T) {
+	dir := filepath.Join("internal", "golden")
+	output := filepath.Join(dir, "objconv_gen.go")
+
+	pkg, err := loadPackage(dir, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	structs, err := pkg.structs([]string{"Source"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := generate(pkg.name, structs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(src, golden) {
+		t.Errorf("%s is out of date, run go generate in %s", output, dir)
+	}
+}
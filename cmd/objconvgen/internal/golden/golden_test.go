@@ -0,0 +1,186 @@
+package golden
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/json"
+	"github.com/segmentio/objconv/msgpack"
+)
+
+// The raw types have the fields of the generated types but none of their
+// methods, they are encoded and decoded by reflection.
+type (
+	rawEvent  Event
+	rawSource Source
+)
+
+var events = []Event{
+	{},
+	{ID: "A", Level: -3, Score: 1.5, Enabled: true, Timeout: time.Second, Tags: []string{"x", "y"}},
+	{
+		ID:      "B",
+		Level:   127,
+		Count:   42,
+		Ratio:   0.25,
+		Data:    []byte("hello"),
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+		Labels:  map[string]string{"env": "prod"},
+		Source:  &Source{Host: "localhost", Port: 8080},
+		Ignored: "ignored",
+		hidden:  "hidden",
+	},
+}
+
+func TestEncode(t *testing.T) {
+	for _, codec := range []objconv.Codec{json.Codec, msgpack.Codec} {
+		for _, e := range events {
+			generated, err := encode(codec, e)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reflected, err := encode(codec, rawEvent(e))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(generated, reflected) {
+				t.Errorf("the generated encoder and the reflection differ:\n%q\n%q", generated, reflected)
+			}
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	for _, e := range events {
+		b, err := encode(json.Codec, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var generated Event
+		var reflected rawEvent
+
+		if err := json.Unmarshal(b, &generated); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := json.Unmarshal(b, &reflected); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(generated, Event(reflected)) {
+			t.Errorf("the generated decoder and the reflection differ:\n%#v\n%#v", generated, reflected)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		in     string
+		config func(*objconv.Decoder)
+	}{
+		{in: `{"id":"a","level":300}`},
+		{in: `{"id":"a","source":{"host":"h","port":[]}}`},
+		{in: `{"id":[]}`},
+		{in: `{"id":[`},
+		{in: `{"id":"a","id":"b"}`, config: func(d *objconv.Decoder) { d.Conformance = objconv.Standard }},
+		{in: `{"id":"a","other":1}`, config: func(d *objconv.Decoder) { d.DisallowUnknownFields = true }},
+		{in: `{"source":{"host":"h","other":1}}`, config: func(d *objconv.Decoder) { d.DisallowUnknownFields = true }},
+		{in: `{"id":"` + strings.Repeat("x", 100) + `"}`, config: func(d *objconv.Decoder) { d.Limits.MaxStringLen = 10 }},
+	}
+
+	for _, test := range tests {
+		var generated Event
+		var reflected rawEvent
+
+		err1 := decode(test.in, test.config, &generated)
+		err2 := decode(test.in, test.config, &reflected)
+
+		if err1 == nil || err2 == nil {
+			t.Errorf("%s: no error returned by the generated decoder (%v) or the reflection (%v)", test.in, err1, err2)
+			continue
+		}
+
+		// The reflection reports errors on the raw types.
+		msg := strings.NewReplacer("golden.rawEvent", "golden.Event", "golden.rawSource", "golden.Source").Replace(err2.Error())
+
+		if err1.Error() != msg {
+			t.Errorf("%s: the errors of the generated decoder and the reflection differ:\n%s\n%s", test.in, err1, msg)
+		}
+
+		if !errors.Is(err1, objconv.ErrType) && !errors.Is(err1, objconv.ErrSyntax) && !errors.Is(err1, objconv.ErrRange) &&
+			!errors.Is(err1, objconv.ErrLimit) && !errors.Is(err1, objconv.ErrUnknownField) {
+			t.Errorf("%s: the error of the generated decoder has no kind: %v", test.in, err1)
+		}
+
+		if !reflect.DeepEqual(generated, Event{}) {
+			t.Errorf("%s: the value was not reset after the error: %#v", test.in, generated)
+		}
+	}
+}
+
+func TestDecodeOptions(t *testing.T) {
+	const in = `{"id":"a","unknown":1,
+"source":{"host":"h"}}`
+
+	type result struct {
+		warnings  []string
+		records   []string
+		positions map[string]objconv.Position
+	}
+
+	run := func(v interface{}) (r result) {
+		r.positions = map[string]objconv.Position{}
+
+		err := decode(in, func(d *objconv.Decoder) {
+			d.Warn = func(w objconv.Warning) {
+				r.warnings = append(r.warnings, fmt.Sprint(w.Kind, " ", w.Field, " ", w.Value))
+			}
+			d.FieldRecorder = recorder(func(t reflect.Type, field string, used bool) {
+				r.records = append(r.records, fmt.Sprint(field, " ", used))
+			})
+			d.Positions = r.positions
+		}, v)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	generated, reflected := run(&Event{}), run(&rawEvent{})
+
+	if !reflect.DeepEqual(generated, reflected) {
+		t.Errorf("the generated decoder and the reflection differ:\n%+v\n%+v", generated, reflected)
+	}
+
+	if len(generated.warnings) == 0 || len(generated.records) == 0 || len(generated.positions) == 0 {
+		t.Errorf("the decoder options were ignored: %+v", generated)
+	}
+}
+
+type recorder func(reflect.Type, string, bool)
+
+func (r recorder) RecordField(t reflect.Type, field string, used bool) { r(t, field, used) }
+
+func encode(codec objconv.Codec, v interface{}) ([]byte, error) {
+	b := &bytes.Buffer{}
+	err := codec.NewEncoder(b).Encode(v)
+	return b.Bytes(), err
+}
+
+func decode(in string, config func(*objconv.Decoder), v interface{}) error {
+	d := json.NewDecoder(strings.NewReader(in))
+	if config != nil {
+		config(d)
+	}
+	return d.Decode(v)
+}
@@ -0,0 +1,264 @@
+// Code generated by objconvgen. DO NOT EDIT.
+
+package golden
+
+import (
+	"reflect"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (v Event) EncodeValue(e objconv.Encoder) error {
+	n := 6
+	if !(v.Count == 0) {
+		n++
+	}
+	if !(v.Ratio == 0) {
+		n++
+	}
+	if !(len(v.Data) == 0) {
+		n++
+	}
+	if !(objutil.IsZero(v.Time)) {
+		n++
+	}
+	if !(objutil.IsEmpty(v.Labels)) {
+		n++
+	}
+	if !(objutil.IsEmpty(v.Source)) {
+		n++
+	}
+	i := 0
+	return e.EncodeMap(n, func(ke objconv.Encoder, ve objconv.Encoder) error {
+		for {
+			i++
+			switch i {
+			case 1:
+				if err := ke.Emitter.EmitString("id"); err != nil {
+					return err
+				}
+				if err := ve.Emitter.EmitMapValue(); err != nil {
+					return err
+				}
+				return ve.Emitter.EmitString(v.ID)
+			case 2:
+				if err := ke.Emitter.EmitString("level"); err != nil {
+					return err
+				}
+				if err := ve.Emitter.EmitMapValue(); err != nil {
+					return err
+				}
+				return ve.Emitter.EmitInt(int64(v.Level), 8)
+			case 3:
+				if v.Count == 0 {
+					continue
+				}
+				if err := ke.Emitter.EmitString("count"); err != nil {
+					return err
+				}
+				if err := ve.Emitter.EmitMapValue(); err != nil {
+					return err
+				}
+				return ve.Emitter.EmitUint(uint64(v.Count), 32)
+			case 4:
+				if err := ke.Emitter.EmitString("score"); err != nil {
+					return err
+				}
+				if err := ve.Emitter.EmitMapValue(); err != nil {
+					return err
+				}
+				return ve.Emitter.EmitFloat(float64(v.Score), 32)
+			case 5:
+				if v.Ratio == 0 {
+					continue
+				}
+				if err := ke.Emitter.EmitString("ratio"); err != nil {
+					return err
+				}
+				if err := ve.Emitter.EmitMapValue(); err != nil {
+					return err
+				}
+				return ve.Emitter.EmitFloat(float64(v.Ratio), 64)
+			case 6:
+				if err := ke.Emitter.EmitString("enabled"); err != nil {
+					return err
+				}
+				if err := ve.Emitter.EmitMapValue(); err != nil {
+					return err
+				}
+				return ve.Emitter.EmitBool(v.Enabled)
+			case 7:
+				if len(v.Data) == 0 {
+					continue
+				}
+				if err := ke.Emitter.EmitString("data"); err != nil {
+					return err
+				}
+				if err := ve.Emitter.EmitMapValue(); err != nil {
+					return err
+				}
+				return ve.Emitter.EmitBytes(v.Data)
+			case 8:
+				if objutil.IsZero(v.Time) {
+					continue
+				}
+				if err := ke.Emitter.EmitString("time"); err != nil {
+					return err
+				}
+				if err := ve.Emitter.EmitMapValue(); err != nil {
+					return err
+				}
+				return ve.Emitter.EmitTime(v.Time)
+			case 9:
+				if err := ke.Emitter.EmitString("timeout"); err != nil {
+					return err
+				}
+				if err := ve.Emitter.EmitMapValue(); err != nil {
+					return err
+				}
+				return ve.Emitter.EmitDuration(v.Timeout)
+			case 10:
+				if err := ke.Emitter.EmitString("tags"); err != nil {
+					return err
+				}
+				return ve.Encode(v.Tags)
+			case 11:
+				if objutil.IsEmpty(v.Labels) {
+					continue
+				}
+				if err := ke.Emitter.EmitString("labels"); err != nil {
+					return err
+				}
+				return ve.Encode(v.Labels)
+			case 12:
+				if objutil.IsEmpty(v.Source) {
+					continue
+				}
+				if err := ke.Emitter.EmitString("source"); err != nil {
+					return err
+				}
+				return ve.Encode(v.Source)
+			default:
+				return objconv.End
+			}
+		}
+	})
+}
+
+var objconvFieldsOfEvent = objconv.NewFieldSet(reflect.TypeOf((*Event)(nil)).Elem(), "id", "level", "count", "score", "ratio", "enabled", "data", "time", "timeout", "tags", "labels", "source")
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (v *Event) DecodeValue(d objconv.Decoder) error {
+	err := d.DecodeFields(objconvFieldsOfEvent, func(i int, vd objconv.Decoder) error {
+		switch i {
+		case 0:
+			return vd.Decode(&v.ID)
+		case 1:
+			var x int64
+			if err := vd.Decode(&x); err != nil {
+				return err
+			}
+			if x < int64(objutil.Int8Min) || x > int64(objutil.Int8Max) {
+				return objutil.CheckInt64Bounds(x, int64(objutil.Int8Min), uint64(objutil.Int8Max), reflect.TypeOf(v.Level))
+			}
+			v.Level = int8(x)
+			return nil
+		case 2:
+			return vd.Decode(&v.Count)
+		case 3:
+			var x float64
+			if err := vd.Decode(&x); err != nil {
+				return err
+			}
+			v.Score = float32(x)
+			return nil
+		case 4:
+			return vd.Decode(&v.Ratio)
+		case 5:
+			return vd.Decode(&v.Enabled)
+		case 6:
+			return vd.Decode(&v.Data)
+		case 7:
+			return vd.Decode(&v.Time)
+		case 8:
+			return vd.Decode(&v.Timeout)
+		case 9:
+			return vd.Decode(&v.Tags)
+		case 10:
+			return vd.Decode(&v.Labels)
+		case 11:
+			return vd.Decode(&v.Source)
+		}
+		return nil
+	})
+	if err != nil {
+		*v = Event{}
+	}
+	return err
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (v Source) EncodeValue(e objconv.Encoder) error {
+	n := 1
+	if !(v.Port == 0) {
+		n++
+	}
+	i := 0
+	return e.EncodeMap(n, func(ke objconv.Encoder, ve objconv.Encoder) error {
+		for {
+			i++
+			switch i {
+			case 1:
+				if err := ke.Emitter.EmitString("host"); err != nil {
+					return err
+				}
+				if err := ve.Emitter.EmitMapValue(); err != nil {
+					return err
+				}
+				return ve.Emitter.EmitString(v.Host)
+			case 2:
+				if v.Port == 0 {
+					continue
+				}
+				if err := ke.Emitter.EmitString("port"); err != nil {
+					return err
+				}
+				if err := ve.Emitter.EmitMapValue(); err != nil {
+					return err
+				}
+				return ve.Emitter.EmitInt(int64(v.Port), 0)
+			default:
+				return objconv.End
+			}
+		}
+	})
+}
+
+var objconvFieldsOfSource = objconv.NewFieldSet(reflect.TypeOf((*Source)(nil)).Elem(), "host", "port")
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (v *Source) DecodeValue(d objconv.Decoder) error {
+	err := d.DecodeFields(objconvFieldsOfSource, func(i int, vd objconv.Decoder) error {
+		switch i {
+		case 0:
+			return vd.Decode(&v.Host)
+		case 1:
+			var x int64
+			if err := vd.Decode(&x); err != nil {
+				return err
+			}
+			if x < int64(objutil.IntMin) || x > int64(objutil.IntMax) {
+				return objutil.CheckInt64Bounds(x, int64(objutil.IntMin), uint64(objutil.IntMax), reflect.TypeOf(v.Port))
+			}
+			v.Port = int(x)
+			return nil
+		}
+		return nil
+	})
+	if err != nil {
+		*v = Source{}
+	}
+	return err
+}
@@ -0,0 +1,32 @@
+// Package golden holds struct types that the objconvgen tests generate methods
+// for, the generated file is compared to the output of the generator and the
+// generated methods to the reflection-based algorithms of objconv.
+package golden
+
+import "time"
+
+//go:generate go run github.com/segmentio/objconv/cmd/objconvgen -type Source
+
+//objconv:generate
+type Event struct {
+	ID      string            `objconv:"id"`
+	Level   int8              `objconv:"level"`
+	Count   uint32            `objconv:"count,omitempty"`
+	Score   float32           `objconv:"score"`
+	Ratio   float64           `objconv:"ratio,omitzero"`
+	Enabled bool              `objconv:"enabled"`
+	Data    []byte            `objconv:"data,omitempty"`
+	Time    time.Time         `objconv:"time,omitzero"`
+	Timeout time.Duration     `objconv:"timeout"`
+	Tags    []string          `objconv:"tags"`
+	Labels  map[string]string `objconv:"labels,omitempty"`
+	Source  *Source           `objconv:"source,omitempty"`
+	Ignored string            `objconv:"-"`
+	hidden  string
+}
+
+// Source is selected with the -type flag of the go:generate directive.
+type Source struct {
+	Host string `json:"host"`
+	Port int    `json:"port,omitempty"`
+}
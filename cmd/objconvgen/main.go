@@ -0,0 +1,200 @@
+// Command objconvgen generates implementations of the objconv.ValueEncoder and
+// objconv.ValueDecoder interfaces for struct types, which encode and decode
+// values without paying the cost of reflecting on the structs.
+//
+// The command loads the Go files of a package directory and generates methods
+// for the struct types listed with the -type flag, and for the struct types
+// annotated with an //objconv:generate comment:
+//
+//	//go:generate objconvgen
+//
+//	//objconv:generate
+//	type Event struct {
+//		ID   string    `objconv:"id"`
+//		Time time.Time `objconv:"time"`
+//	}
+//
+// The generated code supports the same tags as the encoders and decoders of
+// the objconv package, except for the inline and encrypt options which the
+// command rejects. Fields of types that the generator doesn't know are encoded
+// and decoded by the objconv algorithms, and the keys of decoded maps go
+// through the same field matching as the reflection-based decoders (see
+// objconv.Decoder.DecodeFields), which report errors with the same paths and
+// positions, and honor the Conformance, DisallowUnknownFields, Warn,
+// FieldRecorder and Positions options.
+//
+// The generated methods do not support the features that cannot be seen in
+// the source of the struct definitions, which are setters, virtual fields,
+// links, field policies and types registered with objconv.Ignore. The field
+// names are fixed when the code is generated, so the TagNames and FieldNaming
+// options of encoders and decoders are ignored, and so is the FieldRecorder
+// option of encoders.
+//
+// The internal/golden directory holds types whose generated methods are tested
+// against the reflection-based encoders and decoders, run go generate in that
+// directory after changing the generator.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func main() {
+	var output string
+	var types string
+
+	flag.StringVar(&output, "o", "objconv_gen.go", "The name of the generated file, relative to the package directory")
+	flag.StringVar(&types, "type", "", "A comma-separated list of struct types to generate methods for")
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "usage: objconvgen [-o file] [-type T1,T2] [dir]")
+		os.Exit(2)
+	}
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+
+	if err := run(dir, filepath.Join(dir, output), types); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, output string, types string) error {
+	pkg, err := loadPackage(dir, output)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	if len(types) != 0 {
+		names = strings.Split(types, ",")
+	}
+
+	structs, err := pkg.structs(names)
+	if err != nil {
+		return err
+	}
+
+	if len(structs) == 0 {
+		return fmt.Errorf("no struct types to generate methods for in %s", dir)
+	}
+
+	src, err := generate(pkg.name, structs)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(output, src, 0644)
+}
+
+// pkg holds the declarations loaded from a package directory.
+type pkg struct {
+	name  string
+	specs map[string]*typeSpec
+}
+
+type typeSpec struct {
+	spec      *ast.TypeSpec
+	file      *ast.File
+	annotated bool
+}
+
+func loadPackage(dir string, output string) (*pkg, error) {
+	fset := token.NewFileSet()
+
+	filter := func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != filepath.Base(output)
+	}
+
+	pkgs, err := parser.ParseDir(fset, dir, filter, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %s but found %d", dir, len(pkgs))
+	}
+
+	p := &pkg{specs: map[string]*typeSpec{}}
+
+	for name, astPkg := range pkgs {
+		p.name = name
+
+		for _, file := range astPkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+
+				for _, spec := range gen.Specs {
+					ts := spec.(*ast.TypeSpec)
+					p.specs[ts.Name.Name] = &typeSpec{
+						spec: ts,
+						file: file,
+						// Single type declarations carry their comments
+						// on the declaration, grouped ones on the spec.
+						annotated: isAnnotated(ts.Doc) || (len(gen.Specs) == 1 && isAnnotated(gen.Doc)),
+					}
+				}
+			}
+		}
+	}
+
+	return p, nil
+}
+
+func isAnnotated(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == "//objconv:generate" {
+			return true
+		}
+	}
+	return false
+}
+
+// structs returns the struct types listed in names and the annotated ones,
+// sorted by name.
+func (p *pkg) structs(names []string) ([]*structType, error) {
+	selected := map[string]bool{}
+
+	for _, name := range names {
+		if _, ok := p.specs[name]; !ok {
+			return nil, fmt.Errorf("type %s not found in package %s", name, p.name)
+		}
+		selected[name] = true
+	}
+
+	for name, ts := range p.specs {
+		if ts.annotated {
+			selected[name] = true
+		}
+	}
+
+	var structs []*structType
+
+	for name := range selected {
+		s, err := makeStructType(p.specs[name])
+		if err != nil {
+			return nil, err
+		}
+		structs = append(structs, s)
+	}
+
+	sort.Slice(structs, func(i, j int) bool { return structs[i].name < structs[j].name })
+	return structs, nil
+}
@@ -1,8 +1,11 @@
 package objconv
 
 import (
+	"bytes"
 	"io"
 	"sync"
+
+	"github.com/segmentio/objconv/objutil"
 )
 
 // A Codec is a factory for encoder and decoders that work on byte streams.
@@ -31,6 +34,21 @@ func (c Codec) NewStreamDecoder(r io.Reader) *StreamDecoder {
 	return NewStreamDecoder(c.NewParser(r))
 }
 
+// Append appends the encoded representation of v to dst and returns the
+// extended slice, dst is returned unchanged if an error occurs.
+//
+// The emitter of the codec is created by each call, the packages of codecs
+// provide Append functions which reuse them.
+func (c Codec) Append(dst []byte, v interface{}) ([]byte, error) {
+	b := bytes.NewBuffer(dst)
+
+	if err := c.NewEncoder(b).Encode(v); err != nil {
+		return dst, err
+	}
+
+	return b.Bytes(), nil
+}
+
 // A Registry associates mime types to codecs.
 //
 // It is safe to use a registry concurrently from multiple goroutines.
@@ -68,6 +86,16 @@ func (reg *Registry) Lookup(mimetype string) (codec Codec, ok bool) {
 	return
 }
 
+// Append appends the representation of v in the format of the codec registered
+// for mimetype to dst, see Codec.Append.
+func (reg *Registry) Append(mimetype string, dst []byte, v interface{}) ([]byte, error) {
+	codec, ok := reg.Lookup(mimetype)
+	if !ok {
+		return dst, objutil.Errorf(objutil.ErrType, "objconv: no codec registered for %q", mimetype)
+	}
+	return codec.Append(dst, v)
+}
+
 // Codecs returns a map of all codecs registered in reg.
 func (reg *Registry) Codecs() (codecs map[string]Codec) {
 	codecs = make(map[string]Codec)
@@ -102,3 +130,9 @@ func Lookup(mimetype string) (Codec, bool) {
 func Codecs() map[string]Codec {
 	return registry.Codecs()
 }
+
+// Append appends the representation of v in the format of the codec registered
+// for mimetype in the global registry to dst, see Codec.Append.
+func Append(mimetype string, dst []byte, v interface{}) ([]byte, error) {
+	return registry.Append(mimetype, dst, v)
+}
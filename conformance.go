@@ -0,0 +1,113 @@
+package objconv
+
+import (
+	"io"
+	"math"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Conformance is an enumeration of the levels of strictness that decoders
+// apply to their input.
+//
+// The checks are implemented by the decoder rather than the parsers, so the
+// same input is accepted or rejected regardless of the format it was read
+// from, and switching codecs doesn't silently change how strict a program is.
+type Conformance int
+
+const (
+	// Lenient accepts all the values that parsers produce, it is the default
+	// level of decoders.
+	Lenient Conformance = iota
+
+	// Standard rejects inputs which may be misinterpreted: data following the
+	// top-level value, and maps with duplicate keys, which are decoded to the
+	// last value of the key by lenient decoders.
+	Standard
+
+	// Strict also rejects values which cannot be represented portably across
+	// formats: NaN and infinite floating point numbers, and strings containing
+	// control characters other than tabs, line feeds and carriage returns.
+	Strict
+)
+
+// String returns a human-readable representation of c.
+func (c Conformance) String() string {
+	switch c {
+	case Lenient:
+		return "lenient"
+	case Standard:
+		return "standard"
+	case Strict:
+		return "strict"
+	default:
+		return "<unknown conformance>"
+	}
+}
+
+// duplicateKeyCode is the code of field errors reporting duplicate keys.
+const duplicateKeyCode = "duplicate"
+
+// checkEnd verifies that the parser reached the end of its input, it is called
+// after decoding a top-level value when DisallowTrailingData is set or the
+// conformance level is at least Standard.
+func (d Decoder) checkEnd() error {
+	switch _, err := d.Parser.ParseType(); err {
+	case io.EOF:
+		return nil
+	case nil:
+		return objutil.Errorf(objutil.ErrSyntax, "objconv: the input has data after the top-level value")
+	default:
+		return err
+	}
+}
+
+// duplicateKey returns the error reported for duplicate map keys, the path of
+// the error leads to the key.
+func (d Decoder) duplicateKey(key string) error {
+	return d.prefixFieldPath(&FieldError{
+		Code: duplicateKeyCode,
+		Err:  objutil.Errorf(objutil.ErrSyntax, "objconv: the input has the key %q multiple times in the same map, which is rejected by the %s conformance level", key, d.Conformance),
+	}, key)
+}
+
+// readString calls ParseString on the parser, and verifies that the string
+// doesn't exceed the length limit and has no control characters when the
+// conformance level is Strict.
+func (d Decoder) readString() (b []byte, err error) {
+	if b, err = d.Parser.ParseString(); err != nil {
+		return
+	}
+
+	if err = d.Limits.checkStringLen(len(b)); err != nil {
+		return nil, err
+	}
+
+	if d.record != nil {
+		if err = d.record.checkKey(b); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = d.count(0, len(b)); err != nil {
+		return nil, err
+	}
+
+	if d.Conformance >= Strict {
+		for _, c := range b {
+			if (c < 0x20 && c != '\t' && c != '\n' && c != '\r') || c == 0x7f {
+				return nil, objutil.Errorf(objutil.ErrSyntax, "objconv: the input has a string with the control character %q, which is rejected by the %s conformance level", c, d.Conformance)
+			}
+		}
+	}
+	return
+}
+
+// readFloat calls ParseFloat on the parser, and verifies that the number is
+// finite when the conformance level is Strict.
+func (d Decoder) readFloat() (f float64, err error) {
+	if f, err = d.Parser.ParseFloat(); err == nil && d.Conformance >= Strict && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		return 0, objutil.Errorf(objutil.ErrRange, "objconv: the input has the number %g, which is rejected by the %s conformance level", f, d.Conformance)
+	}
+	return
+}
@@ -0,0 +1,163 @@
+package objconv
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// RegisterConstructor registers a constructor function used by decoders to
+// create values of typ, which lets types without exported fields (money
+// amounts, identifiers, ...) be decoded without making their internals public.
+//
+// The constructor must return a value of type typ, optionally followed by an
+// error. Constructors taking a single argument receive the input decoded as a
+// value of the argument type, constructors taking multiple arguments receive
+// the elements of an array, in order:
+//
+//	objconv.RegisterConstructor(reflect.TypeOf(Money{}), func(amount int64, currency string) (Money, error) {
+//		...
+//	})
+//
+// Decoding a null value sets the zero value without calling the constructor.
+//
+// The function panics if the constructor doesn't have a valid signature. Like
+// Install, it is intended to be called during the package initialization
+// phase.
+func RegisterConstructor(typ reflect.Type, constructor interface{}) {
+	fn := reflect.ValueOf(constructor)
+	ft := fn.Type()
+
+	if ft.Kind() != reflect.Func || ft.IsVariadic() || ft.NumIn() == 0 {
+		panic(fmt.Sprintf("objconv: the constructor of %s must be a function taking at least one argument, not %s", typ, ft))
+	}
+
+	switch {
+	case ft.NumOut() == 1 && ft.Out(0) == typ:
+	case ft.NumOut() == 2 && ft.Out(0) == typ && ft.Out(1) == errorInterface:
+	default:
+		panic(fmt.Sprintf("objconv: the constructor of %s must return a %s and optionally an error, not %s", typ, typ, ft))
+	}
+
+	for i, n := 0, ft.NumIn(); i != n; i++ {
+		if ft.In(i) == typ {
+			panic(fmt.Sprintf("objconv: the constructor of %s cannot take an argument of the type it constructs", typ))
+		}
+	}
+
+	constructorMutex.Lock()
+	constructorStore[typ] = fn
+	constructorMutex.Unlock()
+
+	// Same as Install, the struct cache may have become invalid.
+	clearStructCaches()
+}
+
+// ConstructorOf returns the constructor registered for typ, setting ok to true
+// if one was found, false otherwise.
+func ConstructorOf(typ reflect.Type) (constructor interface{}, ok bool) {
+	var fn reflect.Value
+
+	if fn, ok = constructorOf(typ); ok {
+		constructor = fn.Interface()
+	}
+
+	return
+}
+
+func constructorOf(typ reflect.Type) (fn reflect.Value, ok bool) {
+	constructorMutex.RLock()
+	fn, ok = constructorStore[typ]
+	constructorMutex.RUnlock()
+	return
+}
+
+func makeConstructorDecodeFunc(fn reflect.Value, opts decodeFuncOpts) decodeFunc {
+	ft := fn.Type()
+	n := ft.NumIn()
+	decodeArgs := make([]decodeFunc, n)
+
+	for i := range decodeArgs {
+		decodeArgs[i] = makeDecodeFunc(ft.In(i), opts)
+	}
+
+	construct := func(to reflect.Value, args []reflect.Value) error {
+		out := fn.Call(args)
+
+		if len(out) == 2 && !out[1].IsNil() {
+			return out[1].Interface().(error)
+		}
+
+		if to.IsValid() {
+			to.Set(out[0])
+		}
+
+		return nil
+	}
+
+	if n == 1 {
+		return func(d Decoder, to reflect.Value) (t Type, err error) {
+			arg := reflect.New(ft.In(0)).Elem()
+
+			if t, err = decodeArgs[0](d, arg); err != nil {
+				return
+			}
+
+			if t == Nil {
+				if to.IsValid() {
+					to.Set(zeroValueOf(to.Type()))
+				}
+				return
+			}
+
+			err = construct(to, []reflect.Value{arg})
+			return
+		}
+	}
+
+	return func(d Decoder, to reflect.Value) (t Type, err error) {
+		if t, err = d.Parser.ParseType(); err != nil {
+			return
+		}
+
+		if t == Nil {
+			if err = d.Parser.ParseNil(); err == nil && to.IsValid() {
+				to.Set(zeroValueOf(to.Type()))
+			}
+			return
+		}
+
+		args := make([]reflect.Value, n)
+		i := 0
+
+		for j := range args {
+			args[j] = reflect.New(ft.In(j)).Elem()
+		}
+
+		if err = d.decodeArrayImpl(t, func(d Decoder) (err error) {
+			if i >= n {
+				return objutil.Errorf(objutil.ErrRange, "objconv: too many arguments to the constructor of %s, expected %d", ft.Out(0), n)
+			}
+			_, err = decodeArgs[i](d, args[i])
+			i++
+			return
+		}); err != nil {
+			return
+		}
+
+		if i != n {
+			err = objutil.Errorf(objutil.ErrRange, "objconv: not enough arguments to the constructor of %s, expected %d but found %d", ft.Out(0), n, i)
+			return
+		}
+
+		err = construct(to, args)
+		return
+	}
+}
+
+var (
+	constructorMutex sync.RWMutex
+	constructorStore = make(map[reflect.Type]reflect.Value)
+)
@@ -0,0 +1,84 @@
+package objconv
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type constructedID struct{ value string }
+
+type constructedMoney struct {
+	amount   int64
+	currency string
+}
+
+func init() {
+	RegisterConstructor(reflect.TypeOf(constructedID{}), func(s string) (constructedID, error) {
+		if len(s) != 4 {
+			return constructedID{}, errors.New("invalid identifier")
+		}
+		return constructedID{s}, nil
+	})
+
+	RegisterConstructor(reflect.TypeOf(constructedMoney{}), func(amount int64, currency string) constructedMoney {
+		return constructedMoney{amount, currency}
+	})
+}
+
+func TestConstructors(t *testing.T) {
+	type order struct {
+		ID    constructedID     `objconv:"id"`
+		Total *constructedMoney `objconv:"total"`
+	}
+
+	var o order
+
+	if err := NewDecoder(NewValueParser(map[string]interface{}{
+		"id":    "A123",
+		"total": []interface{}{42, "EUR"},
+	})).Decode(&o); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(o, order{ID: constructedID{"A123"}, Total: &constructedMoney{42, "EUR"}}) {
+		t.Errorf("bad value: %#v", o)
+	}
+
+	tests := []interface{}{
+		map[string]interface{}{"id": "bad"},
+		map[string]interface{}{"total": []interface{}{42}},
+		map[string]interface{}{"total": []interface{}{42, "EUR", "?"}},
+	}
+
+	for _, test := range tests {
+		if err := NewDecoder(NewValueParser(test)).Decode(&o); err == nil {
+			t.Errorf("no error returned when decoding %v", test)
+		}
+	}
+
+	if err := NewDecoder(NewValueParser(map[string]interface{}{"total": nil})).Decode(&o); err != nil || o.Total != nil {
+		t.Errorf("decoding a null value did not set the zero value: %#v (%v)", o, err)
+	}
+}
+
+func TestRegisterConstructorPanics(t *testing.T) {
+	typ := reflect.TypeOf(constructedID{})
+
+	for _, fn := range []interface{}{
+		42,
+		func() constructedID { return constructedID{} },
+		func(string) string { return "" },
+		func(constructedID) constructedID { return constructedID{} },
+		func(string) (constructedID, string) { return constructedID{}, "" },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("no panic raised when registering %T", fn)
+				}
+			}()
+			RegisterConstructor(typ, fn)
+		}()
+	}
+}
@@ -0,0 +1,302 @@
+package csv
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/objconv"
+)
+
+type row struct {
+	Name    string        `objconv:"name"`
+	Age     int           `objconv:"age"`
+	Score   float64       `objconv:"score"`
+	Active  bool          `objconv:"active"`
+	Comment string        `objconv:"comment"`
+	Elapsed time.Duration `objconv:"elapsed"`
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	rows1 := []row{
+		{Name: "Luke", Age: 19, Score: 0.5, Active: true, Comment: `says "hi", then leaves`, Elapsed: time.Second},
+		{Name: "Leia", Age: 19, Score: 1.25, Comment: "two\nlines"},
+		{Name: " Han ", Age: 32},
+	}
+
+	b, err := Marshal(rows1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = `name,age,score,active,comment,elapsed
+Luke,19,0.5,true,"says ""hi"", then leaves",1s
+Leia,19,1.25,false,"two
+lines",0s
+" Han ",32,0,false,"",0s
+`
+
+	if s := string(b); s != expected {
+		t.Errorf("bad CSV:\n%s", s)
+	}
+
+	// Without type inference all cells are strings, which cannot be decoded
+	// into the numeric and boolean fields.
+	var rows2 []row
+	if err := Unmarshal(b, &rows2); err == nil {
+		t.Error("expected an error when decoding without type inference")
+	}
+
+	p := NewParser(bytes.NewReader(b))
+	p.Infer = true
+
+	rows2 = nil
+	if err := objconv.NewDecoder(p).Decode(&rows2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(rows1, rows2) {
+		t.Errorf("%#v != %#v", rows1, rows2)
+	}
+}
+
+func TestInfer(t *testing.T) {
+	const doc = "a,b,c,d,e,f\r\n1,2.5,true,hello,007,\"42\"\r\n\r\n-3,1e3,FALSE,,18446744073709551615,x\r\n"
+
+	p := NewParser(strings.NewReader(doc))
+	p.Infer = true
+
+	var v []map[string]interface{}
+	if err := objconv.NewDecoder(p).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[string]interface{}{
+		{"a": int64(1), "b": 2.5, "c": true, "d": "hello", "e": "007", "f": "42"},
+		{"a": int64(-3), "b": 1000.0, "c": false, "d": nil, "e": uint64(18446744073709551615), "f": "x"},
+	}
+
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("%#v != %#v", v, expected)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	const doc = "id,when,ok,zip\n1,2024-01-02T03:04:05Z,1,01234\n"
+
+	p := NewParser(strings.NewReader(doc))
+	p.Infer = true
+	p.Schema = map[string]objconv.Type{
+		"when": objconv.Time,
+		"ok":   objconv.Bool,
+		"zip":  objconv.String,
+	}
+
+	var v []map[string]interface{}
+	if err := objconv.NewDecoder(p).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[string]interface{}{{
+		"id":   int64(1),
+		"when": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"ok":   true,
+		"zip":  "01234",
+	}}
+
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("%#v != %#v", v, expected)
+	}
+
+	p.Reset(strings.NewReader("id,when,ok,zip\n1,yesterday,1,0\n"))
+
+	if err := objconv.NewDecoder(p).Decode(&v); err == nil || !strings.Contains(err.Error(), `line 2: column "when"`) {
+		t.Error("expected an error for the invalid time but got:", err)
+	}
+}
+
+func TestQuoteAndNull(t *testing.T) {
+	values := []map[string]interface{}{
+		{"name": "Luke", "age": 19, "note": nil},
+		{"name": "NULL", "age": nil, "note": ""},
+	}
+
+	tests := []struct {
+		quote QuoteMode
+		null  string
+		out   string
+	}{
+		{QuoteMinimal, "", "age,name,note\n19,Luke,\n,NULL,\"\"\n"},
+		{QuoteMinimal, "NULL", "age,name,note\n19,Luke,NULL\nNULL,\"NULL\",\n"},
+		{QuoteAll, "NULL", "\"age\",\"name\",\"note\"\n\"19\",\"Luke\",NULL\nNULL,\"NULL\",\"\"\n"},
+		{QuoteStrings, "", "age,name,note\n19,\"Luke\",\n,\"NULL\",\"\"\n"},
+	}
+
+	for _, test := range tests {
+		b := &bytes.Buffer{}
+		e := NewEmitter(b)
+		e.Quote = test.quote
+		e.Null = test.null
+
+		if err := (objconv.Encoder{Emitter: e, SortMapKeys: true}).Encode(values); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if s := b.String(); s != test.out {
+			t.Errorf("quote=%d null=%q: bad CSV:\n%s", test.quote, test.null, s)
+		}
+
+		p := NewParser(b)
+		p.Infer = true
+		p.Null = test.null
+
+		var v []map[string]interface{}
+		if err := objconv.NewDecoder(p).Decode(&v); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if v[0]["note"] != nil || v[1]["age"] != nil || v[1]["name"] != "NULL" || v[1]["note"] != "" {
+			t.Errorf("quote=%d null=%q: bad values: %#v", test.quote, test.null, v)
+		}
+	}
+}
+
+func TestStreamDecoder(t *testing.T) {
+	const doc = "name,age\nLuke,19\nLeia,19\n"
+
+	p := NewParser(strings.NewReader(doc))
+	p.Schema = map[string]objconv.Type{"age": objconv.Int}
+
+	d := objconv.NewStreamDecoder(p)
+	n := 0
+
+	for {
+		var r row
+		if d.Decode(&r) != nil {
+			break
+		}
+		if r.Age != 19 {
+			t.Errorf("bad row: %#v", r)
+		}
+		n++
+	}
+
+	if err := d.Err(); err != nil {
+		t.Error(err)
+	}
+
+	if n != 2 {
+		t.Error("bad row count:", n)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		in  string
+		err string
+	}{
+		{"a,b\n1\n", "line 2: expected 2 cells but found 1"},
+		{"a\n\"1\n", "line 2: unterminated quoted cell"},
+		{"a\n1\"2\n", "line 2: unexpected quote"},
+		{"a\n\"1\"2\n", "line 2: expected ','"},
+	}
+
+	for _, test := range tests {
+		var v []map[string]interface{}
+
+		if err := Unmarshal([]byte(test.in), &v); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: expected an error containing %q but got %v", test.in, test.err, err)
+		}
+	}
+
+	if _, err := Marshal([]interface{}{map[string]interface{}{"a": []int{1}}}); err == nil {
+		t.Error("expected an error when encoding nested values")
+	}
+}
+
+func TestDialect(t *testing.T) {
+	values := []map[string]interface{}{
+		{"a": "x;y", "b": 1},
+		{"a": "it's", "b": 2},
+	}
+
+	tests := []struct {
+		dialect Dialect
+		out     string
+	}{
+		{Excel, "\xEF\xBB\xBFa,b\r\nx;y,1\r\nit's,2\r\n"},
+		{TSV, "a\tb\nx;y\t1\nit's\t2\n"},
+		{Dialect{Comma: ';', QuoteChar: '\''}, "a;b\n'x;y';1\n'it''s';2\n"},
+		{Dialect{NoHeader: true}, "x;y,1\nit's,2\n"},
+	}
+
+	for _, test := range tests {
+		b := &bytes.Buffer{}
+		e := NewEmitter(b)
+		e.Dialect = test.dialect
+
+		if err := (objconv.Encoder{Emitter: e, SortMapKeys: true}).Encode(values); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if s := b.String(); s != test.out {
+			t.Errorf("%+v: bad CSV: %q", test.dialect, s)
+		}
+
+		p := NewParser(b)
+		p.Dialect = test.dialect
+		p.Infer = true
+
+		var v []interface{}
+		if err := objconv.NewDecoder(p).Decode(&v); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		var expected []interface{}
+
+		if test.dialect.NoHeader {
+			expected = []interface{}{
+				[]interface{}{"x;y", int64(1)},
+				[]interface{}{"it's", int64(2)},
+			}
+		} else {
+			expected = []interface{}{
+				map[interface{}]interface{}{"a": "x;y", "b": int64(1)},
+				map[interface{}]interface{}{"a": "it's", "b": int64(2)},
+			}
+		}
+
+		if !reflect.DeepEqual(v, expected) {
+			t.Errorf("%+v: %#v != %#v", test.dialect, v, expected)
+		}
+	}
+}
+
+func TestNoHeaderSchema(t *testing.T) {
+	p := NewParser(strings.NewReader("1,2\n3,4\n"))
+	p.NoHeader = true
+	p.Schema = map[string]objconv.Type{"1": objconv.Int}
+
+	var v [][]interface{}
+	if err := objconv.NewDecoder(p).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]interface{}{{"1", int64(2)}, {"3", int64(4)}}
+
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("%#v != %#v", v, expected)
+	}
+
+	p.Reset(strings.NewReader("1,2\n3,4,5\n"))
+
+	if err := objconv.NewDecoder(p).Decode(&v); err == nil || !strings.Contains(err.Error(), "line 2: expected 2 cells but found 3") {
+		t.Error("expected an error for the record of different length but got:", err)
+	}
+}
@@ -0,0 +1,23 @@
+package csv
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/segmentio/objconv"
+)
+
+// NewDecoder returns a new CSV decoder that parses values from r.
+func NewDecoder(r io.Reader) *objconv.Decoder {
+	return objconv.NewDecoder(NewParser(r))
+}
+
+// NewStreamDecoder returns a new CSV stream decoder that parses values from r.
+func NewStreamDecoder(r io.Reader) *objconv.StreamDecoder {
+	return objconv.NewStreamDecoder(NewParser(r))
+}
+
+// Unmarshal decodes a CSV representation of v from b.
+func Unmarshal(b []byte, v interface{}) error {
+	return NewDecoder(bytes.NewReader(b)).Decode(v)
+}
@@ -0,0 +1,60 @@
+package csv
+
+// Dialect describes the variations of the CSV format, it is embedded in
+// emitters and parsers. The zero value is the RFC 4180 format, with a header
+// line and LF line endings.
+type Dialect struct {
+	// Comma is the character separating cells, a comma when zero.
+	Comma byte
+
+	// QuoteChar is the character used to quote cells, a double quote when
+	// zero.
+	QuoteChar byte
+
+	// CRLF makes emitters end lines with "\r\n" instead of "\n". Parsers
+	// accept both line endings.
+	CRLF bool
+
+	// BOM makes emitters write a UTF-8 byte order mark at the beginning of
+	// the output, which tells Excel how the document is encoded. Parsers
+	// always skip byte order marks.
+	BOM bool
+
+	// NoHeader indicates that documents have no header line, records are then
+	// represented as arrays of cells instead of maps. The columns of schemas
+	// are named after their positions ("0", "1", ...) when NoHeader is set.
+	NoHeader bool
+}
+
+var (
+	// Excel is the dialect of documents that Microsoft Excel opens without
+	// going through the import wizard.
+	Excel = Dialect{CRLF: true, BOM: true}
+
+	// TSV is the dialect of tab-separated values.
+	TSV = Dialect{Comma: '\t'}
+)
+
+// bom is the UTF-8 encoding of the byte order mark.
+const bom = "\xEF\xBB\xBF"
+
+func (d *Dialect) comma() byte {
+	if d.Comma == 0 {
+		return ','
+	}
+	return d.Comma
+}
+
+func (d *Dialect) quote() byte {
+	if d.QuoteChar == 0 {
+		return '"'
+	}
+	return d.QuoteChar
+}
+
+func (d *Dialect) eol() string {
+	if d.CRLF {
+		return "\r\n"
+	}
+	return "\n"
+}
@@ -0,0 +1,334 @@
+package csv
+
+import (
+	"encoding/base64"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// QuoteMode is an enumeration of the policies used by emitters to decide which
+// cells are quoted.
+type QuoteMode int
+
+const (
+	// QuoteMinimal quotes cells only when required: when they contain the
+	// delimiter, quotes, line breaks, or leading or trailing spaces, and when
+	// they could be mistaken for null values.
+	QuoteMinimal QuoteMode = iota
+
+	// QuoteAll quotes all cells, except null values.
+	QuoteAll
+
+	// QuoteStrings quotes all cells holding strings, including the ones which
+	// look like numbers or booleans, so parsers with type inference enabled
+	// decode them as strings.
+	QuoteStrings
+)
+
+// Emitter implements a CSV emitter that satisfies the objconv.Emitter
+// interface.
+//
+// The emitter expects arrays of records, where records are either maps (the
+// keys of the first record become the header line) or arrays of cells. A
+// single map emitted at the top level is written as a document with one
+// record.
+type Emitter struct {
+	// Dialect configures the delimiters, line endings and whether a header
+	// line is written.
+	Dialect
+
+	// Quote configures which cells are quoted.
+	Quote QuoteMode
+
+	// Null is the representation of null values, it is empty by default. Cells
+	// holding empty strings are quoted when Null is empty so they can be told
+	// apart from null values.
+	Null string
+
+	w io.Writer
+
+	// The emitter writes to this buffer, which gets flushed to the underlying
+	// writer when the encoder has completed writing a value.
+	buf objutil.BufferedWriter
+
+	depth  int      // nesting level in the document
+	begin  bool     // set when nothing was written yet
+	single bool     // set when a single record is emitted at the top level
+	object bool     // set when the current record is a map
+	key    bool     // set when the next value is a key of the record
+	header []string // names of the columns, set by the first map record
+	keys   []string // keys of the current record when it is a map
+	cells  []cell   // cells of the current record
+	line   []byte   // buffer used to format lines
+}
+
+type cell struct {
+	s     string
+	null  bool
+	quote bool
+}
+
+func NewEmitter(w io.Writer) *Emitter {
+	e := &Emitter{}
+	e.Reset(w)
+	return e
+}
+
+func (e *Emitter) Reset(w io.Writer) {
+	e.buf.Reset(w)
+	e.w = &e.buf
+	e.depth = 0
+	e.begin = true
+	e.single = false
+	e.header = nil
+	e.keys = e.keys[:0]
+	e.cells = e.cells[:0]
+}
+
+// Flush writes the buffered output of the emitter to its underlying writer.
+func (e *Emitter) Flush() error {
+	return e.buf.Flush()
+}
+
+// TextEmitter satisfies the objconv.textEmitter interface.
+func (e *Emitter) TextEmitter() bool {
+	return true
+}
+
+func (e *Emitter) EmitNil() error {
+	return e.emit(cell{null: true})
+}
+
+func (e *Emitter) EmitBool(v bool) error {
+	return e.emit(cell{s: strconv.FormatBool(v), quote: e.Quote == QuoteAll})
+}
+
+func (e *Emitter) EmitInt(v int64, _ int) error {
+	return e.emit(cell{s: strconv.FormatInt(v, 10), quote: e.Quote == QuoteAll})
+}
+
+func (e *Emitter) EmitUint(v uint64, _ int) error {
+	return e.emit(cell{s: strconv.FormatUint(v, 10), quote: e.Quote == QuoteAll})
+}
+
+func (e *Emitter) EmitFloat(v float64, bitSize int) error {
+	return e.emit(cell{s: strconv.FormatFloat(v, 'g', -1, bitSize), quote: e.Quote == QuoteAll})
+}
+
+func (e *Emitter) EmitString(v string) error {
+	return e.emit(e.text(v))
+}
+
+func (e *Emitter) EmitBytes(v []byte) error {
+	return e.emit(e.text(base64.StdEncoding.EncodeToString(v)))
+}
+
+func (e *Emitter) EmitTime(v time.Time) error {
+	return e.emit(e.text(v.Format(time.RFC3339Nano)))
+}
+
+func (e *Emitter) EmitDuration(v time.Duration) error {
+	return e.emit(e.text(v.String()))
+}
+
+func (e *Emitter) EmitError(v error) error {
+	return e.emit(e.text(v.Error()))
+}
+
+func (e *Emitter) EmitArrayBegin(_ int) error {
+	switch e.depth {
+	case 0:
+		e.depth = 1
+	case 1:
+		e.depth, e.object = 2, false
+	default:
+		return e.nestingError()
+	}
+	return nil
+}
+
+func (e *Emitter) EmitArrayEnd() error {
+	switch e.depth {
+	case 1:
+		e.depth = 0
+	case 2:
+		e.depth = 1
+		return e.writeRecord()
+	}
+	return nil
+}
+
+func (e *Emitter) EmitArrayNext() error {
+	return nil
+}
+
+func (e *Emitter) EmitMapBegin(_ int) error {
+	switch e.depth {
+	case 0:
+		e.depth, e.single = 1, true
+		fallthrough
+	case 1:
+		e.depth, e.object, e.key = 2, true, true
+	default:
+		return e.nestingError()
+	}
+	return nil
+}
+
+func (e *Emitter) EmitMapEnd() error {
+	e.depth = 1
+
+	if e.single {
+		e.depth, e.single = 0, false
+	}
+
+	return e.writeRecord()
+}
+
+func (e *Emitter) EmitMapValue() error {
+	e.key = false
+	return nil
+}
+
+func (e *Emitter) EmitMapNext() error {
+	e.key = true
+	return nil
+}
+
+func (e *Emitter) text(s string) cell {
+	return cell{s: s, quote: e.Quote != QuoteMinimal || (len(s) == 0 && len(e.Null) == 0) || s == e.Null}
+}
+
+func (e *Emitter) emit(c cell) error {
+	if e.depth != 2 {
+		return objutil.Errorf(objutil.ErrType, "objconv/csv: values must be emitted in records, which are arrays or maps within an array")
+	}
+
+	if e.object && e.key {
+		e.keys = append(e.keys, c.s)
+	} else {
+		e.cells = append(e.cells, c)
+	}
+
+	return nil
+}
+
+func (e *Emitter) nestingError() error {
+	return objutil.Errorf(objutil.ErrType, "objconv/csv: cells cannot contain arrays or maps")
+}
+
+func (e *Emitter) writeRecord() (err error) {
+	cells := e.cells
+
+	if e.object {
+		if e.header == nil {
+			e.header = append([]string{}, e.keys...)
+
+			if !e.NoHeader {
+				header := make([]cell, len(e.header))
+
+				for i, k := range e.header {
+					header[i] = e.text(k)
+					header[i].quote = header[i].quote && e.Quote != QuoteStrings
+				}
+
+				if err = e.writeLine(header); err != nil {
+					return
+				}
+			}
+		}
+
+		if cells, err = e.arrange(); err != nil {
+			return
+		}
+	}
+
+	err = e.writeLine(cells)
+	e.keys = e.keys[:0]
+	e.cells = e.cells[:0]
+	return
+}
+
+// arrange returns the cells of the current map record in the order of the
+// columns of the header.
+func (e *Emitter) arrange() ([]cell, error) {
+	cells := make([]cell, len(e.header))
+
+	for i := range cells {
+		cells[i].null = true
+	}
+
+	for i, k := range e.keys {
+		j := indexOf(e.header, k)
+
+		if j < 0 {
+			return nil, objutil.Errorf(objutil.ErrUnknownField, "objconv/csv: the %q field does not exist in the header of the document", k)
+		}
+
+		cells[j] = e.cells[i]
+	}
+
+	return cells, nil
+}
+
+func (e *Emitter) writeLine(cells []cell) (err error) {
+	b := e.line[:0]
+	comma, quote := e.comma(), e.quote()
+
+	if e.begin {
+		e.begin = false
+
+		if e.BOM {
+			b = append(b, bom...)
+		}
+	}
+
+	for i, c := range cells {
+		if i != 0 {
+			b = append(b, comma)
+		}
+
+		switch {
+		case c.null:
+			b = append(b, e.Null...)
+		case c.quote || needsQuotes(c.s, comma, quote):
+			b = append(b, quote)
+
+			for j := 0; j != len(c.s); j++ {
+				if c.s[j] == quote {
+					b = append(b, quote)
+				}
+				b = append(b, c.s[j])
+			}
+
+			b = append(b, quote)
+		default:
+			b = append(b, c.s...)
+		}
+	}
+
+	b = append(b, e.eol()...)
+	_, err = e.w.Write(b)
+	e.line = b
+	return
+}
+
+func needsQuotes(s string, comma byte, quote byte) bool {
+	if len(s) != 0 && (s[0] == ' ' || s[0] == '\t' || s[len(s)-1] == ' ' || s[len(s)-1] == '\t') {
+		return true
+	}
+	return strings.IndexByte(s, comma) >= 0 || strings.IndexByte(s, quote) >= 0 || strings.ContainsAny(s, "\r\n")
+}
+
+func indexOf(a []string, s string) int {
+	for i := range a {
+		if a[i] == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,41 @@
+package csv
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/segmentio/objconv"
+)
+
+// NewEncoder returns a new CSV encoder that writes to w.
+func NewEncoder(w io.Writer) *objconv.Encoder {
+	return objconv.NewEncoder(NewEmitter(w))
+}
+
+// NewStreamEncoder returns a new CSV stream encoder that writes to w.
+func NewStreamEncoder(w io.Writer) *objconv.StreamEncoder {
+	return objconv.NewStreamEncoder(NewEmitter(w))
+}
+
+// Marshal writes the CSV representation of v to a byte slice returned in b.
+func Marshal(v interface{}) (b []byte, err error) {
+	buf := &bytes.Buffer{}
+
+	if err = NewEncoder(buf).Encode(v); err == nil {
+		b = buf.Bytes()
+	}
+
+	return
+}
+
+// Append appends the CSV representation of v to dst and returns the extended
+// slice, dst is returned unchanged if an error occurs.
+func Append(dst []byte, v interface{}) (b []byte, err error) {
+	buf := bytes.NewBuffer(dst)
+
+	if err = NewEncoder(buf).Encode(v); err != nil {
+		return dst, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,32 @@
+// Package csv implements a codec for comma-separated values.
+//
+// A CSV document is represented as an array of records. Records are maps from
+// the column names found in the header line to the values of the cells, so
+// slices of structs are encoded and decoded by matching the names of their
+// fields to the columns.
+//
+// CSV carries no type information, cells are parsed as strings unless the
+// parser is configured with a schema of the column types or with type
+// inference enabled.
+package csv
+
+import (
+	"io"
+
+	"github.com/segmentio/objconv"
+)
+
+// Codec for the CSV format.
+var Codec = objconv.Codec{
+	NewEmitter: func(w io.Writer) objconv.Emitter { return NewEmitter(w) },
+	NewParser:  func(r io.Reader) objconv.Parser { return NewParser(r) },
+}
+
+func init() {
+	for _, name := range [...]string{
+		"text/csv",
+		"csv",
+	} {
+		objconv.Register(name, Codec)
+	}
+}
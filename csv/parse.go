@@ -0,0 +1,524 @@
+package csv
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Parser implements a CSV parser that satisfies the objconv.Parser interface.
+//
+// The parser presents a document as an array of records, each record being a
+// map from the column names found in the header line to the values of the
+// cells. When the NoHeader option of the dialect is set, records are arrays of
+// cells instead.
+type Parser struct {
+	// Dialect configures the delimiters and whether documents start with a
+	// header line.
+	Dialect
+
+	// Infer enables type inference, unquoted cells that look like booleans,
+	// integers or floating point numbers are parsed as such instead of strings.
+	// Numbers with leading zeros, like zip codes or identifiers, are left as
+	// strings.
+	Infer bool
+
+	// Schema maps column names to the type that their cells are parsed as. The
+	// schema takes precedence over type inference, columns that it doesn't
+	// list are parsed as strings or with type inference if it is enabled.
+	//
+	// Supported types are Bool, Int, Uint, Float, String, Bytes (base64),
+	// Time (RFC 3339) and Duration.
+	Schema map[string]objconv.Type
+
+	// Null is the representation of null values, unquoted cells equal to Null
+	// are parsed as nil. It is empty by default, which means empty unquoted
+	// cells are null values.
+	Null string
+
+	r *bufio.Reader
+
+	line   int      // number of the line being parsed, for error messages
+	depth  int      // nesting level in the document
+	done   bool     // set when the document was entirely parsed
+	header []string // names of the columns
+	width  int      // number of cells in each record
+	record []field  // cells of the current record, nil at the end
+	col    int      // column of the current cell
+	key    bool     // set when the record is positioned on a key
+	b      []byte   // buffer used to read cells
+}
+
+type field struct {
+	s      string
+	quoted bool
+}
+
+func NewParser(r io.Reader) *Parser {
+	p := &Parser{}
+	p.Reset(r)
+	return p
+}
+
+func (p *Parser) Reset(r io.Reader) {
+	if p.r == nil {
+		p.r = bufio.NewReader(r)
+	} else {
+		p.r.Reset(r)
+	}
+	p.line = 0
+	p.depth = 0
+	p.done = false
+	p.header = nil
+	p.width = 0
+	p.record = nil
+}
+
+// TextParser satisfies the objconv.textParser interface.
+func (p *Parser) TextParser() bool {
+	return true
+}
+
+func (p *Parser) ParseType() (t objconv.Type, err error) {
+	switch p.depth {
+	case 0:
+		if p.done {
+			err = io.EOF
+		} else if _, err = p.r.Peek(1); err == nil {
+			t = objconv.Array
+		}
+
+	case 1:
+		if p.record == nil {
+			err = objconv.End
+		} else {
+			t = objconv.Map
+		}
+
+		if p.NoHeader {
+			t = objconv.Array
+		}
+
+	default:
+		if p.key && !p.NoHeader {
+			t = objconv.String
+		} else {
+			t, err = p.typeOf(p.column(), p.record[p.col])
+		}
+	}
+
+	return
+}
+
+func (p *Parser) typeOf(name string, f field) (objconv.Type, error) {
+	if !f.quoted && f.s == p.Null {
+		return objconv.Nil, nil
+	}
+
+	if t, ok := p.Schema[name]; ok {
+		switch t {
+		case objconv.Bool, objconv.Int, objconv.Uint, objconv.Float, objconv.String, objconv.Bytes, objconv.Time, objconv.Duration:
+			return t, nil
+		default:
+			return objconv.Unknown, objutil.Errorf(objutil.ErrType, "objconv/csv: columns cannot have values of type %s (%q)", t, name)
+		}
+	}
+
+	if p.Infer && !f.quoted {
+		return infer(f.s), nil
+	}
+
+	return objconv.String, nil
+}
+
+// infer returns the type of the value represented by s.
+func infer(s string) objconv.Type {
+	if strings.EqualFold(s, "true") || strings.EqualFold(s, "false") {
+		return objconv.Bool
+	}
+
+	if len(s) == 0 || !isNumber(s) {
+		return objconv.String
+	}
+
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return objconv.Int
+	}
+
+	if _, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return objconv.Uint
+	}
+
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return objconv.Float
+	}
+
+	return objconv.String
+}
+
+// isNumber returns true if s starts like a decimal number without leading
+// zeros, strconv accepts forms like "0x1p-2" or "Inf" which are rarely meant
+// to be numbers in CSV documents.
+func isNumber(s string) bool {
+	if s[0] == '-' || s[0] == '+' {
+		s = s[1:]
+	}
+
+	if len(s) == 0 {
+		return false
+	}
+
+	if s[0] == '0' && len(s) > 1 && s[1] != '.' && s[1] != 'e' && s[1] != 'E' {
+		return false
+	}
+
+	for i := 0; i != len(s); i++ {
+		switch c := s[i]; {
+		case c >= '0' && c <= '9', c == '.', c == 'e', c == 'E', c == '-', c == '+':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// column returns the name of the current column.
+func (p *Parser) column() string {
+	if p.NoHeader {
+		return strconv.Itoa(p.col)
+	}
+	return p.header[p.col]
+}
+
+func (p *Parser) cell() string {
+	return p.record[p.col].s
+}
+
+func (p *Parser) cellError(t objconv.Type, err error) error {
+	return objutil.Errorf(objutil.ErrSyntax, "objconv/csv: line %d: column %q: the cell %q is not a valid %s value: %v", p.line, p.column(), p.cell(), t, err)
+}
+
+func (p *Parser) ParseNil() error {
+	return nil
+}
+
+func (p *Parser) ParseBool() (v bool, err error) {
+	switch s := p.cell(); {
+	case strings.EqualFold(s, "true"):
+		v = true
+	case strings.EqualFold(s, "false"):
+		v = false
+	default:
+		if v, err = strconv.ParseBool(s); err != nil {
+			err = p.cellError(objconv.Bool, err)
+		}
+	}
+	return
+}
+
+func (p *Parser) ParseInt() (v int64, err error) {
+	if v, err = strconv.ParseInt(p.cell(), 10, 64); err != nil {
+		err = p.cellError(objconv.Int, err)
+	}
+	return
+}
+
+func (p *Parser) ParseUint() (v uint64, err error) {
+	if v, err = strconv.ParseUint(p.cell(), 10, 64); err != nil {
+		err = p.cellError(objconv.Uint, err)
+	}
+	return
+}
+
+func (p *Parser) ParseFloat() (v float64, err error) {
+	if v, err = strconv.ParseFloat(p.cell(), 64); err != nil {
+		err = p.cellError(objconv.Float, err)
+	}
+	return
+}
+
+func (p *Parser) ParseString() ([]byte, error) {
+	if p.key && !p.NoHeader {
+		return append(p.b[:0], p.header[p.col]...), nil
+	}
+	return append(p.b[:0], p.cell()...), nil
+}
+
+func (p *Parser) ParseBytes() (v []byte, err error) {
+	if v, err = base64.StdEncoding.DecodeString(p.cell()); err != nil {
+		err = p.cellError(objconv.Bytes, err)
+	}
+	return
+}
+
+func (p *Parser) ParseTime() (v time.Time, err error) {
+	if v, err = time.Parse(time.RFC3339Nano, p.cell()); err != nil {
+		err = p.cellError(objconv.Time, err)
+	}
+	return
+}
+
+func (p *Parser) ParseDuration() (v time.Duration, err error) {
+	if v, err = time.ParseDuration(p.cell()); err != nil {
+		err = p.cellError(objconv.Duration, err)
+	}
+	return
+}
+
+func (p *Parser) ParseError() (v error, err error) {
+	panic("objconv/csv: ParseError should never be called because CSV has no error type, this is likely a bug in the decoder code")
+}
+
+func (p *Parser) ParseArrayBegin() (n int, err error) {
+	switch {
+	case p.depth == 0:
+	case p.depth == 1 && p.NoHeader && p.record != nil:
+		p.depth = 2
+		p.col = 0
+		return len(p.record), nil
+	default:
+		return 0, objutil.Errorf(objutil.ErrType, "objconv/csv: cells cannot contain arrays")
+	}
+
+	if b, _ := p.r.Peek(len(bom)); string(b) == bom {
+		p.r.Discard(len(bom))
+	}
+
+	// The first record is loaded ahead of time so the parser can tell whether
+	// the document is empty.
+	if p.NoHeader {
+		if err = p.readRecord(); err != nil {
+			return
+		}
+		p.width = len(p.record)
+	} else {
+		if p.header, err = p.readHeader(); err != nil {
+			return
+		}
+		if p.width = len(p.header); p.header != nil {
+			if err = p.readRecord(); err != nil {
+				return
+			}
+		}
+	}
+
+	p.depth = 1
+	return -1, nil
+}
+
+func (p *Parser) ParseArrayEnd(n int) error {
+	if p.depth == 2 {
+		p.depth = 1
+	} else {
+		p.depth = 0
+		p.done = true
+	}
+	return nil
+}
+
+func (p *Parser) ParseArrayNext(n int) (err error) {
+	if p.depth == 2 {
+		p.col = n
+		return
+	}
+	if n != 0 && p.record != nil {
+		err = p.readRecord()
+	}
+	if err == nil && p.record == nil {
+		err = objconv.End
+	}
+	return
+}
+
+func (p *Parser) ParseMapBegin() (n int, err error) {
+	if p.depth != 1 || p.record == nil || p.NoHeader {
+		return 0, objutil.Errorf(objutil.ErrType, "objconv/csv: cells cannot contain maps")
+	}
+	p.depth = 2
+	p.col = 0
+	p.key = true
+	return len(p.header), nil
+}
+
+func (p *Parser) ParseMapEnd(n int) error {
+	p.depth = 1
+	return nil
+}
+
+func (p *Parser) ParseMapValue(n int) error {
+	p.key = false
+	return nil
+}
+
+func (p *Parser) ParseMapNext(n int) error {
+	p.col++
+	p.key = true
+	return nil
+}
+
+func (p *Parser) readHeader() ([]string, error) {
+	fields, err := p.readLine()
+
+	if err != nil || fields == nil {
+		return nil, err
+	}
+
+	header := make([]string, len(fields))
+
+	for i, f := range fields {
+		header[i] = f.s
+	}
+
+	return header, nil
+}
+
+func (p *Parser) readRecord() (err error) {
+	if p.record, err = p.readLine(); err == nil && p.record != nil && p.width != 0 && len(p.record) != p.width {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/csv: line %d: expected %d cells but found %d", p.line, p.width, len(p.record))
+	}
+	return
+}
+
+// readLine reads the fields of the next non-empty line of the input, it returns
+// nil when the end of the input is reached.
+func (p *Parser) readLine() (fields []field, err error) {
+	for {
+		var b []byte
+
+		if b, err = p.r.Peek(1); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return
+		}
+
+		p.line++
+
+		switch b[0] {
+		case '\n':
+			p.r.Discard(1)
+			continue
+		case '\r':
+			if b, _ = p.r.Peek(2); len(b) == 2 && b[1] == '\n' {
+				p.r.Discard(2)
+				continue
+			}
+		}
+
+		break
+	}
+
+	for {
+		var f field
+		var end bool
+
+		if f, end, err = p.readField(); err != nil {
+			return
+		}
+
+		fields = append(fields, f)
+
+		if end {
+			return
+		}
+	}
+}
+
+// readField reads a field of the current line, end is set when the field was
+// the last one of the line.
+func (p *Parser) readField() (f field, end bool, err error) {
+	var c byte
+	var comma, quote = p.comma(), p.quote()
+	p.b = p.b[:0]
+
+	if c, err = p.r.ReadByte(); err != nil {
+		if err == io.EOF {
+			end, err = true, nil
+		}
+		return
+	}
+
+	if c == quote {
+		f.quoted = true
+		line := p.line
+
+		for {
+			if c, err = p.r.ReadByte(); err != nil {
+				if err == io.EOF {
+					err = objutil.Errorf(objutil.ErrSyntax, "objconv/csv: line %d: unterminated quoted cell", line)
+				}
+				return
+			}
+
+			if c == quote {
+				if b, _ := p.r.Peek(1); len(b) == 0 || b[0] != quote {
+					break
+				}
+				p.r.ReadByte()
+			} else if c == '\n' {
+				p.line++
+			}
+
+			p.b = append(p.b, c)
+		}
+
+		if c, err = p.r.ReadByte(); err != nil {
+			if err == io.EOF {
+				end, err = true, nil
+			}
+			f.s = string(p.b)
+			return
+		}
+	} else {
+		for c != comma && c != '\n' && c != quote {
+			if c == '\r' {
+				if b, _ := p.r.Peek(1); len(b) != 0 && b[0] == '\n' {
+					p.r.ReadByte()
+					c = '\n'
+					break
+				}
+			}
+
+			p.b = append(p.b, c)
+
+			if c, err = p.r.ReadByte(); err != nil {
+				if err == io.EOF {
+					end, err = true, nil
+				}
+				f.s = string(p.b)
+				return
+			}
+		}
+
+		if c == quote {
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/csv: line %d: unexpected quote in an unquoted cell", p.line)
+			return
+		}
+	}
+
+	switch c {
+	case comma:
+	case '\n':
+		end = true
+	case '\r':
+		if b, _ := p.r.Peek(1); len(b) != 0 && b[0] == '\n' {
+			p.r.ReadByte()
+			end = true
+			break
+		}
+		fallthrough
+	default:
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/csv: line %d: expected '%c' or a line break after a quoted cell but found '%c'", p.line, comma, c)
+		return
+	}
+
+	f.s = string(p.b)
+	return
+}
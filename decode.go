@@ -1,13 +1,15 @@
 package objconv
 
 import (
+	"bytes"
+	"context"
 	"encoding"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"strconv"
 	"time"
-	"unsafe"
 
 	"github.com/segmentio/objconv/objutil"
 )
@@ -24,7 +26,97 @@ type Decoder struct {
 	// there is not destination type (when decoding to an empty interface).
 	MapType reflect.Type
 
-	off int // offset of the value when decoding a map
+	// When set, top-level values loaded by Decode are given to the capturer.
+	Capturer Capturer
+
+	// Warn is called with the non-fatal issues found while decoding values,
+	// like fields of the input that don't exist in the destination structs.
+	// Programs use it to detect drift between the payloads they receive and
+	// the types they decode them into, without failing to decode.
+	Warn func(Warning)
+
+	// When set, the usage of struct fields is reported to the recorder.
+	FieldRecorder FieldRecorder
+
+	// When set, maps produced by encoders with the PreserveTypes option are
+	// decoded as values of their registered named type when the destination
+	// is an empty interface, instead of maps.
+	PreserveTypes bool
+
+	// TagNames lists the struct tags that the names and options of fields
+	// are read from, see Encoder.TagNames.
+	TagNames []string
+
+	// FieldNaming is the naming convention applied to the names of struct
+	// fields, see Encoder.FieldNaming.
+	FieldNaming FieldNaming
+
+	// When set, decoding a map into a struct which has no field for one of
+	// the keys returns an error instead of discarding the value. The error is
+	// a *FieldError with the "unknown" code, its path leads to the offending
+	// key from the top-level value, like "servers[2].prot", and it matches
+	// ErrUnknownField with errors.Is.
+	DisallowUnknownFields bool
+
+	// When set, numbers are decoded as values of type Number instead of int64,
+	// uint64 or float64 when the destination is an empty interface, so large
+	// integers and decimal numbers don't lose precision.
+	UseNumber bool
+
+	// When set, the references of the form ${ref} found in the strings that
+	// are decoded into string values or empty interfaces are replaced by their
+	// resolution, see Interpolate. Map keys and the names of struct fields are
+	// never interpolated.
+	Resolver Resolver
+
+	// Keys provides the keys that the values of struct fields with the
+	// `encrypt` tag option are decrypted with, see Encoder.Keys. Values which
+	// were tampered with are errors of kind ErrSyntax.
+	Keys KeyProvider
+
+	// When set, the decoder records the position in the input of the struct
+	// fields and array elements that it decodes. The keys of the map are the
+	// paths to the values from the top-level value, in the format of the paths
+	// of field errors, like "servers[2].port", so the errors of decoding a
+	// configuration file can be reported with their line and column.
+	//
+	// The keys of the input which don't match any field are recorded as well.
+	// Positions are only recorded if the parser implements PositionParser.
+	Positions map[string]Position
+
+	// When set, Decode returns an error of kind ErrSyntax if the input has data
+	// after the top-level value, instead of leaving it to the next call. The
+	// check is always enabled by the Standard and Strict conformance levels,
+	// and never applies to the elements of streams.
+	DisallowTrailingData bool
+
+	// Conformance is the level of strictness applied to the input, see the
+	// Conformance type for the checks of each level. Duplicate keys are
+	// reported as errors of type *FieldError with the "duplicate" code.
+	Conformance Conformance
+
+	// Limits configures the maximum depth of the values decoded, and the
+	// maximum lengths of their strings, arrays and maps. The MaxBytes limit is
+	// enforced by parsers created with ParserConfig.NewParser.
+	Limits ParserConfig
+
+	// Spill configures the decoder to write the large strings and byte
+	// sequences decoded into Blob values to temporary files.
+	Spill SpillConfig
+
+	// When set, the stats are reset by each call to Decode and updated with
+	// the input that it consumes, so they can be read after the call returned,
+	// whether it succeeded or not. The MaxValues and MaxAllocs limits are
+	// enforced on the same accounting.
+	Stats *DecodeStats
+
+	off    int           // offset of the value when decoding a map
+	nested bool          // set when decoding a value within a top-level value
+	stream bool          // set when decoding the elements of a stream
+	record *streamRecord // set when decoding the elements of a stream, to detect error records
+	depth  *int          // nesting level of the value being decoded, when MaxDepth is set
+	field  string        // name of the struct field being decoded, when Warn is set
+	path   string        // path to the value being decoded, when Positions is set
 }
 
 // NewDecoder returns a decoder object that uses p, will panic if p is nil.
@@ -39,8 +131,102 @@ func NewDecoder(p Parser) *Decoder {
 // the next parsed data.
 //
 // The method panics if v is neither a pointer type nor implements the
-// ValueDecoder interface, or if v is a nil pointer.
-func (d Decoder) Decode(v interface{}) error {
+// ValueDecoder interface, or if v is a nil pointer. Unless RecoverPanics is
+// false, these panics and the ones that occur while decoding a top-level value
+// are returned as errors of type *PanicError.
+func (d Decoder) Decode(v interface{}) (err error) {
+	if d.nested {
+		return d.decodeValue(v)
+	}
+
+	if RecoverPanics {
+		defer recoverPanic(&err)
+	}
+
+	d.nested = true
+
+	if d.Limits.MaxDepth > 0 {
+		// The counter is shared by the copies of the decoder, some of them
+		// are captured before the depth changes.
+		d.depth = new(int)
+	}
+
+	done, err := d.startStats()
+	defer done()
+
+	if err != nil {
+		return
+	}
+
+	// Parsers return io.EOF when the input has no more values, so the value
+	// must have started for io.EOF to be reported as a truncated input.
+	if d.off == 0 {
+		if _, err = d.Parser.ParseType(); err != nil {
+			return
+		}
+	}
+
+	if err = d.decodeValue(v); err == nil && (d.DisallowTrailingData || d.Conformance >= Standard) && !d.stream {
+		err = d.checkEnd()
+	}
+
+	if err == io.EOF {
+		err = errUnexpectedEOF
+	}
+
+	if err == nil && d.Capturer != nil {
+		d.Capturer.Capture(v)
+	}
+
+	return
+}
+
+// Buffered returns a reader of the bytes that the parser of d has read from its
+// input but not parsed yet, which is empty if the parser doesn't implement
+// BufferedParser.
+func (d Decoder) Buffered() io.Reader {
+	if p, ok := d.Parser.(BufferedParser); ok {
+		return p.Buffered()
+	}
+	return bytes.NewReader(nil)
+}
+
+// More returns true if the input has another top-level value to decode, it is
+// used to decode inputs made of values written back-to-back:
+//
+//	for d.More() {
+//		if err := d.Decode(&v); err != nil {
+//			...
+//		}
+//	}
+//
+// The method returns true as well if the parser fails to read the input, so
+// the error is reported by the following call to Decode.
+func (d Decoder) More() bool {
+	_, err := d.Parser.ParseType()
+	return err != io.EOF
+}
+
+// DecodeContext is like Decode, but the parser is first given ctx if it
+// implements ParserV2. Otherwise the method only checks that ctx is not done
+// before decoding the value.
+//
+// The parsers of the codec packages abort the reads blocked on their input
+// when ctx is canceled, the method returns ctx.Err() when the value could not
+// be decoded because of it. The parsers remain bound to ctx until they are
+// given another context.
+func (d Decoder) DecodeContext(ctx context.Context, v interface{}) (err error) {
+	if err = parseContext(d.Parser, ctx); err != nil {
+		return
+	}
+
+	if err = d.Decode(v); err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return
+}
+
+func (d Decoder) decodeValue(v interface{}) error {
 	to := reflect.ValueOf(v)
 
 	if d.off != 0 {
@@ -59,9 +245,47 @@ func (d Decoder) Decode(v interface{}) error {
 
 	// Optimization for ValueDecoder, in practice tho it's also handled in the
 	// methods that are based on reflection.
+	//
+	// This type switch also optimizes decoding to common value types, values
+	// are assigned directly instead of going through reflection. Like in the
+	// encoder, adapters installed for these types are not used.
 	switch x := v.(type) {
 	case ValueDecoder:
 		return x.DecodeValue(d)
+
+	case *bool:
+		return d.decodeBoolPtr(x)
+
+	case *int64:
+		return d.decodeInt64Ptr(x)
+
+	case *float64:
+		return d.decodeFloat64Ptr(x)
+
+	case *string:
+		return d.decodeStringPtr(x)
+
+	case *[]byte:
+		return d.decodeBytesPtr(x)
+
+	case *time.Time:
+		return d.decodeTimePtr(x)
+
+	case *map[string]string:
+		return d.decodeMapStringStringPtr(x)
+
+	case *map[string]interface{}:
+		return d.decodeMapStringInterfacePtr(x)
+
+	case map[string]string:
+		if x != nil {
+			return d.decodeMapStringStringPtr(&x)
+		}
+
+	case map[string]interface{}:
+		if x != nil {
+			return d.decodeMapStringInterfacePtr(&x)
+		}
 	}
 
 	if to.Kind() == reflect.Ptr {
@@ -77,6 +301,110 @@ func (d Decoder) Decode(v interface{}) error {
 	return err
 }
 
+func (d Decoder) decodeBoolPtr(p *bool) (err error) {
+	var t Type
+	var v bool
+
+	if t, err = d.Parser.ParseType(); err == nil {
+		if v, err = d.parseBool(t); err == nil {
+			*p = v
+		}
+	}
+	return
+}
+
+func (d Decoder) decodeInt64Ptr(p *int64) (err error) {
+	var t Type
+	var v int64
+
+	if t, err = d.Parser.ParseType(); err == nil {
+		if v, err = d.parseInt(t, int64Type, true); err == nil {
+			*p = v
+		}
+	}
+	return
+}
+
+func (d Decoder) decodeFloat64Ptr(p *float64) (err error) {
+	var t Type
+	var v float64
+
+	if t, err = d.Parser.ParseType(); err == nil {
+		if v, err = d.parseFloat(t); err == nil {
+			*p = v
+		}
+	}
+	return
+}
+
+func (d Decoder) decodeStringPtr(p *string) (err error) {
+	var a [64]byte
+	var t Type
+	var b []byte
+
+	if t, err = d.Parser.ParseType(); err == nil {
+		if b, err = d.parseString(t, a[:0]); err == nil && t == String && d.Resolver != nil {
+			b, err = d.interpolate(b)
+		}
+		if err == nil {
+			*p = string(b)
+		}
+	}
+	return
+}
+
+func (d Decoder) decodeBytesPtr(p *[]byte) (err error) {
+	var t Type
+	var b []byte
+
+	if t, err = d.Parser.ParseType(); err == nil {
+		if b, err = d.parseBytes(t); err == nil {
+			if t == Nil {
+				*p = nil
+			} else {
+				*p = copyBytes(b)
+			}
+		}
+	}
+	return
+}
+
+func (d Decoder) decodeTimePtr(p *time.Time) (err error) {
+	var t Type
+	var v time.Time
+
+	if t, err = d.Parser.ParseType(); err == nil {
+		if v, err = d.parseTime(t, true); err == nil {
+			*p = v
+		}
+	}
+	return
+}
+
+func (d Decoder) decodeMapStringStringPtr(p *map[string]string) (err error) {
+	var t Type
+
+	if t, err = d.Parser.ParseType(); err == nil {
+		if *p == nil {
+			*p = make(map[string]string)
+		}
+		err = d.decodeMapStringStringInto(t, *p)
+	}
+	return
+}
+
+func (d Decoder) decodeMapStringInterfacePtr(p *map[string]interface{}) (err error) {
+	var t Type
+
+	if t, err = d.Parser.ParseType(); err == nil {
+		if *p == nil {
+			*p = make(map[string]interface{})
+		}
+		err = d.decodeMapStringInterfaceInto(t, *p)
+	}
+	return
+}
+
 func (d Decoder) decode(to reflect.Value) (Type, error) {
 	return decodeFuncOf(to.Type())(d, to)
 }
@@ -91,6 +419,17 @@ func (d Decoder) decodeBool(to reflect.Value) (t Type, err error) {
 func (d Decoder) decodeBoolFromType(t Type, to reflect.Value) (err error) {
 	var v bool
 
+	if v, err = d.parseBool(t); err != nil {
+		return
+	}
+
+	if to.IsValid() {
+		to.SetBool(v)
+	}
+	return
+}
+
+func (d Decoder) parseBool(t Type) (v bool, err error) {
 	switch t {
 	case Nil:
 		err = d.Parser.ParseNil()
@@ -98,17 +437,24 @@ func (d Decoder) decodeBoolFromType(t Type, to reflect.Value) (err error) {
 	case Bool:
 		v, err = d.Parser.ParseBool()
 
+	case String:
+		if !isUntypedParser(d.Parser) {
+			err = typeConversionError(t, Bool)
+			break
+		}
+
+		var b []byte
+
+		if b, err = d.readString(); err == nil {
+			if v, err = strconv.ParseBool(unsafeString(b)); err != nil {
+				err = stringConversionError(b, Bool, err)
+			}
+		}
+
 	default:
 		err = typeConversionError(t, Bool)
 	}
 
-	if err != nil {
-		return
-	}
-
-	if to.IsValid() {
-		to.SetBool(v)
-	}
 	return
 }
 
@@ -121,7 +467,26 @@ func (d Decoder) decodeInt(to reflect.Value) (t Type, err error) {
 
 func (d Decoder) decodeIntFromType(t Type, to reflect.Value) (err error) {
 	var valid = to.IsValid()
+	var typ = int64Type
 	var i int64
+
+	if valid {
+		typ = to.Type()
+	}
+
+	if i, err = d.parseInt(t, typ, valid); err != nil {
+		return
+	}
+
+	if valid {
+		to.SetInt(i)
+	}
+	return
+}
+
+// parseInt parses an integer value, checking that it fits in typ when check is
+// true.
+func (d Decoder) parseInt(t Type, typ reflect.Type, check bool) (i int64, err error) {
 	var u uint64
 
 	switch t {
@@ -133,16 +498,16 @@ func (d Decoder) decodeIntFromType(t Type, to reflect.Value) (err error) {
 			return
 		}
 
-		if valid {
-			switch t := to.Type(); t.Kind() {
+		if check {
+			switch typ.Kind() {
 			case reflect.Int:
-				err = objutil.CheckInt64Bounds(i, int64(objutil.IntMin), uint64(objutil.IntMax), t)
+				err = objutil.CheckInt64Bounds(i, int64(objutil.IntMin), uint64(objutil.IntMax), typ)
 			case reflect.Int8:
-				err = objutil.CheckInt64Bounds(i, objutil.Int8Min, objutil.Int8Max, t)
+				err = objutil.CheckInt64Bounds(i, objutil.Int8Min, objutil.Int8Max, typ)
 			case reflect.Int16:
-				err = objutil.CheckInt64Bounds(i, objutil.Int16Min, objutil.Int16Max, t)
+				err = objutil.CheckInt64Bounds(i, objutil.Int16Min, objutil.Int16Max, typ)
 			case reflect.Int32:
-				err = objutil.CheckInt64Bounds(i, objutil.Int32Min, objutil.Int32Max, t)
+				err = objutil.CheckInt64Bounds(i, objutil.Int32Min, objutil.Int32Max, typ)
 			}
 		}
 
@@ -151,18 +516,18 @@ func (d Decoder) decodeIntFromType(t Type, to reflect.Value) (err error) {
 			return
 		}
 
-		if valid {
-			switch t := to.Type(); t.Kind() {
+		if check {
+			switch typ.Kind() {
 			case reflect.Int:
-				err = objutil.CheckUint64Bounds(u, uint64(objutil.IntMax), t)
+				err = objutil.CheckUint64Bounds(u, uint64(objutil.IntMax), typ)
 			case reflect.Int8:
-				err = objutil.CheckUint64Bounds(u, objutil.Int8Max, t)
+				err = objutil.CheckUint64Bounds(u, objutil.Int8Max, typ)
 			case reflect.Int16:
-				err = objutil.CheckUint64Bounds(u, objutil.Int16Max, t)
+				err = objutil.CheckUint64Bounds(u, objutil.Int16Max, typ)
 			case reflect.Int32:
-				err = objutil.CheckUint64Bounds(u, objutil.Int32Max, t)
+				err = objutil.CheckUint64Bounds(u, objutil.Int32Max, typ)
 			case reflect.Int64:
-				err = objutil.CheckUint64Bounds(u, objutil.Int64Max, t)
+				err = objutil.CheckUint64Bounds(u, objutil.Int64Max, typ)
 			}
 		}
 
@@ -171,40 +536,29 @@ func (d Decoder) decodeIntFromType(t Type, to reflect.Value) (err error) {
 	case String:
 		var b []byte
 
-		if b, err = d.Parser.ParseString(); err != nil {
+		if b, err = d.readString(); err != nil {
 			return
 		}
 
-		i, err = strconv.ParseInt(unsafeString(b), 10, 64)
-		// if an error is received, reparse with a "safe" string in case it is retained in the error
-		if err != nil {
-			_, err = strconv.ParseInt(string(b), 10, 64)
+		if i, err = strconv.ParseInt(unsafeString(b), 10, 64); err != nil {
+			err = stringConversionError(b, Int, err)
 		}
 
 	case Bytes:
 		var b []byte
 
-		if b, err = d.Parser.ParseBytes(); err != nil {
+		if b, err = d.readBytes(); err != nil {
 			return
 		}
 
-		i, err = strconv.ParseInt(unsafeString(b), 10, 64)
-		// if an error is received, reparse with a "safe" string in case it is retained in the error
-		if err != nil {
-			_, err = strconv.ParseInt(string(b), 10, 64)
+		if i, err = strconv.ParseInt(unsafeString(b), 10, 64); err != nil {
+			err = stringConversionError(b, Int, err)
 		}
 
 	default:
 		err = typeConversionError(t, Int)
 	}
 
-	if err != nil {
-		return
-	}
-
-	if valid {
-		to.SetInt(i)
-	}
 	return
 }
 
@@ -267,27 +621,23 @@ func (d Decoder) decodeUintFromType(t Type, to reflect.Value) (err error) {
 	case String:
 		var b []byte
 
-		if b, err = d.Parser.ParseString(); err != nil {
+		if b, err = d.readString(); err != nil {
 			return
 		}
 
-		u, err = strconv.ParseUint(unsafeString(b), 10, 64)
-		// if an error is received, reparse with a "safe" string in case it is retained in the error
-		if err != nil {
-			_, err = strconv.ParseUint(string(b), 10, 64)
+		if u, err = strconv.ParseUint(unsafeString(b), 10, 64); err != nil {
+			err = stringConversionError(b, Uint, err)
 		}
 
 	case Bytes:
 		var b []byte
 
-		if b, err = d.Parser.ParseBytes(); err != nil {
+		if b, err = d.readBytes(); err != nil {
 			return
 		}
 
-		u, err = strconv.ParseUint(unsafeString(b), 10, 64)
-		// if an error is received, reparse with a "safe" string in case it is retained in the error
-		if err != nil {
-			_, err = strconv.ParseUint(string(b), 10, 64)
+		if u, err = strconv.ParseUint(unsafeString(b), 10, 64); err != nil {
+			err = stringConversionError(b, Uint, err)
 		}
 
 	default:
@@ -312,9 +662,24 @@ func (d Decoder) decodeFloat(to reflect.Value) (t Type, err error) {
 }
 
 func (d Decoder) decodeFloatFromType(t Type, to reflect.Value) (err error) {
+	var f float64
+
+	if f, err = d.parseFloat(t); err != nil {
+		return
+	}
+
+	if to.IsValid() {
+		if d.Warn != nil && to.Kind() == reflect.Float32 && f == f && float64(float32(f)) != f {
+			d.warn(Warning{Kind: LossyConversion, Type: to.Type(), Value: f})
+		}
+		to.SetFloat(f)
+	}
+	return
+}
+
+func (d Decoder) parseFloat(t Type) (f float64, err error) {
 	var i int64
 	var u uint64
-	var f float64
 
 	switch t {
 	case Nil:
@@ -335,45 +700,34 @@ func (d Decoder) decodeFloatFromType(t Type, to reflect.Value) (err error) {
 		}
 
 	case Float:
-		f, err = d.Parser.ParseFloat()
+		f, err = d.readFloat()
 
 	case String:
 		var b []byte
 
-		if b, err = d.Parser.ParseString(); err != nil {
+		if b, err = d.readString(); err != nil {
 			return
 		}
 
-		f, err = strconv.ParseFloat(unsafeString(b), 64)
-		// if an error is received, reparse with a "safe" string in case it is retained in the error
-		if err != nil {
-			_, err = strconv.ParseFloat(string(b), 64)
+		if f, err = strconv.ParseFloat(unsafeString(b), 64); err != nil {
+			err = stringConversionError(b, Float, err)
 		}
 
 	case Bytes:
 		var b []byte
 
-		if b, err = d.Parser.ParseBytes(); err != nil {
+		if b, err = d.readBytes(); err != nil {
 			return
 		}
 
-		f, err = strconv.ParseFloat(unsafeString(b), 64)
-		// if an error is received, reparse with a "safe" string in case it is retained in the error
-		if err != nil {
-			_, err = strconv.ParseFloat(string(b), 64)
+		if f, err = strconv.ParseFloat(unsafeString(b), 64); err != nil {
+			err = stringConversionError(b, Float, err)
 		}
 
 	default:
 		err = typeConversionError(t, Float)
 	}
 
-	if err != nil {
-		return
-	}
-
-	if to.IsValid() {
-		to.SetFloat(f)
-	}
 	return
 }
 
@@ -388,73 +742,85 @@ func (d Decoder) decodeStringFromType(t Type, to reflect.Value) (err error) {
 	var a [64]byte
 	var b []byte
 
+	if b, err = d.parseString(t, a[:0]); err != nil {
+		return
+	}
+
+	if t == String && d.Resolver != nil {
+		if b, err = d.interpolate(b); err != nil {
+			return
+		}
+	}
+
+	if to.IsValid() {
+		to.SetString(string(b))
+	}
+	return
+}
+
+// parseString parses a value of any scalar type and returns its string
+// representation, a is used as buffer to format values that aren't strings.
+func (d Decoder) parseString(t Type, a []byte) (b []byte, err error) {
 	switch t {
 	case Nil:
 		err = d.Parser.ParseNil()
 
 	case String:
-		b, err = d.Parser.ParseString()
+		b, err = d.readString()
 
 	case Bytes:
-		b, err = d.Parser.ParseBytes()
+		b, err = d.readBytes()
 
 	case Bool:
 		var v bool
 		if v, err = d.Parser.ParseBool(); err == nil {
 			if v {
-				b = append(a[:0], "true"...)
+				b = append(a, "true"...)
 			} else {
-				b = append(a[:0], "false"...)
+				b = append(a, "false"...)
 			}
 		}
 
 	case Int:
 		var v int64
 		if v, err = d.Parser.ParseInt(); err == nil {
-			b = strconv.AppendInt(a[:0], v, 10)
+			b = strconv.AppendInt(a, v, 10)
 		}
 
 	case Uint:
 		var v uint64
 		if v, err = d.Parser.ParseUint(); err == nil {
-			b = strconv.AppendUint(a[:0], v, 10)
+			b = strconv.AppendUint(a, v, 10)
 		}
 
 	case Float:
 		var v float64
-		if v, err = d.Parser.ParseFloat(); err == nil {
-			b = strconv.AppendFloat(a[:0], v, 'g', -1, 64)
+		if v, err = d.readFloat(); err == nil {
+			b = strconv.AppendFloat(a, v, 'g', -1, 64)
 		}
 
 	case Time:
 		var v time.Time
 		if v, err = d.Parser.ParseTime(); err == nil {
-			b = v.AppendFormat(a[:0], time.RFC3339Nano)
+			b = v.AppendFormat(a, time.RFC3339Nano)
 		}
 
 	case Duration:
 		var v time.Duration
 		if v, err = d.Parser.ParseDuration(); err == nil {
-			b = objutil.AppendDuration(a[:0], v)
+			b = objutil.AppendDuration(a, v)
 		}
 
 	case Error:
 		var v error
 		if v, err = d.Parser.ParseError(); err == nil {
-			b = append(a[:0], v.Error()...)
+			b = append(a, v.Error()...)
 		}
 
 	default:
 		err = typeConversionError(t, String)
 	}
 
-	if err != nil {
-		return
-	}
-
-	if to.IsValid() {
-		to.SetString(string(b))
-	}
 	return
 }
 
@@ -468,15 +834,32 @@ func (d Decoder) decodeBytes(to reflect.Value) (t Type, err error) {
 func (d Decoder) decodeBytesFromType(t Type, to reflect.Value) (err error) {
 	var b []byte
 
+	if b, err = d.parseBytes(t); err != nil {
+		return
+	}
+
+	if to.IsValid() {
+		if t == Nil {
+			to.SetBytes(nil)
+		} else {
+			to.SetBytes(copyBytes(b))
+		}
+	}
+	return
+}
+
+// parseBytes parses a byte sequence, the returned slice may point to the
+// internal buffer of the parser and must be copied if it is retained.
+func (d Decoder) parseBytes(t Type) (b []byte, err error) {
 	switch t {
 	case Nil:
 		err = d.Parser.ParseNil()
 
 	case String:
-		b, err = d.Parser.ParseString()
+		b, err = d.readString()
 
 	case Bytes:
-		b, err = d.Parser.ParseBytes()
+		b, err = d.readBytes()
 
 	default:
 		err = typeConversionError(t, String)
@@ -487,23 +870,18 @@ func (d Decoder) decodeBytesFromType(t Type, to reflect.Value) (err error) {
 	}
 
 	if bd, ok := d.Parser.(bytesDecoder); ok {
-		if b, err = bd.DecodeBytes(b); err != nil {
-			return
-		}
+		b, err = bd.DecodeBytes(b)
 	}
 
-	if to.IsValid() {
-		if t == Nil {
-			to.SetBytes(nil)
-		} else {
-			v := make([]byte, len(b))
-			copy(v, b)
-			to.SetBytes(v)
-		}
-	}
 	return
 }
 
+func copyBytes(b []byte) []byte {
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
+
 func (d Decoder) decodeTime(to reflect.Value) (t Type, err error) {
 	if t, err = d.Parser.ParseType(); err == nil {
 		err = d.decodeTimeFromType(t, to)
@@ -512,18 +890,32 @@ func (d Decoder) decodeTime(to reflect.Value) (t Type, err error) {
 }
 
 func (d Decoder) decodeTimeFromType(t Type, to reflect.Value) (err error) {
-	var s []byte
 	var v time.Time
 
+	if v, err = d.parseTime(t, to.IsValid()); err != nil {
+		return
+	}
+
+	if to.IsValid() {
+		*(to.Addr().Interface().(*time.Time)) = v
+	}
+	return
+}
+
+// parseTime parses a time value, time strings are only parsed when convert is
+// true.
+func (d Decoder) parseTime(t Type, convert bool) (v time.Time, err error) {
+	var s []byte
+
 	switch t {
 	case Nil:
 		err = d.Parser.ParseNil()
 
 	case String:
-		s, err = d.Parser.ParseString()
+		s, err = d.readString()
 
 	case Bytes:
-		s, err = d.Parser.ParseBytes()
+		s, err = d.readBytes()
 
 	case Time:
 		v, err = d.Parser.ParseTime()
@@ -533,15 +925,10 @@ func (d Decoder) decodeTimeFromType(t Type, to reflect.Value) (err error) {
 		return
 	}
 
-	if to.IsValid() {
-		if t == String || t == Bytes {
-			v, err = time.Parse(time.RFC3339Nano, unsafeString(s))
-			// if an error is received, reparse with a "safe" string in case it is retained in the error
-			if err != nil {
-				_, err = time.Parse(time.RFC3339Nano, string(t))
-			}
+	if convert && (t == String || t == Bytes) {
+		if v, err = time.Parse(time.RFC3339Nano, unsafeString(s)); err != nil {
+			err = stringConversionError(s, Time, err)
 		}
-		*(to.Addr().Interface().(*time.Time)) = v
 	}
 	return
 }
@@ -562,10 +949,10 @@ func (d Decoder) decodeDurationFromType(t Type, to reflect.Value) (err error) {
 		err = d.Parser.ParseNil()
 
 	case String:
-		s, err = d.Parser.ParseString()
+		s, err = d.readString()
 
 	case Bytes:
-		s, err = d.Parser.ParseBytes()
+		s, err = d.readBytes()
 
 	case Duration:
 		v, err = d.Parser.ParseDuration()
@@ -576,10 +963,8 @@ func (d Decoder) decodeDurationFromType(t Type, to reflect.Value) (err error) {
 	}
 
 	if t == String || t == Bytes {
-		v, err = time.ParseDuration(unsafeString(s))
-		// if an error is received, reparse with a "safe" string in case it is retained in the error
-		if err != nil {
-			_, err = time.ParseDuration(string(s))
+		if v, err = time.ParseDuration(unsafeString(s)); err != nil {
+			err = stringConversionError(s, Duration, err)
 		}
 	}
 
@@ -605,10 +990,10 @@ func (d Decoder) decodeErrorFromType(t Type, to reflect.Value) (err error) {
 		err = d.Parser.ParseNil()
 
 	case String:
-		s, err = d.Parser.ParseString()
+		s, err = d.readString()
 
 	case Bytes:
-		s, err = d.Parser.ParseBytes()
+		s, err = d.readBytes()
 
 	case Error:
 		v, err = d.Parser.ParseError()
@@ -659,6 +1044,13 @@ func (d Decoder) decodeSliceFromTypeWith(typ Type, to reflect.Value, f decodeFun
 	i := 0
 	n := 0
 
+	if typ == Array {
+		if h := parseLengthHint(d.Parser); h > 0 {
+			n = h
+			s = reflect.MakeSlice(t, n, n)
+		}
+	}
+
 	if err = d.decodeArrayImpl(typ, func(d Decoder) (err error) {
 		if i == n {
 			if n *= 5; n == 0 {
@@ -668,7 +1060,11 @@ func (d Decoder) decodeSliceFromTypeWith(typ Type, to reflect.Value, f decodeFun
 			reflect.Copy(sc, s)
 			s = sc
 		}
+		if d.Positions != nil {
+			d.path = d.recordPosition(d.path, i, "")
+		}
 		if _, err = f(d, s.Index(i)); err != nil {
+			err = d.prefixFieldPath(err, indexPath(i))
 			return
 		}
 		i++
@@ -713,7 +1109,11 @@ func (d Decoder) decodeArrayFromTypeWith(typ Type, to reflect.Value, f decodeFun
 
 	if err = d.decodeArrayImpl(typ, func(d Decoder) (err error) {
 		if i < n {
+			if d.Positions != nil {
+				d.path = d.recordPosition(d.path, i, "")
+			}
 			if _, err = f(d, to.Index(i)); err != nil {
+				err = d.prefixFieldPath(err, indexPath(i))
 				return
 			}
 		}
@@ -726,7 +1126,7 @@ func (d Decoder) decodeArrayFromTypeWith(typ Type, to reflect.Value, f decodeFun
 	if typ == Nil {
 		to.Set(zeroValueOf(t))
 	} else if i != n {
-		err = fmt.Errorf("objconv: array length mismatch, expected %d but only %d elements were decoded", n, i)
+		err = objutil.Errorf(objutil.ErrRange, "objconv: array length mismatch, expected %d but only %d elements were decoded", n, i)
 	}
 
 	return
@@ -795,13 +1195,17 @@ func (d Decoder) decodeMapFromTypeWith(typ Type, to reflect.Value, kf decodeFunc
 	if err = d.decodeMapImpl(typ, func(kd Decoder, vd Decoder) (err error) {
 		kv.Set(kz) // reset the key to its zero-value
 		vv.Set(vz) // reset the value to its zero-value
-		if _, err = kf(d, kv); err != nil {
+		if _, err = kf(kd, kv); err != nil {
 			return
 		}
+		if d.Conformance >= Standard && m.MapIndex(kv).IsValid() {
+			return d.duplicateKey(keyPath(kv))
+		}
 		if err = d.Parser.ParseMapValue(vd.off - 1); err != nil {
 			return
 		}
 		if _, err = vf(d, vv); err != nil {
+			err = d.prefixFieldPath(err, keyPath(kv))
 			return
 		}
 		m.SetMapIndex(kv, vv)
@@ -837,7 +1241,11 @@ func (d Decoder) decodeMapInterfaceInterface(typ Type, to reflect.Value) error {
 		if err = kd.Decode(&k); err != nil {
 			return
 		}
+		if _, dup := m[k]; dup && d.Conformance >= Standard {
+			return d.duplicateKey(fmt.Sprint(k))
+		}
 		if err = vd.Decode(&v); err != nil {
+			err = d.prefixFieldPath(err, fmt.Sprint(k))
 			return
 		}
 
@@ -854,6 +1262,10 @@ func (d Decoder) decodeMapStringInterface(typ Type, to reflect.Value) (err error
 		to.Set(reflect.ValueOf(m))
 	}
 
+	return d.decodeMapStringInterfaceInto(typ, m)
+}
+
+func (d Decoder) decodeMapStringInterfaceInto(typ Type, m map[string]interface{}) (err error) {
 	for k := range m {
 		delete(m, k)
 	}
@@ -868,7 +1280,12 @@ func (d Decoder) decodeMapStringInterface(typ Type, to reflect.Value) (err error
 		}
 		k = string(b)
 
+		if _, dup := m[k]; dup && d.Conformance >= Standard {
+			return d.duplicateKey(k)
+		}
+
 		if err = vd.Decode(&v); err != nil {
+			err = d.prefixFieldPath(err, k)
 			return
 		}
 
@@ -885,6 +1302,10 @@ func (d Decoder) decodeMapStringString(typ Type, to reflect.Value) (err error) {
 		to.Set(reflect.ValueOf(m))
 	}
 
+	return d.decodeMapStringStringInto(typ, m)
+}
+
+func (d Decoder) decodeMapStringStringInto(typ Type, m map[string]string) (err error) {
 	for k := range m {
 		delete(m, k)
 	}
@@ -893,19 +1314,31 @@ func (d Decoder) decodeMapStringString(typ Type, to reflect.Value) (err error) {
 		var b []byte
 		var k string
 		var v string
+		var t Type
 
 		if _, b, err = d.decodeTypeAndString(); err != nil {
 			return
 		}
 		k = string(b)
 
+		if _, dup := m[k]; dup && d.Conformance >= Standard {
+			return d.duplicateKey(k)
+		}
+
 		if err = d.Parser.ParseMapValue(vd.off - 1); err != nil {
 			return
 		}
 
-		if _, b, err = d.decodeTypeAndString(); err != nil {
+		if t, b, err = d.decodeTypeAndString(); err != nil {
+			err = d.prefixFieldPath(err, k)
 			return
 		}
+		if t == String && d.Resolver != nil {
+			if b, err = d.interpolate(b); err != nil {
+				err = d.prefixFieldPath(err, k)
+				return
+			}
+		}
 		v = string(b)
 
 		m[k] = v
@@ -914,7 +1347,7 @@ func (d Decoder) decodeMapStringString(typ Type, to reflect.Value) (err error) {
 }
 
 func (d Decoder) decodeStruct(to reflect.Value) (Type, error) {
-	return d.decodeStructWith(to, structCache.lookup(to.Type()))
+	return d.decodeStructWith(to, structCacheOf(d.TagNames, d.FieldNaming).lookup(to.Type()))
 }
 
 func (d Decoder) decodeStructWith(to reflect.Value, s *structType) (t Type, err error) {
@@ -925,24 +1358,49 @@ func (d Decoder) decodeStructWith(to reflect.Value, s *structType) (t Type, err
 }
 
 func (d Decoder) decodeStructFromTypeWith(typ Type, to reflect.Value, s *structType) (err error) {
+	if s.err != nil {
+		return s.err
+	}
+
+	if d.Warn != nil || d.FieldRecorder != nil || d.DisallowUnknownFields || d.Positions != nil || d.Conformance >= Standard {
+		return d.decodeStructFromTypeTracked(typ, to, s)
+	}
+
+	var field string
+
+	if RecoverPanics {
+		defer repanic(&field)
+	}
+
 	if err = d.decodeMapImpl(typ, func(kd Decoder, vd Decoder) (err error) {
 		var b []byte
 
 		if _, b, err = d.decodeTypeAndString(); err != nil {
 			return
 		}
-		f := s.fieldsByName[string(b)]
+		f := s.field(b)
 
 		if err = d.Parser.ParseMapValue(vd.off - 1); err != nil {
 			return
 		}
 
-		if f == nil {
+		if f == nil && s.rest != nil {
+			if err = d.decodeRest(to, s, b); err != nil {
+				err = d.prefixFieldPath(err, string(b))
+			}
+			return
+		}
+
+		if f == nil || f.discarded() {
 			_, err = d.decodeInterface(reflect.Value{}) // discard
 			return
 		}
 
-		_, err = f.decode(d, to.FieldByIndex(f.index))
+		field = f.name
+		if err = f.decodeInto(d, to); err != nil {
+			err = d.prefixFieldPath(err, f.name)
+		}
+		field = ""
 		return
 	}); err != nil {
 		to.Set(zeroValueOf(to.Type()))
@@ -950,6 +1408,122 @@ func (d Decoder) decodeStructFromTypeWith(typ Type, to reflect.Value, s *structT
 	return
 }
 
+// decodeStructFromTypeTracked is the slower version of the struct decoding
+// algorithm used when d.Warn, d.FieldRecorder, d.DisallowUnknownFields,
+// d.Positions or d.Conformance are set, it keeps track of the fields that were
+// seen in the input.
+func (d Decoder) decodeStructFromTypeTracked(typ Type, to reflect.Value, s *structType) (err error) {
+	var seen = make([]bool, len(s.fields))
+	var field string
+	var path = d.path
+	var pos Position
+	var hasPos bool
+
+	if RecoverPanics {
+		defer repanic(&field)
+	}
+
+	if err = d.decodeMapImpl(typ, func(kd Decoder, vd Decoder) (err error) {
+		var b []byte
+
+		if d.Positions != nil {
+			pos, hasPos = d.position()
+		}
+
+		if _, b, err = d.decodeTypeAndString(); err != nil {
+			return
+		}
+		i := s.lookup.index(b)
+
+		if err = d.Parser.ParseMapValue(vd.off - 1); err != nil {
+			return
+		}
+
+		if i >= 0 && s.fields[i].discarded() {
+			_, err = d.decodeInterface(reflect.Value{}) // discard
+			return
+		}
+
+		if i < 0 && s.rest != nil {
+			if d.Positions != nil {
+				d.path = d.recordPosition(path, -1, string(b))
+			}
+			if err = d.decodeRest(to, s, b); err != nil {
+				err = d.prefixFieldPath(err, string(b))
+			}
+			return
+		}
+
+		if i < 0 {
+			if hasPos {
+				d.Positions[appendFieldPath(path, -1, string(b))] = pos
+			}
+			if d.Warn != nil {
+				d.warn(Warning{Kind: UnknownField, Type: s.typ, Field: string(b)})
+			}
+			if d.DisallowUnknownFields {
+				return d.prefixFieldPath(&FieldError{
+					Code: unknownFieldCode,
+					Err:  objutil.Errorf(objutil.ErrUnknownField, "objconv: the input has a field named %q which doesn't exist in %s", b, s.typ),
+				}, string(b))
+			}
+			_, err = d.decodeInterface(reflect.Value{}) // discard
+			return
+		}
+
+		f := &s.fields[i]
+		if seen[i] && d.Conformance >= Standard {
+			return d.duplicateKey(string(b))
+		}
+		seen[i] = true
+		d.field, field = f.name, f.name
+		if d.Positions != nil {
+			d.path = d.recordPosition(path, -1, f.name)
+		}
+		if err = f.decodeInto(d, to); err != nil {
+			err = d.prefixFieldPath(err, f.name)
+		}
+		field = ""
+		return
+	}); err != nil {
+		to.Set(zeroValueOf(to.Type()))
+		return
+	}
+
+	if typ == Map {
+		for i := range s.fields {
+			if s.fields[i].discarded() || s.fields[i].alias {
+				continue
+			}
+			if d.FieldRecorder != nil {
+				d.FieldRecorder.RecordField(s.typ, s.fields[i].name, seen[i])
+			}
+			if d.Warn != nil && !seen[i] {
+				d.warn(Warning{Kind: UnusedField, Type: s.typ, Field: s.fields[i].name})
+			}
+		}
+	}
+	return
+}
+
+// decodeRest decodes the value of the key k, which matches no fields of s,
+// into the inline map of to. The map is allocated if it was nil.
+func (d Decoder) decodeRest(to reflect.Value, s *structType, k []byte) (err error) {
+	m := s.rest.target(to)
+	v := reflect.New(m.Type().Elem()).Elem()
+
+	if _, err = s.rest.decode(d, v); err != nil {
+		return
+	}
+
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+
+	m.SetMapIndex(reflect.ValueOf(string(k)).Convert(m.Type().Key()), v)
+	return
+}
+
 func (d Decoder) decodePointer(to reflect.Value) (Type, error) {
 	return d.decodePointerWith(to, decodeFuncOf(to.Type().Elem()))
 }
@@ -983,6 +1557,18 @@ func (d Decoder) decodeDecoderPointer(to reflect.Value) (Type, error) {
 }
 
 func (d Decoder) decodeDecoder(to reflect.Value) (Type, error) {
+	if to.Kind() == reflect.Ptr && to.IsNil() && to.CanSet() {
+		// Nil pointers to types implementing ValueDecoder are allocated so
+		// the method isn't called on a nil receiver, unless the value is nil.
+		t, err := d.Parser.ParseType()
+		if err != nil || t == Nil {
+			if err == nil {
+				err = d.Parser.ParseNil()
+			}
+			return t, err
+		}
+		to.Set(reflect.New(to.Type().Elem()))
+	}
 	return Unknown /* just needs to not be Nil */, to.Interface().(ValueDecoder).DecodeValue(d)
 }
 
@@ -1045,6 +1631,20 @@ func (d Decoder) decodeInterface(to reflect.Value) (t Type, err error) {
 }
 
 func (d Decoder) decodeInterfaceFromType(t Type, to reflect.Value) (err error) {
+	if p, ok := d.Parser.(ValueTypeParser); ok && t != Nil && to.IsValid() {
+		if typ := p.ValueType(); typ != nil {
+			v := reflect.New(typ).Elem()
+			if _, err = d.decode(v); err == nil {
+				to.Set(v)
+			}
+			return
+		}
+	}
+
+	if d.UseNumber && (t == Int || t == Uint || t == Float) {
+		return d.decodeInterfaceFrom(numberType, t, to, Decoder.decodeNumberFromType)
+	}
+
 	switch t {
 	case Nil:
 		err = d.decodeInterfaceFromNil(to)
@@ -1076,6 +1676,9 @@ func (d Decoder) decodeInterfaceFromType(t Type, to reflect.Value) (err error) {
 		} else {
 			err = d.decodeInterfaceFrom(mapInterfaceInterfaceType, t, to, Decoder.decodeMapFromType)
 		}
+		if err == nil && d.PreserveTypes && to.IsValid() {
+			err = restoreNamedType(to)
+		}
 	default:
 		panic("objconv: parser returned an unsupported value type: " + t.String())
 	}
@@ -1107,7 +1710,7 @@ func (d Decoder) decodeInterfaceFrom(from reflect.Type, t Type, to reflect.Value
 }
 
 func (d Decoder) decodeUnsupported(to reflect.Value) (Type, error) {
-	return Nil, fmt.Errorf("objconv: the decoder doesn't support values of type %s", to.Type())
+	return Nil, objutil.Errorf(objutil.ErrType, "objconv: the decoder doesn't support values of type %s", to.Type())
 }
 
 func (d Decoder) decodeTypeAndString() (t Type, b []byte, err error) {
@@ -1118,9 +1721,9 @@ func (d Decoder) decodeTypeAndString() (t Type, b []byte, err error) {
 		case Nil:
 			err = d.Parser.ParseNil()
 		case String:
-			b, err = d.Parser.ParseString()
+			b, err = d.readString()
 		case Bytes:
-			b, err = d.Parser.ParseBytes()
+			b, err = d.readBytes()
 		default:
 			err = typeConversionError(t, String)
 		}
@@ -1149,6 +1752,7 @@ func (d Decoder) DecodeArray(f func(Decoder) error) (err error) {
 
 func (d Decoder) decodeArrayImpl(t Type, f func(Decoder) error) (err error) {
 	var n int
+	d.nested = true
 
 	switch t {
 	case Nil:
@@ -1156,9 +1760,20 @@ func (d Decoder) decodeArrayImpl(t Type, f func(Decoder) error) (err error) {
 		return
 
 	case Array:
-		n, err = d.Parser.ParseArrayBegin()
+		defer d.leave()
+
+		if err = d.enter(); err == nil {
+			if n, err = d.Parser.ParseArrayBegin(); err == nil {
+				err = d.Limits.checkArrayLen(n)
+			}
+		}
 
 	default:
+		if isUntypedParser(d.Parser) {
+			// The value is the only element of the array, f parses its type
+			// again.
+			return f(d)
+		}
 		err = typeConversionError(t, Array)
 	}
 
@@ -1178,6 +1793,14 @@ func (d Decoder) decodeArrayImpl(t Type, f func(Decoder) error) (err error) {
 				return
 			}
 		}
+		if n < 0 {
+			if err = d.Limits.checkArrayLen(i + 1); err != nil {
+				return
+			}
+		}
+		if err = d.count(1, 0); err != nil {
+			return
+		}
 		if err = f(d); err != nil {
 			return
 		}
@@ -1213,6 +1836,8 @@ func (d Decoder) DecodeMap(f func(Decoder, Decoder) error) (err error) {
 
 func (d Decoder) decodeMapImpl(t Type, f func(Decoder, Decoder) error) (err error) {
 	var n int
+	var top bool // whether the map is the top-level value of a stream element
+	d.nested = true
 
 	switch t {
 	case Nil:
@@ -1220,7 +1845,16 @@ func (d Decoder) decodeMapImpl(t Type, f func(Decoder, Decoder) error) (err erro
 		return
 
 	case Map:
-		n, err = d.Parser.ParseMapBegin()
+		defer d.leave()
+
+		if err = d.enter(); err == nil {
+			if n, err = d.Parser.ParseMapBegin(); err == nil {
+				err = d.Limits.checkMapLen(n)
+			}
+			if top = d.record != nil && d.record.state == recordWait; top {
+				d.record.beginMap(n)
+			}
+		}
 
 	default:
 		err = typeConversionError(t, Map)
@@ -1242,15 +1876,34 @@ func (d Decoder) decodeMapImpl(t Type, f func(Decoder, Decoder) error) (err erro
 				return
 			}
 		}
+		if n < 0 {
+			if err = d.Limits.checkMapLen(i + 1); err != nil {
+				return
+			}
+		}
+		if err = d.count(2, 0); err != nil {
+			return
+		}
 
 		d1 := d
+		d1.Resolver = nil // map keys are not interpolated
 		d2 := d
 		d2.off = i + 1
 
+		if i == 0 && top {
+			if t, _ := d.Parser.ParseType(); t == String {
+				d.record.state = recordKey
+			}
+		}
+
 		if err = f(d1, d2); err != nil {
 			return
 		}
 
+		if i == 0 && top && d.record.state == recordKey {
+			d.record.state = recordDone
+		}
+
 		i++
 	}
 
@@ -1270,10 +1923,80 @@ type StreamDecoder struct {
 	// there is not destination type (when decoding to an empty interface).
 	MapType reflect.Type
 
+	// When set, values loaded by Decode are given to the capturer.
+	Capturer Capturer
+
+	// Warn is called with the non-fatal issues found while decoding values,
+	// see Decoder.Warn for details.
+	Warn func(Warning)
+
+	// When set, the usage of struct fields is reported to the recorder.
+	FieldRecorder FieldRecorder
+
+	// When set, values of registered named types are restored when decoding
+	// into empty interfaces, see Decoder.PreserveTypes.
+	PreserveTypes bool
+
+	// TagNames lists the struct tags that the names and options of fields
+	// are read from, see Encoder.TagNames.
+	TagNames []string
+
+	// FieldNaming is the naming convention applied to the names of struct
+	// fields, see Encoder.FieldNaming.
+	FieldNaming FieldNaming
+
+	// When set, keys of the input that don't match any field of the
+	// destination struct are errors, see Decoder.DisallowUnknownFields.
+	DisallowUnknownFields bool
+
+	// When set, numbers decoded into empty interfaces are values of type
+	// Number, see Decoder.UseNumber.
+	UseNumber bool
+
+	// When set, references found in decoded strings are replaced by their
+	// resolution, see Decoder.Resolver.
+	Resolver Resolver
+
+	// Keys provides the keys that encrypted fields are decrypted with, see
+	// Decoder.Keys.
+	Keys KeyProvider
+
+	// Conformance is the level of strictness applied to the values of the
+	// stream, see Decoder.Conformance. Streams are made of multiple values, so
+	// data following the elements is not considered trailing data.
+	Conformance Conformance
+
+	// Limits configures the limits applied to the values of the stream, see
+	// Decoder.Limits. The stream itself may have any number of values.
+	Limits ParserConfig
+
+	// Spill is applied to the values decoded by the stream, see Decoder.Spill.
+	Spill SpillConfig
+
+	// When set, the stats are reset and updated by each call to Decode, see
+	// Decoder.Stats.
+	Stats *DecodeStats
+
+	// Sequence configures the decoder to read a stream made of consecutive
+	// top-level values, like newline-delimited records or bare scalars, instead
+	// of a single array. The stream ends when the input is exhausted.
+	Sequence bool
+
+	// When set on a decoder reading a sequence, parse errors don't end the
+	// stream, the decoder skips the input up to the next record boundary and
+	// reports the range of bytes that were discarded to Resync before decoding
+	// the next value. This is only possible if the parser implements the
+	// ResyncParser interface.
+	Resync func(SkippedRange)
+
 	err error
 	typ Type
 	cnt int
 	max int
+	pos int64 // offset of the last value
+
+	// Used to detect terminal error records in the stream.
+	record streamRecord
 }
 
 // NewStreamDecoder returns a new stream decoder that takes input from p.
@@ -1295,6 +2018,10 @@ func (d *StreamDecoder) Len() int {
 		return 0
 	}
 
+	if d.Sequence {
+		return -1
+	}
+
 	if d.typ == Unknown {
 		if d.init() != nil {
 			return 0
@@ -1320,12 +2047,32 @@ func (d *StreamDecoder) Decode(v interface{}) error {
 		return d.err
 	}
 
+	if d.Sequence {
+		return d.decodeSequence(v)
+	}
+
 	err := error(nil)
 	cnt := d.cnt
 	max := d.max
 	dec := Decoder{
-		Parser:  d.Parser,
-		MapType: d.MapType,
+		Parser:        d.Parser,
+		MapType:       d.MapType,
+		Capturer:      d.Capturer,
+		Warn:          d.Warn,
+		FieldRecorder: d.FieldRecorder,
+		PreserveTypes: d.PreserveTypes,
+		TagNames:      d.TagNames,
+		FieldNaming:   d.FieldNaming,
+
+		DisallowUnknownFields: d.DisallowUnknownFields,
+		UseNumber:             d.UseNumber,
+		Resolver:              d.Resolver,
+		Keys:                  d.Keys,
+		Conformance:           d.Conformance,
+		Limits:                d.Limits,
+		Spill:                 d.Spill,
+		Stats:                 d.Stats,
+		stream:                true,
 	}
 
 	switch d.typ {
@@ -1344,14 +2091,14 @@ func (d *StreamDecoder) Decode(v interface{}) error {
 		if cnt == max {
 			err = End
 		} else {
-			switch err = dec.Decode(v); err {
+			switch err = d.decodeElement(dec, v); err {
 			case nil:
 				cnt++
 			case End:
 				cnt++
 				max = cnt
 			default:
-				if max < 0 && dec.Parser.ParseArrayEnd(cnt) == nil {
+				if _, stop := err.(*StreamError); !stop && max < 0 && dec.Parser.ParseArrayEnd(cnt) == nil {
 					err = End
 				}
 			}
@@ -1364,6 +2111,133 @@ func (d *StreamDecoder) Decode(v interface{}) error {
 	return err
 }
 
+// DecodeContext is like Decode, but the parser is bound to ctx while the value
+// is decoded, see Decoder.DecodeContext. Since the position of the parser in
+// the input is lost when a read is aborted, the stream returns the error of
+// ctx from all the following calls to Decode.
+func (d *StreamDecoder) DecodeContext(ctx context.Context, v interface{}) (err error) {
+	if d.err != nil {
+		return d.err
+	}
+
+	if err = parseContext(d.Parser, ctx); err != nil {
+		return
+	}
+
+	if err = d.Decode(v); err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+		d.err = err
+	}
+	return
+}
+
+func (d *StreamDecoder) decodeSequence(v interface{}) (err error) {
+	for {
+		// Reaching the end of the input at a value boundary is the natural end
+		// of a sequence.
+		_, err = d.Parser.ParseType()
+		off := d.offset()
+
+		switch err {
+		case nil:
+			err = d.decodeElement(Decoder{
+				Parser:        d.Parser,
+				MapType:       d.MapType,
+				Capturer:      d.Capturer,
+				Warn:          d.Warn,
+				FieldRecorder: d.FieldRecorder,
+				PreserveTypes: d.PreserveTypes,
+				TagNames:      d.TagNames,
+				FieldNaming:   d.FieldNaming,
+
+				DisallowUnknownFields: d.DisallowUnknownFields,
+				UseNumber:             d.UseNumber,
+				Resolver:              d.Resolver,
+				Keys:                  d.Keys,
+				Conformance:           d.Conformance,
+				Limits:                d.Limits,
+				Spill:                 d.Spill,
+				Stats:                 d.Stats,
+				stream:                true,
+			}, v)
+		case io.EOF:
+			err = End
+		}
+
+		if err == nil {
+			d.cnt++
+			break
+		}
+
+		if !d.resync(off, err) {
+			break
+		}
+	}
+
+	d.err = err
+	return
+}
+
+// DecodeAt decodes the next value from the stream into v like Decode, and
+// returns the offset and length of the bytes that the value was decoded from.
+//
+// The method returns an error if the parser doesn't implement the OffsetParser
+// interface.
+func (d *StreamDecoder) DecodeAt(v interface{}) (off int64, n int64, err error) {
+	p, ok := d.Parser.(OffsetParser)
+	if !ok {
+		err = fmt.Errorf("objconv: offsets are not supported by parsers of type %T", d.Parser)
+		return
+	}
+
+	if err = d.Decode(v); err == nil {
+		off, n = d.pos, p.Offset()-d.pos
+	}
+
+	return
+}
+
+func (d *StreamDecoder) offset() int64 {
+	if p, ok := d.Parser.(OffsetParser); ok {
+		return p.Offset()
+	}
+	return 0
+}
+
+// resync attempts to skip past the record starting at off which caused err,
+// returning true if the decoder can carry on with the next record.
+func (d *StreamDecoder) resync(off int64, err error) bool {
+	if d.Resync == nil || err == End {
+		return false
+	}
+
+	if _, stop := err.(*StreamError); stop {
+		return false
+	}
+
+	p, ok := d.Parser.(ResyncParser)
+	if !ok {
+		return false
+	}
+
+	// Reaching the end of the input is fine as long as some bytes were skipped,
+	// it happens when the last record of the sequence was truncated.
+	if rerr := p.Resync(); rerr != nil && (rerr != io.EOF || p.Offset() == off) {
+		return false
+	}
+
+	d.Resync(SkippedRange{Offset: off, Length: p.Offset() - off, Err: err})
+	return true
+}
+
+// SkippedRange describes a range of bytes that a stream decoder discarded to
+// recover from an error.
+type SkippedRange struct {
+	Offset int64 // offset of the first skipped byte in the input
+	Length int64 // number of bytes skipped
+	Err    error // error that caused the bytes to be skipped
+}
+
 // Encoder returns a new StreamEncoder which can be used to re-encode the stream
 // decoded by d into e.
 //
@@ -1417,7 +2291,7 @@ func (f ValueDecoderFunc) DecodeValue(d Decoder) error { return f(d) }
 
 type decodeFuncOpts struct {
 	recurse bool
-	structs map[reflect.Type]*structType
+	structs *structTypes
 }
 
 type decodeFunc func(Decoder, reflect.Value) (Type, error)
@@ -1435,6 +2309,19 @@ func makeDecodeFunc(t reflect.Type, opts decodeFuncOpts) decodeFunc {
 		}
 	}
 
+	if t.Kind() == reflect.Ptr {
+		// Adapters installed for the element type take precedence over the
+		// methods of the pointer type, for example *url.URL implements
+		// encoding.BinaryUnmarshaler but an adapter exists for url.URL.
+		if _, ok := AdapterOf(t.Elem()); ok {
+			return makeDecodePtrFunc(t, opts)
+		}
+	}
+
+	if fn, ok := constructorOf(t); ok {
+		return makeConstructorDecodeFunc(fn, opts)
+	}
+
 	// fast path: check if it's a basic go type
 	switch t {
 	case boolType:
@@ -1589,14 +2476,99 @@ func makeDecodePtrFunc(t reflect.Type, opts decodeFuncOpts) decodeFunc {
 	}
 }
 
-// unsafeString returns a string that is only safe to use under the following conditions:
-// - b points to data on the heap
-// - the bytes pointed to by b will not be modified while the returned string exists
-// - the returned string will not be stored past the lifetime of b
-// if the method being called with an unsafe returns an error, that error may
-// contain a reference to unsafe string. if the method produces consistent results,
-// calling it again with a safe string should return an error that can be safely
-// returned to the caller.
-func unsafeString(b []byte) string {
-	return *(*string)(unsafe.Pointer(&b))
+// recordPosition records the position of the next value of the parser under
+// the path of the struct field or array element within path that it is
+// decoded into, and returns the path of the value.
+func (d Decoder) recordPosition(path string, index int, name string) string {
+	path = appendFieldPath(path, index, name)
+
+	if pos, ok := d.position(); ok {
+		d.Positions[path] = pos
+	}
+
+	return path
+}
+
+func (d Decoder) position() (Position, bool) {
+	p, ok := d.Parser.(PositionParser)
+	if !ok {
+		return Position{}, false
+	}
+	return p.Position(), true
+}
+
+// appendFieldPath returns the path to a struct field, or to an array element
+// when name is empty, within the value at path.
+func appendFieldPath(path string, index int, name string) string {
+	switch {
+	case len(name) == 0:
+		return path + "[" + strconv.Itoa(index) + "]"
+	case len(path) == 0:
+		return name
+	default:
+		return path + "." + name
+	}
+}
+
+// stringConversionError returns the error reported when the string b, decoded
+// as a value of type t, failed to parse with err. The errors of the strconv and
+// time packages are not returned because they retain b, which may reference
+// the buffer of the parser.
+func stringConversionError(b []byte, t Type, err error) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return objutil.Errorf(objutil.ErrRange, "objconv: the string %q is out of the range of %s values", b, t)
+	}
+	if _, ok := err.(*strconv.NumError); ok {
+		return objutil.Errorf(objutil.ErrType, "objconv: cannot convert the string %q to %s", b, t)
+	}
+	return objutil.Errorf(objutil.ErrType, "objconv: cannot convert the string %q to %s: %v", b, t, err)
+}
+
+// unknownFieldCode is the code of the field errors returned by decoders with
+// the DisallowUnknownFields option.
+const unknownFieldCode = "unknown"
+
+// prefixFieldPath prepends elem, which is the name of a struct field, a map
+// key or the index of an array element formatted by indexPath, to the path of
+// err. Errors that aren't field errors are wrapped in a *FieldError, and the
+// location of the parser in its input is recorded if it wasn't already.
+func (d Decoder) prefixFieldPath(err error, elem string) error {
+	if err == End {
+		return err
+	}
+
+	if err == io.EOF {
+		err = errUnexpectedEOF
+	}
+
+	e, ok := err.(*FieldError)
+
+	if !ok {
+		e = &FieldError{Err: err}
+	}
+
+	if e.Offset == 0 && e.Pos == (Position{}) {
+		if p, ok := d.Parser.(OffsetParser); ok {
+			e.Offset = p.Offset()
+		}
+		e.Pos, _ = d.position()
+	}
+
+	if len(e.Path) != 0 && e.Path[0] != '[' {
+		elem += "."
+	}
+
+	e.Path = elem + e.Path
+	return e
+}
+
+func indexPath(i int) string {
+	return "[" + strconv.Itoa(i) + "]"
+}
+
+func keyPath(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	return fmt.Sprint(k.Interface())
 }
@@ -228,6 +228,23 @@ func TestDecoderDecodeType(t *testing.T) {
 		{struct{}{}, map[string]string{}},
 		{struct{ A string }{"42"}, map[string]string{"A": "42"}},
 
+		// type -> int64, float64, bool, []byte, time (direct assignment)
+		{int(-42), int64(-42)},
+		{uint(42), int64(42)},
+		{"42", int64(42)},
+		{int(42), float64(42)},
+		{float64(0.5), float64(0.5)},
+		{"0.5", float64(0.5)},
+		{true, true},
+		{"A", []byte("A")},
+		{[]byte("A"), []byte("A")},
+		{date, date},
+		{"2016-12-12T01:01:01Z", date},
+
+		// map -> map (direct assignment)
+		{map[string]string{"A": "1"}, map[string]string{"A": "1"}},
+		{map[string]int{"A": 1}, map[string]interface{}{"A": int64(1)}},
+
 		// struct -> struct
 		{struct{}{}, struct{}{}},
 		{struct{ A int }{42}, struct{ A int }{42}},
@@ -261,6 +278,35 @@ func TestDecoderDecodeType(t *testing.T) {
 	}
 }
 
+func TestDecoderDecodeToMap(t *testing.T) {
+	m1 := map[string]string{"A": "1", "B": "2"}
+	m2 := map[string]interface{}{"A": "1", "B": "2"}
+
+	if err := NewDecoder(NewValueParser(map[string]string{"C": "3"})).Decode(m1); err != nil {
+		t.Error(err)
+	}
+
+	if err := NewDecoder(NewValueParser(map[string]string{"C": "3"})).Decode(m2); err != nil {
+		t.Error(err)
+	}
+
+	if !reflect.DeepEqual(m1, map[string]string{"C": "3"}) {
+		t.Errorf("bad map[string]string: %#v", m1)
+	}
+
+	if !reflect.DeepEqual(m2, map[string]interface{}{"C": "3"}) {
+		t.Errorf("bad map[string]interface{}: %#v", m2)
+	}
+}
+
+func TestDecoderDecodeInt64Overflow(t *testing.T) {
+	var v int64
+
+	if err := NewDecoder(NewValueParser(uint64(1 << 63))).Decode(&v); err == nil {
+		t.Error("expected an overflow error")
+	}
+}
+
 func TestDecoderDecodeToEmptyInterface(t *testing.T) {
 	tests := []interface{}{
 		// nil -> interface{}
@@ -420,3 +466,162 @@ func TestStreamRencode(t *testing.T) {
 		})
 	}
 }
+
+func TestDecoderFieldErrorPath(t *testing.T) {
+	type metadata struct {
+		CreatedAt int64 `objconv:"created_at"`
+	}
+
+	type item struct {
+		Metadata metadata `objconv:"metadata"`
+	}
+
+	tests := []struct {
+		in   interface{}
+		to   interface{}
+		path string
+	}{
+		{
+			in: map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{},
+				map[string]interface{}{"metadata": map[string]interface{}{"created_at": true}},
+			}},
+			to: &struct {
+				Items []item `objconv:"items"`
+			}{},
+			path: "items[1].metadata.created_at",
+		},
+		{
+			in:   map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{1, true}}},
+			to:   &map[string]map[string][]int{},
+			path: "a.b[1]",
+		},
+		{
+			in:   []interface{}{map[string]interface{}{"k": true}},
+			to:   &[1]map[string]int{},
+			path: "[0].k",
+		},
+		{
+			in:   map[string]interface{}{"labels": map[string]interface{}{"a": "x", "b": true}},
+			to:   &map[string]map[string]string{},
+			path: "labels.b",
+		},
+	}
+
+	for _, test := range tests {
+		err := NewDecoder(NewValueParser(test.in)).Decode(test.to)
+
+		var fe *FieldError
+
+		if !errors.As(err, &fe) || fe.Path != test.path || !errors.Is(err, ErrType) {
+			t.Errorf("%T: bad error: %v", test.to, err)
+		}
+	}
+}
+
+func TestDecoderDisallowUnknownFields(t *testing.T) {
+	type server struct {
+		Host string
+		Port int
+	}
+
+	type config struct {
+		Name    string
+		Servers []server
+	}
+
+	in := map[string]interface{}{
+		"Name": "prod",
+		"Servers": []interface{}{
+			map[string]interface{}{"Host": "a", "Port": 1},
+			map[string]interface{}{"Host": "b", "Prot": 2},
+		},
+	}
+
+	var c config
+
+	if err := (Decoder{Parser: NewValueParser(in)}).Decode(&c); err != nil {
+		t.Fatal("unknown fields must be discarded by default:", err)
+	}
+
+	err := (Decoder{Parser: NewValueParser(in), DisallowUnknownFields: true}).Decode(&c)
+
+	if !errors.Is(err, ErrUnknownField) {
+		t.Fatalf("bad error: %v", err)
+	}
+
+	var fe *FieldError
+
+	if !errors.As(err, &fe) || fe.Path != "Servers[1].Prot" || fe.Code != "unknown" {
+		t.Errorf("bad field error: %#v", err)
+	}
+}
+
+func TestDecoderNilValueDecoderPointer(t *testing.T) {
+	type container struct {
+		V *Value
+	}
+
+	var c container
+
+	if err := NewDecoder(NewValueParser(map[string]interface{}{"V": 42})).Decode(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.V == nil || c.V.Int() != 42 {
+		t.Errorf("bad value: %#v", c.V)
+	}
+
+	c = container{}
+
+	if err := NewDecoder(NewValueParser(map[string]interface{}{"V": nil})).Decode(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.V != nil {
+		t.Errorf("nil values must not allocate pointers, found %#v", c.V)
+	}
+}
+
+type untypedParser struct {
+	*ValueParser
+}
+
+func (p untypedParser) UntypedParser() bool { return true }
+
+func TestDecoderUntypedParser(t *testing.T) {
+	type record struct {
+		Enabled bool
+		Tags    []string
+		Ports   [1]int
+		Items   []map[string]string
+	}
+
+	in := map[string]interface{}{
+		"Enabled": "true",
+		"Tags":    "a",
+		"Ports":   "80",
+		"Items":   map[string]interface{}{"name": "x"},
+	}
+
+	var r record
+
+	if err := NewDecoder(untypedParser{NewValueParser(in)}).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := record{
+		Enabled: true,
+		Tags:    []string{"a"},
+		Ports:   [1]int{80},
+		Items:   []map[string]string{{"name": "x"}},
+	}
+
+	if !reflect.DeepEqual(r, expect) {
+		t.Errorf("bad value:\n%#v\n%#v", expect, r)
+	}
+
+	if err := NewDecoder(NewValueParser(in)).Decode(&r); err == nil {
+		t.Errorf("typed parsers must not convert strings to booleans or arrays")
+	}
+}
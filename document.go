@@ -0,0 +1,509 @@
+package objconv
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Value is a generic document model, it represents any value that parsers can
+// produce in a tree of nodes which programs can inspect without knowing the
+// structure of the document in advance.
+//
+// Decoding a Value reads the parsed values directly, without going through
+// reflection, and nothing is converted to Go types until the program asks for
+// it. This makes it possible to look at parts of a payload, for example to find
+// which type it should be decoded to, then decode sub-values with the Decode
+// method:
+//
+//	var v objconv.Value
+//
+//	if err := json.Unmarshal(b, &v); err != nil {
+//		...
+//	}
+//
+//	switch v.Get("kind").String() {
+//	case "user":
+//		var u User
+//		err = v.Get("spec").Decode(&u)
+//		...
+//	}
+//
+// The zero value represents a missing value, its type is Unknown. Looking up
+// keys or indexes that don't exist returns the zero value, which lets programs
+// chain lookups and check the result with the Exists method.
+type Value struct {
+	typ   Type
+	n     uint64 // bool, int, uint, float and duration values
+	s     string // string and bytes values
+	t     time.Time
+	e     error
+	keys  []Value // map keys
+	elems []Value // array elements or map values
+}
+
+// Type returns the type of v, which is Unknown if v doesn't exist.
+func (v Value) Type() Type {
+	return v.typ
+}
+
+// Exists returns true if v is not the zero value.
+func (v Value) Exists() bool {
+	return v.typ != Unknown
+}
+
+// IsNil returns true if v is a nil value.
+func (v Value) IsNil() bool {
+	return v.typ == Nil
+}
+
+// Len returns the number of elements of arrays, the number of entries of maps,
+// and zero for other values.
+func (v Value) Len() int {
+	return len(v.elems)
+}
+
+// Index returns the element at index i of an array, or the zero value if v is
+// not an array or i is out of bounds.
+func (v Value) Index(i int) Value {
+	if v.typ != Array || i < 0 || i >= len(v.elems) {
+		return Value{}
+	}
+	return v.elems[i]
+}
+
+// Key returns the value associated with the string key k in a map, or the zero
+// value if v is not a map or has no such key. When the key appears multiple
+// times the last value is returned.
+func (v Value) Key(k string) Value {
+	if v.typ != Map {
+		return Value{}
+	}
+
+	for i := len(v.keys) - 1; i >= 0; i-- {
+		if x := &v.keys[i]; (x.typ == String || x.typ == Bytes) && x.s == k {
+			return v.elems[i]
+		}
+	}
+
+	return Value{}
+}
+
+// Range calls f with each key and value of a map, in the order they were
+// decoded, until f returns false. The method does nothing if v is not a map.
+func (v Value) Range(f func(Value, Value) bool) {
+	if v.typ != Map {
+		return
+	}
+
+	for i := range v.keys {
+		if !f(v.keys[i], v.elems[i]) {
+			break
+		}
+	}
+}
+
+// Get returns the value at path within v, or the zero value if it doesn't
+// exist.
+//
+// The path is a sequence of map keys separated by dots, and of array indexes
+// written between brackets, like "servers[0].address". Keys containing dots or
+// brackets must be looked up with the Key method.
+func (v Value) Get(path string) Value {
+	for len(path) != 0 && v.Exists() {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+
+		case '[':
+			j := strings.IndexByte(path, ']')
+			if j < 0 {
+				return Value{}
+			}
+
+			i, err := strconv.Atoi(path[1:j])
+			if err != nil {
+				return Value{}
+			}
+
+			v, path = v.Index(i), path[j+1:]
+
+		default:
+			j := strings.IndexAny(path, ".[")
+			if j < 0 {
+				j = len(path)
+			}
+
+			v, path = v.Key(path[:j]), path[j:]
+		}
+	}
+	return v
+}
+
+// Bool returns the value of a boolean, or false if v is not a boolean.
+func (v Value) Bool() bool {
+	return v.typ == Bool && v.n != 0
+}
+
+// Int returns v as a signed integer, numbers of other types are converted and
+// zero is returned for all other values.
+func (v Value) Int() int64 {
+	switch v.typ {
+	case Int, Uint, Duration:
+		return int64(v.n)
+	case Float:
+		return int64(math.Float64frombits(v.n))
+	default:
+		return 0
+	}
+}
+
+// Uint returns v as an unsigned integer, numbers of other types are converted
+// and zero is returned for all other values.
+func (v Value) Uint() uint64 {
+	switch v.typ {
+	case Int, Uint:
+		return v.n
+	case Float:
+		return uint64(math.Float64frombits(v.n))
+	default:
+		return 0
+	}
+}
+
+// Float returns v as a floating point number, numbers of other types are
+// converted and zero is returned for all other values.
+func (v Value) Float() float64 {
+	switch v.typ {
+	case Int:
+		return float64(int64(v.n))
+	case Uint:
+		return float64(v.n)
+	case Float:
+		return math.Float64frombits(v.n)
+	default:
+		return 0
+	}
+}
+
+// String returns the content of strings and bytes values, other scalar values
+// are formatted, and an empty string is returned for nil, arrays and maps.
+func (v Value) String() string {
+	switch v.typ {
+	case Unknown, Nil, Array, Map:
+		return ""
+	case String, Bytes:
+		return v.s
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// Bytes returns the content of bytes and strings values, or nil for all other
+// values.
+func (v Value) Bytes() []byte {
+	if v.typ != String && v.typ != Bytes {
+		return nil
+	}
+	return []byte(v.s)
+}
+
+// Time returns the value of a time, or the zero time if v is not a time.
+func (v Value) Time() time.Time {
+	if v.typ != Time {
+		return time.Time{}
+	}
+	return v.t
+}
+
+// Duration returns the value of a duration, integers are interpreted as
+// nanoseconds and zero is returned for other values.
+func (v Value) Duration() time.Duration {
+	if v.typ != Duration && v.typ != Int {
+		return 0
+	}
+	return time.Duration(v.n)
+}
+
+// Err returns the value of an error, or nil if v is not an error.
+func (v Value) Err() error {
+	if v.typ != Error {
+		return nil
+	}
+	return v.e
+}
+
+// Interface converts v to the Go value that a decoder would produce for an
+// empty interface.
+func (v Value) Interface() interface{} {
+	var x interface{}
+	v.Decode(&x)
+	return x
+}
+
+// Decode decodes v into x like a decoder would decode the document that v was
+// decoded from.
+func (v Value) Decode(x interface{}) error {
+	return Decoder{Parser: v.Parser()}.Decode(x)
+}
+
+// Parser returns a parser which produces v, it can be used to configure the
+// options of decoders used to decode sub-values.
+func (v Value) Parser() Parser {
+	return &documentParser{stack: []*Value{&v}}
+}
+
+// EncodeValue satisfies the ValueEncoder interface.
+func (v Value) EncodeValue(e Encoder) error {
+	switch v.typ {
+	case Bool:
+		return e.Encode(v.Bool())
+	case Int:
+		return e.Encode(v.Int())
+	case Uint:
+		return e.Encode(v.Uint())
+	case Float:
+		return e.Encode(v.Float())
+	case String:
+		return e.Encode(v.s)
+	case Bytes:
+		return e.Encode(v.Bytes())
+	case Time:
+		return e.Encode(v.t)
+	case Duration:
+		return e.Encode(v.Duration())
+	case Error:
+		return e.Encode(v.e)
+
+	case Array:
+		i := 0
+		return e.EncodeArray(len(v.elems), func(e Encoder) error {
+			err := e.Encode(v.elems[i])
+			i++
+			return err
+		})
+
+	case Map:
+		i := 0
+		return e.EncodeMap(len(v.elems), func(ke Encoder, ve Encoder) error {
+			if err := ke.Encode(v.keys[i]); err != nil {
+				return err
+			}
+			err := ve.Encode(v.elems[i])
+			i++
+			return err
+		})
+
+	default:
+		return e.Encode(nil)
+	}
+}
+
+// DecodeValue satisfies the ValueDecoder interface.
+func (v *Value) DecodeValue(d Decoder) error {
+	t, err := d.Parser.ParseType()
+	if err != nil {
+		return err
+	}
+
+	*v = Value{typ: t}
+
+	switch t {
+	case Nil:
+		err = d.Parser.ParseNil()
+
+	case Bool:
+		var b bool
+		if b, err = d.Parser.ParseBool(); b {
+			v.n = 1
+		}
+
+	case Int:
+		var i int64
+		i, err = d.Parser.ParseInt()
+		v.n = uint64(i)
+
+	case Uint:
+		v.n, err = d.Parser.ParseUint()
+
+	case Float:
+		var f float64
+		f, err = d.readFloat()
+		v.n = math.Float64bits(f)
+
+	case String:
+		var b []byte
+		if b, err = d.readString(); err == nil && d.Resolver != nil {
+			b, err = d.interpolate(b)
+		}
+		v.s = string(b)
+
+	case Bytes:
+		var b []byte
+		b, err = d.readBytes()
+		v.s = string(b)
+
+	case Time:
+		v.t, err = d.Parser.ParseTime()
+
+	case Duration:
+		var x time.Duration
+		x, err = d.Parser.ParseDuration()
+		v.n = uint64(x)
+
+	case Error:
+		v.e, err = d.Parser.ParseError()
+
+	case Array:
+		err = d.decodeArrayImpl(t, func(d Decoder) error {
+			i := len(v.elems)
+			v.elems = append(v.elems, Value{})
+
+			if err := d.Decode(&v.elems[i]); err != nil {
+				return d.prefixFieldPath(err, indexPath(i))
+			}
+
+			return nil
+		})
+
+	case Map:
+		err = d.decodeMapImpl(t, func(kd Decoder, vd Decoder) error {
+			var k, x Value
+
+			if err := kd.Decode(&k); err != nil {
+				return err
+			}
+
+			if err := vd.Decode(&x); err != nil {
+				return d.prefixFieldPath(err, k.String())
+			}
+
+			v.keys = append(v.keys, k)
+			v.elems = append(v.elems, x)
+			return nil
+		})
+
+	default:
+		err = objutil.Errorf(objutil.ErrType, "objconv: cannot decode a value of type %s", t)
+	}
+
+	return err
+}
+
+// documentParser is the implementation of the Parser interface which produces
+// the content of a Value.
+type documentParser struct {
+	stack []*Value
+	ctx   []*Value
+}
+
+func (p *documentParser) value() *Value {
+	return p.stack[len(p.stack)-1]
+}
+
+func (p *documentParser) push(v *Value) {
+	p.stack = append(p.stack, v)
+}
+
+func (p *documentParser) pop() {
+	p.stack = p.stack[:len(p.stack)-1]
+}
+
+func (p *documentParser) context() *Value {
+	return p.ctx[len(p.ctx)-1]
+}
+
+func (p *documentParser) pushContext(v *Value) {
+	p.ctx = append(p.ctx, v)
+}
+
+func (p *documentParser) popContext() {
+	p.ctx = p.ctx[:len(p.ctx)-1]
+}
+
+func (p *documentParser) ParseType() (Type, error) {
+	if t := p.value().typ; t != Unknown {
+		return t, nil
+	}
+	return Nil, nil
+}
+
+func (p *documentParser) ParseNil() error { return nil }
+
+func (p *documentParser) ParseBool() (bool, error) { return p.value().Bool(), nil }
+
+func (p *documentParser) ParseInt() (int64, error) { return p.value().Int(), nil }
+
+func (p *documentParser) ParseUint() (uint64, error) { return p.value().Uint(), nil }
+
+func (p *documentParser) ParseFloat() (float64, error) { return p.value().Float(), nil }
+
+func (p *documentParser) ParseString() ([]byte, error) { return p.value().Bytes(), nil }
+
+func (p *documentParser) ParseBytes() ([]byte, error) { return p.value().Bytes(), nil }
+
+func (p *documentParser) ParseTime() (time.Time, error) { return p.value().Time(), nil }
+
+func (p *documentParser) ParseDuration() (time.Duration, error) { return p.value().Duration(), nil }
+
+func (p *documentParser) ParseError() (error, error) { return p.value().Err(), nil }
+
+func (p *documentParser) ParseArrayBegin() (int, error) {
+	v := p.value()
+	p.pushContext(v)
+
+	if len(v.elems) != 0 {
+		p.push(&v.elems[0])
+	}
+
+	return len(v.elems), nil
+}
+
+func (p *documentParser) ParseArrayEnd(n int) error {
+	if n != 0 {
+		p.pop()
+	}
+	p.popContext()
+	return nil
+}
+
+func (p *documentParser) ParseArrayNext(n int) error {
+	p.pop()
+	p.push(&p.context().elems[n])
+	return nil
+}
+
+func (p *documentParser) ParseMapBegin() (int, error) {
+	v := p.value()
+	p.pushContext(v)
+
+	if len(v.keys) != 0 {
+		p.push(&v.keys[0])
+	}
+
+	return len(v.keys), nil
+}
+
+func (p *documentParser) ParseMapEnd(n int) error {
+	if n != 0 {
+		p.pop()
+	}
+	p.popContext()
+	return nil
+}
+
+func (p *documentParser) ParseMapValue(n int) error {
+	p.pop()
+	p.push(&p.context().elems[n])
+	return nil
+}
+
+func (p *documentParser) ParseMapNext(n int) error {
+	p.pop()
+	p.push(&p.context().keys[n])
+	return nil
+}
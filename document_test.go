@@ -0,0 +1,139 @@
+package objconv
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func decodeTestDocument(t *testing.T) Value {
+	var v Value
+
+	doc := map[string]interface{}{
+		"name": "example",
+		"a": map[string]interface{}{
+			"b": []interface{}{1, uint(2), 3.5, true, nil},
+		},
+		"servers": []map[string]interface{}{
+			{"host": "localhost", "port": 8080},
+		},
+		"created": time.Date(2017, 7, 14, 2, 40, 0, 0, time.UTC),
+		"timeout": 5 * time.Second,
+	}
+
+	if err := NewDecoder(NewValueParser(doc)).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	return v
+}
+
+func TestValueGet(t *testing.T) {
+	v := decodeTestDocument(t)
+
+	if v.Type() != Map || v.Len() != 5 {
+		t.Fatalf("bad document: %s of length %d", v.Type(), v.Len())
+	}
+
+	tests := []struct {
+		path string
+		typ  Type
+		str  string
+	}{
+		{"name", String, "example"},
+		{"a.b", Array, ""},
+		{"a.b[0]", Int, "1"},
+		{"a.b[1]", Uint, "2"},
+		{"a.b[2]", Float, "3.5"},
+		{"a.b[3]", Bool, "true"},
+		{"a.b[4]", Nil, ""},
+		{"a.b[5]", Unknown, ""},
+		{"a.b[x]", Unknown, ""},
+		{"a.c", Unknown, ""},
+		{"name.a", Unknown, ""},
+		{"servers[0].host", String, "localhost"},
+		{"servers[0].port", Int, "8080"},
+		{"timeout", Duration, "5s"},
+	}
+
+	for _, test := range tests {
+		x := v.Get(test.path)
+
+		if x.Type() != test.typ {
+			t.Errorf("%s: bad type: %s", test.path, x.Type())
+		}
+
+		if s := x.String(); s != test.str {
+			t.Errorf("%s: bad string: %q", test.path, s)
+		}
+
+		if x.Exists() != (test.typ != Unknown) {
+			t.Errorf("%s: bad existence", test.path)
+		}
+	}
+
+	if x := v.Get("a.b[2]"); x.Int() != 3 || x.Float() != 3.5 {
+		t.Errorf("bad number conversions: %d %g", x.Int(), x.Float())
+	}
+
+	if x := v.Get("created").Time(); !x.Equal(time.Date(2017, 7, 14, 2, 40, 0, 0, time.UTC)) {
+		t.Errorf("bad time: %v", x)
+	}
+
+	if x := v.Get("timeout").Duration(); x != 5*time.Second {
+		t.Errorf("bad duration: %v", x)
+	}
+}
+
+func TestValueDecode(t *testing.T) {
+	type server struct {
+		Host string `objconv:"host"`
+		Port int    `objconv:"port"`
+	}
+
+	v := decodeTestDocument(t)
+
+	var s []server
+
+	if err := v.Get("servers").Decode(&s); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(s, []server{{"localhost", 8080}}) {
+		t.Errorf("bad servers: %#v", s)
+	}
+
+	if x := v.Get("a.b").Interface(); !reflect.DeepEqual(x, []interface{}{int64(1), uint64(2), 3.5, true, nil}) {
+		t.Errorf("bad interface: %#v", x)
+	}
+
+	var p struct {
+		Port int `objconv:"port"`
+	}
+
+	d := Decoder{Parser: v.Get("servers[0]").Parser(), DisallowUnknownFields: true}
+
+	var fe *FieldError
+
+	if err := d.Decode(&p); !errors.As(err, &fe) || fe.Path != "host" {
+		t.Errorf("bad error: %v", err)
+	}
+}
+
+func TestValueEncode(t *testing.T) {
+	v := decodeTestDocument(t)
+	e := NewValueEmitter()
+
+	if err := NewEncoder(e).Encode(v.Get("servers")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []interface{}{
+		map[interface{}]interface{}{"host": "localhost", "port": int64(8080)},
+	}
+
+	if x := e.Value(); !reflect.DeepEqual(x, expected) {
+		t.Errorf("bad value: %#v", x)
+	}
+}
@@ -69,6 +69,84 @@ type PrettyEmitter interface {
 	PrettyEmitter() Emitter
 }
 
+// The CanonicalEmitter interface may be implemented by emitters of formats
+// which define a canonical representation of values, like the deterministic
+// encoding of CBOR or the JSON Canonicalization Scheme.
+type CanonicalEmitter interface {
+	// CanonicalEmitter returns a new emitter that outputs to the same writer
+	// the canonical representation of values. The entries of maps are written
+	// in the order defined by the format regardless of the order they were
+	// emitted in.
+	CanonicalEmitter() Emitter
+}
+
+// Format is a formatting policy for the emitters of text formats, it lets
+// programs configure the layout of all the text formats they produce with a
+// single value.
+type Format struct {
+	// Prefix is written at the beginning of each line that follows a line
+	// break. Emitters of formats where values end with line breaks, like YAML,
+	// write it at the beginning of all lines.
+	Prefix string
+
+	// Indent is written once per nesting level at the beginning of each line
+	// of arrays and maps, after the prefix. When empty, values are written on
+	// a single line.
+	Indent string
+
+	// Space is written after the separators of map keys and values, and after
+	// the separators of elements written on a single line.
+	Space string
+
+	// Arrays of scalar values which fit within Width bytes when written on a
+	// single line are not wrapped. Zero wraps all arrays.
+	Width int
+}
+
+// DefaultFormat is the formatting policy of pretty emitters.
+var DefaultFormat = Format{
+	Indent: "  ",
+	Space:  " ",
+}
+
+// The FormatEmitter interface may be implemented by emitters of text formats
+// which support formatting policies.
+type FormatEmitter interface {
+	// FormatEmitter returns a new emitter that outputs to the same writer in
+	// the layout configured by f.
+	FormatEmitter(f Format) Emitter
+}
+
+// The Flusher interface may be implemented by emitters that buffer their
+// output.
+//
+// Encoders call Flush after each top-level value they have written, programs
+// using an emitter directly must call Flush themselves to make sure that all
+// the output has reached the underlying writer.
+type Flusher interface {
+	// Flush writes the buffered output to the underlying writer.
+	Flush() error
+}
+
+// The HeartbeatEmitter interface may be implemented by emitters of formats that
+// have a representation for no-op frames, which parsers of the same format skip.
+//
+// Stream encoders use heartbeats to keep long-lived idle streams active, so
+// intermediaries like proxies or load balancers don't time them out.
+type HeartbeatEmitter interface {
+	// EmitHeartbeat writes a no-op frame, it is only called between values.
+	EmitHeartbeat() error
+}
+
+func flush(emitter Emitter, err error) error {
+	if f, ok := emitter.(Flusher); ok {
+		if ferr := f.Flush(); err == nil {
+			err = ferr
+		}
+	}
+	return err
+}
+
 // The textEmitter interface may be implemented by emitters of human-readable
 // formats. Such emitters instruct the encoder to prefer using
 // encoding.TextMarshaler over encoding.BinaryMarshaler for example.
@@ -82,6 +160,20 @@ func isTextEmitter(emitter Emitter) bool {
 	return e != nil && e.TextEmitter()
 }
 
+// The fixedLengthEmitter interface may be implemented by emitters of formats
+// where arrays and maps are prefixed with their lengths, and which don't accept
+// the negative lengths of values that the encoder produces incrementally.
+type fixedLengthEmitter interface {
+	// FixedLengthEmitter returns true if EmitArrayBegin and EmitMapBegin must
+	// receive the actual number of elements.
+	FixedLengthEmitter() bool
+}
+
+func isFixedLengthEmitter(emitter Emitter) bool {
+	e, _ := emitter.(fixedLengthEmitter)
+	return e != nil && e.FixedLengthEmitter()
+}
+
 type discardEmitter struct{}
 
 func (e discardEmitter) EmitNil() error                     { return nil }
@@ -1,12 +1,15 @@
 package objconv
 
 import (
+	"context"
 	"encoding"
 	"fmt"
 	"io"
+	"math/bits"
 	"reflect"
 	"time"
-	"unsafe"
+
+	"github.com/segmentio/objconv/objutil"
 )
 
 // An Encoder implements the high-level encoding algorithm that inspect encoded
@@ -17,7 +20,72 @@ import (
 type Encoder struct {
 	Emitter     Emitter // the emitter used by this encoder
 	SortMapKeys bool    // whether map keys should be sorted
-	key         bool
+
+	// When set, top-level values passed to Encode are also given to the
+	// capturer.
+	Capturer Capturer
+
+	// When set, the output of Encode is deterministic: map keys are sorted,
+	// and values are written in the canonical representation of the format
+	// if the emitter implements CanonicalEmitter, so they can be hashed or
+	// signed reproducibly. Canonical emitters buffer arrays and maps until
+	// they are complete, which makes encoding slower.
+	Canonical bool
+
+	// When set, the usage of struct fields is reported to the recorder.
+	FieldRecorder FieldRecorder
+
+	// When set, encoding a struct which only has unexported fields returns an
+	// error instead of producing an empty map. Unexported fields can be
+	// encoded by adding the `export` option to their tag.
+	DisallowOpaqueStructs bool
+
+	// When set, values of the named types registered with RegisterNamedType
+	// are encoded as maps holding the name of their type under the "@type"
+	// key and their value under the "@value" key, which lets decoders with
+	// the PreserveTypes option restore their original type.
+	PreserveTypes bool
+
+	// TagNames lists the struct tags that the names and options of fields
+	// are read from, the first tag that a field has is used. The objconv tag
+	// supports all the tag options, other tags are parsed like the json tag of
+	// the standard library. When empty, the objconv tag is used, then the json
+	// tag.
+	TagNames []string
+
+	// FieldNaming is the naming convention applied to the names of struct
+	// fields which have no name in their tag, the Go names of fields are used
+	// when it is GoNaming.
+	FieldNaming FieldNaming
+
+	// Keys provides the keys that the values of struct fields with the
+	// `encrypt` tag option are encrypted with. They are encoded as byte
+	// sequences holding the id of the key, so decoders configured with the
+	// same keys can decrypt them whatever the format. Encoding an encrypted
+	// field without a key provider is an error.
+	Keys KeyProvider
+
+	ctx    context.Context // given to link builders, set by EncodeContext
+	key    bool
+	nested bool // set when encoding a value within a top-level value
+}
+
+// nestedEncoder returns a copy of e used to encode the keys or values of maps
+// written by EncodeMap.
+func (e Encoder) nestedEncoder(key bool) Encoder {
+	return Encoder{
+		Emitter:               e.Emitter,
+		SortMapKeys:           e.SortMapKeys,
+		FieldRecorder:         e.FieldRecorder,
+		DisallowOpaqueStructs: e.DisallowOpaqueStructs,
+		PreserveTypes:         e.PreserveTypes,
+		TagNames:              e.TagNames,
+		FieldNaming:           e.FieldNaming,
+		Keys:                  e.Keys,
+		ctx:                   e.ctx,
+		key:                   key,
+		nested:                true,
+	}
 }
 
 // NewEncoder returns a new encoder that outputs values to e.
@@ -34,8 +102,158 @@ func NewEncoder(e Emitter) *Encoder {
 	return &Encoder{Emitter: e}
 }
 
+// Indent configures e to write values on multiple lines which begin with
+// prefix, followed by one copy of indent for each nesting level.
+//
+// The option is honored by the emitters which implement the FormatEmitter
+// interface, which are the JSON, YAML, TOML and XML emitters, and the emitter
+// of the text form of Ion. Other emitters are left unchanged and ignore the
+// option, which is the case of the emitters of binary formats and of the
+// formats whose layout is fixed, like the RESP emitters (including
+// resp.ClientEmitter, which writes redis commands) and the CSV, fixed-width
+// and segment emitters.
+func (e *Encoder) Indent(prefix string, indent string) {
+	e.Emitter = indentEmitter(e.Emitter, prefix, indent)
+}
+
 // Encode encodes the generic value v.
+//
+// If the emitter implements the Flusher interface it is flushed after encoding
+// a top-level value, which is then given to the capturer of e if it had one.
+//
+// Unless RecoverPanics is false, panics that occur while encoding a top-level
+// value are returned as errors of type *PanicError.
 func (e Encoder) Encode(v interface{}) (err error) {
+	if e.nested {
+		return e.encodeValue(v)
+	}
+	if RecoverPanics {
+		defer recoverPanic(&err)
+	}
+	e.nested = true
+
+	if e.Canonical {
+		e.SortMapKeys = true
+
+		if c, ok := e.Emitter.(CanonicalEmitter); ok {
+			e.Emitter = c.CanonicalEmitter()
+		}
+	}
+
+	err = flush(e.Emitter, e.encodeValue(v))
+
+	if err == nil && e.Capturer != nil {
+		e.Capturer.Capture(v)
+	}
+
+	return err
+}
+
+// EncodeContext is like Encode, but the emitter is first given ctx if it
+// implements EmitterV2. Otherwise the method only checks that ctx is not done
+// before encoding the value. The link builders called while encoding the value
+// also receive ctx, see InstallLinks.
+func (e Encoder) EncodeContext(ctx context.Context, v interface{}) error {
+	if err := emitContext(e.Emitter, ctx); err != nil {
+		return err
+	}
+	e.ctx = ctx
+	return e.Encode(v)
+}
+
+// EncodeNil encodes a nil value.
+func (e Encoder) EncodeNil() error {
+	if err := e.encodeMapValueMaybe(); err != nil {
+		return err
+	}
+	return e.done(e.Emitter.EmitNil())
+}
+
+// EncodeBool encodes the boolean v.
+//
+// This method and the other typed Encode* methods behave like Encode but
+// receive values of concrete types, which prevents the compiler from
+// allocating memory to convert them to empty interfaces.
+func (e Encoder) EncodeBool(v bool) error {
+	if err := e.encodeMapValueMaybe(); err != nil {
+		return err
+	}
+	return e.done(e.Emitter.EmitBool(v))
+}
+
+// EncodeInt encodes the integer v.
+func (e Encoder) EncodeInt(v int) error {
+	if err := e.encodeMapValueMaybe(); err != nil {
+		return err
+	}
+	return e.done(e.Emitter.EmitInt(int64(v), 0))
+}
+
+// EncodeInt64 encodes the 64 bits integer v.
+func (e Encoder) EncodeInt64(v int64) error {
+	if err := e.encodeMapValueMaybe(); err != nil {
+		return err
+	}
+	return e.done(e.Emitter.EmitInt(v, 64))
+}
+
+// EncodeUint64 encodes the 64 bits unsigned integer v.
+func (e Encoder) EncodeUint64(v uint64) error {
+	if err := e.encodeMapValueMaybe(); err != nil {
+		return err
+	}
+	return e.done(e.Emitter.EmitUint(v, 64))
+}
+
+// EncodeFloat64 encodes the 64 bits floating point number v.
+func (e Encoder) EncodeFloat64(v float64) error {
+	if err := e.encodeMapValueMaybe(); err != nil {
+		return err
+	}
+	return e.done(e.Emitter.EmitFloat(v, 64))
+}
+
+// EncodeString encodes the string v.
+func (e Encoder) EncodeString(v string) error {
+	if err := e.encodeMapValueMaybe(); err != nil {
+		return err
+	}
+	return e.done(e.Emitter.EmitString(v))
+}
+
+// EncodeBytes encodes the byte slice v.
+func (e Encoder) EncodeBytes(v []byte) error {
+	if err := e.encodeMapValueMaybe(); err != nil {
+		return err
+	}
+	return e.done(e.Emitter.EmitBytes(v))
+}
+
+// EncodeTime encodes the time value v.
+func (e Encoder) EncodeTime(v time.Time) error {
+	if err := e.encodeMapValueMaybe(); err != nil {
+		return err
+	}
+	return e.done(e.Emitter.EmitTime(v))
+}
+
+// EncodeDuration encodes the duration v.
+func (e Encoder) EncodeDuration(v time.Duration) error {
+	if err := e.encodeMapValueMaybe(); err != nil {
+		return err
+	}
+	return e.done(e.Emitter.EmitDuration(v))
+}
+
+// done flushes the emitter if e is encoding a top-level value.
+func (e Encoder) done(err error) error {
+	if e.nested {
+		return err
+	}
+	return flush(e.Emitter, err)
+}
+
+func (e Encoder) encodeValue(v interface{}) (err error) {
 	if err = e.encodeMapValueMaybe(); err != nil {
 		return
 	}
@@ -117,7 +335,7 @@ func (e Encoder) Encode(v interface{}) (err error) {
 		if x == nil {
 			return e.Emitter.EmitNil()
 		}
-		return e.Emitter.EmitInt(int64(*x), int(8*unsafe.Sizeof(0)))
+		return e.Emitter.EmitInt(int64(*x), bits.UintSize)
 
 	case *int8:
 		if x == nil {
@@ -422,6 +640,10 @@ func (e Encoder) encodeMapInterfaceInterfaceValue(v reflect.Value) error {
 }
 
 func (e Encoder) encodeMapInterfaceInterface(m map[interface{}]interface{}) (err error) {
+	if e.SortMapKeys {
+		return e.encodeMap(reflect.ValueOf(m))
+	}
+
 	n := len(m)
 	i := 0
 
@@ -455,6 +677,10 @@ func (e Encoder) encodeMapStringInterfaceValue(v reflect.Value) error {
 }
 
 func (e Encoder) encodeMapStringInterface(m map[string]interface{}) (err error) {
+	if e.SortMapKeys {
+		return e.encodeMap(reflect.ValueOf(m))
+	}
+
 	n := len(m)
 	i := 0
 
@@ -488,6 +714,10 @@ func (e Encoder) encodeMapStringStringValue(v reflect.Value) error {
 }
 
 func (e Encoder) encodeMapStringString(m map[string]string) (err error) {
+	if e.SortMapKeys {
+		return e.encodeMap(reflect.ValueOf(m))
+	}
+
 	n := len(m)
 	i := 0
 
@@ -517,19 +747,73 @@ func (e Encoder) encodeMapStringString(m map[string]string) (err error) {
 }
 
 func (e Encoder) encodeStruct(v reflect.Value) error {
-	return e.encodeStructWith(v, structCache.lookup(v.Type()))
+	return e.encodeStructWith(v, structCacheOf(e.TagNames, e.FieldNaming).lookup(v.Type()))
 }
 
 func (e Encoder) encodeStructWith(v reflect.Value, s *structType) (err error) {
-	n := 0
+	var field string
+	var n int
+
+	if RecoverPanics {
+		defer repanic(&field)
+	}
+
+	if s.opaque && e.DisallowOpaqueStructs {
+		return objutil.Errorf(objutil.ErrType, "objconv: %s only has unexported fields, it cannot be encoded", v.Type())
+	}
+
+	if s.err != nil {
+		return s.err
+	}
+
+	v = s.addressable(v)
+
+	// The links of the value are built once, with the context of the encoder,
+	// and reused when the field is encoded.
+	var links reflect.Value
 
 	for i := range s.fields {
 		f := &s.fields[i]
-		if !f.omit(v.FieldByIndex(f.index)) {
+		var fv reflect.Value
+
+		if !f.encoded() {
+			continue
+		}
+
+		if f.links != nil {
+			links = buildLinks(e.ctx, f.links, f.parent(v))
+			fv = links
+		} else {
+			fv = f.value(v)
+		}
+
+		if !f.omit(fv) {
 			n++
 		}
 	}
 
+	// The entries of the inline map are encoded after the fields.
+	var rest reflect.Value
+	var keys []reflect.Value
+
+	if s.rest != nil {
+		if rest = s.rest.value(v); rest.Len() != 0 {
+			keys = rest.MapKeys()
+
+			if e.SortMapKeys {
+				sortValues(rest.Type().Key(), keys)
+			}
+
+			for _, k := range keys {
+				if s.field([]byte(k.String())) != nil {
+					return objutil.Errorf(objutil.ErrType, "objconv: the key %q of the inline map of %s conflicts with a field of the same name", k.String(), v.Type())
+				}
+			}
+
+			n += len(keys)
+		}
+	}
+
 	if err = e.Emitter.EmitMapBegin(n); err != nil {
 		return
 	}
@@ -537,7 +821,23 @@ func (e Encoder) encodeStructWith(v reflect.Value, s *structType) (err error) {
 
 	for i := range s.fields {
 		f := &s.fields[i]
-		if fv := v.FieldByIndex(f.index); !f.omit(fv) {
+		fv := links
+
+		if !f.encoded() {
+			continue
+		}
+
+		if f.links == nil {
+			fv = f.value(v)
+		}
+
+		omit := f.omit(fv)
+
+		if e.FieldRecorder != nil {
+			e.FieldRecorder.RecordField(v.Type(), f.name, !omit)
+		}
+
+		if !omit {
 			if n != 0 {
 				if err = e.Emitter.EmitMapNext(); err != nil {
 					return
@@ -549,13 +849,35 @@ func (e Encoder) encodeStructWith(v reflect.Value, s *structType) (err error) {
 			if err = e.Emitter.EmitMapValue(); err != nil {
 				return
 			}
+			field = f.name
 			if err = f.encode(e, fv); err != nil {
 				return
 			}
+			field = ""
 			n++
 		}
 	}
 
+	for _, k := range keys {
+		if n != 0 {
+			if err = e.Emitter.EmitMapNext(); err != nil {
+				return
+			}
+		}
+		if err = e.Emitter.EmitString(k.String()); err != nil {
+			return
+		}
+		if err = e.Emitter.EmitMapValue(); err != nil {
+			return
+		}
+		field = k.String()
+		if err = s.rest.encode(e, rest.MapIndex(k)); err != nil {
+			return
+		}
+		field = ""
+		n++
+	}
+
 	return e.Emitter.EmitMapEnd()
 }
 
@@ -588,16 +910,28 @@ func (e Encoder) encodeMarshaler(v reflect.Value) error {
 	return e.encodeBinaryMarshaler(v)
 }
 
+// encodeBinaryMarshaler encodes v with its AppendBinary method if it has one,
+// which saves allocating a new slice, or with MarshalBinary otherwise.
 func (e Encoder) encodeBinaryMarshaler(v reflect.Value) error {
-	b, err := v.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+	x := v.Interface()
+	if a, ok := x.(binaryAppender); ok {
+		return e.encodeBinaryAppender(a)
+	}
+	b, err := x.(encoding.BinaryMarshaler).MarshalBinary()
 	if err == nil {
 		err = e.Emitter.EmitBytes(b)
 	}
 	return err
 }
 
+// encodeTextMarshaler encodes v with its AppendText method if it has one, or
+// with MarshalText otherwise.
 func (e Encoder) encodeTextMarshaler(v reflect.Value) error {
-	b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	x := v.Interface()
+	if a, ok := x.(textAppender); ok {
+		return e.encodeTextAppender(a)
+	}
+	b, err := x.(encoding.TextMarshaler).MarshalText()
 	if err == nil {
 		err = e.Emitter.EmitString(stringNoCopy(b))
 	}
@@ -605,7 +939,7 @@ func (e Encoder) encodeTextMarshaler(v reflect.Value) error {
 }
 
 func (e Encoder) encodeUnsupported(v reflect.Value) error {
-	return fmt.Errorf("objconv: the encoder doesn't support values of type %s", v.Type())
+	return objutil.Errorf(objutil.ErrType, "objconv: the encoder doesn't support values of type %s", v.Type())
 }
 
 // EncodeArray provides the implementation of the array encoding algorithm,
@@ -618,6 +952,11 @@ func (e Encoder) encodeUnsupported(v reflect.Value) error {
 //
 // The f function is called to encode each element of the array.
 func (e Encoder) EncodeArray(n int, f func(Encoder) error) (err error) {
+	if !e.nested {
+		e.nested = true
+		defer func() { err = flush(e.Emitter, err) }()
+	}
+
 	if e.key {
 		if e.key, err = false, e.Emitter.EmitMapValue(); err != nil {
 			return
@@ -659,6 +998,11 @@ encodeArray:
 // encode two values, the first one being the key, follow by the associated value.
 // The first encoder must be used to encode the key, the second for the value.
 func (e Encoder) EncodeMap(n int, f func(Encoder, Encoder) error) (err error) {
+	if !e.nested {
+		e.nested = true
+		defer func() { err = flush(e.Emitter, err) }()
+	}
+
 	if e.key {
 		if e.key, err = false, e.Emitter.EmitMapValue(); err != nil {
 			return
@@ -678,8 +1022,8 @@ encodeMap:
 		}
 		e.key = true
 		err = f(
-			Encoder{Emitter: e.Emitter, SortMapKeys: e.SortMapKeys},
-			Encoder{Emitter: e.Emitter, SortMapKeys: e.SortMapKeys, key: true},
+			e.nestedEncoder(false),
+			e.nestedEncoder(true),
 		)
 		// Because internal calls don't use the exported methods they may not
 		// reset this flag to false when expected, forcing the value here.
@@ -704,6 +1048,32 @@ type StreamEncoder struct {
 	Emitter     Emitter // the emitter used by this encoder
 	SortMapKeys bool    // whether map keys should be sorted
 
+	// When set, values passed to Encode are also given to the capturer.
+	Capturer Capturer
+
+	// When set, the usage of struct fields is reported to the recorder.
+	FieldRecorder FieldRecorder
+
+	// When set, encoding a struct which only has unexported fields returns an
+	// error, see Encoder.DisallowOpaqueStructs.
+	DisallowOpaqueStructs bool
+
+	// When set, values of registered named types are encoded with their type
+	// name, see Encoder.PreserveTypes.
+	PreserveTypes bool
+
+	// TagNames lists the struct tags that the names and options of fields
+	// are read from, see Encoder.TagNames.
+	TagNames []string
+
+	// FieldNaming is the naming convention applied to the names of struct
+	// fields, see Encoder.FieldNaming.
+	FieldNaming FieldNaming
+
+	// Keys provides the keys that encrypted fields are encrypted with, see
+	// Encoder.Keys.
+	Keys KeyProvider
+
 	err     error
 	max     int
 	cnt     int
@@ -722,6 +1092,23 @@ func NewStreamEncoder(e Emitter) *StreamEncoder {
 	return &StreamEncoder{Emitter: e}
 }
 
+// Indent configures e to write values on multiple lines, see Encoder.Indent.
+// The method must be called before the stream is opened.
+func (e *StreamEncoder) Indent(prefix string, indent string) {
+	e.Emitter = indentEmitter(e.Emitter, prefix, indent)
+}
+
+func indentEmitter(e Emitter, prefix string, indent string) Emitter {
+	if f, ok := e.(FormatEmitter); ok {
+		return f.FormatEmitter(Format{
+			Prefix: prefix,
+			Indent: indent,
+			Space:  DefaultFormat.Space,
+		})
+	}
+	return e
+}
+
 // Open explicitly tells the encoder to start the stream, setting the number
 // of values to n.
 //
@@ -759,40 +1146,208 @@ func (e *StreamEncoder) Close() error {
 		e.closed = true
 
 		if !e.oneshot {
-			e.err = e.Emitter.EmitArrayEnd()
+			e.err = flush(e.Emitter, e.Emitter.EmitArrayEnd())
 		}
 	}
 
 	return e.err
 }
 
+// CloseWithError terminates the stream with an error record, which stream
+// decoders report by returning a *StreamError carrying the message of err.
+//
+// Producers use this method to let consumers know that the stream was
+// interrupted, for example because of a failure while generating the values,
+// and tell this case apart from a connection being dropped.
+//
+// The error record takes the place of an element in streams that were opened
+// with a fixed length. If err is nil the method behaves like Close.
+func (e *StreamEncoder) CloseWithError(err error) error {
+	if err == nil || e.closed {
+		return e.Close()
+	}
+
+	if perr := e.prepare(); perr != nil {
+		return perr
+	}
+
+	if e.err = e.encoder().Encode(streamErrorRecord{err.Error()}); e.err != nil {
+		return e.err
+	}
+
+	e.next()
+	return e.Close()
+}
+
+// Heartbeat writes a no-op frame to the stream and flushes the emitter.
+//
+// Programs that keep streams open for long periods of time may call this
+// method when no values were produced for a while, to prevent intermediaries
+// from timing out the stream. The method must not be called concurrently with
+// other methods of e.
+//
+// An error is returned if the emitter doesn't implement the HeartbeatEmitter
+// interface, the stream stays usable in that case.
+func (e *StreamEncoder) Heartbeat() error {
+	if err := e.err; err != nil {
+		return err
+	}
+
+	if e.closed {
+		return io.ErrClosedPipe
+	}
+
+	h, ok := e.Emitter.(HeartbeatEmitter)
+	if !ok {
+		return fmt.Errorf("objconv: heartbeats are not supported by emitters of type %T", e.Emitter)
+	}
+
+	e.err = flush(e.Emitter, h.EmitHeartbeat())
+	return e.err
+}
+
 // Encode writes v to the stream, encoding it based on the emitter configured
 // on e.
 func (e *StreamEncoder) Encode(v interface{}) error {
+	if err := e.prepare(); err != nil {
+		return err
+	}
+	e.err = e.encoder().Encode(v)
+	e.next()
+	return e.err
+}
+
+// EncodeBool writes the boolean v to the stream.
+//
+// This method and the other typed Encode* methods behave like Encode but
+// receive values of concrete types, which prevents the compiler from
+// allocating memory to convert them to empty interfaces.
+func (e *StreamEncoder) EncodeBool(v bool) error {
+	if err := e.prepare(); err != nil {
+		return err
+	}
+	e.err = e.encoder().EncodeBool(v)
+	e.next()
+	return e.err
+}
+
+// EncodeInt writes the integer v to the stream.
+func (e *StreamEncoder) EncodeInt(v int) error {
+	if err := e.prepare(); err != nil {
+		return err
+	}
+	e.err = e.encoder().EncodeInt(v)
+	e.next()
+	return e.err
+}
+
+// EncodeInt64 writes the 64 bits integer v to the stream.
+func (e *StreamEncoder) EncodeInt64(v int64) error {
+	if err := e.prepare(); err != nil {
+		return err
+	}
+	e.err = e.encoder().EncodeInt64(v)
+	e.next()
+	return e.err
+}
+
+// EncodeUint64 writes the 64 bits unsigned integer v to the stream.
+func (e *StreamEncoder) EncodeUint64(v uint64) error {
+	if err := e.prepare(); err != nil {
+		return err
+	}
+	e.err = e.encoder().EncodeUint64(v)
+	e.next()
+	return e.err
+}
+
+// EncodeFloat64 writes the 64 bits floating point number v to the stream.
+func (e *StreamEncoder) EncodeFloat64(v float64) error {
+	if err := e.prepare(); err != nil {
+		return err
+	}
+	e.err = e.encoder().EncodeFloat64(v)
+	e.next()
+	return e.err
+}
+
+// EncodeString writes the string v to the stream.
+func (e *StreamEncoder) EncodeString(v string) error {
+	if err := e.prepare(); err != nil {
+		return err
+	}
+	e.err = e.encoder().EncodeString(v)
+	e.next()
+	return e.err
+}
+
+// EncodeBytes writes the byte slice v to the stream.
+func (e *StreamEncoder) EncodeBytes(v []byte) error {
+	if err := e.prepare(); err != nil {
+		return err
+	}
+	e.err = e.encoder().EncodeBytes(v)
+	e.next()
+	return e.err
+}
+
+// EncodeTime writes the time value v to the stream.
+func (e *StreamEncoder) EncodeTime(v time.Time) error {
+	if err := e.prepare(); err != nil {
+		return err
+	}
+	e.err = e.encoder().EncodeTime(v)
+	e.next()
+	return e.err
+}
+
+// EncodeDuration writes the duration v to the stream.
+func (e *StreamEncoder) EncodeDuration(v time.Duration) error {
+	if err := e.prepare(); err != nil {
+		return err
+	}
+	e.err = e.encoder().EncodeDuration(v)
+	e.next()
+	return e.err
+}
+
+// prepare gets the stream ready to receive the next value.
+func (e *StreamEncoder) prepare() error {
 	if err := e.Open(-1); err != nil {
 		return err
 	}
 
 	if e.max >= 0 && e.cnt >= e.max {
-		return fmt.Errorf("objconv: too many values sent to a stream encoder exceed the configured limit of %d", e.max)
+		return objutil.Errorf(objutil.ErrLimit, "objconv: too many values sent to a stream encoder exceed the configured limit of %d", e.max)
 	}
 
 	if !e.oneshot && e.cnt != 0 {
 		e.err = e.Emitter.EmitArrayNext()
 	}
 
-	if e.err == nil {
-		e.err = (Encoder{
-			Emitter:     e.Emitter,
-			SortMapKeys: e.SortMapKeys,
-		}).Encode(v)
+	return e.err
+}
 
-		if e.cnt++; e.max >= 0 && e.cnt >= e.max {
-			e.Close()
-		}
+func (e *StreamEncoder) encoder() Encoder {
+	return Encoder{
+		Emitter:               e.Emitter,
+		SortMapKeys:           e.SortMapKeys,
+		Capturer:              e.Capturer,
+		FieldRecorder:         e.FieldRecorder,
+		DisallowOpaqueStructs: e.DisallowOpaqueStructs,
+		PreserveTypes:         e.PreserveTypes,
+		TagNames:              e.TagNames,
+		FieldNaming:           e.FieldNaming,
+		Keys:                  e.Keys,
 	}
+}
 
-	return e.err
+// next counts a value written to the stream and closes it when the limit is
+// reached.
+func (e *StreamEncoder) next() {
+	if e.cnt++; e.max >= 0 && e.cnt >= e.max {
+		e.Close()
+	}
 }
 
 // ValueEncoder is the interface that can be implemented by types that wish to
@@ -814,7 +1369,8 @@ func (f ValueEncoderFunc) EncodeValue(e Encoder) error { return f(e) }
 // encodeFuncOpts is used to configure how the encodeFuncOf behaves.
 type encodeFuncOpts struct {
 	recurse bool
-	structs map[reflect.Type]*structType
+	structs *structTypes
+	named   bool // set when the function of a registered named type is made
 }
 
 // encodeFunc is the prototype of functions that encode values.
@@ -830,6 +1386,19 @@ func makeEncodeFunc(t reflect.Type, opts encodeFuncOpts) encodeFunc {
 		return adapter.Encode
 	}
 
+	if name, ok := namedTypeName(t); ok && !opts.named {
+		opts.named = true
+		return makeEncodeNamedFunc(name, makeEncodeFunc(t, opts))
+	}
+
+	if t.Kind() == reflect.Ptr {
+		// Same as decoders, adapters of the element type are used instead of
+		// the methods of the pointer type.
+		if _, ok := AdapterOf(t.Elem()); ok {
+			return makeEncodePtrFunc(t, opts)
+		}
+	}
+
 	switch t {
 	case boolType:
 		return Encoder.encodeBool
@@ -893,13 +1462,13 @@ func makeEncodeFunc(t reflect.Type, opts encodeFuncOpts) encodeFunc {
 	case t.Implements(valueEncoderInterface):
 		return Encoder.encodeEncoder
 
-	case t.Implements(binaryMarshalerInterface) && t.Implements(textMarshalerInterface):
+	case isBinaryMarshaler(t) && isTextMarshaler(t):
 		return Encoder.encodeMarshaler
 
-	case t.Implements(binaryMarshalerInterface):
+	case isBinaryMarshaler(t):
 		return Encoder.encodeBinaryMarshaler
 
-	case t.Implements(textMarshalerInterface):
+	case isTextMarshaler(t):
 		return Encoder.encodeTextMarshaler
 
 	case t.Implements(errorInterface):
@@ -925,6 +1494,15 @@ func makeEncodeFunc(t reflect.Type, opts encodeFuncOpts) encodeFunc {
 	case reflect.Array:
 		return makeEncodeArrayFunc(t, opts)
 
+	case reflect.Func:
+		if types, ok := seqTypes(t); ok {
+			if len(types) == 1 {
+				return makeEncodeSeqFunc(t, types[0])
+			}
+			return makeEncodeSeq2Func(t, types[0], types[1])
+		}
+		return Encoder.encodeUnsupported
+
 	case reflect.String:
 		return Encoder.encodeString
 
@@ -122,6 +122,19 @@ func TestEncoder(t *testing.T) {
 			},
 		},
 
+		// omitzero uses the IsZero method of types like time.Time
+		{
+			in: &struct {
+				A time.Time `objconv:"a,omitempty"`
+				B time.Time `objconv:"b,omitzero"`
+				C time.Time `objconv:"c,omitzero"`
+			}{time.Time{}, time.Time{}.In(time.FixedZone("UTC+1", 3600)), time.Unix(0, 0).UTC()},
+			out: map[interface{}]interface{}{
+				"a": time.Time{},
+				"c": time.Unix(0, 0).UTC(),
+			},
+		},
+
 		// struct tags (json)
 		{
 			in: &struct {
@@ -330,3 +343,108 @@ func TestStreamEncoderVar(t *testing.T) {
 		t.Error(x1, "!=", x2)
 	}
 }
+
+func TestEncoderTyped(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		key    string
+		encode func(Encoder) error
+		value  interface{}
+	}{
+		{"nil", func(e Encoder) error { return e.EncodeNil() }, nil},
+		{"bool", func(e Encoder) error { return e.EncodeBool(true) }, true},
+		{"int", func(e Encoder) error { return e.EncodeInt(-1) }, int64(-1)},
+		{"int64", func(e Encoder) error { return e.EncodeInt64(-2) }, int64(-2)},
+		{"uint64", func(e Encoder) error { return e.EncodeUint64(3) }, uint64(3)},
+		{"float64", func(e Encoder) error { return e.EncodeFloat64(0.5) }, 0.5},
+		{"string", func(e Encoder) error { return e.EncodeString("A") }, "A"},
+		{"bytes", func(e Encoder) error { return e.EncodeBytes([]byte("B")) }, []byte("B")},
+		{"time", func(e Encoder) error { return e.EncodeTime(now) }, now},
+		{"duration", func(e Encoder) error { return e.EncodeDuration(time.Second) }, time.Second},
+	}
+
+	val := &ValueEmitter{}
+	enc := NewEncoder(val)
+	i := 0
+
+	err := enc.EncodeMap(len(tests), func(ke Encoder, ve Encoder) error {
+		if err := ke.EncodeString(tests[i].key); err != nil {
+			return err
+		}
+		err := tests[i].encode(ve)
+		i++
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := map[interface{}]interface{}{}
+	for _, test := range tests {
+		m[test.key] = test.value
+	}
+
+	if v := val.Value(); !reflect.DeepEqual(v, m) {
+		t.Errorf("%#v != %#v", m, v)
+	}
+}
+
+func TestStreamEncoderTyped(t *testing.T) {
+	val := &ValueEmitter{}
+	enc := NewStreamEncoder(val)
+
+	if err := enc.Open(3); err != nil {
+		t.Error(err)
+	}
+
+	enc.EncodeString("A")
+	enc.EncodeInt64(1)
+	enc.EncodeBool(false)
+
+	if err := enc.EncodeString("too many"); err == nil {
+		t.Error("expected an error when exceeding the stream length")
+	}
+
+	x1 := []interface{}{"A", int64(1), false}
+	x2 := val.Value()
+
+	if !reflect.DeepEqual(x1, x2) {
+		t.Error(x1, "!=", x2)
+	}
+}
+
+func TestStreamEncoderHeartbeatUnsupported(t *testing.T) {
+	val := &ValueEmitter{}
+	enc := NewStreamEncoder(val)
+
+	if err := enc.Heartbeat(); err == nil {
+		t.Error("expected an error from an emitter that doesn't support heartbeats")
+	}
+
+	if err := enc.Encode(1); err != nil {
+		t.Error("the stream is not usable after a heartbeat error:", err)
+	}
+}
+
+type binaryAppenderValue []byte
+
+func (v binaryAppenderValue) AppendBinary(b []byte) ([]byte, error) {
+	return append(b, v...), nil
+}
+
+func TestEncoderBinaryAppender(t *testing.T) {
+	// The value emitter retains the values it receives, the buffers that they
+	// are appended to must not be reused.
+	e := NewValueEmitter()
+	enc := NewEncoder(e)
+	v := []interface{}{binaryAppenderValue("hello"), binaryAppenderValue("world")}
+
+	if err := enc.Encode(v); err != nil {
+		t.Fatal(err)
+	}
+
+	if x := e.Value(); !reflect.DeepEqual(x, []interface{}{[]byte("hello"), []byte("world")}) {
+		t.Errorf("bad value: %#v", x)
+	}
+}
@@ -0,0 +1,261 @@
+package objconv
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding"
+	"fmt"
+	"reflect"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// A KeyProvider supplies the keys used to encrypt and decrypt the values of
+// struct fields which have the `encrypt` tag option, see Encoder.Keys.
+//
+// Key providers that are shared by multiple encoders or decoders must be safe
+// to use concurrently.
+type KeyProvider interface {
+	// EncryptionKey returns the id of the key that values are encrypted with,
+	// and the AEAD of the key. The id is written in the header of encrypted
+	// values, it must be at most 255 bytes long.
+	EncryptionKey() (id string, aead cipher.AEAD, err error)
+
+	// DecryptionKey returns the AEAD of the key with the given id, read from
+	// the header of an encrypted value.
+	DecryptionKey(id string) (cipher.AEAD, error)
+}
+
+// Keyring is a KeyProvider of AES-GCM keys indexed by their ids, the keys must
+// be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+//
+// Values are encrypted with the primary key, and decrypted with the key that
+// they were encrypted with. Keys are rotated by adding a new key to the ring
+// and making it the primary key, the previous keys are kept until the values
+// that they encrypted have been written again.
+type Keyring struct {
+	Primary string            // id of the key used to encrypt values
+	Keys    map[string][]byte // keys indexed by their ids
+}
+
+// EncryptionKey satisfies the KeyProvider interface.
+func (k Keyring) EncryptionKey() (string, cipher.AEAD, error) {
+	aead, err := k.DecryptionKey(k.Primary)
+	return k.Primary, aead, err
+}
+
+// DecryptionKey satisfies the KeyProvider interface.
+func (k Keyring) DecryptionKey(id string) (cipher.AEAD, error) {
+	key, ok := k.Keys[id]
+
+	if !ok {
+		return nil, fmt.Errorf("objconv: the keyring has no key with id %q", id)
+	}
+
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(c)
+}
+
+// Encrypted values are byte sequences made of a header holding the version of
+// the format and the id of the key, followed by the nonce and the sealed
+// plaintext. The header is authenticated as additional data, followed by the
+// type of the struct and the name of the field holding the value, so values
+// fail to decrypt when they are moved to other fields or structs.
+//
+//	| version (1) | len(id) (1) | id | nonce | ciphertext |
+const encryptionVersion = 1
+
+func encrypt(keys KeyProvider, plaintext []byte, context string) ([]byte, error) {
+	id, aead, err := keys.EncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(id) > 255 {
+		return nil, objutil.Errorf(objutil.ErrRange, "objconv: the key id %q is longer than 255 bytes", id)
+	}
+
+	n := aead.NonceSize()
+	b := make([]byte, 2+len(id)+n, 2+len(id)+n+len(plaintext)+aead.Overhead())
+	b[0], b[1] = encryptionVersion, byte(len(id))
+	copy(b[2:], id)
+
+	header, nonce := b[:2+len(id)], b[2+len(id):]
+
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(b, nonce, plaintext, additionalData(header, context)), nil
+}
+
+func decrypt(keys KeyProvider, b []byte, context string) ([]byte, error) {
+	if len(b) < 2 || b[0] != encryptionVersion || len(b) < 2+int(b[1]) {
+		return nil, objutil.Errorf(objutil.ErrSyntax, "objconv: malformed encrypted value")
+	}
+
+	header := b[:2+int(b[1])]
+	aead, err := keys.DecryptionKey(string(header[2:]))
+	if err != nil {
+		return nil, err
+	}
+
+	b = b[len(header):]
+
+	if n := aead.NonceSize(); len(b) < n+aead.Overhead() {
+		return nil, objutil.Errorf(objutil.ErrSyntax, "objconv: malformed encrypted value")
+	}
+
+	nonce, ciphertext := b[:aead.NonceSize()], b[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData(header, context))
+
+	if err != nil {
+		// The value was tampered with, moved from another field, or encrypted
+		// with another key which has the same id.
+		return nil, objutil.Errorf(objutil.ErrSyntax, "objconv: cannot decrypt the value: %s", err)
+	}
+
+	return plaintext, nil
+}
+
+func additionalData(header []byte, context string) []byte {
+	ad := make([]byte, 0, len(header)+len(context))
+	return append(append(ad, header...), context...)
+}
+
+// encryptionContext returns the context that the values of the field named
+// name of the struct type t are authenticated with.
+func encryptionContext(t reflect.Type, name string) string {
+	return t.String() + "\x00" + name
+}
+
+// plaintextCodec converts the values of encrypted fields to and from the bytes
+// that are encrypted.
+type plaintextCodec struct {
+	marshal   func(reflect.Value) ([]byte, error)
+	unmarshal func(reflect.Value, []byte) error
+}
+
+// plaintextCodecOf returns the plaintext codec of values of type t, encrypted
+// fields hold strings, byte slices, values with a binary or text
+// representation, or pointers to those.
+func plaintextCodecOf(t reflect.Type) (c plaintextCodec, ok bool) {
+	if t.Kind() == reflect.Ptr {
+		return plaintextCodecOf(t.Elem())
+	}
+
+	p := reflect.PtrTo(t)
+
+	switch {
+	case t.Implements(binaryMarshalerInterface) && p.Implements(binaryUnmarshalerInterface):
+		c.marshal = func(v reflect.Value) ([]byte, error) {
+			return v.Interface().(encoding.BinaryMarshaler).MarshalBinary()
+		}
+		c.unmarshal = func(v reflect.Value, b []byte) error {
+			return v.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(b)
+		}
+
+	case t.Implements(textMarshalerInterface) && p.Implements(textUnmarshalerInterface):
+		c.marshal = func(v reflect.Value) ([]byte, error) {
+			return v.Interface().(encoding.TextMarshaler).MarshalText()
+		}
+		c.unmarshal = func(v reflect.Value, b []byte) error {
+			return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText(b)
+		}
+
+	case t.Kind() == reflect.String:
+		c.marshal = func(v reflect.Value) ([]byte, error) { return []byte(v.String()), nil }
+		c.unmarshal = func(v reflect.Value, b []byte) error { v.SetString(string(b)); return nil }
+
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		c.marshal = func(v reflect.Value) ([]byte, error) { return v.Bytes(), nil }
+		c.unmarshal = func(v reflect.Value, b []byte) error { v.SetBytes(b); return nil }
+
+	default:
+		return c, false
+	}
+
+	return c, true
+}
+
+// makeEncryptedField configures f, a field of the struct type s which holds
+// values of type t, to encrypt its values when they are encoded and decrypt
+// them when they are decoded.
+func makeEncryptedField(f *structField, s reflect.Type, t reflect.Type) bool {
+	c, ok := plaintextCodecOf(t)
+
+	if ok {
+		ctx := encryptionContext(s, f.name)
+		f.encode = func(e Encoder, v reflect.Value) error { return e.encodeEncrypted(c, ctx, v) }
+		f.decode = func(d Decoder, v reflect.Value) (Type, error) { return d.decodeEncrypted(c, ctx, v) }
+	}
+
+	return ok
+}
+
+func (e Encoder) encodeEncrypted(c plaintextCodec, context string, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return e.Emitter.EmitNil()
+		}
+		v = v.Elem()
+	}
+
+	if e.Keys == nil {
+		return objutil.Errorf(objutil.ErrType, "objconv: encrypted fields cannot be encoded without a key provider")
+	}
+
+	b, err := c.marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if b, err = encrypt(e.Keys, b, context); err != nil {
+		return err
+	}
+
+	return e.Emitter.EmitBytes(b)
+}
+
+func (d Decoder) decodeEncrypted(c plaintextCodec, context string, to reflect.Value) (t Type, err error) {
+	var b []byte
+
+	if t, err = d.Parser.ParseType(); err != nil {
+		return
+	}
+
+	if t == Nil {
+		if err = d.Parser.ParseNil(); err == nil {
+			to.Set(reflect.Zero(to.Type()))
+		}
+		return
+	}
+
+	if d.Keys == nil {
+		err = objutil.Errorf(objutil.ErrType, "objconv: encrypted fields cannot be decoded without a key provider")
+		return
+	}
+
+	if b, err = d.parseBytes(t); err != nil {
+		return
+	}
+
+	if b, err = decrypt(d.Keys, b, context); err != nil {
+		return
+	}
+
+	if to.Kind() == reflect.Ptr {
+		if to.IsNil() {
+			to.Set(reflect.New(to.Type().Elem()))
+		}
+		to = to.Elem()
+	}
+
+	err = c.unmarshal(to, b)
+	return
+}
@@ -0,0 +1,180 @@
+package objconv
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type patient struct {
+	Name  string
+	SSN   string    `objconv:"ssn,encrypt"`
+	Birth time.Time `objconv:"birth,encrypt"`
+	Notes []byte    `objconv:"notes,encrypt,omitempty"`
+	Phone *string   `objconv:"phone,encrypt"`
+}
+
+var testKeyring = Keyring{
+	Primary: "k1",
+	Keys: map[string][]byte{
+		"k1": bytes.Repeat([]byte{1}, 16),
+		"k2": bytes.Repeat([]byte{2}, 32),
+	},
+}
+
+func encryptedValue(t *testing.T, keys KeyProvider, v interface{}) map[interface{}]interface{} {
+	e := NewValueEmitter()
+	enc := NewEncoder(e)
+	enc.Keys = keys
+
+	if err := enc.Encode(v); err != nil {
+		t.Fatal(err)
+	}
+
+	return e.Value().(map[interface{}]interface{})
+}
+
+func TestEncrypt(t *testing.T) {
+	phone := "555-0100"
+	p1 := patient{
+		Name:  "Luke",
+		SSN:   "078-05-1120",
+		Birth: time.Date(1977, 5, 25, 0, 0, 0, 0, time.UTC),
+		Notes: []byte("allergic to sand"),
+		Phone: &phone,
+	}
+
+	m := encryptedValue(t, testKeyring, p1)
+
+	if m["Name"] != "Luke" {
+		t.Errorf("fields without the encrypt option must be left unchanged: %#v", m["Name"])
+	}
+
+	for _, k := range []string{"ssn", "birth", "notes", "phone"} {
+		b, ok := m[k].([]byte)
+
+		if !ok {
+			t.Errorf("%s: encrypted values must be byte sequences: %#v", k, m[k])
+			continue
+		}
+
+		if !bytes.HasPrefix(b, []byte("\x01\x02k1")) {
+			t.Errorf("%s: bad header: %q", k, b)
+		}
+
+		if bytes.Contains(b, []byte("078-05-1120")) || bytes.Contains(b, []byte("sand")) {
+			t.Errorf("%s: the value was not encrypted: %q", k, b)
+		}
+	}
+
+	var p2 patient
+	d := NewDecoder(NewValueParser(m))
+	d.Keys = testKeyring
+
+	if err := d.Decode(&p2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(p1, p2) {
+		t.Errorf("%#v != %#v", p1, p2)
+	}
+}
+
+func TestEncryptNil(t *testing.T) {
+	m := encryptedValue(t, testKeyring, patient{SSN: "1"})
+
+	if _, ok := m["notes"]; ok {
+		t.Error("encrypted fields which are empty must still be omitted")
+	}
+
+	if m["phone"] != nil {
+		t.Errorf("nil pointers must be encoded as nil: %#v", m["phone"])
+	}
+}
+
+func TestEncryptKeyRotation(t *testing.T) {
+	m := encryptedValue(t, testKeyring, patient{SSN: "078-05-1120"})
+
+	// Values encrypted with previous keys are decrypted with the key that
+	// their header references.
+	keys := testKeyring
+	keys.Primary = "k2"
+
+	var p patient
+	d := NewDecoder(NewValueParser(m))
+	d.Keys = keys
+
+	if err := d.Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.SSN != "078-05-1120" {
+		t.Errorf("bad value: %q", p.SSN)
+	}
+
+	if b := encryptedValue(t, keys, p)["ssn"].([]byte); !bytes.HasPrefix(b, []byte("\x01\x02k2")) {
+		t.Errorf("bad header: %q", b)
+	}
+}
+
+func TestEncryptErrors(t *testing.T) {
+	m := encryptedValue(t, testKeyring, patient{SSN: "078-05-1120"})
+	b := m["ssn"].([]byte)
+	b[len(b)-1] ^= 1
+
+	d := NewDecoder(NewValueParser(m))
+	d.Keys = testKeyring
+
+	if err := d.Decode(&patient{}); !errors.Is(err, ErrSyntax) {
+		t.Errorf("tampered values must be syntax errors: %v", err)
+	}
+
+	// Values are bound to their field and struct type, they cannot be moved
+	// to other fields.
+	m = encryptedValue(t, testKeyring, patient{SSN: "078-05-1120", Notes: []byte("notes")})
+	m["ssn"], m["notes"] = m["notes"], m["ssn"]
+
+	d = NewDecoder(NewValueParser(m))
+	d.Keys = testKeyring
+
+	if err := d.Decode(&patient{}); !errors.Is(err, ErrSyntax) {
+		t.Errorf("swapped values must be syntax errors: %v", err)
+	}
+
+	type other struct {
+		SSN string `objconv:"ssn,encrypt"`
+	}
+
+	d = NewDecoder(NewValueParser(encryptedValue(t, testKeyring, patient{SSN: "078-05-1120"})))
+	d.Keys = testKeyring
+
+	if err := d.Decode(&other{}); !errors.Is(err, ErrSyntax) {
+		t.Errorf("values moved to other struct types must be syntax errors: %v", err)
+	}
+
+	if err := NewDecoder(NewValueParser(map[string]interface{}{"ssn": []byte("\x01\xff")})).Decode(&patient{}); !errors.Is(err, ErrType) {
+		t.Errorf("decoding without a key provider must be a type error: %v", err)
+	}
+
+	d = NewDecoder(NewValueParser(map[string]interface{}{"ssn": []byte("\x01\xff")}))
+	d.Keys = testKeyring
+
+	if err := d.Decode(&patient{}); !errors.Is(err, ErrSyntax) {
+		t.Errorf("malformed values must be syntax errors: %v", err)
+	}
+
+	if err := NewEncoder(NewValueEmitter()).Encode(patient{}); !errors.Is(err, ErrType) {
+		t.Errorf("encoding without a key provider must be a type error: %v", err)
+	}
+
+	e := NewEncoder(NewValueEmitter())
+	e.Keys = testKeyring
+
+	if err := e.Encode(struct {
+		N int `objconv:",encrypt"`
+	}{}); !errors.Is(err, ErrType) {
+		t.Errorf("bad error for unsupported types: %v", err)
+	}
+}
@@ -2,13 +2,71 @@ package objconv
 
 import (
 	"errors"
-	"fmt"
+	"io"
+
+	"github.com/segmentio/objconv/objutil"
 )
 
+// StreamError is the error returned by stream decoders when the producer of the
+// stream terminated it with StreamEncoder.CloseWithError.
+//
+// Programs can use it to tell apart streams that were interrupted by their
+// producer from streams that were truncated, for example because a connection
+// was dropped, in which case the decoder returns an error from the parser.
+type StreamError struct {
+	Message string // the error message sent by the producer
+}
+
+// Error satisfies the error interface.
+func (e *StreamError) Error() string {
+	return "objconv: the stream was terminated with an error: " + e.Message
+}
+
+// errUnexpectedEOF is the error returned in place of io.EOF when the input ends
+// in the middle of a value.
+var errUnexpectedEOF error = unexpectedEOF{}
+
+type unexpectedEOF struct{}
+
+func (unexpectedEOF) Error() string { return "objconv: unexpected end of input" }
+
+// Is lets programs test for the error with either ErrSyntax or
+// io.ErrUnexpectedEOF.
+func (unexpectedEOF) Is(err error) bool { return err == ErrSyntax || err == io.ErrUnexpectedEOF }
+
 func typeConversionError(from Type, to Type) error {
-	return fmt.Errorf("objconv: cannot convert from %s to %s", from, to)
+	return objutil.Errorf(objutil.ErrType, "objconv: cannot convert from %s to %s", from, to)
 }
 
+// Kinds of errors returned by the parsers, emitters, encoders and decoders of
+// objconv, programs use errors.Is to test whether an error is of one of these
+// kinds instead of matching error messages:
+//
+//	if errors.Is(err, objconv.ErrSyntax) {
+//		http.Error(w, err.Error(), http.StatusBadRequest)
+//		return
+//	}
+var (
+	// ErrSyntax is the kind of errors reporting malformed input.
+	ErrSyntax = objutil.ErrSyntax
+
+	// ErrType is the kind of errors reporting values that cannot be converted
+	// to or from the requested type.
+	ErrType = objutil.ErrType
+
+	// ErrRange is the kind of errors reporting values that overflow the range
+	// of their destination type or of the output format.
+	ErrRange = objutil.ErrRange
+
+	// ErrLimit is the kind of errors reporting values that exceed a size limit
+	// of the format or of the program.
+	ErrLimit = objutil.ErrLimit
+
+	// ErrUnknownField is the kind of errors reporting fields of the input that
+	// don't exist in the destination struct.
+	ErrUnknownField = objutil.ErrUnknownField
+)
+
 var (
 	// End is expected to be returned to indicate that a function has completed
 	// its work, this is usually employed in generic algorithms.
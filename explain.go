@@ -0,0 +1,387 @@
+package objconv
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Explanation is a structured description of how an encoder serializes a value,
+// it is returned by the Explain method of encoders.
+type Explanation struct {
+	// Name of the struct field, map key, or slice index that the explanation
+	// is for. The name is empty for the top-level value.
+	Name string
+
+	// Tag is the struct tag that the field name and options were read from,
+	// or an empty string if it had none.
+	Tag string
+
+	// Type is the Go type of the value, nil if the value was a nil interface.
+	Type reflect.Type
+
+	// Repr is the type that the value is represented as in the output.
+	Repr Type
+
+	// Method is set when the value is not encoded by the generic algorithms,
+	// it describes the custom encoding that the encoder uses (an adapter, the
+	// ValueEncoder interface, encoding.TextMarshaler, ...).
+	Method string
+
+	// Omitted is the reason why the field is not part of the output, or an
+	// empty string if it is.
+	Omitted string
+
+	// Notes lists suspicious details that were found while explaining the
+	// value, like unknown options in struct tags.
+	Notes []string
+
+	// Fields contains the explanations of struct fields, map entries, or slice
+	// elements.
+	Fields []Explanation
+}
+
+// String returns a human-readable representation of x, with one line per field.
+func (x Explanation) String() string {
+	b := &bytes.Buffer{}
+	x.write(b, 0)
+	return b.String()
+}
+
+func (x *Explanation) write(b *bytes.Buffer, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+
+	if len(x.Name) != 0 {
+		b.WriteString(x.Name)
+		b.WriteString(" ")
+	}
+
+	if x.Type != nil {
+		fmt.Fprintf(b, "(%s) ", x.Type)
+	}
+
+	if len(x.Omitted) != 0 {
+		fmt.Fprintf(b, "omitted: %s", x.Omitted)
+	} else {
+		fmt.Fprintf(b, "-> %s", x.Repr)
+	}
+
+	if len(x.Method) != 0 {
+		fmt.Fprintf(b, " [%s]", x.Method)
+	}
+
+	for _, note := range x.Notes {
+		fmt.Fprintf(b, " (%s)", note)
+	}
+
+	b.WriteString("\n")
+
+	for i := range x.Fields {
+		x.Fields[i].write(b, depth+1)
+	}
+}
+
+// Explain returns a description of how v would be serialized by the encoder,
+// without producing any output.
+//
+// The explanation lists every field of structs found in v, including the ones
+// that are not serialized and why (omitempty, unexported, ...), which helps
+// understanding why a field is missing from the output.
+func (e Encoder) Explain(v interface{}) (Explanation, error) {
+	return e.explain(reflect.ValueOf(v))
+}
+
+func (e Encoder) explain(v reflect.Value) (x Explanation, err error) {
+	if !v.IsValid() {
+		x.Repr = Nil
+		return
+	}
+
+	x.Type = v.Type()
+	x.Method = encodeMethodOf(x.Type)
+
+	if x.Repr, err = e.reprOf(v); err != nil || len(x.Method) != 0 {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			var elem Explanation
+
+			if elem, err = e.explain(v.Elem()); err != nil {
+				return
+			}
+
+			x.Method = elem.Method
+			x.Notes = elem.Notes
+			x.Fields = elem.Fields
+		}
+
+	case reflect.Struct:
+		if x.Repr == Map { // time.Time is a struct encoded as a single value
+			x.Fields, err = e.explainStruct(v)
+		}
+
+	case reflect.Map:
+		keys := v.MapKeys()
+		sortValues(v.Type().Key(), keys)
+
+		for _, k := range keys {
+			var f Explanation
+
+			if f, err = e.explain(v.MapIndex(k)); err != nil {
+				return
+			}
+
+			f.Name = fmt.Sprint(k.Interface())
+			x.Fields = append(x.Fields, f)
+		}
+
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break // byte slices are encoded as a single value
+		}
+
+		for i, n := 0, v.Len(); i != n; i++ {
+			var f Explanation
+
+			if f, err = e.explain(v.Index(i)); err != nil {
+				return
+			}
+
+			f.Name = fmt.Sprintf("[%d]", i)
+			x.Fields = append(x.Fields, f)
+		}
+	}
+
+	return
+}
+
+func (e Encoder) explainStruct(v reflect.Value) (fields []Explanation, err error) {
+	t := v.Type()
+	s := structCacheOf(e.TagNames, e.FieldNaming).lookup(t)
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	v = s.addressable(v)
+
+	for i, n := 0, t.NumField(); i != n; i++ {
+		ft := t.Field(i)
+		fx := Explanation{Name: ft.Name, Type: ft.Type}
+
+		tag, notes := explainTag(ft)
+		fx.Tag = tag
+
+		var sf *structField
+
+		for j := range s.fields {
+			if !s.fields[j].virtual && s.fields[j].inline == nil && s.fields[j].index[0] == i {
+				sf = &s.fields[j]
+				break
+			}
+		}
+
+		switch {
+		case ft.Anonymous:
+			fx.Omitted = "embedded fields are not serialized"
+
+		case isIgnoredType(ft.Type):
+			fx.Omitted = fmt.Sprintf("fields of type %s are ignored", ft.Type)
+
+		case len(ft.PkgPath) != 0 && sf == nil:
+			fx.Omitted = "unexported field"
+
+		case parseFieldTag(ft, e.TagNames).Inline:
+			if fx, err = e.explain(v.Field(i)); err != nil {
+				return
+			}
+			fx.Name, fx.Tag = ft.Name, tag
+			fx.Notes = append(fx.Notes, "inline is set, the fields are merged into the parent object")
+
+		default:
+			if sf == nil {
+				fx.Omitted = `the tag name is "-"`
+				break
+			}
+
+			if sf.omitted {
+				fx.Name, fx.Omitted = sf.name, "the field policy of the type omits the field"
+				break
+			}
+
+			fv := sf.value(v)
+
+			switch {
+			case sf.omitempty && objutil.IsEmptyValue(fv):
+				fx.Omitted = "omitempty is set and the value is empty"
+			case sf.omitzero && objutil.IsZeroValue(fv):
+				fx.Omitted = "omitzero is set and the value is zero"
+			default:
+				if fx, err = e.explain(fv); err != nil {
+					return
+				}
+				fx.Tag = tag
+
+				if sf.redacted {
+					fx.Notes = append(fx.Notes, fmt.Sprintf("the field policy of the type redacts the value, it is encoded as %q", sf.placeholder))
+				}
+
+				if sf.hidden && sf.getter >= 0 {
+					fx.Method = fmt.Sprintf("getter %s", reflect.PtrTo(t).Method(sf.getter).Name)
+				} else if sf.hidden {
+					fx.Notes = append(fx.Notes, "unexported field accessed with the export tag option")
+				}
+			}
+
+			fx.Name = sf.name
+		}
+
+		fx.Notes = append(fx.Notes, notes...)
+		fields = append(fields, fx)
+	}
+
+	for i := range s.fields {
+		sf := &s.fields[i]
+
+		if !sf.virtual || sf.inline != nil {
+			continue
+		}
+
+		fv := sf.value(v)
+		fx := Explanation{Name: sf.name, Type: fv.Type()}
+
+		if sf.omit(fv) {
+			fx.Omitted = "omitempty or omitzero is set and the value is empty"
+		} else if fx, err = e.explain(fv); err != nil {
+			return
+		}
+
+		fx.Name = sf.name
+
+		if sf.links != nil {
+			fx.Method = fmt.Sprintf("%d link builders", len(sf.links))
+		} else {
+			fx.Method = fmt.Sprintf("method %s", reflect.PtrTo(t).Method(sf.getter).Name)
+		}
+
+		fields = append(fields, fx)
+	}
+
+	return
+}
+
+// explainTag returns the struct tag that configures f, and notes about options
+// in the tag that will be ignored.
+func explainTag(f reflect.StructField) (tag string, notes []string) {
+	var s string
+	var known map[string]bool
+
+	if s = f.Tag.Get("objconv"); len(s) != 0 {
+		tag = fmt.Sprintf("objconv:%q", s)
+		known = map[string]bool{"omitempty": true, "omitzero": true, "export": true, "attr": true, "chardata": true, "inline": true, "encrypt": true}
+	} else if s = f.Tag.Get("json"); len(s) != 0 {
+		tag = fmt.Sprintf("json:%q", s)
+		known = map[string]bool{"omitempty": true}
+	} else {
+		return
+	}
+
+	options := strings.Split(s, ",")[1:]
+
+	for _, opt := range options {
+		if !known[opt] {
+			notes = append(notes, fmt.Sprintf("unknown tag option %q is ignored", opt))
+		}
+	}
+
+	return
+}
+
+// encodeMethodOf describes the custom encoding used for values of type t, or
+// returns an empty string if t is encoded by the generic algorithms.
+func encodeMethodOf(t reflect.Type) string {
+	if _, ok := AdapterOf(t); ok {
+		return "adapter"
+	}
+
+	switch t {
+	case timeType, timePtrType, durationType:
+		return ""
+	}
+
+	switch {
+	case t.Implements(valueEncoderInterface):
+		return "objconv.ValueEncoder"
+	case isBinaryMarshaler(t) && isTextMarshaler(t):
+		return textMarshalerName(t) + " or " + binaryMarshalerName(t)
+	case isBinaryMarshaler(t):
+		return binaryMarshalerName(t)
+	case isTextMarshaler(t):
+		return textMarshalerName(t)
+	case t.Implements(errorInterface):
+		return "error"
+	}
+
+	return ""
+}
+
+// reprOf returns the type of the first value emitted when encoding v.
+func (e Encoder) reprOf(v reflect.Value) (Type, error) {
+	r := &reprEmitter{text: e.Emitter != nil && isTextEmitter(e.Emitter)}
+	err := Encoder{Emitter: r, SortMapKeys: e.SortMapKeys, nested: true}.Encode(v.Interface())
+	return r.typ, err
+}
+
+// reprEmitter is an emitter recording the type of the first value it
+// receives.
+type reprEmitter struct {
+	typ  Type
+	text bool
+}
+
+func (e *reprEmitter) set(t Type) error {
+	if e.typ == Unknown {
+		e.typ = t
+	}
+	return nil
+}
+
+func (e *reprEmitter) EmitNil() error                     { return e.set(Nil) }
+func (e *reprEmitter) EmitBool(v bool) error              { return e.set(Bool) }
+func (e *reprEmitter) EmitInt(v int64, _ int) error       { return e.set(Int) }
+func (e *reprEmitter) EmitUint(v uint64, _ int) error     { return e.set(Uint) }
+func (e *reprEmitter) EmitFloat(v float64, _ int) error   { return e.set(Float) }
+func (e *reprEmitter) EmitString(v string) error          { return e.set(String) }
+func (e *reprEmitter) EmitBytes(v []byte) error           { return e.set(Bytes) }
+func (e *reprEmitter) EmitTime(v time.Time) error         { return e.set(Time) }
+func (e *reprEmitter) EmitDuration(v time.Duration) error { return e.set(Duration) }
+func (e *reprEmitter) EmitError(v error) error            { return e.set(Error) }
+func (e *reprEmitter) EmitArrayBegin(v int) error         { return e.set(Array) }
+func (e *reprEmitter) EmitArrayEnd() error                { return nil }
+func (e *reprEmitter) EmitArrayNext() error               { return nil }
+func (e *reprEmitter) EmitMapBegin(v int) error           { return e.set(Map) }
+func (e *reprEmitter) EmitMapEnd() error                  { return nil }
+func (e *reprEmitter) EmitMapNext() error                 { return nil }
+func (e *reprEmitter) EmitMapValue() error                { return nil }
+func (e *reprEmitter) TextEmitter() bool                  { return e.text }
+
+func textMarshalerName(t reflect.Type) string {
+	if t.Implements(textAppenderInterface) {
+		return "encoding.TextAppender"
+	}
+	return "encoding.TextMarshaler"
+}
+
+func binaryMarshalerName(t reflect.Type) string {
+	if t.Implements(binaryAppenderInterface) {
+		return "encoding.BinaryAppender"
+	}
+	return "encoding.BinaryMarshaler"
+}
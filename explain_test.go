@@ -0,0 +1,95 @@
+package objconv
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncoderExplain(t *testing.T) {
+	type T struct {
+		A int       `objconv:"a,omitempty"`
+		B string    `json:"b,string"`
+		C time.Time `objconv:"c"`
+		d int
+		E int  `objconv:"-"`
+		F *int `objconv:"f,omitempyt"`
+		G []string
+		H map[string]int `objconv:"h,omitzero"`
+	}
+
+	e := Encoder{Emitter: Discard}
+	x, err := e.Explain(&T{B: "hello", G: []string{"A"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if x.Repr != Map || x.Type != reflect.TypeOf(&T{}) {
+		t.Errorf("bad top-level explanation: %s", x)
+	}
+
+	expected := []Explanation{
+		{Name: "a", Tag: `objconv:"a,omitempty"`, Type: reflect.TypeOf(0), Omitted: "omitempty is set and the value is empty"},
+		{Name: "b", Tag: `json:"b,string"`, Type: reflect.TypeOf(""), Repr: String, Notes: []string{`unknown tag option "string" is ignored`}},
+		{Name: "c", Tag: `objconv:"c"`, Type: reflect.TypeOf(time.Time{}), Repr: Time},
+		{Name: "d", Type: reflect.TypeOf(0), Omitted: "unexported field"},
+		{Name: "E", Tag: `objconv:"-"`, Type: reflect.TypeOf(0), Omitted: `the tag name is "-"`},
+		{Name: "f", Tag: `objconv:"f,omitempyt"`, Type: reflect.TypeOf((*int)(nil)), Repr: Nil, Notes: []string{`unknown tag option "omitempyt" is ignored`}},
+		{Name: "G", Type: reflect.TypeOf([]string{}), Repr: Array, Fields: []Explanation{{Name: "[0]", Type: reflect.TypeOf(""), Repr: String}}},
+		{Name: "h", Tag: `objconv:"h,omitzero"`, Type: reflect.TypeOf(map[string]int{}), Omitted: "omitzero is set and the value is zero"},
+	}
+
+	if !reflect.DeepEqual(x.Fields, expected) {
+		t.Errorf("bad explanation:\n%s", x)
+
+		for i := range expected {
+			if i < len(x.Fields) && !reflect.DeepEqual(x.Fields[i], expected[i]) {
+				t.Logf("%#v\n%#v", expected[i], x.Fields[i])
+			}
+		}
+	}
+}
+
+func TestEncoderExplainMethod(t *testing.T) {
+	e := Encoder{Emitter: Discard}
+
+	x, err := e.Explain(struct{ D time.Duration }{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f := x.Fields[0]; f.Repr != Duration || f.Method != "" {
+		t.Errorf("bad explanation: %s", x)
+	}
+
+	x, err = e.Explain(ValueEncoderFunc(func(e Encoder) error { return e.EncodeString("") }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if x.Repr != String || x.Method != "objconv.ValueEncoder" {
+		t.Errorf("bad explanation: %s", x)
+	}
+}
+
+func TestEncoderExplainInline(t *testing.T) {
+	e := Encoder{Emitter: Discard}
+
+	x, err := e.Explain(struct {
+		ID   int        `objconv:"id"`
+		Meta inlineMeta `objconv:",inline"`
+	}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(x.Fields) != 2 {
+		t.Fatalf("bad explanation: %s", x)
+	}
+
+	f := x.Fields[1]
+
+	if f.Name != "Meta" || f.Repr != Map || len(f.Fields) != 2 || f.Notes[0] != "inline is set, the fields are merged into the parent object" {
+		t.Errorf("bad explanation: %s", x)
+	}
+}
@@ -0,0 +1,194 @@
+package objconv
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// FieldPolicy describes changes to the serialization of the fields of a struct
+// type, see InstallFieldPolicies. Fields are referenced by their serialized
+// names.
+type FieldPolicy struct {
+	// Rename maps the names of fields to the names they are given in the
+	// output, decoders accept both names.
+	Rename map[string]string
+
+	// Redact lists the fields whose values are replaced with Placeholder in
+	// the output, decoders discard their values.
+	Redact []string
+
+	// Omit lists the fields removed from the output, decoders discard their
+	// values.
+	Omit []string
+
+	// Placeholder is the string that redacted fields are encoded as.
+	Placeholder string
+}
+
+// InstallFieldPolicies installs policies changing how the fields of struct
+// types are serialized, which lets programs apply rules loaded from their
+// configuration (see the objconv/policy package).
+//
+// Policies are applied on top of the fields that encoders and decoders extract
+// from struct types, the other properties of the fields (tag options like
+// omitempty, inline or encrypt, getters and setters, virtual fields and links)
+// still apply. Fields are referenced by the names they have with the default
+// tags of struct types, encoders and decoders configured with other TagNames
+// or FieldNaming options apply the policies to the same fields under the names
+// that these options give them. The policies replace those previously
+// installed for the same types.
+//
+// The function returns an error, without installing any policies, if one of
+// the types is not a struct type or if a policy references fields that don't
+// exist. Like Install, it is intended to be called during the package
+// initialization phase.
+func InstallFieldPolicies(policies map[reflect.Type]FieldPolicy) error {
+	compiled := make(map[reflect.Type]*fieldPolicy, len(policies))
+
+	for t, p := range policies {
+		if t.Kind() != reflect.Struct {
+			return fmt.Errorf("objconv: cannot install a field policy on %s because it is not a struct type", t)
+		}
+
+		c := makeStructTypes(nil, GoNaming)
+		c.raw = true
+		s := newStructType(t, c)
+
+		keys := make(map[string]string, len(s.fields))
+		for i := range s.fields {
+			keys[s.fields[i].name] = fieldKey(&s.fields[i])
+		}
+
+		fp := &fieldPolicy{rules: make(map[string]fieldRule), placeholder: p.Placeholder}
+
+		rule := func(name string, update func(*fieldRule)) error {
+			k, ok := keys[name]
+			if !ok {
+				return fmt.Errorf("objconv: the field policy of %s references the field %q which doesn't exist", t, name)
+			}
+			r := fp.rules[k]
+			update(&r)
+			fp.rules[k] = r
+			return nil
+		}
+
+		for from, to := range p.Rename {
+			if err := rule(from, func(r *fieldRule) { r.rename = to }); err != nil {
+				return err
+			}
+		}
+
+		for _, name := range p.Redact {
+			if err := rule(name, func(r *fieldRule) { r.redact = true }); err != nil {
+				return err
+			}
+		}
+
+		for _, name := range p.Omit {
+			if err := rule(name, func(r *fieldRule) { r.omit = true }); err != nil {
+				return err
+			}
+		}
+
+		compiled[t] = fp
+	}
+
+	policyMutex.Lock()
+	for t, p := range compiled {
+		policyStore[t] = p
+	}
+	policyMutex.Unlock()
+
+	// Same as Install, the struct cache may have become invalid.
+	clearStructCaches()
+	return nil
+}
+
+// fieldPolicy is the form of field policies installed in policyStore, the
+// rules are indexed by the position of the fields in the struct types rather
+// than their names, which depend on the options of encoders and decoders.
+type fieldPolicy struct {
+	rules       map[string]fieldRule
+	placeholder string
+}
+
+type fieldRule struct {
+	rename string
+	redact bool
+	omit   bool
+}
+
+// fieldKey returns the key identifying f in the rules of field policies,
+// virtual fields are identified by their names.
+func fieldKey(f *structField) string {
+	if f.virtual {
+		return "()" + f.name
+	}
+	return fmt.Sprint(f.inline, f.index)
+}
+
+func fieldPolicyOf(typ reflect.Type) *fieldPolicy {
+	policyMutex.RLock()
+	p := policyStore[typ]
+	policyMutex.RUnlock()
+	return p
+}
+
+// applyPolicy applies p to the fields of s. Renamed fields are given aliases
+// that decoders use to accept their original names, unless another field is
+// written to the output under that name.
+func (s *structType) applyPolicy(p *fieldPolicy) {
+	n := len(s.fields)
+
+	for i := 0; i != n; i++ {
+		f := &s.fields[i]
+
+		if f.alias {
+			continue
+		}
+
+		r, ok := p.rules[fieldKey(f)]
+		if !ok {
+			continue
+		}
+
+		switch {
+		case r.omit:
+			f.omitted = true
+		case r.redact:
+			f.redacted, f.placeholder = true, p.placeholder
+			f.encode = func(e Encoder, _ reflect.Value) error { return e.Emitter.EmitString(p.placeholder) }
+		}
+
+		if len(r.rename) != 0 && r.rename != f.name {
+			alias := *f
+			alias.alias = true
+			f.name = r.rename
+			s.fields = append(s.fields, alias)
+		}
+	}
+
+	output := make(map[string]bool, n)
+
+	for i := range s.fields {
+		if f := &s.fields[i]; !f.alias && !f.omitted {
+			output[f.name] = true
+		}
+	}
+
+	fields := s.fields[:0]
+
+	for _, f := range s.fields {
+		if !f.alias || !output[f.name] {
+			fields = append(fields, f)
+		}
+	}
+
+	s.fields = fields
+}
+
+var (
+	policyMutex sync.RWMutex
+	policyStore = make(map[reflect.Type]*fieldPolicy)
+)
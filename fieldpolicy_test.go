@@ -0,0 +1,72 @@
+package objconv
+
+import (
+	"reflect"
+	"testing"
+)
+
+type policyUser struct {
+	Name     string `objconv:"name"`
+	Password string `objconv:"password"`
+	Token    string `objconv:"token"`
+}
+
+func init() {
+	if err := InstallFieldPolicies(map[reflect.Type]FieldPolicy{
+		reflect.TypeOf(policyUser{}): {
+			Rename:      map[string]string{"name": "login"},
+			Redact:      []string{"password"},
+			Omit:        []string{"token"},
+			Placeholder: "***",
+		},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func TestFieldPolicyValueParser(t *testing.T) {
+	e := NewValueEmitter()
+
+	if err := Transcode(e, NewValueParser(policyUser{Name: "luke", Password: "secret", Token: "T"})); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := e.Value(); !reflect.DeepEqual(v, map[interface{}]interface{}{"login": "luke", "password": "***"}) {
+		t.Errorf("bad value: %#v", v)
+	}
+}
+
+func TestFieldPolicyExplain(t *testing.T) {
+	x, err := Encoder{}.Explain(policyUser{Name: "luke", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(x.Fields) != 3 {
+		t.Fatalf("bad explanation:\n%s", x)
+	}
+
+	if f := x.Fields[0]; f.Name != "login" {
+		t.Errorf("bad explanation of the renamed field:\n%s", f)
+	}
+
+	if f := x.Fields[1]; len(f.Notes) == 0 {
+		t.Errorf("bad explanation of the redacted field:\n%s", f)
+	}
+
+	if f := x.Fields[2]; len(f.Omitted) == 0 {
+		t.Errorf("bad explanation of the omitted field:\n%s", f)
+	}
+}
+
+func TestFieldPolicyErrors(t *testing.T) {
+	for _, p := range []map[reflect.Type]FieldPolicy{
+		{reflect.TypeOf(0): {}},
+		{reflect.TypeOf(policyUser{}): {Omit: []string{"Name"}}},
+		{reflect.TypeOf(policyUser{}): {Rename: map[string]string{"missing": "x"}}},
+	} {
+		if err := InstallFieldPolicies(p); err == nil {
+			t.Errorf("no error returned when installing %v", p)
+		}
+	}
+}
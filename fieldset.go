@@ -0,0 +1,69 @@
+package objconv
+
+import "reflect"
+
+// A FieldSet is the set of fields of a struct type decoded by DecodeFields.
+type FieldSet struct {
+	s *structType
+}
+
+// NewFieldSet returns the set of fields of the struct type typ which have the
+// given serialized names.
+func NewFieldSet(typ reflect.Type, names ...string) *FieldSet {
+	s := &structType{typ: typ, fields: make([]structField, len(names))}
+
+	for i, name := range names {
+		i := i
+		s.fields[i] = structField{
+			index:  fieldCallIndex,
+			name:   name,
+			getter: -1,
+			// The value that fields are decoded into is the function
+			// passed to DecodeFields, see fieldCall.
+			decode: func(d Decoder, v reflect.Value) (Type, error) {
+				return Unknown, v.Interface().(func(int, Decoder) error)(i, d)
+			},
+		}
+	}
+
+	s.lookup = makeFieldLookup(s.fields)
+	return &FieldSet{s: s}
+}
+
+// fieldCall holds the function that DecodeFields calls to decode the values of
+// fields, it stands for the struct value that the struct decoding algorithm
+// decodes fields into.
+type fieldCall struct {
+	Fn func(int, Decoder) error
+}
+
+var fieldCallIndex = []int{0}
+
+// DecodeFields decodes a map into the struct fields of the set, calling fn with
+// the index of each field found in the input (its position in the names passed
+// to NewFieldSet), and the decoder of its value.
+//
+// The fields are decoded by the same algorithm as structs decoded by
+// reflection: the keys matching no fields are discarded, or rejected by the
+// DisallowUnknownFields option, the Conformance levels reject duplicate keys,
+// errors are reported with the paths of the fields and their locations in the
+// input, and the Warn, FieldRecorder and Positions options are honored.
+//
+// The method exists for the ValueDecoder implementations generated by the
+// objconvgen command, which decode values without reflecting on the structs.
+func (d Decoder) DecodeFields(fields *FieldSet, fn func(int, Decoder) error) (err error) {
+	var typ Type
+
+	if d.off != 0 {
+		if d.off, err = 0, d.Parser.ParseMapValue(d.off-1); err != nil {
+			return
+		}
+	}
+
+	if typ, err = d.Parser.ParseType(); err != nil {
+		return
+	}
+
+	c := fieldCall{Fn: fn}
+	return d.decodeStructFromTypeWith(typ, reflect.ValueOf(&c).Elem(), fields.s)
+}
@@ -0,0 +1,132 @@
+// Package fieldstats implements a collector of struct field usage statistics,
+// which helps finding fields of large message schemas that are never populated
+// before removing them.
+//
+// A Collector is installed on encoders and decoders through their
+// FieldRecorder field:
+//
+//	stats := &fieldstats.Collector{}
+//
+//	d := json.NewDecoder(r)
+//	d.FieldRecorder = stats
+//
+// After the program has processed a representative amount of traffic, the
+// Unused method lists the fields that never appeared in the payloads.
+package fieldstats
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Usage is the usage statistics of a single struct field.
+type Usage struct {
+	Type   reflect.Type // struct type that the field belongs to
+	Field  string       // serialized name of the field
+	Used   uint64       // number of times the field was emitted or decoded
+	Unused uint64       // number of times the field was omitted or absent
+}
+
+// String returns a human-readable representation of u.
+func (u Usage) String() string {
+	return fmt.Sprintf("%s.%s: used %d/%d", u.Type, u.Field, u.Used, u.Used+u.Unused)
+}
+
+// Collector is an implementation of the objconv.FieldRecorder interface which
+// counts how often struct fields are used.
+//
+// It is safe to use a Collector value concurrently from multiple goroutines.
+type Collector struct {
+	mutex sync.Mutex
+	stats map[key]*Usage
+}
+
+type key struct {
+	typ   reflect.Type
+	field string
+}
+
+// RecordField satisfies the objconv.FieldRecorder interface.
+func (c *Collector) RecordField(t reflect.Type, field string, used bool) {
+	k := key{t, field}
+
+	c.mutex.Lock()
+
+	u := c.stats[k]
+
+	if u == nil {
+		if c.stats == nil {
+			c.stats = make(map[key]*Usage)
+		}
+		u = &Usage{Type: t, Field: field}
+		c.stats[k] = u
+	}
+
+	if used {
+		u.Used++
+	} else {
+		u.Unused++
+	}
+
+	c.mutex.Unlock()
+}
+
+// Stats returns the usage statistics of all fields seen by c, sorted by type
+// and field name.
+func (c *Collector) Stats() []Usage {
+	return c.filter(func(Usage) bool { return true })
+}
+
+// Unused returns the usage statistics of fields that were seen by c but were
+// never used, sorted by type and field name.
+func (c *Collector) Unused() []Usage {
+	return c.filter(func(u Usage) bool { return u.Used == 0 })
+}
+
+// Reset clears the statistics collected by c.
+func (c *Collector) Reset() {
+	c.mutex.Lock()
+	c.stats = nil
+	c.mutex.Unlock()
+}
+
+// WriteTo writes a report of the statistics collected by c to w, with one line
+// per field.
+func (c *Collector) WriteTo(w io.Writer) (n int64, err error) {
+	for _, u := range c.Stats() {
+		var m int
+
+		m, err = fmt.Fprintln(w, u)
+		n += int64(m)
+
+		if err != nil {
+			break
+		}
+	}
+	return
+}
+
+func (c *Collector) filter(f func(Usage) bool) []Usage {
+	c.mutex.Lock()
+	stats := make([]Usage, 0, len(c.stats))
+
+	for _, u := range c.stats {
+		if f(*u) {
+			stats = append(stats, *u)
+		}
+	}
+
+	c.mutex.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if t1, t2 := stats[i].Type.String(), stats[j].Type.String(); t1 != t2 {
+			return t1 < t2
+		}
+		return stats[i].Field < stats[j].Field
+	})
+
+	return stats
+}
@@ -0,0 +1,75 @@
+package fieldstats
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/objconv/json"
+)
+
+type user struct {
+	Name  string `objconv:"name"`
+	Email string `objconv:"email,omitempty"`
+	Fax   string `objconv:"fax"`
+}
+
+func TestCollectorDecoder(t *testing.T) {
+	c := &Collector{}
+
+	for _, s := range []string{
+		`{"name":"A","email":"a@example.com"}`,
+		`{"name":"B"}`,
+	} {
+		var u user
+
+		d := json.NewDecoder(strings.NewReader(s))
+		d.FieldRecorder = c
+
+		if err := d.Decode(&u); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	typ := reflect.TypeOf(user{})
+
+	if stats := c.Stats(); !reflect.DeepEqual(stats, []Usage{
+		{Type: typ, Field: "email", Used: 1, Unused: 1},
+		{Type: typ, Field: "fax", Used: 0, Unused: 2},
+		{Type: typ, Field: "name", Used: 2, Unused: 0},
+	}) {
+		t.Error("bad stats:", stats)
+	}
+
+	if unused := c.Unused(); len(unused) != 1 || unused[0].Field != "fax" {
+		t.Error("bad unused fields:", unused)
+	}
+}
+
+func TestCollectorEncoder(t *testing.T) {
+	c := &Collector{}
+
+	e := json.NewEncoder(&bytes.Buffer{})
+	e.FieldRecorder = c
+
+	if err := e.Encode([]user{{Name: "A"}, {Name: "B", Email: "b@example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &bytes.Buffer{}
+	c.WriteTo(b)
+
+	if s := b.String(); s != `fieldstats.user.email: used 1/2
+fieldstats.user.fax: used 2/2
+fieldstats.user.name: used 2/2
+` {
+		t.Error("bad report:", s)
+	}
+
+	c.Reset()
+
+	if stats := c.Stats(); len(stats) != 0 {
+		t.Error("stats were not reset:", stats)
+	}
+}
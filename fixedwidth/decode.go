@@ -0,0 +1,260 @@
+package fixedwidth
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Decoder reads fixed-width records from an input stream.
+type Decoder struct {
+	r    *bufio.Reader
+	line int
+	c    cellParser
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next record from the input stream into v, which must be a
+// pointer to a struct. When v is a pointer to a slice of structs, all the
+// remaining records of the stream are appended to the slice.
+//
+// The method returns io.EOF when the end of the input is reached. Lines
+// shorter than the records are accepted, the missing characters are treated
+// as padding, which supports files where trailing spaces were stripped.
+func (d *Decoder) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return objutil.Errorf(objutil.ErrType, "objconv/fixedwidth: cannot decode into a value of type %T", v)
+	}
+
+	if rv = rv.Elem(); rv.Kind() != reflect.Slice {
+		return d.decode(rv)
+	}
+
+	t := rv.Type().Elem()
+
+	for {
+		e := reflect.New(t).Elem()
+
+		switch err := d.decode(e); err {
+		case nil:
+			rv.Set(reflect.Append(rv, e))
+		case io.EOF:
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+func (d *Decoder) decode(v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	l, err := layoutOf(v.Type())
+	if err != nil {
+		return err
+	}
+
+	line, err := d.readLine()
+	if err != nil {
+		return err
+	}
+
+	r := []rune(line)
+
+	if len(r) > l.width {
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/fixedwidth: line %d: expected %d characters but found %d", d.line, l.width, len(r))
+	}
+
+	off := 0
+
+	for i := range l.fields {
+		f := &l.fields[i]
+		s := ""
+
+		if end := off + f.width; end <= len(r) {
+			s = string(r[off:end])
+		} else if off < len(r) {
+			s = string(r[off:])
+		}
+
+		off += f.width
+
+		if f.filler {
+			continue
+		}
+
+		if err := d.c.parse(f, f.trim(s), v.Field(f.index)); err != nil {
+			return objutil.Errorf(kindOf(err), "objconv/fixedwidth: line %d: %s field: %s", d.line, f.name, err)
+		}
+	}
+
+	return nil
+}
+
+// readLine returns the next non-empty line of the input.
+func (d *Decoder) readLine() (string, error) {
+	for {
+		s, err := d.r.ReadString('\n')
+
+		if len(s) == 0 && err != nil {
+			return "", err
+		}
+
+		d.line++
+		s = strings.TrimSuffix(strings.TrimSuffix(s, "\n"), "\r")
+
+		if len(s) != 0 {
+			return s, nil
+		}
+	}
+}
+
+// Unmarshal decodes the fixed-width records of b into v, which is a pointer
+// to a struct or to a slice of structs.
+func Unmarshal(b []byte, v interface{}) error {
+	return NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+func kindOf(err error) error {
+	for _, kind := range [...]error{objutil.ErrSyntax, objutil.ErrType, objutil.ErrRange} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	return objutil.ErrSyntax
+}
+
+var textUnmarshalerInterface = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// cellParser is an objconv.Parser which presents the text of a field as a
+// single value of the type expected by the field.
+type cellParser struct {
+	f *field
+	s string
+	t objconv.Type
+}
+
+func (p *cellParser) parse(f *field, s string, to reflect.Value) error {
+	p.f, p.s, p.t = f, s, typeOf(f.typ)
+
+	if len(s) == 0 {
+		p.t = objconv.Nil
+	}
+
+	return (objconv.Decoder{Parser: p}).Decode(to.Addr().Interface())
+}
+
+// typeOf returns the type of the values that fields of type t are decoded
+// from.
+func typeOf(t reflect.Type) objconv.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return objconv.Time
+	}
+
+	if _, ok := objconv.AdapterOf(t); ok || reflect.PtrTo(t).Implements(textUnmarshalerInterface) {
+		return objconv.String
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return objconv.Bool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t == durationType {
+			return objconv.String
+		}
+		return objconv.Int
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return objconv.Uint
+	case reflect.Float32, reflect.Float64:
+		return objconv.Float
+	}
+
+	return objconv.String
+}
+
+func (p *cellParser) ParseType() (objconv.Type, error) { return p.t, nil }
+
+func (p *cellParser) ParseNil() error { return nil }
+
+func (p *cellParser) ParseBool() (bool, error) { return strconv.ParseBool(p.s) }
+
+func (p *cellParser) ParseInt() (int64, error) { return strconv.ParseInt(p.s, 10, 64) }
+
+func (p *cellParser) ParseUint() (uint64, error) { return strconv.ParseUint(p.s, 10, 64) }
+
+func (p *cellParser) ParseFloat() (float64, error) {
+	s := p.s
+
+	// The decimal point is inserted at its position in the string, dividing
+	// the parsed value by a power of ten would introduce rounding errors.
+	if p.f.implied && p.f.decimals != 0 {
+		neg := strings.HasPrefix(s, "-")
+
+		if neg {
+			s = s[1:]
+		}
+
+		if n := p.f.decimals - len(s) + 1; n > 0 {
+			s = strings.Repeat("0", n) + s
+		}
+
+		s = s[:len(s)-p.f.decimals] + "." + s[len(s)-p.f.decimals:]
+
+		if neg {
+			s = "-" + s
+		}
+	}
+
+	return strconv.ParseFloat(s, 64)
+}
+
+func (p *cellParser) ParseString() ([]byte, error) { return []byte(p.s), nil }
+
+func (p *cellParser) ParseBytes() ([]byte, error) { return []byte(p.s), nil }
+
+func (p *cellParser) ParseTime() (time.Time, error) { return time.Parse(p.f.layout, p.s) }
+
+func (p *cellParser) ParseDuration() (time.Duration, error) { return time.ParseDuration(p.s) }
+
+func (p *cellParser) ParseError() (error, error) {
+	panic("objconv/fixedwidth: ParseError should never be called because fields have no error type, this is likely a bug in the decoder code")
+}
+
+func (p *cellParser) ParseArrayBegin() (int, error) { return 0, p.nestingError() }
+func (p *cellParser) ParseArrayEnd(int) error       { return nil }
+func (p *cellParser) ParseArrayNext(int) error      { return nil }
+func (p *cellParser) ParseMapBegin() (int, error)   { return 0, p.nestingError() }
+func (p *cellParser) ParseMapEnd(int) error         { return nil }
+func (p *cellParser) ParseMapValue(int) error       { return nil }
+func (p *cellParser) ParseMapNext(int) error        { return nil }
+
+// TextParser satisfies the objconv.textParser interface.
+func (p *cellParser) TextParser() bool { return true }
+
+func (p *cellParser) nestingError() error {
+	return objutil.Errorf(objutil.ErrType, "fields cannot hold arrays or maps")
+}
@@ -0,0 +1,188 @@
+package fixedwidth
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Encoder writes fixed-width records to an output stream.
+type Encoder struct {
+	// CRLF makes the encoder end records with "\r\n" instead of "\n".
+	CRLF bool
+
+	w io.Writer
+	b []byte
+	c cellEmitter
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes v to the output stream of the encoder. The value is either a
+// struct, written as a single record, or a slice or array of structs written
+// as a sequence of records.
+func (e *Encoder) Encode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i, n := 0, rv.Len(); i != n; i++ {
+			if err := e.encode(reflect.Indirect(rv.Index(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return e.encode(rv)
+}
+
+func (e *Encoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		return objutil.Errorf(objutil.ErrType, "objconv/fixedwidth: cannot encode a nil record")
+	}
+
+	l, err := layoutOf(v.Type())
+	if err != nil {
+		return err
+	}
+
+	b := e.b[:0]
+
+	for i := range l.fields {
+		f := &l.fields[i]
+		s := ""
+
+		if !f.filler {
+			if s, err = e.c.format(f, v.Field(f.index)); err != nil {
+				return err
+			}
+		}
+
+		if b, err = f.format(b, s); err != nil {
+			return err
+		}
+	}
+
+	if e.CRLF {
+		b = append(b, '\r', '\n')
+	} else {
+		b = append(b, '\n')
+	}
+
+	e.b = b
+	_, err = e.w.Write(b)
+	return err
+}
+
+// Marshal returns the fixed-width representation of v, which is either a
+// struct or a slice or array of structs.
+func Marshal(v interface{}) (b []byte, err error) {
+	buf := &bytes.Buffer{}
+
+	if err = NewEncoder(buf).Encode(v); err == nil {
+		b = buf.Bytes()
+	}
+
+	return
+}
+
+// cellEmitter is an objconv.Emitter which captures the text representation of
+// a single value.
+type cellEmitter struct {
+	f *field
+	s string
+}
+
+func (e *cellEmitter) format(f *field, v reflect.Value) (string, error) {
+	e.f, e.s = f, ""
+
+	if err := (objconv.Encoder{Emitter: e}).Encode(v.Interface()); err != nil {
+		return "", err
+	}
+
+	return e.s, nil
+}
+
+func (e *cellEmitter) EmitNil() error {
+	e.s = ""
+	return nil
+}
+
+func (e *cellEmitter) EmitBool(v bool) error {
+	e.s = strconv.FormatBool(v)
+	return nil
+}
+
+func (e *cellEmitter) EmitInt(v int64, _ int) error {
+	e.s = strconv.FormatInt(v, 10)
+	return nil
+}
+
+func (e *cellEmitter) EmitUint(v uint64, _ int) error {
+	e.s = strconv.FormatUint(v, 10)
+	return nil
+}
+
+func (e *cellEmitter) EmitFloat(v float64, bitSize int) error {
+	e.s = strconv.FormatFloat(v, 'f', e.f.decimals, bitSize)
+
+	if e.f.implied {
+		e.s = strings.Replace(e.s, ".", "", 1)
+	}
+
+	return nil
+}
+
+func (e *cellEmitter) EmitString(v string) error {
+	e.s = v
+	return nil
+}
+
+func (e *cellEmitter) EmitBytes(v []byte) error {
+	e.s = string(v)
+	return nil
+}
+
+func (e *cellEmitter) EmitTime(v time.Time) error {
+	e.s = v.Format(e.f.layout)
+	return nil
+}
+
+func (e *cellEmitter) EmitDuration(v time.Duration) error {
+	e.s = v.String()
+	return nil
+}
+
+func (e *cellEmitter) EmitError(v error) error {
+	e.s = v.Error()
+	return nil
+}
+
+func (e *cellEmitter) EmitArrayBegin(int) error { return e.nestingError() }
+func (e *cellEmitter) EmitArrayEnd() error      { return nil }
+func (e *cellEmitter) EmitArrayNext() error     { return nil }
+func (e *cellEmitter) EmitMapBegin(int) error   { return e.nestingError() }
+func (e *cellEmitter) EmitMapEnd() error        { return nil }
+func (e *cellEmitter) EmitMapNext() error       { return nil }
+func (e *cellEmitter) EmitMapValue() error      { return nil }
+
+// TextEmitter satisfies the objconv.textEmitter interface.
+func (e *cellEmitter) TextEmitter() bool { return true }
+
+func (e *cellEmitter) nestingError() error {
+	return objutil.Errorf(objutil.ErrType, "objconv/fixedwidth: the %s field cannot hold arrays or maps", e.f.name)
+}
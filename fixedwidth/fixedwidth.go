@@ -0,0 +1,263 @@
+// Package fixedwidth implements encoding and decoding of fixed-width records,
+// the text format of many mainframe and banking batch files.
+//
+// Records are lines made of fields of constant widths, the layout of records
+// is described by the `fixed` struct tags of the fields of a struct type:
+//
+//	type Transfer struct {
+//		Account string    `fixed:"width=10"`
+//		Amount  float64   `fixed:"width=12,pad=0,decimals=2,implied"`
+//		Date    time.Time `fixed:"width=8,layout=20060102"`
+//		_       struct{}  `fixed:"width=4"`
+//	}
+//
+// The tag options are:
+//
+//	width=N     the number of characters of the field, required
+//	align=X     left or right, numbers are right-aligned by default and other
+//	            values are left-aligned
+//	pad=C       the character the field is padded with, a space by default
+//	decimals=N  the number of digits written after the decimal point of
+//	            floating point numbers
+//	implied     the decimal point of floating point numbers is not written,
+//	            decimals=2 and implied encodes 123.45 as "12345"
+//	layout=L    the layout of time values, RFC 3339 by default
+//
+// Fields without a `fixed` tag are not part of the records. Fields named "_"
+// are fillers, they are written as padding and skipped when decoding.
+//
+// Field values are converted with the objconv algorithms, so adapters and
+// types implementing encoding.TextMarshaler can be used in records.
+package fixedwidth
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+type alignment int
+
+const (
+	alignDefault alignment = iota
+	alignLeft
+	alignRight
+)
+
+type field struct {
+	name     string
+	index    int
+	typ      reflect.Type
+	filler   bool
+	width    int
+	align    alignment
+	pad      rune
+	decimals int // -1 when not set
+	implied  bool
+	layout   string
+}
+
+type layout struct {
+	fields []field
+	width  int
+}
+
+var layouts sync.Map // reflect.Type => *layout
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// layoutOf returns the layout of records of type t, which must be a struct
+// type.
+func layoutOf(t reflect.Type) (*layout, error) {
+	if l, ok := layouts.Load(t); ok {
+		return l.(*layout), nil
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, objutil.Errorf(objutil.ErrType, "objconv/fixedwidth: records must be structs, found %s", t)
+	}
+
+	l := &layout{}
+
+	for i, n := 0, t.NumField(); i != n; i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("fixed")
+
+		if !ok || tag == "-" {
+			continue
+		}
+
+		if len(sf.PkgPath) != 0 && sf.Name != "_" {
+			return nil, objutil.Errorf(objutil.ErrType, "objconv/fixedwidth: %s.%s: unexported fields cannot be part of records", t, sf.Name)
+		}
+
+		f, err := parseField(sf, tag)
+		if err != nil {
+			return nil, objutil.Errorf(objutil.ErrType, "objconv/fixedwidth: %s.%s: %s", t, sf.Name, err)
+		}
+
+		f.index = i
+		l.fields = append(l.fields, f)
+		l.width += f.width
+	}
+
+	if len(l.fields) == 0 {
+		return nil, objutil.Errorf(objutil.ErrType, "objconv/fixedwidth: %s has no fields with a fixed tag", t)
+	}
+
+	v, _ := layouts.LoadOrStore(t, l)
+	return v.(*layout), nil
+}
+
+type tagError string
+
+func (e tagError) Error() string { return string(e) }
+
+func parseField(sf reflect.StructField, tag string) (f field, err error) {
+	f = field{
+		name:     sf.Name,
+		typ:      sf.Type,
+		filler:   sf.Name == "_",
+		pad:      ' ',
+		decimals: -1,
+		layout:   time.RFC3339,
+	}
+
+	for len(tag) != 0 {
+		var opt string
+
+		if i := strings.IndexByte(tag, ','); i < 0 {
+			opt, tag = tag, ""
+		} else {
+			opt, tag = tag[:i], tag[i+1:]
+		}
+
+		key, val, _ := strings.Cut(opt, "=")
+
+		switch key {
+		case "width":
+			if f.width, err = strconv.Atoi(val); err != nil || f.width <= 0 {
+				return f, tagError("invalid width: " + strconv.Quote(val))
+			}
+
+		case "align":
+			switch val {
+			case "left":
+				f.align = alignLeft
+			case "right":
+				f.align = alignRight
+			default:
+				return f, tagError("invalid alignment: " + strconv.Quote(val))
+			}
+
+		case "pad":
+			r, n := utf8.DecodeRuneInString(val)
+			if n == 0 || n != len(val) {
+				return f, tagError("the padding must be a single character: " + strconv.Quote(val))
+			}
+			f.pad = r
+
+		case "decimals":
+			if f.decimals, err = strconv.Atoi(val); err != nil || f.decimals < 0 {
+				return f, tagError("invalid number of decimals: " + strconv.Quote(val))
+			}
+
+		case "implied":
+			f.implied = true
+
+		case "layout":
+			f.layout = val
+
+		default:
+			return f, tagError("unknown tag option: " + strconv.Quote(opt))
+		}
+	}
+
+	if f.width == 0 {
+		return f, tagError("the width option is required")
+	}
+
+	if f.implied && f.decimals < 0 {
+		return f, tagError("the implied option requires the decimals option")
+	}
+
+	if f.align == alignDefault {
+		f.align = alignLeft
+
+		switch f.typ.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64:
+			f.align = alignRight
+		}
+	}
+
+	return f, nil
+}
+
+// format appends s padded to the width of the field to b, or returns an error
+// if s is too long.
+func (f *field) format(b []byte, s string) ([]byte, error) {
+	n := utf8.RuneCountInString(s)
+
+	if n > f.width {
+		return b, objutil.Errorf(objutil.ErrRange, "objconv/fixedwidth: the value of the %s field does not fit in %d characters: %q", f.name, f.width, s)
+	}
+
+	if f.align == alignLeft {
+		b = append(b, s...)
+		return appendPadding(b, f.pad, f.width-n), nil
+	}
+
+	// Zero-padded negative numbers are written with the sign first, like
+	// "-00042".
+	if f.pad == '0' && strings.HasPrefix(s, "-") {
+		b = append(b, '-')
+		s = s[1:]
+	}
+
+	b = appendPadding(b, f.pad, f.width-n)
+	return append(b, s...), nil
+}
+
+// trim removes the padding from s.
+func (f *field) trim(s string) string {
+	if f.align == alignLeft {
+		return strings.TrimRight(s, string(f.pad))
+	}
+
+	if f.pad == '0' {
+		neg := strings.HasPrefix(s, "-")
+
+		if neg {
+			s = s[1:]
+		}
+
+		if s = strings.TrimLeft(s, "0"); len(s) == 0 || s[0] == '.' {
+			s = "0" + s
+		}
+
+		if neg {
+			s = "-" + s
+		}
+
+		return s
+	}
+
+	return strings.TrimLeft(s, string(f.pad))
+}
+
+func appendPadding(b []byte, pad rune, n int) []byte {
+	for i := 0; i < n; i++ {
+		b = utf8.AppendRune(b, pad)
+	}
+	return b
+}
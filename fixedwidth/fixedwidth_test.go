@@ -0,0 +1,107 @@
+package fixedwidth
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+type transfer struct {
+	Account string        `fixed:"width=10"`
+	Amount  float64       `fixed:"width=12,pad=0,decimals=2,implied"`
+	Rate    float64       `fixed:"width=6,decimals=3"`
+	Count   int           `fixed:"width=5,pad=0"`
+	Date    time.Time     `fixed:"width=8,layout=20060102"`
+	_       struct{}      `fixed:"width=3"`
+	Code    string        `fixed:"width=4,align=right,pad=*"`
+	Delay   time.Duration `fixed:"width=6,align=left"`
+	Note    string
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	transfers1 := []transfer{
+		{Account: "FR7630001", Amount: 123.45, Rate: 1.5, Count: 42, Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Code: "AB", Delay: time.Second},
+		{Account: "DE89", Amount: -0.07, Count: -3, Date: time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC), Code: "Z", Delay: time.Minute},
+	}
+
+	b, err := Marshal(transfers1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = "" +
+		"FR7630001 000000012345 1.5000004220240301   **AB1s    \n" +
+		"DE89      -00000000007 0.000-000319991231   ***Z1m0s  \n"
+
+	if s := string(b); s != expected {
+		t.Errorf("bad records:\n%q\n%q", s, expected)
+	}
+
+	var transfers2 []transfer
+	if err := Unmarshal(b, &transfers2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(transfers1, transfers2) {
+		t.Errorf("%#v != %#v", transfers1, transfers2)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	type record struct {
+		ID    uint   `fixed:"width=4"`
+		Name  string `fixed:"width=6"`
+		Valid bool   `fixed:"width=5"`
+	}
+
+	// Trailing spaces are often stripped from records, the second line is
+	// shorter than the layout and the third line is empty.
+	d := NewDecoder(strings.NewReader("   1Luke  true\r\n   2Leia\r\n\r\n"))
+
+	var r record
+	if err := d.Decode(&r); err != nil || r != (record{ID: 1, Name: "Luke", Valid: true}) {
+		t.Errorf("bad record: %#v (%v)", r, err)
+	}
+
+	r = record{}
+	if err := d.Decode(&r); err != nil || r != (record{ID: 2, Name: "Leia"}) {
+		t.Errorf("bad record: %#v (%v)", r, err)
+	}
+
+	if err := d.Decode(&r); err != io.EOF {
+		t.Error("expected io.EOF but got:", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	type record struct {
+		N int    `fixed:"width=3"`
+		S string `fixed:"width=2"`
+	}
+
+	if _, err := Marshal(record{N: 1000}); !errors.Is(err, objutil.ErrRange) {
+		t.Error("expected a range error for a value too long but got:", err)
+	}
+
+	var r record
+	if err := Unmarshal([]byte("  xab\n"), &r); !errors.Is(err, objutil.ErrSyntax) || !strings.Contains(err.Error(), "line 1: N field") {
+		t.Error("expected a syntax error for an invalid number but got:", err)
+	}
+
+	if err := Unmarshal([]byte("  1abc\n"), &r); err == nil || !strings.Contains(err.Error(), "expected 5 characters but found 6") {
+		t.Error("expected an error for a line too long but got:", err)
+	}
+
+	type badTag struct {
+		A int `fixed:"width=3,size=2"`
+	}
+
+	if _, err := Marshal(badTag{}); !errors.Is(err, objutil.ErrType) || !strings.Contains(err.Error(), `unknown tag option: "size=2"`) {
+		t.Error("expected an error for the unknown tag option but got:", err)
+	}
+}
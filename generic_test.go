@@ -0,0 +1,120 @@
+package objconv
+
+import (
+	"reflect"
+	"testing"
+)
+
+type genericSet[T comparable] map[T]struct{}
+
+type genericResult[T any] struct {
+	Value T
+	Err   string
+}
+
+func init() {
+	InstallGeneric(reflect.TypeOf(genericSet[int]{}), func(t reflect.Type) Adapter {
+		return Adapter{
+			Encode: func(e Encoder, v reflect.Value) error {
+				keys := v.MapKeys()
+				sortValues(t.Key(), keys)
+				i := 0
+				return e.EncodeArray(len(keys), func(e Encoder) error {
+					i++
+					return e.Encode(keys[i-1].Interface())
+				})
+			},
+			Decode: func(d Decoder, v reflect.Value) error {
+				m := reflect.MakeMap(t)
+				err := d.DecodeArray(func(d Decoder) error {
+					k := reflect.New(t.Key())
+					if err := d.Decode(k.Interface()); err != nil {
+						return err
+					}
+					m.SetMapIndex(k.Elem(), reflect.ValueOf(struct{}{}))
+					return nil
+				})
+				v.Set(m)
+				return err
+			},
+		}
+	})
+
+	InstallGeneric(reflect.TypeOf(genericResult[int]{}), func(t reflect.Type) Adapter {
+		return Adapter{
+			Encode: func(e Encoder, v reflect.Value) error {
+				if err := v.Field(1).String(); len(err) != 0 {
+					return e.Encode(map[string]string{"error": err})
+				}
+				return e.Encode(v.Field(0).Interface())
+			},
+			Decode: func(d Decoder, v reflect.Value) error {
+				return d.Decode(v.Field(0).Addr().Interface())
+			},
+		}
+	})
+}
+
+func TestInstallGeneric(t *testing.T) {
+	type config struct {
+		Tags  genericSet[string]    `objconv:"tags"`
+		Ports genericSet[int]       `objconv:"ports"`
+		Count genericResult[uint16] `objconv:"count"`
+	}
+
+	c1 := config{
+		Tags:  genericSet[string]{"b": {}, "a": {}},
+		Ports: genericSet[int]{443: {}, 80: {}},
+		Count: genericResult[uint16]{Value: 42},
+	}
+
+	e := NewValueEmitter()
+
+	if err := (Encoder{Emitter: e, SortMapKeys: true}).Encode(c1); err != nil {
+		t.Fatal(err)
+	}
+
+	v := e.Value()
+	x := map[interface{}]interface{}{
+		"tags":  []interface{}{"a", "b"},
+		"ports": []interface{}{int64(80), int64(443)},
+		"count": uint64(42),
+	}
+
+	if !reflect.DeepEqual(v, x) {
+		t.Errorf("bad encoding:\n%#v\n%#v", v, x)
+	}
+
+	var c2 config
+
+	if err := NewDecoder(NewValueParser(v)).Decode(&c2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(c1, c2) {
+		t.Errorf("%#v != %#v", c1, c2)
+	}
+
+	a1, _ := AdapterOf(reflect.TypeOf(genericSet[float64]{}))
+	a2, _ := AdapterOf(reflect.TypeOf(genericSet[float64]{}))
+
+	if reflect.ValueOf(a1.Encode).Pointer() != reflect.ValueOf(a2.Encode).Pointer() {
+		t.Error("the adapter of a generic type instantiation was not cached")
+	}
+}
+
+func TestInstallGenericPanics(t *testing.T) {
+	for _, typ := range []reflect.Type{
+		reflect.TypeOf(0),
+		reflect.TypeOf([]genericSet[int]{}),
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("no panic raised when installing a generic adapter for %s", typ)
+				}
+			}()
+			InstallGeneric(typ, func(reflect.Type) Adapter { return Adapter{} })
+		}()
+	}
+}
@@ -0,0 +1,430 @@
+// Package geo provides geometry types which are encoded as GeoJSON (RFC 7946)
+// by text codecs, and as compact arrays of coordinates by binary codecs.
+//
+// With the json codec, the types produce valid GeoJSON documents:
+//
+//	b, _ := json.Marshal(geo.Feature{
+//		Geometry:   geo.Point{Lon: 2.3522, Lat: 48.8566},
+//		Properties: map[string]interface{}{"name": "Paris"},
+//	})
+//	// {"type":"Feature","geometry":{"type":"Point","coordinates":[2.3522,48.8566]},"properties":{"name":"Paris"}}
+//
+// Binary codecs like msgpack or cbor drop the GeoJSON wrappers and only encode
+// the coordinates, a point is encoded as [lon,lat], a line string as an array
+// of points, and a polygon as an array of rings. Decoders accept both
+// representations regardless of the codec.
+package geo
+
+import (
+	"fmt"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Geometry is implemented by the geometry types of this package.
+type Geometry interface {
+	// Type returns the GeoJSON type name of the geometry, like "Point".
+	Type() string
+}
+
+// Point is a position on earth, in degrees.
+type Point struct {
+	Lon float64
+	Lat float64
+}
+
+// LineString is a sequence of points.
+type LineString []Point
+
+// Polygon is a sequence of linear rings, the first ring is the exterior of the
+// polygon and the others are holes in it. A linear ring is a closed line
+// string with at least four points, where the first and last points are the
+// same.
+type Polygon []LineString
+
+// Type satisfies the Geometry interface.
+func (Point) Type() string { return "Point" }
+
+// Type satisfies the Geometry interface.
+func (LineString) Type() string { return "LineString" }
+
+// Type satisfies the Geometry interface.
+func (Polygon) Type() string { return "Polygon" }
+
+// Validate returns an error if one of the rings of p is not a valid linear
+// ring.
+func (p Polygon) Validate() error {
+	for i, r := range p {
+		if len(r) < 4 {
+			return objutil.Errorf(objutil.ErrSyntax, "objconv/geo: ring %d of the polygon has less than 4 points", i)
+		}
+		if r[0] != r[len(r)-1] {
+			return objutil.Errorf(objutil.ErrSyntax, "objconv/geo: ring %d of the polygon is not closed", i)
+		}
+	}
+	return nil
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (p Point) EncodeValue(e objconv.Encoder) error {
+	return encodeGeometry(e, p, []float64{p.Lon, p.Lat})
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (l LineString) EncodeValue(e objconv.Encoder) error {
+	return encodeGeometry(e, l, lineCoordinates(l))
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface, it returns an
+// error if the polygon is invalid.
+func (p Polygon) EncodeValue(e objconv.Encoder) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	return encodeGeometry(e, p, polygonCoordinates(p))
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (p *Point) DecodeValue(d objconv.Decoder) error {
+	return decodeGeometry(d, p)
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (l *LineString) DecodeValue(d objconv.Decoder) error {
+	return decodeGeometry(d, l)
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface, it returns an
+// error if the decoded polygon is invalid.
+func (p *Polygon) DecodeValue(d objconv.Decoder) error {
+	if err := decodeGeometry(d, p); err != nil {
+		return err
+	}
+	return p.Validate()
+}
+
+// Feature is a geometry associated with properties.
+type Feature struct {
+	ID         interface{}
+	Geometry   Geometry
+	Properties map[string]interface{}
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (f Feature) EncodeValue(e objconv.Encoder) error {
+	r := featureRecord{ID: f.ID, Properties: f.Properties}
+
+	if f.Geometry != nil {
+		r.Geometry = f.Geometry
+	}
+
+	if isTextEmitter(e.Emitter) {
+		r.Type = "Feature"
+	}
+
+	return e.Encode(r)
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (f *Feature) DecodeValue(d objconv.Decoder) error {
+	var r featureRecord
+
+	if err := d.Decode(&r); err != nil {
+		return err
+	}
+
+	if len(r.Type) != 0 && r.Type != "Feature" {
+		return objutil.Errorf(objutil.ErrType, "objconv/geo: expected a Feature but found a %s", r.Type)
+	}
+
+	g, err := makeGeometry(r.Geometry)
+	if err != nil {
+		return err
+	}
+
+	*f = Feature{ID: r.ID, Geometry: g, Properties: r.Properties}
+	return nil
+}
+
+type featureRecord struct {
+	Type       string                 `objconv:"type,omitempty"`
+	ID         interface{}            `objconv:"id,omitempty"`
+	Geometry   interface{}            `objconv:"geometry"`
+	Properties map[string]interface{} `objconv:"properties"`
+}
+
+// FeatureCollection is a list of features.
+type FeatureCollection []Feature
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (c FeatureCollection) EncodeValue(e objconv.Encoder) error {
+	features := []Feature(c)
+
+	if features == nil {
+		features = []Feature{}
+	}
+
+	if isTextEmitter(e.Emitter) {
+		return e.Encode(collectionRecord{Type: "FeatureCollection", Features: features})
+	}
+
+	return e.Encode(features)
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (c *FeatureCollection) DecodeValue(d objconv.Decoder) error {
+	var v interface{}
+
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+
+	if m := mapOf(v); m != nil {
+		if t, _ := m["type"].(string); t != "FeatureCollection" {
+			return objutil.Errorf(objutil.ErrType, "objconv/geo: expected a FeatureCollection but found a %v", m["type"])
+		}
+		v = m["features"]
+	}
+
+	a, ok := v.([]interface{})
+
+	if !ok && v != nil {
+		return objutil.Errorf(objutil.ErrType, "objconv/geo: cannot decode a feature collection from a value of type %T", v)
+	}
+
+	features := make(FeatureCollection, len(a))
+
+	for i, x := range a {
+		if err := objconv.NewDecoder(objconv.NewValueParser(x)).Decode(&features[i]); err != nil {
+			return err
+		}
+	}
+
+	*c = features
+	return nil
+}
+
+type collectionRecord struct {
+	Type     string    `objconv:"type"`
+	Features []Feature `objconv:"features"`
+}
+
+func encodeGeometry(e objconv.Encoder, g Geometry, coordinates interface{}) error {
+	if isTextEmitter(e.Emitter) {
+		return e.Encode(geometryRecord{Type: g.Type(), Coordinates: coordinates})
+	}
+	return e.Encode(coordinates)
+}
+
+type geometryRecord struct {
+	Type        string      `objconv:"type"`
+	Coordinates interface{} `objconv:"coordinates"`
+}
+
+func lineCoordinates(l LineString) [][]float64 {
+	c := make([][]float64, len(l))
+	for i, p := range l {
+		c[i] = []float64{p.Lon, p.Lat}
+	}
+	return c
+}
+
+func polygonCoordinates(p Polygon) [][][]float64 {
+	c := make([][][]float64, len(p))
+	for i, r := range p {
+		c[i] = lineCoordinates(r)
+	}
+	return c
+}
+
+func decodeGeometry(d objconv.Decoder, g Geometry) error {
+	var v interface{}
+
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+
+	if m := mapOf(v); m != nil {
+		if t, _ := m["type"].(string); t != g.Type() {
+			return objutil.Errorf(objutil.ErrType, "objconv/geo: expected a %s but found a %v", g.Type(), m["type"])
+		}
+		v = m["coordinates"]
+	}
+
+	return setCoordinates(g, v)
+}
+
+func setCoordinates(g Geometry, v interface{}) (err error) {
+	switch x := g.(type) {
+	case *Point:
+		*x, err = makePoint(v)
+	case *LineString:
+		*x, err = makeLineString(v)
+	case *Polygon:
+		*x, err = makePolygon(v)
+	}
+	return
+}
+
+// makeGeometry converts v, a GeoJSON geometry or compact array of coordinates,
+// to a geometry value. The type of compact geometries is determined by the
+// depth of the arrays.
+func makeGeometry(v interface{}) (Geometry, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	var g Geometry
+
+	if m := mapOf(v); m != nil {
+		switch t, _ := m["type"].(string); t {
+		case "Point":
+			g = &Point{}
+		case "LineString":
+			g = &LineString{}
+		case "Polygon":
+			g = &Polygon{}
+		default:
+			return nil, objutil.Errorf(objutil.ErrType, "objconv/geo: unsupported geometry type: %v", m["type"])
+		}
+		v = m["coordinates"]
+	} else {
+		switch depthOf(v) {
+		case 1:
+			g = &Point{}
+		case 2:
+			g = &LineString{}
+		case 3:
+			g = &Polygon{}
+		default:
+			return nil, objutil.Errorf(objutil.ErrType, "objconv/geo: cannot decode a geometry from %v", v)
+		}
+	}
+
+	if err := setCoordinates(g, v); err != nil {
+		return nil, err
+	}
+
+	switch x := g.(type) {
+	case *Point:
+		return *x, nil
+	case *LineString:
+		return *x, nil
+	default:
+		p := *x.(*Polygon)
+		return p, p.Validate()
+	}
+}
+
+func makePoint(v interface{}) (Point, error) {
+	a, ok := v.([]interface{})
+
+	// RFC 7946 allows a third element for the altitude, it is discarded.
+	if !ok || len(a) < 2 || len(a) > 3 {
+		return Point{}, objutil.Errorf(objutil.ErrType, "objconv/geo: cannot decode a point from %v", v)
+	}
+
+	lon, ok1 := number(a[0])
+	lat, ok2 := number(a[1])
+
+	if !ok1 || !ok2 {
+		return Point{}, objutil.Errorf(objutil.ErrType, "objconv/geo: cannot decode a point from %v", v)
+	}
+
+	if lon < -180 || lon > 180 || lat < -90 || lat > 90 {
+		return Point{}, objutil.Errorf(objutil.ErrRange, "objconv/geo: coordinates out of range: %v", v)
+	}
+
+	return Point{Lon: lon, Lat: lat}, nil
+}
+
+func makeLineString(v interface{}) (LineString, error) {
+	a, ok := v.([]interface{})
+
+	if !ok {
+		return nil, objutil.Errorf(objutil.ErrType, "objconv/geo: cannot decode a line string from %v", v)
+	}
+
+	l := make(LineString, len(a))
+
+	for i, x := range a {
+		p, err := makePoint(x)
+		if err != nil {
+			return nil, err
+		}
+		l[i] = p
+	}
+
+	return l, nil
+}
+
+func makePolygon(v interface{}) (Polygon, error) {
+	a, ok := v.([]interface{})
+
+	if !ok {
+		return nil, objutil.Errorf(objutil.ErrType, "objconv/geo: cannot decode a polygon from %v", v)
+	}
+
+	p := make(Polygon, len(a))
+
+	for i, x := range a {
+		r, err := makeLineString(x)
+		if err != nil {
+			return nil, err
+		}
+		p[i] = r
+	}
+
+	return p, nil
+}
+
+func depthOf(v interface{}) int {
+	a, ok := v.([]interface{})
+
+	if !ok {
+		return 0
+	}
+
+	if len(a) == 0 {
+		return 1
+	}
+
+	return 1 + depthOf(a[0])
+}
+
+func number(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case float64:
+		return x, true
+	case objconv.Number:
+		f, err := x.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func mapOf(v interface{}) map[string]interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		return x
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, v := range x {
+			m[fmt.Sprint(k)] = v
+		}
+		return m
+	}
+	return nil
+}
+
+func isTextEmitter(emitter objconv.Emitter) bool {
+	e, _ := emitter.(interface {
+		TextEmitter() bool
+	})
+	return e != nil && e.TextEmitter()
+}
@@ -0,0 +1,145 @@
+package geo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/objconv/json"
+	"github.com/segmentio/objconv/msgpack"
+)
+
+var square = Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}}}
+
+func TestGeoJSON(t *testing.T) {
+	tests := []struct {
+		in  interface{}
+		out string
+	}{
+		{
+			in:  Point{Lon: 2.5, Lat: 48.5},
+			out: `{"type":"Point","coordinates":[2.5,48.5]}`,
+		},
+		{
+			in:  LineString{{0, 0}, {1, 1}},
+			out: `{"type":"LineString","coordinates":[[0,0],[1,1]]}`,
+		},
+		{
+			in:  square,
+			out: `{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1],[0,0]]]}`,
+		},
+		{
+			in:  Feature{ID: "A", Geometry: Point{1, 2}, Properties: map[string]interface{}{"name": "A"}},
+			out: `{"type":"Feature","id":"A","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"name":"A"}}`,
+		},
+		{
+			in:  FeatureCollection{},
+			out: `{"type":"FeatureCollection","features":[]}`,
+		},
+	}
+
+	for _, test := range tests {
+		b, err := json.Marshal(test.in)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if s := string(b); s != test.out {
+			t.Errorf("bad GeoJSON:\n%s\n%s", s, test.out)
+		}
+
+		v := reflect.New(reflect.TypeOf(test.in))
+
+		if err := json.Unmarshal(b, v.Interface()); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if !reflect.DeepEqual(v.Elem().Interface(), test.in) {
+			t.Errorf("%#v != %#v", v.Elem().Interface(), test.in)
+		}
+	}
+}
+
+func TestCompactEncoding(t *testing.T) {
+	c1 := FeatureCollection{
+		{Geometry: Point{1, 2}, Properties: map[string]interface{}{}},
+		{Geometry: LineString{{0, 0}, {1, 1}}, Properties: map[string]interface{}{}},
+		{Geometry: square, Properties: map[string]interface{}{"area": 1.0}},
+	}
+
+	b, err := msgpack.Marshal(c1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var v []map[string]interface{}
+	if err := msgpack.Unmarshal(b, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := v[0]["type"]; ok {
+		t.Error("the GeoJSON type was encoded by a binary codec")
+	}
+
+	var c2 FeatureCollection
+	if err := msgpack.Unmarshal(b, &c2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(c1, c2) {
+		t.Errorf("%#v != %#v", c1, c2)
+	}
+}
+
+func TestInvalidGeometries(t *testing.T) {
+	if _, err := json.Marshal(Polygon{{{0, 0}, {1, 1}, {0, 0}}}); err == nil {
+		t.Error("no error returned when encoding an invalid polygon")
+	}
+
+	tests := []struct {
+		in string
+		to interface{}
+	}{
+		{`{"type":"LineString","coordinates":[[0,0]]}`, &Point{}},
+		{`[200,0]`, &Point{}},
+		{`[[[0,0],[1,0],[1,1],[0,1]]]`, &Polygon{}},
+		{`{"type":"Feature","geometry":{"type":"Circle"}}`, &Feature{}},
+	}
+
+	for _, test := range tests {
+		if err := json.Unmarshal([]byte(test.in), test.to); err == nil {
+			t.Errorf("no error returned when decoding %s", test.in)
+		}
+	}
+}
+
+func TestUseNumber(t *testing.T) {
+	tests := []struct {
+		in  string
+		out interface{}
+	}{
+		{`{"type":"Point","coordinates":[1.5,2]}`, Point{Lon: 1.5, Lat: 2}},
+		{`[1.5,2]`, Point{Lon: 1.5, Lat: 2}},
+		{`{"type":"LineString","coordinates":[[0,0],[1,1]]}`, LineString{{0, 0}, {1, 1}}},
+		{`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,1],[0,0]]]}`, square},
+		{`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]}}`, Feature{Geometry: Point{1, 2}}},
+	}
+
+	for _, test := range tests {
+		d := json.NewDecoder(strings.NewReader(test.in))
+		d.UseNumber = true
+
+		v := reflect.New(reflect.TypeOf(test.out))
+
+		if err := d.Decode(v.Interface()); err != nil {
+			t.Errorf("%s: %v", test.in, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(v.Elem().Interface(), test.out) {
+			t.Errorf("%s: %#v != %#v", test.in, v.Elem().Interface(), test.out)
+		}
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/segmentio/objconv
 
+go 1.20
+
 require gopkg.in/yaml.v2 v2.2.1
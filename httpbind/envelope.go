@@ -0,0 +1,98 @@
+package httpbind
+
+import "github.com/segmentio/objconv"
+
+// Envelope configures the envelope that responses are wrapped in, to follow
+// conventions like {"data":...,"error":...,"meta":...} without wrapping the
+// values returned by each handler.
+type Envelope struct {
+	// Data is the key of the values passed to EncodeResponse, responses are
+	// not wrapped if it is empty.
+	Data string
+
+	// Error is the key of the errors passed to EncodeError.
+	Error string
+
+	// Meta is the key of the metadata set with SetMeta, it is omitted when
+	// the response has no metadata or when it is empty.
+	Meta string
+
+	// ErrorValue returns the value encoded for the errors passed to
+	// EncodeError, their messages are encoded when it is nil.
+	ErrorValue func(error) interface{}
+}
+
+// DefaultEnvelope is an envelope with the keys "data", "error" and "meta".
+var DefaultEnvelope = Envelope{Data: "data", Error: "error", Meta: "meta"}
+
+// wrap returns the envelope of v under key, with the metadata of the response,
+// or v itself if env is disabled.
+func (env Envelope) wrap(key string, v interface{}, meta metadata) interface{} {
+	if len(env.Data) == 0 {
+		return v
+	}
+
+	w := wrapper{keys: [2]string{key}, values: [2]interface{}{v}, n: 1}
+
+	if len(env.Meta) != 0 && len(meta) != 0 {
+		w.keys[1], w.values[1], w.n = env.Meta, meta, 2
+	}
+
+	return w
+}
+
+func (env Envelope) errorValue(err error) interface{} {
+	if env.ErrorValue != nil {
+		return env.ErrorValue(err)
+	}
+	return err.Error()
+}
+
+// wrapper is the envelope of a response, it is encoded as a map of the n first
+// keys and values.
+type wrapper struct {
+	keys   [2]string
+	values [2]interface{}
+	n      int
+}
+
+func (w wrapper) EncodeValue(e objconv.Encoder) error {
+	i := 0
+	return e.EncodeMap(w.n, func(ke objconv.Encoder, ve objconv.Encoder) (err error) {
+		if err = ke.Encode(w.keys[i]); err == nil {
+			err = ve.Encode(w.values[i])
+		}
+		i++
+		return
+	})
+}
+
+// metadata is the list of keys and values set with SetMeta, in the order they
+// were first set.
+type metadata []metaEntry
+
+type metaEntry struct {
+	key   string
+	value interface{}
+}
+
+func (m *metadata) set(key string, value interface{}) {
+	for i := range *m {
+		if (*m)[i].key == key {
+			(*m)[i].value = value
+			return
+		}
+	}
+	*m = append(*m, metaEntry{key: key, value: value})
+}
+
+func (m metadata) EncodeValue(e objconv.Encoder) error {
+	i := 0
+	return e.EncodeMap(len(m), func(ke objconv.Encoder, ve objconv.Encoder) (err error) {
+		if err = ke.Encode(m[i].key); err == nil {
+			err = ve.Encode(m[i].value)
+		}
+		i++
+		return
+	})
+}
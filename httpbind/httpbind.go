@@ -0,0 +1,309 @@
+// Package httpbind provides a middleware for net/http servers which negotiates
+// the codecs of each request once, so handlers decode request bodies and
+// encode responses without dealing with the Accept and Content-Type headers.
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+//		var order Order
+//
+//		if err := httpbind.DecodeRequest(r.Context(), &order); err != nil {
+//			http.Error(w, err.Error(), httputil.StatusCode(err))
+//			return
+//		}
+//
+//		httpbind.EncodeResponse(r.Context(), createOrder(order))
+//	})
+//
+//	http.ListenAndServe(":8080", httpbind.Middleware(httpbind.Options{})(mux))
+//
+// The codecs are negotiated with the functions of the httputil package, which
+// look them up in the global registry.
+package httpbind
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/httputil"
+)
+
+// ErrNoBinding is returned by the functions of this package when they are
+// given a context which doesn't come from a request served by the middleware.
+var ErrNoBinding = errors.New("objconv/httpbind: the context has no codecs, the handler must be wrapped by httpbind.Middleware")
+
+// Options configures the middleware created by Middleware.
+type Options struct {
+	// Limits are enforced on the request bodies, including the MaxBytes limit.
+	Limits objconv.ParserConfig
+
+	// When set, request bodies with fields that don't exist in the structs
+	// they are decoded into are rejected, see objconv.Decoder.
+	DisallowUnknownFields bool
+
+	// When set, the map keys of responses are sorted.
+	SortMapKeys bool
+
+	// Envelope configures the envelope that responses are wrapped in, they
+	// are not wrapped by default.
+	Envelope Envelope
+
+	// When set, EncodeError writes the errors as problem details documents,
+	// see EncodeProblem, instead of wrapping them in the envelope.
+	Problems bool
+
+	// When set, responses with the status code 200 are written in their
+	// canonical encoding and have an ETag header which is the hash of the
+	// body, see httputil.ETag, and GET or HEAD requests with a matching
+	// If-None-Match header are answered with the status code 304 and no
+	// body.
+	ETags bool
+}
+
+// Middleware returns a function wrapping http handlers with the negotiation of
+// codecs configured by opts. The codecs are stored in the contexts of requests
+// for the handlers to use.
+//
+// Negotiation errors are not answered by the middleware, they are returned
+// when the handlers decode the request body or encode the response, and match
+// httputil.ErrUnsupportedMediaType or httputil.ErrNotAcceptable.
+func Middleware(opts Options) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b := &binding{opts: opts, w: w, r: r, body: r.Body}
+			b.mediaType, b.encoder, b.encodeErr = httputil.Negotiate(r.Header.Get("Accept"))
+			b.decoder, b.decodeErr = httputil.LookupContentType(r.Header.Get("Content-Type"))
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), bindingKey{}, b)))
+		})
+	}
+}
+
+// NewEncoder returns an encoder writing to w with the codec negotiated for the
+// response of the request that ctx belongs to, which is useful to write the
+// response in parts or to a destination other than the response writer.
+func NewEncoder(ctx context.Context, w io.Writer) (*objconv.Encoder, error) {
+	b, err := bindingOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if b.encodeErr != nil {
+		return nil, b.encodeErr
+	}
+	return b.newEncoder(b.encoder, w), nil
+}
+
+// NewDecoder returns a decoder reading r with the codec negotiated for the body
+// of the request that ctx belongs to.
+func NewDecoder(ctx context.Context, r io.Reader) (*objconv.Decoder, error) {
+	b, err := bindingOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if b.decodeErr != nil {
+		return nil, b.decodeErr
+	}
+	d := b.opts.Limits.NewDecoder(b.decoder, r)
+	d.DisallowUnknownFields = b.opts.DisallowUnknownFields
+	return d, nil
+}
+
+// MediaType returns the media type negotiated for the response of the request
+// that ctx belongs to.
+func MediaType(ctx context.Context) (string, error) {
+	b, err := bindingOf(ctx)
+	if err != nil {
+		return "", err
+	}
+	return b.mediaType, b.encodeErr
+}
+
+// DecodeRequest decodes the body of the request that ctx belongs to into v.
+func DecodeRequest(ctx context.Context, v interface{}) error {
+	b, err := bindingOf(ctx)
+	if err != nil {
+		return err
+	}
+
+	d, err := NewDecoder(ctx, b.body)
+	if err != nil {
+		return err
+	}
+
+	return d.Decode(v)
+}
+
+// EncodeResponse writes v as the body of the response to the request that ctx
+// belongs to, with the status code 200 and the Content-Type header set to the
+// negotiated media type.
+func EncodeResponse(ctx context.Context, v interface{}) error {
+	return EncodeResponseStatus(ctx, http.StatusOK, v)
+}
+
+// EncodeResponseStatus is like EncodeResponse but the response has the given
+// status code.
+//
+// The value is wrapped in the envelope configured by the options of the
+// middleware, if any.
+func EncodeResponseStatus(ctx context.Context, status int, v interface{}) error {
+	b, err := bindingOf(ctx)
+	if err != nil {
+		return err
+	}
+	v = b.opts.Envelope.wrap(b.opts.Envelope.Data, v, b.meta)
+
+	if b.opts.ETags && status == http.StatusOK && b.encodeErr == nil {
+		return b.respondETag(ctx, v)
+	}
+
+	return b.respond(ctx, status, v)
+}
+
+// EncodeError writes err as the body of the response to the request that ctx
+// belongs to, with the given status code. The error is wrapped in the envelope
+// configured by the options of the middleware, if any, or written as a problem
+// details document if the Problems option is set.
+func EncodeError(ctx context.Context, status int, err error) error {
+	b, berr := bindingOf(ctx)
+	if berr != nil {
+		return berr
+	}
+	if b.opts.Problems {
+		p := *ProblemOf(err)
+		if p.Title == http.StatusText(p.Status) {
+			p.Title = http.StatusText(status)
+		}
+		p.Status = status
+		return b.respondProblem(ctx, &p)
+	}
+	return b.respond(ctx, status, b.opts.Envelope.wrap(b.opts.Envelope.Error, b.opts.Envelope.errorValue(err), b.meta))
+}
+
+// EncodeProblem writes the problem details document of err, see ProblemOf, as
+// the body of the response to the request that ctx belongs to, with the status
+// code of the problem. The document is never wrapped in an envelope.
+//
+// The Content-Type header is set to the problem details media type of the
+// negotiated format, like "application/problem+json" or
+// "application/problem+cbor". Problems are written in the default media type of
+// the httputil package when the client accepts none of the registered formats.
+func EncodeProblem(ctx context.Context, err error) error {
+	b, berr := bindingOf(ctx)
+	if berr != nil {
+		return berr
+	}
+	return b.respondProblem(ctx, ProblemOf(err))
+}
+
+// SetMeta sets the value of key in the metadata of the response envelope of
+// the request that ctx belongs to, which is discarded if the options of the
+// middleware have no envelope with a Meta key.
+func SetMeta(ctx context.Context, key string, value interface{}) error {
+	b, err := bindingOf(ctx)
+	if err != nil {
+		return err
+	}
+	b.meta.set(key, value)
+	return nil
+}
+
+func (b *binding) respond(ctx context.Context, status int, v interface{}) error {
+	b.w.Header().Add("Vary", "Accept")
+
+	if b.encodeErr != nil {
+		return b.encodeErr
+	}
+
+	return b.write(ctx, b.encoder, b.mediaType, status, v)
+}
+
+// respondETag encodes v once in a buffer, the entity tag is the hash of the
+// buffered bytes which are then written as the body.
+func (b *binding) respondETag(ctx context.Context, v interface{}) error {
+	buf := &bytes.Buffer{}
+	e := b.newEncoder(b.encoder, buf)
+	e.Canonical = true
+
+	if err := e.EncodeContext(ctx, v); err != nil {
+		return err
+	}
+
+	etag := httputil.ETagOf(buf.Bytes())
+	b.w.Header().Set("ETag", etag)
+	b.w.Header().Add("Vary", "Accept")
+
+	if httputil.NotModified(b.r, etag) {
+		b.w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	b.w.Header().Set("Content-Type", b.mediaType)
+	b.w.WriteHeader(http.StatusOK)
+	_, err := buf.WriteTo(b.w)
+	return err
+}
+
+func (b *binding) respondProblem(ctx context.Context, p *Problem) error {
+	b.w.Header().Add("Vary", "Accept")
+
+	codec, mediaType := b.encoder, b.mediaType
+
+	if b.encodeErr != nil {
+		var ok bool
+		mediaType = httputil.DefaultMediaType
+
+		if codec, ok = objconv.Lookup(mediaType); !ok {
+			return b.encodeErr
+		}
+	}
+
+	status := p.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	return b.write(ctx, codec, problemMediaType(mediaType), status, p)
+}
+
+// write encodes v with the context of the request, which is given to the
+// link builders of the values, see objconv.InstallLinks.
+func (b *binding) write(ctx context.Context, codec objconv.Codec, mediaType string, status int, v interface{}) error {
+	e := b.newEncoder(codec, b.w)
+	b.w.Header().Set("Content-Type", mediaType)
+	b.w.WriteHeader(status)
+	return e.EncodeContext(ctx, v)
+}
+
+func (b *binding) newEncoder(codec objconv.Codec, w io.Writer) *objconv.Encoder {
+	e := codec.NewEncoder(w)
+	e.SortMapKeys = b.opts.SortMapKeys
+	return e
+}
+
+// binding carries the codecs negotiated for a request.
+type binding struct {
+	opts Options
+	w    http.ResponseWriter
+	r    *http.Request
+	body io.Reader
+
+	mediaType string
+	encoder   objconv.Codec
+	encodeErr error
+
+	decoder   objconv.Codec
+	decodeErr error
+
+	meta metadata
+}
+
+type bindingKey struct{}
+
+func bindingOf(ctx context.Context) (*binding, error) {
+	if b, ok := ctx.Value(bindingKey{}).(*binding); ok {
+		return b, nil
+	}
+	return nil, ErrNoBinding
+}
@@ -0,0 +1,402 @@
+package httpbind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/httputil"
+	"github.com/segmentio/objconv/json"
+	_ "github.com/segmentio/objconv/yaml"
+)
+
+type answer struct {
+	Answer int `objconv:"answer"`
+}
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	var v answer
+
+	if err := DecodeRequest(r.Context(), &v); err != nil {
+		http.Error(w, err.Error(), httputil.StatusCode(err))
+		return
+	}
+
+	v.Answer++
+
+	if err := EncodeResponseStatus(r.Context(), http.StatusCreated, v); err != nil {
+		http.Error(w, err.Error(), httputil.StatusCode(err))
+	}
+}
+
+func serve(opts Options, contentType string, accept string, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	r.Header.Set("Accept", accept)
+	w := httptest.NewRecorder()
+	Middleware(opts)(http.HandlerFunc(handler)).ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	w := serve(Options{}, "application/json", "application/yaml", `{"answer":41}`)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("bad status code: %d: %s", w.Code, w.Body.String())
+	}
+
+	if s := w.Header().Get("Content-Type"); s != "application/yaml" {
+		t.Errorf("bad content type: %s", s)
+	}
+
+	if s := w.Header().Get("Vary"); s != "Accept" {
+		t.Errorf("bad vary header: %s", s)
+	}
+
+	if s := w.Body.String(); s != "answer: 42\n" {
+		t.Errorf("bad body: %q", s)
+	}
+}
+
+// countedValue counts the number of times that it is encoded.
+type countedValue struct{ n *int }
+
+func (v countedValue) EncodeValue(e objconv.Encoder) error {
+	*v.n++
+	return e.Encode(*v.n)
+}
+
+func TestETags(t *testing.T) {
+	encodes := 0
+
+	h := Middleware(Options{ETags: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodes = 0
+		EncodeResponse(r.Context(), map[string]interface{}{"b": 2, "a": countedValue{&encodes}})
+	}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	etag := w.Header().Get("ETag")
+
+	if w.Code != http.StatusOK || len(etag) == 0 {
+		t.Fatalf("bad response: %d: %q", w.Code, etag)
+	}
+
+	// The body is the canonical encoding that the entity tag was computed
+	// from, values are only encoded once.
+	if s := w.Body.String(); s != `{"a":1,"b":2}` {
+		t.Errorf("bad body: %q", s)
+	}
+
+	if s := httputil.ETagOf(w.Body.Bytes()); s != etag {
+		t.Errorf("the entity tag is not the hash of the body: %q != %q", etag, s)
+	}
+
+	if encodes != 1 {
+		t.Errorf("the response was encoded %d times", encodes)
+	}
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("bad response: %d: %q", w.Code, w.Body.String())
+	}
+
+	if s := w.Header().Get("ETag"); s != etag {
+		t.Errorf("bad entity tag: %q", s)
+	}
+
+	// The entity tag depends on the negotiated format.
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "application/yaml")
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("bad response: %d: %q", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestMiddlewareErrors(t *testing.T) {
+	tests := []struct {
+		opts        Options
+		contentType string
+		accept      string
+		body        string
+		status      int
+	}{
+		{
+			contentType: "text/html",
+			accept:      "application/json",
+			body:        `{"answer":41}`,
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			contentType: "application/json",
+			accept:      "text/html",
+			body:        `{"answer":41}`,
+			status:      http.StatusNotAcceptable,
+		},
+		{
+			opts:        Options{DisallowUnknownFields: true},
+			contentType: "application/json",
+			body:        `{"answer":41,"question":"?"}`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			opts:        Options{Limits: objconv.ParserConfig{MaxBytes: 8}},
+			contentType: "application/json",
+			body:        `{"answer":41}`,
+			status:      http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		if w := serve(test.opts, test.contentType, test.accept, test.body); w.Code != test.status {
+			t.Errorf("%s -> %s: bad status code: %d: %s", test.contentType, test.accept, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestNoBinding(t *testing.T) {
+	if err := DecodeRequest(context.Background(), new(answer)); !errors.Is(err, ErrNoBinding) {
+		t.Errorf("bad error: %v", err)
+	}
+
+	if err := EncodeResponse(context.Background(), answer{}); !errors.Is(err, ErrNoBinding) {
+		t.Errorf("bad error: %v", err)
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		var v answer
+
+		if err := DecodeRequest(r.Context(), &v); err != nil {
+			EncodeError(r.Context(), httputil.StatusCode(err), errors.New("bad request"))
+			return
+		}
+
+		SetMeta(r.Context(), "page", 1)
+		SetMeta(r.Context(), "total", 10)
+		SetMeta(r.Context(), "page", 2)
+		EncodeResponse(r.Context(), v)
+	}
+
+	tests := []struct {
+		envelope Envelope
+		body     string
+		output   string
+	}{
+		{
+			envelope: DefaultEnvelope,
+			body:     `{"answer":42}`,
+			output:   `{"data":{"answer":42},"meta":{"page":2,"total":10}}`,
+		},
+		{
+			envelope: DefaultEnvelope,
+			body:     `{`,
+			output:   `{"error":"bad request"}`,
+		},
+		{
+			envelope: Envelope{Data: "result", Error: "failure"},
+			body:     `{"answer":42}`,
+			output:   `{"result":{"answer":42}}`,
+		},
+		{
+			envelope: Envelope{
+				Data:       "data",
+				Error:      "errors",
+				ErrorValue: func(err error) interface{} { return []string{err.Error()} },
+			},
+			body:   `{`,
+			output: `{"errors":["bad request"]}`,
+		},
+		{
+			body:   `{"answer":42}`,
+			output: `{"answer":42}`,
+		},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		Middleware(Options{Envelope: test.envelope})(http.HandlerFunc(h)).ServeHTTP(w, r)
+
+		if s := w.Body.String(); s != test.output {
+			t.Errorf("%+v: bad output: %s", test.envelope, s)
+		}
+	}
+}
+
+type order struct {
+	Quantity int `objconv:"quantity"`
+}
+
+func (o *order) SetQuantity(n int) error {
+	if n <= 0 {
+		return errors.New("must be positive")
+	}
+	o.Quantity = n
+	return nil
+}
+
+func TestEncodeProblem(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		var o order
+
+		if err := DecodeRequest(r.Context(), &o); err != nil {
+			EncodeProblem(r.Context(), err)
+			return
+		}
+
+		EncodeProblem(r.Context(), &Problem{
+			Type:       "https://example.com/problems/out-of-stock",
+			Title:      "Out of stock",
+			Status:     http.StatusConflict,
+			Extensions: map[string]interface{}{"status": 0, "available": 3},
+		})
+	}
+
+	tests := []struct {
+		opts        Options
+		contentType string
+		accept      string
+		body        string
+		status      int
+		mediaType   string
+		output      string
+	}{
+		{
+			contentType: "application/json",
+			body:        `{"quantity":`,
+			status:      http.StatusBadRequest,
+			mediaType:   "application/problem+json",
+		},
+		{
+			contentType: "application/json",
+			body:        `{"quantity":true}`,
+			status:      http.StatusUnprocessableEntity,
+			mediaType:   "application/problem+json",
+		},
+		{
+			contentType: "application/json",
+			body:        `{"quantity":-1}`,
+			status:      http.StatusUnprocessableEntity,
+			mediaType:   "application/problem+json",
+			output:      `{"title":"Unprocessable Entity","status":422,"detail":"quantity (line 1, column 15): must be positive","errors":[{"path":"quantity","message":"must be positive"}]}`,
+		},
+		{
+			opts:        Options{Limits: objconv.ParserConfig{MaxBytes: 4}},
+			contentType: "application/json",
+			body:        `{"quantity":1}`,
+			status:      http.StatusRequestEntityTooLarge,
+			mediaType:   "application/problem+json",
+		},
+		{
+			contentType: "text/html",
+			body:        `<p>1</p>`,
+			status:      http.StatusUnsupportedMediaType,
+			mediaType:   "application/problem+json",
+		},
+		{
+			contentType: "application/json",
+			accept:      "text/html",
+			body:        `{"quantity":1}`,
+			status:      http.StatusConflict,
+			mediaType:   "application/problem+json",
+			output:      `{"type":"https://example.com/problems/out-of-stock","title":"Out of stock","status":409,"available":3}`,
+		},
+		{
+			contentType: "application/json",
+			accept:      "application/yaml",
+			body:        `{"quantity":1}`,
+			status:      http.StatusConflict,
+			mediaType:   "application/yaml",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.body, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+			r.Header.Set("Content-Type", test.contentType)
+			r.Header.Set("Accept", test.accept)
+			w := httptest.NewRecorder()
+			Middleware(test.opts)(http.HandlerFunc(h)).ServeHTTP(w, r)
+
+			if w.Code != test.status {
+				t.Errorf("bad status code: %d: %s", w.Code, w.Body.String())
+			}
+			if s := w.Header().Get("Content-Type"); s != test.mediaType {
+				t.Errorf("bad content type: %s", s)
+			}
+			if s := w.Body.String(); len(test.output) != 0 && s != test.output {
+				t.Errorf("bad output:\n%s\n%s", test.output, s)
+			}
+		})
+	}
+}
+
+func TestEncodeErrorProblems(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		EncodeError(r.Context(), http.StatusNotFound, errors.New("no such order"))
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	Middleware(Options{Envelope: DefaultEnvelope, Problems: true})(http.HandlerFunc(h)).ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("bad status code: %d", w.Code)
+	}
+	if s := w.Body.String(); s != `{"title":"Not Found","status":404}` {
+		t.Errorf("bad output: %s", s)
+	}
+}
+
+func TestProblemDecode(t *testing.T) {
+	var p Problem
+
+	err := json.Unmarshal([]byte(`{"type":"about:blank","title":"Bad Request","status":400,"errors":[{"path":"a","code":"unknown","message":"unknown field"}],"trace":"abc"}`), &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Type != "about:blank" || p.Status != 400 || len(p.Errors) != 1 || p.Extensions["trace"] != "abc" {
+		t.Errorf("bad problem: %#v", p)
+	}
+
+	if s := p.Error(); s != "Bad Request" {
+		t.Errorf("bad error message: %s", s)
+	}
+
+	if ProblemOf(fmt.Errorf("wrapped: %w", &p)) != &p {
+		t.Error("problems must be returned by ProblemOf")
+	}
+}
+
+func TestProblemMediaType(t *testing.T) {
+	for mediaType, expect := range map[string]string{
+		"application/json":         "application/problem+json",
+		"application/cbor":         "application/problem+cbor",
+		"text/xml":                 "application/problem+xml",
+		"application/vnd.api+json": "application/problem+json",
+		"application/msgpack":      "application/msgpack",
+	} {
+		if s := problemMediaType(mediaType); s != expect {
+			t.Errorf("%s: bad problem media type: %s", mediaType, s)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package httpbind
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/httputil"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Problem is the problem details document defined by RFC 7807, which gives a
+// standard machine-readable format to the errors of HTTP APIs.
+//
+// Problem implements error, so handlers can return problems of their own where
+// errors are expected. Problems are encoded as maps of their non-empty members
+// followed by their extension members.
+type Problem struct {
+	// Type is a URI identifying the type of problem, which is "about:blank"
+	// when it is empty.
+	Type string
+
+	// Title is a short summary of the type of problem.
+	Title string
+
+	// Status is the HTTP status code of the response.
+	Status int
+
+	// Detail is an explanation of this occurrence of the problem.
+	Detail string
+
+	// Instance is a URI identifying this occurrence of the problem.
+	Instance string
+
+	// Errors lists the fields of the request that caused the problem, it is
+	// encoded as the "errors" extension member.
+	Errors objconv.MultiError
+
+	// Extensions holds the other extension members of the problem, they are
+	// encoded in the order of their keys.
+	Extensions map[string]interface{}
+}
+
+// ProblemOf returns the problem details document describing err.
+//
+// If err matches a *Problem it is returned. The errors of objconv decoders are
+// mapped to client errors carrying the field errors found in err: malformed
+// inputs get the status 400, inputs exceeding limits 413, and values of the
+// wrong type, unknown fields or validation errors 422. Negotiation errors get
+// the status 406 or 415. Other errors are server errors with the status 500,
+// their messages are not exposed in the problem.
+func ProblemOf(err error) *Problem {
+	var p *Problem
+
+	if errors.As(err, &p) {
+		return p
+	}
+
+	var status int
+	var fields objconv.MultiError
+	appendFieldErrors(&fields, err)
+
+	switch {
+	case errors.Is(err, httputil.ErrNotAcceptable):
+		status = http.StatusNotAcceptable
+	case errors.Is(err, httputil.ErrUnsupportedMediaType):
+		status = http.StatusUnsupportedMediaType
+	case errors.Is(err, objutil.ErrLimit):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, objutil.ErrSyntax), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		status = http.StatusBadRequest
+	case len(fields) != 0, errors.Is(err, objutil.ErrType), errors.Is(err, objutil.ErrRange), errors.Is(err, objutil.ErrUnknownField):
+		status = http.StatusUnprocessableEntity
+	default:
+		return &Problem{Title: http.StatusText(http.StatusInternalServerError), Status: http.StatusInternalServerError}
+	}
+
+	return &Problem{
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: err.Error(),
+		Errors: fields,
+	}
+}
+
+// appendFieldErrors appends the field errors found in the tree of errors of
+// err to m, errors wrapped by field errors are not inspected.
+func appendFieldErrors(m *objconv.MultiError, err error) {
+	switch e := err.(type) {
+	case nil:
+	case *objconv.FieldError:
+		m.Append(e)
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			appendFieldErrors(m, err)
+		}
+	case interface{ Unwrap() error }:
+		appendFieldErrors(m, e.Unwrap())
+	}
+}
+
+// Error satisfies the error interface.
+func (p *Problem) Error() string {
+	title := p.Title
+	if len(title) == 0 {
+		title = http.StatusText(p.Status)
+	}
+
+	switch {
+	case len(title) == 0:
+		return "objconv/httpbind: " + p.Detail
+	case len(p.Detail) == 0:
+		return title
+	default:
+		return title + ": " + p.Detail
+	}
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (p *Problem) EncodeValue(e objconv.Encoder) error {
+	m := make(metadata, 0, 6+len(p.Extensions))
+
+	for _, member := range [...]metaEntry{
+		{key: "type", value: p.Type},
+		{key: "title", value: p.Title},
+		{key: "status", value: p.Status},
+		{key: "detail", value: p.Detail},
+		{key: "instance", value: p.Instance},
+	} {
+		if member.value != "" && member.value != 0 {
+			m = append(m, member)
+		}
+	}
+
+	if len(p.Errors) != 0 {
+		m = append(m, metaEntry{key: "errors", value: p.Errors})
+	}
+
+	keys := make([]string, 0, len(p.Extensions))
+
+	for k := range p.Extensions {
+		if !isProblemMember(k) {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		m = append(m, metaEntry{key: k, value: p.Extensions[k]})
+	}
+
+	return m.EncodeValue(e)
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface, the members which
+// are not defined by the Problem type are decoded into its extensions.
+func (p *Problem) DecodeValue(d objconv.Decoder) error {
+	*p = Problem{}
+
+	return d.DecodeMap(func(kd objconv.Decoder, vd objconv.Decoder) error {
+		var k string
+
+		if err := kd.Decode(&k); err != nil {
+			return err
+		}
+
+		switch k {
+		case "type":
+			return vd.Decode(&p.Type)
+		case "title":
+			return vd.Decode(&p.Title)
+		case "status":
+			return vd.Decode(&p.Status)
+		case "detail":
+			return vd.Decode(&p.Detail)
+		case "instance":
+			return vd.Decode(&p.Instance)
+		case "errors":
+			return vd.Decode(&p.Errors)
+		}
+
+		var v interface{}
+
+		if err := vd.Decode(&v); err != nil {
+			return err
+		}
+
+		if p.Extensions == nil {
+			p.Extensions = make(map[string]interface{})
+		}
+
+		p.Extensions[k] = v
+		return nil
+	})
+}
+
+func isProblemMember(k string) bool {
+	switch k {
+	case "type", "title", "status", "detail", "instance", "errors":
+		return true
+	default:
+		return false
+	}
+}
+
+// problemMediaType returns the media type of problem details documents in the
+// format of mediaType. Formats with a structured syntax suffix for problem
+// details, like "application/problem+json", use it, others keep their media
+// type.
+func problemMediaType(mediaType string) string {
+	sub := mediaType[strings.IndexByte(mediaType, '/')+1:]
+
+	if i := strings.LastIndexByte(sub, '+'); i >= 0 {
+		sub = sub[i+1:]
+	}
+
+	switch sub {
+	case "json", "cbor", "xml":
+		return "application/problem+" + sub
+	default:
+		return mediaType
+	}
+}
@@ -0,0 +1,78 @@
+package httputil
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/segmentio/objconv"
+)
+
+// ETag returns a strong entity tag of v, which is the quoted SHA-256 hash of
+// the canonical encoding of v with codec, see objconv.Encoder.Canonical.
+//
+// Equal values always get the same entity tag, and the encodings of values are
+// hashed while they are produced instead of being buffered. The context is
+// given to the encoder, see objconv.Encoder.EncodeContext.
+func ETag(ctx context.Context, codec objconv.Codec, v interface{}) (string, error) {
+	h := sha256.New()
+	e := codec.NewEncoder(h)
+	e.Canonical = true
+
+	if err := e.EncodeContext(ctx, v); err != nil {
+		return "", err
+	}
+
+	var sum [sha256.Size]byte
+	return formatETag(h.Sum(sum[:0])), nil
+}
+
+// ETagOf returns a strong entity tag of the representation b, which is its
+// quoted SHA-256 hash.
+//
+// Programs which write the canonical encoding of values as response bodies use
+// this function to compute the entity tags of the bytes that they write, which
+// are then the same as the entity tags returned by ETag.
+func ETagOf(b []byte) string {
+	sum := sha256.Sum256(b)
+	return formatETag(sum[:])
+}
+
+func formatETag(sum []byte) string {
+	var b [2 + 2*sha256.Size]byte
+	b[0], b[len(b)-1] = '"', '"'
+	hex.Encode(b[1:], sum)
+	return string(b[:])
+}
+
+// NotModified returns true if r is a GET or HEAD request with an If-None-Match
+// header matching etag, in which case handlers respond with the status code
+// 304 instead of writing the response body.
+//
+// Entity tags are compared with the weak comparison of RFC 7232, so they match
+// whether they are weak or not.
+func NotModified(r *http.Request, etag string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	header := r.Header.Get("If-None-Match")
+	if len(header) == 0 {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, tag := range strings.Split(header, ",") {
+		switch tag = strings.TrimSpace(tag); tag {
+		case "*":
+			return true
+		case etag, "W/" + etag:
+			return true
+		}
+	}
+
+	return false
+}
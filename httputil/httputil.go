@@ -0,0 +1,251 @@
+// Package httputil implements content negotiation for HTTP handlers, picking
+// the codecs of the objconv registry that match the Accept header of requests
+// to encode responses, and their Content-Type header to decode request bodies.
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		d, err := httputil.NegotiateDecoder(r)
+//		if err != nil {
+//			http.Error(w, err.Error(), httputil.StatusCode(err))
+//			return
+//		}
+//
+//		var req request
+//		if err := d.Decode(&req); err != nil {
+//			http.Error(w, err.Error(), http.StatusBadRequest)
+//			return
+//		}
+//
+//		e, err := httputil.NegotiateEncoder(w, r)
+//		if err != nil {
+//			http.Error(w, err.Error(), httputil.StatusCode(err))
+//			return
+//		}
+//		e.Encode(handle(req))
+//	}
+//
+// The codecs are looked up in the global registry, so the packages of the
+// formats that handlers support must be imported.
+package httputil
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+var (
+	// ErrNotAcceptable matches the errors returned when no registered codec
+	// produces one of the media types accepted by a client.
+	ErrNotAcceptable = errors.New("objconv/httputil: not acceptable")
+
+	// ErrUnsupportedMediaType matches the errors returned when no registered
+	// codec parses the media type of a request body.
+	ErrUnsupportedMediaType = errors.New("objconv/httputil: unsupported media type")
+)
+
+// DefaultMediaType is the media type preferred when several codecs are equally
+// acceptable to a client, for example when its Accept header is "*/*" or when
+// it has none.
+var DefaultMediaType = "application/json"
+
+// NegotiateEncoder returns an encoder writing the response body of w in the
+// format that the client sending r prefers. The Content-Type header of the
+// response is set to the media type of the selected codec.
+//
+// The returned error matches ErrNotAcceptable if none of the registered codecs
+// produce a media type accepted by the client.
+func NegotiateEncoder(w http.ResponseWriter, r *http.Request) (*objconv.Encoder, error) {
+	mediaType, codec, err := Negotiate(r.Header.Get("Accept"))
+	if err != nil {
+		return nil, err
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", mediaType)
+	h.Add("Vary", "Accept")
+	return codec.NewEncoder(w), nil
+}
+
+// NegotiateDecoder returns a decoder reading the body of r with the codec of
+// its Content-Type header. Media types with a structured syntax suffix, like
+// "application/vnd.api+json", are decoded with the codec of the suffix when
+// they have none of their own.
+//
+// The returned error matches ErrUnsupportedMediaType if the request has no
+// content type or if no codec is registered for it.
+func NegotiateDecoder(r *http.Request) (*objconv.Decoder, error) {
+	codec, err := LookupContentType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	return codec.NewDecoder(r.Body), nil
+}
+
+// Negotiate returns the registered media type and codec best matching the
+// value of an Accept header, which lists media ranges with optional quality
+// values like "application/msgpack, application/json;q=0.9, */*;q=0.1".
+//
+// Codecs selected by higher quality values are preferred, then the ones
+// matched by the most specific media ranges, then the ones appearing first in
+// the header. An empty header accepts all media types.
+func Negotiate(accept string) (mediaType string, codec objconv.Codec, err error) {
+	ranges := parseAccept(accept)
+	codecs := objconv.Codecs()
+	best := match{q: -1}
+
+	for name := range codecs {
+		typ, sub, ok := splitMediaType(name)
+		if !ok {
+			continue // short names like "json" are not media types
+		}
+
+		m := bestMatch(ranges, typ, sub)
+		m.name = name
+
+		if m.q > 0 && m.better(best) {
+			best = m
+		}
+	}
+
+	if best.q <= 0 {
+		err = objutil.Errorf(ErrNotAcceptable, "objconv/httputil: none of the media types accepted by the client are supported: %q", accept)
+		return
+	}
+
+	return best.name, codecs[best.name], nil
+}
+
+// StatusCode returns the HTTP status code that handlers respond with when
+// NegotiateEncoder or NegotiateDecoder returned err.
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrNotAcceptable):
+		return http.StatusNotAcceptable
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// LookupContentType returns the registered codec parsing the media type of the
+// Content-Type header value contentType, with the same rules as
+// NegotiateDecoder.
+func LookupContentType(contentType string) (objconv.Codec, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return objconv.Codec{}, objutil.Errorf(ErrUnsupportedMediaType, "objconv/httputil: invalid content type: %q", contentType)
+	}
+
+	if typ, sub, ok := splitMediaType(mediaType); ok {
+		if codec, ok := objconv.Lookup(mediaType); ok {
+			return codec, nil
+		}
+		if i := strings.LastIndexByte(sub, '+'); i >= 0 {
+			if codec, ok := objconv.Lookup(typ + "/" + sub[i+1:]); ok {
+				return codec, nil
+			}
+		}
+	}
+
+	return objconv.Codec{}, objutil.Errorf(ErrUnsupportedMediaType, "objconv/httputil: no codecs are registered for the content type %q", mediaType)
+}
+
+// mediaRange is an element of an Accept header.
+type mediaRange struct {
+	typ   string
+	sub   string
+	q     float64
+	index int
+}
+
+func parseAccept(accept string) (ranges []mediaRange) {
+	if strings.TrimSpace(accept) == "" {
+		return []mediaRange{{typ: "*", sub: "*", q: 1}}
+	}
+
+	for i, s := range strings.Split(accept, ",") {
+		params := strings.Split(s, ";")
+
+		typ, sub, ok := splitMediaType(strings.TrimSpace(params[0]))
+		if !ok {
+			continue
+		}
+
+		r := mediaRange{typ: typ, sub: sub, q: 1, index: i}
+
+		for _, p := range params[1:] {
+			if k, v, ok := strings.Cut(strings.TrimSpace(p), "="); ok && strings.EqualFold(k, "q") {
+				if q, err := strconv.ParseFloat(v, 64); err == nil && q >= 0 && q <= 1 {
+					r.q = q
+				}
+			}
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	// The most specific ranges come first so they override the wildcards that
+	// match the same media types.
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return specificity(ranges[i]) > specificity(ranges[j])
+	})
+	return
+}
+
+func specificity(r mediaRange) int {
+	switch {
+	case r.typ == "*":
+		return 0
+	case r.sub == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// match is the media range of an Accept header which selected a codec.
+type match struct {
+	name        string
+	q           float64
+	specificity int
+	index       int
+}
+
+func (m match) better(other match) bool {
+	if m.q != other.q {
+		return m.q > other.q
+	}
+	if m.specificity != other.specificity {
+		return m.specificity > other.specificity
+	}
+	if m.index != other.index {
+		return m.index < other.index
+	}
+	if (m.name == DefaultMediaType) != (other.name == DefaultMediaType) {
+		return m.name == DefaultMediaType
+	}
+	return m.name < other.name
+}
+
+func bestMatch(ranges []mediaRange, typ string, sub string) match {
+	for _, r := range ranges {
+		if (r.typ == "*" || strings.EqualFold(r.typ, typ)) && (r.sub == "*" || strings.EqualFold(r.sub, sub)) {
+			return match{q: r.q, specificity: specificity(r), index: r.index}
+		}
+	}
+	return match{}
+}
+
+func splitMediaType(s string) (typ string, sub string, ok bool) {
+	if typ, sub, ok = strings.Cut(s, "/"); ok {
+		ok = typ != "" && sub != ""
+	}
+	return
+}
@@ -0,0 +1,206 @@
+package httputil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/objconv"
+	_ "github.com/segmentio/objconv/json"
+	_ "github.com/segmentio/objconv/msgpack"
+	_ "github.com/segmentio/objconv/yaml"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept    string
+		mediaType string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"application/msgpack", "application/msgpack"},
+		{"APPLICATION/MSGPACK", "application/msgpack"},
+		{"text/html, application/msgpack;q=0.5, application/json;q=0.9", "application/json"},
+		{"application/yaml, application/json", "application/yaml"},
+		{"application/*;q=0.5, application/msgpack", "application/msgpack"},
+		{"*/*;q=0.1, text/*;q=0.2", "text/json"},
+		{"application/json;q=0, */*", "application/msgpack"},
+		{"text/html;level=1;q=0.9, application/yaml;q=0.8", "application/yaml"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.accept, func(t *testing.T) {
+			mediaType, _, err := Negotiate(test.accept)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mediaType != test.mediaType {
+				t.Errorf("bad media type: %s", mediaType)
+			}
+		})
+	}
+}
+
+func TestNegotiateNotAcceptable(t *testing.T) {
+	for _, accept := range []string{"text/html", "application/json;q=0", "image/*"} {
+		if _, _, err := Negotiate(accept); !errors.Is(err, ErrNotAcceptable) {
+			t.Errorf("%s: bad error: %v", accept, err)
+		}
+	}
+}
+
+func TestNegotiateEncoder(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "application/yaml")
+	w := httptest.NewRecorder()
+
+	e, err := NegotiateEncoder(w, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Encode(map[string]int{"answer": 42}); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := w.Header().Get("Content-Type"); s != "application/yaml" {
+		t.Errorf("bad content type: %s", s)
+	}
+
+	if s := w.Header().Get("Vary"); s != "Accept" {
+		t.Errorf("bad vary header: %s", s)
+	}
+
+	if s := w.Body.String(); s != "answer: 42\n" {
+		t.Errorf("bad body: %q", s)
+	}
+}
+
+func TestNegotiateDecoder(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+	}{
+		{"application/json", `{"answer":42}`},
+		{"application/json; charset=utf-8", `{"answer":42}`},
+		{"application/vnd.api+json", `{"answer":42}`},
+		{"text/yaml", "answer: 42\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.contentType, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+			r.Header.Set("Content-Type", test.contentType)
+
+			d, err := NegotiateDecoder(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var v struct {
+				Answer int `objconv:"answer"`
+			}
+
+			if err := d.Decode(&v); err != nil {
+				t.Fatal(err)
+			}
+
+			if v.Answer != 42 {
+				t.Errorf("bad value: %d", v.Answer)
+			}
+		})
+	}
+}
+
+func TestNegotiateDecoderUnsupported(t *testing.T) {
+	for _, contentType := range []string{"", "text/html", "application/vnd.api+xml", "json"} {
+		r := httptest.NewRequest("POST", "/", strings.NewReader("{}"))
+		r.Header.Set("Content-Type", contentType)
+
+		_, err := NegotiateDecoder(r)
+
+		if !errors.Is(err, ErrUnsupportedMediaType) {
+			t.Errorf("%q: bad error: %v", contentType, err)
+		}
+
+		if code := StatusCode(err); code != http.StatusUnsupportedMediaType {
+			t.Errorf("%q: bad status code: %d", contentType, code)
+		}
+	}
+}
+
+func TestETag(t *testing.T) {
+	codec, _ := objconv.Lookup("application/json")
+	ctx := context.Background()
+
+	a, err := ETag(ctx, codec, map[string]int{"a": 1, "b": 2, "c": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a) != 66 || a[0] != '"' || a[65] != '"' {
+		t.Errorf("bad entity tag: %s", a)
+	}
+
+	// Canonical encodings don't depend on the iteration order of maps.
+	for i := 0; i != 10; i++ {
+		if b, _ := ETag(ctx, codec, map[string]int{"c": 3, "b": 2, "a": 1}); b != a {
+			t.Fatalf("entity tags of equal values differ: %s != %s", a, b)
+		}
+	}
+
+	if b, _ := ETag(ctx, codec, map[string]int{"a": 1, "b": 2, "c": 4}); b == a {
+		t.Errorf("entity tags of different values are equal: %s", b)
+	}
+
+	buf := &bytes.Buffer{}
+	e := codec.NewEncoder(buf)
+	e.Canonical = true
+	e.Encode(map[string]int{"a": 1, "b": 2, "c": 3})
+
+	if s := ETagOf(buf.Bytes()); s != a {
+		t.Errorf("the entity tag of the canonical encoding differs: %s != %s", a, s)
+	}
+
+	msgpack, _ := objconv.Lookup("application/msgpack")
+
+	if b, _ := ETag(ctx, msgpack, map[string]int{"a": 1, "b": 2, "c": 3}); b == a {
+		t.Errorf("entity tags of different formats are equal: %s", b)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	const etag = `"1234"`
+
+	tests := []struct {
+		method string
+		header string
+		match  bool
+	}{
+		{method: "GET", header: "", match: false},
+		{method: "GET", header: `"1234"`, match: true},
+		{method: "HEAD", header: `"1234"`, match: true},
+		{method: "GET", header: `W/"1234"`, match: true},
+		{method: "GET", header: `"abcd", W/"1234"`, match: true},
+		{method: "GET", header: `"abcd"`, match: false},
+		{method: "GET", header: `1234`, match: false},
+		{method: "GET", header: `*`, match: true},
+		{method: "POST", header: `"1234"`, match: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method+" "+test.header, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, "/", nil)
+			if len(test.header) != 0 {
+				r.Header.Set("If-None-Match", test.header)
+			}
+			if match := NotModified(r, etag); match != test.match {
+				t.Errorf("bad result: %t", match)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package objconv
+
+import (
+	"reflect"
+	"sync"
+)
+
+// IgnoreType configures encoders and decoders to skip struct fields of type
+// typ, as if they were tagged with "-".
+//
+// This is mostly useful for types that carry no data of their own, like the
+// locks guarding the other fields of a struct (see the IgnoreLocks function of
+// the adapters/sync package), which would otherwise be serialized as empty
+// objects or require wrapping the struct in a shadow type.
+//
+// Like Install, the function is intended to be called during the package
+// initialization phase.
+func IgnoreType(typ reflect.Type) {
+	ignoreMutex.Lock()
+	ignoreStore[typ] = struct{}{}
+	ignoreMutex.Unlock()
+
+	// Same as Install, the struct cache may have become invalid.
+	clearStructCaches()
+}
+
+func isIgnoredType(typ reflect.Type) (ok bool) {
+	ignoreMutex.RLock()
+	_, ok = ignoreStore[typ]
+	ignoreMutex.RUnlock()
+	return
+}
+
+var (
+	ignoreMutex sync.RWMutex
+	ignoreStore = make(map[reflect.Type]struct{})
+)
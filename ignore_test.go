@@ -0,0 +1,39 @@
+package objconv
+
+import (
+	"reflect"
+	"testing"
+)
+
+type ignoredLock struct{ state int32 }
+
+func init() {
+	IgnoreType(reflect.TypeOf(ignoredLock{}))
+}
+
+func TestIgnoreType(t *testing.T) {
+	type guarded struct {
+		Mu    ignoredLock
+		Value int
+	}
+
+	e := NewValueEmitter()
+
+	if err := (Encoder{Emitter: e}).Encode(guarded{Value: 42}); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := e.Value(); !reflect.DeepEqual(v, map[interface{}]interface{}{"Value": int64(42)}) {
+		t.Errorf("bad encoding: %#v", v)
+	}
+
+	var g guarded
+
+	if err := NewDecoder(NewValueParser(map[string]interface{}{"Mu": 1, "Value": 1})).Decode(&g); err != nil {
+		t.Fatal(err)
+	}
+
+	if g != (guarded{Value: 1}) {
+		t.Errorf("bad value: %#v", g)
+	}
+}
@@ -0,0 +1,225 @@
+// Package include implements include directives for configuration files split
+// into multiple documents.
+//
+// The maps of documents loaded by a Loader may have an "$include" key, whose
+// value is the path of a document, or an array of paths, to merge into the map:
+//
+//	# config.yaml
+//	$include: [defaults.yaml, secrets.json]
+//	server:
+//	  address: :8080
+//
+// Paths are relative to the directory of the document where they appear. The
+// included documents must be maps, they are merged in order and the entries of
+// the including map take precedence over the included ones. Nested maps are
+// merged recursively, other values replace each other.
+//
+// The format of documents is selected by the extension of their path, which is
+// looked up in the codec registry of the objconv package, so the packages of
+// the codecs must be imported by the program.
+package include
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// DefaultKey is the key of include directives used by loaders that don't set
+// one.
+const DefaultKey = "$include"
+
+// Loader loads documents with include directives from a file system.
+type Loader struct {
+	// FS is the file system that documents are loaded from.
+	FS fs.FS
+
+	// Key is the key of include directives, DefaultKey is used if empty.
+	Key string
+
+	// Codecs associates file extensions, without the leading dot, to the
+	// codecs used to load documents. Extensions that aren't in the map are
+	// looked up in the objconv codec registry.
+	Codecs map[string]objconv.Codec
+
+	// Decoder is used as template for the decoders of values produced by the
+	// Decode method, which lets programs configure options like Resolver or
+	// DisallowUnknownFields. The parser is ignored.
+	Decoder objconv.Decoder
+}
+
+// Load returns the document at p with all its include directives expanded.
+//
+// Maps of the returned value are of type map[string]interface{}.
+func (l *Loader) Load(p string) (interface{}, error) {
+	return l.load(path.Clean(p), nil)
+}
+
+// Decode loads the document at p, expands its include directives, and decodes
+// the result into v.
+func (l *Loader) Decode(p string, v interface{}) error {
+	x, err := l.Load(p)
+	if err != nil {
+		return err
+	}
+
+	d := l.Decoder
+	d.Parser = objconv.NewValueParser(x)
+	return d.Decode(v)
+}
+
+func (l *Loader) load(p string, stack []string) (interface{}, error) {
+	for _, s := range stack {
+		if s == p {
+			return nil, fmt.Errorf("objconv/include: %s includes itself through %s", p, strings.Join(stack, " -> "))
+		}
+	}
+
+	codec, err := l.codec(p)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := l.FS.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var v interface{}
+
+	d := codec.NewDecoder(f)
+	d.MapType = mapType
+
+	if err := d.Decode(&v); err != nil {
+		return nil, fmt.Errorf("objconv/include: %s: %w", p, err)
+	}
+
+	return l.expand(v, p, append(stack, p))
+}
+
+func (l *Loader) codec(p string) (objconv.Codec, error) {
+	ext := strings.TrimPrefix(path.Ext(p), ".")
+
+	if c, ok := l.Codecs[ext]; ok {
+		return c, nil
+	}
+
+	if c, ok := objconv.Lookup(ext); ok {
+		return c, nil
+	}
+
+	return objconv.Codec{}, fmt.Errorf("objconv/include: no codec found for the extension of %s", p)
+}
+
+// expand replaces the include directives found in v, which was loaded from the
+// document at p.
+func (l *Loader) expand(v interface{}, p string, stack []string) (interface{}, error) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, e := range x {
+			var err error
+			if x[k], err = l.expand(e, p, stack); err != nil {
+				return nil, err
+			}
+		}
+
+		key := l.Key
+		if len(key) == 0 {
+			key = DefaultKey
+		}
+
+		inc, ok := x[key]
+		if !ok {
+			return x, nil
+		}
+		delete(x, key)
+
+		paths, err := includePaths(inc, p, key)
+		if err != nil {
+			return nil, err
+		}
+
+		m := map[string]interface{}{}
+
+		for _, ip := range paths {
+			y, err := l.load(ip, stack)
+			if err != nil {
+				return nil, err
+			}
+
+			ym, ok := y.(map[string]interface{})
+			if !ok {
+				return nil, objutil.Errorf(objutil.ErrType, "objconv/include: %s: the included document %s is not a map", p, ip)
+			}
+
+			merge(m, ym)
+		}
+
+		merge(m, x)
+		return m, nil
+
+	case []interface{}:
+		for i, e := range x {
+			var err error
+			if x[i], err = l.expand(e, p, stack); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return v, nil
+}
+
+// includePaths returns the paths of the documents referenced by the value v of
+// an include directive of the document at p.
+func includePaths(v interface{}, p string, key string) ([]string, error) {
+	var list []interface{}
+
+	switch x := v.(type) {
+	case string:
+		list = []interface{}{x}
+	case []interface{}:
+		list = x
+	default:
+		return nil, objutil.Errorf(objutil.ErrType, "objconv/include: %s: the value of %s must be a string or an array of strings, not %T", p, key, v)
+	}
+
+	paths := make([]string, len(list))
+
+	for i, e := range list {
+		s, ok := e.(string)
+		if !ok {
+			return nil, objutil.Errorf(objutil.ErrType, "objconv/include: %s: the value of %s must be a string or an array of strings, found %T", p, key, e)
+		}
+
+		if strings.HasPrefix(s, "/") {
+			paths[i] = path.Clean(s[1:])
+		} else {
+			paths[i] = path.Join(path.Dir(p), s)
+		}
+	}
+
+	return paths, nil
+}
+
+// merge copies the entries of src into dst, nested maps are merged.
+func merge(dst, src map[string]interface{}) {
+	for k, v := range src {
+		a, ok1 := dst[k].(map[string]interface{})
+		b, ok2 := v.(map[string]interface{})
+
+		if ok1 && ok2 {
+			merge(a, b)
+		} else {
+			dst[k] = v
+		}
+	}
+}
+
+var mapType = reflect.TypeOf(map[string]interface{}(nil))
@@ -0,0 +1,117 @@
+package include
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/segmentio/objconv"
+	_ "github.com/segmentio/objconv/json"
+	"github.com/segmentio/objconv/yaml"
+)
+
+var testFS = fstest.MapFS{
+	"config.yaml": {Data: []byte(`
+$include: [conf.d/defaults.json, conf.d/server.yml]
+name: prod
+server:
+  port: 443
+`)},
+	"conf.d/defaults.json": {Data: []byte(`{
+  "name": "default",
+  "server": {"address": "0.0.0.0", "port": 80},
+  "tags": ["a", "b"]
+}`)},
+	"conf.d/server.yml": {Data: []byte(`
+server:
+  tls:
+    $include: /certs/tls.json
+`)},
+	"certs/tls.json": {Data: []byte(`{"cert": "server.pem"}`)},
+
+	"loop/a.json": {Data: []byte(`{"$include": "b.json"}`)},
+	"loop/b.json": {Data: []byte(`{"$include": "a.json"}`)},
+	"array.json":  {Data: []byte(`{"$include": "list.json"}`)},
+	"list.json":   {Data: []byte(`[1, 2]`)},
+	"bad.json":    {Data: []byte(`{"$include": 42}`)},
+	"unknown.ini": {Data: []byte(`a = 1`)},
+}
+
+func newLoader() *Loader {
+	return &Loader{
+		FS:     testFS,
+		Codecs: map[string]objconv.Codec{"yml": yaml.Codec},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	v, err := newLoader().Load("config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"name": "prod",
+		"server": map[string]interface{}{
+			"address": "0.0.0.0",
+			"port":    int64(443),
+			"tls":     map[string]interface{}{"cert": "server.pem"},
+		},
+		"tags": []interface{}{"a", "b"},
+	}
+
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("bad value:\n%#v\n%#v", v, expected)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	type config struct {
+		Name   string `objconv:"name"`
+		Server struct {
+			Address string `objconv:"address"`
+			Port    int    `objconv:"port"`
+		} `objconv:"server"`
+	}
+
+	l := newLoader()
+	l.Decoder.DisallowUnknownFields = true
+
+	var c config
+	err := l.Decode("config.yaml", &c)
+
+	if !errors.Is(err, objconv.ErrUnknownField) {
+		t.Errorf("bad error: %v", err)
+	}
+
+	l.Decoder.DisallowUnknownFields = false
+
+	if err := l.Decode("config.yaml", &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Name != "prod" || c.Server.Address != "0.0.0.0" || c.Server.Port != 443 {
+		t.Errorf("bad value: %#v", c)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		path string
+		msg  string
+	}{
+		{"loop/a.json", "includes itself"},
+		{"array.json", "is not a map"},
+		{"bad.json", "must be a string or an array of strings"},
+		{"unknown.ini", "no codec found"},
+		{"missing.json", "file does not exist"},
+	}
+
+	for _, test := range tests {
+		if _, err := newLoader().Load(test.path); err == nil || !strings.Contains(err.Error(), test.msg) {
+			t.Errorf("%s: bad error: %v", test.path, err)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// Package index implements a companion format for append-only files made of
+// consecutive encoded values, recording sync points that map record numbers to
+// byte offsets.
+//
+// Files of length-less formats like newline-delimited JSON or CBOR sequences
+// can only be read from the beginning, an index makes it possible to jump close
+// to any record and only decode the few values that separate it from the
+// nearest sync point.
+package index
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/segmentio/objconv"
+)
+
+// DefaultEvery is the default number of records between two sync points.
+const DefaultEvery = 1000
+
+// entrySize is the size of an index entry on disk, each entry is made of the
+// record number and byte offset as two big-endian 64 bits integers.
+const entrySize = 16
+
+// An Entry is a sync point of an index.
+type Entry struct {
+	Record int64 // the record number, starting at zero
+	Offset int64 // the byte offset of the record in the data file
+}
+
+// An Index is a list of sync points sorted by record number.
+type Index []Entry
+
+// Read loads an index from r.
+//
+// A truncated entry at the end of the input is ignored, it happens when the
+// program writing the index was interrupted.
+func Read(r io.Reader) (Index, error) {
+	var x Index
+	var b [entrySize]byte
+	var br = bufio.NewReader(r)
+
+	for {
+		if _, err := io.ReadFull(br, b[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return x, nil
+			}
+			return x, err
+		}
+
+		e := Entry{
+			Record: int64(binary.BigEndian.Uint64(b[:8])),
+			Offset: int64(binary.BigEndian.Uint64(b[8:])),
+		}
+
+		if n := len(x); n != 0 && (e.Record <= x[n-1].Record || e.Offset < x[n-1].Offset) {
+			return x, fmt.Errorf("objconv/index: entry %d is out of order (record = %d, offset = %d)", n, e.Record, e.Offset)
+		}
+
+		x = append(x, e)
+	}
+}
+
+// Build scans the values encoded in r with codec and returns an index with a
+// sync point every n records.
+//
+// The function is useful to create the index of files that were written
+// without it, the parser of the codec must implement objconv.OffsetParser.
+func Build(r io.Reader, codec objconv.Codec, n int) (Index, error) {
+	var x Index
+
+	if n <= 0 {
+		n = DefaultEvery
+	}
+
+	d := codec.NewStreamDecoder(r)
+	d.Sequence = true
+
+	for i := int64(0); ; i++ {
+		var v interface{}
+
+		off, _, err := d.DecodeAt(&v)
+		if err != nil {
+			if err == objconv.End {
+				err = nil
+			}
+			return x, err
+		}
+
+		if i%int64(n) == 0 {
+			x = append(x, Entry{Record: i, Offset: off})
+		}
+	}
+}
+
+// WriteTo writes the index to w in the format expected by Read.
+func (x Index) WriteTo(w io.Writer) (n int64, err error) {
+	var b [entrySize]byte
+
+	for _, e := range x {
+		putEntry(b[:], e)
+
+		if _, err = w.Write(b[:]); err != nil {
+			return
+		}
+
+		n += entrySize
+	}
+
+	return
+}
+
+// Find returns the sync point closest to record, which is the entry with the
+// largest record number lower than or equal to it.
+//
+// The zero-value entry is returned if no sync point precedes the record, which
+// is the beginning of the data file.
+func (x Index) Find(record int64) Entry {
+	i := sort.Search(len(x), func(i int) bool { return x[i].Record > record })
+
+	if i == 0 {
+		return Entry{}
+	}
+
+	return x[i-1]
+}
+
+// Seek positions r at the sync point closest to record and returns a stream
+// decoder for codec that yields the values starting at this record.
+//
+// Seeking to the record that follows the last one of the data file is allowed,
+// the decoder then yields no values until more are appended.
+func (x Index) Seek(r io.ReadSeeker, codec objconv.Codec, record int64) (*objconv.StreamDecoder, error) {
+	if record < 0 {
+		return nil, fmt.Errorf("objconv/index: invalid negative record number: %d", record)
+	}
+
+	e := x.Find(record)
+
+	if _, err := r.Seek(e.Offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	d := codec.NewStreamDecoder(r)
+	d.Sequence = true
+
+	for i := e.Record; i != record; i++ {
+		var v interface{}
+
+		if err := d.Decode(&v); err != nil {
+			if err == objconv.End {
+				err = fmt.Errorf("objconv/index: record %d is past the end of the data file (%d records)", record, i)
+			}
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+// Writer encodes values to a data file and records a sync point in an index
+// file every few records.
+type Writer struct {
+	// Every is the number of records between sync points, DefaultEvery is used
+	// if it is zero or negative.
+	Every int
+
+	// Separator is written after each value, text formats usually need one to
+	// delimit values (like a newline for newline-delimited JSON).
+	Separator []byte
+
+	data   countWriter
+	index  io.Writer
+	enc    *objconv.Encoder
+	record int64
+	err    error
+	b      [entrySize]byte
+}
+
+// NewWriter returns a new writer encoding values to data with codec and
+// writing the sync points to index.
+func NewWriter(data io.Writer, index io.Writer, codec objconv.Codec) *Writer {
+	w := &Writer{index: index}
+	w.data.w = data
+	w.enc = objconv.NewEncoder(codec.NewEmitter(&w.data))
+	return w
+}
+
+// NewWriterAt is like NewWriter but for data files that already contain
+// records, new values are numbered starting at record and are assumed to be
+// written at offset in the data file.
+func NewWriterAt(data io.Writer, index io.Writer, codec objconv.Codec, record int64, offset int64) *Writer {
+	w := NewWriter(data, index, codec)
+	w.record = record
+	w.data.n = offset
+	return w
+}
+
+// Record returns the number of the next record written by w.
+func (w *Writer) Record() int64 {
+	return w.record
+}
+
+// Offset returns the offset in the data file where the next record written by
+// w will be located.
+func (w *Writer) Offset() int64 {
+	return w.data.n
+}
+
+// Encode writes v as the next record of the data file.
+//
+// Once an error was returned by Encode it is returned by all following calls.
+func (w *Writer) Encode(v interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	every := int64(w.Every)
+
+	if every <= 0 {
+		every = DefaultEvery
+	}
+
+	// The offset is captured before the record is written, the entry is only
+	// written once the record is complete so the index never references a
+	// record that failed to be encoded.
+	off := w.data.n
+
+	if err := w.enc.Encode(v); err != nil {
+		w.err = err
+		return err
+	}
+
+	if len(w.Separator) != 0 {
+		if _, err := w.data.Write(w.Separator); err != nil {
+			w.err = err
+			return err
+		}
+	}
+
+	if w.record%every == 0 {
+		putEntry(w.b[:], Entry{Record: w.record, Offset: off})
+
+		if _, err := w.index.Write(w.b[:]); err != nil {
+			w.err = err
+			return err
+		}
+	}
+
+	w.record++
+	return nil
+}
+
+func putEntry(b []byte, e Entry) {
+	binary.BigEndian.PutUint64(b[:8], uint64(e.Record))
+	binary.BigEndian.PutUint64(b[8:], uint64(e.Offset))
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countWriter) Write(b []byte) (n int, err error) {
+	n, err = w.w.Write(b)
+	w.n += int64(n)
+	return
+}
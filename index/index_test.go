@@ -0,0 +1,136 @@
+package index
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/cbor"
+	"github.com/segmentio/objconv/json"
+)
+
+func TestWriterSeek(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec objconv.Codec
+		sep   []byte
+	}{
+		{"json", json.Codec, []byte("\n")},
+		{"cbor", cbor.Codec, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := &bytes.Buffer{}
+			file := &bytes.Buffer{}
+
+			w := NewWriter(data, file, test.codec)
+			w.Every = 10
+			w.Separator = test.sep
+
+			for i := 0; i != 95; i++ {
+				if err := w.Encode(map[string]int{"i": i}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			x, err := Read(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(x) != 10 {
+				t.Fatal("bad number of sync points:", len(x))
+			}
+
+			built, err := Build(bytes.NewReader(data.Bytes()), test.codec, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(x, built) {
+				t.Errorf("%v != %v", x, built)
+			}
+
+			for _, record := range []int64{0, 9, 10, 42, 94} {
+				d, err := x.Seek(bytes.NewReader(data.Bytes()), test.codec, record)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var v struct {
+					I int64 `objconv:"i"`
+				}
+				if err := d.Decode(&v); err != nil {
+					t.Fatal(err)
+				}
+
+				if v.I != record {
+					t.Errorf("bad record: %d != %d", record, v.I)
+				}
+			}
+
+			if _, err := x.Seek(bytes.NewReader(data.Bytes()), test.codec, 96); err == nil {
+				t.Error("no error returned when seeking past the end of the data file")
+			}
+		})
+	}
+}
+
+func TestWriterEncodeError(t *testing.T) {
+	data := &bytes.Buffer{}
+	file := &bytes.Buffer{}
+
+	w := NewWriter(data, file, json.Codec)
+
+	if err := w.Encode(make(chan int)); err == nil {
+		t.Fatal("no error returned when encoding an unsupported value")
+	}
+
+	// The index must not reference the record which failed to be encoded.
+	if file.Len() != 0 {
+		t.Errorf("%d bytes were written to the index", file.Len())
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	b := &bytes.Buffer{}
+	x := Index{{Record: 0, Offset: 0}, {Record: 10, Offset: 123}}
+
+	if _, err := x.WriteTo(b); err != nil {
+		t.Fatal(err)
+	}
+
+	b.Write([]byte{1, 2, 3})
+
+	y, err := Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(x, y) {
+		t.Errorf("%v != %v", x, y)
+	}
+}
+
+func TestFind(t *testing.T) {
+	x := Index{{Record: 0, Offset: 0}, {Record: 10, Offset: 100}, {Record: 20, Offset: 200}}
+
+	tests := []struct {
+		record int64
+		entry  Entry
+	}{
+		{0, Entry{0, 0}},
+		{9, Entry{0, 0}},
+		{10, Entry{10, 100}},
+		{15, Entry{10, 100}},
+		{1000, Entry{20, 200}},
+	}
+
+	for _, test := range tests {
+		if e := x.Find(test.record); e != test.entry {
+			t.Errorf("%d: %v != %v", test.record, test.entry, e)
+		}
+	}
+}
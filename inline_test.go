@@ -0,0 +1,161 @@
+package objconv
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+type inlineMeta struct {
+	Version int    `objconv:"version"`
+	Owner   string `objconv:"owner,omitempty"`
+}
+
+type inlineResource struct {
+	ID    string                 `objconv:"id"`
+	Meta  inlineMeta             `objconv:",inline"`
+	User  virtualUser            `objconv:",inline"`
+	Extra map[string]interface{} `objconv:",inline"`
+}
+
+func TestInlineEncode(t *testing.T) {
+	e := NewValueEmitter()
+
+	if err := NewEncoder(e).Encode(inlineResource{
+		ID:    "42",
+		Meta:  inlineMeta{Version: 2},
+		User:  virtualUser{First: "Luke", Last: "Skywalker"},
+		Extra: map[string]interface{}{"color": "blue"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The method fields of virtualUser are inlined as well.
+	if v := e.Value(); !reflect.DeepEqual(v, map[interface{}]interface{}{
+		"id":           "42",
+		"version":      int64(2),
+		"first":        "Luke",
+		"last":         "Skywalker",
+		"display_name": "Luke Skywalker",
+		"initials":     "LS",
+		"color":        "blue",
+	}) {
+		t.Errorf("bad value: %#v", v)
+	}
+}
+
+func TestInlineDecode(t *testing.T) {
+	input := map[string]interface{}{
+		"id":      "42",
+		"version": 3,
+		"owner":   "han",
+		"first":   "Leia",
+		"color":   "red",
+		"size":    []interface{}{1, 2},
+	}
+
+	expect := inlineResource{
+		ID:    "42",
+		Meta:  inlineMeta{Version: 3, Owner: "han"},
+		User:  virtualUser{First: "Leia"},
+		Extra: map[string]interface{}{"color": "red", "size": []interface{}{int64(1), int64(2)}},
+	}
+
+	for _, d := range []Decoder{
+		{Parser: NewValueParser(input)},
+		{Parser: NewValueParser(input), DisallowUnknownFields: true},
+	} {
+		var v inlineResource
+
+		if err := d.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(v, expect) {
+			t.Errorf("bad value:\n%#v\n%#v", expect, v)
+		}
+	}
+}
+
+func TestInlineValueParser(t *testing.T) {
+	var m map[string]interface{}
+
+	if err := NewDecoder(NewValueParser(inlineResource{
+		ID:    "1",
+		Extra: map[string]interface{}{"color": "blue"},
+	})).Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(m, map[string]interface{}{
+		"id":           "1",
+		"version":      int64(0),
+		"first":        "",
+		"last":         "",
+		"display_name": " ",
+		"color":        "blue",
+	}) {
+		t.Errorf("bad value: %#v", m)
+	}
+}
+
+func TestInlineErrors(t *testing.T) {
+	type conflict struct {
+		Version int        `objconv:"version"`
+		Meta    inlineMeta `objconv:",inline"`
+	}
+
+	type maps struct {
+		A map[string]int `objconv:",inline"`
+		B map[string]int `objconv:",inline"`
+	}
+
+	type scalar struct {
+		A int `objconv:",inline"`
+	}
+
+	type keys struct {
+		A map[int]int `objconv:",inline"`
+	}
+
+	type unexported struct {
+		meta inlineMeta `objconv:",inline,export"`
+	}
+
+	type nested struct {
+		Scalar scalar `objconv:",inline"`
+	}
+
+	for _, v := range []interface{}{
+		conflict{},
+		maps{},
+		scalar{},
+		keys{},
+		unexported{},
+		nested{},
+	} {
+		t.Run(reflect.TypeOf(v).Name(), func(t *testing.T) {
+			if err := NewEncoder(NewValueEmitter()).Encode(v); !errors.Is(err, objutil.ErrType) {
+				t.Errorf("bad encoding error: %v", err)
+			}
+
+			p := reflect.New(reflect.TypeOf(v))
+
+			if err := NewDecoder(NewValueParser(map[string]int{})).Decode(p.Interface()); !errors.Is(err, objutil.ErrType) {
+				t.Errorf("bad decoding error: %v", err)
+			}
+		})
+	}
+}
+
+func TestInlineMapKeyConflict(t *testing.T) {
+	err := NewEncoder(NewValueEmitter()).Encode(inlineResource{
+		Extra: map[string]interface{}{"version": 1},
+	})
+
+	if !errors.Is(err, objutil.ErrType) {
+		t.Errorf("bad error: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package objconv
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// A Resolver resolves the references found in the string values loaded by the
+// decoders that it is configured on, see Interpolate for the syntax of the
+// references.
+//
+// Resolvers that are shared by multiple decoders must be safe to use
+// concurrently.
+type Resolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// ResolverFunc makes it possible to use basic functions as resolvers.
+type ResolverFunc func(string) (string, error)
+
+// Resolve calls f.
+func (f ResolverFunc) Resolve(ref string) (string, error) { return f(ref) }
+
+var (
+	// EnvResolver resolves references to the values of environment variables,
+	// referencing a variable which isn't set is an error.
+	EnvResolver Resolver = ResolverFunc(resolveEnv)
+
+	// FileResolver resolves references to the content of files, without the
+	// trailing line break, which is convenient to load secrets mounted in
+	// containers.
+	FileResolver Resolver = ResolverFunc(resolveFile)
+
+	// DefaultResolver resolves references to environment variables, or to
+	// files when they are prefixed with "file:", like ${file:/run/secrets/db}.
+	DefaultResolver Resolver = SchemeResolver{
+		"":     EnvResolver,
+		"env":  EnvResolver,
+		"file": FileResolver,
+	}
+)
+
+// SchemeResolver dispatches references to resolvers based on their scheme,
+// which is the part of the reference up to the first colon, the resolvers are
+// given the rest of the reference. References without a scheme are given to
+// the resolver registered for the empty scheme.
+type SchemeResolver map[string]Resolver
+
+// Resolve satisfies the Resolver interface.
+func (m SchemeResolver) Resolve(ref string) (string, error) {
+	scheme, name := "", ref
+
+	if i := strings.IndexByte(ref, ':'); i >= 0 {
+		scheme, name = ref[:i], ref[i+1:]
+	}
+
+	r := m[scheme]
+
+	if r == nil {
+		return "", fmt.Errorf("objconv: no resolver for references of scheme %q", scheme)
+	}
+
+	return r.Resolve(name)
+}
+
+// Interpolate returns s with the references of the form ${ref} replaced by
+// their resolution by r, "$${" is written as a literal "${".
+func Interpolate(s string, r Resolver) (string, error) {
+	i := strings.Index(s, "${")
+	if i < 0 {
+		return s, nil
+	}
+
+	b := make([]byte, 0, len(s))
+
+	for i >= 0 {
+		if i != 0 && s[i-1] == '$' {
+			b = append(b, s[:i-1]...)
+			b = append(b, "${"...)
+			s = s[i+2:]
+		} else {
+			j := strings.IndexByte(s[i:], '}')
+			if j < 0 {
+				return "", objutil.Errorf(objutil.ErrSyntax, "objconv: unterminated reference in %q", s)
+			}
+
+			v, err := r.Resolve(s[i+2 : i+j])
+			if err != nil {
+				return "", err
+			}
+
+			b = append(b, s[:i]...)
+			b = append(b, v...)
+			s = s[i+j+1:]
+		}
+
+		i = strings.Index(s, "${")
+	}
+
+	return string(append(b, s...)), nil
+}
+
+func (d Decoder) interpolate(b []byte) ([]byte, error) {
+	s, err := Interpolate(string(b), d.Resolver)
+	return []byte(s), err
+}
+
+func resolveEnv(name string) (string, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("objconv: the environment variable %s is not set", name)
+	}
+	return v, nil
+}
+
+func resolveFile(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimSuffix(string(b), "\n")
+	return strings.TrimSuffix(s, "\r"), nil
+}
@@ -0,0 +1,118 @@
+package objconv
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	r := SchemeResolver{
+		"": ResolverFunc(func(name string) (string, error) {
+			if name == "missing" {
+				return "", errors.New("missing")
+			}
+			return "<" + name + ">", nil
+		}),
+	}
+
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"", ""},
+		{"hello", "hello"},
+		{"${A}", "<A>"},
+		{"a${A}b${B}c", "a<A>b<B>c"},
+		{"$${A}", "${A}"},
+		{"$A}", "$A}"},
+	}
+
+	for _, test := range tests {
+		if s, err := Interpolate(test.in, r); err != nil || s != test.out {
+			t.Errorf("%q: bad result: %q (%v)", test.in, s, err)
+		}
+	}
+
+	for _, s := range []string{"${A", "${missing}", "${file:/}"} {
+		if _, err := Interpolate(s, r); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+
+	if _, err := Interpolate("a${b", r); !errors.Is(err, ErrSyntax) {
+		t.Errorf("bad error: %v", err)
+	}
+}
+
+func TestDefaultResolver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+
+	if err := ioutil.WriteFile(path, []byte("hunter2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("OBJCONV_TEST_HOST", "localhost")
+	defer os.Unsetenv("OBJCONV_TEST_HOST")
+
+	s, err := Interpolate("${OBJCONV_TEST_HOST}:${env:OBJCONV_TEST_HOST}:${file:"+path+"}", DefaultResolver)
+
+	if err != nil || s != "localhost:localhost:hunter2" {
+		t.Errorf("bad result: %q (%v)", s, err)
+	}
+
+	if _, err := Interpolate("${OBJCONV_TEST_UNSET}", DefaultResolver); err == nil {
+		t.Error("expected an error for an unset variable")
+	}
+}
+
+func TestDecoderResolver(t *testing.T) {
+	type config struct {
+		Host   string
+		Tags   []string
+		Labels map[string]string
+		Extra  interface{}
+		Port   int
+	}
+
+	r := ResolverFunc(func(name string) (string, error) { return "<" + name + ">", nil })
+
+	in := map[string]interface{}{
+		"Host":   "${host}",
+		"Tags":   []interface{}{"${a}", "b"},
+		"Labels": map[string]interface{}{"${key}": "${value}"},
+		"Extra":  "${extra}",
+		"Port":   8080,
+	}
+
+	var c config
+
+	if err := (Decoder{Parser: NewValueParser(in), Resolver: r}).Decode(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := config{
+		Host:   "<host>",
+		Tags:   []string{"<a>", "b"},
+		Labels: map[string]string{"${key}": "<value>"},
+		Extra:  "<extra>",
+		Port:   8080,
+	}
+
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("bad value: %#v", c)
+	}
+
+	var m map[interface{}]interface{}
+
+	if err := (Decoder{Parser: NewValueParser(map[string]string{"${k}": "${v}"}), Resolver: r}).Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(m, map[interface{}]interface{}{"${k}": "<v>"}) {
+		t.Errorf("bad value: %#v", m)
+	}
+}
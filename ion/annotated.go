@@ -0,0 +1,46 @@
+package ion
+
+import "github.com/segmentio/objconv"
+
+// Annotated wraps values of type T with their Ion annotations, which are the
+// symbols written before values, like the type names of QLDB exports.
+//
+// Annotations are written by Ion emitters and dropped by emitters of other
+// formats. When decoding, the annotations of the value are loaded when the
+// input is Ion and set to nil otherwise. Annotations of values decoded into
+// destinations which aren't Annotated are ignored.
+type Annotated[T any] struct {
+	Annotations []string
+	Value       T
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (a Annotated[T]) EncodeValue(e objconv.Encoder) error {
+	if x, ok := e.Emitter.(annotationEmitter); ok && len(a.Annotations) != 0 {
+		if err := x.EmitAnnotations(a.Annotations); err != nil {
+			return err
+		}
+	}
+	return e.Encode(a.Value)
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (a *Annotated[T]) DecodeValue(d objconv.Decoder) (err error) {
+	a.Annotations = nil
+
+	if x, ok := d.Parser.(annotationParser); ok {
+		if a.Annotations, err = x.ParseAnnotations(); err != nil {
+			return
+		}
+	}
+
+	return d.Decode(&a.Value)
+}
+
+type annotationEmitter interface {
+	EmitAnnotations([]string) error
+}
+
+type annotationParser interface {
+	ParseAnnotations() ([]string, error)
+}
@@ -0,0 +1,56 @@
+package ion
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/segmentio/objconv"
+)
+
+// NewDecoder returns a new decoder that parses values from r, the input may be
+// in the text or binary form of Ion.
+func NewDecoder(r io.Reader) *objconv.Decoder {
+	return objconv.NewDecoder(NewParser(r))
+}
+
+// NewStreamDecoder returns a new stream decoder that parses values from r, the
+// input may be in the text or binary form of Ion.
+//
+// The decoder reads the values of a top-level list, like the streams written by
+// NewStreamEncoder. Ion streams which are sequences of top-level values, like
+// QLDB exports, are decoded when the Sequence option of the decoder is enabled.
+func NewStreamDecoder(r io.Reader) *objconv.StreamDecoder {
+	return objconv.NewStreamDecoder(NewParser(r))
+}
+
+// Unmarshal decodes an Ion representation of v from b, which may be in the text
+// or binary form. An error is returned if b has data after the value.
+func Unmarshal(b []byte, v interface{}) error {
+	u := unmarshalerPool.Get().(*unmarshaler)
+	u.reset(b)
+
+	err := (objconv.Decoder{Parser: u, DisallowTrailingData: true}).Decode(v)
+
+	u.reset(nil)
+	unmarshalerPool.Put(u)
+	return err
+}
+
+var unmarshalerPool = sync.Pool{
+	New: func() interface{} { return newUnmarshaler() },
+}
+
+type unmarshaler struct {
+	Parser
+	b bytes.Buffer
+}
+
+func newUnmarshaler() *unmarshaler {
+	return &unmarshaler{}
+}
+
+func (u *unmarshaler) reset(b []byte) {
+	u.b = *bytes.NewBuffer(b)
+	u.Reset(&u.b)
+}
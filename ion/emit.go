@@ -0,0 +1,380 @@
+package ion
+
+import (
+	"io"
+	"math"
+	"time"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Emitter implements an emitter of the binary form of Ion that satisfies the
+// objconv.Emitter interface.
+//
+// Ion values start with their length, so the emitter builds each top-level
+// value in memory and writes it to the underlying writer once it is complete.
+// Struct field names and annotations are symbols, the symbols which appear in
+// a value are written in a local symbol table which precedes it.
+type Emitter struct {
+	w io.Writer
+
+	// The top-level value being built, and the buffer used to prefix it with
+	// the version marker and symbol tables.
+	b []byte
+	t []byte
+
+	// Symbols of the stream, n is the number of symbols which were written in
+	// symbol tables already.
+	symbols symbolTable
+	n       int
+	started bool
+
+	// Annotations of the next value, set by EmitAnnotations.
+	annotations []string
+
+	// Set when the next value is a struct field name.
+	key bool
+
+	// This stack is used to keep track of the containers and annotation
+	// wrappers being written. The sback array is the initial backend array for
+	// the stack.
+	stack []emitFrame
+	sback [16]emitFrame
+}
+
+type emitFrame struct {
+	start int  // offset in b of the content of the container
+	code  byte // type code of the container
+}
+
+func NewEmitter(w io.Writer) *Emitter {
+	e := &Emitter{}
+	e.stack = e.sback[:0]
+	e.Reset(w)
+	return e
+}
+
+// Reset configures the emitter to write to w, the next value is preceded by a
+// version marker and the symbol table is cleared.
+func (e *Emitter) Reset(w io.Writer) {
+	e.w = w
+	e.b = e.b[:0]
+	e.symbols.reset()
+	e.n = len(e.symbols.symbols)
+	e.started = false
+	e.annotations = e.annotations[:0]
+	e.key = false
+	e.stack = e.stack[:0]
+}
+
+// EmitAnnotations sets the annotations of the next value, it is called when
+// values of type Annotated are encoded.
+func (e *Emitter) EmitAnnotations(annotations []string) (err error) {
+	if e.key {
+		return objutil.Errorf(objutil.ErrType, "objconv/ion: struct field names cannot be annotated")
+	}
+	e.annotations = append(e.annotations[:0], annotations...)
+	return
+}
+
+func (e *Emitter) EmitNil() (err error) {
+	if err = e.begin("a nil value"); err == nil {
+		e.b = append(e.b, typeNull<<4|lenNull)
+		err = e.end()
+	}
+	return
+}
+
+func (e *Emitter) EmitBool(v bool) (err error) {
+	if err = e.begin("a boolean"); err == nil {
+		if v {
+			e.b = append(e.b, typeBool<<4|1)
+		} else {
+			e.b = append(e.b, typeBool<<4)
+		}
+		err = e.end()
+	}
+	return
+}
+
+func (e *Emitter) EmitInt(v int64, _ int) (err error) {
+	if v < 0 {
+		return e.emitInt(typeNegInt, uint64(-v))
+	}
+	return e.emitInt(typePosInt, uint64(v))
+}
+
+func (e *Emitter) EmitUint(v uint64, _ int) (err error) {
+	return e.emitInt(typePosInt, v)
+}
+
+func (e *Emitter) emitInt(code byte, v uint64) (err error) {
+	if err = e.begin("an integer"); err == nil {
+		i := len(e.b)
+		e.b = appendUInt(append(e.b, 0), v)
+		e.b[i] = code<<4 | byte(len(e.b)-(i+1))
+		err = e.end()
+	}
+	return
+}
+
+// EmitFloat writes v as a 32 bits float if bitSize is 32 and the conversion
+// doesn't lose precision, positive zero is represented by no bytes.
+func (e *Emitter) EmitFloat(v float64, bitSize int) (err error) {
+	if err = e.begin("a floating point number"); err == nil {
+		switch {
+		case v == 0 && !math.Signbit(v):
+			e.b = append(e.b, typeFloat<<4)
+		case bitSize == 32 && (float64(float32(v)) == v || math.IsNaN(v)):
+			e.b = append(e.b, typeFloat<<4|4)
+			e.b = appendUint32(e.b, math.Float32bits(float32(v)))
+		default:
+			e.b = append(e.b, typeFloat<<4|8)
+			e.b = appendUint64(e.b, math.Float64bits(v))
+		}
+		err = e.end()
+	}
+	return
+}
+
+func (e *Emitter) EmitString(v string) (err error) {
+	if e.key {
+		return e.emitKey(v)
+	}
+
+	if err = e.begin("a string"); err == nil {
+		e.b = appendHeader(e.b, typeString, len(v))
+		e.b = append(e.b, v...)
+		err = e.end()
+	}
+	return
+}
+
+// EmitBytes writes v as a blob, or as a field name if it is emitted as struct
+// key.
+func (e *Emitter) EmitBytes(v []byte) (err error) {
+	if e.key {
+		return e.emitKey(string(v))
+	}
+
+	if err = e.begin("a byte slice"); err == nil {
+		e.b = appendHeader(e.b, typeBlob, len(v))
+		e.b = append(e.b, v...)
+		err = e.end()
+	}
+	return
+}
+
+// EmitTime writes v as a timestamp with a precision of one nanosecond, the
+// offset of the time zone is kept in minutes.
+func (e *Emitter) EmitTime(v time.Time) (err error) {
+	if v.Year() < 1 {
+		return objutil.Errorf(objutil.ErrRange, "objconv/ion: the year of timestamps must be positive: %s", v)
+	}
+
+	if err = e.begin("a time"); err == nil {
+		_, offset := v.Zone()
+		u := v.UTC()
+
+		i := len(e.b)
+		e.b = appendVarInt(e.b, int64(offset/60))
+		e.b = appendVarUInt(e.b, uint64(u.Year()))
+		e.b = appendVarUInt(e.b, uint64(u.Month()))
+		e.b = appendVarUInt(e.b, uint64(u.Day()))
+		e.b = appendVarUInt(e.b, uint64(u.Hour()))
+		e.b = appendVarUInt(e.b, uint64(u.Minute()))
+		e.b = appendVarUInt(e.b, uint64(u.Second()))
+
+		if nsec := u.Nanosecond(); nsec != 0 {
+			e.b = appendVarInt(e.b, -9)
+			e.b = appendInt(e.b, int64(nsec))
+		}
+
+		e.b = insertHeader(e.b, i, typeTimestamp)
+		err = e.end()
+	}
+	return
+}
+
+// EmitDuration writes v as a string since Ion has no duration type.
+func (e *Emitter) EmitDuration(v time.Duration) (err error) {
+	return e.EmitString(string(objutil.AppendDuration(nil, v)))
+}
+
+func (e *Emitter) EmitError(v error) (err error) {
+	return e.EmitString(v.Error())
+}
+
+func (e *Emitter) EmitArrayBegin(_ int) (err error) {
+	if err = e.begin("an array"); err == nil {
+		e.stack = append(e.stack, emitFrame{start: len(e.b), code: typeList})
+	}
+	return
+}
+
+func (e *Emitter) EmitArrayEnd() (err error) {
+	e.pop()
+	return e.end()
+}
+
+func (e *Emitter) EmitArrayNext() (err error) {
+	return
+}
+
+func (e *Emitter) EmitMapBegin(_ int) (err error) {
+	if err = e.begin("a map"); err == nil {
+		e.stack = append(e.stack, emitFrame{start: len(e.b), code: typeStruct})
+		e.key = true
+	}
+	return
+}
+
+func (e *Emitter) EmitMapEnd() (err error) {
+	e.key = false
+	e.pop()
+	return e.end()
+}
+
+func (e *Emitter) EmitMapValue() (err error) {
+	return
+}
+
+func (e *Emitter) EmitMapNext() (err error) {
+	e.key = true
+	return
+}
+
+// emitKey writes the symbol identifier of the field name k.
+func (e *Emitter) emitKey(k string) (err error) {
+	id, _ := e.symbols.intern(k)
+	e.b = appendVarUInt(e.b, id)
+	e.key = false
+	return
+}
+
+// begin is called before a value is written, it returns an error if the value
+// was expected to be a field name, and starts the annotation wrapper of the
+// value if it has annotations.
+func (e *Emitter) begin(what string) error {
+	if e.key {
+		return objutil.Errorf(objutil.ErrType, "objconv/ion: %s cannot be used as struct field name", what)
+	}
+
+	if len(e.annotations) != 0 {
+		e.stack = append(e.stack, emitFrame{start: len(e.b), code: typeAnnotation})
+
+		i := len(e.b)
+
+		for _, a := range e.annotations {
+			id, _ := e.symbols.intern(a)
+			e.b = appendVarUInt(e.b, id)
+		}
+
+		var a [10]byte
+		e.b = insert(e.b, i, appendVarUInt(a[:0], uint64(len(e.b)-i)))
+		e.annotations = e.annotations[:0]
+	}
+
+	return nil
+}
+
+// end is called after a value was written, it completes the annotation
+// wrapper of the value, and writes the value if it was at the top level.
+func (e *Emitter) end() error {
+	if i := len(e.stack) - 1; i >= 0 && e.stack[i].code == typeAnnotation {
+		e.pop()
+	}
+
+	if len(e.stack) != 0 {
+		return nil
+	}
+
+	t := e.t[:0]
+
+	if !e.started {
+		t = append(t, bvm[:]...)
+		e.started = true
+	}
+
+	if syms := e.symbols.symbols; len(syms) > e.n {
+		t = appendSymbolTable(t, syms[e.n:], e.n != len(systemSymbols))
+		e.n = len(syms)
+	}
+
+	e.t = t
+
+	if len(t) != 0 {
+		if _, err := e.w.Write(t); err != nil {
+			return err
+		}
+	}
+
+	_, err := e.w.Write(e.b)
+	e.b = e.b[:0]
+	return err
+}
+
+// pop completes the container or annotation wrapper on top of the stack.
+func (e *Emitter) pop() {
+	i := len(e.stack) - 1
+	f := e.stack[i]
+	e.stack = e.stack[:i]
+	e.b = insertHeader(e.b, f.start, f.code)
+}
+
+// appendSymbolTable appends a local symbol table declaring symbols, which are
+// appended to the current table of the stream if imports is true.
+func appendSymbolTable(b []byte, symbols []string, imports bool) []byte {
+	i := len(b)
+	b = append(b, 0x81, 0x80|sidIonSymbolTable) // $ion_symbol_table::
+
+	j := len(b)
+	if imports {
+		b = append(b, 0x80|sidImports, typeSymbol<<4|1, sidIonSymbolTable)
+	}
+	b = append(b, 0x80|sidSymbols)
+
+	k := len(b)
+	for _, s := range symbols {
+		b = appendHeader(b, typeString, len(s))
+		b = append(b, s...)
+	}
+
+	b = insertHeader(b, k, typeList)
+	b = insertHeader(b, j, typeStruct)
+	b = insertHeader(b, i, typeAnnotation)
+	return b
+}
+
+// appendHeader appends the type descriptor and length of a value of type code
+// with n bytes of content.
+func appendHeader(b []byte, code byte, n int) []byte {
+	if n < int(lenVarUInt) {
+		return append(b, code<<4|byte(n))
+	}
+	return appendVarUInt(append(b, code<<4|lenVarUInt), uint64(n))
+}
+
+// insertHeader inserts the header of a value of type code at offset i of b,
+// the content of the value is b[i:].
+func insertHeader(b []byte, i int, code byte) []byte {
+	var a [11]byte
+	h := appendHeader(a[:0], code, len(b)-i)
+	return insert(b, i, h)
+}
+
+func insert(b []byte, i int, h []byte) []byte {
+	n := len(b)
+	b = append(b, h...)
+	copy(b[i+len(h):], b[i:n])
+	copy(b[i:], h)
+	return b
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(v>>32)), uint32(v))
+}
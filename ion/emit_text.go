@@ -0,0 +1,532 @@
+package ion
+
+import (
+	"encoding/base64"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// TextEmitter implements an emitter of the text form of Ion that satisfies the
+// objconv.Emitter interface.
+//
+// Top-level values are separated by new lines, struct field names and
+// annotations are written as identifiers when they don't need to be quoted.
+type TextEmitter struct {
+	w io.Writer
+	s []byte
+	a [128]byte
+
+	// The emitter writes to this buffer, which gets flushed to the underlying
+	// writer when the encoder has completed writing a value.
+	buf objutil.BufferedWriter
+
+	// Annotations of the next value, set by EmitAnnotations, and the buffer
+	// they are formatted in.
+	annotations []string
+	t           []byte
+
+	// Nesting level of the value being written, and whether the next value is
+	// a struct field name.
+	depth int
+	key   bool
+
+	// Layout of the output, values are written on a single line without
+	// spaces when tab is empty.
+	pre   []byte
+	tab   []byte
+	space []byte
+	width int
+	lens  []int // lengths of the lists and structs being written
+	ind   int   // number of non-empty lists and structs being written
+	line  lineBuffer
+}
+
+func NewTextEmitter(w io.Writer) *TextEmitter {
+	e := &TextEmitter{}
+	e.s = e.a[:0]
+	e.Reset(w)
+	return e
+}
+
+// NewTextFormatEmitter returns a new emitter that writes the text form of Ion
+// values to w in the layout configured by f.
+func NewTextFormatEmitter(w io.Writer, f objconv.Format) *TextEmitter {
+	e := NewTextEmitter(w)
+	e.pre = []byte(f.Prefix)
+	e.tab = []byte(f.Indent)
+	e.space = []byte(f.Space)
+	e.width = f.Width
+	return e
+}
+
+// FormatEmitter satisfies the objconv.FormatEmitter interface.
+func (e *TextEmitter) FormatEmitter(f objconv.Format) objconv.Emitter {
+	return NewTextFormatEmitter(e.buf.Writer(), f)
+}
+
+func (e *TextEmitter) Reset(w io.Writer) {
+	e.buf.Reset(w)
+	e.w = &e.buf
+	e.annotations = e.annotations[:0]
+	e.depth = 0
+	e.key = false
+	e.lens = e.lens[:0]
+	e.ind = 0
+	e.line.reset()
+}
+
+// Flush writes the buffered output of the emitter to its underlying writer.
+func (e *TextEmitter) Flush() error {
+	return e.buf.Flush()
+}
+
+func (e *TextEmitter) TextEmitter() bool {
+	return true
+}
+
+// EmitAnnotations sets the annotations of the next value, it is called when
+// values of type Annotated are encoded.
+func (e *TextEmitter) EmitAnnotations(annotations []string) (err error) {
+	if e.key {
+		return objutil.Errorf(objutil.ErrType, "objconv/ion: struct field names cannot be annotated")
+	}
+	e.annotations = append(e.annotations[:0], annotations...)
+	return
+}
+
+func (e *TextEmitter) EmitNil() error {
+	return e.write("a nil value", append(e.s[:0], "null"...))
+}
+
+func (e *TextEmitter) EmitBool(v bool) error {
+	if v {
+		return e.write("a boolean", append(e.s[:0], "true"...))
+	}
+	return e.write("a boolean", append(e.s[:0], "false"...))
+}
+
+func (e *TextEmitter) EmitInt(v int64, _ int) error {
+	return e.write("an integer", strconv.AppendInt(e.s[:0], v, 10))
+}
+
+func (e *TextEmitter) EmitUint(v uint64, _ int) error {
+	return e.write("an integer", strconv.AppendUint(e.s[:0], v, 10))
+}
+
+// EmitFloat writes v with an exponent, Ion numbers without exponents are
+// decimals.
+func (e *TextEmitter) EmitFloat(v float64, bitSize int) error {
+	s := e.s[:0]
+
+	switch {
+	case math.IsNaN(v):
+		s = append(s, "nan"...)
+
+	case math.IsInf(v, +1):
+		s = append(s, "+inf"...)
+
+	case math.IsInf(v, -1):
+		s = append(s, "-inf"...)
+
+	default:
+		s = strconv.AppendFloat(s, v, 'g', -1, bitSize)
+
+		hasExponent := false
+		for _, c := range s {
+			if c == 'e' {
+				hasExponent = true
+			}
+		}
+		if !hasExponent {
+			s = append(s, "e0"...)
+		}
+	}
+
+	return e.write("a floating point number", s)
+}
+
+func (e *TextEmitter) EmitString(v string) error {
+	if e.key {
+		return e.emitKey(v)
+	}
+	return e.write("a string", appendQuoted(e.s[:0], v, '"'))
+}
+
+// EmitBytes writes v as a blob, or as a field name if it is emitted as struct
+// key.
+func (e *TextEmitter) EmitBytes(v []byte) error {
+	if e.key {
+		return e.emitKey(string(v))
+	}
+
+	s := append(e.s[:0], "{{"...)
+	n := len(s)
+	s = append(s, make([]byte, base64.StdEncoding.EncodedLen(len(v)))...)
+	base64.StdEncoding.Encode(s[n:], v)
+	s = append(s, "}}"...)
+
+	return e.write("a byte slice", s)
+}
+
+// EmitTime writes v as a timestamp with a precision of one nanosecond, the
+// year must be in the range of four digits years.
+func (e *TextEmitter) EmitTime(v time.Time) error {
+	if y := v.Year(); y < 1 || y > 9999 {
+		return objutil.Errorf(objutil.ErrRange, "objconv/ion: the year of timestamps must be between 1 and 9999: %s", v)
+	}
+	return e.write("a time", v.AppendFormat(e.s[:0], time.RFC3339Nano))
+}
+
+// EmitDuration writes v as a string since Ion has no duration type.
+func (e *TextEmitter) EmitDuration(v time.Duration) error {
+	return e.EmitString(string(objutil.AppendDuration(nil, v)))
+}
+
+func (e *TextEmitter) EmitError(v error) error {
+	return e.EmitString(v.Error())
+}
+
+func (e *TextEmitter) EmitArrayBegin(n int) (err error) {
+	if err = e.open(n); err != nil {
+		return
+	}
+	if err = e.begin("an array"); err != nil {
+		return
+	}
+	if _, err = e.w.Write(append(e.s[:0], '[')); err != nil {
+		return
+	}
+	e.depth++
+	if e.push(n) != 0 {
+		if e.width > 0 && len(e.tab) != 0 {
+			// Lists are buffered until they are known to fit on a line.
+			e.line.w, e.line.on, e.line.depth = e.w, true, len(e.lens)
+			e.line.elems = append(e.line.elems, 0)
+			e.w = &e.line
+			return
+		}
+		err = e.indent()
+	}
+	return
+}
+
+func (e *TextEmitter) EmitArrayEnd() (err error) {
+	e.depth--
+	if e.line.on && e.line.depth == len(e.lens) {
+		if e.fits() {
+			e.pop()
+			if err = e.inline(); err != nil {
+				return
+			}
+			return e.end(append(e.s[:0], ']'))
+		}
+		if err = e.wrap(); err != nil {
+			return
+		}
+	}
+	if e.pop() != 0 {
+		if err = e.indent(); err != nil {
+			return
+		}
+	}
+	return e.end(append(e.s[:0], ']'))
+}
+
+func (e *TextEmitter) EmitArrayNext() (err error) {
+	if _, err = e.w.Write(append(e.s[:0], ',')); err != nil {
+		return
+	}
+	if e.line.on {
+		e.line.Write(e.space)
+		e.line.elems = append(e.line.elems, len(e.line.b))
+		if !e.fits() {
+			err = e.wrap()
+		}
+		return
+	}
+	return e.next()
+}
+
+func (e *TextEmitter) EmitMapBegin(n int) (err error) {
+	if err = e.open(n); err != nil {
+		return
+	}
+	if err = e.begin("a map"); err != nil {
+		return
+	}
+	if _, err = e.w.Write(append(e.s[:0], '{')); err != nil {
+		return
+	}
+	e.depth++
+	e.key = true
+	if e.push(n) != 0 {
+		err = e.indent()
+	}
+	return
+}
+
+func (e *TextEmitter) EmitMapEnd() (err error) {
+	e.depth--
+	e.key = false
+	if e.pop() != 0 {
+		if err = e.indent(); err != nil {
+			return
+		}
+	}
+	return e.end(append(e.s[:0], '}'))
+}
+
+func (e *TextEmitter) EmitMapValue() (err error) {
+	if _, err = e.w.Write(append(e.s[:0], ':')); err == nil {
+		_, err = e.w.Write(e.space)
+	}
+	return
+}
+
+func (e *TextEmitter) EmitMapNext() (err error) {
+	e.key = true
+	if _, err = e.w.Write(append(e.s[:0], ',')); err != nil {
+		return
+	}
+	return e.next()
+}
+
+// open is called before lists and structs of length n are written, the lists
+// that contain non-empty lists or structs are never written on a single line.
+func (e *TextEmitter) open(n int) error {
+	if e.line.on && n != 0 {
+		return e.wrap()
+	}
+	return nil
+}
+
+// next is called after the separators of list elements and struct fields.
+func (e *TextEmitter) next() (err error) {
+	if len(e.tab) != 0 {
+		return e.indent()
+	}
+	_, err = e.w.Write(e.space)
+	return
+}
+
+func (e *TextEmitter) fits() bool {
+	return len("[")+len(e.line.b)+len("]") <= e.width
+}
+
+// inline writes the buffered list on a single line.
+func (e *TextEmitter) inline() (err error) {
+	e.w = e.line.w
+	_, err = e.w.Write(e.line.b)
+	e.line.reset()
+	return
+}
+
+// wrap writes the elements of the buffered list on separate lines, the emitter
+// then continues writing the list as if it had never been buffered.
+func (e *TextEmitter) wrap() (err error) {
+	l := &e.line
+	e.w = l.w
+	sep := len(",") + len(e.space)
+
+	for i, off := range l.elems {
+		end := len(l.b)
+
+		if i+1 < len(l.elems) {
+			end = l.elems[i+1] - sep
+		}
+
+		if i != 0 {
+			if _, err = e.w.Write(append(e.s[:0], ',')); err != nil {
+				break
+			}
+		}
+
+		if err = e.indent(); err != nil {
+			break
+		}
+
+		if _, err = e.w.Write(l.b[off:end]); err != nil {
+			break
+		}
+	}
+
+	l.reset()
+	return
+}
+
+func (e *TextEmitter) indent() (err error) {
+	if len(e.tab) == 0 {
+		return
+	}
+
+	if _, err = e.w.Write(append(e.s[:0], '\n')); err != nil {
+		return
+	}
+
+	if _, err = e.w.Write(e.pre); err != nil {
+		return
+	}
+
+	for n := e.ind; n != 0; n-- {
+		if _, err = e.w.Write(e.tab); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (e *TextEmitter) push(n int) int {
+	if n != 0 {
+		e.ind++
+	}
+	e.lens = append(e.lens, n)
+	return n
+}
+
+func (e *TextEmitter) pop() int {
+	i := len(e.lens) - 1
+	n := e.lens[i]
+	e.lens = e.lens[:i]
+	if n != 0 {
+		e.ind--
+	}
+	return n
+}
+
+// lineBuffer holds the output of a list until it is known to fit on a single
+// line, the offsets of its elements are recorded so it can be wrapped when it
+// doesn't.
+type lineBuffer struct {
+	w     io.Writer // writer of the emitter while the list is buffered
+	b     []byte
+	elems []int
+	depth int // depth of the list on the stack of the emitter
+	on    bool
+}
+
+func (l *lineBuffer) Write(b []byte) (int, error) {
+	l.b = append(l.b, b...)
+	return len(b), nil
+}
+
+func (l *lineBuffer) reset() {
+	l.w = nil
+	l.b = l.b[:0]
+	l.elems = l.elems[:0]
+	l.depth = 0
+	l.on = false
+}
+
+func (e *TextEmitter) emitKey(k string) (err error) {
+	e.key = false
+	_, err = e.w.Write(appendSymbol(e.s[:0], k))
+	return
+}
+
+// write writes the scalar value s, preceded by its annotations.
+func (e *TextEmitter) write(what string, s []byte) error {
+	e.s = s[:0] // in case the buffer was reallocated
+
+	if err := e.begin(what); err != nil {
+		return err
+	}
+
+	return e.end(s)
+}
+
+// begin returns an error if the value was expected to be a field name, and
+// writes the annotations of the value.
+func (e *TextEmitter) begin(what string) (err error) {
+	if e.key {
+		return objutil.Errorf(objutil.ErrType, "objconv/ion: %s cannot be used as struct field name", what)
+	}
+
+	if len(e.annotations) != 0 {
+		t := e.t[:0]
+
+		for _, a := range e.annotations {
+			t = appendSymbol(t, a)
+			t = append(t, "::"...)
+		}
+
+		e.t = t
+		e.annotations = e.annotations[:0]
+		_, err = e.w.Write(t)
+	}
+
+	return
+}
+
+// end writes s, which completes a value, and separates top-level values with
+// new lines.
+func (e *TextEmitter) end(s []byte) (err error) {
+	if e.depth == 0 {
+		s = append(s, '\n')
+	}
+	e.s = s[:0]
+	_, err = e.w.Write(s)
+	return
+}
+
+// appendSymbol appends the symbol s, which is quoted unless it is a valid
+// identifier.
+func appendSymbol(b []byte, s string) []byte {
+	switch s {
+	case "", "null", "true", "false", "nan":
+		return appendQuoted(b, s, '\'')
+	}
+
+	if !isIdentStart(s[0]) || (s[0] == '$' && isDigits([]byte(s[1:]))) {
+		return appendQuoted(b, s, '\'')
+	}
+
+	for i := 1; i != len(s); i++ {
+		if !isIdent(s[i]) {
+			return appendQuoted(b, s, '\'')
+		}
+	}
+
+	return append(b, s...)
+}
+
+// appendQuoted appends s delimited by quote, with the characters which cannot
+// appear in quoted strings escaped.
+func appendQuoted(b []byte, s string, quote byte) []byte {
+	const hex = "0123456789abcdef"
+
+	i := 0
+	b = append(b, quote)
+
+	for j := 0; j != len(s); j++ {
+		c := s[j]
+
+		if c >= 0x20 && c != 0x7F && c != '\\' && c != quote {
+			continue
+		}
+
+		b = append(b, s[i:j]...)
+		i = j + 1
+
+		switch c {
+		case '\\', quote:
+			b = append(b, '\\', c)
+		case '\n':
+			b = append(b, '\\', 'n')
+		case '\r':
+			b = append(b, '\\', 'r')
+		case '\t':
+			b = append(b, '\\', 't')
+		default:
+			b = append(b, '\\', 'x', hex[c>>4], hex[c&0xF])
+		}
+	}
+
+	b = append(b, s[i:]...)
+	return append(b, quote)
+}
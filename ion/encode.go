@@ -0,0 +1,129 @@
+package ion
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/segmentio/objconv"
+)
+
+// NewEncoder returns a new encoder that writes values to w in the binary form
+// of Ion.
+func NewEncoder(w io.Writer) *objconv.Encoder {
+	return objconv.NewEncoder(NewEmitter(w))
+}
+
+// NewStreamEncoder returns a new stream encoder that writes values to w in the
+// binary form of Ion, the values of the stream are written in a list.
+func NewStreamEncoder(w io.Writer) *objconv.StreamEncoder {
+	return objconv.NewStreamEncoder(NewEmitter(w))
+}
+
+// NewTextEncoder returns a new encoder that writes values to w in the text
+// form of Ion.
+func NewTextEncoder(w io.Writer) *objconv.Encoder {
+	return objconv.NewEncoder(NewTextEmitter(w))
+}
+
+// NewTextStreamEncoder returns a new stream encoder that writes values to w in
+// the text form of Ion, the values of the stream are written in a list.
+func NewTextStreamEncoder(w io.Writer) *objconv.StreamEncoder {
+	return objconv.NewStreamEncoder(NewTextEmitter(w))
+}
+
+// Marshal writes the binary Ion representation of v to a byte slice returned
+// in b.
+func Marshal(v interface{}) (b []byte, err error) {
+	m := marshalerPool.Get().(*marshaler)
+	m.b.Truncate(0)
+	m.Reset(&m.b)
+
+	if err = (objconv.Encoder{Emitter: m}).Encode(v); err == nil {
+		b = make([]byte, m.b.Len())
+		copy(b, m.b.Bytes())
+	}
+
+	marshalerPool.Put(m)
+	return
+}
+
+// Append appends the binary Ion representation of v to dst and returns the extended
+// slice, dst is returned unchanged if an error occurs. Unlike Marshal, it
+// doesn't allocate memory when dst has enough capacity.
+func Append(dst []byte, v interface{}) (b []byte, err error) {
+	m := marshalerPool.Get().(*marshaler)
+	m.b.Truncate(0)
+	m.Reset(&m.b)
+	b = dst
+
+	if err = (objconv.Encoder{Emitter: m}).Encode(v); err == nil {
+		b = append(dst, m.b.Bytes()...)
+	}
+
+	marshalerPool.Put(m)
+	return
+}
+
+// MarshalText writes the text Ion representation of v to a byte slice returned
+// in b.
+func MarshalText(v interface{}) (b []byte, err error) {
+	m := textMarshalerPool.Get().(*textMarshaler)
+	m.b.Truncate(0)
+	m.Reset(&m.b)
+
+	if err = (objconv.Encoder{Emitter: m}).Encode(v); err == nil {
+		b = make([]byte, m.b.Len())
+		copy(b, m.b.Bytes())
+	}
+
+	textMarshalerPool.Put(m)
+	return
+}
+
+// AppendText appends the text Ion representation of v to dst and returns the extended
+// slice, dst is returned unchanged if an error occurs. Unlike MarshalText, it
+// doesn't allocate memory when dst has enough capacity.
+func AppendText(dst []byte, v interface{}) (b []byte, err error) {
+	m := textMarshalerPool.Get().(*textMarshaler)
+	m.b.Truncate(0)
+	m.Reset(&m.b)
+	b = dst
+
+	if err = (objconv.Encoder{Emitter: m}).Encode(v); err == nil {
+		b = append(dst, m.b.Bytes()...)
+	}
+
+	textMarshalerPool.Put(m)
+	return
+}
+
+var marshalerPool = sync.Pool{
+	New: func() interface{} { return newMarshaler() },
+}
+
+type marshaler struct {
+	Emitter
+	b bytes.Buffer
+}
+
+func newMarshaler() *marshaler {
+	m := &marshaler{}
+	m.stack = m.sback[:0]
+	return m
+}
+
+var textMarshalerPool = sync.Pool{
+	New: func() interface{} { return newTextMarshaler() },
+}
+
+type textMarshaler struct {
+	TextEmitter
+	b bytes.Buffer
+}
+
+func newTextMarshaler() *textMarshaler {
+	m := &textMarshaler{}
+	m.s = m.a[:0]
+	return m
+}
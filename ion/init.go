@@ -0,0 +1,28 @@
+package ion
+
+import (
+	"io"
+
+	"github.com/segmentio/objconv"
+)
+
+// Codec for the binary form of Ion, the parser also accepts the text form.
+var Codec = objconv.Codec{
+	NewEmitter: func(w io.Writer) objconv.Emitter { return NewEmitter(w) },
+	NewParser:  func(r io.Reader) objconv.Parser { return NewParser(r) },
+}
+
+// TextCodec for the text form of Ion, the parser also accepts the binary form.
+var TextCodec = objconv.Codec{
+	NewEmitter: func(w io.Writer) objconv.Emitter { return NewTextEmitter(w) },
+	NewParser:  func(r io.Reader) objconv.Parser { return NewParser(r) },
+}
+
+func init() {
+	for _, name := range [...]string{
+		"application/ion",
+		"ion",
+	} {
+		objconv.Register(name, Codec)
+	}
+}
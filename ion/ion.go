@@ -0,0 +1,237 @@
+package ion
+
+import (
+	"github.com/segmentio/objconv/objutil"
+)
+
+// The Binary Version Marker starts Ion binary streams, and resets the symbol
+// table when it appears between top-level values.
+var bvm = [...]byte{0xE0, 0x01, 0x00, 0xEA}
+
+const ( // type codes, the high nibble of type descriptors
+	typeNull       byte = 0x0
+	typeBool       byte = 0x1
+	typePosInt     byte = 0x2
+	typeNegInt     byte = 0x3
+	typeFloat      byte = 0x4
+	typeDecimal    byte = 0x5
+	typeTimestamp  byte = 0x6
+	typeSymbol     byte = 0x7
+	typeString     byte = 0x8
+	typeClob       byte = 0x9
+	typeBlob       byte = 0xA
+	typeList       byte = 0xB
+	typeSexp       byte = 0xC
+	typeStruct     byte = 0xD
+	typeAnnotation byte = 0xE
+	typeReserved   byte = 0xF
+)
+
+const ( // special values of the low nibble of type descriptors
+	lenVarUInt byte = 14 // the length follows the descriptor as a VarUInt
+	lenNull    byte = 15 // the value is a typed null
+)
+
+const ( // symbol identifiers of the system symbol table
+	sidIonSymbolTable = 3
+	sidImports        = 6
+	sidSymbols        = 7
+)
+
+// Symbols of the system symbol table, the first symbol identifier is 1.
+var systemSymbols = [...]string{
+	"$ion",
+	"$ion_1_0",
+	"$ion_symbol_table",
+	"name",
+	"version",
+	"imports",
+	"symbols",
+	"max_id",
+	"$ion_shared_symbol_table",
+}
+
+// symbolTable maps the symbol identifiers of a stream to their text, starting
+// with the system symbols.
+type symbolTable struct {
+	symbols []string
+	ids     map[string]uint64
+}
+
+func (t *symbolTable) reset() {
+	t.symbols = append(t.symbols[:0], systemSymbols[:]...)
+
+	if t.ids == nil {
+		t.ids = make(map[string]uint64)
+	}
+	for k := range t.ids {
+		delete(t.ids, k)
+	}
+	for i, sym := range systemSymbols {
+		t.ids[sym] = uint64(i + 1)
+	}
+}
+
+// lookup returns the text of the symbol id, unknown symbols (the ones imported
+// from shared tables) have an empty text.
+func (t *symbolTable) lookup(id uint64) (string, error) {
+	if id == 0 {
+		return "", nil
+	}
+	if id > uint64(len(t.symbols)) {
+		return "", objutil.Errorf(objutil.ErrSyntax, "objconv/ion: symbol identifier %d is out of the range of the symbol table", id)
+	}
+	return t.symbols[id-1], nil
+}
+
+// intern returns the identifier of the symbol s, the boolean is true if the
+// symbol was added to the table.
+func (t *symbolTable) intern(s string) (uint64, bool) {
+	if len(t.symbols) == 0 {
+		t.reset()
+	}
+	if id, ok := t.ids[s]; ok {
+		return id, false
+	}
+	t.symbols = append(t.symbols, s)
+	id := uint64(len(t.symbols))
+	t.ids[s] = id
+	return id, true
+}
+
+func appendVarUInt(b []byte, v uint64) []byte {
+	n := 1
+	for x := v >> 7; x != 0; x >>= 7 {
+		n++
+	}
+	for i := n - 1; i >= 0; i-- {
+		c := byte(v>>(7*uint(i))) & 0x7F
+		if i == 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+func appendVarInt(b []byte, v int64) []byte {
+	neg := v < 0
+	u := uint64(v)
+	if neg {
+		u = -u
+	}
+
+	n := 1 // the first byte holds 6 bits
+	for x := u >> 6; x != 0; x >>= 7 {
+		n++
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		var c byte
+		if i == n-1 {
+			c = byte(u>>(7*uint(i))) & 0x3F
+			if neg {
+				c |= 0x40
+			}
+		} else {
+			c = byte(u>>(7*uint(i))) & 0x7F
+		}
+		if i == 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+// appendUInt appends the big-endian representation of v without leading
+// zeros, zero is represented by no bytes.
+func appendUInt(b []byte, v uint64) []byte {
+	n := 0
+	for x := v; x != 0; x >>= 8 {
+		n++
+	}
+	for i := n - 1; i >= 0; i-- {
+		b = append(b, byte(v>>(8*uint(i))))
+	}
+	return b
+}
+
+// appendInt appends the sign and magnitude representation of v without
+// leading zeros, the sign is the highest bit of the first byte.
+func appendInt(b []byte, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+
+	neg := v < 0
+	u := uint64(v)
+	if neg {
+		u = -u
+	}
+
+	i := len(b)
+	b = appendUInt(b, u)
+
+	if b[i]&0x80 != 0 {
+		b = append(b, 0)
+		copy(b[i+1:], b[i:])
+		b[i] = 0
+	}
+
+	if neg {
+		b[i] |= 0x80
+	}
+	return b
+}
+
+// readVarUInt decodes a VarUInt from the beginning of b, and returns the number
+// of bytes it was made of, or zero if b doesn't contain a complete VarUInt.
+func readVarUInt(b []byte) (v uint64, n int, err error) {
+	for i, c := range b {
+		if i == 9 {
+			return 0, 0, objutil.Errorf(objutil.ErrRange, "objconv/ion: VarUInt values larger than 63 bits are not supported")
+		}
+		v = v<<7 | uint64(c&0x7F)
+		if c&0x80 != 0 {
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, nil
+}
+
+// readVarInt is like readVarUInt for VarInt values, neg is true for negative
+// values, including negative zero.
+func readVarInt(b []byte) (v int64, neg bool, n int, err error) {
+	var u uint64
+
+	for i, c := range b {
+		if i == 9 {
+			return 0, false, 0, objutil.Errorf(objutil.ErrRange, "objconv/ion: VarInt values larger than 63 bits are not supported")
+		}
+		if i == 0 {
+			neg = c&0x40 != 0
+			u = uint64(c & 0x3F)
+		} else {
+			u = u<<7 | uint64(c&0x7F)
+		}
+		if c&0x80 != 0 {
+			v = int64(u)
+			if neg {
+				v = -v
+			}
+			return v, neg, i + 1, nil
+		}
+	}
+	return 0, false, 0, nil
+}
+
+func readUInt(b []byte) (v uint64, err error) {
+	if len(b) > 8 {
+		return 0, objutil.Errorf(objutil.ErrRange, "objconv/ion: integers of %d bytes overflow 64 bits", len(b))
+	}
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return
+}
@@ -0,0 +1,469 @@
+package ion
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objtests"
+)
+
+func TestCodec(t *testing.T) {
+	objtests.TestCodec(t, Codec)
+}
+
+func TestTextCodec(t *testing.T) {
+	objtests.TestCodec(t, TextCodec)
+}
+
+func TestAppend(t *testing.T) {
+	objtests.TestAppend(t, Codec, Append)
+}
+
+func TestAppendText(t *testing.T) {
+	objtests.TestAppend(t, TextCodec, AppendText)
+}
+
+func BenchmarkCodec(b *testing.B) {
+	objtests.BenchmarkCodec(b, Codec)
+}
+
+func TestStreamCloseWithError(t *testing.T) {
+	objtests.TestStreamCloseWithError(t, Codec)
+	objtests.TestStreamCloseWithError(t, TextCodec)
+}
+
+func TestConformance(t *testing.T) {
+	objtests.TestConformance(t, Codec)
+}
+
+func TestTextConformance(t *testing.T) {
+	objtests.TestConformance(t, TextCodec)
+}
+
+// Values encoded in the binary form, the examples of the Ion specification
+// which have no version marker are prefixed with one.
+var binaryTests = []struct {
+	v interface{}
+	b string
+}{
+	{v: nil, b: "\x0f"},
+	{v: true, b: "\x11"},
+	{v: false, b: "\x10"},
+	{v: int64(0), b: "\x20"},
+	{v: int64(1), b: "\x21\x01"},
+	{v: int64(-300), b: "\x32\x01\x2c"},
+	{v: uint64(math.MaxUint64), b: "\x28\xff\xff\xff\xff\xff\xff\xff\xff"},
+	{v: 0.0, b: "\x40"},
+	{v: 1.5, b: "\x48\x3f\xf8\x00\x00\x00\x00\x00\x00"},
+	{v: "hello", b: "\x85hello"},
+	{v: []byte{1, 2, 3}, b: "\xa3\x01\x02\x03"},
+	{v: []interface{}{int64(1), "A"}, b: "\xb4\x21\x01\x81A"},
+	{v: time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC), b: "\x68\x80\x0f\xd0\x81\x82\x83\x84\x85"},
+	{
+		v: map[interface{}]interface{}{"name": "Ion"},
+		b: "\xd5\x84\x83Ion", // name is a system symbol
+	},
+}
+
+func TestMarshal(t *testing.T) {
+	for _, test := range binaryTests {
+		b, err := Marshal(test.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := string(bvm[:]) + test.b; string(b) != s {
+			t.Errorf("%#v: bad output:\n%q\n%q", test.v, s, b)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	for _, test := range binaryTests {
+		var v interface{}
+
+		if err := Unmarshal([]byte(string(bvm[:])+test.b), &v); err != nil {
+			t.Errorf("%#v: %s", test.v, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(v, test.v) {
+			t.Errorf("%#v: bad value: %#v", test.v, v)
+		}
+	}
+}
+
+func TestUnmarshalBinary(t *testing.T) {
+	tests := []struct {
+		b string
+		v interface{}
+	}{
+		{ // NOP pads are ignored
+			b: "\x00\x01\x00\x21\x2a",
+			v: int64(42),
+		},
+		{ // typed nulls
+			b: "\x8f",
+			v: nil,
+		},
+		{ // symbol value of the system symbol table
+			b: "\x71\x04",
+			v: "name",
+		},
+		{ // decimal 1.25 (125d-2)
+			b: "\x52\xc2\x7d",
+			v: 1.25,
+		},
+		{ // float32 value
+			b: "\x44\x3f\xc0\x00\x00",
+			v: 1.5,
+		},
+		{ // timestamp with a +01:00 offset and a fraction of 0.5s
+			b: "\x6a\xbc\x0f\xd0\x81\x82\x83\x84\x85\xc1\x05",
+			v: time.Date(2000, 1, 2, 4, 4, 5, 5e8, time.FixedZone("", 3600)),
+		},
+		{ // local symbol table followed by a struct using its symbols
+			b: "\xe9\x81\x83\xd6\x87\xb4\x83abc" + "\xd3\x8a\x21\x01",
+			v: map[interface{}]interface{}{"abc": int64(1)},
+		},
+		{ // sorted struct with a VarUInt length
+			b: "\xd1\x83\x84\x21\x01",
+			v: map[interface{}]interface{}{"name": int64(1)},
+		},
+	}
+
+	for _, test := range tests {
+		var v interface{}
+
+		if err := Unmarshal([]byte(string(bvm[:])+test.b), &v); err != nil {
+			t.Errorf("%q: %s", test.b, err)
+			continue
+		}
+
+		if tm, ok := v.(time.Time); ok {
+			if !tm.Equal(test.v.(time.Time)) {
+				t.Errorf("%q: bad time: %s", test.b, tm)
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(v, test.v) {
+			t.Errorf("%q: bad value: %#v", test.b, v)
+		}
+	}
+}
+
+func TestUnmarshalText(t *testing.T) {
+	tests := []struct {
+		s string
+		v interface{}
+	}{
+		{s: "null", v: nil},
+		{s: "null.struct", v: nil},
+		{s: "true", v: true},
+		{s: "-1_000", v: int64(-1000)},
+		{s: "0x1F", v: int64(31)},
+		{s: "0b101", v: int64(5)},
+		{s: "18446744073709551615", v: uint64(math.MaxUint64)},
+		{s: "1.5", v: 1.5},
+		{s: "15d-1", v: 1.5},
+		{s: "1.5e0", v: 1.5},
+		{s: "-inf", v: math.Inf(-1)},
+		{s: `"a\x41é\U0001F600\n"`, v: "aAé😀\n"},
+		{s: "'''hello '''\n'''world'''", v: "hello world"},
+		{s: "symbol", v: "symbol"},
+		{s: "'quoted symbol'", v: "quoted symbol"},
+		{s: "$4", v: "name"},
+		{s: "{{aGVsbG8=}}", v: []byte("hello")},
+		{s: `{{ "clob\x00" }}`, v: []byte("clob\x00")},
+		{s: "2007T", v: time.Date(2007, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{s: "2007-02T", v: time.Date(2007, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{s: "2007-02-23", v: time.Date(2007, 2, 23, 0, 0, 0, 0, time.UTC)},
+		{s: "2007-02-23T12:14Z", v: time.Date(2007, 2, 23, 12, 14, 0, 0, time.UTC)},
+		{s: "2007-02-23T12:14:33.079-00:00", v: time.Date(2007, 2, 23, 12, 14, 33, 79e6, time.UTC)},
+		{s: "[1, two, \"three\"]", v: []interface{}{int64(1), "two", "three"}},
+		{s: "(+ 1 -2)", v: []interface{}{"+", int64(1), int64(-2)}},
+		{s: "[]", v: []interface{}{}},
+		{
+			s: "/* comment */ { a: 1, 'b c': [true], \"d\": {} } // comment",
+			v: map[interface{}]interface{}{
+				"a":   int64(1),
+				"b c": []interface{}{true},
+				"d":   map[interface{}]interface{}{},
+			},
+		},
+		{ // annotations are ignored when the destination isn't Annotated
+			s: "a::b::[c::1]",
+			v: []interface{}{int64(1)},
+		},
+		{ // version marker and local symbol table
+			s: "$ion_1_0 $ion_symbol_table::{symbols:[\"hello\"]} $10",
+			v: "hello",
+		},
+	}
+
+	for _, test := range tests {
+		var v interface{}
+
+		if err := Unmarshal([]byte(test.s), &v); err != nil {
+			t.Errorf("%s: %s", test.s, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(v, test.v) {
+			t.Errorf("%s: bad value: %#v", test.s, v)
+		}
+	}
+}
+
+func TestUnmarshalTextOffset(t *testing.T) {
+	var v time.Time
+
+	if err := Unmarshal([]byte("2007-02-23T20:14:33.079+08:00"), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if !v.Equal(time.Date(2007, 2, 23, 12, 14, 33, 79e6, time.UTC)) {
+		t.Error("bad time:", v)
+	}
+
+	if _, offset := v.Zone(); offset != 8*3600 {
+		t.Error("bad offset:", offset)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"[1, 2",
+		"{a 1}",
+		"\"abc",
+		"\"a\nb\"",
+		"012",
+		"0x",
+		"2007-13-01",
+		"2007-02-23T12:14",
+		"null.nothing",
+		"a::",
+		"$42",
+		"{{ a }}",
+		string(bvm[:]) + "\x85abc",
+		string(bvm[:]) + "\xf0",
+		string(bvm[:]) + "\xb2\x21",
+		string(bvm[:]) + "\x12",
+		string(bvm[:]) + "\x71\x2a",
+		"\xe0\x02\x00\xea",
+	}
+
+	for _, test := range tests {
+		var v interface{}
+
+		if err := Unmarshal([]byte(test), &v); err == nil {
+			t.Errorf("%q: no error", test)
+		}
+	}
+}
+
+func TestMarshalText(t *testing.T) {
+	tests := []struct {
+		v interface{}
+		s string
+	}{
+		{v: nil, s: "null"},
+		{v: -42, s: "-42"},
+		{v: 1.0, s: "1e0"},
+		{v: math.NaN(), s: "nan"},
+		{v: "a\"\x00é", s: `"a\"\x00é"`},
+		{v: []byte("hello"), s: "{{aGVsbG8=}}"},
+		{v: time.Date(2007, 2, 23, 12, 14, 33, 79e6, time.UTC), s: "2007-02-23T12:14:33.079Z"},
+		{v: []int{1, 2}, s: "[1,2]"},
+		{
+			v: struct {
+				A int         `objconv:"a"`
+				B bool        `objconv:"b c"`
+				C interface{} `objconv:"null"`
+				D string      `objconv:"$1"`
+			}{A: 1, B: true, D: "x"},
+			s: `{a:1,'b c':true,'null':null,'$1':"x"}`,
+		},
+		{
+			v: Annotated[[]int]{Annotations: []string{"list", "of ints"}, Value: []int{1}},
+			s: "list::'of ints'::[1]",
+		},
+	}
+
+	for _, test := range tests {
+		b, err := MarshalText(test.v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := test.s + "\n"; string(b) != s {
+			t.Errorf("%#v: bad output:\n%q\n%q", test.v, s, b)
+		}
+	}
+}
+
+func TestTextFormatEmitter(t *testing.T) {
+	v := map[string]interface{}{
+		"a": []int{1, 2, 3},
+		"b": []interface{}{Annotated[int]{Annotations: []string{"x"}, Value: 4}, []int{}, map[string]int{"c": 5}},
+		"d": map[string]interface{}{},
+	}
+
+	tests := []struct {
+		f objconv.Format
+		s string
+	}{
+		{
+			f: objconv.DefaultFormat,
+			s: "{\n  a: [\n    1,\n    2,\n    3\n  ],\n  b: [\n    x::4,\n    [],\n    {\n      c: 5\n    }\n  ],\n  d: {}\n}\n",
+		},
+		{
+			f: objconv.Format{Prefix: " ", Indent: "\t", Space: " ", Width: 12},
+			s: "{\n \ta: [1, 2, 3],\n \tb: [\n \t\tx::4,\n \t\t[],\n \t\t{\n \t\t\tc: 5\n \t\t}\n \t],\n \td: {}\n }\n",
+		},
+		{
+			f: objconv.Format{Space: " "},
+			s: "{a: [1, 2, 3], b: [x::4, [], {c: 5}], d: {}}\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%q", test.f.Indent), func(t *testing.T) {
+			b := &bytes.Buffer{}
+			e := objconv.Encoder{Emitter: NewTextEmitter(b).FormatEmitter(test.f), SortMapKeys: true}
+
+			if err := e.Encode(v); err != nil {
+				t.Fatal(err)
+			}
+
+			if s := b.String(); s != test.s {
+				t.Errorf("bad output:\n%s", s)
+			}
+
+			var x map[string]interface{}
+
+			if err := Unmarshal(b.Bytes(), &x); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(x) != len(v) {
+				t.Errorf("bad value: %v", x)
+			}
+		})
+	}
+}
+
+type document struct {
+	ID    Annotated[string]            `objconv:"id"`
+	Tags  []Annotated[int]             `objconv:"tags"`
+	Attrs map[string]Annotated[string] `objconv:"attrs"`
+}
+
+func TestAnnotated(t *testing.T) {
+	v1 := Annotated[document]{
+		Annotations: []string{"Document"},
+		Value: document{
+			ID: Annotated[string]{Annotations: []string{"uuid"}, Value: "1234"},
+			Tags: []Annotated[int]{
+				{Value: 1},
+				{Annotations: []string{"a", "b"}, Value: 2},
+			},
+			Attrs: map[string]Annotated[string]{
+				"name": {Annotations: []string{"$ion_symbol_table"}, Value: "Ion"},
+			},
+		},
+	}
+
+	for _, codec := range []objconv.Codec{Codec, TextCodec} {
+		b := &bytes.Buffer{}
+
+		if err := objconv.NewEncoder(codec.NewEmitter(b)).Encode(v1); err != nil {
+			t.Fatal(err)
+		}
+
+		var v2 Annotated[document]
+
+		if err := Unmarshal(b.Bytes(), &v2); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(v1, v2) {
+			t.Errorf("%q: bad value: %#v", b.Bytes(), v2)
+		}
+	}
+}
+
+func TestAnnotatedOtherCodec(t *testing.T) {
+	v := Annotated[int]{Annotations: []string{"a"}, Value: 42}
+
+	if err := v.DecodeValue(objconv.Decoder{Parser: objconv.NewValueParser(1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Annotations != nil || v.Value != 1 {
+		t.Errorf("bad value: %#v", v)
+	}
+}
+
+func TestSymbolTables(t *testing.T) {
+	// The symbols of each top-level value are appended to the symbol table of
+	// the stream, so the second value only declares the symbol "B".
+	b := &bytes.Buffer{}
+	e := NewEncoder(b)
+
+	values := []interface{}{
+		map[string]int{"A": 1},
+		map[string]int{"A": 2, "B": 3},
+		map[string]int{"B": 4},
+	}
+
+	for _, v := range values {
+		if err := e.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := bytes.Count(b.Bytes(), bvm[:]); n != 1 {
+		t.Errorf("the stream has %d version markers", n)
+	}
+
+	d := objconv.NewStreamDecoder(NewParser(b))
+	d.Sequence = true
+
+	for i, v1 := range values {
+		var v2 map[string]int
+
+		if err := d.Decode(&v2); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(v1, v2) {
+			t.Errorf("value at index %d: %#v != %#v", i, v1, v2)
+		}
+	}
+
+	if err := d.Decode(new(interface{})); err != objconv.End {
+		t.Error("expected the end of the stream but got:", err)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	tests := []interface{}{
+		map[int]int{1: 2},
+		time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	for _, test := range tests {
+		if _, err := Marshal(test); err == nil {
+			t.Errorf("%#v: no error", test)
+		}
+		if _, err := MarshalText(test); err == nil {
+			t.Errorf("%#v: no error in text form", test)
+		}
+	}
+}
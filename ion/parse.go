@@ -0,0 +1,263 @@
+package ion
+
+import (
+	"io"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Parser implements an Ion parser that satisfies the objconv.Parser interface.
+//
+// The parser supports both the text and the binary forms of Ion, the form of
+// the input is detected when the first value is parsed: binary streams start
+// with a version marker, inputs that don't are parsed as text.
+type Parser struct {
+	reader
+
+	impl valueParser // parser of the detected form, nil until detection
+	bin  binaryParser
+	txt  textParser
+}
+
+// valueParser is the interface implemented by the parsers of the text and
+// binary forms.
+type valueParser interface {
+	objconv.Parser
+	ParseNumber() ([]byte, error)
+	ParseAnnotations() ([]string, error)
+	reset()
+}
+
+func NewParser(r io.Reader) *Parser {
+	p := &Parser{}
+	p.Reset(r)
+	return p
+}
+
+func (p *Parser) Reset(r io.Reader) {
+	p.reader.reset(r)
+	p.bin.reader = &p.reader
+	p.txt.reader = &p.reader
+	p.bin.reset()
+	p.txt.reset()
+	p.impl = nil
+}
+
+// Offset returns the number of bytes consumed by the parser.
+func (p *Parser) Offset() int64 {
+	return p.offset()
+}
+
+// TextParser satisfies the objconv.textParser interface, it returns true if
+// the input is in the text form of Ion.
+func (p *Parser) TextParser() bool {
+	return p.detect() == nil && p.impl == valueParser(&p.txt)
+}
+
+// ParseAnnotations returns the annotations of the next value, or nil if it has
+// none, the value itself is parsed by the following calls to the parser.
+func (p *Parser) ParseAnnotations() ([]string, error) {
+	if err := p.detect(); err != nil {
+		return nil, err
+	}
+	return p.impl.ParseAnnotations()
+}
+
+// ParseNumber satisfies the objconv.NumberParser interface, decimals keep
+// their exact representation.
+func (p *Parser) ParseNumber() ([]byte, error) { return p.impl.ParseNumber() }
+
+func (p *Parser) ParseType() (objconv.Type, error) {
+	if err := p.detect(); err != nil {
+		return objconv.Unknown, err
+	}
+	return p.impl.ParseType()
+}
+
+func (p *Parser) ParseNil() error                       { return p.impl.ParseNil() }
+func (p *Parser) ParseBool() (bool, error)              { return p.impl.ParseBool() }
+func (p *Parser) ParseInt() (int64, error)              { return p.impl.ParseInt() }
+func (p *Parser) ParseUint() (uint64, error)            { return p.impl.ParseUint() }
+func (p *Parser) ParseFloat() (float64, error)          { return p.impl.ParseFloat() }
+func (p *Parser) ParseString() ([]byte, error)          { return p.impl.ParseString() }
+func (p *Parser) ParseBytes() ([]byte, error)           { return p.impl.ParseBytes() }
+func (p *Parser) ParseTime() (time.Time, error)         { return p.impl.ParseTime() }
+func (p *Parser) ParseDuration() (time.Duration, error) { return p.impl.ParseDuration() }
+func (p *Parser) ParseError() (error, error)            { return p.impl.ParseError() }
+func (p *Parser) ParseArrayBegin() (int, error)         { return p.impl.ParseArrayBegin() }
+func (p *Parser) ParseArrayEnd(n int) error             { return p.impl.ParseArrayEnd(n) }
+func (p *Parser) ParseArrayNext(n int) error            { return p.impl.ParseArrayNext(n) }
+func (p *Parser) ParseMapBegin() (int, error)           { return p.impl.ParseMapBegin() }
+func (p *Parser) ParseMapEnd(n int) error               { return p.impl.ParseMapEnd(n) }
+func (p *Parser) ParseMapValue(n int) error             { return p.impl.ParseMapValue(n) }
+func (p *Parser) ParseMapNext(n int) error              { return p.impl.ParseMapNext(n) }
+
+// detect selects the parser of the form of the input.
+func (p *Parser) detect() error {
+	if p.impl != nil {
+		return nil
+	}
+
+	b, err := p.peek(1)
+	if err != nil {
+		return err
+	}
+
+	if b[0] == bvm[0] {
+		p.impl = &p.bin
+	} else {
+		p.impl = &p.txt
+	}
+
+	return nil
+}
+
+// reader buffers the input of a parser, the buffer grows as values require
+// more bytes to be loaded so the declared lengths of values don't cause large
+// allocations upfront.
+type reader struct {
+	r   io.Reader
+	b   []byte
+	i   int   // offset of the first unread byte in b
+	off int64 // offset in the input of the first byte in b
+	err error // error returned by the last read
+}
+
+func (r *reader) reset(x io.Reader) {
+	r.r = x
+	r.b = r.b[:0]
+	r.i = 0
+	r.off = 0
+	r.err = nil
+}
+
+func (r *reader) offset() int64 {
+	return r.off + int64(r.i)
+}
+
+// peek returns the next n bytes of the input, the slice is only valid until
+// the next call to the reader. The error is io.EOF if the input has less than
+// n bytes left.
+func (r *reader) peek(n int) ([]byte, error) {
+	if len(r.b)-r.i < n {
+		if err := r.fill(n); err != nil {
+			return nil, err
+		}
+	}
+	return r.b[r.i : r.i+n], nil
+}
+
+// peekByte returns the byte at offset i from the first unread byte.
+func (r *reader) peekByte(i int) (byte, error) {
+	if len(r.b)-r.i <= i {
+		if err := r.fill(i + 1); err != nil {
+			return 0, err
+		}
+	}
+	return r.b[r.i+i], nil
+}
+
+// read returns the next n bytes of the input and consumes them.
+func (r *reader) read(n int) ([]byte, error) {
+	b, err := r.peek(n)
+	if err == nil {
+		r.i += n
+	}
+	return b, err
+}
+
+func (r *reader) skip(n int) {
+	r.i += n
+}
+
+func (r *reader) fill(n int) error {
+	for len(r.b)-r.i < n {
+		if r.err != nil {
+			return r.err
+		}
+
+		if len(r.b) == cap(r.b) {
+			if r.i != 0 { // pack
+				m := copy(r.b, r.b[r.i:])
+				r.off += int64(r.i)
+				r.b = r.b[:m]
+				r.i = 0
+			}
+
+			if len(r.b) == cap(r.b) {
+				c := 2 * cap(r.b)
+				if c < 4096 {
+					c = 4096
+				}
+				b := make([]byte, len(r.b), c)
+				copy(b, r.b)
+				r.b = b
+			}
+		}
+
+		m, err := r.r.Read(r.b[len(r.b):cap(r.b)])
+		r.b = r.b[:len(r.b)+m]
+
+		if err != nil {
+			r.err = err
+		} else if m == 0 {
+			r.err = io.ErrNoProgress
+		}
+	}
+	return nil
+}
+
+// unexpectedEOF converts io.EOF to io.ErrUnexpectedEOF, it is used when the
+// input ends in the middle of a value.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// loadSymbolTable decodes the local symbol table which is the next value of p,
+// and applies it to t.
+func loadSymbolTable(p objconv.Parser, t *symbolTable) error {
+	var lst struct {
+		Imports interface{}   `objconv:"imports"`
+		Symbols []interface{} `objconv:"symbols"`
+	}
+
+	if err := (objconv.Decoder{Parser: p}).Decode(&lst); err != nil {
+		return err
+	}
+
+	// The symbols are appended to the current table when the table imports
+	// $ion_symbol_table, the table is replaced otherwise.
+	if s, _ := lst.Imports.(string); s != "$ion_symbol_table" {
+		t.reset()
+
+		imports, _ := lst.Imports.([]interface{})
+
+		for _, x := range imports {
+			// The symbols of shared tables are unknown, they are given
+			// placeholders so the identifiers of the local symbols are
+			// right.
+			m, _ := x.(map[interface{}]interface{})
+			n, _ := m["max_id"].(int64)
+
+			if n < 0 || n > objutil.Int32Max {
+				return objutil.Errorf(objutil.ErrRange, "objconv/ion: invalid max_id of imported symbol table: %d", n)
+			}
+
+			for i := int64(0); i != n; i++ {
+				t.symbols = append(t.symbols, "")
+			}
+		}
+	}
+
+	for _, x := range lst.Symbols {
+		s, _ := x.(string)
+		t.symbols = append(t.symbols, s)
+	}
+
+	return nil
+}
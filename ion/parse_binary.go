@@ -0,0 +1,764 @@
+package ion
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// binaryParser parses the binary form of Ion.
+type binaryParser struct {
+	*reader
+
+	symbols symbolTable
+	stack   []binaryFrame
+
+	// Header of the next value, loaded by ParseType.
+	peeked      bool
+	code        byte
+	low         byte
+	size        int
+	annotations []string
+
+	// Name of the next struct field, loaded by ParseMapNext and returned by
+	// ParseString.
+	field string
+	key   bool
+
+	s []byte // string buffer
+}
+
+type binaryFrame struct {
+	end int64 // offset in the input of the end of the container
+}
+
+func (p *binaryParser) reset() {
+	p.symbols.reset()
+	p.stack = p.stack[:0]
+	p.peeked = false
+	p.annotations = p.annotations[:0]
+	p.field = ""
+	p.key = false
+}
+
+func (p *binaryParser) ParseAnnotations() ([]string, error) {
+	if p.key {
+		return nil, nil
+	}
+	if _, err := p.ParseType(); err != nil {
+		return nil, err
+	}
+	if len(p.annotations) == 0 {
+		return nil, nil
+	}
+	return append([]string{}, p.annotations...), nil
+}
+
+func (p *binaryParser) ParseType() (objconv.Type, error) {
+	if p.key {
+		return objconv.String, nil
+	}
+
+	if !p.peeked {
+		if err := p.peekValue(); err != nil {
+			return objconv.Unknown, err
+		}
+	}
+
+	if p.low == lenNull {
+		return objconv.Nil, nil
+	}
+
+	switch p.code {
+	case typeNull:
+		return objconv.Nil, nil
+
+	case typeBool:
+		return objconv.Bool, nil
+
+	case typePosInt:
+		if p.size == 8 {
+			b, err := p.peek(8)
+			if err != nil {
+				return objconv.Unknown, unexpectedEOF(err)
+			}
+			if b[0]&0x80 != 0 {
+				return objconv.Uint, nil
+			}
+		}
+		return objconv.Int, nil
+
+	case typeNegInt:
+		return objconv.Int, nil
+
+	case typeFloat, typeDecimal:
+		return objconv.Float, nil
+
+	case typeTimestamp:
+		return objconv.Time, nil
+
+	case typeSymbol, typeString:
+		return objconv.String, nil
+
+	case typeClob, typeBlob:
+		return objconv.Bytes, nil
+
+	case typeList, typeSexp:
+		return objconv.Array, nil
+
+	default: // typeStruct, the other type codes were rejected by peekValue
+		return objconv.Map, nil
+	}
+}
+
+func (p *binaryParser) ParseNil() (err error) {
+	_, err = p.content()
+	return
+}
+
+func (p *binaryParser) ParseBool() (v bool, err error) {
+	v = p.low == 1
+	_, err = p.content()
+	return
+}
+
+func (p *binaryParser) ParseInt() (v int64, err error) {
+	var b []byte
+	var u uint64
+
+	neg := p.code == typeNegInt
+
+	if b, err = p.content(); err != nil {
+		return
+	}
+
+	if u, err = readUInt(b); err != nil {
+		return
+	}
+
+	switch {
+	case !neg && u > objutil.Int64Max:
+		err = objutil.Errorf(objutil.ErrRange, "objconv/ion: %d overflows int64", u)
+	case neg && u > uint64(objutil.Int64Max)+1:
+		err = objutil.Errorf(objutil.ErrRange, "objconv/ion: -%d overflows int64", u)
+	case neg:
+		v = -int64(u)
+	default:
+		v = int64(u)
+	}
+
+	return
+}
+
+func (p *binaryParser) ParseUint() (v uint64, err error) {
+	var b []byte
+
+	if b, err = p.content(); err == nil {
+		v, err = readUInt(b)
+	}
+
+	return
+}
+
+func (p *binaryParser) ParseFloat() (v float64, err error) {
+	var b []byte
+
+	if p.code == typeDecimal {
+		if b, err = p.parseDecimal(); err == nil {
+			v, err = strconv.ParseFloat(string(b), 64)
+		}
+		return
+	}
+
+	if b, err = p.content(); err != nil {
+		return
+	}
+
+	switch len(b) {
+	case 0:
+	case 4:
+		v = float64(math.Float32frombits(uint32(getUint64(b))))
+	case 8:
+		v = math.Float64frombits(getUint64(b))
+	default:
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid length of float value: %d", len(b))
+	}
+
+	return
+}
+
+func (p *binaryParser) ParseNumber() (b []byte, err error) {
+	switch p.code {
+	case typeDecimal:
+		return p.parseDecimal()
+
+	case typePosInt, typeNegInt:
+		neg := p.code == typeNegInt
+
+		if b, err = p.content(); err != nil {
+			return
+		}
+
+		// Ion integers have no size limit, the number representation keeps
+		// the ones which don't fit in 64 bits.
+		v := new(big.Int).SetBytes(b)
+		if neg {
+			v.Neg(v)
+		}
+		b = v.Append(p.s[:0], 10)
+		p.s = b
+		return
+
+	default:
+		var f float64
+		if f, err = p.ParseFloat(); err == nil {
+			b = strconv.AppendFloat(p.s[:0], f, 'g', -1, 64)
+			p.s = b
+		}
+		return
+	}
+}
+
+// parseDecimal returns the decimal value in a form accepted by
+// strconv.ParseFloat, the exponent is always present.
+func (p *binaryParser) parseDecimal() (s []byte, err error) {
+	var b []byte
+	var exp int64
+	var n int
+
+	if b, err = p.content(); err != nil {
+		return
+	}
+
+	if len(b) == 0 { // 0d0
+		s = append(p.s[:0], "0e0"...)
+		p.s = s
+		return
+	}
+
+	if exp, _, n, err = readVarInt(b); err != nil {
+		return
+	}
+	if n == 0 {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid exponent of decimal value")
+		return
+	}
+
+	s = p.s[:0]
+	b = b[n:]
+
+	if len(b) != 0 && b[0]&0x80 != 0 {
+		s = append(s, '-')
+		b = append([]byte{b[0] & 0x7F}, b[1:]...)
+	}
+
+	s = new(big.Int).SetBytes(b).Append(s, 10)
+	s = append(s, 'e')
+	s = strconv.AppendInt(s, exp, 10)
+	p.s = s
+	return
+}
+
+func (p *binaryParser) ParseString() (v []byte, err error) {
+	if p.key {
+		p.key = false
+		v = append(p.s[:0], p.field...)
+		p.s = v
+		return
+	}
+
+	if p.code == typeString {
+		return p.content()
+	}
+
+	var b []byte
+	var id uint64
+	var sym string
+
+	if b, err = p.content(); err != nil {
+		return
+	}
+
+	if id, err = readUInt(b); err != nil {
+		return
+	}
+
+	if sym, err = p.symbols.lookup(id); err != nil {
+		return
+	}
+
+	v = append(p.s[:0], sym...)
+	p.s = v
+	return
+}
+
+func (p *binaryParser) ParseBytes() (v []byte, err error) {
+	return p.content()
+}
+
+func (p *binaryParser) ParseTime() (v time.Time, err error) {
+	var b []byte
+
+	if b, err = p.content(); err != nil {
+		return
+	}
+
+	// The offset is in minutes, -00:00 means that the offset is unknown and
+	// the time is returned in UTC.
+	offset, _, n, err := readVarInt(b)
+	if err != nil {
+		return
+	}
+	if n == 0 {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid offset of timestamp value")
+		return
+	}
+	b = b[n:]
+
+	// The year is required, the other components default to the first month
+	// and day of the year.
+	date := [...]uint64{0, 1, 1, 0, 0, 0}
+	i := 0
+
+	for i != len(date) && len(b) != 0 {
+		if date[i], n, err = readVarUInt(b); err != nil {
+			return
+		}
+		if n == 0 {
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid component of timestamp value")
+			return
+		}
+		b = b[n:]
+		i++
+	}
+
+	if i == 0 {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/ion: timestamp values must have a year")
+		return
+	}
+
+	nsec := int64(0)
+
+	if len(b) != 0 {
+		var exp, coef int64
+
+		if exp, _, n, err = readVarInt(b); err != nil {
+			return
+		}
+		if n == 0 {
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid fraction of timestamp value")
+			return
+		}
+
+		if coef, err = readInt(b[n:]); err != nil {
+			return
+		}
+
+		if nsec, err = fractionNanoseconds(exp, coef); err != nil {
+			return
+		}
+	}
+
+	for i := range date {
+		if date[i] > objutil.Int32Max {
+			err = objutil.Errorf(objutil.ErrRange, "objconv/ion: component of timestamp value out of range: %d", date[i])
+			return
+		}
+	}
+
+	v = time.Date(
+		int(date[0]),
+		time.Month(date[1]),
+		int(date[2]),
+		int(date[3]),
+		int(date[4]),
+		int(date[5]),
+		int(nsec),
+		time.UTC,
+	)
+
+	if offset != 0 {
+		v = v.In(time.FixedZone("", int(offset)*60))
+	}
+	return
+}
+
+func (p *binaryParser) ParseDuration() (v time.Duration, err error) {
+	panic("objconv/ion: ParseDuration should never be called because Ion has no duration type, this is likely a bug in the decoder code")
+}
+
+func (p *binaryParser) ParseError() (v error, err error) {
+	panic("objconv/ion: ParseError should never be called because Ion has no error type, this is likely a bug in the decoder code")
+}
+
+func (p *binaryParser) ParseArrayBegin() (n int, err error) {
+	p.push()
+	return -1, nil
+}
+
+func (p *binaryParser) ParseArrayEnd(n int) (err error) {
+	return p.pop()
+}
+
+func (p *binaryParser) ParseArrayNext(n int) (err error) {
+	var more bool
+
+	if more, err = p.skipPads(); err == nil && !more {
+		err = objconv.End
+	}
+
+	return
+}
+
+func (p *binaryParser) ParseMapBegin() (n int, err error) {
+	p.push()
+	return -1, nil
+}
+
+func (p *binaryParser) ParseMapEnd(n int) (err error) {
+	return p.pop()
+}
+
+func (p *binaryParser) ParseMapValue(n int) (err error) {
+	return
+}
+
+func (p *binaryParser) ParseMapNext(n int) (err error) {
+	end := p.stack[len(p.stack)-1].end
+
+	for {
+		if p.offset() >= end {
+			return objconv.End
+		}
+
+		var id uint64
+
+		if id, err = p.readVarUInt(); err != nil {
+			return
+		}
+
+		// Pads may have field names, they are skipped like in lists.
+		var c byte
+
+		if c, err = p.peekByte(0); err != nil {
+			return unexpectedEOF(err)
+		}
+
+		if c>>4 == typeNull && c&0x0F != lenNull {
+			var size int
+
+			if _, _, size, err = p.header(); err != nil {
+				return
+			}
+			if _, err = p.read(size); err != nil {
+				return unexpectedEOF(err)
+			}
+			continue
+		}
+
+		if p.field, err = p.symbols.lookup(id); err != nil {
+			return
+		}
+
+		p.key = true
+		return
+	}
+}
+
+// content consumes the content of the value which was loaded by ParseType.
+func (p *binaryParser) content() (b []byte, err error) {
+	if b, err = p.read(p.size); err != nil {
+		err = unexpectedEOF(err)
+	}
+	p.peeked = false
+	return
+}
+
+func (p *binaryParser) push() {
+	p.stack = append(p.stack, binaryFrame{end: p.offset() + int64(p.size)})
+	p.peeked = false
+}
+
+func (p *binaryParser) pop() error {
+	i := len(p.stack) - 1
+	end := p.stack[i].end
+	p.stack = p.stack[:i]
+
+	if n := end - p.offset(); n > 0 {
+		if _, err := p.read(int(n)); err != nil {
+			return unexpectedEOF(err)
+		}
+	}
+
+	return nil
+}
+
+// skipPads skips the pads at the current position of the container on top of
+// the stack, and returns true if there are more values in the container.
+func (p *binaryParser) skipPads() (bool, error) {
+	end := p.stack[len(p.stack)-1].end
+
+	for p.offset() < end {
+		c, err := p.peekByte(0)
+		if err != nil {
+			return false, unexpectedEOF(err)
+		}
+
+		if c>>4 != typeNull || c&0x0F == lenNull {
+			return true, nil
+		}
+
+		_, _, size, err := p.header()
+		if err != nil {
+			return false, err
+		}
+		if _, err := p.read(size); err != nil {
+			return false, unexpectedEOF(err)
+		}
+	}
+
+	return false, nil
+}
+
+// peekValue loads the header of the next value, skipping the system values
+// and pads which precede it.
+func (p *binaryParser) peekValue() error {
+	for {
+		top := len(p.stack) == 0
+
+		c, err := p.peekByte(0)
+		if err != nil {
+			if top {
+				return err
+			}
+			return unexpectedEOF(err)
+		}
+
+		if top && c == bvm[0] {
+			b, err := p.peek(len(bvm))
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			if string(b) != string(bvm[:]) {
+				return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: unsupported version marker: %#x", b)
+			}
+			p.skip(len(bvm))
+			p.symbols.reset()
+			continue
+		}
+
+		code, low, size, err := p.header()
+		if err != nil {
+			return err
+		}
+		p.annotations = p.annotations[:0]
+
+		if code == typeAnnotation {
+			end := p.offset() + int64(size)
+
+			if err := p.parseAnnotationWrapper(size); err != nil {
+				return err
+			}
+			if code, low, size, err = p.header(); err != nil {
+				return err
+			}
+			if p.offset()+int64(size) != end {
+				return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: the annotated value doesn't match the length of its annotation wrapper")
+			}
+			if code == typeAnnotation {
+				return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: annotation wrappers cannot be nested")
+			}
+			if code == typeNull && low != lenNull {
+				return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: pads cannot be annotated")
+			}
+		}
+
+		if code == typeNull && low != lenNull { // pad
+			if _, err := p.read(size); err != nil {
+				return unexpectedEOF(err)
+			}
+			continue
+		}
+
+		if code == typeReserved {
+			return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: reserved type descriptor: %#x", code<<4|low)
+		}
+
+		if code == typeBool && low > 1 && low != lenNull {
+			return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid type descriptor of boolean value: %#x", code<<4|low)
+		}
+
+		if !top && p.offset()+int64(size) > p.stack[len(p.stack)-1].end {
+			return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: value of %d bytes overflows its container", size)
+		}
+
+		p.code, p.low, p.size, p.peeked = code, low, size, true
+
+		if top && code == typeStruct && low != lenNull && len(p.annotations) != 0 && p.annotations[0] == "$ion_symbol_table" {
+			if err := loadSymbolTable(p, &p.symbols); err != nil {
+				return err
+			}
+			continue
+		}
+
+		return nil
+	}
+}
+
+// parseAnnotationWrapper consumes the annotations of an annotation wrapper of
+// the given length, which must be followed by the annotated value.
+func (p *binaryParser) parseAnnotationWrapper(size int) error {
+	if size < 3 {
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid length of annotation wrapper: %d", size)
+	}
+
+	start := p.offset()
+
+	n, err := p.readVarUInt()
+	if err != nil {
+		return err
+	}
+	if n == 0 || n > uint64(size) {
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid length of annotations: %d", n)
+	}
+
+	b, err := p.read(int(n))
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+
+	for len(b) != 0 {
+		id, n, err := readVarUInt(b)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid annotation symbol")
+		}
+		sym, err := p.symbols.lookup(id)
+		if err != nil {
+			return err
+		}
+		p.annotations = append(p.annotations, sym)
+		b = b[n:]
+	}
+
+	if p.offset()-start >= int64(size) {
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: annotation wrapper has no value")
+	}
+
+	return nil
+}
+
+// header consumes a type descriptor and the length which follows it, it
+// returns the type code, the low nibble of the descriptor, and the length of
+// the content of the value.
+func (p *binaryParser) header() (code byte, low byte, size int, err error) {
+	var c byte
+	var n uint64
+
+	if c, err = p.peekByte(0); err != nil {
+		err = unexpectedEOF(err)
+		return
+	}
+	p.skip(1)
+
+	code, low = c>>4, c&0x0F
+
+	switch {
+	case code == typeBool || low == lenNull:
+		return
+
+	case code == typeStruct && low == 1: // sorted struct
+		n, err = p.readVarUInt()
+
+	case low == lenVarUInt:
+		n, err = p.readVarUInt()
+
+	default:
+		n = uint64(low)
+	}
+
+	if err == nil && n > objutil.Int32Max {
+		err = objutil.Errorf(objutil.ErrRange, "objconv/ion: length of value out of range: %d", n)
+	}
+
+	size = int(n)
+	return
+}
+
+func (p *binaryParser) readVarUInt() (uint64, error) {
+	for k := 1; ; k++ {
+		b, err := p.peek(k)
+		if err != nil {
+			return 0, unexpectedEOF(err)
+		}
+		v, n, err := readVarUInt(b)
+		if err != nil {
+			return 0, err
+		}
+		if n != 0 {
+			p.skip(n)
+			return v, nil
+		}
+	}
+}
+
+// readInt decodes a sign and magnitude integer.
+func readInt(b []byte) (int64, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	neg := b[0]&0x80 != 0
+	b = append([]byte{b[0] & 0x7F}, b[1:]...)
+
+	for len(b) != 0 && b[0] == 0 {
+		b = b[1:]
+	}
+
+	u, err := readUInt(b)
+	if err != nil {
+		return 0, err
+	}
+	if u > objutil.Int64Max {
+		return 0, objutil.Errorf(objutil.ErrRange, "objconv/ion: %d overflows int64", u)
+	}
+
+	v := int64(u)
+	if neg {
+		v = -v
+	}
+	return v, nil
+}
+
+// fractionNanoseconds converts the fraction of second coef*10^exp to a number
+// of nanoseconds, digits beyond the nanoseconds are truncated.
+func fractionNanoseconds(exp int64, coef int64) (int64, error) {
+	if coef < 0 || (coef != 0 && exp >= 0) {
+		return 0, objutil.Errorf(objutil.ErrSyntax, "objconv/ion: fraction of timestamp value out of range: %de%d", coef, exp)
+	}
+
+	for ; exp < -9 && coef != 0; exp++ {
+		coef /= 10
+	}
+	for ; exp > -9 && coef < int64(time.Second); exp-- {
+		coef *= 10
+	}
+
+	if coef >= int64(time.Second) {
+		return 0, objutil.Errorf(objutil.ErrSyntax, "objconv/ion: fraction of timestamp value out of range")
+	}
+	return coef, nil
+}
+
+func getUint64(b []byte) (v uint64) {
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return
+}
@@ -0,0 +1,992 @@
+package ion
+
+import (
+	"encoding/base64"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// textParser parses the text form of Ion.
+type textParser struct {
+	*reader
+
+	symbols symbolTable
+	stack   []byte // opening characters of the containers being parsed
+
+	// Next value, loaded by ParseType.
+	peeked      bool
+	typ         objconv.Type
+	kind        byte      // opening character of containers
+	b           []byte    // decoded strings and blobs, or text of numbers
+	v           bool      // value of booleans
+	t           time.Time // value of timestamps
+	ident       bool      // true if the value is an unquoted symbol
+	annotations []string
+
+	// Name of the next struct field, loaded by ParseMapNext and returned by
+	// ParseString.
+	field []byte
+	key   bool
+
+	s []byte // buffer of ParseNumber
+}
+
+func (p *textParser) reset() {
+	p.symbols.reset()
+	p.stack = p.stack[:0]
+	p.peeked = false
+	p.annotations = p.annotations[:0]
+	p.key = false
+}
+
+func (p *textParser) ParseAnnotations() ([]string, error) {
+	if p.key {
+		return nil, nil
+	}
+	if _, err := p.ParseType(); err != nil {
+		return nil, err
+	}
+	if len(p.annotations) == 0 {
+		return nil, nil
+	}
+	return append([]string{}, p.annotations...), nil
+}
+
+func (p *textParser) ParseType() (objconv.Type, error) {
+	if p.key {
+		return objconv.String, nil
+	}
+
+	if !p.peeked {
+		if err := p.peekValue(); err != nil {
+			return objconv.Unknown, err
+		}
+	}
+
+	return p.typ, nil
+}
+
+func (p *textParser) ParseNil() (err error) {
+	p.peeked = false
+	return
+}
+
+func (p *textParser) ParseBool() (v bool, err error) {
+	p.peeked = false
+	v = p.v
+	return
+}
+
+func (p *textParser) ParseInt() (v int64, err error) {
+	p.peeked = false
+
+	if v, err = strconv.ParseInt(string(p.b), 0, 64); err != nil {
+		err = numberError(p.b, err)
+	}
+
+	return
+}
+
+func (p *textParser) ParseUint() (v uint64, err error) {
+	p.peeked = false
+
+	if v, err = strconv.ParseUint(string(p.b), 0, 64); err != nil {
+		err = numberError(p.b, err)
+	}
+
+	return
+}
+
+func (p *textParser) ParseFloat() (v float64, err error) {
+	p.peeked = false
+
+	if v, err = strconv.ParseFloat(string(p.b), 64); err != nil {
+		err = numberError(p.b, err)
+	}
+
+	return
+}
+
+func (p *textParser) ParseNumber() (b []byte, err error) {
+	p.peeked = false
+	b = p.b
+
+	// Integers in base 2 and 16 are converted to base 10.
+	if p.typ != objconv.Float && len(b) > 2 && strings.ContainsAny(string(b), "xXbB") {
+		v, ok := new(big.Int).SetString(string(b), 0)
+		if !ok {
+			return nil, numberError(b, strconv.ErrSyntax)
+		}
+		b = v.Append(p.s[:0], 10)
+		p.s = b
+	}
+
+	return
+}
+
+func (p *textParser) ParseString() (v []byte, err error) {
+	if p.key {
+		p.key = false
+		v = p.field
+		return
+	}
+	p.peeked = false
+	v = p.b
+	return
+}
+
+func (p *textParser) ParseBytes() (v []byte, err error) {
+	p.peeked = false
+	v = p.b
+	return
+}
+
+func (p *textParser) ParseTime() (v time.Time, err error) {
+	p.peeked = false
+	v = p.t
+	return
+}
+
+func (p *textParser) ParseDuration() (v time.Duration, err error) {
+	panic("objconv/ion: ParseDuration should never be called because Ion has no duration type, this is likely a bug in the decoder code")
+}
+
+func (p *textParser) ParseError() (v error, err error) {
+	panic("objconv/ion: ParseError should never be called because Ion has no error type, this is likely a bug in the decoder code")
+}
+
+func (p *textParser) ParseArrayBegin() (n int, err error) {
+	p.stack = append(p.stack, p.kind)
+	p.peeked = false
+	return -1, nil
+}
+
+func (p *textParser) ParseArrayEnd(n int) (err error) {
+	p.stack = p.stack[:len(p.stack)-1]
+	return
+}
+
+func (p *textParser) ParseArrayNext(n int) (err error) {
+	var c byte
+
+	if p.stack[len(p.stack)-1] == '(' {
+		// The values of s-expressions are only separated by whitespaces.
+		if c, err = p.skipSpace(); err != nil {
+			return unexpectedEOF(err)
+		}
+		if c == ')' {
+			p.skip(1)
+			return objconv.End
+		}
+		return
+	}
+
+	return p.parseNext(n, ']')
+}
+
+func (p *textParser) ParseMapBegin() (n int, err error) {
+	p.stack = append(p.stack, p.kind)
+	p.peeked = false
+	return -1, nil
+}
+
+func (p *textParser) ParseMapEnd(n int) (err error) {
+	p.stack = p.stack[:len(p.stack)-1]
+	return
+}
+
+func (p *textParser) ParseMapValue(n int) (err error) {
+	return
+}
+
+func (p *textParser) ParseMapNext(n int) (err error) {
+	var c byte
+
+	if err = p.parseNext(n, '}'); err != nil {
+		return
+	}
+
+	if c, err = p.skipSpace(); err != nil {
+		return unexpectedEOF(err)
+	}
+
+	switch {
+	case c == '"':
+		p.skip(1)
+		p.b = p.b[:0]
+		err = p.parseShortString('"', false)
+
+	case c == '\'':
+		if p.hasPrefix("'''") {
+			p.b = p.b[:0]
+			err = p.parseLongStrings(false)
+		} else {
+			p.skip(1)
+			err = p.parseQuotedSymbol()
+		}
+
+	case isIdentStart(c):
+		err = p.parseIdentifier()
+
+	default:
+		err = unexpectedChar(c, "field name")
+	}
+
+	if err != nil {
+		return
+	}
+
+	if c, err = p.skipSpace(); err != nil {
+		return unexpectedEOF(err)
+	}
+	if c != ':' || p.hasPrefix("::") {
+		return unexpectedChar(c, "':' after field name")
+	}
+	p.skip(1)
+
+	p.field = append(p.field[:0], p.b...)
+	p.key = true
+	return
+}
+
+// parseNext consumes the separator between the values of a list or a struct,
+// or the closing character, in which case it returns objconv.End.
+func (p *textParser) parseNext(n int, end byte) (err error) {
+	var c byte
+
+	if c, err = p.skipSpace(); err != nil {
+		return unexpectedEOF(err)
+	}
+
+	if c == end {
+		p.skip(1)
+		return objconv.End
+	}
+
+	if n != 0 {
+		if c != ',' {
+			return unexpectedChar(c, "','")
+		}
+		p.skip(1)
+	}
+
+	return
+}
+
+// peekValue loads the next value, skipping the system values which precede
+// it.
+func (p *textParser) peekValue() error {
+	p.annotations = p.annotations[:0]
+
+	for {
+		top := len(p.stack) == 0
+
+		c, err := p.skipSpace()
+		if err != nil {
+			if top && len(p.annotations) == 0 {
+				return err
+			}
+			return unexpectedEOF(err)
+		}
+
+		annotation, err := p.lex(c)
+		if err != nil {
+			return err
+		}
+
+		if annotation {
+			p.annotations = append(p.annotations, string(p.b))
+			continue
+		}
+
+		if top && len(p.annotations) == 0 && p.ident && string(p.b) == "$ion_1_0" {
+			p.symbols.reset()
+			continue
+		}
+
+		p.peeked = true
+
+		if top && p.typ == objconv.Map && len(p.annotations) != 0 && p.annotations[0] == "$ion_symbol_table" {
+			if err := loadSymbolTable(p, &p.symbols); err != nil {
+				return err
+			}
+			p.annotations = p.annotations[:0]
+			continue
+		}
+
+		return nil
+	}
+}
+
+// lex parses the value starting with c, or the annotation it is preceded by,
+// in which case the returned boolean is true and the annotation is in p.b.
+func (p *textParser) lex(c byte) (annotation bool, err error) {
+	p.ident = false
+	p.b = p.b[:0]
+	sexp := len(p.stack) != 0 && p.stack[len(p.stack)-1] == '('
+
+	switch {
+	case c == '"':
+		p.skip(1)
+		p.typ = objconv.String
+		err = p.parseShortString('"', false)
+
+	case c == '\'':
+		if p.hasPrefix("'''") {
+			p.typ = objconv.String
+			err = p.parseLongStrings(false)
+			return
+		}
+		p.skip(1)
+		if err = p.parseQuotedSymbol(); err != nil {
+			return
+		}
+		return p.parseAnnotation()
+
+	case c == '{':
+		if p.hasPrefix("{{") {
+			p.skip(2)
+			p.typ = objconv.Bytes
+			err = p.parseLob()
+			return
+		}
+		p.skip(1)
+		p.typ, p.kind = objconv.Map, c
+
+	case c == '[', c == '(':
+		p.skip(1)
+		p.typ, p.kind = objconv.Array, c
+
+	case (c == '+' || c == '-') && sexp && !p.signedNumber():
+		p.parseOperator()
+
+	case isDigit(c) || c == '-' || c == '+':
+		err = p.parseNumber()
+
+	case isIdentStart(c):
+		if err = p.parseIdentifier(); err != nil {
+			return
+		}
+
+		if p.ident {
+			switch string(p.b) {
+			case "null":
+				p.typ = objconv.Nil
+				err = p.parseNullType()
+				return
+			case "true", "false":
+				p.typ, p.v = objconv.Bool, p.b[0] == 't'
+				return
+			case "nan":
+				p.typ, p.b = objconv.Float, append(p.b[:0], "NaN"...)
+				return
+			}
+		}
+
+		return p.parseAnnotation()
+
+	case sexp && isOperator(c):
+		p.parseOperator()
+
+	default:
+		err = unexpectedChar(c, "value")
+	}
+
+	return
+}
+
+// parseAnnotation is called after a symbol was parsed, it returns true if the
+// symbol is an annotation, or makes it the next value otherwise.
+func (p *textParser) parseAnnotation() (bool, error) {
+	c, err := p.skipSpace()
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	if err == nil && c == ':' && p.hasPrefix("::") {
+		p.skip(2)
+		return true, nil
+	}
+
+	p.typ = objconv.String
+	return false, nil
+}
+
+func (p *textParser) parseNullType() error {
+	if c, err := p.peekByte(0); err != nil || c != '.' {
+		if err == io.EOF {
+			err = nil
+		}
+		return err
+	}
+	p.skip(1)
+
+	if err := p.parseIdentifier(); err != nil {
+		return err
+	}
+
+	switch string(p.b) {
+	case "null", "bool", "int", "float", "decimal", "timestamp", "symbol", "string", "clob", "blob", "list", "sexp", "struct":
+		return nil
+	}
+
+	return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid type of null value: %q", p.b)
+}
+
+// parseIdentifier loads an unquoted symbol in p.b, symbols of the form $N are
+// resolved with the symbol table.
+func (p *textParser) parseIdentifier() error {
+	p.b = p.b[:0]
+
+	for {
+		c, err := p.peekByte(0)
+		if err == io.EOF || (err == nil && !isIdent(c)) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		p.b = append(p.b, c)
+		p.skip(1)
+	}
+
+	if len(p.b) > 1 && p.b[0] == '$' && isDigits(p.b[1:]) {
+		id, err := strconv.ParseUint(string(p.b[1:]), 10, 64)
+		if err != nil {
+			return numberError(p.b, err)
+		}
+		sym, err := p.symbols.lookup(id)
+		if err != nil {
+			return err
+		}
+		p.b = append(p.b[:0], sym...)
+		return nil
+	}
+
+	p.ident = true
+	return nil
+}
+
+func (p *textParser) parseQuotedSymbol() error {
+	p.b = p.b[:0]
+	return p.parseShortString('\'', false)
+}
+
+// parseOperator loads a symbol made of operator characters, which are only
+// allowed in s-expressions.
+func (p *textParser) parseOperator() {
+	for {
+		c, err := p.peekByte(0)
+		if err != nil || !isOperator(c) {
+			break
+		}
+		p.b = append(p.b, c)
+		p.skip(1)
+	}
+	p.typ = objconv.String
+}
+
+// signedNumber returns true if the sign at the current position starts a
+// number, which is ambiguous in s-expressions where signs are operators.
+func (p *textParser) signedNumber() bool {
+	c, err := p.peekByte(1)
+	return (err == nil && isDigit(c)) || p.hasPrefix("+inf") || p.hasPrefix("-inf")
+}
+
+// parseNumber loads the integer, float, decimal, or timestamp at the current
+// position.
+func (p *textParser) parseNumber() error {
+	for {
+		c, err := p.peekByte(0)
+		if err == io.EOF || (err == nil && !isNumberChar(c)) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		p.b = append(p.b, c)
+		p.skip(1)
+	}
+
+	s := string(p.b)
+
+	switch {
+	case s == "+inf", s == "-inf":
+		p.typ, p.b = objconv.Float, append(p.b[:0], s[:1]+"Inf"...)
+		return nil
+
+	case len(s) > 4 && isDigits(p.b[:4]) && (s[4] == '-' || s[4] == 'T'):
+		t, err := parseTimestamp(s)
+		p.typ, p.t = objconv.Time, t
+		return err
+	}
+
+	// Underscores separate the digits of numbers for readability.
+	s = strings.ReplaceAll(s, "_", "")
+	p.b = append(p.b[:0], s...)
+	u := strings.TrimPrefix(s, "-")
+
+	switch {
+	case len(u) > 2 && u[0] == '0' && (u[1] == 'x' || u[1] == 'X' || u[1] == 'b' || u[1] == 'B'):
+		p.typ = objconv.Int
+
+	case len(u) == 0 || !isDigit(u[0]):
+		return numberError(p.b, strconv.ErrSyntax)
+
+	case strings.ContainsAny(u, "dD"): // decimal with an exponent
+		p.typ = objconv.Float
+		for i, c := range p.b {
+			if c == 'd' || c == 'D' {
+				p.b[i] = 'e'
+			}
+		}
+
+	case strings.ContainsAny(u, ".eE"):
+		p.typ = objconv.Float
+
+	case !isDigits([]byte(u)) || (len(u) > 1 && u[0] == '0'):
+		return numberError(p.b, strconv.ErrSyntax)
+
+	default:
+		p.typ = objconv.Int
+	}
+
+	if p.typ == objconv.Int {
+		if _, err := strconv.ParseInt(s, 0, 64); err != nil {
+			if e, ok := err.(*strconv.NumError); !ok || e.Err != strconv.ErrRange {
+				return numberError(p.b, err)
+			}
+			if _, err := strconv.ParseUint(s, 0, 64); err == nil {
+				p.typ = objconv.Uint
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseLob loads a blob or a clob, the opening braces were consumed.
+func (p *textParser) parseLob() (err error) {
+	var c byte
+
+	if c, err = p.skipSpace(); err != nil {
+		return unexpectedEOF(err)
+	}
+
+	switch {
+	case c == '"':
+		p.skip(1)
+		err = p.parseShortString('"', true)
+
+	case c == '\'' && p.hasPrefix("'''"):
+		err = p.parseLongStrings(true)
+
+	default:
+		// Base64 characters may be separated by whitespaces.
+		for {
+			if c, err = p.skipSpace(); err != nil {
+				return unexpectedEOF(err)
+			}
+			if c == '}' {
+				break
+			}
+			p.b = append(p.b, c)
+			p.skip(1)
+		}
+
+		n := len(p.b)
+		b := append(p.b, make([]byte, base64.StdEncoding.DecodedLen(n))...)
+
+		if n, err = base64.StdEncoding.Decode(b[n:], b[:n]); err != nil {
+			return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid base64 content of blob value: %s", err)
+		}
+
+		p.b = append(b[:0], b[len(p.b):len(p.b)+n]...)
+	}
+
+	if err != nil {
+		return
+	}
+
+	if c, err = p.skipSpace(); err != nil {
+		return unexpectedEOF(err)
+	}
+	if !p.hasPrefix("}}") {
+		return unexpectedChar(c, "'}}'")
+	}
+	p.skip(2)
+	return
+}
+
+// parseShortString appends the content of a string delimited by quote to p.b,
+// the opening quote was consumed.
+func (p *textParser) parseShortString(quote byte, clob bool) error {
+	for {
+		c, err := p.peekByte(0)
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		p.skip(1)
+
+		switch {
+		case c == quote:
+			return nil
+		case c == '\\':
+			if err := p.parseEscape(clob); err != nil {
+				return err
+			}
+		case c == '\n', c == '\r':
+			return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: new lines must be escaped in quoted strings")
+		default:
+			p.b = append(p.b, c)
+		}
+	}
+}
+
+// parseLongStrings appends the content of adjacent long strings to p.b.
+func (p *textParser) parseLongStrings(clob bool) error {
+	for p.hasPrefix("'''") {
+		p.skip(3)
+
+		for !p.hasPrefix("'''") {
+			c, err := p.peekByte(0)
+			if err != nil {
+				return unexpectedEOF(err)
+			}
+			p.skip(1)
+
+			if c == '\\' {
+				if err := p.parseEscape(clob); err != nil {
+					return err
+				}
+			} else {
+				p.b = append(p.b, c)
+			}
+		}
+
+		p.skip(3)
+
+		if _, err := p.skipSpace(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// parseEscape appends the character of the escape sequence at the current
+// position to p.b, the backslash was consumed.
+func (p *textParser) parseEscape(clob bool) error {
+	c, err := p.peekByte(0)
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	p.skip(1)
+
+	switch c {
+	case 'a':
+		c = '\a'
+	case 'b':
+		c = '\b'
+	case 't':
+		c = '\t'
+	case 'n':
+		c = '\n'
+	case 'f':
+		c = '\f'
+	case 'r':
+		c = '\r'
+	case 'v':
+		c = '\v'
+	case '0':
+		c = 0
+	case '?', '/', '\'', '"', '\\':
+	case '\n': // line continuation
+		return nil
+	case '\r':
+		if c, err := p.peekByte(0); err == nil && c == '\n' {
+			p.skip(1)
+		}
+		return nil
+	case 'x':
+		return p.parseHexEscape(2, clob)
+	case 'u', 'U':
+		if !clob {
+			if c == 'u' {
+				return p.parseHexEscape(4, false)
+			}
+			return p.parseHexEscape(8, false)
+		}
+		fallthrough
+	default:
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid escape sequence: '\\%c'", c)
+	}
+
+	p.b = append(p.b, c)
+	return nil
+}
+
+func (p *textParser) parseHexEscape(n int, raw bool) error {
+	b, err := p.read(n)
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+
+	v, err := strconv.ParseUint(string(b), 16, 32)
+	if err != nil {
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid escape sequence: %q", b)
+	}
+
+	if raw {
+		p.b = append(p.b, byte(v))
+		return nil
+	}
+
+	r := rune(v)
+
+	if utf16.IsSurrogate(r) {
+		// Characters outside of the basic plane may be escaped as surrogate
+		// pairs.
+		if b, err := p.peek(6); err == nil && b[0] == '\\' && b[1] == 'u' {
+			if v, err := strconv.ParseUint(string(b[2:]), 16, 32); err == nil {
+				if r = utf16.DecodeRune(r, rune(v)); r != utf8.RuneError {
+					p.skip(6)
+				}
+			}
+		}
+	}
+
+	if !utf8.ValidRune(r) {
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid character in escape sequence: %U", r)
+	}
+
+	p.b = utf8.AppendRune(p.b, r)
+	return nil
+}
+
+// skipSpace skips whitespaces and comments, and returns the next character
+// without consuming it.
+func (p *textParser) skipSpace() (byte, error) {
+	for {
+		c, err := p.peekByte(0)
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case c == ' ', c == '\t', c == '\n', c == '\r', c == '\v', c == '\f':
+			p.skip(1)
+
+		case c == '/' && p.hasPrefix("//"):
+			for {
+				if c, err = p.peekByte(0); err != nil {
+					return 0, err
+				}
+				p.skip(1)
+				if c == '\n' {
+					break
+				}
+			}
+
+		case c == '/' && p.hasPrefix("/*"):
+			p.skip(2)
+			for !p.hasPrefix("*/") {
+				if _, err = p.peekByte(0); err != nil {
+					return 0, unexpectedEOF(err)
+				}
+				p.skip(1)
+			}
+			p.skip(2)
+
+		default:
+			return c, nil
+		}
+	}
+}
+
+func (p *textParser) hasPrefix(s string) bool {
+	b, err := p.peek(len(s))
+	return err == nil && string(b) == s
+}
+
+// parseTimestamp parses the text representation of Ion timestamps, which have
+// the precisions of the year, month, day, minute, second, or fractions of
+// seconds.
+func parseTimestamp(s string) (time.Time, error) {
+	r := timestampReader{s: s}
+
+	year := r.digits(4)
+	month, day := 1, 1
+	hour, minute, second, nsec := 0, 0, 0, 0
+	loc := time.UTC
+
+	switch {
+	case r.next('T'): // year precision
+	case !r.next('-'):
+		r.fail()
+	default:
+		month = r.digits(2)
+
+		switch {
+		case r.next('T'): // month precision
+		case !r.next('-'):
+			r.fail()
+		default:
+			day = r.digits(2)
+
+			if r.next('T') && r.i != len(s) {
+				hour = r.digits(2)
+				r.expect(':')
+				minute = r.digits(2)
+
+				if r.next(':') {
+					second = r.digits(2)
+
+					if r.next('.') {
+						n := 0
+						for r.i != len(s) && isDigit(s[r.i]) {
+							if n != 9 {
+								nsec = 10*nsec + int(s[r.i]-'0')
+								n++
+							}
+							r.i++
+						}
+						if n == 0 {
+							r.fail()
+						}
+						for ; n != 9; n++ {
+							nsec *= 10
+						}
+					}
+				}
+
+				if !r.next('Z') {
+					sign := 1
+
+					switch {
+					case r.next('+'):
+					case r.next('-'):
+						sign = -1
+					default:
+						r.fail()
+					}
+
+					h := r.digits(2)
+					r.expect(':')
+					m := r.digits(2)
+
+					// -00:00 means that the offset is unknown.
+					if offset := 60*h + m; offset != 0 {
+						loc = time.FixedZone("", sign*offset*60)
+					}
+				}
+			}
+		}
+	}
+
+	if r.bad || r.i != len(s) {
+		return time.Time{}, objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid timestamp: %q", s)
+	}
+
+	t := time.Date(year, time.Month(month), day, hour, minute, second, nsec, loc)
+
+	if t.Year() != year || int(t.Month()) != month || t.Day() != day || t.Hour() != hour || t.Minute() != minute || t.Second() != second {
+		return time.Time{}, objutil.Errorf(objutil.ErrRange, "objconv/ion: timestamp out of range: %q", s)
+	}
+
+	return t, nil
+}
+
+type timestampReader struct {
+	s   string
+	i   int
+	bad bool
+}
+
+func (r *timestampReader) digits(n int) (v int) {
+	if len(r.s)-r.i < n {
+		r.fail()
+		return
+	}
+	for _, c := range []byte(r.s[r.i : r.i+n]) {
+		if !isDigit(c) {
+			r.fail()
+			return
+		}
+		v = 10*v + int(c-'0')
+	}
+	r.i += n
+	return
+}
+
+func (r *timestampReader) next(c byte) bool {
+	if r.i != len(r.s) && r.s[r.i] == c {
+		r.i++
+		return true
+	}
+	return false
+}
+
+func (r *timestampReader) expect(c byte) {
+	if !r.next(c) {
+		r.fail()
+	}
+}
+
+func (r *timestampReader) fail() {
+	r.bad = true
+	r.i = len(r.s)
+}
+
+func numberError(b []byte, err error) error {
+	if e, ok := err.(*strconv.NumError); ok {
+		err = e.Err
+	}
+	if err == strconv.ErrRange {
+		return objutil.Errorf(objutil.ErrRange, "objconv/ion: %s overflows 64 bits", b)
+	}
+	return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: invalid number: %q", b)
+}
+
+func unexpectedChar(c byte, expected string) error {
+	return objutil.Errorf(objutil.ErrSyntax, "objconv/ion: expected %s but found '%c'", expected, c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isDigits(b []byte) bool {
+	for _, c := range b {
+		if !isDigit(c) {
+			return false
+		}
+	}
+	return len(b) != 0
+}
+
+func isIdentStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c == '$'
+}
+
+func isIdent(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isNumberChar(c byte) bool {
+	return isIdent(c) || c == '.' || c == '+' || c == '-' || c == ':'
+}
+
+func isOperator(c byte) bool {
+	return strings.IndexByte("!#%&*+-./;<=>?@^`|~", c) >= 0
+}
@@ -0,0 +1,22 @@
+//go:build !purego
+// +build !purego
+
+package json
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/segmentio/objconv"
+)
+
+// The text of appenders is only passed to emitters without being copied when
+// the package is allowed to use unsafe.
+func TestEncoderTextAppenderAllocs(t *testing.T) {
+	v := &version{major: 1, minor: 24}
+	enc := objconv.NewEncoder(NewEmitter(ioutil.Discard))
+
+	if n := testing.AllocsPerRun(100, func() { enc.Encode(v) }); n != 0 {
+		t.Errorf("%v allocations made when encoding a text appender", n)
+	}
+}
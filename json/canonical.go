@@ -0,0 +1,262 @@
+package json
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/segmentio/objconv"
+)
+
+// CanonicalEmitter implements an emitter of the JSON Canonicalization Scheme
+// (RFC 8785): values are written without white spaces, strings only escape the
+// characters that must be escaped, numbers use the ECMAScript representation
+// of floating point numbers, and the entries of maps are sorted by the UTF-16
+// code units of their keys.
+//
+// Maps are buffered until they are complete, raw values are written as they
+// are.
+type CanonicalEmitter struct {
+	Emitter
+
+	// Stack of the maps being buffered, frames are reused by the following
+	// maps at the same depth.
+	frames []*canonicalFrame
+	depth  int
+}
+
+type canonicalFrame struct {
+	w       io.Writer // writer that the frame gets written to
+	buf     bytes.Buffer
+	entries []canonicalEntry
+}
+
+// canonicalEntry holds the offsets of a map entry in the buffer of a frame.
+type canonicalEntry struct {
+	key int
+	val int
+	end int
+}
+
+// NewCanonicalEmitter returns a new emitter that writes the canonical JSON
+// representation of values to w.
+func NewCanonicalEmitter(w io.Writer) *CanonicalEmitter {
+	e := &CanonicalEmitter{}
+	e.init(w)
+	return e
+}
+
+func (e *CanonicalEmitter) Reset(w io.Writer) {
+	e.Emitter.Reset(w)
+	e.depth = 0
+}
+
+// CanonicalEmitter satisfies the objconv.CanonicalEmitter interface.
+func (e *Emitter) CanonicalEmitter() objconv.Emitter {
+	return NewCanonicalEmitter(e.writer())
+}
+
+// CanonicalEmitter satisfies the objconv.CanonicalEmitter interface, the
+// method returns the emitter itself.
+func (e *CanonicalEmitter) CanonicalEmitter() objconv.Emitter {
+	return e
+}
+
+func (e *CanonicalEmitter) EmitFloat(v float64, bitSize int) (err error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return e.Emitter.EmitFloat(v, bitSize)
+	}
+
+	f := byte('f')
+
+	if a := math.Abs(v); a != 0 && (a < 1e-6 || a >= 1e21) {
+		f = 'e'
+	}
+
+	if v == 0 {
+		v = 0 // negative zero is written as 0
+	}
+
+	s := strconv.AppendFloat(e.s[:0], v, f, -1, 64)
+
+	// The exponents of ECMAScript numbers have no leading zeros.
+	if n := len(s); f == 'e' && n >= 4 && s[n-4] == 'e' && s[n-3] == '-' && s[n-2] == '0' {
+		s[n-2] = s[n-1]
+		s = s[:n-1]
+	}
+
+	_, err = e.w.Write(s)
+	return
+}
+
+func (e *CanonicalEmitter) EmitString(v string) (err error) {
+	const hex = "0123456789abcdef"
+
+	i := 0
+	s := append(e.s[:0], '"')
+
+	for j := 0; j != len(v); j++ {
+		b := v[j]
+
+		switch {
+		case b == '"' || b == '\\':
+			s = append(append(s, v[i:j]...), '\\', b)
+		case b == '\b':
+			s = append(append(s, v[i:j]...), '\\', 'b')
+		case b == '\f':
+			s = append(append(s, v[i:j]...), '\\', 'f')
+		case b == '\n':
+			s = append(append(s, v[i:j]...), '\\', 'n')
+		case b == '\r':
+			s = append(append(s, v[i:j]...), '\\', 'r')
+		case b == '\t':
+			s = append(append(s, v[i:j]...), '\\', 't')
+		case b < 0x20:
+			s = append(append(s, v[i:j]...), '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+		default:
+			continue
+		}
+
+		i = j + 1
+	}
+
+	s = append(s, v[i:]...)
+	s = append(s, '"')
+	e.s = s[:0] // in case the buffer was reallocated
+
+	_, err = e.w.Write(s)
+	return
+}
+
+func (e *CanonicalEmitter) EmitError(v error) error {
+	return e.EmitString(v.Error())
+}
+
+func (e *CanonicalEmitter) EmitMapBegin(_ int) (err error) {
+	f := e.push()
+	f.entries = append(f.entries, canonicalEntry{})
+	return
+}
+
+func (e *CanonicalEmitter) EmitMapEnd() (err error) {
+	f := e.pop()
+	b := f.buf.Bytes()
+
+	if len(b) == 0 {
+		f.entries = f.entries[:0]
+	} else {
+		f.entries[len(f.entries)-1].end = len(b)
+	}
+
+	sort.Slice(f.entries, func(i int, j int) bool {
+		e1, e2 := f.entries[i], f.entries[j]
+		return lessUTF16(unquoteKey(b[e1.key:e1.val]), unquoteKey(b[e2.key:e2.val]))
+	})
+
+	if _, err = e.w.Write(mapOpen[:]); err != nil {
+		return
+	}
+
+	for i, x := range f.entries {
+		if i != 0 {
+			if _, err = e.w.Write(comma[:]); err != nil {
+				return
+			}
+		}
+		if _, err = e.w.Write(b[x.key:x.end]); err != nil {
+			return
+		}
+	}
+
+	_, err = e.w.Write(mapClose[:])
+	return
+}
+
+func (e *CanonicalEmitter) EmitMapValue() (err error) {
+	f := e.frames[e.depth-1]
+	f.entries[len(f.entries)-1].val = f.buf.Len()
+	_, err = e.w.Write(column[:])
+	return
+}
+
+func (e *CanonicalEmitter) EmitMapNext() (err error) {
+	f := e.frames[e.depth-1]
+	n := f.buf.Len()
+	f.entries[len(f.entries)-1].end = n
+	f.entries = append(f.entries, canonicalEntry{key: n})
+	return
+}
+
+// push starts buffering a map, the values emitted until the call to pop are
+// written to the buffer of the returned frame.
+func (e *CanonicalEmitter) push() *canonicalFrame {
+	if e.depth == len(e.frames) {
+		e.frames = append(e.frames, &canonicalFrame{})
+	}
+
+	f := e.frames[e.depth]
+	f.w, e.w = e.w, &f.buf
+	f.buf.Reset()
+	f.entries = f.entries[:0]
+
+	e.depth++
+	return f
+}
+
+// pop stops buffering the innermost map, and restores the writer of the
+// emitter.
+func (e *CanonicalEmitter) pop() *canonicalFrame {
+	e.depth--
+	f := e.frames[e.depth]
+	e.w = f.w
+	return f
+}
+
+// unquoteKey returns the value of the JSON string b, or b itself if it isn't
+// a string.
+func unquoteKey(b []byte) string {
+	s := string(b)
+
+	if len(s) < 2 || s[0] != '"' {
+		return s
+	}
+
+	if bytes.IndexByte(b, '\\') < 0 {
+		return s[1 : len(s)-1]
+	}
+
+	// The escape sequences written by the canonical emitter are all valid
+	// escape sequences of Go strings.
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+
+	return s
+}
+
+// lessUTF16 compares the UTF-16 representations of s1 and s2, which differs
+// from the comparison of their UTF-8 representations for the characters that
+// are encoded as surrogate pairs.
+func lessUTF16(s1 string, s2 string) bool {
+	for s1 != "" && s2 != "" {
+		r1, n1 := utf8.DecodeRuneInString(s1)
+		r2, n2 := utf8.DecodeRuneInString(s2)
+
+		if r1 != r2 {
+			switch {
+			case r1 >= 0x10000 && r2 < 0x10000:
+				return 0xD800+((r1-0x10000)>>10) < r2
+			case r1 < 0x10000 && r2 >= 0x10000:
+				return r1 < 0xD800+((r2-0x10000)>>10)
+			default:
+				return r1 < r2
+			}
+		}
+
+		s1, s2 = s1[n1:], s2[n2:]
+	}
+	return len(s1) < len(s2)
+}
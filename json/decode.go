@@ -18,12 +18,19 @@ func NewStreamDecoder(r io.Reader) *objconv.StreamDecoder {
 	return objconv.NewStreamDecoder(NewParser(r))
 }
 
-// Unmarshal decodes a JSON representation of v from b.
+// NewLinesStreamDecoder returns a new newline-delimited JSON stream decoder
+// that parses values from r, each line of the input is a value of the stream.
+func NewLinesStreamDecoder(r io.Reader) *objconv.StreamDecoder {
+	return objconv.NewStreamDecoder(NewLinesParser(r))
+}
+
+// Unmarshal decodes a JSON representation of v from b, an error is returned
+// if b has data after the value.
 func Unmarshal(b []byte, v interface{}) error {
 	u := unmarshalerPool.Get().(*unmarshaler)
 	u.reset(b)
 
-	err := (objconv.Decoder{Parser: u}).Decode(v)
+	err := (objconv.Decoder{Parser: u, DisallowTrailingData: true}).Decode(v)
 
 	u.reset(nil)
 	unmarshalerPool.Put(u)
@@ -2,7 +2,6 @@ package json
 
 import (
 	"encoding/base64"
-	"errors"
 	"io"
 	"math"
 	"strconv"
@@ -27,7 +26,6 @@ var (
 	column = [...]byte{':'}
 
 	newline = [...]byte{'\n'}
-	spaces  = [...]byte{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '}
 )
 
 // Emitter implements a JSON emitter that satisfies the objconv.Emitter
@@ -36,16 +34,54 @@ type Emitter struct {
 	w io.Writer
 	s []byte
 	a [128]byte
+
+	// The emitter writes to this buffer, which gets flushed to the underlying
+	// writer when the encoder has completed writing a value.
+	buf objutil.BufferedWriter
+
+	// Cache of the last formatted time value, batches of records often have
+	// many identical timestamps.
+	times objutil.TimeCache
 }
 
 func NewEmitter(w io.Writer) *Emitter {
-	e := &Emitter{w: w}
-	e.s = e.a[:0]
+	e := &Emitter{}
+	e.init(w)
 	return e
 }
 
+func (e *Emitter) init(w io.Writer) {
+	e.s = e.a[:0]
+	e.Reset(w)
+}
+
 func (e *Emitter) Reset(w io.Writer) {
-	e.w = w
+	e.buf.Reset(w)
+	e.w = &e.buf
+}
+
+// Flush writes the buffered output of the emitter to its underlying writer.
+func (e *Emitter) Flush() error {
+	return e.buf.Flush()
+}
+
+// EmitRaw satisfies the objconv.RawEmitter interface.
+func (e *Emitter) EmitRaw(b []byte) (err error) {
+	_, err = e.w.Write(b)
+	return
+}
+
+// TransientEmitter satisfies the objconv.transientEmitter interface, values
+// are written to the output when they are emitted.
+func (e *Emitter) TransientEmitter() bool {
+	return true
+}
+
+// EmitHeartbeat satisfies the objconv.HeartbeatEmitter interface, JSON
+// heartbeats are newline characters.
+func (e *Emitter) EmitHeartbeat() (err error) {
+	_, err = e.w.Write(newline[:])
+	return
 }
 
 func (e *Emitter) EmitNil() (err error) {
@@ -75,13 +111,13 @@ func (e *Emitter) EmitUint(v uint64, _ int) (err error) {
 func (e *Emitter) EmitFloat(v float64, bitSize int) (err error) {
 	switch {
 	case math.IsNaN(v):
-		err = errors.New("NaN has no json representation")
+		err = objutil.Errorf(objutil.ErrRange, "NaN has no json representation")
 
 	case math.IsInf(v, +1):
-		err = errors.New("+Inf has no json representation")
+		err = objutil.Errorf(objutil.ErrRange, "+Inf has no json representation")
 
 	case math.IsInf(v, -1):
-		err = errors.New("-Inf has no json representation")
+		err = objutil.Errorf(objutil.ErrRange, "-Inf has no json representation")
 
 	default:
 		_, err = e.w.Write(strconv.AppendFloat(e.s[:0], v, 'g', -1, bitSize))
@@ -157,7 +193,7 @@ func (e *Emitter) EmitTime(v time.Time) (err error) {
 	s := e.s[:0]
 
 	s = append(s, '"')
-	s = v.AppendFormat(s, time.RFC3339Nano)
+	s = e.times.AppendTime(s, v)
 	s = append(s, '"')
 
 	e.s = s[:0]
@@ -221,7 +257,20 @@ func (e *Emitter) TextEmitter() bool {
 }
 
 func (e *Emitter) PrettyEmitter() objconv.Emitter {
-	return NewPrettyEmitter(e.w)
+	return NewPrettyEmitter(e.writer())
+}
+
+// FormatEmitter satisfies the objconv.FormatEmitter interface.
+func (e *Emitter) FormatEmitter(f objconv.Format) objconv.Emitter {
+	return NewFormatEmitter(e.writer(), f)
+}
+
+func (e *Emitter) writer() io.Writer {
+	if e.w == io.Writer(&e.buf) {
+		e.Flush()
+		return e.buf.Writer()
+	}
+	return e.w
 }
 
 func align(n int, a int) int {
@@ -231,17 +280,63 @@ func align(n int, a int) int {
 	return ((n / a) + 1) * a
 }
 
+// PrettyEmitter implements a JSON emitter which writes values in the layout
+// configured by a formatting policy.
 type PrettyEmitter struct {
 	Emitter
 	i int
 	s []int
 	a [8]int
+
+	pre   []byte // prefix of each line after the first
+	tab   []byte // indentation of each nesting level
+	space []byte // spacing after separators
+	width int    // maximum length of arrays written on a single line
+
+	// The array being written on a single line, if any.
+	line lineBuffer
+}
+
+// lineBuffer holds the output of an array until it is known to fit on a single
+// line, the offsets of its elements are recorded so it can be wrapped when it
+// doesn't.
+type lineBuffer struct {
+	w     io.Writer // writer of the emitter while the array is buffered
+	b     []byte
+	elems []int
+	depth int // depth of the array on the stack of the emitter
+	on    bool
 }
 
+func (l *lineBuffer) Write(b []byte) (int, error) {
+	l.b = append(l.b, b...)
+	return len(b), nil
+}
+
+func (l *lineBuffer) reset() {
+	l.w = nil
+	l.b = l.b[:0]
+	l.elems = l.elems[:0]
+	l.depth = 0
+	l.on = false
+}
+
+// NewPrettyEmitter returns a new emitter that writes JSON values to w in the
+// layout configured by objconv.DefaultFormat.
 func NewPrettyEmitter(w io.Writer) *PrettyEmitter {
+	return NewFormatEmitter(w, objconv.DefaultFormat)
+}
+
+// NewFormatEmitter returns a new emitter that writes JSON values to w in the
+// layout configured by f.
+func NewFormatEmitter(w io.Writer, f objconv.Format) *PrettyEmitter {
 	e := &PrettyEmitter{
-		Emitter: *NewEmitter(w),
+		pre:   []byte(f.Prefix),
+		tab:   []byte(f.Indent),
+		space: []byte(f.Space),
+		width: f.Width,
 	}
+	e.Emitter.init(w)
 	e.s = e.a[:0]
 	return e
 }
@@ -250,19 +345,41 @@ func (e *PrettyEmitter) Reset(w io.Writer) {
 	e.Emitter.Reset(w)
 	e.i = 0
 	e.s = e.s[:0]
+	e.line.reset()
 }
 
 func (e *PrettyEmitter) EmitArrayBegin(n int) (err error) {
+	if err = e.begin(n); err != nil {
+		return
+	}
 	if err = e.Emitter.EmitArrayBegin(n); err != nil {
 		return
 	}
 	if e.push(n) != 0 {
+		if e.width > 0 && len(e.tab) != 0 {
+			e.line.w, e.line.on, e.line.depth = e.w, true, len(e.s)
+			e.line.elems = append(e.line.elems, 0)
+			e.w = &e.line
+			return
+		}
 		err = e.indent()
 	}
 	return
 }
 
 func (e *PrettyEmitter) EmitArrayEnd() (err error) {
+	if e.line.on && e.line.depth == len(e.s) {
+		if e.fits() {
+			e.pop()
+			if err = e.inline(); err != nil {
+				return
+			}
+			return e.Emitter.EmitArrayEnd()
+		}
+		if err = e.wrap(); err != nil {
+			return
+		}
+	}
 	if e.pop() != 0 {
 		if err = e.indent(); err != nil {
 			return
@@ -275,10 +392,21 @@ func (e *PrettyEmitter) EmitArrayNext() (err error) {
 	if err = e.Emitter.EmitArrayNext(); err != nil {
 		return
 	}
-	return e.indent()
+	if e.line.on {
+		e.line.Write(e.space)
+		e.line.elems = append(e.line.elems, len(e.line.b))
+		if !e.fits() {
+			err = e.wrap()
+		}
+		return
+	}
+	return e.next()
 }
 
 func (e *PrettyEmitter) EmitMapBegin(n int) (err error) {
+	if err = e.begin(n); err != nil {
+		return
+	}
 	if err = e.Emitter.EmitMapBegin(n); err != nil {
 		return
 	}
@@ -301,7 +429,7 @@ func (e *PrettyEmitter) EmitMapValue() (err error) {
 	if err = e.Emitter.EmitMapValue(); err != nil {
 		return
 	}
-	_, err = e.w.Write(spaces[:1])
+	_, err = e.w.Write(e.space)
 	return
 }
 
@@ -309,31 +437,93 @@ func (e *PrettyEmitter) EmitMapNext() (err error) {
 	if err = e.Emitter.EmitMapNext(); err != nil {
 		return
 	}
-	return e.indent()
+	return e.next()
 }
 
 func (e *PrettyEmitter) TextEmitter() bool {
 	return true
 }
 
+// begin is called before arrays and maps of length n are written, arrays that
+// contain non-empty arrays or maps are never written on a single line.
+func (e *PrettyEmitter) begin(n int) error {
+	if e.line.on && n != 0 {
+		return e.wrap()
+	}
+	return nil
+}
+
+// next is called after the separators of array elements and map items.
+func (e *PrettyEmitter) next() (err error) {
+	if len(e.tab) != 0 {
+		return e.indent()
+	}
+	_, err = e.w.Write(e.space)
+	return
+}
+
+func (e *PrettyEmitter) fits() bool {
+	return len(arrayOpen)+len(e.line.b)+len(arrayClose) <= e.width
+}
+
+// inline writes the buffered array on a single line.
+func (e *PrettyEmitter) inline() (err error) {
+	e.w = e.line.w
+	_, err = e.w.Write(e.line.b)
+	e.line.reset()
+	return
+}
+
+// wrap writes the elements of the buffered array on separate lines, the
+// emitter then continues writing the array as if it had never been buffered.
+func (e *PrettyEmitter) wrap() (err error) {
+	l := &e.line
+	e.w = l.w
+	sep := len(comma) + len(e.space)
+
+	for i, off := range l.elems {
+		end := len(l.b)
+
+		if i+1 < len(l.elems) {
+			end = l.elems[i+1] - sep
+		}
+
+		if i != 0 {
+			if _, err = e.w.Write(comma[:]); err != nil {
+				break
+			}
+		}
+
+		if err = e.indent(); err != nil {
+			break
+		}
+
+		if _, err = e.w.Write(l.b[off:end]); err != nil {
+			break
+		}
+	}
+
+	l.reset()
+	return
+}
+
 func (e *PrettyEmitter) indent() (err error) {
-	if _, err = e.w.Write(newline[:]); err != nil {
+	if len(e.tab) == 0 {
 		return
 	}
 
-	for n := 2 * e.i; n != 0; {
-		n1 := n
-		n2 := len(spaces)
+	if _, err = e.w.Write(newline[:]); err != nil {
+		return
+	}
 
-		if n1 > n2 {
-			n1 = n2
-		}
+	if _, err = e.w.Write(e.pre); err != nil {
+		return
+	}
 
-		if _, err = e.w.Write(spaces[:n1]); err != nil {
+	for n := e.i; n != 0; n-- {
+		if _, err = e.w.Write(e.tab); err != nil {
 			return
 		}
-
-		n -= n1
 	}
 
 	return
@@ -356,3 +546,133 @@ func (e *PrettyEmitter) pop() int {
 	}
 	return n
 }
+
+// LinesEmitter implements an emitter of newline-delimited JSON (NDJSON, also
+// known as JSON Lines). Top-level arrays are unbounded streams of documents,
+// their elements are written on their own lines instead of being enclosed in
+// brackets, so the stream encoders produce one record per line.
+type LinesEmitter struct {
+	Emitter
+	depth int  // nesting level of the arrays and maps being written
+	seq   bool // whether the top-level array was opened
+}
+
+// NewLinesEmitter returns a new emitter that writes newline-delimited JSON
+// values to w.
+func NewLinesEmitter(w io.Writer) *LinesEmitter {
+	e := &LinesEmitter{}
+	e.init(w)
+	return e
+}
+
+func (e *LinesEmitter) Reset(w io.Writer) {
+	e.Emitter.Reset(w)
+	e.depth = 0
+	e.seq = false
+}
+
+// EmitRaw satisfies the objconv.RawEmitter interface, raw values must not
+// contain line breaks.
+func (e *LinesEmitter) EmitRaw(b []byte) error {
+	return e.end(e.Emitter.EmitRaw(b))
+}
+
+func (e *LinesEmitter) EmitNil() error {
+	return e.end(e.Emitter.EmitNil())
+}
+
+func (e *LinesEmitter) EmitBool(v bool) error {
+	return e.end(e.Emitter.EmitBool(v))
+}
+
+func (e *LinesEmitter) EmitInt(v int64, bitSize int) error {
+	return e.end(e.Emitter.EmitInt(v, bitSize))
+}
+
+func (e *LinesEmitter) EmitUint(v uint64, bitSize int) error {
+	return e.end(e.Emitter.EmitUint(v, bitSize))
+}
+
+func (e *LinesEmitter) EmitFloat(v float64, bitSize int) error {
+	return e.end(e.Emitter.EmitFloat(v, bitSize))
+}
+
+func (e *LinesEmitter) EmitString(v string) error {
+	return e.end(e.Emitter.EmitString(v))
+}
+
+func (e *LinesEmitter) EmitBytes(v []byte) error {
+	return e.end(e.Emitter.EmitBytes(v))
+}
+
+func (e *LinesEmitter) EmitTime(v time.Time) error {
+	return e.end(e.Emitter.EmitTime(v))
+}
+
+func (e *LinesEmitter) EmitDuration(v time.Duration) error {
+	return e.end(e.Emitter.EmitDuration(v))
+}
+
+func (e *LinesEmitter) EmitError(v error) error {
+	return e.EmitString(v.Error())
+}
+
+func (e *LinesEmitter) EmitArrayBegin(n int) error {
+	if e.depth == 0 && !e.seq {
+		e.seq = true
+		return nil
+	}
+	e.depth++
+	return e.Emitter.EmitArrayBegin(n)
+}
+
+func (e *LinesEmitter) EmitArrayEnd() error {
+	if e.depth == 0 && e.seq {
+		e.seq = false
+		return nil
+	}
+	e.depth--
+	return e.end(e.Emitter.EmitArrayEnd())
+}
+
+func (e *LinesEmitter) EmitArrayNext() error {
+	if e.depth == 0 && e.seq {
+		return nil
+	}
+	return e.Emitter.EmitArrayNext()
+}
+
+func (e *LinesEmitter) EmitMapBegin(n int) error {
+	e.depth++
+	return e.Emitter.EmitMapBegin(n)
+}
+
+func (e *LinesEmitter) EmitMapEnd() error {
+	e.depth--
+	return e.end(e.Emitter.EmitMapEnd())
+}
+
+// PrettyEmitter returns e, the documents of newline-delimited JSON streams
+// cannot span multiple lines.
+func (e *LinesEmitter) PrettyEmitter() objconv.Emitter {
+	return e
+}
+
+// FormatEmitter returns e, see PrettyEmitter.
+func (e *LinesEmitter) FormatEmitter(f objconv.Format) objconv.Emitter {
+	return e
+}
+
+// CanonicalEmitter returns e, newline-delimited JSON streams have no canonical
+// representation.
+func (e *LinesEmitter) CanonicalEmitter() objconv.Emitter {
+	return e
+}
+
+// end terminates the line of top-level values.
+func (e *LinesEmitter) end(err error) error {
+	if err == nil && e.depth == 0 {
+		_, err = e.w.Write(newline[:])
+	}
+	return err
+}
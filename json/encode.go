@@ -28,6 +28,12 @@ func NewPrettyStreamEncoder(w io.Writer) *objconv.StreamEncoder {
 	return objconv.NewStreamEncoder(NewPrettyEmitter(w))
 }
 
+// NewLinesStreamEncoder returns a new newline-delimited JSON stream encoder
+// that writes to w, each value of the stream is written on its own line.
+func NewLinesStreamEncoder(w io.Writer) *objconv.StreamEncoder {
+	return objconv.NewStreamEncoder(NewLinesEmitter(w))
+}
+
 // Marshal writes the JSON representation of v to a byte slice returned in b.
 func Marshal(v interface{}) (b []byte, err error) {
 	m := marshalerPool.Get().(*marshaler)
@@ -42,6 +48,22 @@ func Marshal(v interface{}) (b []byte, err error) {
 	return
 }
 
+// Append appends the JSON representation of v to dst and returns the extended
+// slice, dst is returned unchanged if an error occurs. Unlike Marshal, it
+// doesn't allocate memory when dst has enough capacity.
+func Append(dst []byte, v interface{}) (b []byte, err error) {
+	m := marshalerPool.Get().(*marshaler)
+	m.b.Truncate(0)
+	b = dst
+
+	if err = (objconv.Encoder{Emitter: m}).Encode(v); err == nil {
+		b = append(dst, m.b.Bytes()...)
+	}
+
+	marshalerPool.Put(m)
+	return
+}
+
 var marshalerPool = sync.Pool{
 	New: func() interface{} { return newMarshaler() },
 }
@@ -18,6 +18,13 @@ var PrettyCodec = objconv.Codec{
 	NewParser:  func(r io.Reader) objconv.Parser { return NewParser(r) },
 }
 
+// LinesCodec for the newline-delimited JSON format (NDJSON, also known as JSON
+// Lines), where streams are top-level arrays written one element per line.
+var LinesCodec = objconv.Codec{
+	NewEmitter: func(w io.Writer) objconv.Emitter { return NewLinesEmitter(w) },
+	NewParser:  func(r io.Reader) objconv.Parser { return NewLinesParser(r) },
+}
+
 func init() {
 	for _, name := range [...]string{
 		"application/json",
@@ -26,4 +33,11 @@ func init() {
 	} {
 		objconv.Register(name, Codec)
 	}
+
+	for _, name := range [...]string{
+		"application/x-ndjson",
+		"ndjson",
+	} {
+		objconv.Register(name, LinesCodec)
+	}
 }
@@ -1,11 +1,21 @@
 package json
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math"
+	"net"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
+	"github.com/segmentio/objconv"
 	"github.com/segmentio/objconv/objtests"
 )
 
@@ -17,6 +27,56 @@ func BenchmarkCodec(b *testing.B) {
 	objtests.BenchmarkCodec(b, Codec)
 }
 
+func TestRawMessage(t *testing.T) {
+	objtests.TestRawMessage(t, Codec)
+}
+
+func TestStreamHeartbeat(t *testing.T) {
+	objtests.TestStreamHeartbeat(t, Codec)
+}
+
+func TestStreamCloseWithError(t *testing.T) {
+	objtests.TestStreamCloseWithError(t, Codec)
+}
+
+func TestStreamDecodeAt(t *testing.T) {
+	objtests.TestStreamDecodeAt(t, Codec)
+}
+
+func TestGolden(t *testing.T) {
+	type item struct {
+		Name  string            `objconv:"name"`
+		Tags  []string          `objconv:"tags"`
+		Attrs map[string]string `objconv:"attrs"`
+	}
+
+	objtests.Golden(t, Codec, item{
+		Name:  "hammer",
+		Tags:  []string{"tool"},
+		Attrs: map[string]string{"weight": "1kg", "color": "red"},
+	}, "testdata/golden.json")
+}
+
+func TestAppend(t *testing.T) {
+	objtests.TestAppend(t, Codec, Append)
+}
+
+func TestAppendAllocs(t *testing.T) {
+	objtests.TestAppendAllocs(t, Append)
+}
+
+func TestEncoderAllocs(t *testing.T) {
+	objtests.TestEncoderAllocs(t, Codec)
+}
+
+func TestTypedEncoderAllocs(t *testing.T) {
+	objtests.TestTypedEncoderAllocs(t, Codec)
+}
+
+func BenchmarkEncoderAllocs(b *testing.B) {
+	objtests.BenchmarkEncoderAllocs(b, Codec)
+}
+
 func TestPrettyCodec(t *testing.T) {
 	objtests.TestCodec(t, PrettyCodec)
 }
@@ -25,6 +85,88 @@ func BenchmarkPrettyCodec(b *testing.B) {
 	objtests.BenchmarkCodec(b, PrettyCodec)
 }
 
+func TestFormatCodec(t *testing.T) {
+	objtests.TestCodec(t, objconv.Codec{
+		NewEmitter: func(w io.Writer) objconv.Emitter {
+			return NewFormatEmitter(w, objconv.Format{Indent: "\t", Space: " ", Width: 40})
+		},
+		NewParser: Codec.NewParser,
+	})
+}
+
+func TestFormatEmitter(t *testing.T) {
+	v := map[string]interface{}{
+		"A": []int{1, 2, 3},
+		"B": []interface{}{"Hello World!", []int{}, map[string]int{"C": 42}},
+		"D": []int{},
+	}
+
+	tests := []struct {
+		f objconv.Format
+		s string
+	}{
+		{
+			f: objconv.DefaultFormat,
+			s: "{\n  \"A\": [\n    1,\n    2,\n    3\n  ],\n  \"B\": [\n    \"Hello World!\",\n    [],\n    {\n      \"C\": 42\n    }\n  ],\n  \"D\": []\n}",
+		},
+		{
+			f: objconv.Format{Indent: "\t", Space: " ", Width: 12},
+			s: "{\n\t\"A\": [1, 2, 3],\n\t\"B\": [\n\t\t\"Hello World!\",\n\t\t[],\n\t\t{\n\t\t\t\"C\": 42\n\t\t}\n\t],\n\t\"D\": []\n}",
+		},
+		{
+			f: objconv.Format{Indent: "  ", Width: 4},
+			s: "{\n  \"A\":[\n    1,\n    2,\n    3\n  ],\n  \"B\":[\n    \"Hello World!\",\n    [],\n    {\n      \"C\":42\n    }\n  ],\n  \"D\":[]\n}",
+		},
+		{
+			f: objconv.Format{Space: " "},
+			s: `{"A": [1, 2, 3], "B": ["Hello World!", [], {"C": 42}], "D": []}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%q", test.f.Indent), func(t *testing.T) {
+			b := &bytes.Buffer{}
+			e := objconv.Encoder{Emitter: NewEmitter(b).FormatEmitter(test.f), SortMapKeys: true}
+
+			if err := e.Encode(v); err != nil {
+				t.Fatal(err)
+			}
+
+			if s := b.String(); s != test.s {
+				t.Errorf("bad output:\n%s", s)
+			}
+
+			var x interface{}
+
+			if err := Unmarshal(b.Bytes(), &x); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestEncoderIndent(t *testing.T) {
+	b := &bytes.Buffer{}
+	e := NewStreamEncoder(b)
+	e.Indent("//", "\t")
+
+	for _, v := range []interface{}{1, map[string][]int{"A": {1, 2}}} {
+		if err := e.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	const s = "[\n//\t1,\n//\t{\n//\t\t\"A\": [\n//\t\t\t1,\n//\t\t\t2\n//\t\t]\n//\t}\n//]"
+
+	if b.String() != s {
+		t.Errorf("bad output:\n%s", b.String())
+	}
+}
+
 func TestUnicode(t *testing.T) {
 	tests := []struct {
 		in  string
@@ -85,3 +227,826 @@ func TestEmitImpossibleFloats(t *testing.T) {
 		})
 	}
 }
+
+type writeCounter struct {
+	bytes.Buffer
+	n int
+}
+
+func (w *writeCounter) Write(b []byte) (int, error) {
+	w.n++
+	return w.Buffer.Write(b)
+}
+
+func TestEncoderWritesOncePerValue(t *testing.T) {
+	w := &writeCounter{}
+	e := NewEncoder(w)
+
+	v := struct {
+		A int
+		B []string
+		C map[string]int
+	}{42, []string{"a", "b", "c"}, map[string]int{"answer": 42}}
+
+	if err := e.Encode(v); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.n != 1 {
+		t.Error("bad number of writes:", w.n)
+	}
+
+	if s := w.String(); s != `{"A":42,"B":["a","b","c"],"C":{"answer":42}}` {
+		t.Error("bad output:", s)
+	}
+}
+
+func TestStreamEncoderFlushesEachValue(t *testing.T) {
+	w := &writeCounter{}
+	e := NewStreamEncoder(w)
+
+	for i := 0; i != 3; i++ {
+		if err := e.Encode(i); err != nil {
+			t.Fatal(err)
+		}
+		if w.n != i+1 {
+			t.Error("the stream encoder did not flush value", i)
+		}
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := w.String(); s != "[0,1,2]" {
+		t.Error("bad output:", s)
+	}
+}
+
+func TestEncodeRepeatedTimes(t *testing.T) {
+	t0 := time.Date(2017, 6, 1, 12, 30, 0, 0, time.UTC)
+	t1 := t0.Add(time.Second)
+
+	b, err := Marshal([]time.Time{t0, t0, t1, t1, t0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const s = `["2017-06-01T12:30:00Z","2017-06-01T12:30:00Z","2017-06-01T12:30:01Z","2017-06-01T12:30:01Z","2017-06-01T12:30:00Z"]`
+
+	if string(b) != s {
+		t.Error("bad output:", string(b))
+	}
+}
+
+func TestEncoderSortMapKeys(t *testing.T) {
+	tests := []interface{}{
+		map[string]string{"C": "3", "A": "1", "B": "2"},
+		map[string]interface{}{"C": 3, "A": 1, "B": 2},
+		map[interface{}]interface{}{"C": 3, "A": 1, "B": 2},
+		&map[string]string{"C": "3", "A": "1", "B": "2"},
+	}
+
+	for _, test := range tests {
+		b := &bytes.Buffer{}
+		e := NewEncoder(b)
+		e.SortMapKeys = true
+
+		if err := e.Encode(test); err != nil {
+			t.Fatal(err)
+		}
+
+		if s := b.String(); s != `{"A":"1","B":"2","C":"3"}` && s != `{"A":1,"B":2,"C":3}` {
+			t.Errorf("%T: keys were not sorted: %s", test, s)
+		}
+	}
+}
+
+func TestDecoderAllocs(t *testing.T) {
+	var (
+		b bool
+		i int64
+		f float64
+		s string
+		d time.Time
+	)
+
+	tests := []struct {
+		in string
+		to interface{}
+	}{
+		{`true`, &b},
+		{`42`, &i},
+		{`0.5`, &f},
+		{`""`, &s},
+		{`"2016-12-20T00:20:01Z"`, &d},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%T", test.to), func(t *testing.T) {
+			r := strings.NewReader(test.in)
+			p := NewParser(r)
+			d := objconv.NewDecoder(p)
+
+			n := testing.AllocsPerRun(100, func() {
+				r.Reset(test.in)
+				p.Reset(r)
+				d.Decode(test.to)
+			})
+
+			if n != 0 {
+				t.Errorf("%v allocations made when decoding %s", n, test.in)
+			}
+		})
+	}
+}
+
+func TestDecoderWarnings(t *testing.T) {
+	type point struct {
+		X float32 `objconv:"x"`
+		Y float32 `objconv:"y"`
+	}
+
+	type shape struct {
+		Name   string  `objconv:"name"`
+		Points []point `objconv:"points"`
+	}
+
+	var warnings []string
+	var v shape
+
+	d := NewDecoder(strings.NewReader(`{"name":"A","color":"red","points":[{"x":1,"y":0.1},{"x":2}]}`))
+	d.Warn = func(w objconv.Warning) { warnings = append(warnings, w.String()) }
+
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		`objconv: unknown field: the input has a field named "color" which doesn't exist in json.shape`,
+		`objconv: lossy conversion: 0.1 cannot be represented exactly as float32 in field "y"`,
+		`objconv: unused field: field "y" of json.point was absent from the input`,
+	}
+
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("bad warnings:\n%s", strings.Join(warnings, "\n"))
+	}
+}
+
+func TestDecoderErrorKinds(t *testing.T) {
+	tests := []struct {
+		in   string
+		to   interface{}
+		kind error
+	}{
+		{`{"A":}`, new(map[string]int), objconv.ErrSyntax},
+		{`[true`, new([]int), objconv.ErrType},
+		{`"hello"`, new([]int), objconv.ErrType},
+		{`1000`, new(int8), objconv.ErrRange},
+		{`[1]`, new([2]int), objconv.ErrRange},
+		{`{"A":`, new(map[string]int), objconv.ErrSyntax},
+		{`[1,`, new([]int), objconv.ErrSyntax},
+		{`"abc`, new(string), objconv.ErrSyntax},
+		{`tru`, new(interface{}), objconv.ErrSyntax},
+		{`"x"`, new(int), objconv.ErrType},
+		{`{"A":"x"}`, new(struct{ A uint }), objconv.ErrType},
+		{`"1e999"`, new(float64), objconv.ErrRange},
+		{`"yes"`, new(bool), objconv.ErrType},
+		{`"1 day"`, new(time.Duration), objconv.ErrType},
+	}
+
+	for _, test := range tests {
+		err := NewDecoder(strings.NewReader(test.in)).Decode(test.to)
+
+		if !errors.Is(err, test.kind) {
+			t.Errorf("decoding %s into %T: expected an error of kind %q but got %v", test.in, test.to, test.kind, err)
+		}
+	}
+
+	// A truncated input is not the end of the input.
+	err := NewDecoder(strings.NewReader(`{"A":[1`)).Decode(new(interface{}))
+
+	if err == io.EOF || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("bad error for a truncated input: %v", err)
+	}
+
+	if err := NewDecoder(strings.NewReader(` `)).Decode(new(interface{})); err != io.EOF {
+		t.Errorf("expected io.EOF for an empty input but got %v", err)
+	}
+
+	if err := objconv.Transcode(NewEmitter(io.Discard), NewParser(strings.NewReader(`[1,`))); !errors.Is(err, objconv.ErrSyntax) {
+		t.Errorf("bad error transcoding a truncated input: %v", err)
+	}
+}
+
+func TestDecoderPositions(t *testing.T) {
+	type server struct {
+		Host string `objconv:"host"`
+		Port int    `objconv:"port"`
+	}
+
+	type config struct {
+		Name    string   `objconv:"name"`
+		Servers []server `objconv:"servers"`
+	}
+
+	const in = `{
+  "name": "example",
+  "servers": [
+    {"host": "a", "port": 80},
+    {
+      "host": "b",
+      "prot": 443
+    }
+  ]
+}`
+
+	// The one byte reader forces the parser to discard bytes from its buffer
+	// between each value.
+	positions := map[string]objconv.Position{}
+	d := NewDecoder(iotest.OneByteReader(strings.NewReader(in)))
+	d.Positions = positions
+
+	var c config
+
+	if err := d.Decode(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]objconv.Position{
+		"name":            {Line: 2, Column: 11},
+		"servers":         {Line: 3, Column: 14},
+		"servers[0]":      {Line: 4, Column: 5},
+		"servers[0].host": {Line: 4, Column: 14},
+		"servers[0].port": {Line: 4, Column: 27},
+		"servers[1]":      {Line: 5, Column: 5},
+		"servers[1].host": {Line: 6, Column: 15},
+		"servers[1].prot": {Line: 7, Column: 7},
+	}
+
+	if !reflect.DeepEqual(positions, expected) {
+		t.Errorf("bad positions:\n%v\n%v", positions, expected)
+	}
+}
+
+func TestDecoderFieldErrors(t *testing.T) {
+	type item struct {
+		Metadata struct {
+			CreatedAt int64 `objconv:"created_at"`
+		} `objconv:"metadata"`
+	}
+
+	const in = `{"items": [
+  {},
+  {"metadata": {"created_at": "yesterday"}}
+]}`
+
+	var v struct {
+		Items []item `objconv:"items"`
+	}
+
+	err := NewDecoder(strings.NewReader(in)).Decode(&v)
+
+	var fe *objconv.FieldError
+
+	if !errors.As(err, &fe) {
+		t.Fatalf("bad error: %v", err)
+	}
+
+	if fe.Path != "items[1].metadata.created_at" || fe.Pos != (objconv.Position{Line: 3, Column: 42}) || fe.Offset != 59 {
+		t.Errorf("bad field error: %s %s %d", fe.Path, fe.Pos, fe.Offset)
+	}
+
+	if s := err.Error(); !strings.HasPrefix(s, "items[1].metadata.created_at (line 3, column 42): ") {
+		t.Errorf("bad error message: %s", s)
+	}
+}
+
+func TestDecoderUseNumber(t *testing.T) {
+	const in = `{"id":18446744073709551615,"price":0.10,"big":1e400,"n":-3}`
+
+	var v map[string]interface{}
+
+	d := NewDecoder(strings.NewReader(in))
+	d.UseNumber = true
+
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"id":    objconv.Number("18446744073709551615"),
+		"price": objconv.Number("0.10"),
+		"big":   objconv.Number("1e400"),
+		"n":     objconv.Number("-3"),
+	}
+
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("bad value: %#v", v)
+	}
+
+	if b, err := Marshal(v["id"]); err != nil || string(b) != "18446744073709551615" {
+		t.Errorf("bad encoding: %s (%v)", b, err)
+	}
+
+	if err := d.Decode(&v); err != io.EOF {
+		t.Errorf("bad error: %v", err)
+	}
+
+	d = NewDecoder(strings.NewReader(`[1-2]`))
+	d.UseNumber = true
+
+	if err := d.Decode(new(interface{})); !errors.Is(err, objconv.ErrSyntax) {
+		t.Errorf("bad error: %v", err)
+	}
+}
+
+func TestStreamDecoderSequence(t *testing.T) {
+	tests := []struct {
+		in  string
+		out []interface{}
+	}{
+		{"", []interface{}{}},
+		{"1\n2\n3\n", []interface{}{int64(1), int64(2), int64(3)}},
+		{`"A" "B"`, []interface{}{"A", "B"}},
+		{"\x1e1\n\x1e2\n", []interface{}{int64(1), int64(2)}},
+		{"{\"A\":1}\n[true]\nnull", []interface{}{
+			map[interface{}]interface{}{"A": int64(1)},
+			[]interface{}{true},
+			nil,
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			d := NewStreamDecoder(strings.NewReader(test.in))
+			d.Sequence = true
+
+			values := []interface{}{}
+
+			for {
+				var v interface{}
+				if d.Decode(&v) != nil {
+					break
+				}
+				values = append(values, v)
+			}
+
+			if err := d.Err(); err != nil {
+				t.Error(err)
+			}
+
+			if !reflect.DeepEqual(values, test.out) {
+				t.Errorf("%#v != %#v", test.out, values)
+			}
+		})
+	}
+}
+
+func TestStreamDecoderMapElements(t *testing.T) {
+	// The map elements of streams are checked for error records, they must
+	// still be decoded with all the features of the parser.
+	d := NewStreamDecoder(strings.NewReader(`[{"a":1}, {"b":[2]}]`))
+
+	for _, expected := range []string{`{"a":1}`, `{"b":[2]}`} {
+		var m objconv.RawMessage
+
+		if err := d.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+
+		if string(m) != expected {
+			t.Errorf("bad raw message: %s", m)
+		}
+	}
+
+	d = NewStreamDecoder(strings.NewReader(`[{"n":12345678901234567890123}]`))
+	d.UseNumber = true
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(v, map[interface{}]interface{}{"n": objconv.Number("12345678901234567890123")}) {
+		t.Errorf("bad value: %#v", v)
+	}
+
+	const in = `[{"n":"x"}]`
+
+	var x struct {
+		N int `objconv:"n"`
+	}
+
+	var plain, stream *objconv.FieldError
+
+	if err := NewDecoder(strings.NewReader(in[1 : len(in)-1])).Decode(&x); !errors.As(err, &plain) {
+		t.Fatalf("bad error: %v", err)
+	}
+
+	if err := NewStreamDecoder(strings.NewReader(in)).Decode(&x); !errors.As(err, &stream) {
+		t.Fatalf("bad error: %v", err)
+	}
+
+	if stream.Offset != plain.Offset+1 || stream.Pos != (objconv.Position{Line: plain.Pos.Line, Column: plain.Pos.Column + 1}) || stream.Offset == 0 {
+		t.Errorf("bad field error position: %s %d (plain decoder: %s %d)", stream.Pos, stream.Offset, plain.Pos, plain.Offset)
+	}
+}
+
+func TestStreamDecoderResync(t *testing.T) {
+	tests := []struct {
+		in      string
+		out     []interface{}
+		skipped []objconv.SkippedRange
+	}{
+		{
+			in:  "1\n2\n",
+			out: []interface{}{int64(1), int64(2)},
+		},
+		{
+			in:      "1\n?\n3\n",
+			out:     []interface{}{int64(1), int64(3)},
+			skipped: []objconv.SkippedRange{{Offset: 2, Length: 2}},
+		},
+		{
+			in:      "{\"A\":\n[1,2}\n{}\n",
+			out:     []interface{}{map[interface{}]interface{}{}},
+			skipped: []objconv.SkippedRange{{Offset: 0, Length: 12}},
+		},
+		{
+			in:      "\x1e[1\x1e[2]\n",
+			out:     []interface{}{[]interface{}{int64(2)}},
+			skipped: []objconv.SkippedRange{{Offset: 1, Length: 3}},
+		},
+		{
+			in:      "1\n{\"A\":",
+			out:     []interface{}{int64(1)},
+			skipped: []objconv.SkippedRange{{Offset: 2, Length: 5}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			var skipped []objconv.SkippedRange
+
+			d := NewStreamDecoder(strings.NewReader(test.in))
+			d.Sequence = true
+			d.Resync = func(r objconv.SkippedRange) {
+				if r.Err == nil {
+					t.Error("no error reported for the skipped range")
+				}
+				r.Err = nil
+				skipped = append(skipped, r)
+			}
+
+			values := []interface{}{}
+
+			for {
+				var v interface{}
+				if d.Decode(&v) != nil {
+					break
+				}
+				values = append(values, v)
+			}
+
+			if err := d.Err(); err != nil {
+				t.Error(err)
+			}
+
+			if !reflect.DeepEqual(values, test.out) {
+				t.Errorf("%#v != %#v", test.out, values)
+			}
+
+			if !reflect.DeepEqual(skipped, test.skipped) {
+				t.Errorf("%#v != %#v", test.skipped, skipped)
+			}
+		})
+	}
+}
+
+// version implements both encoding.TextMarshaler and the AppendText method of
+// encoding.TextAppender, the encoder must prefer the latter.
+type version struct{ major, minor uint8 }
+
+func (v *version) AppendText(b []byte) ([]byte, error) {
+	b = strconv.AppendUint(b, uint64(v.major), 10)
+	b = append(b, '.')
+	return strconv.AppendUint(b, uint64(v.minor), 10), nil
+}
+
+func (v *version) MarshalText() ([]byte, error) {
+	return []byte("MarshalText"), nil
+}
+
+func TestEncoderTextAppender(t *testing.T) {
+	v := &version{major: 1, minor: 24}
+
+	b, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(b); s != `"1.24"` {
+		t.Errorf("bad output: %s", s)
+	}
+}
+
+func TestLinesCodec(t *testing.T) {
+	type record struct {
+		ID   int      `objconv:"id"`
+		Tags []string `objconv:"tags"`
+	}
+
+	b := &bytes.Buffer{}
+	e := NewLinesStreamEncoder(b)
+
+	for i := 0; i != 3; i++ {
+		if err := e.Encode(record{ID: i, Tags: []string{"a"}}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Each record is written to the output as soon as it's encoded.
+		if n := strings.Count(b.String(), "\n"); n != i+1 {
+			t.Errorf("%d lines written after %d records", n, i+1)
+		}
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = `{"id":0,"tags":["a"]}
+{"id":1,"tags":["a"]}
+{"id":2,"tags":["a"]}
+`
+
+	if s := b.String(); s != expected {
+		t.Errorf("bad output:\n%s", s)
+	}
+
+	d := NewLinesStreamDecoder(strings.NewReader(expected + "\n\r\n"))
+	n := 0
+
+	for {
+		var r record
+		if err := d.Decode(&r); err != nil {
+			if err != objconv.End {
+				t.Fatal(err)
+			}
+			break
+		}
+		if r.ID != n || len(r.Tags) != 1 {
+			t.Errorf("bad record: %#v", r)
+		}
+		n++
+	}
+
+	if n != 3 {
+		t.Errorf("bad number of records: %d", n)
+	}
+
+	var records []record
+
+	if err := LinesCodec.NewDecoder(strings.NewReader(expected)).Decode(&records); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 3 {
+		t.Errorf("bad records: %#v", records)
+	}
+
+	if codec, ok := objconv.Lookup("application/x-ndjson"); !ok || codec.NewParser == nil {
+		t.Error("the codec is not registered for application/x-ndjson")
+	}
+
+	d = NewLinesStreamDecoder(strings.NewReader("{}\n{"))
+	var r record
+
+	if err := d.Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Decode(&r); err == nil || err == objconv.End {
+		t.Errorf("bad error decoding a truncated record: %v", err)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	objtests.TestConformance(t, Codec)
+}
+
+func TestDecoderDisallowTrailingData(t *testing.T) {
+	tests := []struct {
+		in string
+		ok bool
+	}{
+		{`{"A":1}`, true},
+		{"{\"A\":1}\n\t ", true},
+		{`{"A":1}}`, false},
+		{`{"A":1} {"A":2}`, false},
+	}
+
+	for _, test := range tests {
+		var v map[string]int
+
+		d := NewDecoder(strings.NewReader(test.in))
+		d.DisallowTrailingData = true
+
+		if err := d.Decode(&v); test.ok && err != nil {
+			t.Errorf("decoding %q: %v", test.in, err)
+		} else if !test.ok && !errors.Is(err, objconv.ErrSyntax) {
+			t.Errorf("decoding %q: expected an error of kind %q but got %v", test.in, objconv.ErrSyntax, err)
+		}
+
+		if err := Unmarshal([]byte(test.in), &v); test.ok != (err == nil) {
+			t.Errorf("unmarshaling %q: %v", test.in, err)
+		}
+	}
+}
+
+func TestCanonicalEmitter(t *testing.T) {
+	tests := []struct {
+		v interface{}
+		s string
+	}{
+		{ // RFC 8785, section 3.2.2
+			v: map[string]interface{}{
+				"numbers":  []interface{}{333333333.33333329, 1e30, 4.50, 2e-3, 0.000000000000000000000000001},
+				"string":   "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"/",
+				"literals": []interface{}{nil, true, false},
+			},
+			s: `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+		{ // RFC 8785, section 3.2.3
+			v: map[string]int{"\u20ac": 1, "\r": 2, "\ufb33": 3, "1": 4, "\U0001f600": 5, "\u0080": 6, "\u00f6": 7},
+			s: "{\"\\r\":2,\"1\":4,\"\u0080\":6,\"\u00f6\":7,\"\u20ac\":1,\"\U0001f600\":5,\"\ufb33\":3}",
+		},
+		{
+			v: struct {
+				B float32
+				A []map[string]float64
+			}{B: 0.5, A: []map[string]float64{{"y": -0.0, "x": 1e-7}}},
+			s: `{"A":[{"x":1e-7,"y":0}],"B":0.5}`,
+		},
+	}
+
+	for _, test := range tests {
+		b := &bytes.Buffer{}
+
+		if err := (&objconv.Encoder{Emitter: NewEmitter(b), Canonical: true}).Encode(test.v); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if s := b.String(); s != test.s {
+			t.Errorf("bad encoding:\n%s\n%s", s, test.s)
+		}
+	}
+}
+
+func TestDecoderMore(t *testing.T) {
+	tests := []struct {
+		in  string
+		out []interface{}
+	}{
+		{``, nil},
+		{" \n", nil},
+		{`1`, []interface{}{int64(1)}},
+		{"1 \"A\"\n{\"a\":true} [] ", []interface{}{int64(1), "A", map[interface{}]interface{}{"a": true}, []interface{}{}}},
+	}
+
+	for _, test := range tests {
+		var out []interface{}
+
+		d := NewDecoder(strings.NewReader(test.in))
+
+		for d.More() {
+			var v interface{}
+
+			if err := d.Decode(&v); err != nil {
+				t.Fatalf("decoding %q: %v", test.in, err)
+			}
+
+			out = append(out, v)
+		}
+
+		if !reflect.DeepEqual(out, test.out) {
+			t.Errorf("decoding %q: %#v != %#v", test.in, out, test.out)
+		}
+	}
+}
+
+func TestParserConfig(t *testing.T) {
+	tests := []struct {
+		in     string
+		limits objconv.ParserConfig
+	}{
+		{`[[[1]]]`, objconv.ParserConfig{MaxDepth: 2}},
+		{`{"a":{"b":{}}}`, objconv.ParserConfig{MaxDepth: 2}},
+		{`[1,2,3]`, objconv.ParserConfig{MaxArrayLen: 2}},
+		{`{"a":1,"b":2}`, objconv.ParserConfig{MaxMapLen: 1}},
+		{`"hello"`, objconv.ParserConfig{MaxStringLen: 4}},
+		{`{"hello":1}`, objconv.ParserConfig{MaxStringLen: 4}},
+		{`[1, 2, 3]`, objconv.ParserConfig{MaxBytes: 8}},
+		{`[1, 2, 3]`, objconv.ParserConfig{MaxValues: 3}},
+		{`["hello"]`, objconv.ParserConfig{MaxAllocs: 32}},
+	}
+
+	for _, test := range tests {
+		var v interface{}
+
+		if err := test.limits.NewDecoder(Codec, strings.NewReader(test.in)).Decode(&v); !errors.Is(err, objconv.ErrLimit) {
+			t.Errorf("%s: %+v: expected an error of kind ErrLimit but got %v", test.in, test.limits, err)
+		}
+
+		if err := Unmarshal([]byte(test.in), &v); err != nil {
+			t.Errorf("%s: %v", test.in, err)
+		}
+	}
+}
+
+func TestDecodeStats(t *testing.T) {
+	var v interface{}
+	var stats objconv.DecodeStats
+
+	d := NewDecoder(strings.NewReader(`{"a": [1, 2]} {"b": "c"}`))
+	d.Stats = &stats
+
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Bytes != 13 || stats.Values != 5 {
+		t.Errorf("bad stats of the first value: %+v", stats)
+	}
+
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Bytes != 11 || stats.Values != 3 {
+		t.Errorf("bad stats of the second value: %+v", stats)
+	}
+}
+
+func TestAppendMimetype(t *testing.T) {
+	b, err := objconv.Append("application/json", []byte("["), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(b); s != "[42" {
+		t.Errorf("bad output: %q", s)
+	}
+
+	if _, err := objconv.Append("application/unknown", nil, 42); !errors.Is(err, objconv.ErrType) {
+		t.Errorf("bad error: %v", err)
+	}
+}
+
+func TestDecodeContext(t *testing.T) {
+	r, w := net.Pipe()
+	defer w.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d := NewDecoder(r)
+
+	go w.Write([]byte(`{"answer":`))
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	var v interface{}
+
+	if err := d.DecodeContext(ctx, &v); err != context.Canceled {
+		t.Errorf("expected context.Canceled but got %v", err)
+	}
+}
+
+func TestStreamDecodeContext(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	d := NewStreamDecoder(r)
+	go w.Write([]byte(`[1,2,`))
+
+	var v int
+
+	for i := 1; i <= 2; i++ {
+		if err := d.DecodeContext(ctx, &v); err != nil {
+			t.Fatal(err)
+		}
+		if v != i {
+			t.Errorf("bad value: %d", v)
+		}
+	}
+
+	if err := d.DecodeContext(ctx, &v); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded but got %v", err)
+	}
+
+	if err := d.Decode(&v); err != context.DeadlineExceeded {
+		t.Errorf("expected the stream to remain in the error state but got %v", err)
+	}
+}
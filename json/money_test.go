@@ -0,0 +1,76 @@
+package json
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/msgpack"
+)
+
+func TestMoney(t *testing.T) {
+	type payment struct {
+		Total objconv.Money `objconv:"total"`
+	}
+
+	p1 := payment{Total: objconv.Money{Amount: 1234, Currency: "USD"}}
+
+	b, err := Marshal(p1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(b); s != `{"total":{"amount":"12.34","currency":"USD"}}` {
+		t.Error("bad JSON:", s)
+	}
+
+	var p2 payment
+	if err := Unmarshal(b, &p2); err != nil {
+		t.Fatal(err)
+	}
+
+	if p1 != p2 {
+		t.Errorf("%#v != %#v", p1, p2)
+	}
+
+	m, err := msgpack.Marshal(p1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The binary representation is an array of minor units and currency.
+	var v struct {
+		Total []interface{} `objconv:"total"`
+	}
+	if err := msgpack.Unmarshal(m, &v); err != nil || len(v.Total) != 2 || v.Total[1] != "USD" {
+		t.Errorf("bad msgpack representation: %#v (%v)", v, err)
+	}
+
+	var p3 payment
+	if err := msgpack.Unmarshal(m, &p3); err != nil || p1 != p3 {
+		t.Errorf("%#v != %#v (%v)", p1, p3, err)
+	}
+
+	tests := []struct {
+		in  string
+		out objconv.Money
+	}{
+		{`{"amount":"-0.05","currency":"EUR"}`, objconv.Money{Amount: -5, Currency: "EUR"}},
+		{`{"amount":"1500","currency":"JPY"}`, objconv.Money{Amount: 1500, Currency: "JPY"}},
+		{`{"amount":"1.005","currency":"KWD"}`, objconv.Money{Amount: 1005, Currency: "KWD"}},
+		{`{"amount":12,"currency":"USD"}`, objconv.Money{Amount: 1200, Currency: "USD"}},
+		{`[99,"GBP"]`, objconv.Money{Amount: 99, Currency: "GBP"}},
+	}
+
+	for _, test := range tests {
+		var m objconv.Money
+		if err := Unmarshal([]byte(test.in), &m); err != nil || m != test.out {
+			t.Errorf("%s: %#v != %#v (%v)", test.in, m, test.out, err)
+		}
+	}
+
+	var x objconv.Money
+	if err := Unmarshal([]byte(`{"amount":"1.234","currency":"USD"}`), &x); !errors.Is(err, objconv.ErrRange) {
+		t.Error("expected a range error but got:", err)
+	}
+}
@@ -2,20 +2,23 @@ package json
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
-	"fmt"
+	"errors"
 	"io"
-	"reflect"
 	"strconv"
 	"time"
 	"unicode/utf16"
 	"unicode/utf8"
-	"unsafe"
 
 	"github.com/segmentio/objconv"
 	"github.com/segmentio/objconv/objutil"
 )
 
+// recordSeparator is the ASCII RS character that prefixes the records of JSON
+// text sequences, parsers treat it as white space between top-level values.
+const recordSeparator = 0x1E
+
 type Parser struct {
 	r io.Reader // reader to load bytes from
 	s []byte    // buffer used for building strings
@@ -23,6 +26,18 @@ type Parser struct {
 	j int       // offset of the last byte in b
 	b [128]byte // buffer where bytes are loaded from the reader
 	c [128]byte // initial backend array for s
+
+	// offset in the input of the first byte in b
+	off int64
+
+	// nesting level of arrays and maps being parsed
+	depth int
+
+	// number of line breaks before the offset lnoff in the input, and offset
+	// of the first byte of the last line, used to compute positions
+	line  int64
+	lnoff int64
+	bol   int64
 }
 
 func NewParser(r io.Reader) *Parser {
@@ -35,12 +50,26 @@ func (p *Parser) Reset(r io.Reader) {
 	p.r = r
 	p.i = 0
 	p.j = 0
+	p.off = 0
+	p.depth = 0
+	p.line = 0
+	p.lnoff = 0
+	p.bol = 0
 }
 
+// Buffered satisfies the objconv.BufferedParser interface.
 func (p *Parser) Buffered() io.Reader {
 	return bytes.NewReader(p.b[p.i:p.j])
 }
 
+// ParseRaw satisfies the objconv.RawParser interface, the value is re-encoded
+// by a JSON emitter, which writes it in its compact form.
+func (p *Parser) ParseRaw() ([]byte, error) {
+	b := &bytes.Buffer{}
+	err := objconv.Transcode(NewEmitter(b), p)
+	return b.Bytes(), err
+}
+
 func (p *Parser) ParseType() (t objconv.Type, err error) {
 	var b byte
 
@@ -88,7 +117,7 @@ func (p *Parser) ParseType() (t objconv.Type, err error) {
 		p.s = append(p.s[:0], chunk...)
 
 	default:
-		err = fmt.Errorf("objconv/json: expected token but found '%c'", b)
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/json: expected token but found '%c'", b)
 	}
 
 	return
@@ -113,7 +142,7 @@ func (p *Parser) ParseBool() (v bool, err error) {
 		v, err = true, p.readToken(trueBytes[:])
 
 	default:
-		err = fmt.Errorf("objconv/json: expected boolean but found '%c'", b)
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/json: expected boolean but found '%c'", b)
 	}
 
 	return
@@ -139,6 +168,17 @@ func (p *Parser) ParseFloat() (v float64, err error) {
 	return
 }
 
+// ParseNumber satisfies the objconv.NumberParser interface.
+func (p *Parser) ParseNumber() (v []byte, err error) {
+	if _, err = strconv.ParseFloat(stringNoCopy(p.s), 64); err != nil && !errors.Is(err, strconv.ErrRange) {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/json: invalid number: %q", p.s)
+		return
+	}
+	v, err = p.s, nil
+	p.i += len(p.s)
+	return
+}
+
 func (p *Parser) ParseString() (v []byte, err error) {
 	if p.i == p.j {
 		if err = p.fill(); err != nil {
@@ -247,14 +287,20 @@ func (p *Parser) ParseError() (v error, err error) {
 }
 
 func (p *Parser) ParseArrayBegin() (n int, err error) {
-	return -1, p.readByte('[')
+	if err = p.readByte('['); err == nil {
+		p.depth++
+	}
+	return -1, err
 }
 
 func (p *Parser) ParseArrayEnd(n int) (err error) {
 	if err = p.skipSpaces(); err != nil {
 		return
 	}
-	return p.readByte(']')
+	if err = p.readByte(']'); err == nil {
+		p.depth--
+	}
+	return
 }
 
 func (p *Parser) ParseArrayNext(n int) (err error) {
@@ -275,7 +321,7 @@ func (p *Parser) ParseArrayNext(n int) (err error) {
 		err = objconv.End
 	default:
 		if n != 0 { // we likely are not in an empty array, there's a value to parse
-			err = fmt.Errorf("objconv/json: expected ',' or ']' but found '%c'", b)
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/json: expected ',' or ']' but found '%c'", b)
 		}
 	}
 
@@ -283,14 +329,20 @@ func (p *Parser) ParseArrayNext(n int) (err error) {
 }
 
 func (p *Parser) ParseMapBegin() (n int, err error) {
-	return -1, p.readByte('{')
+	if err = p.readByte('{'); err == nil {
+		p.depth++
+	}
+	return -1, err
 }
 
 func (p *Parser) ParseMapEnd(n int) (err error) {
 	if err = p.skipSpaces(); err != nil {
 		return
 	}
-	return p.readByte('}')
+	if err = p.readByte('}'); err == nil {
+		p.depth--
+	}
+	return
 }
 
 func (p *Parser) ParseMapValue(n int) (err error) {
@@ -318,13 +370,83 @@ func (p *Parser) ParseMapNext(n int) (err error) {
 		err = objconv.End
 	default:
 		if n != 0 { // the map is not empty, likely there's a value to parse
-			err = fmt.Errorf("objconv/json: expected ',' or '}' but found '%c'", b)
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/json: expected ',' or '}' but found '%c'", b)
 		}
 	}
 
 	return
 }
 
+// ParseContext satisfies the objconv.ParserV2 interface, the reads from the
+// underlying reader are aborted when ctx is canceled.
+func (p *Parser) ParseContext(ctx context.Context) error {
+	p.r = objutil.WithContext(p.r, ctx)
+	return ctx.Err()
+}
+
+// ParseLengthHint satisfies the objconv.ParserV2 interface, the parser has no
+// hints.
+func (p *Parser) ParseLengthHint() int {
+	return -1
+}
+
+// Offset returns the number of bytes consumed by the parser.
+func (p *Parser) Offset() int64 {
+	return p.off + int64(p.i)
+}
+
+// Position satisfies the objconv.PositionParser interface, it skips the white
+// spaces that precede the next value and returns its position.
+func (p *Parser) Position() objconv.Position {
+	p.skipSpaces()
+	p.countLines(p.i)
+	return objconv.Position{
+		Line:   int(p.line) + 1,
+		Column: int(p.Offset()-p.bol) + 1,
+	}
+}
+
+// countLines counts the line breaks in the read buffer up to i, it must be
+// called before bytes are discarded from the buffer.
+func (p *Parser) countLines(i int) {
+	b := p.b[p.lnoff-p.off : i]
+
+	for {
+		j := bytes.IndexByte(b, '\n')
+		if j < 0 {
+			break
+		}
+		p.line++
+		p.bol = p.off + int64(i-len(b)+j+1)
+		b = b[j+1:]
+	}
+
+	p.lnoff = p.off + int64(i)
+}
+
+// Resync discards the input up to the next newline or record separator (RS),
+// which are the record boundaries of newline-delimited JSON and JSON text
+// sequences (RFC 7464).
+func (p *Parser) Resync() (err error) {
+	p.depth = 0
+
+	for {
+		if p.i == p.j {
+			if err = p.fill(); err != nil {
+				return
+			}
+		}
+
+		for _, b := range p.b[p.i:p.j] {
+			p.i++
+
+			if b == '\n' || b == recordSeparator {
+				return
+			}
+		}
+	}
+}
+
 func (p *Parser) TextParser() bool {
 	return true
 }
@@ -398,7 +520,7 @@ func (p *Parser) readByte(b byte) (err error) {
 		if b == c {
 			p.i++
 		} else {
-			err = fmt.Errorf("objconv/json: expected '%c' but found '%c'", b, c)
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/json: expected '%c' but found '%c'", b, c)
 		}
 	}
 
@@ -413,7 +535,7 @@ func (p *Parser) readToken(token []byte) (err error) {
 		if bytes.Equal(chunk, token) {
 			p.i += n
 		} else {
-			err = fmt.Errorf("objconv/json: expected %#v but found %#v", string(token), string(chunk))
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/json: expected %#v but found %#v", string(token), string(chunk))
 		}
 	}
 
@@ -429,12 +551,12 @@ func (p *Parser) readUnicode() (r rune, err error) {
 	}
 
 	if code, err = objutil.ParseUintHex(chunk); err != nil {
-		err = fmt.Errorf("objconv/json: expected an hexadecimal unicode code point but found %#v", string(chunk))
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/json: expected an hexadecimal unicode code point but found %#v", string(chunk))
 		return
 	}
 
 	if code > objutil.Uint16Max {
-		err = fmt.Errorf("objconv/json: expected an hexadecimal unicode code points but found an overflowing value %X", code)
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/json: expected an hexadecimal unicode code points but found an overflowing value %X", code)
 		return
 	}
 
@@ -456,20 +578,29 @@ func (p *Parser) skipSpaces() (err error) {
 			switch b {
 			case ' ', '\n', '\t', '\r', '\b', '\f':
 				p.i++
+			case recordSeparator:
+				if p.depth != 0 { // a truncated record was followed by another
+					return
+				}
+				p.i++
 			default:
 				return
 			}
 		}
 
 		// all trailing bytes in the read buffer were spaces, clear and refill.
+		p.countLines(p.j)
+		p.off += int64(p.j)
 		p.i = 0
 		p.j = 0
 	}
 }
 
 func (p *Parser) fill() (err error) {
+	p.countLines(p.i)
 	n := p.j - p.i
 	copy(p.b[:n], p.b[p.i:p.j])
+	p.off += int64(p.i)
 	p.i = 0
 	p.j = n
 
@@ -486,13 +617,71 @@ func (p *Parser) fill() (err error) {
 	return
 }
 
-func stringNoCopy(b []byte) string {
-	n := len(b)
-	if n == 0 {
-		return ""
+// LinesParser implements a parser of newline-delimited JSON (NDJSON, also
+// known as JSON Lines). The input is exposed as a top-level array of unknown
+// length, made of the documents found on each line, which ends with the input.
+type LinesParser struct {
+	Parser
+	seq bool // whether the top-level array was opened
+}
+
+// NewLinesParser returns a new parser of newline-delimited JSON values read
+// from r.
+func NewLinesParser(r io.Reader) *LinesParser {
+	p := &LinesParser{}
+	p.r = r
+	p.s = p.c[:0]
+	return p
+}
+
+func (p *LinesParser) Reset(r io.Reader) {
+	p.Parser.Reset(r)
+	p.seq = false
+}
+
+func (p *LinesParser) ParseType() (objconv.Type, error) {
+	if p.depth == 0 && !p.seq {
+		return objconv.Array, nil
+	}
+	return p.Parser.ParseType()
+}
+
+func (p *LinesParser) ParseArrayBegin() (int, error) {
+	if p.depth == 0 && !p.seq {
+		p.seq = true
+		return -1, nil
+	}
+	return p.Parser.ParseArrayBegin()
+}
+
+func (p *LinesParser) ParseArrayEnd(n int) error {
+	if p.depth != 0 || !p.seq {
+		return p.Parser.ParseArrayEnd(n)
+	}
+
+	switch err := p.skipSpaces(); err {
+	case nil:
+		b, _ := p.peekByteAt(0)
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/json: expected a line break but found '%c'", b)
+	case io.EOF:
+		p.seq = false
+		return nil
+	default:
+		return err
+	}
+}
+
+func (p *LinesParser) ParseArrayNext(n int) error {
+	if p.depth != 0 || !p.seq {
+		return p.Parser.ParseArrayNext(n)
+	}
+
+	// Documents are separated by line breaks, which are skipped with the
+	// other white spaces, the sequence ends with the input.
+	switch err := p.skipSpaces(); err {
+	case io.EOF:
+		return objconv.End
+	default:
+		return err
 	}
-	return *(*string)(unsafe.Pointer(&reflect.StringHeader{
-		Data: uintptr(unsafe.Pointer(&b[0])),
-		Len:  n,
-	}))
 }
@@ -0,0 +1,59 @@
+package json
+
+import (
+	"testing"
+
+	"github.com/segmentio/objconv"
+)
+
+func TestDiffPatch(t *testing.T) {
+	type address struct {
+		City    string `objconv:"city"`
+		Country string `objconv:"country"`
+	}
+
+	type user struct {
+		Name    string   `objconv:"name"`
+		Email   string   `objconv:"email,omitempty"`
+		Tags    []string `objconv:"tags"`
+		Address address  `objconv:"address"`
+		Manager *string  `objconv:"manager"`
+	}
+
+	manager := "bob"
+
+	u1 := user{
+		Name:    "alice",
+		Email:   "alice@example.com",
+		Tags:    []string{"a", "b"},
+		Address: address{City: "Paris", Country: "France"},
+		Manager: &manager,
+	}
+
+	tests := []struct {
+		old   interface{}
+		new   interface{}
+		patch string
+	}{
+		{u1, u1, `{}`},
+		{u1, func() user { u := u1; u.Name = "carol"; return u }(), `{"name":"carol"}`},
+		{u1, func() user { u := u1; u.Email = ""; return u }(), `{"email":null}`},
+		{u1, func() user { u := u1; u.Tags = []string{"a"}; return u }(), `{"tags":["a"]}`},
+		{u1, func() user { u := u1; u.Address.City = "Lyon"; return u }(), `{"address":{"city":"Lyon"}}`},
+		{u1, func() user { u := u1; u.Manager = nil; return u }(), `{"manager":null}`},
+		{map[string]int{"a": 1}, map[string]int{"b": 2}, `{"a":null,"b":2}`},
+		{u1, []int{1}, `[1]`},
+	}
+
+	for _, test := range tests {
+		p, err := objconv.DiffPatch(test.old, test.new)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if s := string(p); s != test.patch {
+			t.Errorf("bad patch: %s != %s", s, test.patch)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build purego
+// +build purego
+
+package json
+
+func stringNoCopy(b []byte) string {
+	return string(b)
+}
@@ -0,0 +1,49 @@
+package json
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/msgpack"
+)
+
+func TestTranscode(t *testing.T) {
+	const stream = `{"id":1,"tags":["a","b"],"nested":{"ok":true,"ratio":0.25}}
+{"id":2,"tags":[],"nested":{}}
+[1,-2,null,"x"]
+`
+	b := &bytes.Buffer{}
+	e := msgpack.NewEmitter(b)
+	p := NewParser(strings.NewReader(stream))
+
+	for {
+		if err := objconv.Transcode(e, p); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatal(err)
+		}
+	}
+
+	// Transcoding back to JSON must produce the original stream.
+	out := &bytes.Buffer{}
+	e2 := NewEmitter(out)
+	p2 := msgpack.NewParser(b)
+
+	for {
+		if err := objconv.Transcode(e2, p2); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatal(err)
+		}
+		out.WriteByte('\n')
+	}
+
+	if s := out.String(); s != stream {
+		t.Errorf("bad output:\n%s", s)
+	}
+}
@@ -0,0 +1,57 @@
+//go:build !purego
+// +build !purego
+
+package json
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/objconv"
+)
+
+// Unexported fields without getter methods are accessed with the unsafe
+// package, which isn't available when building with the purego tag.
+
+type account struct {
+	id    int    `objconv:"id,export"`
+	email string `objconv:"email,export"`
+	token string
+}
+
+func (a *account) Email() string { return strings.ToLower(a.email) }
+
+func TestUnexportedFields(t *testing.T) {
+	b := &bytes.Buffer{}
+
+	if err := NewEncoder(b).Encode(account{id: 1, email: "Me@Example.com", token: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := b.String(); s != `{"id":1,"email":"me@example.com"}` {
+		t.Error("bad output:", s)
+	}
+
+	var a account
+
+	if err := NewDecoder(b).Decode(&a); err != nil {
+		t.Fatal(err)
+	}
+
+	if a != (account{id: 1, email: "me@example.com"}) {
+		t.Errorf("bad value: %#v", a)
+	}
+
+	e := NewEncoder(&bytes.Buffer{})
+	e.DisallowOpaqueStructs = true
+
+	if err := e.Encode(struct{ a, b int }{}); !errors.Is(err, objconv.ErrType) {
+		t.Error("expected a type error when encoding an opaque struct but got:", err)
+	}
+
+	if err := e.Encode(struct{}{}); err != nil {
+		t.Error("empty structs must not be rejected:", err)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !purego
+// +build !purego
+
+package json
+
+import "unsafe"
+
+// stringNoCopy returns a string that shares its memory with b, the program
+// must ensure that b is not modified for as long as the string is in use.
+func stringNoCopy(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
@@ -0,0 +1,172 @@
+package objconv
+
+import (
+	"io"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// ParserConfig carries the limits applied to the inputs of decoders, which
+// protect programs decoding untrusted inputs from values nested deeply enough
+// to exhaust the stack, or large enough to exhaust the memory.
+//
+// Zero values mean that there is no limit. Inputs that exceed the limits are
+// rejected with errors of kind ErrLimit.
+type ParserConfig struct {
+	// MaxDepth is the maximum nesting level of arrays and maps, top-level
+	// arrays and maps have a depth of one.
+	MaxDepth int
+
+	// MaxStringLen is the maximum length of strings and byte sequences, in
+	// bytes.
+	//
+	// Decoders verify the length of strings after they were read, parsers
+	// created with the NewParser method reject them before if they implement
+	// the LimitParser interface. With other parsers, MaxBytes should also be
+	// set to bound the memory used to read strings.
+	MaxStringLen int
+
+	// MaxArrayLen is the maximum number of elements of arrays.
+	MaxArrayLen int
+
+	// MaxMapLen is the maximum number of entries of maps.
+	MaxMapLen int
+
+	// MaxBytes is the maximum number of bytes read from the input, it is only
+	// enforced by parsers created with the NewParser method since decoders
+	// don't have access to the input.
+	MaxBytes int64
+
+	// MaxValues is the maximum number of values decoded by each call to
+	// Decode, counted like the Values field of DecodeStats.
+	MaxValues int64
+
+	// MaxAllocs is the maximum estimate of the memory allocated by each call
+	// to Decode, in bytes, see the Allocs field of DecodeStats.
+	MaxAllocs int64
+}
+
+// NewParser returns a parser of codec reading from r, which fails when more
+// than MaxBytes bytes are read, or when strings exceed MaxStringLen if the
+// parser implements LimitParser.
+func (c ParserConfig) NewParser(codec Codec, r io.Reader) Parser {
+	if c.MaxBytes > 0 {
+		r = &limitReader{r: r, n: c.MaxBytes, max: c.MaxBytes}
+	}
+
+	p := codec.NewParser(r)
+
+	if l, ok := p.(LimitParser); ok && c.MaxStringLen > 0 {
+		l.SetMaxStringLen(c.MaxStringLen)
+	}
+
+	return p
+}
+
+// NewDecoder returns a decoder of values read from r by a parser of codec,
+// which enforces all the limits of c.
+func (c ParserConfig) NewDecoder(codec Codec, r io.Reader) *Decoder {
+	return &Decoder{Parser: c.NewParser(codec, r), Limits: c}
+}
+
+// NewStreamDecoder is like NewDecoder but returns a stream decoder.
+func (c ParserConfig) NewStreamDecoder(codec Codec, r io.Reader) *StreamDecoder {
+	return &StreamDecoder{Parser: c.NewParser(codec, r), Limits: c}
+}
+
+// readBytes calls ParseBytes on the parser, and verifies that the byte
+// sequence doesn't exceed the length limit.
+func (d Decoder) readBytes() (b []byte, err error) {
+	if b, err = d.Parser.ParseBytes(); err == nil {
+		if err = d.Limits.checkStringLen(len(b)); err == nil {
+			err = d.count(0, len(b))
+		}
+		if err != nil {
+			b = nil
+		}
+	}
+	return
+}
+
+// enter increments the depth of d when an array or a map begins, and verifies
+// that it doesn't exceed the depth limit. Each call must be followed by a call
+// to leave.
+func (d *Decoder) enter() error {
+	if d.Limits.MaxDepth <= 0 {
+		return nil
+	}
+	if d.depth == nil {
+		d.depth = new(int)
+	}
+	*d.depth++
+	return d.Limits.checkDepth(*d.depth)
+}
+
+// leave decrements the depth of d when an array or a map ends.
+func (d *Decoder) leave() {
+	if d.Limits.MaxDepth > 0 {
+		*d.depth--
+	}
+}
+
+// checkDepth returns an error if a value at depth exceeds the limit.
+func (c ParserConfig) checkDepth(depth int) error {
+	if c.MaxDepth > 0 && depth > c.MaxDepth {
+		return objutil.Errorf(objutil.ErrLimit, "objconv: the input has arrays or maps nested deeper than the limit of %d levels", c.MaxDepth)
+	}
+	return nil
+}
+
+// checkArrayLen returns an error if an array of length n exceeds the limit.
+func (c ParserConfig) checkArrayLen(n int) error {
+	if c.MaxArrayLen > 0 && n > c.MaxArrayLen {
+		return objutil.Errorf(objutil.ErrLimit, "objconv: the input has an array with more elements than the limit of %d", c.MaxArrayLen)
+	}
+	return nil
+}
+
+// checkMapLen returns an error if a map of length n exceeds the limit.
+func (c ParserConfig) checkMapLen(n int) error {
+	if c.MaxMapLen > 0 && n > c.MaxMapLen {
+		return objutil.Errorf(objutil.ErrLimit, "objconv: the input has a map with more entries than the limit of %d", c.MaxMapLen)
+	}
+	return nil
+}
+
+// checkStringLen returns an error if a string or byte sequence of length n
+// exceeds the limit.
+func (c ParserConfig) checkStringLen(n int) error {
+	if c.MaxStringLen > 0 && n > c.MaxStringLen {
+		return objutil.Errorf(objutil.ErrLimit, "objconv: the input has a string of %d bytes, which exceeds the limit of %d bytes", n, c.MaxStringLen)
+	}
+	return nil
+}
+
+// limitReader is a reader returning an error after n bytes were read. Unlike
+// io.LimitReader the error tells truncated inputs apart from inputs which
+// were too large.
+type limitReader struct {
+	r   io.Reader
+	n   int64
+	max int64
+	err error
+}
+
+func (r *limitReader) Read(b []byte) (n int, err error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	// One extra byte is read to tell whether the input ends at the limit.
+	if int64(len(b)) > r.n+1 {
+		b = b[:r.n+1]
+	}
+
+	if n, err = r.r.Read(b); int64(n) > r.n {
+		r.err = objutil.Errorf(objutil.ErrLimit, "objconv: the input exceeds the limit of %d bytes", r.max)
+		n, err = int(r.n), r.err
+	}
+
+	r.n -= int64(n)
+	return
+}
@@ -0,0 +1,94 @@
+package objconv
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoderLimits(t *testing.T) {
+	type T struct{ A []string }
+
+	tests := []struct {
+		in     interface{}
+		to     interface{}
+		limits ParserConfig
+	}{
+		{[]interface{}{[]interface{}{1}}, new(interface{}), ParserConfig{MaxDepth: 1}},
+		{map[string]interface{}{"a": map[string]interface{}{}}, new(interface{}), ParserConfig{MaxDepth: 1}},
+		{map[string]interface{}{"a": map[string]interface{}{}}, new(map[string]interface{}), ParserConfig{MaxDepth: 1}},
+		{map[string]interface{}{"A": []string{}}, new(T), ParserConfig{MaxDepth: 1}},
+		{map[string]interface{}{"B": []string{}}, new(T), ParserConfig{MaxDepth: 1}},
+		{[]interface{}{1, 2, 3}, new(interface{}), ParserConfig{MaxArrayLen: 2}},
+		{map[string]interface{}{"a": 1, "b": 2}, new(interface{}), ParserConfig{MaxMapLen: 1}},
+		{"hello", new(interface{}), ParserConfig{MaxStringLen: 4}},
+		{[]byte("hello"), new(interface{}), ParserConfig{MaxStringLen: 4}},
+		{T{[]string{"hello"}}, new(T), ParserConfig{MaxStringLen: 4}},
+	}
+
+	for _, test := range tests {
+		e := NewValueEmitter()
+
+		if err := (Encoder{Emitter: e}).Encode(test.in); err != nil {
+			t.Fatal(err)
+		}
+
+		d := Decoder{Parser: NewValueParser(e.Value()), Limits: test.limits}
+
+		if err := d.Decode(test.to); !errors.Is(err, ErrLimit) {
+			t.Errorf("%#v: %+v: expected an error of kind ErrLimit but got %v", test.in, test.limits, err)
+		}
+
+		d = Decoder{Parser: NewValueParser(e.Value())}
+
+		if err := d.Decode(test.to); err != nil {
+			t.Errorf("%#v: the value was rejected without limits: %v", test.in, err)
+		}
+	}
+
+	// Values at the limits are accepted.
+	d := Decoder{
+		Parser: NewValueParser([]interface{}{[]interface{}{"ab"}, []interface{}{"cd"}}),
+		Limits: ParserConfig{MaxDepth: 2, MaxArrayLen: 2, MaxStringLen: 2},
+	}
+
+	if err := d.Decode(new(interface{})); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParserConfigMaxBytes(t *testing.T) {
+	codec := Codec{
+		NewParser: func(r io.Reader) Parser {
+			b, err := io.ReadAll(r)
+			if err != nil {
+				return NewValueParser(err)
+			}
+			return NewValueParser(string(b))
+		},
+	}
+
+	for _, test := range []struct {
+		in string
+		ok bool
+	}{
+		{"", true},
+		{"hello", true},
+		{"hello world", false},
+	} {
+		var s interface{}
+
+		err := ParserConfig{MaxBytes: 5}.NewDecoder(codec, strings.NewReader(test.in)).Decode(&s)
+
+		if test.ok && s != test.in {
+			t.Errorf("%q: bad value decoded: %#v", test.in, s)
+		}
+
+		if !test.ok {
+			if e, _ := s.(error); !errors.Is(e, ErrLimit) {
+				t.Errorf("%q: the input was not rejected: %#v %v", test.in, s, err)
+			}
+		}
+	}
+}
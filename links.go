@@ -0,0 +1,140 @@
+package objconv
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// LinksField is the key that the links of values are encoded under, which is
+// the convention of HAL (Hypertext Application Language) documents.
+const LinksField = "_links"
+
+// Link is a hypermedia link to a resource related to an encoded value, it is
+// encoded as a map like {"href":"/orders/42"}.
+type Link struct {
+	Href      string `objconv:"href"`
+	Templated bool   `objconv:"templated,omitempty"`
+	Type      string `objconv:"type,omitempty"`
+	Title     string `objconv:"title,omitempty"`
+}
+
+// Links maps relation types, like "self" or "next", to the links of a value.
+// Links are encoded in the order of their relation types.
+type Links map[string]Link
+
+// EncodeValue satisfies the ValueEncoder interface.
+func (l Links) EncodeValue(e Encoder) error {
+	rels := make([]string, 0, len(l))
+
+	for rel := range l {
+		rels = append(rels, rel)
+	}
+
+	sort.Strings(rels)
+	i := 0
+
+	return e.EncodeMap(len(rels), func(ke Encoder, ve Encoder) (err error) {
+		if err = ke.Encode(rels[i]); err == nil {
+			err = ve.Encode(l[rels[i]])
+		}
+		i++
+		return
+	})
+}
+
+// A LinkBuilder returns the links of v, a value of the struct type that the
+// builder was installed for. The context is the one given to the EncodeContext
+// method of the encoder, or context.Background if it was not.
+type LinkBuilder func(ctx context.Context, v reflect.Value) Links
+
+// InstallLinks registers a link builder for the struct type typ, which lets
+// hypermedia APIs generate the links of their resources in one place instead
+// of adding them to the values returned by each handler.
+//
+// Encoders call the builders of a struct type when they encode its values, and
+// add the links to the output under the LinksField key after the other fields.
+// The key is omitted when there are no links. When multiple builders are
+// installed for a type their links are merged, the links of builders installed
+// later replace the ones of earlier builders for the same relation types.
+// Decoders ignore the links of the input.
+//
+//	objconv.InstallLinks(reflect.TypeOf(Order{}), func(ctx context.Context, v reflect.Value) objconv.Links {
+//		return objconv.Links{"self": {Href: "/orders/" + v.Interface().(Order).ID}}
+//	})
+//
+// The function panics if typ is not a struct type. Like Install, it is
+// intended to be called during the package initialization phase.
+func InstallLinks(typ reflect.Type, builder LinkBuilder) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("objconv: cannot install links on %s because it is not a struct type", typ))
+	}
+
+	if builder == nil {
+		panic(fmt.Sprintf("objconv: cannot install a nil link builder on %s", typ))
+	}
+
+	virtualMutex.Lock()
+	linkStore[typ] = append(linkStore[typ], builder)
+	virtualMutex.Unlock()
+
+	// Same as Install, the struct cache may have become invalid.
+	clearStructCaches()
+}
+
+func linkBuildersOf(typ reflect.Type) []LinkBuilder {
+	virtualMutex.RLock()
+	builders := linkStore[typ]
+	virtualMutex.RUnlock()
+	return builders
+}
+
+func makeLinksField(builders []LinkBuilder, c *structTypes) structField {
+	return structField{
+		name:      LinksField,
+		omitempty: true,
+		hidden:    true,
+		virtual:   true,
+		getter:    -1,
+		links:     builders,
+
+		encode: makeEncodeFunc(linksType, encodeFuncOpts{
+			recurse: true,
+			structs: c,
+		}),
+	}
+}
+
+// buildLinks returns the links of v built by the builders.
+func buildLinks(ctx context.Context, builders []LinkBuilder, v reflect.Value) reflect.Value {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if len(builders) == 1 {
+		return reflect.ValueOf(builders[0](ctx, v))
+	}
+
+	var links Links
+
+	for _, b := range builders {
+		for rel, link := range b(ctx, v) {
+			if links == nil {
+				links = make(Links)
+			}
+			links[rel] = link
+		}
+	}
+
+	return reflect.ValueOf(links)
+}
+
+var (
+	linkStore = make(map[reflect.Type][]LinkBuilder)
+	linksType = reflect.TypeOf(Links(nil))
+)
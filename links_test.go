@@ -0,0 +1,103 @@
+package objconv
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type linkedOrder struct {
+	ID    string `objconv:"id"`
+	Items int    `objconv:"items"`
+}
+
+type linkedOrders struct {
+	Orders []linkedOrder `objconv:"orders"`
+}
+
+type linkBaseKey struct{}
+
+func init() {
+	InstallLinks(reflect.TypeOf(linkedOrder{}), func(ctx context.Context, v reflect.Value) Links {
+		base, _ := ctx.Value(linkBaseKey{}).(string)
+		return Links{"self": {Href: base + "/orders/" + v.Interface().(linkedOrder).ID}}
+	})
+
+	InstallLinks(reflect.TypeOf(&linkedOrder{}), func(ctx context.Context, v reflect.Value) Links {
+		o := v.Interface().(linkedOrder)
+		if o.Items == 0 {
+			return nil
+		}
+		return Links{"items": {Href: "/orders/" + o.ID + "/items{?page}", Templated: true}}
+	})
+
+	InstallLinks(reflect.TypeOf(linkedOrders{}), func(ctx context.Context, v reflect.Value) Links {
+		return nil
+	})
+}
+
+func TestLinks(t *testing.T) {
+	e := NewValueEmitter()
+	ctx := context.WithValue(context.Background(), linkBaseKey{}, "https://example.com")
+
+	if err := NewEncoder(e).EncodeContext(ctx, linkedOrders{Orders: []linkedOrder{{ID: "1", Items: 2}, {ID: "2"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[interface{}]interface{}{
+		"orders": []interface{}{
+			map[interface{}]interface{}{
+				"id":    "1",
+				"items": int64(2),
+				"_links": map[interface{}]interface{}{
+					"self":  map[interface{}]interface{}{"href": "https://example.com/orders/1"},
+					"items": map[interface{}]interface{}{"href": "/orders/1/items{?page}", "templated": true},
+				},
+			},
+			map[interface{}]interface{}{
+				"id":    "2",
+				"items": int64(0),
+				"_links": map[interface{}]interface{}{
+					"self": map[interface{}]interface{}{"href": "https://example.com/orders/2"},
+				},
+			},
+		},
+	}
+
+	if v := e.Value(); !reflect.DeepEqual(v, expect) {
+		t.Errorf("bad value:\n%#v\n%#v", expect, v)
+	}
+
+	e = NewValueEmitter()
+
+	if err := NewEncoder(e).Encode(linkedOrder{ID: "3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := e.Value().(map[interface{}]interface{})["_links"]; !reflect.DeepEqual(v, map[interface{}]interface{}{
+		"self": map[interface{}]interface{}{"href": "/orders/3"},
+	}) {
+		t.Errorf("bad links without context: %#v", v)
+	}
+
+	var o linkedOrder
+	d := NewDecoder(NewValueParser(map[string]interface{}{"id": "4", "_links": map[string]interface{}{"self": "?"}}))
+	d.DisallowUnknownFields = true
+
+	if err := d.Decode(&o); err != nil {
+		t.Fatal(err)
+	}
+
+	if o != (linkedOrder{ID: "4"}) {
+		t.Errorf("bad value: %#v", o)
+	}
+}
+
+func TestInstallLinksPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("installing links on a non-struct type must panic")
+		}
+	}()
+	InstallLinks(reflect.TypeOf(0), func(context.Context, reflect.Value) Links { return nil })
+}
@@ -0,0 +1,607 @@
+// Package logfile implements an append-only store of records encoded with an
+// objconv codec, a reusable building block for write-ahead logs and queues.
+//
+// A log is a directory of segment files, each record is written as a frame
+// made of a 4 bytes big-endian length, a 4 bytes CRC-32 (Castagnoli) checksum
+// of the payload and the payload produced by the codec. Segment files are named
+// after the number of their first record so that a record can be located
+// without scanning the whole log.
+//
+// When a log is opened the last segment is truncated to its longest valid
+// prefix, which discards records that were partially written when a program
+// crashed.
+package logfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/segmentio/objconv"
+)
+
+const (
+	// DefaultSegmentSize is the size after which segments are rotated when
+	// the configuration doesn't specify one.
+	DefaultSegmentSize = 64 * 1024 * 1024
+
+	// MaxRecordSize is the maximum size of a record payload, larger frame
+	// lengths are considered corrupted.
+	MaxRecordSize = 1024 * 1024 * 1024
+
+	headerSize = 8
+	segmentExt = ".log"
+)
+
+var (
+	// ErrClosed is returned when using a Log that was closed.
+	ErrClosed = errors.New("objconv/logfile: the log is closed")
+
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// Config carries the configuration of a Log.
+type Config struct {
+	// SegmentSize is the size in bytes after which a new segment is started,
+	// DefaultSegmentSize is used if it is zero.
+	SegmentSize int64
+
+	// When Sync is true every call to Append waits for the record to be
+	// written to stable storage.
+	Sync bool
+}
+
+// Log is an append-only sequence of records stored in segment files.
+//
+// Logs are safe to use from multiple goroutines.
+type Log struct {
+	dir    string
+	codec  objconv.Codec
+	config Config
+
+	mutex     sync.Mutex
+	segments  []int64  // first record number of each segment, sorted
+	file      *os.File // the last segment, where records are appended
+	size      int64    // size of the last segment
+	next      int64    // number of the next record
+	recovered int64    // bytes discarded when the log was opened
+	closed    bool
+
+	buf bytes.Buffer
+	enc *objconv.Encoder
+}
+
+// Open opens the log stored in dir, creating the directory if it doesn't
+// exist, records are encoded and decoded with codec.
+func Open(dir string, codec objconv.Codec, config Config) (*Log, error) {
+	if config.SegmentSize <= 0 {
+		config.SegmentSize = DefaultSegmentSize
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Log{
+		dir:      dir,
+		codec:    codec,
+		config:   config,
+		segments: segments,
+	}
+	l.enc = objconv.NewEncoder(codec.NewEmitter(&l.buf))
+
+	if len(segments) == 0 {
+		if err := l.create(0); err != nil {
+			return nil, err
+		}
+		return l, nil
+	}
+
+	if err := l.recover(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// First returns the number of the first record in the log.
+func (l *Log) First() int64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.segments[0]
+}
+
+// Next returns the number that will be assigned to the next appended record.
+func (l *Log) Next() int64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.next
+}
+
+// Recovered returns the number of bytes that were discarded from the end of
+// the log when it was opened because they didn't form valid records.
+func (l *Log) Recovered() int64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.recovered
+}
+
+// Append encodes v and writes it at the end of the log, returning the number
+// of the new record.
+func (l *Log) Append(v interface{}) (record int64, err error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.closed {
+		return 0, ErrClosed
+	}
+
+	var header [headerSize]byte
+	l.buf.Reset()
+	l.buf.Write(header[:])
+
+	if err = l.enc.Encode(v); err != nil {
+		return
+	}
+
+	frame := l.buf.Bytes()
+	payload := frame[headerSize:]
+
+	if len(payload) > MaxRecordSize {
+		err = fmt.Errorf("objconv/logfile: record of %d bytes exceeds the maximum size of %d bytes", len(payload), MaxRecordSize)
+		return
+	}
+
+	binary.BigEndian.PutUint32(frame[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:], crc32.Checksum(payload, crcTable))
+
+	if l.size != 0 && (l.size+int64(len(frame))) > l.config.SegmentSize {
+		if err = l.rotate(); err != nil {
+			return
+		}
+	}
+
+	if _, err = l.file.Write(frame); err != nil {
+		// Attempt to remove the partial frame, if it fails it will be done
+		// the next time the log is opened.
+		l.file.Truncate(l.size)
+		return
+	}
+
+	if l.config.Sync {
+		if err = l.file.Sync(); err != nil {
+			return
+		}
+	}
+
+	l.size += int64(len(frame))
+	record = l.next
+	l.next++
+	return
+}
+
+// Truncate removes all records starting at record from the log.
+func (l *Log) Truncate(record int64) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.closed {
+		return ErrClosed
+	}
+
+	if record < l.segments[0] || record > l.next {
+		return fmt.Errorf("objconv/logfile: cannot truncate at record %d which is outside of the log [%d:%d]", record, l.segments[0], l.next)
+	}
+
+	i := segmentIndex(l.segments, record)
+
+	for _, base := range l.segments[i+1:] {
+		if err := os.Remove(l.path(base)); err != nil {
+			return err
+		}
+	}
+
+	l.segments = l.segments[:i+1]
+
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	base := l.segments[i]
+	f, err := os.OpenFile(l.path(base), os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+
+	off, err := skipFrames(bufio.NewReader(f), record-base)
+	if err == nil {
+		err = f.Truncate(off)
+	}
+	if err == nil {
+		_, err = f.Seek(off, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	l.file = f
+	l.size = off
+	l.next = record
+	return nil
+}
+
+// Trim removes the segments that only contain records before record, this is
+// how programs reclaim the space used by records they don't need anymore.
+//
+// The last segment is never removed, so First may still return a number lower
+// than record after Trim returns.
+func (l *Log) Trim(record int64) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.closed {
+		return ErrClosed
+	}
+
+	n := 0
+
+	for n < len(l.segments)-1 && l.segments[n+1] <= record {
+		if err := os.Remove(l.path(l.segments[n])); err != nil {
+			l.segments = l.segments[n:]
+			return err
+		}
+		n++
+	}
+
+	l.segments = l.segments[n:]
+	return nil
+}
+
+// Sync commits the records appended to the log to stable storage.
+func (l *Log) Sync() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.closed {
+		return ErrClosed
+	}
+
+	return l.file.Sync()
+}
+
+// Close closes the log, readers that were already created remain usable.
+func (l *Log) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.closed {
+		return ErrClosed
+	}
+
+	l.closed = true
+	return l.file.Close()
+}
+
+// Reader returns a reader positioned at record, which must be between First
+// and Next.
+func (l *Log) Reader(record int64) (*Reader, error) {
+	l.mutex.Lock()
+	first, next := l.segments[0], l.next
+	l.mutex.Unlock()
+
+	if record < first || record > next {
+		return nil, fmt.Errorf("objconv/logfile: cannot read from record %d which is outside of the log [%d:%d]", record, first, next)
+	}
+
+	r := &Reader{log: l, record: record}
+
+	if err := r.open(record); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (l *Log) path(base int64) string {
+	return filepath.Join(l.dir, fmt.Sprintf("%020d%s", base, segmentExt))
+}
+
+func (l *Log) create(base int64) error {
+	f, err := os.OpenFile(l.path(base), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	l.segments = append(l.segments, base)
+	l.file = f
+	l.size = 0
+	l.next = base
+	return nil
+}
+
+func (l *Log) rotate() error {
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	return l.create(l.next)
+}
+
+// recover opens the last segment and truncates it to its longest valid prefix.
+func (l *Log) recover() error {
+	base := l.segments[len(l.segments)-1]
+
+	f, err := os.OpenFile(l.path(base), os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+
+	n, off, err := scanFrames(bufio.NewReader(f))
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err == nil && size != off {
+		if err = f.Truncate(off); err == nil {
+			_, err = f.Seek(off, io.SeekStart)
+		}
+	}
+
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	l.file = f
+	l.size = off
+	l.next = base + n
+	l.recovered = size - off
+	return nil
+}
+
+// Reader iterates over the records of a log.
+//
+// Readers are not safe to use from multiple goroutines.
+type Reader struct {
+	log    *Log
+	file   *os.File
+	buf    *bufio.Reader
+	base   int64 // first record of the current segment
+	record int64 // number of the next record
+	data   []byte
+	err    error
+}
+
+// Record returns the number of the next record that Decode will load.
+func (r *Reader) Record() int64 {
+	return r.record
+}
+
+// Decode loads the next record of the log into v, or returns objconv.End if
+// the reader reached the end of the log.
+//
+// Records appended to the log after the reader reached the end can be read by
+// calling Decode again.
+func (r *Reader) Decode(v interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	for {
+		payload, err := readFrame(r.buf, r.data)
+
+		switch err {
+		case nil:
+			r.data = payload[:0]
+			r.record++
+			return r.log.codec.NewDecoder(bytes.NewReader(payload)).Decode(v)
+
+		case io.EOF:
+			if !r.hasNext() {
+				return objconv.End
+			}
+			if err = r.open(r.record); err != nil {
+				r.err = err
+				return err
+			}
+
+		case io.ErrUnexpectedEOF:
+			if !r.hasNext() { // the record is being written
+				r.rewind()
+				return objconv.End
+			}
+			fallthrough
+
+		default:
+			r.err = fmt.Errorf("objconv/logfile: corrupted record %d in segment %s: %s", r.record, r.log.path(r.base), err)
+			return r.err
+		}
+	}
+}
+
+// Close releases the resources held by the reader.
+func (r *Reader) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	r.err = ErrClosed
+	return err
+}
+
+// hasNext returns true if the records that follow the reader's position are in
+// a different segment.
+func (r *Reader) hasNext() bool {
+	r.log.mutex.Lock()
+	defer r.log.mutex.Unlock()
+	segments := r.log.segments
+	return segments[segmentIndex(segments, r.record)] != r.base
+}
+
+func (r *Reader) open(record int64) error {
+	r.log.mutex.Lock()
+	base := r.log.segments[segmentIndex(r.log.segments, record)]
+	path := r.log.path(base)
+	r.log.mutex.Unlock()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	b := bufio.NewReader(f)
+
+	if _, err = skipFrames(b, record-base); err != nil {
+		f.Close()
+		return err
+	}
+
+	if r.file != nil {
+		r.file.Close()
+	}
+
+	r.file = f
+	r.buf = b
+	r.base = base
+	return nil
+}
+
+// rewind moves the reader back to the beginning of the next record so reading
+// it can be retried once it has been fully written.
+func (r *Reader) rewind() {
+	if err := r.open(r.record); err != nil {
+		r.err = err
+	}
+}
+
+func listSegments(dir string) ([]int64, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]int64, 0, len(files))
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), segmentExt)
+		base, err := strconv.ParseInt(name, 10, 64)
+		if err != nil {
+			continue // not a segment
+		}
+		segments = append(segments, base)
+	}
+
+	sort.Slice(segments, func(i int, j int) bool { return segments[i] < segments[j] })
+	return segments, nil
+}
+
+// segmentIndex returns the index of the segment containing record.
+func segmentIndex(segments []int64, record int64) int {
+	i := sort.Search(len(segments), func(i int) bool { return segments[i] > record })
+	if i != 0 {
+		i--
+	}
+	return i
+}
+
+// readFrame reads the next frame from r, reusing b to store the payload.
+//
+// The function returns io.EOF if there was no more frames, and
+// io.ErrUnexpectedEOF if the last frame was incomplete.
+func readFrame(r *bufio.Reader, b []byte) ([]byte, error) {
+	var header [headerSize]byte
+
+	if n, err := io.ReadFull(r, header[:]); err != nil {
+		if n != 0 && err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[:4])
+	sum := binary.BigEndian.Uint32(header[4:])
+
+	if size == 0 || size > MaxRecordSize {
+		return nil, fmt.Errorf("invalid frame length: %d", size)
+	}
+
+	if cap(b) < int(size) {
+		b = make([]byte, size)
+	} else {
+		b = b[:size]
+	}
+
+	if _, err := io.ReadFull(r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	if crc32.Checksum(b, crcTable) != sum {
+		return nil, errors.New("checksum mismatch")
+	}
+
+	return b, nil
+}
+
+// scanFrames reads valid frames from r until the end of the input or the first
+// invalid frame, returning how many were read and the size they occupied.
+func scanFrames(r *bufio.Reader) (n int64, off int64, err error) {
+	var b []byte
+
+	for {
+		if b, err = readFrame(r, b); err != nil {
+			if _, ok := err.(*os.PathError); !ok {
+				err = nil // the rest of the input is not a valid frame
+			}
+			return
+		}
+		n++
+		off += headerSize + int64(len(b))
+	}
+}
+
+// skipFrames reads n frames from r and returns the size they occupied.
+func skipFrames(r *bufio.Reader, n int64) (off int64, err error) {
+	var header [headerSize]byte
+
+	for i := int64(0); i != n; i++ {
+		if _, err = io.ReadFull(r, header[:]); err != nil {
+			return
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+
+		if _, err = r.Discard(int(size)); err != nil {
+			return
+		}
+
+		off += headerSize + size
+	}
+
+	return
+}
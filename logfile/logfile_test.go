@@ -0,0 +1,213 @@
+package logfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/msgpack"
+)
+
+type record struct {
+	ID   int    `objconv:"id"`
+	Name string `objconv:"name"`
+}
+
+func testLog(t *testing.T, config Config) (*Log, string) {
+	dir, err := ioutil.TempDir("", "objconv-logfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Open(dir, msgpack.Codec, config)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	return l, dir
+}
+
+func appendRecords(t *testing.T, l *Log, from int, to int) {
+	for i := from; i != to; i++ {
+		n, err := l.Append(record{ID: i, Name: "hello"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != int64(i) {
+			t.Fatalf("bad record number: %d != %d", i, n)
+		}
+	}
+}
+
+func readRecords(t *testing.T, l *Log, from int64) []int {
+	r, err := l.Reader(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	var ids []int
+
+	for {
+		var v record
+
+		if err := r.Decode(&v); err != nil {
+			if err != objconv.End {
+				t.Fatal(err)
+			}
+			return ids
+		}
+
+		ids = append(ids, v.ID)
+	}
+}
+
+func checkRecords(t *testing.T, ids []int, from int, to int) {
+	if len(ids) != (to - from) {
+		t.Fatalf("bad number of records: %d != %d", to-from, len(ids))
+	}
+	for i, id := range ids {
+		if id != from+i {
+			t.Fatalf("bad record at index %d: %d != %d", i, from+i, id)
+		}
+	}
+}
+
+func TestLogAppendAndRead(t *testing.T) {
+	l, dir := testLog(t, Config{SegmentSize: 100})
+	defer os.RemoveAll(dir)
+	defer l.Close()
+
+	appendRecords(t, l, 0, 50)
+
+	if segments, _ := listSegments(dir); len(segments) < 2 {
+		t.Error("the log was not split in multiple segments:", segments)
+	}
+
+	checkRecords(t, readRecords(t, l, 0), 0, 50)
+	checkRecords(t, readRecords(t, l, 42), 42, 50)
+	checkRecords(t, readRecords(t, l, 50), 50, 50)
+
+	if _, err := l.Reader(51); err == nil {
+		t.Error("no error returned when reading past the end of the log")
+	}
+}
+
+func TestLogReaderFollow(t *testing.T) {
+	l, dir := testLog(t, Config{SegmentSize: 100})
+	defer os.RemoveAll(dir)
+	defer l.Close()
+
+	r, err := l.Reader(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	var v record
+
+	for i := 0; i != 20; i++ {
+		appendRecords(t, l, i, i+1)
+
+		if err := r.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+
+		if v.ID != i {
+			t.Fatalf("bad record: %d != %d", i, v.ID)
+		}
+
+		if err := r.Decode(&v); err != objconv.End {
+			t.Fatal("expected the end of the log but got:", err)
+		}
+	}
+}
+
+func TestLogRecover(t *testing.T) {
+	l, dir := testLog(t, Config{})
+	defer os.RemoveAll(dir)
+
+	appendRecords(t, l, 0, 10)
+	size := l.size
+	l.Close()
+
+	// Simulate a crash in the middle of writing a record.
+	path := filepath.Join(dir, "00000000000000000000.log")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{0, 0, 0, 42, 1, 2, 3, 4, 5})
+	f.Close()
+
+	if l, err = Open(dir, msgpack.Codec, Config{}); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	if n := l.Recovered(); n != 9 {
+		t.Error("bad number of recovered bytes:", n)
+	}
+
+	if l.size != size {
+		t.Errorf("bad segment size after recovery: %d != %d", size, l.size)
+	}
+
+	appendRecords(t, l, 10, 15)
+	checkRecords(t, readRecords(t, l, 0), 0, 15)
+}
+
+func TestLogTruncate(t *testing.T) {
+	l, dir := testLog(t, Config{SegmentSize: 100})
+	defer os.RemoveAll(dir)
+	defer l.Close()
+
+	appendRecords(t, l, 0, 30)
+
+	if err := l.Truncate(12); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := l.Next(); n != 12 {
+		t.Error("bad next record number:", n)
+	}
+
+	checkRecords(t, readRecords(t, l, 0), 0, 12)
+	appendRecords(t, l, 12, 20)
+	checkRecords(t, readRecords(t, l, 0), 0, 20)
+
+	if err := l.Truncate(21); err == nil {
+		t.Error("no error returned when truncating past the end of the log")
+	}
+}
+
+func TestLogTrim(t *testing.T) {
+	l, dir := testLog(t, Config{SegmentSize: 100})
+	defer os.RemoveAll(dir)
+	defer l.Close()
+
+	appendRecords(t, l, 0, 30)
+
+	if err := l.Trim(15); err != nil {
+		t.Fatal(err)
+	}
+
+	first := l.First()
+
+	if first == 0 || first > 15 {
+		t.Fatal("bad first record after trimming the log:", first)
+	}
+
+	checkRecords(t, readRecords(t, l, first), int(first), 30)
+
+	if err := l.Trim(1000); err != nil {
+		t.Fatal(err)
+	}
+
+	if segments, _ := listSegments(dir); len(segments) != 1 {
+		t.Error("bad number of segments after trimming the whole log:", segments)
+	}
+}
@@ -0,0 +1,225 @@
+// Package merge implements three-way merges of documents, which reconcile the
+// changes made concurrently to two copies of a common base document.
+//
+// The documents are compared in their encoded form, so they can be maps
+// and slices produced by decoders, or values of struct types whose fields are
+// identified by their serialized names. Maps are merged key by key, and the
+// changes made to different keys of the two copies are combined:
+//
+//	// base:   {"replicas": 2, "image": "app:1"}
+//	// mine:   {"replicas": 3, "image": "app:1"}
+//	// theirs: {"replicas": 2, "image": "app:2"}
+//	v, err := merge.Merge(base, mine, theirs)
+//	// v:      {"replicas": 3, "image": "app:2"}
+//
+// Other values, including arrays, are merged as a whole. When both copies
+// changed the same value differently, the conflict is given to the policy of
+// the merger, which either picks a value or reports the conflict.
+package merge
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/segmentio/objconv"
+)
+
+// ErrConflict is the error wrapped by the field errors reported for conflicts
+// by the Fail policy.
+var ErrConflict = errors.New("objconv/merge: conflicting changes")
+
+// Absent represents values which don't exist in a document in conflicts, for
+// example because they were deleted from one of the copies. Policies can
+// return Absent to delete the value from the merged document.
+var Absent interface{} = absent{}
+
+type absent struct{}
+
+// Conflict describes a value changed differently in the two copies of a
+// document, the values are in the form produced by objconv.ValueEmitter.
+type Conflict struct {
+	Path   string // dotted path to the value, empty for the top-level value
+	Base   interface{}
+	Mine   interface{}
+	Theirs interface{}
+}
+
+// A Policy resolves the conflicts found by a merge.
+type Policy interface {
+	// Resolve returns the merged value for the conflict c, or an error to
+	// report the conflict.
+	Resolve(c Conflict) (interface{}, error)
+}
+
+// PolicyFunc makes it possible to use basic functions as policies.
+type PolicyFunc func(Conflict) (interface{}, error)
+
+// Resolve calls f.
+func (f PolicyFunc) Resolve(c Conflict) (interface{}, error) { return f(c) }
+
+var (
+	// Fail reports conflicts as errors of type *objconv.FieldError, with the
+	// "conflict" code.
+	Fail Policy = PolicyFunc(fail)
+
+	// Mine resolves conflicts in favor of the first copy.
+	Mine Policy = PolicyFunc(func(c Conflict) (interface{}, error) { return c.Mine, nil })
+
+	// Theirs resolves conflicts in favor of the second copy.
+	Theirs Policy = PolicyFunc(func(c Conflict) (interface{}, error) { return c.Theirs, nil })
+)
+
+// Merger carries the configuration of three-way merges.
+type Merger struct {
+	// Policy resolves the conflicts, Fail is used if nil.
+	Policy Policy
+}
+
+// Merge merges the changes made to base in mine and theirs with the Fail
+// policy, see Merger.Merge.
+func Merge(base, mine, theirs interface{}) (interface{}, error) {
+	return (&Merger{}).Merge(base, mine, theirs)
+}
+
+// Merge returns the document combining the changes made to base in mine and
+// theirs, maps of the returned document are of type
+// map[interface{}]interface{}.
+//
+// Errors returned by the policy don't interrupt the merge, they are returned in
+// an objconv.MultiError once all the document was merged, in which case the
+// merged document is not returned.
+func (m *Merger) Merge(base, mine, theirs interface{}) (interface{}, error) {
+	var docs [3]interface{}
+
+	for i, v := range [...]interface{}{base, mine, theirs} {
+		e := objconv.NewValueEmitter()
+
+		if err := objconv.NewEncoder(e).Encode(v); err != nil {
+			return nil, err
+		}
+
+		docs[i] = e.Value()
+	}
+
+	s := state{policy: m.Policy}
+
+	if s.policy == nil {
+		s.policy = Fail
+	}
+
+	v := s.merge("", docs[0], docs[1], docs[2])
+
+	if err := s.errs.Err(); err != nil {
+		return nil, err
+	}
+
+	if v == Absent {
+		v = nil
+	}
+
+	return v, nil
+}
+
+type state struct {
+	policy Policy
+	errs   objconv.MultiError
+}
+
+func (s *state) merge(path string, base, mine, theirs interface{}) interface{} {
+	switch {
+	case equal(mine, theirs):
+		return mine
+	case equal(base, mine):
+		return theirs
+	case equal(base, theirs):
+		return mine
+	}
+
+	b, ok1 := base.(map[interface{}]interface{})
+	m, ok2 := mine.(map[interface{}]interface{})
+	t, ok3 := theirs.(map[interface{}]interface{})
+
+	if ok2 && ok3 && (ok1 || base == Absent) {
+		return s.mergeMaps(path, b, m, t)
+	}
+
+	v, err := s.policy.Resolve(Conflict{Path: path, Base: base, Mine: mine, Theirs: theirs})
+	s.errs.Append(err)
+	return v
+}
+
+func (s *state) mergeMaps(path string, base, mine, theirs map[interface{}]interface{}) map[interface{}]interface{} {
+	merged := make(map[interface{}]interface{}, len(mine))
+
+	for _, k := range keysOf(base, mine, theirs) {
+		p := fmt.Sprint(k)
+
+		if len(path) != 0 {
+			p = path + "." + p
+		}
+
+		if v := s.merge(p, lookup(base, k), lookup(mine, k), lookup(theirs, k)); v != Absent {
+			merged[k] = v
+		}
+	}
+
+	return merged
+}
+
+// keysOf returns the keys of the maps, sorted so conflicts are resolved in a
+// predictable order.
+func keysOf(maps ...map[interface{}]interface{}) []interface{} {
+	seen := map[interface{}]bool{}
+	keys := []interface{}{}
+
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+	return keys
+}
+
+func lookup(m map[interface{}]interface{}, k interface{}) interface{} {
+	if v, ok := m[k]; ok {
+		return v
+	}
+	return Absent
+}
+
+func equal(a, b interface{}) bool {
+	if t1, ok := a.(time.Time); ok {
+		t2, ok := b.(time.Time)
+		return ok && t1.Equal(t2)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func fail(c Conflict) (interface{}, error) {
+	return Absent, &objconv.FieldError{
+		Path: c.Path,
+		Code: "conflict",
+		Err:  fmt.Errorf("%w: %s and %s", ErrConflict, describe(c.Mine), describe(c.Theirs)),
+	}
+}
+
+func describe(v interface{}) string {
+	switch v.(type) {
+	case absent:
+		return "deleted"
+	case map[interface{}]interface{}:
+		return "map"
+	case []interface{}:
+		return "array"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,123 @@
+package merge
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/objconv"
+)
+
+type service struct {
+	Image    string            `objconv:"image"`
+	Replicas int               `objconv:"replicas"`
+	Labels   map[string]string `objconv:"labels,omitempty"`
+	Ports    []int             `objconv:"ports,omitempty"`
+}
+
+func TestMerge(t *testing.T) {
+	base := service{Image: "app:1", Replicas: 2, Labels: map[string]string{"team": "a", "tier": "web"}}
+
+	mine := base
+	mine.Replicas = 3
+	mine.Labels = map[string]string{"team": "a"}
+
+	theirs := base
+	theirs.Image = "app:2"
+	theirs.Labels = map[string]string{"team": "a", "tier": "web", "env": "prod"}
+	theirs.Ports = []int{80}
+
+	v, err := Merge(base, mine, theirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[interface{}]interface{}{
+		"image":    "app:2",
+		"replicas": int64(3),
+		"labels":   map[interface{}]interface{}{"team": "a", "env": "prod"},
+		"ports":    []interface{}{int64(80)},
+	}
+
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("bad merge:\n%#v\n%#v", v, expected)
+	}
+
+	var s service
+
+	if err := objconv.NewDecoder(objconv.NewValueParser(v)).Decode(&s); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Image != "app:2" || s.Replicas != 3 || len(s.Labels) != 2 {
+		t.Errorf("bad decoded value: %#v", s)
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	base := map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": 1, "d": 1}}
+	mine := map[string]interface{}{"a": 2, "b": map[string]interface{}{"c": 2, "d": 1}}
+	theirs := map[string]interface{}{"a": 3, "b": map[string]interface{}{"c": 1}}
+
+	_, err := Merge(base, mine, theirs)
+
+	var errs objconv.MultiError
+
+	if !errors.As(err, &errs) || len(errs) != 1 || !errors.Is(err, ErrConflict) {
+		t.Fatalf("bad error: %v", err)
+	}
+
+	var fe *objconv.FieldError
+
+	if !errors.As(errs[0], &fe) || fe.Path != "a" || fe.Code != "conflict" {
+		t.Errorf("bad conflict: %v", errs[0])
+	}
+
+	tests := []struct {
+		policy Policy
+		a      interface{}
+	}{
+		{Mine, int64(2)},
+		{Theirs, int64(3)},
+		{PolicyFunc(func(c Conflict) (interface{}, error) { return Absent, nil }), nil},
+	}
+
+	for _, test := range tests {
+		v, err := (&Merger{Policy: test.policy}).Merge(base, mine, theirs)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		m := v.(map[interface{}]interface{})
+
+		if a, ok := m["a"]; a != test.a || ok != (test.a != nil) {
+			t.Errorf("bad resolution of the conflict: %#v", a)
+		}
+
+		if b := m["b"]; !reflect.DeepEqual(b, map[interface{}]interface{}{"c": int64(2)}) {
+			t.Errorf("bad merge of the nested map: %#v", b)
+		}
+	}
+}
+
+func TestMergeDeletedAndModified(t *testing.T) {
+	base := map[string]interface{}{"a": map[string]interface{}{"b": 1}}
+	mine := map[string]interface{}{}
+	theirs := map[string]interface{}{"a": map[string]interface{}{"b": 2}}
+
+	v, err := (&Merger{Policy: PolicyFunc(func(c Conflict) (interface{}, error) {
+		if c.Path != "a" || c.Mine != Absent {
+			t.Errorf("bad conflict: %#v", c)
+		}
+		return c.Theirs, nil
+	})}).Merge(base, mine, theirs)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(v, map[interface{}]interface{}{"a": map[interface{}]interface{}{"b": int64(2)}}) {
+		t.Errorf("bad merge: %#v", v)
+	}
+}
@@ -0,0 +1,547 @@
+// Package migrate rewrites stored documents from an old schema to a new one,
+// renaming fields and converting their values to the new types.
+//
+// A migration is described by the old and new Go types of the documents (which
+// programs usually keep side by side while the migration is deployed), and a
+// map of renamed fields. Fields are identified by their serialized names, the
+// fields of nested structs are referenced by dotted paths like "address.zip",
+// and the new name of a field only contains the last component of the path:
+//
+//	m, err := migrate.New(UserV1{}, UserV2{}, map[string]string{
+//		"mail":        "email",
+//		"address.zip": "postal_code",
+//	})
+//
+// Migrations created without types work on dynamically typed documents (maps,
+// slices and scalars), which is how the objconv-migrate command operates.
+package migrate
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Migration rewrites documents of an old schema to a new one.
+type Migration struct {
+	from   reflect.Type
+	to     reflect.Type
+	rename map[string]string
+
+	// Types is used by migrations on dynamically typed documents to convert
+	// the values of fields, it is indexed by the (old) path of the fields.
+	Types map[string]reflect.Type
+}
+
+// New returns a migration of documents from the type of old to the type of
+// new, where fields listed in rename are renamed.
+//
+// old and new may be nil to create a migration of dynamically typed
+// documents. The function returns an error if rename references fields that do
+// not exist in the old type, or renames them to fields that don't exist in the
+// new type. Fields with the encrypt tag option must remain encrypted, the
+// function also returns an error if they are migrated to fields of the new
+// type which don't have the option, so migrations never write their values in
+// plaintext.
+func New(old interface{}, new interface{}, rename map[string]string) (*Migration, error) {
+	m := &Migration{rename: rename}
+
+	if (old == nil) != (new == nil) {
+		return nil, fmt.Errorf("objconv/migrate: the old and new types must either both be set or both be nil")
+	}
+
+	if old != nil {
+		m.from = indirectType(reflect.TypeOf(old))
+		m.to = indirectType(reflect.TypeOf(new))
+
+		for path, name := range rename {
+			if _, ok := fieldType(m.from, path); !ok {
+				return nil, fmt.Errorf("objconv/migrate: the renamed field %q does not exist in %s", path, m.from)
+			}
+
+			newPath := name
+			if i := strings.LastIndexByte(path, '.'); i >= 0 {
+				newPath = m.newPath(path[:i]) + "." + name
+			}
+
+			if _, ok := fieldType(m.to, newPath); !ok {
+				return nil, fmt.Errorf("objconv/migrate: the field %q is renamed to %q which does not exist in %s", path, newPath, m.to)
+			}
+		}
+
+		if err := m.checkEncrypted(m.from, m.to, "", map[reflect.Type]bool{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Apply migrates v, returning the new version of the value.
+//
+// When the migration has types, v must be a value of the old type (or a
+// pointer to it) and the returned value is a pointer to a value of the new
+// type.
+func (m *Migration) Apply(v interface{}) (interface{}, error) {
+	if m.from == nil {
+		return m.applyDynamic(v, "")
+	}
+
+	from := reflect.ValueOf(v)
+
+	if from.Kind() == reflect.Ptr {
+		from = from.Elem()
+	}
+
+	if from.Type() != m.from {
+		return nil, fmt.Errorf("objconv/migrate: cannot migrate a value of type %s, expected %s", from.Type(), m.from)
+	}
+
+	to := reflect.New(m.to)
+
+	if err := m.convert(to.Elem(), from, ""); err != nil {
+		return nil, err
+	}
+
+	return to.Interface(), nil
+}
+
+// Transcode migrates all the documents read from d and writes them to e, then
+// closes e.
+func (m *Migration) Transcode(e *objconv.StreamEncoder, d *objconv.StreamDecoder) error {
+	for {
+		var v interface{}
+		var err error
+
+		if m.from == nil {
+			err = d.Decode(&v)
+		} else {
+			p := reflect.New(m.from)
+			err = d.Decode(p.Interface())
+			v = p.Interface()
+		}
+
+		if err != nil {
+			if err == objconv.End {
+				return e.Close()
+			}
+			return err
+		}
+
+		if v, err = m.Apply(v); err != nil {
+			return err
+		}
+
+		if err = e.Encode(v); err != nil {
+			return err
+		}
+	}
+}
+
+// Migrate reads documents encoded with codec from r, migrates them and writes
+// the new versions to w.
+//
+// The output has the same shape as the input, a single document or an array of
+// documents.
+func (m *Migration) Migrate(w io.Writer, r io.Reader, codec objconv.Codec) error {
+	d := codec.NewStreamDecoder(r)
+
+	e, err := d.Encoder(codec.NewEmitter(w))
+	if err != nil {
+		if err == io.EOF {
+			err = nil // empty input
+		}
+		return err
+	}
+
+	e.SortMapKeys = true
+	return m.Transcode(e, d)
+}
+
+// newName returns the new name of the field at path.
+func (m *Migration) newName(path string, name string) string {
+	if rename, ok := m.rename[path]; ok {
+		return rename
+	}
+	return name
+}
+
+// newPath translates a path in the old schema to the new one.
+func (m *Migration) newPath(path string) string {
+	old := strings.Split(path, ".")
+	parts := make([]string, len(old))
+
+	for i, name := range old {
+		parts[i] = m.newName(strings.Join(old[:i+1], "."), name)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func (m *Migration) convert(to reflect.Value, from reflect.Value, path string) error {
+	if from.Kind() == reflect.Interface || from.Kind() == reflect.Ptr {
+		if from.IsNil() {
+			return nil // leave the zero-value
+		}
+		from = from.Elem()
+	}
+
+	if to.Kind() == reflect.Ptr {
+		p := reflect.New(to.Type().Elem())
+		if err := m.convert(p.Elem(), from, path); err != nil {
+			return err
+		}
+		to.Set(p)
+		return nil
+	}
+
+	switch {
+	case to.Kind() == reflect.Struct && from.Kind() == reflect.Struct && from.Type() != to.Type():
+		return m.convertStruct(to, from, path)
+
+	case to.Kind() == reflect.Slice && from.Kind() == reflect.Slice && from.Type() != to.Type() && to.Type().Elem().Kind() != reflect.Uint8:
+		s := reflect.MakeSlice(to.Type(), from.Len(), from.Len())
+
+		for i := 0; i != from.Len(); i++ {
+			if err := m.convert(s.Index(i), from.Index(i), path); err != nil {
+				return err
+			}
+		}
+
+		to.Set(s)
+		return nil
+
+	case to.Kind() == reflect.Map && from.Kind() == reflect.Map && from.Type() != to.Type():
+		x := reflect.MakeMapWithSize(to.Type(), from.Len())
+		it := from.MapRange()
+
+		for it.Next() {
+			k := reflect.New(to.Type().Key()).Elem()
+			v := reflect.New(to.Type().Elem()).Elem()
+
+			if err := convertValue(k, it.Key()); err != nil {
+				return fieldError(path, err)
+			}
+
+			if err := m.convert(v, it.Value(), path); err != nil {
+				return err
+			}
+
+			x.SetMapIndex(k, v)
+		}
+
+		to.Set(x)
+		return nil
+	}
+
+	if err := convertValue(to, from); err != nil {
+		return fieldError(path, err)
+	}
+
+	return nil
+}
+
+// checkEncrypted returns an error if encrypted fields of from are migrated to
+// fields of to which are not encrypted, seen prevents infinite recursions on
+// types that reference themselves.
+func (m *Migration) checkEncrypted(from reflect.Type, to reflect.Type, path string, seen map[reflect.Type]bool) error {
+	if from = elemType(from); from.Kind() != reflect.Struct || seen[from] {
+		return nil
+	}
+	seen[from] = true
+
+	if to = elemType(to); to.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := fieldsOf(to)
+
+	for _, f := range fieldsOf(from) {
+		p := joinPath(path, f.name)
+		g, ok := fields[m.newName(p, f.name)]
+
+		switch {
+		case !ok:
+		case f.encrypt && !g.encrypt:
+			return fmt.Errorf("objconv/migrate: the encrypted field %q is migrated to the field %q of %s which is not encrypted", p, g.name, to)
+		case !f.encrypt:
+			if err := m.checkEncrypted(f.typ, g.typ, p, seen); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *Migration) convertStruct(to reflect.Value, from reflect.Value, path string) error {
+	fields := fieldsOf(to.Type())
+
+	for _, f := range fieldsOf(from.Type()) {
+		p := joinPath(path, f.name)
+		i, ok := fields[m.newName(p, f.name)]
+
+		if !ok {
+			continue // the field was removed
+		}
+
+		if err := m.convert(to.FieldByIndex(i.index), from.FieldByIndex(f.index), p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Migration) applyDynamic(v interface{}, path string) (interface{}, error) {
+	switch x := v.(type) {
+	case map[interface{}]interface{}:
+		r := make(map[interface{}]interface{}, len(x))
+
+		for k, v := range x {
+			var err error
+
+			if s, ok := k.(string); ok {
+				p := joinPath(path, s)
+				k = m.newName(p, s)
+
+				if v, err = m.applyDynamic(v, p); err != nil {
+					return nil, err
+				}
+			}
+
+			r[k] = v
+		}
+
+		return r, nil
+
+	case map[string]interface{}:
+		r := make(map[string]interface{}, len(x))
+
+		for k, v := range x {
+			p := joinPath(path, k)
+			v, err := m.applyDynamic(v, p)
+			if err != nil {
+				return nil, err
+			}
+			r[m.newName(p, k)] = v
+		}
+
+		return r, nil
+
+	case []interface{}:
+		r := make([]interface{}, len(x))
+
+		for i, v := range x {
+			v, err := m.applyDynamic(v, path)
+			if err != nil {
+				return nil, err
+			}
+			r[i] = v
+		}
+
+		return r, nil
+	}
+
+	if t, ok := m.Types[path]; ok && v != nil {
+		to := reflect.New(t).Elem()
+
+		if err := convertValue(to, reflect.ValueOf(v)); err != nil {
+			return nil, fieldError(path, err)
+		}
+
+		return to.Interface(), nil
+	}
+
+	return v, nil
+}
+
+// convertValue sets to to the value of from, converting it to the type of to.
+func convertValue(to reflect.Value, from reflect.Value) error {
+	ft, tt := from.Type(), to.Type()
+
+	if ft.AssignableTo(tt) {
+		to.Set(from)
+		return nil
+	}
+
+	fk, tk := kindOf(ft), kindOf(tt)
+
+	if fk == reflect.Int && tk == reflect.Int && isSigned(ft) && !isSigned(tt) && from.Int() < 0 {
+		return fmt.Errorf("%v overflows %s", from, tt)
+	}
+
+	switch {
+	case fk == tk && fk != reflect.Invalid:
+		// Converting between numeric types may overflow, the conversion is
+		// validated by converting the value back.
+		v := from.Convert(tt)
+		if fk != reflect.String && v.Convert(ft).Interface() != from.Interface() {
+			return fmt.Errorf("%v overflows %s", from, tt)
+		}
+		to.Set(v)
+		return nil
+
+	case tk == reflect.String && fk != reflect.Invalid:
+		to.SetString(fmt.Sprint(from.Interface()))
+		return nil
+
+	case fk == reflect.Int || fk == reflect.Float64:
+		if tk == reflect.Int || tk == reflect.Float64 {
+			v := from.Convert(tt)
+			if v.Convert(ft).Interface() != from.Interface() {
+				return fmt.Errorf("%v cannot be represented as %s", from, tt)
+			}
+			to.Set(v)
+			return nil
+		}
+
+	case fk == reflect.String && from.Len() == 0 && (tk == reflect.Bool || tk == reflect.Int || tk == reflect.Float64):
+		to.Set(reflect.Zero(tt)) // empty strings are treated as missing values
+		return nil
+
+	case fk == reflect.String:
+		s := from.String()
+
+		switch tk {
+		case reflect.Bool:
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return err
+			}
+			to.SetBool(b)
+			return nil
+
+		case reflect.Int, reflect.Float64:
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return err
+			}
+			return convertValue(to, reflect.ValueOf(f))
+		}
+	}
+
+	if ft.ConvertibleTo(tt) && ft.Kind() == tt.Kind() {
+		to.Set(from.Convert(tt))
+		return nil
+	}
+
+	return fmt.Errorf("cannot convert %s to %s", ft, tt)
+}
+
+// kindOf groups the kinds of basic types, all numeric kinds are reported as
+// reflect.Int or reflect.Float64.
+func kindOf(t reflect.Type) reflect.Kind {
+	switch k := t.Kind(); k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.Int
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	case reflect.Bool, reflect.String:
+		return k
+	}
+	return reflect.Invalid
+}
+
+func isSigned(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+type field struct {
+	name    string
+	index   []int
+	typ     reflect.Type
+	encrypt bool
+}
+
+// fieldsOf returns the serializable fields of the struct type t indexed by
+// name, following the same rules as the objconv encoder.
+func fieldsOf(t reflect.Type) map[string]field {
+	fields := make(map[string]field, t.NumField())
+
+	for i := 0; i != t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.Anonymous || len(f.PkgPath) != 0 { // anonymous or non-exported
+			continue
+		}
+
+		var tag objutil.Tag
+
+		if s := f.Tag.Get("objconv"); len(s) != 0 {
+			tag = objutil.ParseTag(s)
+		} else {
+			tag = objutil.ParseTagJSON(f.Tag.Get("json"))
+		}
+
+		name := f.Name
+
+		if len(tag.Name) != 0 {
+			name = tag.Name
+		}
+
+		if name != "-" {
+			fields[name] = field{name: name, index: f.Index, typ: f.Type, encrypt: tag.Encrypt}
+		}
+	}
+
+	return fields
+}
+
+// fieldType returns the type of the field at path in the struct type t.
+func fieldType(t reflect.Type, path string) (reflect.Type, bool) {
+	for _, name := range strings.Split(path, ".") {
+		if t = elemType(t); t.Kind() != reflect.Struct {
+			return nil, false
+		}
+
+		f, ok := fieldsOf(t)[name]
+		if !ok {
+			return nil, false
+		}
+
+		t = f.typ
+	}
+	return t, true
+}
+
+// elemType returns the type of the values that fields of type t contain,
+// looking through pointers, slices and maps.
+func elemType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func joinPath(path string, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return path + "." + name
+}
+
+func fieldError(path string, err error) error {
+	return fmt.Errorf("objconv/migrate: field %q: %s", path, err)
+}
@@ -0,0 +1,183 @@
+package migrate
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/objconv/json"
+)
+
+type addressV1 struct {
+	Street string `objconv:"street"`
+	Zip    int    `objconv:"zip"`
+}
+
+type userV1 struct {
+	Name    string      `objconv:"name"`
+	Mail    string      `objconv:"mail"`
+	Age     int32       `objconv:"age"`
+	Admin   string      `objconv:"admin"`
+	Address addressV1   `objconv:"address"`
+	Old     []addressV1 `objconv:"old"`
+	Removed bool        `objconv:"removed"`
+}
+
+type addressV2 struct {
+	Street     string `objconv:"street"`
+	PostalCode string `objconv:"postal_code"`
+}
+
+type userV2 struct {
+	Name    string       `objconv:"name"`
+	Email   string       `objconv:"email"`
+	Age     uint8        `objconv:"age"`
+	Admin   bool         `objconv:"admin"`
+	Address *addressV2   `objconv:"address"`
+	Old     []addressV2  `objconv:"old"`
+	Added   []string     `objconv:"added"`
+	Extra   map[int]bool `objconv:"extra"`
+}
+
+var renames = map[string]string{
+	"mail":        "email",
+	"address.zip": "postal_code",
+	"old.zip":     "postal_code",
+}
+
+func TestApply(t *testing.T) {
+	m, err := New(userV1{}, userV2{}, renames)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := m.Apply(userV1{
+		Name:    "Luke",
+		Mail:    "luke@example.com",
+		Age:     19,
+		Admin:   "true",
+		Address: addressV1{Street: "Main St", Zip: 94107},
+		Old:     []addressV1{{Street: "Farm Rd", Zip: 1}},
+		Removed: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &userV2{
+		Name:    "Luke",
+		Email:   "luke@example.com",
+		Age:     19,
+		Admin:   true,
+		Address: &addressV2{Street: "Main St", PostalCode: "94107"},
+		Old:     []addressV2{{Street: "Farm Rd", PostalCode: "1"}},
+	}
+
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("%#v != %#v", expected, v)
+	}
+}
+
+func TestApplyOverflow(t *testing.T) {
+	m, err := New(userV1{}, userV2{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Apply(userV1{Age: 1000}); err == nil {
+		t.Error("no error returned when a value overflows the new type")
+	}
+
+	if _, err := m.Apply(userV1{Age: -1}); err == nil {
+		t.Error("no error returned when a negative value is converted to an unsigned type")
+	}
+}
+
+func TestNewInvalidRename(t *testing.T) {
+	tests := []map[string]string{
+		{"nope": "email"},
+		{"mail": "nope"},
+		{"address.nope": "postal_code"},
+		{"address.zip": "nope"},
+	}
+
+	for _, test := range tests {
+		if _, err := New(userV1{}, userV2{}, test); err == nil {
+			t.Errorf("no error returned for %v", test)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	m, err := New(userV1{}, userV2{}, renames)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := strings.NewReader(`[{"name":"A","mail":"a@example.com"},{"name":"B","address":{"zip":42}}]`)
+	w := &bytes.Buffer{}
+
+	if err := m.Migrate(w, r, json.Codec); err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = `[{"name":"A","email":"a@example.com","age":0,"admin":false,"address":{"street":"","postal_code":"0"},"old":[],"added":[],"extra":{}},` +
+		`{"name":"B","email":"","age":0,"admin":false,"address":{"street":"","postal_code":"42"},"old":[],"added":[],"extra":{}}]`
+
+	if s := w.String(); s != expected {
+		t.Errorf("%s\n%s", expected, s)
+	}
+}
+
+func TestMigrateDynamic(t *testing.T) {
+	m, err := New(nil, nil, renames)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.Types = map[string]reflect.Type{"address.zip": reflect.TypeOf("")}
+
+	r := strings.NewReader(`{"mail":"a@example.com","address":{"zip":42},"old":[{"zip":1}]}`)
+	w := &bytes.Buffer{}
+
+	if err := m.Migrate(w, r, json.Codec); err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = `{"address":{"postal_code":"42"},"email":"a@example.com","old":[{"postal_code":1}]}`
+
+	if s := w.String(); s != expected {
+		t.Errorf("%s\n%s", expected, s)
+	}
+}
+
+func TestNewEncryptedField(t *testing.T) {
+	type secretV1 struct {
+		SSN string `objconv:"ssn,encrypt"`
+	}
+
+	type secretV2 struct {
+		SSN string `objconv:"ssn"`
+	}
+
+	type holderV1 struct {
+		Secrets []secretV1 `objconv:"secrets"`
+	}
+
+	type holderV2 struct {
+		Secrets []secretV2 `objconv:"secrets"`
+	}
+
+	if _, err := New(secretV1{}, secretV2{}, nil); err == nil {
+		t.Error("no error returned when migrating an encrypted field to a field which is not encrypted")
+	}
+
+	if _, err := New(holderV1{}, holderV2{}, nil); err == nil {
+		t.Error("no error returned when migrating a nested encrypted field to a field which is not encrypted")
+	}
+
+	if _, err := New(secretV1{}, secretV1{}, nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,202 @@
+package objconv
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Money is an amount of money in a currency, represented as an integer number
+// of minor units (like cents) to avoid the rounding errors of floating point
+// numbers.
+//
+// Text formats encode money values as maps with the amount written as a
+// decimal string, like {"amount":"12.34","currency":"USD"}, binary formats use
+// a compact array of the number of minor units and the currency code, like
+// [1234,"USD"]. Decoders accept both representations.
+type Money struct {
+	Amount   int64  // number of minor units, 1234 is 12.34 in USD
+	Currency string // ISO 4217 currency code, like "USD"
+}
+
+// CurrencyExponent returns the number of digits of the minor units of the
+// currency with the given ISO 4217 code, which is 2 for most currencies.
+func CurrencyExponent(currency string) int {
+	if exp, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+var currencyExponents = map[string]int{
+	"BHD": 3, "BIF": 0, "CLF": 4, "CLP": 0, "DJF": 0, "GNF": 0, "IQD": 3,
+	"ISK": 0, "JOD": 3, "JPY": 0, "KMF": 0, "KRW": 0, "KWD": 3, "LYD": 3,
+	"OMR": 3, "PYG": 0, "RWF": 0, "TND": 3, "UGX": 0, "UYI": 0, "UYW": 4,
+	"VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// ParseMoney parses amount, a decimal number of major units like "12.34", as
+// an amount of money in the given currency. An error is returned if the amount
+// has more decimals than the minor units of the currency can represent.
+func ParseMoney(amount string, currency string) (Money, error) {
+	exp := CurrencyExponent(currency)
+	s := amount
+	neg := false
+
+	switch {
+	case strings.HasPrefix(s, "-"):
+		s, neg = s[1:], true
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	i, f := s, ""
+
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		i, f = s[:dot], s[dot+1:]
+	}
+
+	if len(i) == 0 || !isDigits(i) || !isDigits(f) {
+		return Money{}, objutil.Errorf(objutil.ErrSyntax, "objconv: invalid amount of money: %q", amount)
+	}
+
+	if len(f) > exp {
+		return Money{}, objutil.Errorf(objutil.ErrRange, "objconv: %s amounts cannot have more than %d decimals: %q", currency, exp, amount)
+	}
+
+	n, err := strconv.ParseInt(i+f+strings.Repeat("0", exp-len(f)), 10, 64)
+
+	if err != nil {
+		return Money{}, objutil.Errorf(objutil.ErrRange, "objconv: amount of money out of range: %q", amount)
+	}
+
+	if neg {
+		n = -n
+	}
+
+	return Money{Amount: n, Currency: currency}, nil
+}
+
+// Decimal returns the amount of m as a decimal number of major units, like
+// "12.34".
+func (m Money) Decimal() string {
+	exp := CurrencyExponent(m.Currency)
+	abs := uint64(m.Amount)
+
+	if m.Amount < 0 {
+		abs = -abs
+	}
+
+	s := strconv.FormatUint(abs, 10)
+
+	if exp != 0 {
+		if len(s) <= exp {
+			s = strings.Repeat("0", exp-len(s)+1) + s
+		}
+		s = s[:len(s)-exp] + "." + s[len(s)-exp:]
+	}
+
+	if m.Amount < 0 {
+		s = "-" + s
+	}
+
+	return s
+}
+
+// String returns a human-readable representation of m, like "12.34 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// EncodeValue satisfies the ValueEncoder interface.
+func (m Money) EncodeValue(e Encoder) error {
+	if e.Emitter != nil && isTextEmitter(e.Emitter) {
+		return e.Encode(moneyRecord{Amount: m.Decimal(), Currency: m.Currency})
+	}
+	return e.Encode([]interface{}{m.Amount, m.Currency})
+}
+
+// DecodeValue satisfies the ValueDecoder interface.
+func (m *Money) DecodeValue(d Decoder) error {
+	var v interface{}
+
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+
+	var amount, currency interface{}
+
+	switch x := v.(type) {
+	case map[string]interface{}:
+		amount, currency = x["amount"], x["currency"]
+	case map[interface{}]interface{}:
+		amount, currency = x["amount"], x["currency"]
+	}
+
+	if c, ok := currency.(string); ok {
+		var s string
+
+		switch a := amount.(type) {
+		case string:
+			s = a
+		case int64:
+			s = strconv.FormatInt(a, 10)
+		case uint64:
+			s = strconv.FormatUint(a, 10)
+		case float64:
+			s = strconv.FormatFloat(a, 'f', -1, 64)
+		case Number:
+			// Keep the exact decimal representation unless the number was
+			// written with an exponent, which ParseMoney doesn't accept.
+			if s = string(a); strings.ContainsAny(s, "eE") {
+				f, _ := a.Float64()
+				s = strconv.FormatFloat(f, 'f', -1, 64)
+			}
+		}
+
+		if len(s) != 0 {
+			r, err := ParseMoney(s, c)
+			if err == nil {
+				*m = r
+			}
+			return err
+		}
+	}
+
+	if x, ok := v.([]interface{}); ok && len(x) == 2 {
+		c, _ := x[1].(string)
+
+		switch a := x[0].(type) {
+		case int64:
+			*m = Money{Amount: a, Currency: c}
+			return nil
+		case uint64:
+			if int64(a) >= 0 {
+				*m = Money{Amount: int64(a), Currency: c}
+				return nil
+			}
+		case Number:
+			if i, err := a.Int64(); err == nil {
+				*m = Money{Amount: i, Currency: c}
+				return nil
+			}
+		}
+	}
+
+	return objutil.Errorf(objutil.ErrType, "objconv: cannot decode an amount of money from %v", v)
+}
+
+type moneyRecord struct {
+	Amount   string `objconv:"amount"`
+	Currency string `objconv:"currency"`
+}
+
+func isDigits(s string) bool {
+	for i := range s {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package objconv
+
+import "testing"
+
+func TestMoneyDecimal(t *testing.T) {
+	tests := []struct {
+		in  Money
+		out string
+	}{
+		{Money{Amount: 0, Currency: "USD"}, "0.00"},
+		{Money{Amount: 5, Currency: "USD"}, "0.05"},
+		{Money{Amount: -1234, Currency: "EUR"}, "-12.34"},
+		{Money{Amount: 1500, Currency: "JPY"}, "1500"},
+		{Money{Amount: 1005, Currency: "KWD"}, "1.005"},
+	}
+
+	for _, test := range tests {
+		if s := test.in.Decimal(); s != test.out {
+			t.Errorf("%#v: %q != %q", test.in, s, test.out)
+		}
+
+		if m, err := ParseMoney(test.out, test.in.Currency); err != nil || m != test.in {
+			t.Errorf("%q: %#v != %#v (%v)", test.out, m, test.in, err)
+		}
+	}
+
+	for _, s := range []string{"", ".5", "1.2.3", "1e3", "--1"} {
+		if _, err := ParseMoney(s, "USD"); err == nil {
+			t.Errorf("%q: no error returned when parsing an invalid amount", s)
+		}
+	}
+}
+
+func TestMoneyUseNumber(t *testing.T) {
+	tests := []interface{}{
+		map[string]interface{}{"amount": 12.34, "currency": "USD"},
+		map[string]interface{}{"amount": "12.34", "currency": "USD"},
+		[]interface{}{1234, "USD"},
+	}
+
+	for _, test := range tests {
+		var m Money
+
+		if err := (Decoder{Parser: NewValueParser(test), UseNumber: true}).Decode(&m); err != nil {
+			t.Errorf("%v: %v", test, err)
+			continue
+		}
+
+		if m != (Money{Amount: 1234, Currency: "USD"}) {
+			t.Errorf("%v: bad value: %#v", test, m)
+		}
+	}
+}
@@ -18,12 +18,13 @@ func NewStreamDecoder(r io.Reader) *objconv.StreamDecoder {
 	return objconv.NewStreamDecoder(NewParser(r))
 }
 
-// Unmarshal decodes a MessagePack representation of v from b.
+// Unmarshal decodes a MessagePack representation of v from b, an error is returned
+// if b has data after the value.
 func Unmarshal(b []byte, v interface{}) error {
 	u := unmarshalerPool.Get().(*unmarshaler)
 	u.reset(b)
 
-	err := (objconv.Decoder{Parser: u}).Decode(v)
+	err := (objconv.Decoder{Parser: u, DisallowTrailingData: true}).Decode(v)
 
 	u.reset(nil)
 	unmarshalerPool.Put(u)
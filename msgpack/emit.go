@@ -2,7 +2,6 @@ package msgpack
 
 import (
 	"bytes"
-	"fmt"
 	"io"
 	"math"
 	"sync"
@@ -25,25 +24,48 @@ type Emitter struct {
 	// sback is used as the initial backing array for the stack slice to avoid
 	// dynamic memory allocations for the most common use cases.
 	sback [8]*context
+
+	// The emitter writes to this buffer, which gets flushed to the underlying
+	// writer when the encoder has completed writing a value.
+	buf objutil.BufferedWriter
 }
 
 type context struct {
-	b bytes.Buffer // buffer where the array elements are cached
+	b bytes.Buffer // buffer where the array or map elements are cached
 	w io.Writer    // the previous writer where b will be flushed
-	n int          // the number of elements written to the array
+	n int          // the number of elements written to the array or map
 }
 
 func NewEmitter(w io.Writer) *Emitter {
-	e := &Emitter{w: w}
+	e := &Emitter{}
 	e.stack = e.sback[:0]
+	e.Reset(w)
 	return e
 }
 
 func (e *Emitter) Reset(w io.Writer) {
-	e.w = w
+	e.buf.Reset(w)
+	e.w = &e.buf
 	e.stack = e.stack[:0]
 }
 
+// Flush writes the buffered output of the emitter to its underlying writer.
+func (e *Emitter) Flush() error {
+	return e.buf.Flush()
+}
+
+// EmitRaw satisfies the objconv.RawEmitter interface.
+func (e *Emitter) EmitRaw(b []byte) (err error) {
+	_, err = e.w.Write(b)
+	return
+}
+
+// TransientEmitter satisfies the objconv.transientEmitter interface, values
+// are written to the output when they are emitted.
+func (e *Emitter) TransientEmitter() bool {
+	return true
+}
+
 func (e *Emitter) EmitNil() (err error) {
 	e.b[0] = Nil
 	_, err = e.w.Write(e.b[:1])
@@ -184,7 +206,7 @@ func (e *Emitter) EmitString(v string) (err error) {
 		n = 5
 
 	default:
-		err = fmt.Errorf("objconv/msgpack: string of length %d is too long to be encoded", n)
+		err = objutil.Errorf(objutil.ErrLimit, "objconv/msgpack: string of length %d is too long to be encoded", n)
 		return
 	}
 
@@ -231,7 +253,7 @@ func (e *Emitter) EmitBytes(v []byte) (err error) {
 		n = 5
 
 	default:
-		err = fmt.Errorf("objconv/msgpack: byte slice of length %d is too long to be encoded", n)
+		err = objutil.Errorf(objutil.ErrLimit, "objconv/msgpack: byte slice of length %d is too long to be encoded", n)
 		return
 	}
 
@@ -286,11 +308,7 @@ func (e *Emitter) EmitArrayBegin(n int) (err error) {
 	var c *context
 
 	if n < 0 {
-		c = contextPool.Get().(*context)
-		c.b.Truncate(0)
-		c.n = 0
-		c.w = e.w
-		e.w = &c.b
+		c = e.pushContext()
 	} else {
 		err = e.emitArray(n)
 	}
@@ -329,11 +347,61 @@ func (e *Emitter) EmitArrayNext() (err error) {
 }
 
 func (e *Emitter) EmitMapBegin(n int) (err error) {
+	var c *context
+
 	if n < 0 {
-		err = fmt.Errorf("objconv/msgpack: encoding maps of unknown length is not supported (n = %d)", n)
-		return
+		c = e.pushContext()
+	} else {
+		err = e.emitMap(n)
+	}
+
+	e.stack = append(e.stack, c)
+	return
+}
+
+func (e *Emitter) EmitMapEnd() (err error) {
+	i := len(e.stack) - 1
+	c := e.stack[i]
+	e.stack = e.stack[:i]
+
+	if c != nil {
+		e.w = c.w
+
+		if c.b.Len() != 0 {
+			c.n++
+		}
+
+		if err = e.emitMap(c.n); err == nil {
+			_, err = c.b.WriteTo(c.w)
+		}
+
+		contextPool.Put(c)
 	}
 
+	return
+}
+
+func (e *Emitter) EmitMapValue() (err error) {
+	return
+}
+
+func (e *Emitter) EmitMapNext() (err error) {
+	if c := e.stack[len(e.stack)-1]; c != nil {
+		c.n++
+	}
+	return
+}
+
+func (e *Emitter) pushContext() *context {
+	c := contextPool.Get().(*context)
+	c.b.Truncate(0)
+	c.n = 0
+	c.w = e.w
+	e.w = &c.b
+	return c
+}
+
+func (e *Emitter) emitMap(n int) (err error) {
 	switch {
 	case n <= 15:
 		e.b[0] = byte(n) | FixmapTag
@@ -350,7 +418,7 @@ func (e *Emitter) EmitMapBegin(n int) (err error) {
 		n = 5
 
 	default:
-		err = fmt.Errorf("objconv/msgpack: map of length %d is too long to be encoded", n)
+		err = objutil.Errorf(objutil.ErrLimit, "objconv/msgpack: map of length %d is too long to be encoded", n)
 		return
 	}
 
@@ -358,18 +426,6 @@ func (e *Emitter) EmitMapBegin(n int) (err error) {
 	return
 }
 
-func (e *Emitter) EmitMapEnd() (err error) {
-	return
-}
-
-func (e *Emitter) EmitMapValue() (err error) {
-	return
-}
-
-func (e *Emitter) EmitMapNext() (err error) {
-	return
-}
-
 func (e *Emitter) emitArray(n int) (err error) {
 	switch {
 	case n <= 15:
@@ -387,7 +443,7 @@ func (e *Emitter) emitArray(n int) (err error) {
 		n = 5
 
 	default:
-		err = fmt.Errorf("objconv/msgpack: array of length %d is too long to be encoded", n)
+		err = objutil.Errorf(objutil.ErrLimit, "objconv/msgpack: array of length %d is too long to be encoded", n)
 		return
 	}
 
@@ -1,8 +1,12 @@
 package msgpack
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"testing"
 
+	"github.com/segmentio/objconv"
 	"github.com/segmentio/objconv/objtests"
 )
 
@@ -13,3 +17,94 @@ func TestCodec(t *testing.T) {
 func BenchmarkCodec(b *testing.B) {
 	objtests.BenchmarkCodec(b, Codec)
 }
+
+func TestRawMessage(t *testing.T) {
+	objtests.TestRawMessage(t, Codec)
+}
+
+func TestStreamCloseWithError(t *testing.T) {
+	objtests.TestStreamCloseWithError(t, Codec)
+}
+
+func TestStreamDecodeAt(t *testing.T) {
+	objtests.TestStreamDecodeAt(t, Codec)
+}
+
+func TestParserStringLimit(t *testing.T) {
+	objtests.TestParserStringLimit(t, Codec)
+}
+
+func TestAppend(t *testing.T) {
+	objtests.TestAppend(t, Codec, Append)
+}
+
+func TestAppendAllocs(t *testing.T) {
+	objtests.TestAppendAllocs(t, Append)
+}
+
+func TestEncoderAllocs(t *testing.T) {
+	objtests.TestEncoderAllocs(t, Codec)
+}
+
+func TestTypedEncoderAllocs(t *testing.T) {
+	objtests.TestTypedEncoderAllocs(t, Codec)
+}
+
+func BenchmarkEncoderAllocs(b *testing.B) {
+	objtests.BenchmarkEncoderAllocs(b, Codec)
+}
+
+func TestConformance(t *testing.T) {
+	objtests.TestConformance(t, Codec)
+}
+
+func TestTruncatedString(t *testing.T) {
+	var v interface{}
+
+	// The declared length doesn't cause the parser to allocate 4 GB.
+	b := []byte{Str32, 0xff, 0xff, 0xff, 0xff, 'h', 'e', 'l', 'l', 'o'}
+
+	if err := Unmarshal(b, &v); err == nil {
+		t.Errorf("expected an error decoding a truncated string but got %#v", v)
+	}
+}
+
+func TestCopyBytes(t *testing.T) {
+	var v struct {
+		Name string
+		Data objconv.Blob
+	}
+
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+	b, err := Marshal(struct {
+		Name string
+		Data []byte
+	}{"A", data})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoder(bytes.NewReader(b))
+	d.Spill = objconv.SpillConfig{Threshold: 100, Dir: t.TempDir()}
+
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	defer v.Data.Close()
+
+	if !v.Data.Spilled() {
+		t.Error("the blob was not spilled to a temporary file")
+	}
+
+	if c, _ := io.ReadAll(&v.Data); !bytes.Equal(c, data) {
+		t.Errorf("bad blob content: %d bytes", len(c))
+	}
+
+	// The input is truncated in the middle of the byte sequence.
+	d = NewDecoder(bytes.NewReader(b[:len(b)-10]))
+	d.Spill = objconv.SpillConfig{Threshold: 100, Dir: t.TempDir()}
+
+	if err := d.Decode(&v); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF but got %v", err)
+	}
+}
@@ -2,12 +2,13 @@ package msgpack
 
 import (
 	"bytes"
-	"fmt"
+	gocontext "context"
 	"io"
 	"math"
 	"time"
 
 	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
 )
 
 type Parser struct {
@@ -16,6 +17,12 @@ type Parser struct {
 	j int       // offset + 1 of the last unread byte in b
 	s []byte    // string buffer
 	b [240]byte // read buffer
+
+	// offset in the input of the first byte in b
+	off int64
+
+	// maximum length of strings, zero means no limit
+	maxStringLen int
 }
 
 func NewParser(r io.Reader) *Parser {
@@ -26,12 +33,40 @@ func (p *Parser) Reset(r io.Reader) {
 	p.r = r
 	p.i = 0
 	p.j = 0
+	p.off = 0
+}
+
+// ParseContext satisfies the objconv.ParserV2 interface, the reads from the
+// underlying reader are aborted when ctx is canceled.
+func (p *Parser) ParseContext(ctx gocontext.Context) error {
+	p.r = objutil.WithContext(p.r, ctx)
+	return ctx.Err()
+}
+
+// ParseLengthHint satisfies the objconv.ParserV2 interface, the parser has no
+// hints.
+func (p *Parser) ParseLengthHint() int {
+	return -1
+}
+
+// Offset returns the number of bytes consumed by the parser.
+func (p *Parser) Offset() int64 {
+	return p.off + int64(p.i)
 }
 
+// Buffered satisfies the objconv.BufferedParser interface.
 func (p *Parser) Buffered() io.Reader {
 	return bytes.NewReader(p.b[p.i:p.j])
 }
 
+// ParseRaw satisfies the objconv.RawParser interface, the value is re-encoded
+// by a MessagePack emitter.
+func (p *Parser) ParseRaw() ([]byte, error) {
+	b := &bytes.Buffer{}
+	err := objconv.Transcode(NewEmitter(b), p)
+	return b.Bytes(), err
+}
+
 func (p *Parser) ParseType() (objconv.Type, error) {
 	b, err := p.peek(1)
 	if err != nil {
@@ -95,12 +130,12 @@ func (p *Parser) ParseType() (objconv.Type, error) {
 			return objconv.Time, nil
 
 		default:
-			return objconv.Unknown, fmt.Errorf("objconv/msgpack: unsupported extension '%d'", tag)
+			return objconv.Unknown, objutil.Errorf(objutil.ErrSyntax, "objconv/msgpack: unsupported extension '%d'", tag)
 		}
 
 	case Ext8, Ext16, Ext32: // continue after the switch
 	default:
-		return objconv.Unknown, fmt.Errorf("objconv/msgpack: unknown tag '%#x'", tag)
+		return objconv.Unknown, objutil.Errorf(objutil.ErrSyntax, "objconv/msgpack: unknown tag '%#x'", tag)
 	}
 
 	switch tag {
@@ -121,7 +156,7 @@ func (p *Parser) ParseType() (objconv.Type, error) {
 		return objconv.Time, nil
 	}
 
-	return objconv.Unknown, fmt.Errorf("objconv/msgpack: unknown extension '%d'", tag)
+	return objconv.Unknown, objutil.Errorf(objutil.ErrSyntax, "objconv/msgpack: unknown extension '%d'", tag)
 }
 
 func (p *Parser) ParseNil() (err error) {
@@ -246,54 +281,102 @@ func (p *Parser) ParseFloat() (v float64, err error) {
 }
 
 func (p *Parser) ParseString() (v []byte, err error) {
-	tag := p.b[p.i]
-	p.i++
+	var n int
 
-	n := 0
+	if n, err = p.parseLength(); err == nil {
+		if err = p.checkStringLen(n); err == nil {
+			v, err = p.read(n)
+		}
+	}
 
-	if (tag & FixstrMask) == FixstrTag {
-		n = int(tag & ^byte(FixstrMask))
-	} else {
-		var b []byte
+	return
+}
 
-		switch tag {
-		case Str8:
-			n = 1
-		case Str16:
-			n = 2
-		default:
-			n = 4
+func (p *Parser) ParseBytes() (v []byte, err error) {
+	var n int
+
+	if n, err = p.parseLength(); err == nil {
+		if err = p.checkStringLen(n); err == nil {
+			v, err = p.read(n)
 		}
+	}
 
-		if b, err = p.peek(n); err != nil {
+	return
+}
+
+// SetMaxStringLen satisfies the objconv.LimitParser interface, the lengths of
+// strings are verified before they are loaded.
+func (p *Parser) SetMaxStringLen(n int) {
+	p.maxStringLen = n
+}
+
+func (p *Parser) checkStringLen(n int) error {
+	if p.maxStringLen > 0 && n > p.maxStringLen {
+		return objutil.Errorf(objutil.ErrLimit, "objconv/msgpack: the input has a string of %d bytes, which exceeds the limit of %d bytes", n, p.maxStringLen)
+	}
+	return nil
+}
+
+// CopyBytes satisfies the objconv.CopyParser interface, the content of the
+// string or byte sequence is copied to w as it is read from the input.
+func (p *Parser) CopyBytes(w io.Writer) (n int64, err error) {
+	var m int
+
+	if m, err = p.parseLength(); err != nil {
+		return
+	}
+
+	// Bytes which were already loaded in the read buffer are written first,
+	// the rest is copied from the underlying reader.
+	k := p.j - p.i
+	if k > m {
+		k = m
+	}
+
+	if k != 0 {
+		if _, err = w.Write(p.b[p.i : p.i+k]); err != nil {
 			return
 		}
-		p.i += n
+		p.i += k
+		n += int64(k)
+	}
 
-		switch n {
-		case 1:
-			n = int(b[0])
-		case 2:
-			n = int(getUint16(b))
-		default:
-			n = int(getUint32(b))
+	if n != int64(m) {
+		var c int64
+
+		p.off += int64(p.j)
+		p.i = 0
+		p.j = 0
+
+		c, err = io.CopyN(w, p.r, int64(m)-n)
+		p.off += c
+		n += c
+
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
 		}
 	}
 
-	return p.read(n)
+	return
 }
 
-func (p *Parser) ParseBytes() (v []byte, err error) {
+// parseLength consumes the tag and length of a string or byte sequence, and
+// returns the length.
+func (p *Parser) parseLength() (n int, err error) {
 	tag := p.b[p.i]
 	p.i++
 
+	if (tag & FixstrMask) == FixstrTag {
+		n = int(tag & ^byte(FixstrMask))
+		return
+	}
+
 	var b []byte
-	var n int
 
 	switch tag {
-	case Bin8:
+	case Str8, Bin8:
 		n = 1
-	case Bin16:
+	case Str16, Bin16:
 		n = 2
 	default:
 		n = 4
@@ -313,7 +396,7 @@ func (p *Parser) ParseBytes() (v []byte, err error) {
 		n = int(getUint32(b))
 	}
 
-	return p.read(n)
+	return
 }
 
 func (p *Parser) ParseTime() (v time.Time, err error) {
@@ -347,7 +430,7 @@ func (p *Parser) ParseTime() (v time.Time, err error) {
 			return
 		}
 		if b[0] != 12 {
-			err = fmt.Errorf("objconv/msgpack: invalid timestamp length, expected 12 but found %d", int(b[0]))
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/msgpack: invalid timestamp length, expected 12 but found %d", int(b[0]))
 			return
 		}
 		p.i += 2 // skip the extension length and type
@@ -358,7 +441,7 @@ func (p *Parser) ParseTime() (v time.Time, err error) {
 		s, ns = int64(getUint64(b[4:])), int64(getUint32(b))
 
 	default:
-		err = fmt.Errorf("objconv/msgpack: invalid extension tag found while decoding a timestamp '%d'", tag)
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/msgpack: invalid extension tag found while decoding a timestamp '%d'", tag)
 		return
 	}
 
@@ -475,18 +558,17 @@ func (p *Parser) read(n int) (b []byte, err error) {
 		return
 	}
 
-	if cap(p.s) < n {
-		p.s = make([]byte, n, align(n, 1024))
-	} else {
-		p.s = p.s[:n]
-	}
-
-	copy(p.s, p.b[p.i:p.j])
-	n = p.j - p.i
+	// The length comes from the input, the buffer is grown as the bytes are
+	// read instead of being allocated upfront.
+	p.s = append(p.s[:0], p.b[p.i:p.j]...)
+	m := len(p.s)
+	p.off += int64(p.j)
 	p.i = 0
 	p.j = 0
 
-	if _, err = io.ReadFull(p.r, p.s[n:]); err != nil {
+	p.s, err = objutil.ReadFull(p.s, p.r, n-m)
+	p.off += int64(len(p.s) - m)
+	if err != nil {
 		return
 	}
 
@@ -507,6 +589,7 @@ func (p *Parser) peek(n int) (b []byte, err error) {
 func (p *Parser) fill() (err error) {
 	n := p.j - p.i
 	copy(p.b[:], p.b[p.i:p.j])
+	p.off += int64(p.i)
 	p.i = 0
 	p.j = n
 
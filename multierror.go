@@ -0,0 +1,173 @@
+package objconv
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// FieldError is an error associated with a field of a value, it carries the
+// path to the field and a machine-readable code which let programs report
+// validation errors to their clients.
+//
+// Decoders return field errors when they fail to decode the fields of structs,
+// the elements of arrays or the values of maps, the path leads to the value
+// from the top-level value, like "items[3].metadata.created_at". The errors
+// also carry the location of the parser in its input when the error occurred,
+// if the parser implements OffsetParser or PositionParser.
+type FieldError struct {
+	Path string // dotted path to the field, like "address.zip"
+	Code string // machine-readable code identifying the error, like "required"
+	Err  error  // the underlying error
+
+	Offset int64    // offset of the parser in its input, zero if unknown
+	Pos    Position // line and column of the parser, zero if unknown
+}
+
+// Error satisfies the error interface.
+func (e *FieldError) Error() string {
+	msg := "<nil>"
+
+	if e.Err != nil {
+		msg = e.Err.Error()
+	}
+
+	if len(e.Path) == 0 {
+		return msg
+	}
+
+	switch {
+	case e.Pos.Line != 0:
+		return e.Path + " (line " + strconv.Itoa(e.Pos.Line) + ", column " + strconv.Itoa(e.Pos.Column) + "): " + msg
+	case e.Offset != 0:
+		return e.Path + " (offset " + strconv.FormatInt(e.Offset, 10) + "): " + msg
+	default:
+		return e.Path + ": " + msg
+	}
+}
+
+// Unwrap returns the underlying error of e.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// EncodeValue satisfies the ValueEncoder interface, field errors are encoded
+// as maps with "path", "code" and "message" keys, empty values are omitted.
+func (e *FieldError) EncodeValue(enc Encoder) error {
+	r := fieldErrorRecord{Path: e.Path, Code: e.Code}
+
+	if e.Err != nil {
+		r.Message = e.Err.Error()
+	}
+
+	return enc.Encode(&r)
+}
+
+// DecodeValue satisfies the ValueDecoder interface.
+func (e *FieldError) DecodeValue(dec Decoder) error {
+	var r fieldErrorRecord
+
+	if err := dec.Decode(&r); err != nil {
+		return err
+	}
+
+	*e = FieldError{Path: r.Path, Code: r.Code, Err: errors.New(r.Message)}
+	return nil
+}
+
+type fieldErrorRecord struct {
+	Path    string `objconv:"path,omitempty"`
+	Code    string `objconv:"code,omitempty"`
+	Message string `objconv:"message"`
+}
+
+// MultiError is an error aggregating multiple errors, for example all the
+// validation errors found in a decoded value.
+//
+// MultiError implements ValueEncoder, the errors are encoded as an array of
+// maps (see FieldError.EncodeValue) so they can be written directly to API
+// responses in the format negotiated with the client. Errors that aren't field
+// errors are encoded with only a "message" key.
+type MultiError []error
+
+// Error satisfies the error interface.
+func (m MultiError) Error() string {
+	switch len(m) {
+	case 0:
+		return "objconv: no errors"
+	case 1:
+		return m[0].Error()
+	}
+
+	s := make([]string, len(m))
+
+	for i, err := range m {
+		s[i] = err.Error()
+	}
+
+	return strings.Join(s, "; ")
+}
+
+// Unwrap returns the list of errors in m, it is used by errors.Is and errors.As
+// to inspect the aggregated errors.
+func (m MultiError) Unwrap() []error {
+	return m
+}
+
+// Err returns m if it contains at least one error, nil otherwise.
+//
+// Programs building a MultiError should return the result of this method,
+// a nil error is different from an empty MultiError wrapped in an error
+// interface.
+func (m MultiError) Err() error {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+// Append adds err to m, nil errors are ignored and the errors of MultiError
+// values are flattened.
+func (m *MultiError) Append(err error) {
+	switch e := err.(type) {
+	case nil:
+	case MultiError:
+		*m = append(*m, e...)
+	default:
+		*m = append(*m, err)
+	}
+}
+
+// EncodeValue satisfies the ValueEncoder interface.
+func (m MultiError) EncodeValue(e Encoder) error {
+	i := 0
+
+	return e.EncodeArray(len(m), func(e Encoder) error {
+		err := m[i]
+		i++
+
+		fe, ok := err.(*FieldError)
+		if !ok {
+			fe = &FieldError{Err: err}
+		}
+
+		return fe.EncodeValue(e)
+	})
+}
+
+// DecodeValue satisfies the ValueDecoder interface, the decoded errors are all
+// of type *FieldError.
+func (m *MultiError) DecodeValue(d Decoder) error {
+	*m = (*m)[:0]
+
+	return d.DecodeArray(func(d Decoder) error {
+		fe := &FieldError{}
+
+		if err := fe.DecodeValue(d); err != nil {
+			return err
+		}
+
+		*m = append(*m, fe)
+		return nil
+	})
+}
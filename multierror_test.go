@@ -0,0 +1,55 @@
+package objconv
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestMultiError(t *testing.T) {
+	var m MultiError
+
+	if m.Err() != nil {
+		t.Error("an empty multi-error must convert to a nil error")
+	}
+
+	m.Append(nil)
+	m.Append(&FieldError{Path: "address.zip", Code: "invalid", Err: errors.New("bad zip code")})
+	m.Append(MultiError{io.ErrUnexpectedEOF})
+
+	if len(m) != 2 {
+		t.Fatal("bad number of errors:", len(m))
+	}
+
+	if s := m.Error(); s != "address.zip: bad zip code; unexpected EOF" {
+		t.Error("bad error message:", s)
+	}
+
+	if !errors.Is(m.Err(), io.ErrUnexpectedEOF) {
+		t.Error("errors.Is did not find the aggregated error")
+	}
+
+	e := NewValueEmitter()
+
+	if err := NewEncoder(e).Encode(m); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := e.Value(); !reflect.DeepEqual(v, []interface{}{
+		map[interface{}]interface{}{"path": "address.zip", "code": "invalid", "message": "bad zip code"},
+		map[interface{}]interface{}{"message": "unexpected EOF"},
+	}) {
+		t.Errorf("bad encoded value: %#v", v)
+	}
+
+	var d MultiError
+
+	if err := NewDecoder(NewValueParser(e.Value())).Decode(&d); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Error() != m.Error() || d[0].(*FieldError).Code != "invalid" {
+		t.Errorf("bad decoded value: %#v", d)
+	}
+}
@@ -0,0 +1,149 @@
+package objconv
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// RegisterNamedType associates name with typ, a named type based on a basic
+// type like `type UserID string`.
+//
+// Values of registered types are encoded with their type name by encoders
+// that have the PreserveTypes option, and decoded back to their type by
+// decoders with the same option when the destination is an empty interface,
+// which keeps type information through dynamic pipelines where values travel
+// as interface{}.
+//
+// The function panics if typ is not a named type based on a boolean, number,
+// or string type, or if name is already registered for another type. Like
+// Install, it is intended to be called during the package initialization
+// phase.
+func RegisterNamedType(name string, typ reflect.Type) {
+	if len(typ.Name()) == 0 || len(typ.PkgPath()) == 0 || namedBaseType(typ) == nil {
+		panic(fmt.Sprintf("objconv: cannot register %s because it is not a named type based on a basic type", typ))
+	}
+
+	namedMutex.Lock()
+	defer namedMutex.Unlock()
+
+	if t, ok := namedTypes[name]; ok && t != typ {
+		panic(fmt.Sprintf("objconv: cannot register %s as %q because the name is already used by %s", typ, name, t))
+	}
+
+	namedTypes[name] = typ
+	namedNames[typ] = name
+
+	// Same as Install, the struct cache may have become invalid.
+	clearStructCaches()
+}
+
+func namedTypeName(typ reflect.Type) (name string, ok bool) {
+	namedMutex.RLock()
+	name, ok = namedNames[typ]
+	namedMutex.RUnlock()
+	return
+}
+
+func namedTypeOf(name string) (typ reflect.Type, ok bool) {
+	namedMutex.RLock()
+	typ, ok = namedTypes[name]
+	namedMutex.RUnlock()
+	return
+}
+
+// namedBaseType returns the basic type that values of typ are decoded as when
+// the destination is an empty interface, or nil if typ is not based on a basic
+// type.
+func namedBaseType(typ reflect.Type) reflect.Type {
+	switch typ.Kind() {
+	case reflect.Bool:
+		return boolType
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int64Type
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return uint64Type
+	case reflect.Float32, reflect.Float64:
+		return float64Type
+	case reflect.String:
+		return stringType
+	}
+	return nil
+}
+
+var (
+	namedMutex sync.RWMutex
+	namedTypes = make(map[string]reflect.Type)
+	namedNames = make(map[reflect.Type]string)
+)
+
+func makeEncodeNamedFunc(name string, encode encodeFunc) encodeFunc {
+	return func(e Encoder, v reflect.Value) error {
+		if !e.PreserveTypes {
+			return encode(e, v)
+		}
+		i := 0
+		return e.EncodeMap(2, func(ke Encoder, ve Encoder) (err error) {
+			if i++; i == 1 {
+				if err = e.Emitter.EmitString("@type"); err == nil {
+					if err = e.Emitter.EmitMapValue(); err == nil {
+						err = e.Emitter.EmitString(name)
+					}
+				}
+				return
+			}
+			if err = e.Emitter.EmitString("@value"); err == nil {
+				if err = e.Emitter.EmitMapValue(); err == nil {
+					err = encode(e, v)
+				}
+			}
+			return
+		})
+	}
+}
+
+// restoreNamedType replaces the map held by the empty interface v with the
+// value of a registered named type when the map was produced by an encoder
+// with the PreserveTypes option.
+func restoreNamedType(v reflect.Value) error {
+	m := v.Elem()
+
+	if m.Kind() != reflect.Map || m.Len() != 2 || m.Type().Key().Kind() != reflect.String && m.Type().Key() != emptyInterface {
+		return nil
+	}
+
+	key := func(k string) reflect.Value {
+		x := m.MapIndex(reflect.ValueOf(k).Convert(m.Type().Key()))
+		if x.IsValid() && x.Kind() == reflect.Interface {
+			x = x.Elem()
+		}
+		return x
+	}
+
+	name, value := key("@type"), key("@value")
+
+	if !name.IsValid() || !value.IsValid() || name.Kind() != reflect.String {
+		return nil
+	}
+
+	typ, ok := namedTypeOf(name.String())
+
+	if !ok {
+		return nil
+	}
+
+	if !value.Type().ConvertibleTo(typ) || namedBaseType(value.Type()) == nil {
+		return objutil.Errorf(objutil.ErrType, "objconv: cannot decode a value of type %s as %s", value.Type(), typ)
+	}
+
+	x := value.Convert(typ)
+
+	if !reflect.DeepEqual(x.Convert(value.Type()).Interface(), value.Interface()) {
+		return objutil.Errorf(objutil.ErrRange, "objconv: value out of range of %s: %v", typ, value)
+	}
+
+	v.Set(x)
+	return nil
+}
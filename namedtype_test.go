@@ -0,0 +1,73 @@
+package objconv
+
+import (
+	"reflect"
+	"testing"
+)
+
+type namedUserID string
+
+type namedCount int16
+
+func init() {
+	RegisterNamedType("UserID", reflect.TypeOf(namedUserID("")))
+	RegisterNamedType("Count", reflect.TypeOf(namedCount(0)))
+}
+
+func TestPreserveTypes(t *testing.T) {
+	in := map[string]interface{}{
+		"user":  namedUserID("u-42"),
+		"count": namedCount(3),
+		"list":  []interface{}{namedUserID("u-1"), "plain"},
+	}
+
+	e := NewValueEmitter()
+
+	if err := (Encoder{Emitter: e, PreserveTypes: true}).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+
+	var out interface{}
+
+	if err := (&Decoder{Parser: NewValueParser(e.Value()), PreserveTypes: true, MapType: reflect.TypeOf(map[string]interface{}(nil))}).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("%#v != %#v", out, in)
+	}
+
+	e = NewValueEmitter()
+
+	if err := (Encoder{Emitter: e}).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := e.Value().(map[interface{}]interface{})["user"]; v != "u-42" {
+		t.Errorf("the type of a named value was encoded without the PreserveTypes option: %#v", v)
+	}
+
+	var x interface{}
+	overflow := map[string]interface{}{"@type": "Count", "@value": 1 << 20}
+
+	if err := (&Decoder{Parser: NewValueParser(overflow), PreserveTypes: true}).Decode(&x); err == nil {
+		t.Errorf("no error returned when decoding an out of range value: %#v", x)
+	}
+}
+
+func TestRegisterNamedTypePanics(t *testing.T) {
+	for _, typ := range []reflect.Type{
+		reflect.TypeOf(""),
+		reflect.TypeOf(struct{}{}),
+		reflect.TypeOf(namedCount(0)),
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("no panic raised when registering %s", typ)
+				}
+			}()
+			RegisterNamedType("UserID", typ)
+		}()
+	}
+}
@@ -0,0 +1,144 @@
+package objconv
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FieldNaming is the type of the conventions that encoders and decoders apply
+// to the names of struct fields which have no name in their tag.
+//
+// The names are split into words at the changes of case, acronyms are kept as
+// single words, so the name "UserID" has the words "User" and "ID", and the
+// name "HTTPServer" has the words "HTTP" and "Server".
+type FieldNaming int
+
+const (
+	// GoNaming uses the names of Go fields unchanged, it is the default.
+	GoNaming FieldNaming = iota
+
+	// SnakeCase joins lowercase words with underscores ("user_id").
+	SnakeCase
+
+	// CamelCase joins capitalized words, except the first one which is
+	// lowercase ("userId").
+	CamelCase
+
+	// KebabCase joins lowercase words with dashes ("user-id").
+	KebabCase
+
+	// PascalCase joins capitalized words ("UserId").
+	PascalCase
+)
+
+// String returns a human-readable representation of the naming convention.
+func (n FieldNaming) String() string {
+	switch n {
+	case GoNaming:
+		return "GoNaming"
+	case SnakeCase:
+		return "SnakeCase"
+	case CamelCase:
+		return "CamelCase"
+	case KebabCase:
+		return "KebabCase"
+	case PascalCase:
+		return "PascalCase"
+	default:
+		return "<field naming>"
+	}
+}
+
+// Apply returns the name of a field called name in the naming convention.
+func (n FieldNaming) Apply(name string) string {
+	switch n {
+	case SnakeCase:
+		return joinWords(name, '_', false, false)
+	case CamelCase:
+		return joinWords(name, 0, false, true)
+	case KebabCase:
+		return joinWords(name, '-', false, false)
+	case PascalCase:
+		return joinWords(name, 0, true, true)
+	default:
+		return name
+	}
+}
+
+// joinWords joins the words of name with sep, or without separator if it is
+// zero. The first letter of the first word is uppercase if upperFirst is set,
+// the first letters of the other words are uppercase if upperNext is set, all
+// other letters are lowercase.
+func joinWords(name string, sep byte, upperFirst bool, upperNext bool) string {
+	var b strings.Builder
+	b.Grow(len(name) + 4)
+
+	for i, w := range splitWords(name) {
+		upper := upperFirst
+
+		if i != 0 {
+			if sep != 0 {
+				b.WriteByte(sep)
+			}
+			upper = upperNext
+		}
+
+		for j, r := range w {
+			if j == 0 && upper {
+				b.WriteRune(unicode.ToUpper(r))
+			} else {
+				b.WriteRune(unicode.ToLower(r))
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// splitWords splits name into words at the changes of case and on underscores,
+// digits are part of the word which precedes them.
+func splitWords(name string) []string {
+	words := make([]string, 0, 4)
+	start := -1
+
+	for i, r := range name {
+		if r == '_' {
+			if start >= 0 {
+				words = append(words, name[start:i])
+			}
+			start = -1
+			continue
+		}
+
+		if start < 0 {
+			start = i
+			continue
+		}
+
+		if !unicode.IsUpper(r) {
+			// The last letter of an acronym which is followed by a lowercase
+			// letter starts the next word, as the "S" in "HTTPServer".
+			if unicode.IsLower(r) {
+				if p, n := utf8.DecodeLastRuneInString(name[:i]); i-n > start && unicode.IsUpper(p) {
+					if q, _ := utf8.DecodeLastRuneInString(name[:i-n]); unicode.IsUpper(q) {
+						words = append(words, name[start:i-n])
+						start = i - n
+					}
+				}
+			}
+			continue
+		}
+
+		if p, _ := utf8.DecodeLastRuneInString(name[:i]); !unicode.IsUpper(p) {
+			words = append(words, name[start:i])
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		words = append(words, name[start:])
+	}
+
+	return words
+}
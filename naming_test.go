@@ -0,0 +1,92 @@
+package objconv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldNaming(t *testing.T) {
+	tests := []struct {
+		name   string
+		snake  string
+		camel  string
+		kebab  string
+		pascal string
+	}{
+		{"A", "a", "a", "a", "A"},
+		{"Name", "name", "name", "name", "Name"},
+		{"UserID", "user_id", "userId", "user-id", "UserId"},
+		{"HTTPServer", "http_server", "httpServer", "http-server", "HttpServer"},
+		{"CreatedAt", "created_at", "createdAt", "created-at", "CreatedAt"},
+		{"Field2Name", "field2_name", "field2Name", "field2-name", "Field2Name"},
+		{"already_snake", "already_snake", "alreadySnake", "already-snake", "AlreadySnake"},
+		{"hiddenField", "hidden_field", "hiddenField", "hidden-field", "HiddenField"},
+		{"ÉtéValue", "été_value", "étéValue", "été-value", "ÉtéValue"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for naming, expect := range map[FieldNaming]string{
+				GoNaming:   test.name,
+				SnakeCase:  test.snake,
+				CamelCase:  test.camel,
+				KebabCase:  test.kebab,
+				PascalCase: test.pascal,
+			} {
+				if name := naming.Apply(test.name); name != expect {
+					t.Errorf("%s: %q != %q", naming, name, expect)
+				}
+			}
+		})
+	}
+}
+
+func TestFieldNamingCodec(t *testing.T) {
+	type Model struct {
+		UserID    int
+		FirstName string
+		Tagged    string `objconv:"TAGGED"`
+		Options   string `objconv:",omitempty"`
+	}
+
+	e := NewValueEmitter()
+	enc := Encoder{Emitter: e, SortMapKeys: true, FieldNaming: SnakeCase}
+
+	if err := enc.Encode(Model{UserID: 1, FirstName: "Luke", Tagged: "A", Options: "B"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[interface{}]interface{}{
+		"user_id":    int64(1),
+		"first_name": "Luke",
+		"TAGGED":     "A",
+		"options":    "B",
+	}
+
+	if v := e.Value(); !reflect.DeepEqual(v, expect) {
+		t.Errorf("bad encoded value: %#v", v)
+	}
+
+	var m Model
+	dec := Decoder{Parser: NewValueParser(expect), FieldNaming: SnakeCase}
+
+	if err := dec.Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+
+	if m != (Model{UserID: 1, FirstName: "Luke", Tagged: "A", Options: "B"}) {
+		t.Errorf("bad decoded value: %#v", m)
+	}
+
+	// The default naming of the fields isn't affected by the cache of struct
+	// types used by the encoder.
+	e = NewValueEmitter()
+
+	if err := NewEncoder(e).Encode(Model{UserID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := e.Value().(map[interface{}]interface{}); v["UserID"] != int64(1) {
+		t.Errorf("bad encoded value: %#v", v)
+	}
+}
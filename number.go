@@ -0,0 +1,174 @@
+package objconv
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Number is a number kept in its textual form.
+//
+// Decoders with the UseNumber option produce numbers instead of int64, uint64
+// or float64 values when the destination is an empty interface, which lets
+// programs choose how to interpret them. Parsers of formats representing
+// numbers as text, like JSON, give decoders the exact representation of the
+// numbers, so large integers and decimal numbers don't lose precision.
+type Number string
+
+var numberType = reflect.TypeOf(Number(""))
+
+// String returns n as a string.
+func (n Number) String() string {
+	return string(n)
+}
+
+// Int64 returns n as a signed integer.
+//
+// Numbers written with a fractional part or an exponent are accepted as long as
+// they represent integers, like "1e3".
+func (n Number) Int64() (int64, error) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return i, nil
+	}
+
+	b, err := n.BigInt()
+	if err != nil {
+		return 0, err
+	}
+
+	if !b.IsInt64() {
+		return 0, objutil.Errorf(objutil.ErrRange, "objconv: %s cannot be represented by a signed 64 bits integer", n)
+	}
+
+	return b.Int64(), nil
+}
+
+// Uint64 returns n as an unsigned integer, see Int64 for the accepted forms.
+func (n Number) Uint64() (uint64, error) {
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return u, nil
+	}
+
+	b, err := n.BigInt()
+	if err != nil {
+		return 0, err
+	}
+
+	if !b.IsUint64() {
+		return 0, objutil.Errorf(objutil.ErrRange, "objconv: %s cannot be represented by an unsigned 64 bits integer", n)
+	}
+
+	return b.Uint64(), nil
+}
+
+// Float64 returns n as a floating point number, which may be an approximation
+// of n.
+func (n Number) Float64() (float64, error) {
+	f, err := strconv.ParseFloat(string(n), 64)
+
+	switch {
+	case err == nil:
+		return f, nil
+	case errors.Is(err, strconv.ErrRange):
+		return 0, objutil.Errorf(objutil.ErrRange, "objconv: %s cannot be represented by a 64 bits floating point number", n)
+	default:
+		return 0, objutil.Errorf(objutil.ErrSyntax, "objconv: invalid number: %q", string(n))
+	}
+}
+
+// BigInt returns n as an arbitrary precision integer, it returns an error if n
+// has a fractional part.
+func (n Number) BigInt() (*big.Int, error) {
+	if b, ok := new(big.Int).SetString(string(n), 10); ok {
+		return b, nil
+	}
+
+	r, ok := new(big.Rat).SetString(string(n))
+	if !ok {
+		return nil, objutil.Errorf(objutil.ErrSyntax, "objconv: invalid number: %q", string(n))
+	}
+
+	if !r.IsInt() {
+		return nil, objutil.Errorf(objutil.ErrRange, "objconv: %s cannot be represented by an integer", n)
+	}
+
+	return r.Num(), nil
+}
+
+// EncodeValue satisfies the ValueEncoder interface, numbers are encoded as
+// integers when they are representable by 64 bits integers, and as floating
+// point numbers otherwise.
+func (n Number) EncodeValue(e Encoder) error {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return e.Encode(i)
+	}
+
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return e.Encode(u)
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return err
+	}
+
+	return e.Encode(f)
+}
+
+// DecodeValue satisfies the ValueDecoder interface, numbers can only be
+// decoded from integers and floating point numbers.
+func (n *Number) DecodeValue(d Decoder) error {
+	var v interface{}
+
+	d.UseNumber = true
+
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+
+	switch x := v.(type) {
+	case nil:
+		*n = ""
+	case Number:
+		*n = x
+	default:
+		return objutil.Errorf(objutil.ErrType, "objconv: cannot decode a number from a value of type %T", v)
+	}
+
+	return nil
+}
+
+func (d Decoder) decodeNumberFromType(t Type, to reflect.Value) (err error) {
+	var b []byte
+
+	if p, ok := d.Parser.(NumberParser); ok {
+		b, err = p.ParseNumber()
+	} else {
+		switch t {
+		case Int:
+			var i int64
+			if i, err = d.Parser.ParseInt(); err == nil {
+				b = strconv.AppendInt(nil, i, 10)
+			}
+		case Uint:
+			var u uint64
+			if u, err = d.Parser.ParseUint(); err == nil {
+				b = strconv.AppendUint(nil, u, 10)
+			}
+		default:
+			var f float64
+			if f, err = d.readFloat(); err == nil {
+				b = strconv.AppendFloat(nil, f, 'g', -1, 64)
+			}
+		}
+	}
+
+	if err == nil && to.IsValid() {
+		to.SetString(string(b))
+	}
+
+	return
+}
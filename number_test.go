@@ -0,0 +1,99 @@
+package objconv
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNumber(t *testing.T) {
+	n := Number("18446744073709551615")
+
+	if _, err := n.Int64(); !errors.Is(err, ErrRange) {
+		t.Errorf("bad error: %v", err)
+	}
+
+	if u, err := n.Uint64(); err != nil || u != 18446744073709551615 {
+		t.Errorf("bad value: %d (%v)", u, err)
+	}
+
+	if b, err := Number("1e21").BigInt(); err != nil || b.String() != "1000000000000000000000" {
+		t.Errorf("bad value: %v (%v)", b, err)
+	}
+
+	if i, err := Number("-1.5e2").Int64(); err != nil || i != -150 {
+		t.Errorf("bad value: %d (%v)", i, err)
+	}
+
+	if _, err := Number("0.5").BigInt(); !errors.Is(err, ErrRange) {
+		t.Errorf("bad error: %v", err)
+	}
+
+	if f, err := Number("0.1").Float64(); err != nil || f != 0.1 {
+		t.Errorf("bad value: %g (%v)", f, err)
+	}
+
+	if _, err := Number("1x").Float64(); !errors.Is(err, ErrSyntax) {
+		t.Errorf("bad error: %v", err)
+	}
+}
+
+func TestDecoderUseNumber(t *testing.T) {
+	type point struct {
+		X Number
+		Y Number
+	}
+
+	in := map[string]interface{}{
+		"a": []interface{}{1, uint64(2), 0.5},
+		"P": map[string]interface{}{"X": 1, "Y": 2.5},
+	}
+
+	var v map[string]interface{}
+	var p struct{ P point }
+
+	if err := (Decoder{Parser: NewValueParser(in), UseNumber: true}).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(v["a"], []interface{}{Number("1"), Number("2"), Number("0.5")}) {
+		t.Errorf("bad value: %#v", v["a"])
+	}
+
+	// Number fields are decoded without the UseNumber option.
+	if err := NewDecoder(NewValueParser(in)).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.P != (point{X: "1", Y: "2.5"}) {
+		t.Errorf("bad value: %#v", p.P)
+	}
+
+	if err := NewDecoder(NewValueParser(map[string]interface{}{"P": map[string]interface{}{"X": "1"}})).Decode(&p); !errors.Is(err, ErrType) {
+		t.Errorf("bad error: %v", err)
+	}
+}
+
+func TestNumberEncoding(t *testing.T) {
+	tests := []struct {
+		in  Number
+		out interface{}
+	}{
+		{"-42", int64(-42)},
+		{"18446744073709551615", uint64(18446744073709551615)},
+		{"0.25", 0.25},
+	}
+
+	for _, test := range tests {
+		e := NewValueEmitter()
+
+		if err := (Encoder{Emitter: e}).Encode(test.in); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if v := e.Value(); v != test.out {
+			t.Errorf("%s: bad value: %#v", test.in, v)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package objtests
+
+// raceEnabled is true when the tests are compiled with the race detector, whose
+// instrumentation makes allocations that the allocation tests would report.
+const raceEnabled = true
@@ -0,0 +1,98 @@
+package objtests
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/segmentio/objconv"
+)
+
+// AllocValues is an array of values that codecs are expected to encode without
+// making any dynamic memory allocations.
+var AllocValues = [...]interface{}{
+	nil,
+	true,
+	123456789,
+	int64(-123456789),
+	uint64(123456789),
+	0.5,
+	"Hello World!",
+	[]byte("Hello World!"),
+	time.Date(2016, 12, 20, 0, 20, 1, 0, time.UTC),
+	42 * time.Second,
+	[]int{1, 2, 3},
+	[]string{"A", "B", "C"},
+	map[string]string{"A": "1", "B": "2", "C": "3"},
+	flatStruct{A: 42, B: "Hello World!", C: true, D: 0.5},
+	&flatStruct{A: 42, B: "Hello World!", C: true, D: 0.5},
+}
+
+type flatStruct struct {
+	A int
+	B string
+	C bool
+	D float64
+}
+
+// TestEncoderAllocs verifies that encoding the values of AllocValues with the
+// codec doesn't make any dynamic memory allocation.
+func TestEncoderAllocs(t *testing.T, codec objconv.Codec) {
+	if raceEnabled {
+		t.Skip("allocations are not measured with the race detector")
+	}
+
+	e := codec.NewEncoder(ioutil.Discard)
+
+	for _, v := range AllocValues {
+		t.Run(testName(v), func(t *testing.T) {
+			if n := testing.AllocsPerRun(100, func() { e.Encode(v) }); n != 0 {
+				t.Errorf("%v allocations made when encoding %#v", n, v)
+			}
+		})
+	}
+}
+
+// TestTypedEncoderAllocs verifies that the typed encoding methods don't make
+// any dynamic memory allocation with the codec.
+func TestTypedEncoderAllocs(t *testing.T, codec objconv.Codec) {
+	if raceEnabled {
+		t.Skip("allocations are not measured with the race detector")
+	}
+
+	e := codec.NewEncoder(ioutil.Discard)
+	d := time.Date(2016, 12, 20, 0, 20, 1, 0, time.UTC)
+	b := []byte("Hello World!")
+
+	n := testing.AllocsPerRun(100, func() {
+		e.EncodeNil()
+		e.EncodeBool(true)
+		e.EncodeInt(123456789)
+		e.EncodeInt64(-123456789)
+		e.EncodeUint64(123456789)
+		e.EncodeFloat64(0.5)
+		e.EncodeString("Hello World!")
+		e.EncodeBytes(b)
+		e.EncodeTime(d)
+		e.EncodeDuration(42 * time.Second)
+	})
+
+	if n != 0 {
+		t.Errorf("%v allocations made by typed encoding methods", n)
+	}
+}
+
+// BenchmarkEncoderAllocs reports the allocations made when encoding the values
+// of AllocValues with the codec.
+func BenchmarkEncoderAllocs(b *testing.B, codec objconv.Codec) {
+	for _, v := range AllocValues {
+		b.Run(testName(v), func(b *testing.B) {
+			e := codec.NewEncoder(ioutil.Discard)
+			b.ReportAllocs()
+
+			for i := 0; i != b.N; i++ {
+				e.Encode(v)
+			}
+		})
+	}
+}
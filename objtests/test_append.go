@@ -0,0 +1,77 @@
+package objtests
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/objconv"
+)
+
+// AppendFunc is the signature of the Append functions of codec packages.
+type AppendFunc func(dst []byte, v interface{}) ([]byte, error)
+
+// TestAppend verifies that the Append function of a codec appends the same
+// representations of the values of AllocValues as its encoders write, and
+// preserves the content of the destination slice. The representations are
+// compared after being decoded since the order of map keys is random.
+func TestAppend(t *testing.T, codec objconv.Codec, append AppendFunc) {
+	prefix := []byte("prefix")
+
+	for _, v := range AllocValues {
+		t.Run(testName(v), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			if err := codec.NewEncoder(buf).Encode(v); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := append(prefix[:len(prefix):len(prefix)], v)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.HasPrefix(b, prefix) || !sameValue(codec, b[len(prefix):], buf.Bytes()) {
+				t.Errorf("bad output: %q != %q", b, buf.Bytes())
+			}
+
+			if c, err := codec.Append(prefix[:len(prefix):len(prefix)], v); err != nil {
+				t.Error(err)
+			} else if !bytes.HasPrefix(c, prefix) || !sameValue(codec, c[len(prefix):], buf.Bytes()) {
+				t.Errorf("bad output of the generic implementation: %q != %q", c, buf.Bytes())
+			}
+		})
+	}
+}
+
+// TestAppendAllocs verifies that appending the values of AllocValues to a
+// slice with enough capacity doesn't make any dynamic memory allocation.
+func TestAppendAllocs(t *testing.T, append AppendFunc) {
+	if raceEnabled {
+		t.Skip("allocations are not measured with the race detector")
+	}
+
+	buf := make([]byte, 0, 4096)
+
+	for _, v := range AllocValues {
+		t.Run(testName(v), func(t *testing.T) {
+			if n := testing.AllocsPerRun(100, func() { append(buf, v) }); n != 0 {
+				t.Errorf("%v allocations made when appending %#v", n, v)
+			}
+		})
+	}
+}
+
+func sameValue(codec objconv.Codec, a []byte, b []byte) bool {
+	var va, vb interface{}
+
+	if err := codec.NewDecoder(bytes.NewReader(a)).Decode(&va); err != nil {
+		return false
+	}
+
+	if err := codec.NewDecoder(bytes.NewReader(b)).Decode(&vb); err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(va, vb)
+}
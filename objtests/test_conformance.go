@@ -0,0 +1,112 @@
+package objtests
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/segmentio/objconv"
+)
+
+type conformanceStruct struct {
+	A interface{} `objconv:"a"`
+}
+
+// TestConformance verifies that decoders of the codec apply the checks of the
+// conformance levels, and accept the same inputs when the checks are disabled.
+func TestConformance(t *testing.T, codec objconv.Codec) {
+	t.Run("trailing data", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		e := codec.NewEncoder(b)
+
+		for i := 0; i != 2; i++ {
+			if err := e.Encode(map[string]int{"a": i}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		testConformance(t, codec, b.Bytes(), objconv.Standard, objconv.ErrSyntax, new(map[string]int))
+	})
+
+	t.Run("duplicate keys", func(t *testing.T) {
+		b := encodeMap(t, codec, "a", 1, "a", 2)
+
+		for _, v := range []interface{}{
+			new(interface{}),
+			new(map[string]interface{}),
+			new(map[string]int),
+			new(map[interface{}]interface{}),
+			new(conformanceStruct),
+		} {
+			testConformance(t, codec, b, objconv.Standard, objconv.ErrSyntax, v)
+		}
+	})
+
+	t.Run("non-finite numbers", func(t *testing.T) {
+		for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+			b := &bytes.Buffer{}
+
+			if err := codec.NewEncoder(b).Encode(map[string]float64{"a": f}); err != nil {
+				t.Skipf("the codec has no representation of %g: %v", f, err)
+			}
+
+			testConformance(t, codec, b.Bytes(), objconv.Strict, objconv.ErrRange, new(conformanceStruct))
+		}
+	})
+
+	t.Run("control characters", func(t *testing.T) {
+		b := &bytes.Buffer{}
+
+		if err := codec.NewEncoder(b).Encode(map[string]string{"a": "hello\x00world"}); err != nil {
+			t.Skipf("the codec has no representation of control characters: %v", err)
+		}
+
+		testConformance(t, codec, b.Bytes(), objconv.Strict, objconv.ErrSyntax, new(conformanceStruct), new(map[string]string))
+	})
+}
+
+// testConformance decodes b into each of the values with all the conformance
+// levels, and verifies that it is rejected with an error of the given kind by
+// the levels from level.
+func testConformance(t *testing.T, codec objconv.Codec, b []byte, level objconv.Conformance, kind error, values ...interface{}) {
+	t.Helper()
+
+	for _, v := range values {
+		for _, c := range []objconv.Conformance{objconv.Lenient, objconv.Standard, objconv.Strict} {
+			d := codec.NewDecoder(bytes.NewReader(b))
+			d.Conformance = c
+
+			err := d.Decode(v)
+
+			switch {
+			case c < level && err != nil:
+				t.Errorf("%T: the %s level rejected the input: %v", v, c, err)
+			case c >= level && !errors.Is(err, kind):
+				t.Errorf("%T: the %s level did not reject the input with an error of kind %v: %v", v, c, kind, err)
+			}
+		}
+	}
+}
+
+// encodeMap encodes a map made of the keys and values of kv, which may have
+// duplicate keys.
+func encodeMap(t *testing.T, codec objconv.Codec, kv ...interface{}) []byte {
+	b := &bytes.Buffer{}
+	i := 0
+
+	if err := codec.NewEncoder(b).EncodeMap(len(kv)/2, func(ke objconv.Encoder, ve objconv.Encoder) error {
+		if err := ke.Encode(kv[i]); err != nil {
+			return err
+		}
+		if err := ve.Encode(kv[i+1]); err != nil {
+			return err
+		}
+		i += 2
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return b.Bytes()
+}
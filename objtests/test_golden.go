@@ -0,0 +1,153 @@
+package objtests
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/segmentio/objconv"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "Rewrites the golden files compared by objtests.Golden")
+
+// Golden verifies that v encodes with the codec to the same document as the
+// golden file at path, which lets programs detect unexpected changes to their
+// serialized formats.
+//
+// The documents are compared semantically, both are decoded and the failure
+// message lists the paths of values that differ, so the formatting of the
+// golden file and the order of map keys don't matter. Running the tests with
+// the -update-golden flag writes the canonical encoding of v to the golden
+// file, with sorted map keys and pretty-printed if the emitters of the codec
+// support it.
+func Golden(t *testing.T, codec objconv.Codec, v interface{}, path string) {
+	t.Helper()
+
+	b, err := encodeCanonical(codec, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	g, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run the tests with -update-golden to create the file)", err)
+	}
+
+	var expected, found interface{}
+
+	if err := codec.NewDecoder(bytes.NewReader(g)).Decode(&expected); err != nil {
+		t.Fatalf("decoding the golden file %s: %v", path, err)
+	}
+
+	if err := codec.NewDecoder(bytes.NewReader(b)).Decode(&found); err != nil {
+		t.Fatalf("decoding the encoded value: %v", err)
+	}
+
+	if diff := differences("", expected, found, nil); len(diff) != 0 {
+		t.Errorf("the encoded value differs from the golden file %s at %v (run the tests with -update-golden to update the file):\n%s", path, diff, b)
+	}
+}
+
+func encodeCanonical(codec objconv.Codec, v interface{}) ([]byte, error) {
+	b := &bytes.Buffer{}
+	m := codec.NewEmitter(b)
+
+	if p, ok := m.(objconv.PrettyEmitter); ok {
+		m = p.PrettyEmitter()
+	}
+
+	e := objconv.Encoder{Emitter: m, SortMapKeys: true}
+
+	if err := e.Encode(v); err != nil {
+		return nil, err
+	}
+
+	// Text files are expected to end with a line break.
+	if t, ok := m.(interface{ TextEmitter() bool }); ok && t.TextEmitter() && !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+		b.WriteByte('\n')
+	}
+
+	return b.Bytes(), nil
+}
+
+// differences appends to diff the paths of the values that differ between a
+// and b.
+func differences(path string, a, b interface{}, diff []string) []string {
+	m1, ok1 := a.(map[interface{}]interface{})
+	m2, ok2 := b.(map[interface{}]interface{})
+
+	if ok1 && ok2 {
+		keys := []interface{}{}
+
+		for k := range m1 {
+			keys = append(keys, k)
+		}
+
+		for k := range m2 {
+			if _, ok := m1[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+
+		for _, k := range keys {
+			p := fmt.Sprint(k)
+			if len(path) != 0 {
+				p = path + "." + p
+			}
+
+			v1, ok1 := m1[k]
+			v2, ok2 := m2[k]
+
+			if ok1 != ok2 {
+				diff = append(diff, p)
+			} else {
+				diff = differences(p, v1, v2, diff)
+			}
+		}
+
+		return diff
+	}
+
+	s1, ok1 := a.([]interface{})
+	s2, ok2 := b.([]interface{})
+
+	if ok1 && ok2 && len(s1) == len(s2) {
+		for i := range s1 {
+			diff = differences(fmt.Sprintf("%s[%d]", path, i), s1[i], s2[i], diff)
+		}
+		return diff
+	}
+
+	if t1, ok := a.(time.Time); ok {
+		if t2, ok := b.(time.Time); ok && t1.Equal(t2) {
+			return diff
+		}
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		if len(path) == 0 {
+			path = "."
+		}
+		diff = append(diff, path)
+	}
+
+	return diff
+}
@@ -0,0 +1,52 @@
+package objtests
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/objconv"
+)
+
+// TestStreamHeartbeat verifies that heartbeats written by a stream encoder of
+// the codec are skipped when the stream is decoded.
+func TestStreamHeartbeat(t *testing.T, codec objconv.Codec) {
+	b := &bytes.Buffer{}
+	e := codec.NewStreamEncoder(b)
+
+	if err := e.Heartbeat(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i != 3; i++ {
+		if err := e.Encode(i); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Heartbeat(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var values []int
+	d := codec.NewStreamDecoder(b)
+
+	for {
+		var v int
+		if d.Decode(&v) != nil {
+			break
+		}
+		values = append(values, v)
+	}
+
+	if err := d.Err(); err != nil {
+		t.Error(err)
+	}
+
+	if !reflect.DeepEqual(values, []int{0, 1, 2}) {
+		t.Error("bad values decoded from a stream with heartbeats:", values)
+	}
+}
@@ -0,0 +1,40 @@
+package objtests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/segmentio/objconv"
+)
+
+// TestParserStringLimit verifies that the parsers of the codec created by
+// ParserConfig.NewParser reject the byte sequences exceeding MaxStringLen when
+// they read their length prefixes. The input is truncated after the prefix, so
+// parsers which attempt to read the bytes report a syntax error instead.
+func TestParserStringLimit(t *testing.T, codec objconv.Codec) {
+	c := objconv.ParserConfig{MaxStringLen: 100}
+	b := &bytes.Buffer{}
+
+	if err := codec.NewEncoder(b).Encode(bytes.Repeat([]byte("A"), 1000)); err != nil {
+		t.Fatal(err)
+	}
+
+	var v []byte
+
+	if err := c.NewDecoder(codec, bytes.NewReader(b.Bytes()[:16])).Decode(&v); !errors.Is(err, objconv.ErrLimit) {
+		t.Error("expected a limit error but got:", err)
+	}
+
+	b.Reset()
+
+	if err := codec.NewEncoder(b).Encode(bytes.Repeat([]byte("A"), 100)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.NewDecoder(codec, b).Decode(&v); err != nil {
+		t.Error(err)
+	} else if len(v) != 100 {
+		t.Error("bad length:", len(v))
+	}
+}
@@ -0,0 +1,56 @@
+package objtests
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/objconv"
+)
+
+type rawEnvelope struct {
+	Kind    string
+	Payload objconv.RawMessage
+}
+
+type rawPayload struct {
+	ID   int
+	Tags []string
+}
+
+// TestRawMessage verifies that raw messages decoded with the codec capture the
+// representation of values, and that encoding them writes it back to the
+// output.
+func TestRawMessage(t *testing.T, codec objconv.Codec) {
+	p1 := rawPayload{ID: 42, Tags: []string{"a", "b"}}
+	b := &bytes.Buffer{}
+
+	if err := codec.NewEncoder(b).Encode(p1); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := objconv.RawMessage(append([]byte{}, b.Bytes()...))
+	b.Reset()
+
+	if err := codec.NewEncoder(b).Encode(rawEnvelope{Kind: "payload", Payload: raw}); err != nil {
+		t.Fatal(err)
+	}
+
+	var e rawEnvelope
+	if err := codec.NewDecoder(b).Decode(&e); err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Kind != "payload" {
+		t.Errorf("bad kind decoded next to a raw message: %q", e.Kind)
+	}
+
+	var p2 rawPayload
+	if err := codec.NewDecoder(bytes.NewReader(e.Payload)).Decode(&p2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(p1, p2) {
+		t.Errorf("bad payload decoded from a raw message: %#v != %#v", p1, p2)
+	}
+}
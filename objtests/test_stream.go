@@ -0,0 +1,141 @@
+package objtests
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/objconv"
+)
+
+// TestStreamCloseWithError verifies that streams of the codec terminated with
+// an error report a *objconv.StreamError when they are decoded.
+func TestStreamCloseWithError(t *testing.T, codec objconv.Codec) {
+	for _, n := range []int{-1, 4} {
+		b := &bytes.Buffer{}
+		e := codec.NewStreamEncoder(b)
+
+		if err := e.Open(n); err != nil {
+			t.Fatal(err)
+		}
+
+		values := []interface{}{
+			map[interface{}]interface{}{"A": "1"},
+			map[interface{}]interface{}{},
+			"hello",
+		}
+
+		for _, v := range values {
+			if err := e.Encode(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := e.CloseWithError(errors.New("something went wrong")); err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded []interface{}
+		d := codec.NewStreamDecoder(b)
+
+		for {
+			var v interface{}
+			if d.Decode(&v) != nil {
+				break
+			}
+			decoded = append(decoded, v)
+		}
+
+		if !reflect.DeepEqual(decoded, values) {
+			t.Errorf("n = %d: %#v != %#v", n, values, decoded)
+		}
+
+		switch err := d.Err().(type) {
+		case *objconv.StreamError:
+			if err.Message != "something went wrong" {
+				t.Errorf("n = %d: bad error message: %q", n, err.Message)
+			}
+		default:
+			t.Errorf("n = %d: bad error: %v", n, err)
+		}
+	}
+}
+
+// TestStreamDecodeAt verifies that the offsets reported by stream decoders of
+// the codec match the location of the values in the input.
+func TestStreamDecodeAt(t *testing.T, codec objconv.Codec) {
+	values := []interface{}{
+		map[string]string{"A": "1"},
+		"hello",
+		[]int{1, 2, 3},
+		time.Date(2009, 11, 10, 23, 0, 0, 0, time.UTC),
+		map[string]int{},
+	}
+
+	t.Run("sequence", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		offsets := make([][2]int64, len(values))
+
+		for i, v := range values {
+			off := b.Len()
+			if err := codec.NewEncoder(b).Encode(v); err != nil {
+				t.Fatal(err)
+			}
+			offsets[i] = [2]int64{int64(off), int64(b.Len() - off)}
+		}
+
+		d := codec.NewStreamDecoder(b)
+		d.Sequence = true
+
+		for i := range values {
+			var v interface{}
+
+			off, n, err := d.DecodeAt(&v)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if at := [2]int64{off, n}; at != offsets[i] {
+				t.Errorf("value at index %d: bad offsets: %v != %v", i, offsets[i], at)
+			}
+		}
+	})
+
+	t.Run("array", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		e := codec.NewStreamEncoder(b)
+
+		for _, v := range values {
+			if err := e.Encode(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		s := b.Bytes()
+		d := codec.NewStreamDecoder(bytes.NewReader(s))
+
+		for i := range values {
+			var v1 interface{}
+			var v2 interface{}
+
+			off, n, err := d.DecodeAt(&v1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := codec.NewDecoder(bytes.NewReader(s[off : off+n])).Decode(&v2); err != nil {
+				t.Errorf("value at index %d: %s", i, err)
+			}
+
+			if !reflect.DeepEqual(v1, v2) {
+				t.Errorf("value at index %d: %#v != %#v", i, v1, v2)
+			}
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package objutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// ContextReader is an io.Reader which aborts the reads from its underlying
+// reader when the context it is bound to is canceled, returning the error of
+// the context instead of blocking until data is available.
+//
+// Reads from readers which have a SetReadDeadline method, like net.Conn, are
+// aborted by moving the read deadline to the past. Other readers are read in a
+// separate goroutine which may remain blocked after the read was aborted, its
+// result is returned by the next call to Read.
+//
+// ContextReader values are not safe for use by multiple goroutines.
+type ContextReader struct {
+	r   io.Reader
+	ctx context.Context
+
+	// Set when the underlying reader supports deadlines, closing stop
+	// terminates the goroutine watching the context, which closes exit.
+	deadliner readDeadliner
+	stop      chan struct{}
+	exit      chan struct{}
+
+	// State of the reads done in a separate goroutine, buf[off:] holds the
+	// bytes not returned yet, followed by err.
+	pending chan readResult
+	buf     []byte
+	off     int
+	err     error
+}
+
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+type readResult struct {
+	n   int
+	err error
+}
+
+// NewContextReader returns a reader of r bound to ctx.
+func NewContextReader(r io.Reader, ctx context.Context) *ContextReader {
+	c := &ContextReader{r: r, ctx: context.Background()}
+	c.deadliner, _ = r.(readDeadliner)
+	c.SetContext(ctx)
+	return c
+}
+
+// WithContext returns a reader of r bound to ctx. The function returns r if it
+// is a *ContextReader after binding it to ctx, or if ctx can never be canceled
+// since there is no need to wrap it then.
+func WithContext(r io.Reader, ctx context.Context) io.Reader {
+	if c, ok := r.(*ContextReader); ok {
+		c.SetContext(ctx)
+		return c
+	}
+	if ctx.Done() == nil {
+		return r
+	}
+	return NewContextReader(r, ctx)
+}
+
+// SetContext binds r to ctx, the following reads are aborted when ctx is
+// canceled.
+func (r *ContextReader) SetContext(ctx context.Context) {
+	if r.stop != nil {
+		close(r.stop)
+		<-r.exit
+		r.stop, r.exit = nil, nil
+	}
+
+	r.ctx = ctx
+
+	if r.deadliner == nil {
+		return
+	}
+
+	deadline, _ := ctx.Deadline()
+	r.deadliner.SetReadDeadline(deadline)
+
+	if done := ctx.Done(); done != nil {
+		stop, exit := make(chan struct{}), make(chan struct{})
+		r.stop, r.exit = stop, exit
+
+		go func() {
+			defer close(exit)
+			select {
+			case <-done:
+				r.deadliner.SetReadDeadline(aLongTimeAgo)
+			case <-stop:
+			}
+		}()
+	}
+}
+
+// Read satisfies the io.Reader interface.
+func (r *ContextReader) Read(b []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if r.deadliner != nil {
+		n, err := r.r.Read(b)
+		if err != nil {
+			err = r.contextError(err)
+		}
+		return n, err
+	}
+
+	if r.ctx.Done() == nil && r.pending == nil && r.off == len(r.buf) && r.err == nil {
+		return r.r.Read(b)
+	}
+
+	return r.readAsync(b)
+}
+
+// readAsync reads from the underlying reader in a separate goroutine, and
+// returns when the read completed or the context was canceled.
+func (r *ContextReader) readAsync(b []byte) (int, error) {
+	if r.pending == nil && r.off == len(r.buf) && r.err == nil {
+		if cap(r.buf) < len(b) {
+			r.buf = make([]byte, len(b))
+		}
+
+		buf := r.buf[:len(b)]
+		ch := make(chan readResult, 1)
+		r.pending = ch
+
+		go func() {
+			n, err := r.r.Read(buf)
+			ch <- readResult{n: n, err: err}
+		}()
+	}
+
+	if r.pending != nil {
+		select {
+		case res := <-r.pending:
+			r.pending = nil
+			r.buf = r.buf[:res.n]
+			r.off = 0
+			r.err = res.err
+		case <-r.ctx.Done():
+			return 0, r.ctx.Err()
+		}
+	}
+
+	n := copy(b, r.buf[r.off:])
+	r.off += n
+
+	if r.off != len(r.buf) {
+		return n, nil
+	}
+
+	err := r.err
+	r.err = nil
+	return n, err
+}
+
+// contextError returns the error of the context if the read failed because
+// it was canceled or its deadline expired, or err otherwise.
+func (r *ContextReader) contextError(err error) error {
+	if cerr := r.ctx.Err(); cerr != nil {
+		return cerr
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		if deadline, ok := r.ctx.Deadline(); ok && !time.Now().Before(deadline) {
+			return context.DeadlineExceeded
+		}
+	}
+	return err
+}
+
+// aLongTimeAgo is a read deadline in the past, setting it aborts blocking
+// reads immediately.
+var aLongTimeAgo = time.Unix(1, 0)
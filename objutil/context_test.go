@@ -0,0 +1,85 @@
+package objutil
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestContextReader(t *testing.T) {
+	tests := []struct {
+		name string
+		pipe func() (io.Reader, io.WriteCloser)
+	}{
+		{
+			name: "deadline",
+			pipe: func() (io.Reader, io.WriteCloser) { return net.Pipe() },
+		},
+		{
+			name: "goroutine",
+			pipe: func() (io.Reader, io.WriteCloser) { return io.Pipe() },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, w := test.pipe()
+			defer w.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			c := NewContextReader(r, ctx)
+
+			go w.Write([]byte("hello"))
+
+			b := make([]byte, 16)
+			if n, err := c.Read(b); err != nil || string(b[:n]) != "hello" {
+				t.Fatalf("bad read: %q: %v", b[:n], err)
+			}
+
+			time.AfterFunc(10*time.Millisecond, cancel)
+
+			if _, err := c.Read(b); err != context.Canceled {
+				t.Fatalf("expected context.Canceled but got %v", err)
+			}
+
+			// The reader can be used again after being bound to another
+			// context, the aborted read doesn't lose data.
+			ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			c.SetContext(ctx)
+
+			if _, err := c.Read(b); err != context.DeadlineExceeded {
+				t.Fatalf("expected context.DeadlineExceeded but got %v", err)
+			}
+
+			c.SetContext(context.Background())
+			go w.Write([]byte("world"))
+
+			if n, err := c.Read(b); err != nil || string(b[:n]) != "world" {
+				t.Fatalf("bad read: %q: %v", b[:n], err)
+			}
+		})
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	r, _ := io.Pipe()
+
+	if WithContext(r, context.Background()) != io.Reader(r) {
+		t.Error("readers must not be wrapped with contexts that are never canceled")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := WithContext(r, ctx)
+	if _, ok := c.(*ContextReader); !ok {
+		t.Fatalf("bad reader type: %T", c)
+	}
+
+	if WithContext(c, context.Background()) != c {
+		t.Error("context readers must be bound to the new context")
+	}
+}
@@ -4,10 +4,7 @@ package objutil
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-import (
-	"reflect"
-	"unsafe"
-)
+import "reflect"
 
 // IsEmpty returns true if the value given as argument would be considered
 // empty by the standard library packages, and therefore not serialized if
@@ -39,7 +36,7 @@ func IsEmptyValue(v reflect.Value) bool {
 	case reflect.Interface, reflect.Ptr, reflect.Chan, reflect.Func:
 		return v.IsNil()
 	case reflect.UnsafePointer:
-		return unsafe.Pointer(v.Pointer()) == nil
+		return v.Pointer() == 0
 	}
 	return false
 }
@@ -0,0 +1,45 @@
+package objutil
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrSyntax is the kind of errors reporting malformed input.
+	ErrSyntax = errors.New("objconv: syntax error")
+
+	// ErrType is the kind of errors reporting values that cannot be converted
+	// to or from the requested type.
+	ErrType = errors.New("objconv: type error")
+
+	// ErrRange is the kind of errors reporting values that overflow the range
+	// of their destination type or of the output format.
+	ErrRange = errors.New("objconv: range error")
+
+	// ErrLimit is the kind of errors reporting values that exceed a size limit
+	// of the format or of the program.
+	ErrLimit = errors.New("objconv: limit exceeded")
+
+	// ErrUnknownField is the kind of errors reporting fields of the input that
+	// don't exist in the destination struct.
+	ErrUnknownField = errors.New("objconv: unknown field")
+)
+
+// Errorf returns an error with a message formatted from format and args, which
+// matches kind when tested with errors.Is.
+//
+// The message of the error isn't prefixed by the message of kind, programs
+// that want to branch on the kind of errors use errors.Is instead of matching
+// strings.
+func Errorf(kind error, format string, args ...interface{}) error {
+	return &kindError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string { return e.msg }
+func (e *kindError) Unwrap() error { return e.kind }
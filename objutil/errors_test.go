@@ -0,0 +1,33 @@
+package objutil
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestErrorf(t *testing.T) {
+	err := Errorf(ErrRange, "objconv: %d is too large", 42)
+
+	if s := err.Error(); s != "objconv: 42 is too large" {
+		t.Error("bad error message:", s)
+	}
+
+	if !errors.Is(err, ErrRange) {
+		t.Error("the error doesn't match its kind")
+	}
+
+	if errors.Is(err, ErrSyntax) {
+		t.Error("the error matches the wrong kind")
+	}
+}
+
+func TestCheckBoundsErrorKind(t *testing.T) {
+	if err := CheckInt64Bounds(1000, Int8Min, Int8Max, reflect.TypeOf(int8(0))); !errors.Is(err, ErrRange) {
+		t.Error("bad error kind:", err)
+	}
+
+	if _, err := ParseInt([]byte("nope")); !errors.Is(err, ErrSyntax) {
+		t.Error("bad error kind:", err)
+	}
+}
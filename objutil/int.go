@@ -1,7 +1,5 @@
 package objutil
 
-import "fmt"
-
 // ParseInt parses a decimanl representation of an int64 from b.
 //
 // The function is equivalent to calling strconv.ParseInt(string(b), 10, 64) but
@@ -117,17 +115,17 @@ func ParseUintHex(b []byte) (uint64, error) {
 }
 
 func errorInvalidInt64(b []byte) error {
-	return fmt.Errorf("objconv: %#v is not a valid decimal representation of a signed 64 bits integer", string(b))
+	return Errorf(ErrSyntax, "objconv: %#v is not a valid decimal representation of a signed 64 bits integer", string(b))
 }
 
 func errorOverflowInt64(b []byte) error {
-	return fmt.Errorf("objconv: %#v overflows the maximum values of a signed 64 bits integer", string(b))
+	return Errorf(ErrRange, "objconv: %#v overflows the maximum values of a signed 64 bits integer", string(b))
 }
 
 func errorInvalidUint64(b []byte) error {
-	return fmt.Errorf("objconv: %#v is not a valid decimal representation of an unsigned 64 bits integer", string(b))
+	return Errorf(ErrSyntax, "objconv: %#v is not a valid decimal representation of an unsigned 64 bits integer", string(b))
 }
 
 func errorOverflowUint64(b []byte) error {
-	return fmt.Errorf("objconv: %#v overflows the maximum values of an unsigned 64 bits integer", string(b))
+	return Errorf(ErrRange, "objconv: %#v overflows the maximum values of an unsigned 64 bits integer", string(b))
 }
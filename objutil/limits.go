@@ -1,9 +1,6 @@
 package objutil
 
-import (
-	"fmt"
-	"reflect"
-)
+import "reflect"
 
 const (
 	// UintMax is the maximum value of a uint.
@@ -89,7 +86,7 @@ const (
 // original type of v.
 func CheckUint64Bounds(v uint64, max uint64, t reflect.Type) (err error) {
 	if v > max {
-		err = fmt.Errorf("objconv: %d overflows the maximum value of %d for %s", v, max, t)
+		err = Errorf(ErrRange, "objconv: %d overflows the maximum value of %d for %s", v, max, t)
 	}
 	return
 }
@@ -98,10 +95,10 @@ func CheckUint64Bounds(v uint64, max uint64, t reflect.Type) (err error) {
 // original type of v.
 func CheckInt64Bounds(v int64, min int64, max uint64, t reflect.Type) (err error) {
 	if v < min {
-		err = fmt.Errorf("objconv: %d overflows the minimum value of %d for %s", v, min, t)
+		err = Errorf(ErrRange, "objconv: %d overflows the minimum value of %d for %s", v, min, t)
 	}
 	if v > 0 && uint64(v) > max {
-		err = fmt.Errorf("objconv: %d overflows the maximum value of %d for %s", v, max, t)
+		err = Errorf(ErrRange, "objconv: %d overflows the maximum value of %d for %s", v, max, t)
 	}
 	return
 }
@@ -0,0 +1,46 @@
+package objutil
+
+import "io"
+
+// readChunkSize is the initial amount of memory allocated by ReadFull when the
+// buffer it receives is too small.
+const readChunkSize = 4096
+
+// ReadFull appends n bytes read from r to b, and returns the extended slice.
+//
+// Unlike allocating n bytes and calling io.ReadFull, the buffer grows as the
+// bytes are read, so a length read from a malformed or malicious input cannot
+// make the program allocate more memory than the size of the input.
+func ReadFull(b []byte, r io.Reader, n int) ([]byte, error) {
+	for i := len(b); n != 0; {
+		if len(b) == cap(b) {
+			c := 2 * cap(b)
+			if c < readChunkSize {
+				c = readChunkSize
+			}
+			if c > len(b)+n {
+				c = len(b) + n
+			}
+			x := make([]byte, len(b), c)
+			copy(x, b)
+			b = x
+		}
+
+		k := cap(b) - len(b)
+		if k > n {
+			k = n
+		}
+
+		m, err := io.ReadFull(r, b[len(b):len(b)+k])
+		b = b[:len(b)+m]
+		n -= m
+
+		if err != nil {
+			if err == io.EOF && len(b) != i {
+				err = io.ErrUnexpectedEOF
+			}
+			return b, err
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,31 @@
+package objutil
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadFull(t *testing.T) {
+	s := strings.Repeat("0123456789", 1000)
+
+	b, err := ReadFull([]byte("head:"), strings.NewReader(s), len(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, []byte("head:"+s)) {
+		t.Error("bad content read")
+	}
+
+	b, err = ReadFull(nil, strings.NewReader("hello"), 1<<40)
+	if err != io.ErrUnexpectedEOF {
+		t.Error("expected io.ErrUnexpectedEOF but got", err)
+	}
+	if string(b) != "hello" {
+		t.Errorf("bad content read: %q", b)
+	}
+	if cap(b) > readChunkSize {
+		t.Errorf("too much memory allocated to read 5 bytes: %d", cap(b))
+	}
+}
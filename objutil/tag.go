@@ -12,6 +12,27 @@ type Tag struct {
 
 	// Omitzero is true if the tag had `omitzero` set.
 	Omitzero bool
+
+	// Export is true if the tag had `export` set.
+	Export bool
+
+	// Attr is true if the tag had `attr` set, the field is then encoded as an
+	// attribute by formats which have them, like XML.
+	Attr bool
+
+	// Chardata is true if the tag had `chardata` set, the field is then
+	// encoded as the character data of its element by formats which have
+	// them, like XML.
+	Chardata bool
+
+	// Inline is true if the tag had `inline` set, the fields of the struct or
+	// the entries of the map held by the field are then merged into the
+	// parent object.
+	Inline bool
+
+	// Encrypt is true if the tag had `encrypt` set, the value of the field is
+	// then encrypted by encoders and decrypted by decoders.
+	Encrypt bool
 }
 
 // ParseTag parses a raw tag obtained from a struct field, returning the results
@@ -20,6 +41,11 @@ func ParseTag(s string) Tag {
 	var name string
 	var omitzero bool
 	var omitempty bool
+	var export bool
+	var attr bool
+	var chardata bool
+	var inline bool
+	var encrypt bool
 
 	name, s = parseNextTagToken(s)
 
@@ -30,6 +56,16 @@ func ParseTag(s string) Tag {
 			omitempty = true
 		case "omitzero":
 			omitzero = true
+		case "export":
+			export = true
+		case "attr":
+			attr = true
+		case "chardata":
+			chardata = true
+		case "inline":
+			inline = true
+		case "encrypt":
+			encrypt = true
 		}
 	}
 
@@ -37,6 +73,11 @@ func ParseTag(s string) Tag {
 		Name:      name,
 		Omitempty: omitempty,
 		Omitzero:  omitzero,
+		Export:    export,
+		Attr:      attr,
+		Chardata:  chardata,
+		Inline:    inline,
+		Encrypt:   encrypt,
 	}
 }
 
@@ -35,10 +35,30 @@ func TestParseTag(t *testing.T) {
 			tag: "hello,omitzero",
 			res: Tag{Name: "hello", Omitzero: true},
 		},
+		{
+			tag: "hello,export",
+			res: Tag{Name: "hello", Export: true},
+		},
 		{
 			tag: "-,omitempty,omitzero",
 			res: Tag{Name: "-", Omitempty: true, Omitzero: true},
 		},
+		{
+			tag: "id,attr,omitempty",
+			res: Tag{Name: "id", Attr: true, Omitempty: true},
+		},
+		{
+			tag: ",chardata",
+			res: Tag{Chardata: true},
+		},
+		{
+			tag: ",inline",
+			res: Tag{Inline: true},
+		},
+		{
+			tag: "ssn,encrypt,omitempty",
+			res: Tag{Name: "ssn", Encrypt: true, Omitempty: true},
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,45 @@
+package objutil
+
+import "time"
+
+// TimeCache caches the RFC3339Nano representation of the last time value that
+// was formatted.
+//
+// Emitters use it to avoid formatting the same time value over and over again
+// when encoding batches of records that share the same timestamp, which is
+// common in logs where timestamps are truncated to the second.
+//
+// The zero-value is a valid, empty cache. TimeCache values are not safe to use
+// concurrently from multiple goroutines.
+type TimeCache struct {
+	t time.Time
+	b []byte
+	s string
+	a [40]byte
+}
+
+// AppendTime appends the RFC3339Nano representation of t to b and returns the
+// extended buffer.
+func (c *TimeCache) AppendTime(b []byte, t time.Time) []byte {
+	return append(b, c.format(t)...)
+}
+
+// FormatTime returns the RFC3339Nano representation of t.
+func (c *TimeCache) FormatTime(t time.Time) string {
+	c.format(t)
+
+	if len(c.s) == 0 {
+		c.s = string(c.b)
+	}
+
+	return c.s
+}
+
+func (c *TimeCache) format(t time.Time) []byte {
+	if len(c.b) == 0 || !t.Equal(c.t) || t.Location() != c.t.Location() {
+		c.t = t
+		c.b = t.AppendFormat(c.a[:0], time.RFC3339Nano)
+		c.s = ""
+	}
+	return c.b
+}
@@ -0,0 +1,49 @@
+package objutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeCache(t *testing.T) {
+	t0 := time.Date(2017, 6, 1, 12, 30, 0, 0, time.UTC)
+	t1 := t0.Add(time.Second)
+	t2 := t0.In(time.FixedZone("PDT", -7*3600))
+
+	c := TimeCache{}
+
+	for _, v := range []time.Time{t0, t0, t1, t0, t2, t2, {}} {
+		if s := string(c.AppendTime(nil, v)); s != v.Format(time.RFC3339Nano) {
+			t.Errorf("AppendTime: %s != %s", v.Format(time.RFC3339Nano), s)
+		}
+		if s := c.FormatTime(v); s != v.Format(time.RFC3339Nano) {
+			t.Errorf("FormatTime: %s != %s", v.Format(time.RFC3339Nano), s)
+		}
+	}
+}
+
+func TestTimeCacheAllocs(t *testing.T) {
+	v := time.Date(2017, 6, 1, 12, 30, 0, 0, time.UTC)
+	b := make([]byte, 0, 64)
+	c := TimeCache{}
+
+	if n := testing.AllocsPerRun(100, func() { c.AppendTime(b, v) }); n != 0 {
+		t.Error("too many memory allocations:", n)
+	}
+
+	c.FormatTime(v)
+
+	if n := testing.AllocsPerRun(100, func() { c.FormatTime(v) }); n != 0 {
+		t.Error("too many memory allocations:", n)
+	}
+}
+
+func BenchmarkTimeCache(b *testing.B) {
+	v := time.Date(2017, 6, 1, 12, 30, 0, 0, time.UTC)
+	a := make([]byte, 0, 64)
+	c := TimeCache{}
+
+	for i := 0; i != b.N; i++ {
+		c.AppendTime(a, v)
+	}
+}
@@ -0,0 +1,123 @@
+package objutil
+
+import (
+	"io"
+	"net"
+)
+
+// DefaultBufferSize is the size of the buffer used by BufferedWriter values
+// when none was explicitly configured.
+const DefaultBufferSize = 4096
+
+// BufferedWriter is an io.Writer which accumulates small writes in an internal
+// buffer. When a write doesn't fit in the buffer the buffered bytes and the
+// written bytes are sent together as a net.Buffers value, which results in a
+// single writev syscall when the underlying writer is a net.Conn.
+//
+// The zero-value is a valid writer that has no underlying output, it must be
+// configured with a call to Reset before being used.
+type BufferedWriter struct {
+	w io.Writer
+	b []byte
+	v net.Buffers
+	a [2][]byte
+
+	// Size is the capacity of the internal buffer, DefaultBufferSize is used
+	// if it is zero.
+	Size int
+}
+
+// NewBufferedWriter returns a new BufferedWriter that outputs to w.
+func NewBufferedWriter(w io.Writer) *BufferedWriter {
+	return &BufferedWriter{w: w}
+}
+
+// Reset discards the buffered data and sets w as the new underlying writer.
+func (w *BufferedWriter) Reset(x io.Writer) {
+	w.w = x
+	w.b = w.b[:0]
+}
+
+// Writer returns the underlying writer of w.
+func (w *BufferedWriter) Writer() io.Writer {
+	return w.w
+}
+
+// Buffered returns the number of bytes that have been buffered.
+func (w *BufferedWriter) Buffered() int {
+	return len(w.b)
+}
+
+// Write satisfies the io.Writer interface.
+//
+// The method never retains b after it returns.
+func (w *BufferedWriter) Write(b []byte) (n int, err error) {
+	w.init()
+
+	if (len(w.b) + len(b)) <= cap(w.b) {
+		w.b = append(w.b, b...)
+		return len(b), nil
+	}
+
+	if len(w.b) == 0 {
+		return w.w.Write(b)
+	}
+
+	w.a[0], w.a[1] = w.b, b
+	w.v = w.a[:]
+	_, err = w.v.WriteTo(w.w)
+	w.a[0], w.a[1] = nil, nil
+	w.b = w.b[:0]
+
+	if err == nil {
+		n = len(b)
+	}
+	return
+}
+
+// WriteString satisfies the io.StringWriter interface, strings that fit in the
+// buffer are copied to it without being converted to byte slices.
+func (w *BufferedWriter) WriteString(s string) (n int, err error) {
+	w.init()
+
+	if (len(w.b) + len(s)) <= cap(w.b) {
+		w.b = append(w.b, s...)
+		return len(s), nil
+	}
+
+	if err = w.Flush(); err == nil {
+		n, err = io.WriteString(w.w, s)
+	}
+	return
+}
+
+func (w *BufferedWriter) init() {
+	if w.b == nil {
+		size := w.Size
+		if size <= 0 {
+			size = DefaultBufferSize
+		}
+		w.b = make([]byte, 0, size)
+	}
+}
+
+// Flush writes the buffered data to the underlying writer.
+func (w *BufferedWriter) Flush() (err error) {
+	if len(w.b) != 0 {
+		_, err = w.w.Write(w.b)
+		w.b = w.b[:0]
+	}
+	return
+}
+
+// WriteTo writes the buffered data to x, satisfying the io.WriterTo
+// interface.
+func (w *BufferedWriter) WriteTo(x io.Writer) (n int64, err error) {
+	if len(w.b) != 0 {
+		var c int
+		c, err = x.Write(w.b)
+		n = int64(c)
+		w.b = w.b[:0]
+	}
+	return
+}
@@ -0,0 +1,106 @@
+package objutil
+
+import (
+	"bytes"
+	"testing"
+)
+
+type writeCounter struct {
+	bytes.Buffer
+	n int
+}
+
+func (w *writeCounter) Write(b []byte) (int, error) {
+	w.n++
+	return w.Buffer.Write(b)
+}
+
+func TestBufferedWriter(t *testing.T) {
+	tests := []struct {
+		scenario string
+		writes   []string
+		size     int
+		calls    int
+	}{
+		{
+			scenario: "small writes are merged",
+			writes:   []string{"Hello", " ", "World", "!"},
+			size:     64,
+			calls:    1,
+		},
+		{
+			scenario: "writes exceeding the buffer size are sent as a vector",
+			writes:   []string{"Hello", " World!"},
+			size:     8,
+			calls:    2,
+		},
+		{
+			scenario: "large writes with an empty buffer are passed through",
+			writes:   []string{"Hello World!"},
+			size:     8,
+			calls:    1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scenario, func(t *testing.T) {
+			c := &writeCounter{}
+			w := &BufferedWriter{Size: test.size}
+			w.Reset(c)
+
+			for _, s := range test.writes {
+				if _, err := w.Write([]byte(s)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			if s := c.String(); s != "Hello World!" {
+				t.Error("bad output:", s)
+			}
+
+			if c.n != test.calls {
+				t.Error("bad number of writes:", c.n)
+			}
+
+			if n := w.Buffered(); n != 0 {
+				t.Error("bytes remain buffered after flushing:", n)
+			}
+		})
+	}
+}
+
+func TestBufferedWriterString(t *testing.T) {
+	c := &writeCounter{}
+	w := &BufferedWriter{Size: 8}
+	w.Reset(c)
+
+	for _, s := range []string{"Hello", " World!", "!"} {
+		if _, err := w.WriteString(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := c.String(); s != "Hello World!!" {
+		t.Error("bad output:", s)
+	}
+
+	if n := testing.AllocsPerRun(100, func() { w.WriteString("Hello"); w.b = w.b[:0] }); n != 0 {
+		t.Errorf("%v allocations made when writing a string", n)
+	}
+}
+
+func TestBufferedWriterFlushEmpty(t *testing.T) {
+	w := &BufferedWriter{}
+
+	if err := w.Flush(); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,9 +1,6 @@
 package objutil
 
-import (
-	"reflect"
-	"unsafe"
-)
+import "reflect"
 
 // IsZero returns true if the value given as argument is the zero-value of
 // the type of v.
@@ -11,6 +8,9 @@ func IsZero(v interface{}) bool {
 	return IsZeroValue(reflect.ValueOf(v))
 }
 
+// IsZeroValue returns true if v is the zero-value of its type. Structs and
+// arrays which have an IsZero method, like time.Time, are zero when the method
+// returns true.
 func IsZeroValue(v reflect.Value) bool {
 	if !v.IsValid() {
 		return true // nil interface{}
@@ -29,10 +29,16 @@ func IsZeroValue(v reflect.Value) bool {
 	case reflect.String:
 		return v.Len() == 0
 	case reflect.UnsafePointer:
-		return unsafe.Pointer(v.Pointer()) == nil
+		return v.Pointer() == 0
 	case reflect.Array:
+		if z, ok := zeroer(v); ok {
+			return z.IsZero()
+		}
 		return isZeroArray(v)
 	case reflect.Struct:
+		if z, ok := zeroer(v); ok {
+			return z.IsZero()
+		}
 		return isZeroStruct(v)
 	}
 	return false
@@ -55,3 +61,14 @@ func isZeroStruct(v reflect.Value) bool {
 	}
 	return true
 }
+
+// zeroer returns the IsZero method of v, values read from unexported fields
+// cannot be converted to interfaces so their method is never used.
+func zeroer(v reflect.Value) (z interface{ IsZero() bool }, ok bool) {
+	if v.Type().Implements(zeroerType) && v.CanInterface() {
+		z, ok = v.Interface().(interface{ IsZero() bool })
+	}
+	return
+}
+
+var zeroerType = reflect.TypeOf((*interface{ IsZero() bool })(nil)).Elem()
@@ -3,6 +3,7 @@ package objutil
 import (
 	"fmt"
 	"testing"
+	"time"
 	"unsafe"
 )
 
@@ -41,6 +42,9 @@ func TestIsZeroTrue(t *testing.T) {
 
 		struct{}{},
 		struct{ A int }{},
+		time.Time{},
+		time.Time{}.In(time.FixedZone("UTC+1", 3600)),
+		struct{ T time.Time }{time.Time{}.Local()},
 
 		(chan struct{})(nil),
 		(func())(nil),
@@ -90,6 +94,7 @@ func TestIsZeroFalse(t *testing.T) {
 		unsafe.Pointer(&answer),
 
 		struct{ A int }{42},
+		time.Unix(0, 0),
 
 		make(chan struct{}),
 		func() {},
@@ -0,0 +1,164 @@
+package objconv
+
+import "io"
+
+// Page is the metadata of a paginated response, written after its items.
+type Page struct {
+	// Cursor is the opaque cursor of the next page, it is encoded as nil when
+	// empty to indicate that the page is the last one.
+	Cursor string
+
+	// Total is the number of items of all pages, it is encoded as nil when
+	// negative to indicate that it is unknown.
+	Total int
+}
+
+// A PageEncoder writes a paginated response, which is an object holding the
+// items of the page followed by its metadata:
+//
+//	{"items":[...],"cursor":"...","total":42}
+//
+// The items are streamed by a StreamEncoder, so responses can be written while
+// the items are being produced, and metadata like the total, which is often
+// only known once all the items were seen, is given when the page is closed.
+//
+// Instances of PageEncoder are not safe for use by multiple goroutines.
+type PageEncoder struct {
+	s      *StreamEncoder
+	err    error
+	opened bool
+	closed bool
+	count  int
+}
+
+// NewPageEncoder returns a new page encoder that streams the items of the page
+// with s, its emitter and options are also used to write the metadata. The
+// stream must not have been opened yet.
+//
+// The function panics if s is nil.
+func NewPageEncoder(s *StreamEncoder) *PageEncoder {
+	if s == nil {
+		panic("objconv.NewPageEncoder: the stream encoder is nil")
+	}
+	return &PageEncoder{s: s}
+}
+
+// Open explicitly tells the encoder to start the page, setting the number of
+// items to n, see StreamEncoder.Open.
+func (p *PageEncoder) Open(n int) error {
+	if err := p.begin(); err != nil {
+		return err
+	}
+	// Errors of the stream are kept by the stream encoder.
+	return p.s.Open(n)
+}
+
+// begin writes the beginning of the object, up to the items of the page.
+func (p *PageEncoder) begin() error {
+	if err := p.err; err != nil {
+		return err
+	}
+
+	if p.closed {
+		return io.ErrClosedPipe
+	}
+
+	if !p.opened {
+		p.opened = true
+		e := p.s.Emitter
+
+		// The object always has three keys so formats which need the length
+		// of maps ahead of time can be written.
+		if p.err = e.EmitMapBegin(3); p.err != nil {
+			return p.err
+		}
+		if p.err = e.EmitString("items"); p.err != nil {
+			return p.err
+		}
+		p.err = e.EmitMapValue()
+	}
+
+	return p.err
+}
+
+// Encode writes v to the items of the page.
+func (p *PageEncoder) Encode(v interface{}) error {
+	if err := p.Open(-1); err != nil {
+		return err
+	}
+
+	err := p.s.Encode(v)
+
+	if err == nil {
+		p.count++
+	}
+
+	return err
+}
+
+// Count returns the number of items written to the page.
+func (p *PageEncoder) Count() int {
+	return p.count
+}
+
+// Close terminates the items of the page and writes its metadata.
+func (p *PageEncoder) Close(page Page) error {
+	if p.closed {
+		return p.err
+	}
+
+	if err := p.begin(); err != nil {
+		return err
+	}
+
+	p.closed = true
+
+	if p.err = p.s.Close(); p.err != nil {
+		return p.err
+	}
+
+	e := p.s.Emitter
+
+	if p.err = e.EmitMapNext(); p.err != nil {
+		return p.err
+	}
+	if p.err = e.EmitString("cursor"); p.err != nil {
+		return p.err
+	}
+	if p.err = e.EmitMapValue(); p.err != nil {
+		return p.err
+	}
+
+	if len(page.Cursor) == 0 {
+		p.err = e.EmitNil()
+	} else {
+		p.err = e.EmitString(page.Cursor)
+	}
+
+	if p.err != nil {
+		return p.err
+	}
+
+	if p.err = e.EmitMapNext(); p.err != nil {
+		return p.err
+	}
+	if p.err = e.EmitString("total"); p.err != nil {
+		return p.err
+	}
+	if p.err = e.EmitMapValue(); p.err != nil {
+		return p.err
+	}
+
+	if page.Total < 0 {
+		p.err = e.EmitNil()
+	} else {
+		p.err = e.EmitInt(int64(page.Total), 0)
+	}
+
+	if p.err != nil {
+		return p.err
+	}
+
+	p.err = flush(e, e.EmitMapEnd())
+	return p.err
+}
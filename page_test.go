@@ -0,0 +1,86 @@
+package objconv
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestPageEncoder(t *testing.T) {
+	e := NewValueEmitter()
+	p := NewPageEncoder(NewStreamEncoder(e))
+
+	for _, v := range []string{"A", "B", "C"} {
+		if err := p.Encode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := p.Count(); n != 3 {
+		t.Errorf("bad count: %d", n)
+	}
+
+	if err := p.Close(Page{Cursor: "next", Total: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := e.Value(); !reflect.DeepEqual(v, map[interface{}]interface{}{
+		"items":  []interface{}{"A", "B", "C"},
+		"cursor": "next",
+		"total":  int64(10),
+	}) {
+		t.Errorf("bad value: %#v", v)
+	}
+
+	if err := p.Encode("D"); err != io.ErrClosedPipe {
+		t.Errorf("bad error after closing the page: %v", err)
+	}
+}
+
+func TestPageEncoderLastPage(t *testing.T) {
+	e := NewValueEmitter()
+	p := NewPageEncoder(NewStreamEncoder(e))
+
+	if err := p.Close(Page{Total: -1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := e.Value(); !reflect.DeepEqual(v, map[interface{}]interface{}{
+		"items":  []interface{}{},
+		"cursor": nil,
+		"total":  nil,
+	}) {
+		t.Errorf("bad value: %#v", v)
+	}
+}
+
+func TestPageEncoderOpen(t *testing.T) {
+	e := NewValueEmitter()
+	p := NewPageEncoder(NewStreamEncoder(e))
+
+	if err := p.Open(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Encode(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stream of items is closed once it holds the announced number of
+	// values, the metadata can still be written.
+	if err := p.Encode(2); err != io.ErrClosedPipe {
+		t.Errorf("bad error: %v", err)
+	}
+
+	if err := p.Close(Page{Total: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := e.Value(); !reflect.DeepEqual(v, map[interface{}]interface{}{
+		"items":  []interface{}{int64(1)},
+		"cursor": nil,
+		"total":  int64(1),
+	}) {
+		t.Errorf("bad value: %#v", v)
+	}
+}
@@ -0,0 +1,89 @@
+package objconv
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// RecoverPanics controls whether encoders and decoders convert the panics that
+// occur while encoding or decoding top-level values into errors of type
+// *PanicError.
+//
+// Recovering from panics prevents a single malformed payload, or a value that
+// the reflection-based algorithms fail to handle, from crashing a server. It
+// can be disabled when debugging to get the original panic and stack trace.
+//
+// The variable must be set during the program initialization, it is not safe
+// to modify while values are being encoded or decoded.
+var RecoverPanics = true
+
+// PanicError is the error returned by encoders and decoders when a panic was
+// recovered while processing a value.
+type PanicError struct {
+	Path  string      // dotted path to the struct field where the panic occurred
+	Value interface{} // the value passed to panic
+	Stack []byte      // stack trace of the goroutine at the time of the panic
+}
+
+// Error satisfies the error interface.
+func (e *PanicError) Error() string {
+	if len(e.Path) == 0 {
+		return fmt.Sprintf("objconv: panic: %v", e.Value)
+	}
+	return fmt.Sprintf("objconv: panic at %s: %v", e.Path, e.Value)
+}
+
+// Unwrap returns the value passed to panic if it was an error, nil otherwise.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// panicPath is used as panic value to propagate a panic up the stack while
+// recording the names of the struct fields it crossed.
+type panicPath struct {
+	path  []string // field names in reverse order
+	value interface{}
+	stack []byte
+}
+
+// repanic is deferred by the functions encoding and decoding struct fields,
+// it adds the name of the field being processed (if any) to the panic value
+// before propagating it.
+func repanic(field *string) {
+	if r := recover(); r != nil {
+		p := asPanicPath(r)
+		if len(*field) != 0 {
+			p.path = append(p.path, *field)
+		}
+		panic(p)
+	}
+}
+
+func asPanicPath(r interface{}) *panicPath {
+	p, ok := r.(*panicPath)
+	if !ok {
+		p = &panicPath{value: r, stack: debug.Stack()}
+	}
+	return p
+}
+
+// recoverPanic is deferred by the methods encoding and decoding top-level
+// values, it converts panics into errors set to *err.
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		p := asPanicPath(r)
+		path := make([]string, len(p.path))
+
+		for i, name := range p.path {
+			path[len(path)-(i+1)] = name
+		}
+
+		*err = &PanicError{
+			Path:  strings.Join(path, "."),
+			Value: p.value,
+			Stack: p.stack,
+		}
+	}
+}
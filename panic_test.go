@@ -0,0 +1,79 @@
+package objconv
+
+import (
+	"errors"
+	"testing"
+)
+
+type panicker struct{}
+
+func (panicker) EncodeValue(Encoder) error  { panic(errors.New("oops")) }
+func (*panicker) DecodeValue(Decoder) error { panic("oops") }
+
+func TestEncoderRecoverPanic(t *testing.T) {
+	type inner struct {
+		P panicker `objconv:"p"`
+	}
+
+	type outer struct {
+		A int   `objconv:"a"`
+		B inner `objconv:"b"`
+	}
+
+	err := NewEncoder(Discard).Encode(outer{})
+
+	p, ok := err.(*PanicError)
+	if !ok {
+		t.Fatalf("expected a panic error but got %T: %v", err, err)
+	}
+
+	if p.Path != "b.p" {
+		t.Error("bad path:", p.Path)
+	}
+
+	if s := p.Error(); s != "objconv: panic at b.p: oops" {
+		t.Error("bad error message:", s)
+	}
+
+	if errors.Unwrap(p) == nil || len(p.Stack) == 0 {
+		t.Error("the panic error is missing the panic value or the stack trace")
+	}
+}
+
+func TestDecoderRecoverPanic(t *testing.T) {
+	type T struct {
+		A int       `objconv:"a"`
+		P *panicker `objconv:"p"`
+	}
+
+	for _, warn := range []func(Warning){nil, func(Warning) {}} {
+		d := NewDecoder(NewValueParser(map[string]interface{}{"a": 1, "p": 2}))
+		d.Warn = warn
+
+		var v T
+		err := d.Decode(&v)
+
+		if p, ok := err.(*PanicError); !ok || p.Path != "p" || p.Value != "oops" {
+			t.Errorf("bad error: %#v", err)
+		}
+	}
+
+	var v *int
+
+	if _, ok := NewDecoder(NewValueParser(1)).Decode(v).(*PanicError); !ok {
+		t.Error("decoding into a nil pointer did not return a panic error")
+	}
+}
+
+func TestRecoverPanicsDisabled(t *testing.T) {
+	RecoverPanics = false
+	defer func() { RecoverPanics = true }()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("no panic was raised")
+		}
+	}()
+
+	NewEncoder(Discard).Encode(panicker{})
+}
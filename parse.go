@@ -1,6 +1,11 @@
 package objconv
 
-import "time"
+import (
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+)
 
 // The Parser interface must be implemented by types that provide decoding of a
 // specific format (like json, resp, ...).
@@ -136,3 +141,122 @@ func isTextParser(parser Parser) bool {
 	p, _ := parser.(textParser)
 	return p != nil && p.TextParser()
 }
+
+// The OffsetParser interface may be implemented by parsers that keep track of
+// their position in the input.
+//
+// Stream decoders use it to report where the values they decode are located,
+// which makes it possible to build indexes over large encoded files.
+type OffsetParser interface {
+	// Offset returns the position of the parser in its input, which is the
+	// number of bytes that it has consumed.
+	Offset() int64
+}
+
+// The BufferedParser interface may be implemented by parsers that read their
+// input in chunks, and may have loaded bytes beyond the values they parsed.
+//
+// Protocols which switch from values decoded by objconv to raw bytes, like the
+// bulk data following a RESP command, use it to recover the bytes that the
+// parser already read from the connection.
+type BufferedParser interface {
+	// Buffered returns a reader of the bytes that the parser has read from its
+	// input but not parsed yet. The reader is only valid until the next call
+	// to a method of the parser.
+	Buffered() io.Reader
+}
+
+// The LimitParser interface may be implemented by parsers of formats where
+// strings and byte sequences are prefixed with their lengths.
+//
+// Parsers created by ParserConfig.NewParser use it to reject the strings that
+// exceed the MaxStringLen limit when they read their length prefixes, instead
+// of loading them in memory.
+type LimitParser interface {
+	// SetMaxStringLen sets the maximum length of strings and byte sequences,
+	// zero means no limit. The parser returns errors of kind ErrLimit for the
+	// strings that exceed it.
+	SetMaxStringLen(n int)
+}
+
+// The NumberParser interface may be implemented by parsers of formats which
+// represent numbers as text.
+//
+// Decoders with the UseNumber option use it to produce Number values that keep
+// the exact representation of the numbers in the input.
+type NumberParser interface {
+	// ParseNumber is called instead of ParseInt, ParseUint or ParseFloat to
+	// parse the next number, it returns the text of the number. The returned
+	// slice is only valid until the next call to the parser.
+	ParseNumber() ([]byte, error)
+}
+
+// The ValueTypeParser interface may be implemented by parsers of formats which
+// carry information about the Go types of values, like the tags of CBOR.
+//
+// Decoders use it when the destination of a value is an empty interface, to
+// produce a value of the type given by the parser instead of the default type
+// for the objconv type of the value.
+type ValueTypeParser interface {
+	// ValueType is called after ParseType, it returns the Go type of the
+	// next value, or nil if the parser has no type information about it.
+	ValueType() reflect.Type
+}
+
+// The UntypedParser interface may be implemented by parsers of formats which
+// represent all scalar values as strings and can't tell single values from
+// arrays of one element, like XML.
+//
+// Decoders use it to decode booleans from strings, and values which aren't
+// arrays into slices and arrays of one element. Numbers, times and durations
+// are always decoded from strings. Parsers implementing the interface must
+// return the same type when ParseType is called again before the value was
+// parsed.
+type UntypedParser interface {
+	// UntypedParser returns true if the parser produces untyped values.
+	UntypedParser() bool
+}
+
+func isUntypedParser(parser Parser) bool {
+	p, _ := parser.(UntypedParser)
+	return p != nil && p.UntypedParser()
+}
+
+// Position is a location in the input of a parser of a text format, lines and
+// columns start at 1 and columns are counted in bytes.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// String returns p formatted as "line:column".
+func (p Position) String() string {
+	return strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+}
+
+// The PositionParser interface may be implemented by parsers of text formats
+// that keep track of the lines and columns of the values they parse.
+//
+// Decoders use it to report where the fields they decode are located when
+// their Positions field is set.
+type PositionParser interface {
+	// Position returns the position of the next key or value that the parser
+	// will produce.
+	Position() Position
+}
+
+// The ResyncParser interface may be implemented by parsers of formats where
+// records are separated by boundaries that can be found without parsing the
+// records, like newlines in newline-delimited JSON.
+//
+// Stream decoders configured to read sequences use it to recover from parse
+// errors when their Resync field is set.
+type ResyncParser interface {
+	OffsetParser
+
+	// Resync discards the input up to and including the next record boundary.
+	//
+	// The method returns io.EOF if the end of the input was reached before
+	// finding a boundary.
+	Resync() error
+}
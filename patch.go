@@ -0,0 +1,114 @@
+package objconv
+
+import (
+	"bytes"
+	"reflect"
+	"time"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// DiffPatch returns a JSON merge patch (RFC 7386) which transforms old into
+// new, it only contains the fields that differ between the two values.
+//
+// The values are compared in their encoded form, so struct tags are honored:
+// fields are named after their tags, and omitted fields are considered absent.
+// Fields which are absent from new are set to null in the patch, which is how
+// merge patches remove fields. As a consequence, fields set to nil in new are
+// removed as well, merge patches cannot represent null values. Arrays are not
+// merged, they are replaced entirely when they differ.
+//
+// The patch is encoded with the codec registered for "application/json", so
+// the program must import the objconv/json package.
+func DiffPatch(old, new interface{}) (RawMessage, error) {
+	codec, ok := Lookup("application/json")
+	if !ok {
+		return nil, objutil.Errorf(objutil.ErrType, "objconv: generating patches requires the json codec, the objconv/json package must be imported")
+	}
+
+	a, err := encodedValue(old)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := encodedValue(new)
+	if err != nil {
+		return nil, err
+	}
+
+	var patch interface{}
+
+	m1, ok1 := a.(map[interface{}]interface{})
+	m2, ok2 := b.(map[interface{}]interface{})
+
+	if ok1 && ok2 {
+		patch = diffMaps(m1, m2)
+	} else {
+		patch = b
+	}
+
+	buf := &bytes.Buffer{}
+	enc := codec.NewEncoder(buf)
+	enc.SortMapKeys = true
+
+	if err := enc.Encode(patch); err != nil {
+		return nil, err
+	}
+
+	return RawMessage(bytes.TrimSpace(buf.Bytes())), nil
+}
+
+func encodedValue(v interface{}) (interface{}, error) {
+	e := NewValueEmitter()
+
+	if err := NewEncoder(e).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return e.Value(), nil
+}
+
+// diffMaps returns the merge patch turning a into b.
+func diffMaps(a, b map[interface{}]interface{}) map[interface{}]interface{} {
+	patch := map[interface{}]interface{}{}
+
+	for k, v1 := range a {
+		if v2, ok := b[k]; v1 != nil && (!ok || v2 == nil) {
+			patch[k] = nil
+		}
+	}
+
+	for k, v2 := range b {
+		v1, ok := a[k]
+
+		switch {
+		case v2 == nil:
+			// removals were handled by the other loop
+
+		case !ok:
+			patch[k] = v2
+
+		default:
+			m1, ok1 := v1.(map[interface{}]interface{})
+			m2, ok2 := v2.(map[interface{}]interface{})
+
+			if ok1 && ok2 {
+				if sub := diffMaps(m1, m2); len(sub) != 0 {
+					patch[k] = sub
+				}
+			} else if !equalValues(v1, v2) {
+				patch[k] = v2
+			}
+		}
+	}
+
+	return patch
+}
+
+func equalValues(a, b interface{}) bool {
+	if t1, ok := a.(time.Time); ok {
+		t2, ok := b.(time.Time)
+		return ok && t1.Equal(t2)
+	}
+	return reflect.DeepEqual(a, b)
+}
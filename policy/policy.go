@@ -0,0 +1,140 @@
+// Package policy applies serialization policies loaded from configuration to
+// the struct types of a program.
+//
+// Policies let operators adjust the output of a program without deploying new
+// code, for example to hide a field that leaks sensitive data or to rename a
+// field expected under a different name by a consumer. A policy file lists the
+// changes per type, fields are referenced by their serialized names:
+//
+//	{
+//	  "types": {
+//	    "users.User": {
+//	      "rename": {"mail": "email"},
+//	      "redact": ["password"],
+//	      "omit":   ["ssn"]
+//	    }
+//	  }
+//	}
+//
+// The policy is loaded at startup and applied by installing objconv field
+// policies for the types it references (see objconv.InstallFieldPolicies),
+// which are layered over the regular struct fields, so tag options like
+// encrypt or inline keep applying to the fields of these types:
+//
+//	p, err := policy.LoadFile("/etc/service/policy.json")
+//	...
+//	err = p.Install(users.User{}, users.Group{})
+//
+// Fields that a policy removes from the output (redacted or omitted fields)
+// are also ignored when decoding, and renamed fields are accepted under both
+// their original and new names.
+package policy
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/segmentio/objconv"
+)
+
+// Redacted is the value that replaces redacted fields in the output.
+const Redacted = "REDACTED"
+
+// Policy is a set of serialization policies indexed by type name.
+type Policy struct {
+	// Types maps type names to their policy. Names are either the qualified
+	// name of the type in its package (like "users.User"), or its full import
+	// path (like "github.com/acme/service/users.User").
+	Types map[string]TypePolicy `objconv:"types"`
+}
+
+// TypePolicy is the serialization policy of a single struct type.
+type TypePolicy struct {
+	// Rename maps the serialized names of fields to the names they are given
+	// in the output.
+	Rename map[string]string `objconv:"rename"`
+
+	// Redact lists the fields whose values are replaced with Redacted.
+	Redact []string `objconv:"redact"`
+
+	// Omit lists the fields that are removed from the output.
+	Omit []string `objconv:"omit"`
+}
+
+// Load reads a policy from r with codec.
+func Load(r io.Reader, codec objconv.Codec) (*Policy, error) {
+	p := &Policy{}
+
+	if err := codec.NewDecoder(r).Decode(p); err != nil {
+		return nil, fmt.Errorf("objconv/policy: loading policy: %s", err)
+	}
+
+	return p, nil
+}
+
+// LoadFile reads a policy from the file at path, the codec is selected from
+// the file extension in the global objconv registry (".json", ".yaml", ...).
+func LoadFile(path string) (*Policy, error) {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	codec, ok := objconv.Lookup(ext)
+
+	if !ok {
+		return nil, fmt.Errorf("objconv/policy: no codec found for policy file %s", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f, codec)
+}
+
+// Install applies p to types, which are values of the struct types (or pointers
+// to them) that the policy may reference.
+//
+// The method returns an error, without installing anything, if the policy
+// references types that are not in the list or fields that don't exist.
+// Because installing policies is not safe while values are being encoded or
+// decoded, it must be called during the program initialization.
+func (p *Policy) Install(types ...interface{}) error {
+	byName := make(map[string]reflect.Type, 2*len(types))
+
+	for _, v := range types {
+		t := reflect.TypeOf(v)
+
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		if t == nil || t.Kind() != reflect.Struct {
+			return fmt.Errorf("objconv/policy: %T is not a struct type", v)
+		}
+
+		byName[t.String()] = t
+		byName[t.PkgPath()+"."+t.Name()] = t
+	}
+
+	policies := make(map[reflect.Type]objconv.FieldPolicy, len(p.Types))
+
+	for name, tp := range p.Types {
+		t, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("objconv/policy: the policy references type %s which is not in the list of installable types", name)
+		}
+
+		policies[t] = objconv.FieldPolicy{
+			Rename:      tp.Rename,
+			Redact:      tp.Redact,
+			Omit:        tp.Omit,
+			Placeholder: Redacted,
+		}
+	}
+
+	return objconv.InstallFieldPolicies(policies)
+}
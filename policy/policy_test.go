@@ -0,0 +1,247 @@
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/json"
+)
+
+type account struct {
+	ID       int    `objconv:"id"`
+	Mail     string `objconv:"mail"`
+	Password string `objconv:"password"`
+	SSN      string `objconv:"ssn,omitempty"`
+	Note     string `objconv:"note,omitempty"`
+}
+
+func TestPolicy(t *testing.T) {
+	p, err := Load(strings.NewReader(`{
+  "types": {
+    "policy.account": {
+      "rename": {"mail": "email"},
+      "redact": ["password"],
+      "omit":   ["ssn"]
+    }
+  }
+}`), json.Codec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Install(account{}); err != nil {
+		t.Fatal(err)
+	}
+
+	a := account{ID: 1, Mail: "me@example.com", Password: "secret", SSN: "000-00-0000"}
+	b := &bytes.Buffer{}
+
+	if err := json.NewEncoder(b).Encode(&a); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := b.String(); s != `{"id":1,"email":"me@example.com","password":"REDACTED"}` {
+		t.Error("bad output:", s)
+	}
+
+	var v account
+
+	if err := json.NewDecoder(b).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(v, account{ID: 1, Mail: "me@example.com"}) {
+		t.Errorf("bad value: %#v", v)
+	}
+
+	if err := json.NewDecoder(strings.NewReader(`{"mail":"other@example.com"}`)).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Mail != "other@example.com" {
+		t.Error("the original name of a renamed field was not accepted:", v.Mail)
+	}
+}
+
+func TestPolicyErrors(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		types  []interface{}
+	}{
+		{Policy{Types: map[string]TypePolicy{"policy.missing": {}}}, []interface{}{account{}}},
+		{Policy{Types: map[string]TypePolicy{"policy.account": {Omit: []string{"Mail"}}}}, []interface{}{account{}}},
+		{Policy{}, []interface{}{42}},
+	}
+
+	for _, test := range tests {
+		if err := test.policy.Install(test.types...); err == nil {
+			t.Errorf("no error returned when installing %+v", test.policy)
+		}
+	}
+}
+
+type profile struct {
+	Bio string `objconv:"bio"`
+}
+
+type member struct {
+	ID      int     `objconv:"id"`
+	Profile profile `objconv:",inline"`
+	secret  string  `objconv:"secret,export"`
+	Mail    string  `objconv:"mail" db:"email"`
+	Age     int     `objconv:"age"`
+}
+
+func (m *member) SetAge(age int) error {
+	if age < 0 {
+		return errors.New("negative age")
+	}
+	m.Age = age
+	return nil
+}
+
+func (m *member) Secret() string { return m.secret }
+
+func (m *member) SetSecret(s string) { m.secret = s }
+
+func (m member) Label() string { return fmt.Sprint("member-", m.ID) }
+
+func init() {
+	objconv.InstallMethodField(reflect.TypeOf(member{}), "label", "Label")
+}
+
+func TestPolicyStructFields(t *testing.T) {
+	p := Policy{Types: map[string]TypePolicy{
+		"policy.member": {
+			Rename: map[string]string{"bio": "about", "secret": "token"},
+			Redact: []string{"mail"},
+			Omit:   []string{"label"},
+		},
+	}}
+
+	if err := p.Install(member{}); err != nil {
+		t.Fatal(err)
+	}
+
+	m := member{ID: 1, Profile: profile{Bio: "hi"}, secret: "s", Mail: "me@example.com", Age: 42}
+	b := &bytes.Buffer{}
+
+	if err := json.NewEncoder(b).Encode(&m); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := b.String(); s != `{"id":1,"about":"hi","token":"s","mail":"REDACTED","age":42}` {
+		t.Error("bad output:", s)
+	}
+
+	var v member
+	d := json.NewDecoder(b)
+	d.DisallowUnknownFields = true
+
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(v, member{ID: 1, Profile: profile{Bio: "hi"}, secret: "s", Age: 42}) {
+		t.Errorf("bad value: %#v", v)
+	}
+
+	d = json.NewDecoder(strings.NewReader(`{"bio":"hello","label":"x"}`))
+	d.DisallowUnknownFields = true
+
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Profile.Bio != "hello" {
+		t.Error("the original name of a renamed inline field was not accepted:", v.Profile.Bio)
+	}
+
+	if err := json.NewDecoder(strings.NewReader(`{"age":-1}`)).Decode(&v); err == nil || !strings.Contains(err.Error(), "negative age") {
+		t.Error("the setter of a field of a type with a policy was not called:", err)
+	}
+
+	if err := json.NewDecoder(strings.NewReader(`{"other":1}`)).Decode(&v); err != nil {
+		t.Error(err)
+	}
+
+	d = json.NewDecoder(strings.NewReader(`{"other":1}`))
+	d.DisallowUnknownFields = true
+
+	if err := d.Decode(&v); !errors.Is(err, objconv.ErrUnknownField) {
+		t.Error("bad error:", err)
+	}
+
+	b.Reset()
+	e := json.NewEncoder(b)
+	e.TagNames = []string{"db"}
+	e.FieldNaming = objconv.SnakeCase
+
+	if err := e.Encode(&m); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := b.String(); s != `{"id":1,"profile":{"bio":"hi"},"email":"REDACTED","age":42}` {
+		t.Error("bad output with other tag names:", s)
+	}
+}
+
+type citizen struct {
+	Name string `objconv:"name"`
+	SSN  string `objconv:"ssn,encrypt"`
+	Tax  string `objconv:"tax,encrypt"`
+}
+
+func TestPolicyEncryptedFields(t *testing.T) {
+	p := Policy{Types: map[string]TypePolicy{
+		"policy.citizen": {
+			Rename: map[string]string{"ssn": "social_security_number"},
+			Redact: []string{"tax"},
+		},
+	}}
+
+	if err := p.Install(citizen{}); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := objconv.Keyring{Primary: "k", Keys: map[string][]byte{"k": bytes.Repeat([]byte{1}, 16)}}
+	c := citizen{Name: "Luke", SSN: "078-05-1120", Tax: "T-1234"}
+	b := &bytes.Buffer{}
+
+	if err := json.NewEncoder(b).Encode(&c); err == nil {
+		t.Error("no error returned when encoding an encrypted field without keys:", b.String())
+	}
+
+	b.Reset()
+	e := json.NewEncoder(b)
+	e.Keys = keys
+
+	if err := e.Encode(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := b.String(); strings.Contains(s, c.SSN) || strings.Contains(s, c.Tax) || !strings.Contains(s, `"social_security_number":"`) {
+		t.Error("the output has encrypted fields in plaintext:", s)
+	}
+
+	var v citizen
+	d := json.NewDecoder(b)
+	d.Keys = keys
+
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if v != (citizen{Name: "Luke", SSN: "078-05-1120"}) {
+		t.Errorf("bad value: %#v", v)
+	}
+
+	if err := objconv.Transcode(json.NewEmitter(b), objconv.NewValueParser(c)); !errors.Is(err, objconv.ErrType) {
+		t.Error("bad error:", err)
+	}
+}
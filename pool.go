@@ -0,0 +1,142 @@
+package objconv
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+)
+
+// A WorkerPool encodes and decodes values with a codec, bounding the number of
+// operations that happen concurrently and reusing the emitters and parsers
+// that it creates.
+//
+// Programs that have to serialize values at a high rate, like network servers,
+// can use a worker pool to get a predictable memory usage, the pool never holds
+// more emitters and parsers than its concurrency level.
+//
+// It is safe to use a worker pool concurrently from multiple goroutines.
+type WorkerPool struct {
+	codec   Codec
+	workers chan *worker
+}
+
+type worker struct {
+	r bytes.Reader
+	b bytes.Buffer
+	p Parser
+	e Emitter
+}
+
+type parserResetter interface {
+	Reset(io.Reader)
+}
+
+type emitterResetter interface {
+	Reset(io.Writer)
+}
+
+// NewWorkerPool returns a new worker pool running at most n operations
+// concurrently with codec. If n is zero or negative the concurrency level is
+// set to runtime.GOMAXPROCS(0).
+func NewWorkerPool(codec Codec, n int) *WorkerPool {
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+
+	pool := &WorkerPool{
+		codec:   codec,
+		workers: make(chan *worker, n),
+	}
+
+	for i := 0; i != n; i++ {
+		pool.workers <- &worker{}
+	}
+
+	return pool
+}
+
+// Concurrency returns the maximum number of operations that the pool runs
+// concurrently.
+func (pool *WorkerPool) Concurrency() int {
+	return cap(pool.workers)
+}
+
+// Encode returns the encoded representation of v, the call blocks until a
+// worker is available in the pool.
+func (pool *WorkerPool) Encode(v interface{}) (b []byte, err error) {
+	w := <-pool.workers
+	w.b.Reset()
+
+	if err = (Encoder{Emitter: pool.emitter(w, &w.b)}).Encode(v); err == nil {
+		b = make([]byte, w.b.Len())
+		copy(b, w.b.Bytes())
+	}
+
+	pool.release(w)
+	return
+}
+
+// EncodeTo writes the encoded representation of v to out, the call blocks
+// until a worker is available in the pool.
+func (pool *WorkerPool) EncodeTo(out io.Writer, v interface{}) (err error) {
+	w := <-pool.workers
+	err = (Encoder{Emitter: pool.emitter(w, out)}).Encode(v)
+	pool.release(w)
+	return
+}
+
+// Decode decodes the value encoded in b into v, the call blocks until a worker
+// is available in the pool.
+func (pool *WorkerPool) Decode(b []byte, v interface{}) (err error) {
+	w := <-pool.workers
+	w.r.Reset(b)
+	err = (Decoder{Parser: pool.parser(w, &w.r)}).Decode(v)
+	w.r.Reset(nil)
+	pool.release(w)
+	return
+}
+
+// DecodeFrom decodes the value read from in into v, the call blocks until a
+// worker is available in the pool.
+//
+// The parsers of the pool are not bound to the readers they decode from, and
+// may read past the end of the values. in must hold a single value, the method
+// reads it to the end and returns an error if it has data after the value.
+// Programs that decode sequences of values from a reader should use a
+// StreamDecoder instead.
+func (pool *WorkerPool) DecodeFrom(in io.Reader, v interface{}) (err error) {
+	w := <-pool.workers
+	err = (Decoder{Parser: pool.parser(w, in), DisallowTrailingData: true}).Decode(v)
+	pool.release(w)
+	return
+}
+
+func (pool *WorkerPool) emitter(w *worker, out io.Writer) Emitter {
+	if r, ok := w.e.(emitterResetter); ok {
+		r.Reset(out)
+	} else {
+		w.e = pool.codec.NewEmitter(out)
+	}
+	return w.e
+}
+
+func (pool *WorkerPool) parser(w *worker, in io.Reader) Parser {
+	if r, ok := w.p.(parserResetter); ok {
+		r.Reset(in)
+	} else {
+		w.p = pool.codec.NewParser(in)
+	}
+	return w.p
+}
+
+func (pool *WorkerPool) release(w *worker) {
+	// Drop the references to the reader and writer of the last operation so
+	// they can be garbage collected while the worker is idle.
+	if r, ok := w.e.(emitterResetter); ok {
+		r.Reset(nil)
+	}
+	if r, ok := w.p.(parserResetter); ok {
+		r.Reset(nil)
+	}
+	pool.workers <- w
+}
@@ -0,0 +1,95 @@
+package objconv_test
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/json"
+)
+
+func TestWorkerPool(t *testing.T) {
+	type T struct {
+		A int
+		B string
+	}
+
+	pool := objconv.NewWorkerPool(json.Codec, 2)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i != 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			v1 := T{A: i, B: "Hello World!"}
+			v2 := T{}
+
+			b, err := pool.Encode(v1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			if err := pool.Decode(b, &v2); err != nil {
+				t.Error(err)
+				return
+			}
+
+			if !reflect.DeepEqual(v1, v2) {
+				t.Errorf("%#v != %#v", v1, v2)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+func TestWorkerPoolStreams(t *testing.T) {
+	pool := objconv.NewWorkerPool(json.Codec, 1)
+	buf := &bytes.Buffer{}
+
+	if err := pool.EncodeTo(buf, []int{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	var v []int
+	if err := pool.DecodeFrom(buf, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(v, []int{1, 2, 3}) {
+		t.Error(v)
+	}
+}
+
+func TestWorkerPoolDecodeFromTrailingData(t *testing.T) {
+	pool := objconv.NewWorkerPool(json.Codec, 1)
+	buf := bytes.NewBufferString("1 2")
+
+	var v int
+	if err := pool.DecodeFrom(buf, &v); err == nil {
+		t.Error("no error returned when decoding a reader holding multiple values")
+	}
+
+	if err := pool.DecodeFrom(buf, &v); err != io.EOF {
+		t.Error("bad error:", err)
+	}
+
+	if err := pool.DecodeFrom(bytes.NewBufferString("1\n"), &v); err != nil || v != 1 {
+		t.Errorf("bad value: %d (%v)", v, err)
+	}
+}
+
+func TestWorkerPoolConcurrency(t *testing.T) {
+	if n := objconv.NewWorkerPool(json.Codec, 3).Concurrency(); n != 3 {
+		t.Error("bad concurrency level:", n)
+	}
+
+	if n := objconv.NewWorkerPool(json.Codec, 0).Concurrency(); n <= 0 {
+		t.Error("bad default concurrency level:", n)
+	}
+}
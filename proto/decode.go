@@ -0,0 +1,427 @@
+package proto
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"reflect"
+
+	"github.com/segmentio/objconv/objutil"
+	"github.com/segmentio/objconv/wire"
+)
+
+// maxMessageSize is the default limit on the size of messages, which is the
+// limit of the protobuf implementations.
+const maxMessageSize = 1<<31 - 1
+
+// Decoder reads protobuf messages from an input stream.
+type Decoder struct {
+	// When set, messages are read from a stream where they are preceded by
+	// their length encoded as a varint, see Encoder.Delimited. Otherwise the
+	// whole input is decoded as a single message.
+	Delimited bool
+
+	// MaxSize limits the size of messages, which protects programs from
+	// allocating large amounts of memory on malformed input. Zero means the
+	// 2 GiB limit of the protobuf implementations.
+	MaxSize int
+
+	r    *bufio.Reader
+	b    []byte
+	done bool
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next message from the input stream into v, which must be a
+// pointer to a struct. The fields of v are reset before the message is decoded.
+//
+// The method returns io.EOF when the end of the input is reached, messages
+// truncated by the end of the input are syntax errors.
+func (d *Decoder) Decode(v interface{}) error {
+	rv, m, err := target(v)
+	if err != nil {
+		return err
+	}
+
+	b, err := d.read()
+	if err != nil {
+		return err
+	}
+
+	rv.Set(reflect.Zero(rv.Type()))
+	return decodeMessage(b, m, rv)
+}
+
+func (d *Decoder) read() ([]byte, error) {
+	max := d.MaxSize
+
+	if max <= 0 {
+		max = maxMessageSize
+	}
+
+	if !d.Delimited {
+		if d.done {
+			return nil, io.EOF
+		}
+		d.done = true
+
+		b, err := io.ReadAll(io.LimitReader(d.r, int64(max)+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > max {
+			return nil, objutil.Errorf(objutil.ErrLimit, "objconv/proto: the message exceeds the limit of %d bytes", max)
+		}
+		return b, nil
+	}
+
+	size, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	if size > uint64(max) {
+		return nil, objutil.Errorf(objutil.ErrLimit, "objconv/proto: the message length %d exceeds the limit of %d bytes", size, max)
+	}
+
+	if n := int(size); cap(d.b) < n {
+		d.b = make([]byte, n)
+	} else {
+		d.b = d.b[:n]
+	}
+
+	if _, err := io.ReadFull(d.r, d.b); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/proto: truncated message, expected %d bytes", size)
+		}
+		return nil, err
+	}
+
+	return d.b, nil
+}
+
+// readLength reads the varint prefix of the next message, it returns io.EOF
+// when the input ends before the prefix.
+func (d *Decoder) readLength() (uint64, error) {
+	var size uint64
+
+	for i := 0; i != wire.MaxVarintLen; i++ {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			if err == io.EOF && i != 0 {
+				err = objutil.Errorf(objutil.ErrSyntax, "objconv/proto: truncated message length")
+			}
+			return 0, err
+		}
+
+		size |= uint64(c&0x7F) << (7 * uint(i))
+
+		if c < 0x80 {
+			return size, nil
+		}
+	}
+
+	return 0, objutil.Errorf(objutil.ErrRange, "objconv/proto: the message length overflows 64 bits")
+}
+
+// Unmarshal decodes the protobuf message in b into v, which must be a pointer
+// to a struct. The fields of v are reset before the message is decoded.
+func Unmarshal(b []byte, v interface{}) error {
+	rv, m, err := target(v)
+	if err != nil {
+		return err
+	}
+
+	rv.Set(reflect.Zero(rv.Type()))
+	return decodeMessage(b, m, rv)
+}
+
+func target(v interface{}) (reflect.Value, *message, error) {
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return rv, nil, objutil.Errorf(objutil.ErrType, "objconv/proto: cannot decode into a value of type %T", v)
+	}
+
+	rv = rv.Elem()
+	m, err := messageOf(rv.Type())
+	return rv, m, err
+}
+
+func decodeMessage(b []byte, m *message, v reflect.Value) error {
+	for len(b) != 0 {
+		key, n, err := wire.ConsumeUvarint(b)
+		if err != nil {
+			return err
+		}
+		b = b[n:]
+
+		number, wireType := key>>3, int(key&7)
+
+		if number == 0 {
+			return objutil.Errorf(objutil.ErrSyntax, "objconv/proto: invalid field number 0")
+		}
+
+		if f := m.field(number); f != nil {
+			n, err = decodeField(b, f, v.Field(f.index), wireType)
+		} else {
+			n, err = skipValue(b, wireType)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		b = b[n:]
+	}
+
+	return nil
+}
+
+// skipValue returns the length of the value of wire type at the beginning of
+// b, which belongs to a field unknown to the message.
+func skipValue(b []byte, wireType int) (int, error) {
+	switch wireType {
+	case wireVarint:
+		_, n, err := wire.ConsumeUvarint(b)
+		return n, err
+	case wireBytes:
+		_, n, err := wire.ConsumeBytes(b)
+		return n, err
+	case wireFixed32:
+		return 4, checkFixed(b, 4)
+	case wireFixed64:
+		return 8, checkFixed(b, 8)
+	default:
+		return 0, objutil.Errorf(objutil.ErrSyntax, "objconv/proto: unsupported wire type %d", wireType)
+	}
+}
+
+func decodeField(b []byte, f *field, v reflect.Value, wireType int) (int, error) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(b, f, v.Elem(), wireType)
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return decodeRepeated(b, f, v, wireType)
+		}
+
+	case reflect.Map:
+		return decodeEntry(b, f, v, wireType)
+	}
+
+	return decodeValue(b, f, v, wireType)
+}
+
+func decodeValue(b []byte, f *field, v reflect.Value, wireType int) (int, error) {
+	if want := f.wireType(v.Type()); wireType != want {
+		return 0, objutil.Errorf(objutil.ErrType, "objconv/proto: %s field: found wire type %d instead of %d", f.name, wireType, want)
+	}
+
+	if v.Kind() != reflect.Struct {
+		return decodeScalar(b, f, v)
+	}
+
+	// Like in protobuf implementations, multiple occurrences of a message
+	// field are merged.
+	p, n, err := wire.ConsumeBytes(b)
+	if err == nil {
+		err = decodeMessage(p, f.msg, v)
+	}
+	return n, err
+}
+
+// decodeScalar decodes the scalar value of the field f at the beginning of b
+// into v, the wire type of the value is implied by the type of v.
+func decodeScalar(b []byte, f *field, v reflect.Value) (int, error) {
+	t := v.Type()
+
+	switch v.Kind() {
+	case reflect.String:
+		s, n, err := wire.ConsumeString(b)
+		if err == nil {
+			v.SetString(s)
+		}
+		return n, err
+
+	case reflect.Slice: // []byte
+		p, n, err := wire.ConsumeBytes(b)
+		if err == nil {
+			v.SetBytes(append([]byte{}, p...))
+		}
+		return n, err
+	}
+
+	u, n, err := readNumber(b, f.wireType(t))
+	if err != nil {
+		return n, err
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(u != 0)
+
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		var x int64
+
+		switch {
+		case f.zigzag:
+			x = wire.DecodeZigZag(u)
+		case t.Size() == 4:
+			// Varints of int32 fields are sign-extended to 64 bits, they
+			// are truncated like protobuf implementations do.
+			x = int64(int32(u))
+		default:
+			x = int64(u)
+		}
+
+		if v.OverflowInt(x) {
+			return n, objutil.Errorf(objutil.ErrRange, "objconv/proto: %s field: the value %d overflows %s", f.name, x, t)
+		}
+		v.SetInt(x)
+
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		if t.Size() == 4 {
+			u = uint64(uint32(u))
+		}
+		if v.OverflowUint(u) {
+			return n, objutil.Errorf(objutil.ErrRange, "objconv/proto: %s field: the value %d overflows %s", f.name, u, t)
+		}
+		v.SetUint(u)
+
+	case reflect.Float32:
+		v.SetFloat(float64(math.Float32frombits(uint32(u))))
+
+	case reflect.Float64:
+		v.SetFloat(math.Float64frombits(u))
+	}
+
+	return n, nil
+}
+
+// decodeRepeated appends the values at the beginning of b to the slice v, in
+// the packed or unpacked encoding.
+func decodeRepeated(b []byte, f *field, v reflect.Value, wireType int) (int, error) {
+	t := v.Type().Elem()
+
+	if isPackable(t) && wireType == wireBytes {
+		p, n, err := wire.ConsumeBytes(b)
+		if err != nil {
+			return n, err
+		}
+
+		for len(p) != 0 {
+			e := reflect.New(t).Elem()
+
+			k, err := decodeScalar(p, f, e)
+			if err != nil {
+				return n, err
+			}
+
+			p = p[k:]
+			v.Set(reflect.Append(v, e))
+		}
+
+		return n, nil
+	}
+
+	e := reflect.New(t).Elem()
+	x := e
+
+	if t.Kind() == reflect.Ptr {
+		e.Set(reflect.New(t.Elem()))
+		x = e.Elem()
+	}
+
+	n, err := decodeValue(b, f, x, wireType)
+	if err == nil {
+		v.Set(reflect.Append(v, e))
+	}
+	return n, err
+}
+
+// decodeEntry decodes the map entry at the beginning of b into the map v.
+func decodeEntry(b []byte, f *field, v reflect.Value, wireType int) (int, error) {
+	if wireType != wireBytes {
+		return 0, objutil.Errorf(objutil.ErrType, "objconv/proto: %s field: found wire type %d instead of %d", f.name, wireType, wireBytes)
+	}
+
+	p, n, err := wire.ConsumeBytes(b)
+	if err != nil {
+		return n, err
+	}
+
+	t := v.Type()
+	key := reflect.New(t.Key()).Elem()
+	val := reflect.New(t.Elem()).Elem()
+
+	if val.Kind() == reflect.Ptr {
+		// Entries without values hold empty messages.
+		val.Set(reflect.New(t.Elem().Elem()))
+	}
+
+	for len(p) != 0 {
+		tag, k, err := wire.ConsumeUvarint(p)
+		if err != nil {
+			return n, err
+		}
+		p = p[k:]
+
+		switch number, wireType := tag>>3, int(tag&7); number {
+		case 1:
+			k, err = decodeValue(p, f.key, key, wireType)
+		case 2:
+			k, err = decodeField(p, f.val, val, wireType)
+		default:
+			k, err = skipValue(p, wireType)
+		}
+
+		if err != nil {
+			return n, err
+		}
+
+		p = p[k:]
+	}
+
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(t))
+	}
+
+	v.SetMapIndex(key, val)
+	return n, nil
+}
+
+func readNumber(b []byte, wireType int) (uint64, int, error) {
+	switch wireType {
+	case wireFixed32:
+		if err := checkFixed(b, 4); err != nil {
+			return 0, 0, err
+		}
+		return uint64(binary.LittleEndian.Uint32(b)), 4, nil
+
+	case wireFixed64:
+		if err := checkFixed(b, 8); err != nil {
+			return 0, 0, err
+		}
+		return binary.LittleEndian.Uint64(b), 8, nil
+
+	default:
+		return wire.ConsumeUvarint(b)
+	}
+}
+
+func checkFixed(b []byte, size int) error {
+	if len(b) < size {
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/proto: truncated fixed%d value", 8*size)
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package proto
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/segmentio/objconv/objutil"
+	"github.com/segmentio/objconv/wire"
+)
+
+// Encoder writes protobuf messages to an output stream.
+type Encoder struct {
+	// When set, messages are preceded by their length encoded as a varint,
+	// which lets programs write multiple messages to the same stream, like
+	// the writeDelimitedTo methods of the protobuf libraries.
+	Delimited bool
+
+	w io.Writer
+	b []byte
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes v, which is a struct or a pointer to a struct, as a message to
+// the output stream of the encoder.
+func (e *Encoder) Encode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr {
+		return objutil.Errorf(objutil.ErrType, "objconv/proto: cannot encode a nil message")
+	}
+
+	m, err := messageOf(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	encode := func(b []byte) ([]byte, error) { return encodeMessage(b, m, rv) }
+
+	if e.Delimited {
+		e.b, err = appendNested(e.b[:0], encode)
+	} else {
+		e.b, err = encode(e.b[:0])
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(e.b)
+	return err
+}
+
+// Marshal returns the protobuf encoding of v, which is a struct or a pointer
+// to a struct.
+func Marshal(v interface{}) (b []byte, err error) {
+	buf := &bytes.Buffer{}
+
+	if err = NewEncoder(buf).Encode(v); err == nil {
+		b = buf.Bytes()
+	}
+
+	return
+}
+
+func encodeMessage(b []byte, m *message, v reflect.Value) ([]byte, error) {
+	var err error
+
+	for i := range m.fields {
+		f := &m.fields[i]
+
+		if b, err = encodeField(b, f, v.Field(f.index)); err != nil {
+			return b, err
+		}
+	}
+
+	return b, nil
+}
+
+// encodeField appends the field f holding v to b, fields holding zero values
+// are omitted.
+func encodeField(b []byte, f *field, v reflect.Value) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return b, nil
+		}
+		return appendValue(b, f, v.Elem())
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return encodeRepeated(b, f, v)
+		}
+		if v.Len() == 0 {
+			return b, nil
+		}
+
+	case reflect.Map:
+		return encodeMap(b, f, v)
+
+	default:
+		if v.IsZero() {
+			return b, nil
+		}
+	}
+
+	return appendValue(b, f, v)
+}
+
+// appendValue appends the field f holding v to b, even if v is a zero value.
+func appendValue(b []byte, f *field, v reflect.Value) ([]byte, error) {
+	if v.Kind() == reflect.Struct {
+		b = wire.AppendUvarint(b, f.tag(wireBytes))
+		return appendNested(b, func(b []byte) ([]byte, error) { return encodeMessage(b, f.msg, v) })
+	}
+
+	b = wire.AppendUvarint(b, f.tag(f.wireType(v.Type())))
+	return appendScalar(b, f, v), nil
+}
+
+// appendScalar appends the scalar value v of the field f to b, without the key
+// of the field.
+func appendScalar(b []byte, f *field, v reflect.Value) []byte {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 1)
+		}
+		return append(b, 0)
+
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		switch x := v.Int(); {
+		case f.zigzag:
+			return wire.AppendUvarint(b, wire.EncodeZigZag(x))
+		case f.fixed:
+			return appendFixed(b, int(v.Type().Size()), uint64(x))
+		default:
+			// Negative numbers are sign-extended to 64 bits, like protobuf
+			// implementations do for int32 values.
+			return wire.AppendUvarint(b, uint64(x))
+		}
+
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		if f.fixed {
+			return appendFixed(b, int(v.Type().Size()), v.Uint())
+		}
+		return wire.AppendUvarint(b, v.Uint())
+
+	case reflect.Float32:
+		return appendFixed(b, 4, uint64(math.Float32bits(float32(v.Float()))))
+
+	case reflect.Float64:
+		return appendFixed(b, 8, math.Float64bits(v.Float()))
+
+	case reflect.String:
+		return wire.AppendString(b, v.String())
+
+	default: // []byte
+		return wire.AppendBytes(b, v.Bytes())
+	}
+}
+
+func encodeRepeated(b []byte, f *field, v reflect.Value) ([]byte, error) {
+	n := v.Len()
+
+	if n == 0 {
+		return b, nil
+	}
+
+	if isPackable(v.Type().Elem()) {
+		b = wire.AppendUvarint(b, f.tag(wireBytes))
+		return appendNested(b, func(b []byte) ([]byte, error) {
+			for i := 0; i != n; i++ {
+				b = appendScalar(b, f, v.Index(i))
+			}
+			return b, nil
+		})
+	}
+
+	var err error
+
+	for i := 0; i != n; i++ {
+		e := v.Index(i)
+
+		if e.Kind() == reflect.Ptr {
+			if e.IsNil() {
+				return b, objutil.Errorf(objutil.ErrType, "objconv/proto: %s field: repeated messages cannot be nil", f.name)
+			}
+			e = e.Elem()
+		}
+
+		if b, err = appendValue(b, f, e); err != nil {
+			return b, err
+		}
+	}
+
+	return b, nil
+}
+
+// encodeMap appends the entries of the map v as messages holding their keys
+// and values, in the order of their keys.
+func encodeMap(b []byte, f *field, v reflect.Value) ([]byte, error) {
+	if v.Len() == 0 {
+		return b, nil
+	}
+
+	keys := v.MapKeys()
+	sortKeys(keys)
+
+	var err error
+
+	for _, k := range keys {
+		x := v.MapIndex(k)
+		b = wire.AppendUvarint(b, f.tag(wireBytes))
+
+		if b, err = appendNested(b, func(b []byte) ([]byte, error) {
+			b, _ = appendValue(b, f.key, k)
+
+			if x.Kind() == reflect.Ptr {
+				if x.IsNil() {
+					return b, nil
+				}
+				x = x.Elem()
+			}
+
+			return appendValue(b, f.val, x)
+		}); err != nil {
+			return b, err
+		}
+	}
+
+	return b, nil
+}
+
+// appendNested appends the bytes produced by encode to b, prefixed with their
+// length encoded as a varint.
+func appendNested(b []byte, encode func([]byte) ([]byte, error)) ([]byte, error) {
+	start := len(b)
+
+	b, err := encode(b)
+	if err != nil {
+		return b, err
+	}
+
+	// The length is only known once the bytes were produced, they are moved
+	// to make room for it.
+	n := len(b) - start
+	k := wire.SizeUvarint(uint64(n))
+
+	for i := 0; i != k; i++ {
+		b = append(b, 0)
+	}
+
+	copy(b[start+k:], b[start:start+n])
+	wire.AppendUvarint(b[:start], uint64(n))
+	return b, nil
+}
+
+func appendFixed(b []byte, size int, v uint64) []byte {
+	for i := 0; i != size; i++ {
+		b = append(b, byte(v>>(8*uint(i))))
+	}
+	return b
+}
+
+func sortKeys(keys []reflect.Value) {
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+
+		switch a.Kind() {
+		case reflect.String:
+			return a.String() < b.String()
+		case reflect.Bool:
+			return !a.Bool() && b.Bool()
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+			return a.Int() < b.Int()
+		default:
+			return a.Uint() < b.Uint()
+		}
+	})
+}
@@ -0,0 +1,357 @@
+// Package proto implements encoding and decoding of structs in the binary wire
+// format of protocol buffers, so simple messages can be exchanged with programs
+// using code generated by protoc without depending on a protobuf runtime.
+//
+// The field numbers of messages are given by `protobuf` struct tags, fields
+// which have none are not part of the messages:
+//
+//	type Order struct {
+//		ID     string            `protobuf:"1"`
+//		Count  int32             `protobuf:"2"`
+//		Delta  int64             `protobuf:"3,zigzag"`
+//		Items  []Item            `protobuf:"4"`
+//		Labels map[string]string `protobuf:"5"`
+//		Note   *string           `protobuf:"6"`
+//	}
+//
+// Go types are represented by the following protobuf types:
+//
+//	bool                 bool
+//	int32, int64, int    int32, int64, or sint32, sint64 with the zigzag
+//	                     option, or sfixed32, sfixed64 with the fixed option
+//	uint32, uint64, uint uint32, uint64, or fixed32, fixed64 with the fixed
+//	                     option
+//	float32, float64     float, double
+//	string, []byte       string, bytes
+//	structs              messages, which may be referenced by pointers
+//	slices               repeated fields, packed when they hold numbers
+//	maps                 map fields
+//	pointers to scalars  optional fields
+//
+// Like in proto3, fields holding zero values are not written to the output,
+// except pointers to scalars which are written when they are not nil. Decoders
+// skip the fields with unknown numbers, and read repeated numbers in both the
+// packed and unpacked encodings.
+//
+// The tags of the code generated by protoc-gen-go, like
+// `protobuf:"zigzag64,3,opt,name=delta,proto3"`, are understood as well, so
+// the struct types may be copied from generated code. Groups, oneofs and the
+// well-known types are not supported.
+package proto
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// The wire types of protobuf fields.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// The range of valid field numbers, numbers from 19000 to 19999 are reserved by
+// the protobuf implementations.
+const (
+	minFieldNumber = 1
+	maxFieldNumber = 1<<29 - 1
+)
+
+type field struct {
+	name   string
+	index  int
+	number uint64
+	typ    reflect.Type
+	zigzag bool     // signed integers use the zigzag encoding
+	fixed  bool     // integers use the fixed-size encodings
+	msg    *message // set when the field holds messages
+	key    *field   // set on map fields, the key of the entries
+	val    *field   // set on map fields, the value of the entries
+}
+
+type message struct {
+	fields   []field
+	byNumber map[uint64]int
+}
+
+// field returns the field of m with the given number, or nil if there are
+// none.
+func (m *message) field(number uint64) *field {
+	if i, ok := m.byNumber[number]; ok {
+		return &m.fields[i]
+	}
+	return nil
+}
+
+var messages sync.Map // reflect.Type => *message
+
+// messageOf returns the message of values of type t, which must be a struct
+// type.
+func messageOf(t reflect.Type) (*message, error) {
+	if m, ok := messages.Load(t); ok {
+		return m.(*message), nil
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, objutil.Errorf(objutil.ErrType, "objconv/proto: messages must be structs, found %s", t)
+	}
+
+	// Message types may reference themselves through pointers, slices or
+	// maps, the messages being built are shared until they are complete.
+	building := map[reflect.Type]*message{}
+
+	if _, err := makeMessage(t, building); err != nil {
+		return nil, err
+	}
+
+	for typ, m := range building {
+		messages.LoadOrStore(typ, m)
+	}
+
+	m, _ := messages.Load(t)
+	return m.(*message), nil
+}
+
+func makeMessage(t reflect.Type, building map[reflect.Type]*message) (*message, error) {
+	if m, ok := messages.Load(t); ok {
+		return m.(*message), nil
+	}
+
+	if m := building[t]; m != nil {
+		return m, nil
+	}
+
+	m := &message{byNumber: map[uint64]int{}}
+	building[t] = m
+
+	for i, n := 0, t.NumField(); i != n; i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("protobuf")
+
+		if !ok || tag == "-" || len(sf.PkgPath) != 0 {
+			continue
+		}
+
+		f, err := parseField(sf, tag)
+		if err != nil {
+			return nil, objutil.Errorf(objutil.ErrType, "objconv/proto: %s.%s: %s", t, sf.Name, err)
+		}
+
+		if _, dup := m.byNumber[f.number]; dup {
+			return nil, objutil.Errorf(objutil.ErrType, "objconv/proto: %s.%s: the field number %d is used by multiple fields", t, sf.Name, f.number)
+		}
+
+		if err := checkField(&f, f.typ, building); err != nil {
+			if _, ok := err.(tagError); !ok {
+				return nil, err // error of a nested message
+			}
+			return nil, objutil.Errorf(objutil.ErrType, "objconv/proto: %s.%s: %s", t, sf.Name, err)
+		}
+
+		f.index = i
+		m.byNumber[f.number] = len(m.fields)
+		m.fields = append(m.fields, f)
+	}
+
+	// Fields are written in the order of their numbers, like protobuf
+	// implementations do.
+	sort.SliceStable(m.fields, func(i, j int) bool {
+		return m.fields[i].number < m.fields[j].number
+	})
+
+	for i := range m.fields {
+		m.byNumber[m.fields[i].number] = i
+	}
+
+	return m, nil
+}
+
+type tagError string
+
+func (e tagError) Error() string { return string(e) }
+
+func parseField(sf reflect.StructField, tag string) (f field, err error) {
+	f = field{name: sf.Name, typ: sf.Type}
+
+	for len(tag) != 0 {
+		var opt string
+
+		if opt, tag, _ = strings.Cut(tag, ","); len(opt) == 0 {
+			continue
+		}
+
+		if n, err := strconv.ParseUint(opt, 10, 64); err == nil {
+			if f.number != 0 {
+				return f, tagError("multiple field numbers: " + strconv.Quote(opt))
+			}
+			if n < minFieldNumber || n > maxFieldNumber || (n >= 19000 && n <= 19999) {
+				return f, tagError("invalid field number: " + opt)
+			}
+			f.number = n
+			continue
+		}
+
+		if strings.IndexByte(opt, '=') >= 0 {
+			continue // name=, json=, enum=, def= of generated code
+		}
+
+		switch opt {
+		case "zigzag", "zigzag32", "zigzag64":
+			f.zigzag = true
+
+		case "fixed", "fixed32", "fixed64", "sfixed32", "sfixed64":
+			f.fixed = true
+
+		case "varint", "bytes", "opt", "req", "rep", "packed", "proto3":
+
+		default:
+			return f, tagError("unknown tag option: " + strconv.Quote(opt))
+		}
+	}
+
+	if f.number == 0 {
+		return f, tagError("the tag has no field number")
+	}
+
+	if f.zigzag && f.fixed {
+		return f, tagError("the zigzag and fixed options cannot be combined")
+	}
+
+	return f, nil
+}
+
+// checkField verifies that values of type t can be held by the field f, and
+// sets the message of f when t holds messages.
+func checkField(f *field, t reflect.Type, building map[reflect.Type]*message) error {
+	switch t.Kind() {
+	case reflect.Ptr:
+		if e := t.Elem(); e.Kind() != reflect.Struct && !isScalar(e) {
+			return tagError("pointers must reference structs or scalars, not " + e.String())
+		}
+		return checkElem(f, t.Elem(), building)
+
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		e := t.Elem()
+		if e.Kind() == reflect.Ptr && e.Elem().Kind() == reflect.Struct {
+			e = e.Elem()
+		}
+		if e.Kind() == reflect.Slice && e.Elem().Kind() != reflect.Uint8 {
+			return tagError("slices of slices cannot be represented, found " + t.String())
+		}
+		return checkElem(f, e, building)
+
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.Float32, reflect.Float64, reflect.Slice:
+			return tagError("map keys must be integers, booleans or strings, not " + t.Key().String())
+		}
+		if !isScalar(t.Key()) {
+			return tagError("map keys must be integers, booleans or strings, not " + t.Key().String())
+		}
+
+		f.key = &field{name: f.name, number: 1, typ: t.Key(), zigzag: f.zigzag, fixed: f.fixed}
+		f.val = &field{name: f.name, number: 2, typ: t.Elem()}
+
+		switch v := t.Elem(); {
+		case v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct:
+			return checkElem(f.val, v.Elem(), building)
+		case v.Kind() == reflect.Slice && v.Elem().Kind() != reflect.Uint8, v.Kind() == reflect.Map, v.Kind() == reflect.Ptr:
+			return tagError("map values cannot be repeated fields, maps or optional fields, found " + t.String())
+		default:
+			return checkElem(f.val, v, building)
+		}
+	}
+
+	return checkElem(f, t, building)
+}
+
+// checkElem verifies that t is a scalar or a message type.
+func checkElem(f *field, t reflect.Type, building map[reflect.Type]*message) error {
+	if t.Kind() == reflect.Struct {
+		m, err := makeMessage(t, building)
+		f.msg = m
+		return err
+	}
+
+	if !isScalar(t) {
+		return tagError("values of type " + t.String() + " have no protobuf representation")
+	}
+
+	if f.fixed {
+		switch t.Kind() {
+		case reflect.Int32, reflect.Int64, reflect.Int, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		default:
+			return tagError("the fixed option only applies to 32 and 64 bits integers, not " + t.String())
+		}
+	}
+
+	if f.zigzag {
+		switch t.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		default:
+			return tagError("the zigzag option only applies to signed integers, not " + t.String())
+		}
+	}
+
+	return nil
+}
+
+func isScalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool,
+		reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint,
+		reflect.Float32, reflect.Float64,
+		reflect.String:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return false
+}
+
+// isPackable returns true if repeated values of type t are packed.
+func isPackable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Slice, reflect.Struct, reflect.Ptr:
+		return false
+	}
+	return true
+}
+
+// wireType returns the wire type of the scalar values of type t held by f.
+func (f *field) wireType(t reflect.Type) int {
+	switch t.Kind() {
+	case reflect.Float32:
+		return wireFixed32
+	case reflect.Float64:
+		return wireFixed64
+	case reflect.String, reflect.Slice, reflect.Struct, reflect.Ptr, reflect.Map:
+		return wireBytes
+	}
+
+	if f.fixed {
+		if t.Size() == 4 {
+			return wireFixed32
+		}
+		return wireFixed64
+	}
+
+	return wireVarint
+}
+
+// tag returns the key of f in the wire format for values of the given wire
+// type.
+func (f *field) tag(wireType int) uint64 {
+	return f.number<<3 | uint64(wireType)
+}
@@ -0,0 +1,363 @@
+package proto
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+func TestEncodingExamples(t *testing.T) {
+	// The examples of the encoding guide of protocol buffers.
+	type test1 struct {
+		A int32 `protobuf:"1"`
+	}
+
+	type test2 struct {
+		B string `protobuf:"2"`
+	}
+
+	type test3 struct {
+		C test1 `protobuf:"3"`
+	}
+
+	type test4 struct {
+		D []int32 `protobuf:"4"`
+	}
+
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{&test1{A: 150}, "\x08\x96\x01"},
+		{&test2{B: "testing"}, "\x12\x07testing"},
+		{&test3{C: test1{A: 150}}, "\x1a\x03\x08\x96\x01"},
+		{&test4{D: []int32{3, 270, 86942}}, "\x22\x06\x03\x8e\x02\x9e\xa7\x05"},
+	}
+
+	for _, test := range tests {
+		b, err := Marshal(test.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(b) != test.expected {
+			t.Errorf("%T: bad encoding: %q", test.value, b)
+		}
+
+		v := reflect.New(reflect.TypeOf(test.value).Elem())
+
+		if err := Unmarshal(b, v.Interface()); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(v.Interface(), test.value) {
+			t.Errorf("%T: bad value: %#v", test.value, v.Interface())
+		}
+	}
+}
+
+type item struct {
+	Name  string  `protobuf:"1"`
+	Price float64 `protobuf:"2"`
+}
+
+type order struct {
+	ID       string            `protobuf:"1"`
+	Count    int32             `protobuf:"2"`
+	Delta    int64             `protobuf:"3,zigzag"`
+	Items    []item            `protobuf:"4"`
+	Labels   map[string]string `protobuf:"5"`
+	Note     *string           `protobuf:"6"`
+	Hash     uint64            `protobuf:"7,fixed"`
+	Ratio    float32           `protobuf:"8"`
+	Valid    bool              `protobuf:"9"`
+	Data     []byte            `protobuf:"10"`
+	Sizes    []uint32          `protobuf:"11"`
+	Parent   *order            `protobuf:"12"`
+	Codes    map[int32]*item   `protobuf:"13"`
+	Offset   int32             `protobuf:"14"`
+	Internal string
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	note := ""
+
+	o1 := order{
+		ID:     "1234",
+		Count:  3,
+		Delta:  -2,
+		Items:  []item{{Name: "A", Price: 1.5}, {Name: "B"}},
+		Labels: map[string]string{"b": "2", "a": "1"},
+		Note:   &note,
+		Hash:   math.MaxUint64,
+		Ratio:  0.25,
+		Valid:  true,
+		Data:   []byte("hello"),
+		Sizes:  []uint32{1, 300},
+		Parent: &order{ID: "0"},
+		Codes:  map[int32]*item{-1: {Name: "C"}},
+		Offset: -1,
+	}
+
+	b, err := Marshal(&o1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var o2 order
+	if err := Unmarshal(b, &o2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(o1, o2) {
+		t.Errorf("%#v != %#v", o1, o2)
+	}
+
+	// Negative int32 values are sign-extended to 10 bytes.
+	if !bytes.HasSuffix(b, []byte{0x70, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}) {
+		t.Errorf("bad encoding of negative int32 values: %x", b)
+	}
+
+	// Entries are written in the order of their keys.
+	if i, j := bytes.Index(b, []byte("\x0a\x01a")), bytes.Index(b, []byte("\x0a\x01b")); i < 0 || j < i {
+		t.Errorf("bad order of map entries: %x", b)
+	}
+
+	// Decoding resets the fields of the target.
+	if err := Unmarshal([]byte{0x0a, 0x01, 'X'}, &o2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(o2, order{ID: "X"}) {
+		t.Errorf("bad value: %#v", o2)
+	}
+}
+
+func TestZeroValues(t *testing.T) {
+	zero := int32(0)
+
+	b, err := Marshal(order{Items: []item{}, Labels: map[string]string{}, Data: []byte{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b) != 0 {
+		t.Errorf("zero values must not be written: %x", b)
+	}
+
+	// Pointers to scalars are written when they are not nil.
+	type optional struct {
+		N *int32 `protobuf:"1"`
+	}
+
+	if b, err = Marshal(optional{N: &zero}); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != "\x08\x00" {
+		t.Errorf("bad encoding: %x", b)
+	}
+}
+
+func TestGeneratedTags(t *testing.T) {
+	// Struct tags of the code generated by protoc-gen-go.
+	type message struct {
+		Delta  int64   `protobuf:"zigzag64,1,opt,name=delta,proto3" json:"delta,omitempty"`
+		Hash   uint32  `protobuf:"fixed32,2,opt,name=hash,proto3" json:"hash,omitempty"`
+		Values []int64 `protobuf:"varint,3,rep,packed,name=values,proto3" json:"values,omitempty"`
+		Name   string  `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	}
+
+	m1 := message{Delta: -1, Hash: 1, Values: []int64{1, 2}, Name: "A"}
+
+	b, err := Marshal(&m1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "\x08\x01\x15\x01\x00\x00\x00\x1a\x02\x01\x02\x22\x01A"; string(b) != expected {
+		t.Errorf("bad encoding: %q", b)
+	}
+
+	var m2 message
+	if err := Unmarshal(b, &m2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(m1, m2) {
+		t.Errorf("%#v != %#v", m1, m2)
+	}
+}
+
+func TestUnknownAndUnpackedFields(t *testing.T) {
+	type message struct {
+		Values []int32 `protobuf:"2"`
+	}
+
+	b := []byte{
+		0x08, 0x96, 0x01, // unknown varint
+		0x10, 0x01, // unpacked value
+		0x19, 1, 2, 3, 4, 5, 6, 7, 8, // unknown fixed64
+		0x12, 0x02, 0x02, 0x03, // packed values
+		0x1a, 0x01, 'x', // unknown bytes
+		0x25, 1, 2, 3, 4, // unknown fixed32
+	}
+
+	var m message
+	if err := Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(m.Values, []int32{1, 2, 3}) {
+		t.Errorf("bad values: %v", m.Values)
+	}
+}
+
+func TestDelimited(t *testing.T) {
+	buf := &bytes.Buffer{}
+	e := NewEncoder(buf)
+	e.Delimited = true
+
+	items := []item{{Name: "A"}, {}, {Name: "B", Price: 2}}
+
+	for i := range items {
+		if err := e.Encode(items[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := NewDecoder(buf)
+	d.Delimited = true
+
+	for i := range items {
+		var it item
+
+		if err := d.Decode(&it); err != nil {
+			t.Fatal(err)
+		}
+
+		if it != items[i] {
+			t.Errorf("bad item %d: %#v", i, it)
+		}
+	}
+
+	if err := d.Decode(&item{}); err != io.EOF {
+		t.Errorf("bad error at the end of the stream: %v", err)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	d := NewDecoder(strings.NewReader("\x0a\x01A"))
+
+	var it item
+	if err := d.Decode(&it); err != nil {
+		t.Fatal(err)
+	}
+
+	if it.Name != "A" {
+		t.Errorf("bad item: %#v", it)
+	}
+
+	if err := d.Decode(&it); err != io.EOF {
+		t.Errorf("bad error at the end of the stream: %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	type small struct {
+		N int8 `protobuf:"1"`
+	}
+
+	errs := []struct {
+		input  string
+		target interface{}
+		kind   error
+	}{
+		{"\x0a", &item{}, objutil.ErrSyntax},
+		{"\x0a\x05A", &item{}, objutil.ErrSyntax},
+		{"\x11\x00", &item{}, objutil.ErrSyntax},
+		{"\x08\x01", &item{}, objutil.ErrType},
+		{"\x1b", &item{}, objutil.ErrSyntax},
+		{"\x00", &item{}, objutil.ErrSyntax},
+		{"\x08\x80\x01", &small{}, objutil.ErrRange},
+	}
+
+	for _, test := range errs {
+		if err := Unmarshal([]byte(test.input), test.target); !errors.Is(err, test.kind) {
+			t.Errorf("%q: bad error: %v", test.input, err)
+		}
+	}
+
+	d := NewDecoder(strings.NewReader("\x10"))
+	d.Delimited = true
+	d.MaxSize = 8
+
+	if err := d.Decode(&item{}); !errors.Is(err, objutil.ErrLimit) {
+		t.Errorf("bad error: %v", err)
+	}
+
+	d = NewDecoder(strings.NewReader("\x05\x0a"))
+	d.Delimited = true
+
+	if err := d.Decode(&item{}); !errors.Is(err, objutil.ErrSyntax) {
+		t.Errorf("bad error: %v", err)
+	}
+
+	invalid := []interface{}{
+		struct {
+			N int `protobuf:"0"`
+		}{},
+		struct {
+			N int `protobuf:"19000"`
+		}{},
+		struct {
+			N int `protobuf:"1,unknown"`
+		}{},
+		struct {
+			A int `protobuf:"1"`
+			B int `protobuf:"1"`
+		}{},
+		struct {
+			F float64 `protobuf:"1,zigzag"`
+		}{},
+		struct {
+			U uint8 `protobuf:"1,fixed"`
+		}{},
+		struct {
+			M map[float64]int `protobuf:"1"`
+		}{},
+		struct {
+			S [][]int `protobuf:"1"`
+		}{},
+		struct {
+			C chan int `protobuf:"1"`
+		}{},
+		struct {
+			I struct {
+				C chan int `protobuf:"1"`
+			} `protobuf:"1"`
+		}{},
+	}
+
+	for _, v := range invalid {
+		if _, err := Marshal(v); !errors.Is(err, objutil.ErrType) {
+			t.Errorf("%T: bad error: %v", v, err)
+		}
+	}
+
+	if _, err := Marshal(order{Items: nil, Parent: &order{}, Codes: nil}); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := Marshal(struct {
+		P []*item `protobuf:"1"`
+	}{P: []*item{nil}}); !errors.Is(err, objutil.ErrType) {
+		t.Errorf("bad error: %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build purego
+// +build purego
+
+package objconv
+
+import "reflect"
+
+// When built with the purego tag the package doesn't import unsafe, the
+// conversions between strings and byte slices always make a copy.
+
+// purego is true when unexported fields can only be accessed with their getter
+// and setter methods.
+const purego = true
+
+func stringNoCopy(b []byte) string {
+	return string(b)
+}
+
+func unsafeString(b []byte) string {
+	return string(b)
+}
+
+func exportValue(v reflect.Value) reflect.Value {
+	panic("objconv: unexported fields without getter methods cannot be accessed when building with the purego tag")
+}
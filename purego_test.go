@@ -0,0 +1,44 @@
+//go:build purego
+// +build purego
+
+package objconv
+
+import (
+	"errors"
+	"testing"
+)
+
+type account struct {
+	id int `objconv:"id,export"`
+}
+
+func (a *account) Id() int { return a.id }
+
+func TestPuregoHiddenFields(t *testing.T) {
+	// Unexported fields without setter cannot be decoded without the unsafe
+	// package, the struct type is rejected instead of panicking.
+	if err := NewEncoder(NewValueEmitter()).Encode(account{id: 1}); !errors.Is(err, ErrType) {
+		t.Error("bad encoding error:", err)
+	}
+
+	if err := NewDecoder(NewValueParser(map[string]interface{}{"id": 1})).Decode(&account{}); !errors.Is(err, ErrType) {
+		t.Error("bad decoding error:", err)
+	}
+
+	var b settableAccount
+
+	if err := NewDecoder(NewValueParser(map[string]interface{}{"id": 1})).Decode(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	if b.id != 1 {
+		t.Error("bad value:", b.id)
+	}
+}
+
+type settableAccount struct {
+	id int `objconv:"id,export"`
+}
+
+func (a *settableAccount) Id() int      { return a.id }
+func (a *settableAccount) SetId(id int) { a.id = id }
@@ -0,0 +1,59 @@
+package random
+
+import (
+	"bytes"
+	"reflect"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Populate sets v, which must be a non-nil pointer, to a fully populated
+// sample value: all fields are set, slices and maps have one element, and
+// values are chosen to look sensible in documentation while respecting the
+// constraints of the struct tags. Enums use their first value, formatted
+// strings use examples like user@example.com, and numbers with a min and a max
+// are set to the middle of the range.
+//
+// The values are not random, populating values of the same type always
+// produces the same result.
+func Populate(v interface{}) error {
+	return (&Generator{Example: true}).Generate(v)
+}
+
+// Example returns an example document for the type of v, encoded with the
+// codec registered for mimetype. The content of v is ignored, the document is
+// generated from a value populated by Populate.
+//
+// The document is pretty-printed if the codec supports it and map keys are
+// sorted, which makes the output suitable for embedding in API documentation.
+func Example(v interface{}, mimetype string) ([]byte, error) {
+	codec, ok := objconv.Lookup(mimetype)
+	if !ok {
+		return nil, objutil.Errorf(objutil.ErrType, "objconv/random: no codec registered for %q", mimetype)
+	}
+
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, objutil.Errorf(objutil.ErrType, "objconv/random: cannot generate examples of nil values")
+	}
+
+	x := reflect.New(t)
+
+	if err := Populate(x.Interface()); err != nil {
+		return nil, err
+	}
+
+	b := &bytes.Buffer{}
+	m := codec.NewEmitter(b)
+
+	if p, ok := m.(objconv.PrettyEmitter); ok {
+		m = p.PrettyEmitter()
+	}
+
+	if err := (objconv.Encoder{Emitter: m, SortMapKeys: true}).Encode(x.Elem().Interface()); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,727 @@
+// Package random generates random values of Go types, for fuzzing codecs or
+// producing realistic examples of the documents exchanged by APIs.
+//
+// The values of struct fields are constrained by the `random` struct tags of
+// the fields:
+//
+//	type User struct {
+//		ID    string   `random:"required,format=uuid"`
+//		Email string   `random:"required,format=email"`
+//		Age   int      `random:"min=18,max=99"`
+//		Role  string   `random:"enum=admin|member|guest"`
+//		Tags  []string `random:"max=3,enum=a|b|c"`
+//	}
+//
+// The tag options are:
+//
+//	required    the field is always generated, other fields are left to
+//	            their zero value one time out of four
+//	enum=A|B    the value is one of the list, the values are decoded to the
+//	            field type by the objconv algorithms
+//	min=N       the minimum of numbers, or the minimum length of strings,
+//	            slices and maps
+//	max=N       the maximum of numbers, or the maximum length of strings,
+//	            slices and maps
+//	format=F    the format of strings, one of email, uuid, url, hostname,
+//	            ipv4, ipv6, date-time and date
+//
+// The enum and format options of fields of slice or array types apply to the
+// elements. Fields with a "-" tag and unexported fields are left to their
+// zero value.
+//
+// The Populate and Example functions use the same constraints to produce
+// fully populated sample values instead of random ones, for example to embed
+// example documents in API documentation.
+package random
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Generator carries the configuration of the generation of random values.
+type Generator struct {
+	// Rand is the source of randomness, the generator uses the top-level
+	// functions of the math/rand package if nil. Setting a source with a
+	// fixed seed makes the generated values reproducible.
+	Rand *rand.Rand
+
+	// MaxLen is the maximum length of strings, slices and maps that have no
+	// max tag option, 8 is used if zero.
+	MaxLen int
+
+	// MaxDepth limits the nesting of values of recursive types, below that
+	// depth the pointers, slices and maps that are not required are left
+	// empty. 4 is used if zero.
+	MaxDepth int
+
+	// Example makes the generator produce fully populated sample values
+	// instead of random ones, see Populate.
+	Example bool
+}
+
+// Generate sets v, which must be a non-nil pointer, to a random value, see
+// Generator.Generate.
+func Generate(v interface{}) error {
+	return (&Generator{}).Generate(v)
+}
+
+// Generate sets v, which must be a non-nil pointer, to a random value
+// respecting the constraints of the struct tags.
+//
+// An error is returned if one of the tags is malformed or its constraints
+// cannot be satisfied. Values of channel, function and non-empty interface
+// types are left to their zero value.
+func (g *Generator) Generate(v interface{}) error {
+	p := reflect.ValueOf(v)
+
+	if p.Kind() != reflect.Ptr || p.IsNil() {
+		return objutil.Errorf(objutil.ErrType, "objconv/random: values must be generated to non-nil pointers, found %T", v)
+	}
+
+	return g.generate(p.Elem(), &constraints{required: true}, 0)
+}
+
+type constraints struct {
+	required bool
+	enum     []reflect.Value
+	min      string
+	max      string
+	format   string
+}
+
+type field struct {
+	index int
+	c     constraints
+}
+
+var fieldsCache sync.Map // reflect.Type => []field
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+	lenType   = reflect.TypeOf(0) // the type of lengths in range errors
+)
+
+func (g *Generator) generate(v reflect.Value, c *constraints, depth int) error {
+	if len(c.enum) != 0 && c.enum[0].Type() == v.Type() {
+		if g.Example {
+			v.Set(c.enum[0])
+		} else {
+			v.Set(c.enum[g.intn(len(c.enum))])
+		}
+		return nil
+	}
+
+	if v.Type() == timeType {
+		v.Set(reflect.ValueOf(g.time()))
+		return nil
+	}
+
+	if len(c.format) != 0 {
+		switch v.Kind() {
+		case reflect.String:
+			v.SetString(g.format(c.format))
+			return nil
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+		default:
+			return objutil.Errorf(objutil.ErrType, "objconv/random: the format option only applies to strings, found %s", v.Type())
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(g.Example || g.intn(2) == 1)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		lo, hi := int64(math.MinInt64), int64(math.MaxInt64)
+		if bits := v.Type().Bits(); bits != 64 {
+			lo, hi = -1<<(bits-1), 1<<(bits-1)-1
+		}
+		if err := parseIntBounds(c, &lo, &hi, v.Type()); err != nil {
+			return err
+		}
+		switch {
+		case !g.Example:
+			v.SetInt(lo + int64(g.uint64n(uint64(hi-lo))))
+		case len(c.min) != 0 && len(c.max) != 0:
+			v.SetInt(lo + int64(uint64(hi-lo)/2))
+		default:
+			v.SetInt(clampInt(1, lo, hi))
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		lo, hi := uint64(0), uint64(math.MaxUint64)
+		if bits := v.Type().Bits(); bits != 64 {
+			hi = 1<<bits - 1
+		}
+		if err := parseUintBounds(c, &lo, &hi, v.Type()); err != nil {
+			return err
+		}
+		switch {
+		case !g.Example:
+			v.SetUint(lo + g.uint64n(hi-lo))
+		case len(c.min) != 0 && len(c.max) != 0:
+			v.SetUint(lo + (hi-lo)/2)
+		default:
+			v.SetUint(clampUint(1, lo, hi))
+		}
+
+	case reflect.Float32, reflect.Float64:
+		f, err := g.float(c, v.Type())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+
+	case reflect.String:
+		n, err := g.length(c, 0)
+		if err != nil {
+			return err
+		}
+		if g.Example {
+			v.SetString(sample("string", c))
+		} else {
+			v.SetString(g.text(n))
+		}
+
+	case reflect.Slice:
+		n, err := g.length(c, depth)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.Type() == bytesType {
+			if g.Example {
+				v.SetBytes([]byte(sample("bytes", c)))
+				return nil
+			}
+			b := make([]byte, n)
+			g.read(b)
+			v.SetBytes(b)
+			return nil
+		}
+		s := reflect.MakeSlice(v.Type(), n, n)
+		if err := g.generateElems(s, c, depth); err != nil {
+			return err
+		}
+		v.Set(s)
+
+	case reflect.Array:
+		return g.generateElems(v, c, depth)
+
+	case reflect.Map:
+		n, err := g.length(c, depth)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		t := v.Type()
+		m := reflect.MakeMapWithSize(t, n)
+		required := &constraints{required: true}
+		// Duplicate keys make the map shorter than n, it is an acceptable
+		// trade off for types like bool which don't have enough values.
+		for i := 0; i != n; i++ {
+			k := reflect.New(t.Key()).Elem()
+			x := reflect.New(t.Elem()).Elem()
+			if err := g.generate(k, required, depth+1); err != nil {
+				return err
+			}
+			if err := g.generate(x, required, depth+1); err != nil {
+				return err
+			}
+			m.SetMapIndex(k, x)
+		}
+		v.Set(m)
+
+	case reflect.Ptr:
+		if !c.required && depth >= g.maxDepth() {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		p := reflect.New(v.Type().Elem())
+		if err := g.generate(p.Elem(), c, depth+1); err != nil {
+			return err
+		}
+		v.Set(p)
+
+	case reflect.Struct:
+		fields, err := fieldsOf(v.Type())
+		if err != nil {
+			return err
+		}
+		for i := range fields {
+			f := &fields[i]
+			if !f.c.required && !g.Example && g.intn(4) == 0 {
+				x := v.Field(f.index)
+				x.Set(reflect.Zero(x.Type()))
+				continue
+			}
+			if err := g.generate(v.Field(f.index), &f.c, depth+1); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Interface:
+		if v.NumMethod() == 0 {
+			return g.generateInterface(v, depth)
+		}
+	}
+
+	return nil
+}
+
+func (g *Generator) generateElems(v reflect.Value, c *constraints, depth int) error {
+	e := &constraints{required: true, enum: c.enum, format: c.format}
+
+	for i, n := 0, v.Len(); i != n; i++ {
+		if err := g.generate(v.Index(i), e, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// generateInterface sets the empty interface v to a value of one of the types
+// that decoders produce for scalars.
+func (g *Generator) generateInterface(v reflect.Value, depth int) error {
+	var x reflect.Value
+	var i int
+
+	if !g.Example {
+		i = g.intn(4)
+	}
+
+	switch i {
+	case 1:
+		x = reflect.New(reflect.TypeOf(false)).Elem()
+	case 2:
+		x = reflect.New(reflect.TypeOf(int64(0))).Elem()
+	case 3:
+		x = reflect.New(reflect.TypeOf(float64(0))).Elem()
+	default:
+		x = reflect.New(reflect.TypeOf("")).Elem()
+	}
+
+	if err := g.generate(x, &constraints{required: true}, depth); err != nil {
+		return err
+	}
+
+	v.Set(x)
+	return nil
+}
+
+func (g *Generator) float(c *constraints, t reflect.Type) (float64, error) {
+	lo, hasMin, err := parseFloat(c.min)
+	if err != nil {
+		return 0, err
+	}
+
+	hi, hasMax, err := parseFloat(c.max)
+	if err != nil {
+		return 0, err
+	}
+
+	if hasMin && hasMax && lo > hi {
+		return 0, rangeError(c, t)
+	}
+
+	if g.Example {
+		switch {
+		case hasMin && hasMax:
+			return (lo + hi) / 2, nil
+		case hasMin:
+			return math.Max(lo, 1.5), nil
+		case hasMax:
+			return math.Min(hi, 1.5), nil
+		default:
+			return 1.5, nil
+		}
+	}
+
+	switch {
+	case hasMin && hasMax:
+		return lo + g.float64()*(hi-lo), nil
+	case hasMin:
+		return lo + math.Abs(g.normFloat64())*1000, nil
+	case hasMax:
+		return hi - math.Abs(g.normFloat64())*1000, nil
+	default:
+		return g.normFloat64() * 1000, nil
+	}
+}
+
+// length returns the random length of a string, slice or map. The values
+// deeper than the max depth get the minimum length.
+func (g *Generator) length(c *constraints, depth int) (int, error) {
+	lo, hi := uint64(0), uint64(math.MaxUint64)
+
+	if err := parseUintBounds(c, &lo, &hi, lenType); err != nil {
+		return 0, err
+	}
+
+	if len(c.max) == 0 {
+		hi = lo + uint64(g.maxLen())
+	}
+
+	if depth >= g.maxDepth() && !c.required {
+		return int(lo), nil
+	}
+
+	if g.Example {
+		return int(clampUint(1, lo, hi)), nil
+	}
+
+	return int(lo + g.uint64n(hi-lo)), nil
+}
+
+const alnum = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// text returns a random alphanumeric string of length n.
+func (g *Generator) text(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alnum[g.intn(len(alnum))]
+	}
+	return string(b)
+}
+
+func (g *Generator) word(n int) string {
+	return strings.ToLower(g.text(1 + g.intn(n)))
+}
+
+func (g *Generator) format(f string) string {
+	if g.Example {
+		return examples[f]
+	}
+
+	switch f {
+	case "email":
+		return g.word(10) + "@" + g.hostname()
+	case "uuid":
+		var b [16]byte
+		g.read(b[:])
+		b[6] = (b[6] & 0x0f) | 0x40 // version 4
+		b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[:4], b[4:6], b[6:8], b[8:10], b[10:])
+	case "url":
+		return "https://" + g.hostname() + "/" + g.word(10)
+	case "hostname":
+		return g.hostname()
+	case "ipv4":
+		return fmt.Sprintf("%d.%d.%d.%d", g.intn(256), g.intn(256), g.intn(256), g.intn(256))
+	case "ipv6":
+		return fmt.Sprintf("2001:db8::%x:%x", g.intn(1<<16), g.intn(1<<16))
+	case "date-time":
+		return g.time().Format(time.RFC3339)
+	default: // date, the formats were validated when parsing the tags
+		return g.time().Format("2006-01-02")
+	}
+}
+
+// examples are the sample values of the formats.
+var examples = map[string]string{
+	"email":     "user@example.com",
+	"uuid":      "123e4567-e89b-42d3-a456-426614174000",
+	"url":       "https://example.com/path",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"date-time": "2006-01-02T15:04:05Z",
+	"date":      "2006-01-02",
+}
+
+var exampleTime = time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+
+func (g *Generator) time() time.Time {
+	if g.Example {
+		return exampleTime
+	}
+	return time.Unix(g.int63n(4102444800), 0).UTC()
+}
+
+// sample returns the sample string s repeated or truncated to satisfy the
+// length constraints, the bounds were validated by the caller.
+func sample(s string, c *constraints) string {
+	lo, hi := uint64(0), uint64(math.MaxUint64)
+	parseUintBounds(c, &lo, &hi, lenType)
+
+	b := []byte(s)
+	for uint64(len(b)) < lo {
+		b = append(b, s...)
+	}
+	if uint64(len(b)) > hi {
+		b = b[:hi]
+	}
+	return string(b)
+}
+
+func (g *Generator) hostname() string {
+	return g.word(10) + "." + [...]string{"com", "net", "org", "io"}[g.intn(4)]
+}
+
+func (g *Generator) maxLen() int {
+	if g.MaxLen > 0 {
+		return g.MaxLen
+	}
+	return 8
+}
+
+func (g *Generator) maxDepth() int {
+	if g.MaxDepth > 0 {
+		return g.MaxDepth
+	}
+	return 4
+}
+
+func (g *Generator) intn(n int) int {
+	if g.Rand != nil {
+		return g.Rand.Intn(n)
+	}
+	return rand.Intn(n)
+}
+
+func (g *Generator) int63n(n int64) int64 {
+	if g.Rand != nil {
+		return g.Rand.Int63n(n)
+	}
+	return rand.Int63n(n)
+}
+
+func (g *Generator) float64() float64 {
+	if g.Rand != nil {
+		return g.Rand.Float64()
+	}
+	return rand.Float64()
+}
+
+func (g *Generator) normFloat64() float64 {
+	if g.Rand != nil {
+		return g.Rand.NormFloat64()
+	}
+	return rand.NormFloat64()
+}
+
+func (g *Generator) read(b []byte) {
+	if g.Rand != nil {
+		g.Rand.Read(b)
+	} else {
+		rand.Read(b)
+	}
+}
+
+// uint64n returns a uniform random number in [0, n], n included.
+func (g *Generator) uint64n(n uint64) uint64 {
+	var x uint64
+	if g.Rand != nil {
+		x = g.Rand.Uint64()
+	} else {
+		x = rand.Uint64()
+	}
+	if n == math.MaxUint64 {
+		return x
+	}
+	return x % (n + 1)
+}
+
+// fieldsOf returns the fields of the struct type t that values are generated
+// for.
+func fieldsOf(t reflect.Type) ([]field, error) {
+	if f, ok := fieldsCache.Load(t); ok {
+		return f.([]field), nil
+	}
+
+	fields := []field{}
+
+	for i, n := 0, t.NumField(); i != n; i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("random")
+
+		if len(sf.PkgPath) != 0 || tag == "-" {
+			continue
+		}
+
+		c, err := parseField(sf, tag)
+		if err != nil {
+			return nil, objutil.Errorf(objutil.ErrType, "objconv/random: %s.%s: %s", t, sf.Name, err)
+		}
+
+		fields = append(fields, field{index: i, c: c})
+	}
+
+	f, _ := fieldsCache.LoadOrStore(t, fields)
+	return f.([]field), nil
+}
+
+type tagError string
+
+func (e tagError) Error() string { return string(e) }
+
+func parseField(sf reflect.StructField, tag string) (c constraints, err error) {
+	for len(tag) != 0 {
+		var opt string
+
+		if i := strings.IndexByte(tag, ','); i < 0 {
+			opt, tag = tag, ""
+		} else {
+			opt, tag = tag[:i], tag[i+1:]
+		}
+
+		key, val, _ := strings.Cut(opt, "=")
+
+		switch key {
+		case "required":
+			c.required = true
+
+		case "enum":
+			t := enumType(sf.Type)
+			for _, s := range strings.Split(val, "|") {
+				x := reflect.New(t)
+				if err = objconv.NewDecoder(objconv.NewValueParser(s)).Decode(x.Interface()); err != nil {
+					return c, tagError(fmt.Sprintf("invalid enum value %q: %s", s, err))
+				}
+				c.enum = append(c.enum, x.Elem())
+			}
+
+		case "min", "max":
+			if _, err = strconv.ParseFloat(val, 64); err != nil {
+				return c, tagError("invalid " + key + ": " + strconv.Quote(val))
+			}
+			if key == "min" {
+				c.min = val
+			} else {
+				c.max = val
+			}
+
+		case "format":
+			switch val {
+			case "email", "uuid", "url", "hostname", "ipv4", "ipv6", "date-time", "date":
+				if enumType(sf.Type).Kind() != reflect.String {
+					return c, tagError("the format option only applies to strings, found " + sf.Type.String())
+				}
+				c.format = val
+			default:
+				return c, tagError("unknown format: " + strconv.Quote(val))
+			}
+
+		default:
+			return c, tagError("unknown tag option: " + strconv.Quote(opt))
+		}
+	}
+
+	return c, nil
+}
+
+// enumType returns the type that the enum values of fields of type t are
+// decoded to, the type of the elements for slices and arrays.
+func enumType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr:
+			t = t.Elem()
+		case reflect.Slice, reflect.Array:
+			if t == bytesType {
+				return t
+			}
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+func clampInt(x, lo, hi int64) int64 {
+	if x < lo {
+		return lo
+	}
+	if x > hi {
+		return hi
+	}
+	return x
+}
+
+func clampUint(x, lo, hi uint64) uint64 {
+	if x < lo {
+		return lo
+	}
+	if x > hi {
+		return hi
+	}
+	return x
+}
+
+func parseFloat(s string) (float64, bool, error) {
+	if len(s) == 0 {
+		return 0, false, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, true, err
+}
+
+func parseIntBounds(c *constraints, lo, hi *int64, t reflect.Type) error {
+	if len(c.min) != 0 {
+		min, err := strconv.ParseInt(c.min, 10, 64)
+		if err != nil || min > *hi {
+			return rangeError(c, t)
+		}
+		if min > *lo {
+			*lo = min
+		}
+	}
+
+	if len(c.max) != 0 {
+		max, err := strconv.ParseInt(c.max, 10, 64)
+		if err != nil || max < *lo {
+			return rangeError(c, t)
+		}
+		if max < *hi {
+			*hi = max
+		}
+	}
+
+	return nil
+}
+
+func parseUintBounds(c *constraints, lo, hi *uint64, t reflect.Type) error {
+	if len(c.min) != 0 {
+		min, err := strconv.ParseUint(c.min, 10, 64)
+		if err != nil || min > *hi {
+			return rangeError(c, t)
+		}
+		if min > *lo {
+			*lo = min
+		}
+	}
+
+	if len(c.max) != 0 {
+		max, err := strconv.ParseUint(c.max, 10, 64)
+		if err != nil || max < *lo {
+			return rangeError(c, t)
+		}
+		if max < *hi {
+			*hi = max
+		}
+	}
+
+	return nil
+}
+
+func rangeError(c *constraints, t reflect.Type) error {
+	return objutil.Errorf(objutil.ErrRange, "objconv/random: no values of type %s satisfy the bounds min=%q max=%q", t, c.min, c.max)
+}
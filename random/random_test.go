@@ -0,0 +1,184 @@
+package random
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/segmentio/objconv/json"
+	"github.com/segmentio/objconv/objutil"
+)
+
+type user struct {
+	ID      string            `random:"required,format=uuid"`
+	Email   string            `random:"required,format=email"`
+	Age     int8              `random:"required,min=18,max=99"`
+	Score   float64           `random:"required,min=0,max=1"`
+	Role    string            `random:"required,enum=admin|member"`
+	Level   int               `random:"required,enum=1|2|3"`
+	Tags    []string          `random:"required,min=1,max=3,enum=a|b"`
+	Name    string            `random:"required,min=2,max=4"`
+	IP      *string           `random:"required,format=ipv4"`
+	Created time.Time         `random:"required"`
+	Labels  map[string]string `random:"max=2"`
+	Friends []*user
+	Extra   interface{}
+	Skipped string `random:"-"`
+	hidden  string
+}
+
+func TestGenerate(t *testing.T) {
+	g := &Generator{Rand: rand.New(rand.NewSource(42))}
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	email := regexp.MustCompile(`^[a-z0-9]+@[a-z0-9]+\.[a-z]+$`)
+
+	for i := 0; i != 100; i++ {
+		var u user
+
+		if err := g.Generate(&u); err != nil {
+			t.Fatal(err)
+		}
+
+		switch {
+		case !uuid.MatchString(u.ID):
+			t.Fatalf("bad uuid: %q", u.ID)
+		case !email.MatchString(u.Email):
+			t.Fatalf("bad email: %q", u.Email)
+		case u.Age < 18 || u.Age > 99:
+			t.Fatalf("age out of bounds: %d", u.Age)
+		case u.Score < 0 || u.Score > 1:
+			t.Fatalf("score out of bounds: %g", u.Score)
+		case u.Role != "admin" && u.Role != "member":
+			t.Fatalf("bad role: %q", u.Role)
+		case u.Level < 1 || u.Level > 3:
+			t.Fatalf("bad level: %d", u.Level)
+		case len(u.Tags) < 1 || len(u.Tags) > 3:
+			t.Fatalf("bad number of tags: %q", u.Tags)
+		case len(u.Name) < 2 || len(u.Name) > 4:
+			t.Fatalf("bad name: %q", u.Name)
+		case u.IP == nil || net.ParseIP(*u.IP).To4() == nil:
+			t.Fatalf("bad ip: %v", u.IP)
+		case u.Created.IsZero():
+			t.Fatal("the creation time was not generated")
+		case len(u.Labels) > 2:
+			t.Fatalf("too many labels: %v", u.Labels)
+		case len(u.Skipped) != 0 || len(u.hidden) != 0:
+			t.Fatal("skipped fields were generated")
+		}
+
+		for _, tag := range u.Tags {
+			if tag != "a" && tag != "b" {
+				t.Fatalf("bad tag: %q", tag)
+			}
+		}
+
+		// The generated values must survive a round trip through codecs.
+		b, err := json.Marshal(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var v user
+
+		if err := json.Unmarshal(b, &v); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGenerateReproducible(t *testing.T) {
+	var u1, u2 user
+
+	if err := (&Generator{Rand: rand.New(rand.NewSource(1))}).Generate(&u1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (&Generator{Rand: rand.New(rand.NewSource(1))}).Generate(&u2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(u1, u2) {
+		t.Errorf("generators with the same seed produced different values:\n%#v\n%#v", u1, u2)
+	}
+}
+
+func TestGenerateError(t *testing.T) {
+	tests := []struct {
+		v   interface{}
+		err error
+	}{
+		{v: user{}, err: objutil.ErrType},
+		{v: &struct {
+			A int `random:"color=red"`
+		}{}, err: objutil.ErrType},
+		{v: &struct {
+			A int `random:"enum=1|x"`
+		}{}, err: objutil.ErrType},
+		{v: &struct {
+			A int `random:"format=email"`
+		}{}, err: objutil.ErrType},
+		{v: &struct {
+			A int8 `random:"required,min=200"`
+		}{}, err: objutil.ErrRange},
+		{v: &struct {
+			A uint `random:"required,min=5,max=2"`
+		}{}, err: objutil.ErrRange},
+	}
+
+	for _, test := range tests {
+		if err := Generate(test.v); !errors.Is(err, test.err) {
+			t.Errorf("%T: bad error: %v", test.v, err)
+		}
+	}
+}
+
+func TestExample(t *testing.T) {
+	type address struct {
+		City string `objconv:"city"`
+		Zip  string `objconv:"zip" random:"min=5,max=5"`
+	}
+
+	type account struct {
+		ID      string    `objconv:"id" random:"format=uuid"`
+		Email   string    `objconv:"email" random:"format=email"`
+		Age     int       `objconv:"age" random:"min=18,max=98"`
+		Plan    string    `objconv:"plan" random:"enum=free|pro"`
+		Active  bool      `objconv:"active"`
+		Created time.Time `objconv:"created"`
+		Address *address  `objconv:"address"`
+		Tags    []string  `objconv:"tags"`
+	}
+
+	b, err := Example(account{}, "application/json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = `{
+  "id": "123e4567-e89b-42d3-a456-426614174000",
+  "email": "user@example.com",
+  "age": 58,
+  "plan": "free",
+  "active": true,
+  "created": "2006-01-02T15:04:05Z",
+  "address": {
+    "city": "string",
+    "zip": "strin"
+  },
+  "tags": [
+    "string"
+  ]
+}`
+
+	if s := string(b); s != expected {
+		t.Errorf("bad example:\n%s\n%s", s, expected)
+	}
+
+	if _, err := Example(account{}, "application/x-unknown"); !errors.Is(err, objutil.ErrType) {
+		t.Errorf("bad error for an unknown codec: %v", err)
+	}
+}
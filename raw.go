@@ -0,0 +1,62 @@
+package objconv
+
+import "github.com/segmentio/objconv/objutil"
+
+// RawMessage is a value kept in its encoded representation, it is used to
+// defer the decoding of parts of a document, or to route payloads without
+// paying the cost of decoding and re-encoding them.
+//
+// Decoding a RawMessage captures the representation of the next value of the
+// parser, encoding it writes the representation back to the emitter. The
+// parsers and emitters must implement the RawParser and RawEmitter interfaces,
+// which is the case of the codecs of the objconv packages, and the raw message
+// must be written by an emitter of the same format as the parser it was read
+// from. An empty raw message is encoded as a nil value.
+type RawMessage []byte
+
+// EncodeValue satisfies the ValueEncoder interface.
+func (m RawMessage) EncodeValue(e Encoder) error {
+	if len(m) == 0 {
+		return e.Emitter.EmitNil()
+	}
+
+	r, ok := e.Emitter.(RawEmitter)
+
+	if !ok {
+		return objutil.Errorf(objutil.ErrType, "objconv: emitters of type %T cannot encode raw messages", e.Emitter)
+	}
+
+	return r.EmitRaw(m)
+}
+
+// DecodeValue satisfies the ValueDecoder interface.
+func (m *RawMessage) DecodeValue(d Decoder) error {
+	r, ok := d.Parser.(RawParser)
+
+	if !ok {
+		return objutil.Errorf(objutil.ErrType, "objconv: parsers of type %T cannot decode raw messages", d.Parser)
+	}
+
+	b, err := r.ParseRaw()
+
+	if err == nil {
+		*m = append((*m)[:0], b...)
+	}
+
+	return err
+}
+
+// The RawEmitter interface may be implemented by emitters which can write
+// values that are already encoded in their format, see RawMessage.
+type RawEmitter interface {
+	// EmitRaw writes b, the representation of a single value, verbatim.
+	EmitRaw(b []byte) error
+}
+
+// The RawParser interface may be implemented by parsers which can return the
+// representation of values instead of decoding them, see RawMessage.
+type RawParser interface {
+	// ParseRaw reads the next value and returns its representation, which
+	// is only valid until the next call to a method of the parser.
+	ParseRaw() ([]byte, error)
+}
@@ -0,0 +1,28 @@
+package objconv
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+func TestRawMessageUnsupported(t *testing.T) {
+	e := NewValueEmitter()
+
+	if err := NewEncoder(e).Encode(RawMessage(nil)); err != nil {
+		t.Error(err)
+	} else if v := e.Value(); v != nil {
+		t.Errorf("empty raw messages must be encoded as nil values, found %#v", v)
+	}
+
+	if err := NewEncoder(e).Encode(RawMessage("{}")); !errors.Is(err, objutil.ErrType) {
+		t.Errorf("bad error: %v", err)
+	}
+
+	var m RawMessage
+
+	if err := NewDecoder(NewValueParser(1)).Decode(&m); !errors.Is(err, objutil.ErrType) {
+		t.Errorf("bad error: %v", err)
+	}
+}
@@ -1,11 +1,16 @@
 package resp
 
 import (
+	"errors"
+	"io"
+	"math"
+	"math/big"
 	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objtests"
 )
 
 var respDecodeTests = []struct {
@@ -116,3 +121,118 @@ func BenchmarkDecoder(b *testing.B) {
 		})
 	}
 }
+
+func TestStreamDecodeAt(t *testing.T) {
+	objtests.TestStreamDecodeAt(t, Codec)
+}
+
+func TestParserStringLimit(t *testing.T) {
+	objtests.TestParserStringLimit(t, Codec)
+}
+
+func TestDecoderBuffered(t *testing.T) {
+	// A command followed by raw bytes which aren't encoded in RESP.
+	r := strings.NewReader("*2\r\n$3\r\nSET\r\n:5\r\nhello")
+	d := NewDecoder(r)
+
+	var cmd []interface{}
+
+	if err := d.Decode(&cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := io.ReadAll(io.MultiReader(d.Buffered(), r))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(b); s != "hello" {
+		t.Errorf("bad raw bytes: %q", s)
+	}
+}
+
+func TestDecodeRESP3(t *testing.T) {
+	tests := []struct {
+		v interface{}
+		s string
+	}{
+		{nil, "_\r\n"},
+		{true, "#t\r\n"},
+		{false, "#f\r\n"},
+		{1.5, ",1.5\r\n"},
+		{math.Inf(-1), ",-inf\r\n"},
+		{"Hello World!", "=16\r\ntxt:Hello World!\r\n"},
+		{NewError("SYNTAX invalid"), "!14\r\nSYNTAX invalid\r\n"},
+		{map[interface{}]interface{}{"a": int64(1), "b": nil}, "%2\r\n+a\r\n:1\r\n+b\r\n_\r\n"},
+		{Set{int64(1), "A"}, "~2\r\n:1\r\n+A\r\n"},
+		{Push{[]byte("message"), "news"}, ">2\r\n$7\r\nmessage\r\n+news\r\n"},
+		{[]interface{}{int64(42)}, "*1\r\n|1\r\n+ttl\r\n:3600\r\n:42\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(testName(test.s), func(t *testing.T) {
+			var v interface{}
+
+			if err := objconv.NewDecoder(NewParserVersion(strings.NewReader(test.s), 3)).Decode(&v); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(v, test.v) {
+				t.Errorf("%#v != %#v", v, test.v)
+			}
+
+			if err := Unmarshal([]byte(test.s), &v); !errors.Is(err, objconv.ErrSyntax) {
+				t.Errorf("expected a syntax error decoding RESP3 in RESP2 but got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecodeBigNumber(t *testing.T) {
+	var v interface{}
+	var n big.Int
+	var s string
+
+	p := NewParserVersion(strings.NewReader("(3492890328409238509324850943850943825024385\r\n"), 3)
+
+	if err := objconv.NewDecoder(p).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	n.SetString("3492890328409238509324850943850943825024385", 10)
+
+	if b, ok := v.(*big.Int); !ok || b.Cmp(&n) != 0 {
+		t.Errorf("bad big number: %#v", v)
+	}
+
+	p.Reset(strings.NewReader("(-1\r\n"))
+	p.SetVersion(3)
+
+	if err := objconv.NewDecoder(p).Decode(&s); err != nil || s != "-1" {
+		t.Errorf("bad big number: %q (%v)", s, err)
+	}
+}
+
+func TestParserAttributes(t *testing.T) {
+	p := NewParserVersion(strings.NewReader("|1\r\n+key-popularity\r\n%1\r\n+a\r\n,0.1923\r\n*1\r\n:2039123\r\n"), 3)
+
+	var v []int
+
+	if err := objconv.NewDecoder(p).Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(v, []int{2039123}) {
+		t.Errorf("bad value: %#v", v)
+	}
+
+	attrs := p.Attributes()
+
+	if !reflect.DeepEqual(attrs, map[string]interface{}{"key-popularity": map[interface{}]interface{}{"a": 0.1923}}) {
+		t.Errorf("bad attributes: %#v", attrs)
+	}
+
+	if attrs := p.Attributes(); attrs != nil {
+		t.Errorf("the attributes were not cleared: %#v", attrs)
+	}
+}
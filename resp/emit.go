@@ -2,8 +2,8 @@ package resp
 
 import (
 	"bytes"
-	"fmt"
 	"io"
+	"math"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,8 +15,12 @@ import (
 var (
 	crlfBytes  = [...]byte{'\r', '\n'}
 	nullBytes  = [...]byte{'$', '-', '1', '\r', '\n'}
+	null3Bytes = [...]byte{'_', '\r', '\n'}
 	trueBytes  = [...]byte{'+', 't', 'r', 'u', 'e', '\r', '\n'}
 	falseBytes = [...]byte{'+', 'f', 'a', 'l', 's', 'e', '\r', '\n'}
+
+	true3Bytes  = [...]byte{'#', 't', '\r', '\n'}
+	false3Bytes = [...]byte{'#', 'f', '\r', '\n'}
 )
 
 // Emitter implements a RESP emitter that satisfies the objconv.Emitter
@@ -40,6 +44,21 @@ type Emitter struct {
 	// sback is used as the initial backing array for the stack slice to avoid
 	// dynamic memory allocations for the most common use cases.
 	sback [8]*context
+
+	// The emitter writes to this buffer, which gets flushed to the underlying
+	// writer when the encoder has completed writing a value.
+	buf objutil.BufferedWriter
+
+	// Cache of the last formatted time value, batches of records often have
+	// many identical timestamps.
+	times objutil.TimeCache
+
+	// protocol version, zero means RESP2
+	version int
+
+	// type token of the next array or map, set by EmitPush, EmitSet and
+	// EmitAttribute
+	next byte
 }
 
 type context struct {
@@ -47,17 +66,47 @@ type context struct {
 	w io.Writer    // the previous writer where b will be flushed
 	n int          // the length of the array as initially set by the encoder
 	i int          // the number of elements written to the array
+	t byte         // the type token of the array or map
 }
 
 func NewEmitter(w io.Writer) *Emitter {
-	e := &Emitter{w: w}
+	e := &Emitter{}
 	e.s = e.a[:0]
 	e.stack = e.sback[:0]
+	e.Reset(w)
 	return e
 }
 
+// NewEmitterVersion returns a new emitter of the given protocol version, which
+// must be 2 or 3, the function panics otherwise.
+func NewEmitterVersion(w io.Writer, version int) *Emitter {
+	e := NewEmitter(w)
+	if err := e.SetVersion(version); err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// Flush writes the buffered output of the emitter to its underlying writer.
+func (e *Emitter) Flush() error {
+	return e.buf.Flush()
+}
+
+// EmitRaw satisfies the objconv.RawEmitter interface.
+func (e *Emitter) EmitRaw(b []byte) (err error) {
+	_, err = e.w.Write(b)
+	return
+}
+
+// TransientEmitter satisfies the objconv.transientEmitter interface, values
+// are written to the output when they are emitted.
+func (e *Emitter) TransientEmitter() bool {
+	return true
+}
+
 func (e *Emitter) Reset(w io.Writer) {
-	e.w = w
+	e.buf.Reset(w)
+	e.w = &e.buf
 
 	if e.stack == nil {
 		e.stack = e.stack[:0]
@@ -70,17 +119,81 @@ func (e *Emitter) Reset(w io.Writer) {
 	} else {
 		e.s = e.s[:0]
 	}
+
+	e.version = 0
+	e.next = 0
+}
+
+// Version returns the version of the protocol that the emitter writes, which
+// is 2 unless it was changed by a call to SetVersion.
+func (e *Emitter) Version() int {
+	return versionOf(e.version)
+}
+
+// SetVersion changes the version of the protocol that the emitter writes,
+// typically after a HELLO command was exchanged. The output that the emitter
+// has buffered is kept, the values emitted after the call are written in the
+// new version.
+//
+// The version cannot change while an array or a map is being emitted.
+func (e *Emitter) SetVersion(v int) error {
+	if err := checkVersion(v); err != nil {
+		return err
+	}
+	if len(e.stack) != 0 {
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/resp: the protocol version cannot change while an array or a map is being emitted")
+	}
+	e.version = v
+	return nil
+}
+
+// EmitPush makes the next array written by the emitter a push message. The
+// array is written as a regular array by RESP2 emitters.
+func (e *Emitter) EmitPush() error {
+	if e.version >= 3 {
+		e.next = '>'
+	}
+	return nil
+}
+
+// EmitSet makes the next array written by the emitter a set. The array is
+// written as a regular array by RESP2 emitters.
+func (e *Emitter) EmitSet() error {
+	if e.version >= 3 {
+		e.next = '~'
+	}
+	return nil
+}
+
+// EmitAttribute makes the next map written by the emitter an attribute map,
+// which applies to the value written after it. RESP2 has no representation of
+// attributes, the method returns an error if the emitter isn't in RESP3.
+func (e *Emitter) EmitAttribute() error {
+	if e.version < 3 {
+		return objutil.Errorf(objutil.ErrType, "objconv/resp: attributes are only supported by RESP3")
+	}
+	e.next = '|'
+	return nil
 }
 
 func (e *Emitter) EmitNil() (err error) {
-	_, err = e.w.Write(nullBytes[:])
+	if e.version < 3 {
+		_, err = e.w.Write(nullBytes[:])
+	} else {
+		_, err = e.w.Write(null3Bytes[:])
+	}
 	return
 }
 
 func (e *Emitter) EmitBool(v bool) (err error) {
-	if v {
+	switch {
+	case e.version >= 3 && v:
+		_, err = e.w.Write(true3Bytes[:])
+	case e.version >= 3:
+		_, err = e.w.Write(false3Bytes[:])
+	case v:
 		_, err = e.w.Write(trueBytes[:])
-	} else {
+	default:
 		_, err = e.w.Write(falseBytes[:])
 	}
 	return
@@ -99,13 +212,16 @@ func (e *Emitter) EmitInt(v int64, _ int) (err error) {
 }
 
 func (e *Emitter) EmitUint(v uint64, _ int) (err error) {
-	if v > objutil.Int64Max {
-		return fmt.Errorf("objconv/resp: %d overflows the maximum integer value of %d", v, objutil.Int64Max)
-	}
-
 	s := e.s[:0]
 
-	s = append(s, ':')
+	if v <= objutil.Int64Max {
+		s = append(s, ':')
+	} else if e.version >= 3 {
+		s = append(s, '(') // big number
+	} else {
+		return objutil.Errorf(objutil.ErrRange, "objconv/resp: %d overflows the maximum integer value of %d", v, objutil.Int64Max)
+	}
+
 	s = appendUint(s, v)
 	s = appendCRLF(s)
 
@@ -117,8 +233,24 @@ func (e *Emitter) EmitUint(v uint64, _ int) (err error) {
 func (e *Emitter) EmitFloat(v float64, bitSize int) (err error) {
 	s := e.s[:0]
 
-	s = append(s, '+')
-	s = appendFloat(s, v, bitSize)
+	if e.version < 3 {
+		s = append(s, '+')
+		s = appendFloat(s, v, bitSize)
+	} else {
+		s = append(s, ',')
+
+		switch {
+		case math.IsInf(v, 1):
+			s = append(s, "inf"...)
+		case math.IsInf(v, -1):
+			s = append(s, "-inf"...)
+		case math.IsNaN(v):
+			s = append(s, "nan"...)
+		default:
+			s = appendFloat(s, v, bitSize)
+		}
+	}
+
 	s = appendCRLF(s)
 
 	e.s = s[:0]
@@ -180,7 +312,7 @@ func (e *Emitter) EmitTime(v time.Time) (err error) {
 	s := e.s[:0]
 
 	s = append(s, '+')
-	s = v.AppendFormat(s, time.RFC3339Nano)
+	s = e.times.AppendTime(s, v)
 	s = appendCRLF(s)
 
 	e.s = s[:0]
@@ -218,23 +350,77 @@ func (e *Emitter) EmitError(v error) (err error) {
 }
 
 func (e *Emitter) EmitArrayBegin(n int) (err error) {
+	t := byte('*')
+
+	if e.next == '>' || e.next == '~' {
+		t, e.next = e.next, 0
+	}
+
+	return e.begin(t, n)
+}
+
+func (e *Emitter) EmitArrayEnd() (err error) {
+	return e.end()
+}
+
+func (e *Emitter) EmitArrayNext() (err error) {
+	e.more()
+	return
+}
+
+func (e *Emitter) EmitMapBegin(n int) (err error) {
+	if e.version < 3 {
+		// RESP2 maps are arrays of keys and values, when the length is
+		// unknown both the keys and values are counted as elements.
+		return e.begin('*', n+n)
+	}
+
+	t := byte('%')
+
+	if e.next == '|' {
+		t, e.next = e.next, 0
+	}
+
+	return e.begin(t, n)
+}
+
+func (e *Emitter) EmitMapEnd() (err error) {
+	return e.end()
+}
+
+func (e *Emitter) EmitMapValue() (err error) {
+	if e.version < 3 {
+		e.more()
+	}
+	return
+}
+
+func (e *Emitter) EmitMapNext() (err error) {
+	e.more()
+	return
+}
+
+// begin starts an array or a map of type t, when n is negative the elements
+// are cached until end is called so the length can be written first.
+func (e *Emitter) begin(t byte, n int) (err error) {
 	var c *context
 
 	if n < 0 {
 		c = contextPool.Get().(*context)
 		c.b.Truncate(0)
 		c.n = 0
+		c.t = t
 		c.w = e.w
 		e.w = &c.b
 	} else {
-		err = e.emitArray(n)
+		err = e.emitHeader(t, n)
 	}
 
 	e.stack = append(e.stack, c)
 	return
 }
 
-func (e *Emitter) EmitArrayEnd() (err error) {
+func (e *Emitter) end() (err error) {
 	i := len(e.stack) - 1
 	c := e.stack[i]
 	e.stack = e.stack[:i]
@@ -246,7 +432,7 @@ func (e *Emitter) EmitArrayEnd() (err error) {
 			c.n++
 		}
 
-		if err = e.emitArray(c.n); err == nil {
+		if err = e.emitHeader(c.t, c.n); err == nil {
 			_, err = c.b.WriteTo(c.w)
 		}
 
@@ -256,33 +442,20 @@ func (e *Emitter) EmitArrayEnd() (err error) {
 	return
 }
 
-func (e *Emitter) EmitArrayNext() (err error) {
+func (e *Emitter) more() {
 	if c := e.stack[len(e.stack)-1]; c != nil {
 		c.n++
 	}
-	return
-}
-
-func (e *Emitter) EmitMapBegin(n int) (err error) {
-	return e.emitArray(n + n)
 }
 
-func (e *Emitter) EmitMapEnd() (err error) {
-	return
-}
-
-func (e *Emitter) EmitMapValue() (err error) {
-	return
-}
-
-func (e *Emitter) EmitMapNext() (err error) {
-	return
+func (e *Emitter) emitArray(n int) (err error) {
+	return e.emitHeader('*', n)
 }
 
-func (e *Emitter) emitArray(n int) (err error) {
+func (e *Emitter) emitHeader(t byte, n int) (err error) {
 	s := e.s[:0]
 
-	s = append(s, '*')
+	s = append(s, t)
 	s = appendUint(s, uint64(n))
 	s = appendCRLF(s)
 
@@ -338,9 +511,9 @@ type ClientEmitter struct {
 
 func NewClientEmitter(w io.Writer) *ClientEmitter {
 	e := &ClientEmitter{}
-	e.w = w
 	e.s = e.a[:0]
 	e.stack = e.sback[:0]
+	e.Reset(w)
 	return e
 }
 
@@ -4,10 +4,15 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objtests"
 )
 
 var respEncodeTests = []struct {
@@ -109,6 +114,45 @@ func TestStreamEncoder(t *testing.T) {
 	}
 }
 
+func TestEncoderIndent(t *testing.T) {
+	// The layout of RESP values is fixed by the protocol, the option is
+	// ignored.
+	for _, newEmitter := range []func(io.Writer) objconv.Emitter{
+		func(w io.Writer) objconv.Emitter { return NewEmitter(w) },
+		func(w io.Writer) objconv.Emitter { return NewClientEmitter(w) },
+	} {
+		var outputs [2]bytes.Buffer
+
+		for i := range outputs {
+			e := objconv.NewEncoder(newEmitter(&outputs[i]))
+
+			if i != 0 {
+				e.Indent("", "  ")
+			}
+
+			if err := e.Encode([]interface{}{"SET", "key", []int{1, 2}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if a, b := outputs[0].String(), outputs[1].String(); a != b {
+			t.Errorf("bad output: %q != %q", a, b)
+		}
+	}
+}
+
+func TestEncoderAllocs(t *testing.T) {
+	objtests.TestEncoderAllocs(t, Codec)
+}
+
+func TestTypedEncoderAllocs(t *testing.T) {
+	objtests.TestTypedEncoderAllocs(t, Codec)
+}
+
+func BenchmarkEncoderAllocs(b *testing.B) {
+	objtests.BenchmarkEncoderAllocs(b, Codec)
+}
+
 func BenchmarkEncoder(b *testing.B) {
 	e := NewEncoder(ioutil.Discard)
 
@@ -140,3 +184,127 @@ func BenchmarkMarshal(b *testing.B) {
 func testName(s string) string {
 	return strings.Replace(s, "\r\n", "", -1)
 }
+
+func TestEncodeRESP3(t *testing.T) {
+	tests := []struct {
+		v interface{}
+		s string
+	}{
+		{nil, "_\r\n"},
+		{true, "#t\r\n"},
+		{false, "#f\r\n"},
+		{0.5, ",0.5\r\n"},
+		{math.Inf(1), ",inf\r\n"},
+		{math.NaN(), ",nan\r\n"},
+		{uint64(math.MaxUint64), "(18446744073709551615\r\n"},
+		{map[string]int{"A": 1}, "%1\r\n+A\r\n:1\r\n"},
+		{struct{ A, B int }{1, 2}, "%2\r\n+A\r\n:1\r\n+B\r\n:2\r\n"},
+		{Set{1, 2}, "~2\r\n:1\r\n:2\r\n"},
+		{Push{"message", "news", "hello"}, ">3\r\n+message\r\n+news\r\n+hello\r\n"},
+		{Push(nil), ">0\r\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(testName(test.s), func(t *testing.T) {
+			b := &bytes.Buffer{}
+
+			if err := objconv.NewEncoder(NewEmitterVersion(b, 3)).Encode(test.v); err != nil {
+				t.Fatal(err)
+			}
+
+			if s := b.String(); s != test.s {
+				t.Errorf("%q != %q", s, test.s)
+			}
+		})
+	}
+}
+
+func TestEncodeRESP3Stream(t *testing.T) {
+	b := &bytes.Buffer{}
+	e := NewEmitterVersion(b, 3)
+
+	for _, f := range []func() error{
+		func() error { return e.EmitMapBegin(-1) },
+		func() error { return e.EmitString("A") },
+		func() error { return e.EmitMapValue() },
+		func() error { return e.EmitSet() },
+		func() error { return e.EmitArrayBegin(-1) },
+		func() error { return e.EmitInt(1, 64) },
+		func() error { return e.EmitArrayEnd() },
+		func() error { return e.EmitMapNext() },
+		func() error { return e.EmitString("B") },
+		func() error { return e.EmitMapValue() },
+		func() error { return e.EmitNil() },
+		func() error { return e.EmitMapEnd() },
+		e.Flush,
+	} {
+		if err := f(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if s := b.String(); s != "%2\r\n+A\r\n~1\r\n:1\r\n+B\r\n_\r\n" {
+		t.Errorf("bad output: %q", s)
+	}
+}
+
+func TestEmitAttribute(t *testing.T) {
+	b := &bytes.Buffer{}
+	e := objconv.NewEncoder(NewEmitterVersion(b, 3))
+
+	if err := e.Emitter.(*Emitter).EmitAttribute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Encode(map[string]int{"ttl": 3600}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Encode(42); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := b.String(); s != "|1\r\n+ttl\r\n:3600\r\n:42\r\n" {
+		t.Errorf("bad output: %q", s)
+	}
+
+	if err := NewEmitter(b).EmitAttribute(); err == nil {
+		t.Error("expected an error emitting attributes in RESP2")
+	}
+}
+
+func TestMarshalRESP2Aggregates(t *testing.T) {
+	b, err := Marshal(Push{"message", 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(b); s != "*2\r\n+message\r\n:1\r\n" {
+		t.Errorf("bad output: %q", s)
+	}
+}
+
+func TestEncodeMapOfUnknownLength(t *testing.T) {
+	// Iterators of key/value pairs are encoded as maps of unknown length.
+	pairs := func(yield func(string, interface{}) bool) {
+		_ = yield("a", 1) && yield("b", []int{})
+	}
+
+	for _, test := range []struct {
+		version int
+		output  string
+	}{
+		{2, "*4\r\n+a\r\n:1\r\n+b\r\n*0\r\n"},
+		{3, "%2\r\n+a\r\n:1\r\n+b\r\n*0\r\n"},
+	} {
+		b := &bytes.Buffer{}
+
+		if err := objconv.NewEncoder(NewEmitterVersion(b, test.version)).Encode(pairs); err != nil {
+			t.Fatal(err)
+		}
+
+		if s := b.String(); s != test.output {
+			t.Errorf("RESP%d: bad output: %q", test.version, s)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package resp
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// HelloVersion returns the protocol version requested by cmd, which is a HELLO
+// command followed by its arguments, as decoded by a server. The version is
+// zero if the command has no arguments, in which case the connection keeps
+// its current version.
+//
+// The error is a *Error with the NOPROTO type if the requested version is not
+// supported, which servers are expected to send back to the client.
+func HelloVersion(cmd []string) (version int, err error) {
+	if len(cmd) == 0 || !strings.EqualFold(cmd[0], "HELLO") {
+		return 0, objutil.Errorf(objutil.ErrSyntax, "objconv/resp: expected a HELLO command but found %q", cmd)
+	}
+
+	if len(cmd) == 1 {
+		return 0, nil
+	}
+
+	if version, err = strconv.Atoi(cmd[1]); err != nil || checkVersion(version) != nil {
+		return 0, NewError("NOPROTO unsupported protocol version")
+	}
+
+	return version, nil
+}
+
+// Upgrade changes the protocol version of a parser and an emitter exchanging
+// values over the same connection.
+func Upgrade(p *Parser, e *Emitter, version int) error {
+	if err := e.SetVersion(version); err != nil {
+		return err
+	}
+	return p.SetVersion(version)
+}
+
+func versionOf(v int) int {
+	if v == 0 {
+		v = 2
+	}
+	return v
+}
+
+func checkVersion(v int) error {
+	if v != 2 && v != 3 {
+		return objutil.Errorf(objutil.ErrRange, "objconv/resp: unsupported protocol version %d, only RESP2 and RESP3 are supported", v)
+	}
+	return nil
+}
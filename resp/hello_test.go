@@ -0,0 +1,143 @@
+package resp
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/objconv"
+)
+
+func TestHelloVersion(t *testing.T) {
+	tests := []struct {
+		cmd     []string
+		version int
+		noproto bool
+	}{
+		{[]string{"HELLO"}, 0, false},
+		{[]string{"hello", "2"}, 2, false},
+		{[]string{"HELLO", "3", "AUTH", "user", "pass"}, 3, false},
+		{[]string{"HELLO", "4"}, 0, true},
+		{[]string{"HELLO", "three"}, 0, true},
+	}
+
+	for _, test := range tests {
+		version, err := HelloVersion(test.cmd)
+
+		if version != test.version {
+			t.Errorf("%q: bad version: %d != %d", test.cmd, version, test.version)
+		}
+
+		if e, ok := err.(*Error); test.noproto != (ok && e.Type() == "NOPROTO") {
+			t.Errorf("%q: bad error: %v", test.cmd, err)
+		}
+	}
+
+	if _, err := HelloVersion([]string{"PING"}); err == nil {
+		t.Error("expected an error for a command which isn't HELLO")
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	// The client sends values in RESP3 right after the HELLO command, they
+	// are buffered by the parser when it reads the command.
+	r := strings.NewReader("*2\r\n$5\r\nHELLO\r\n$1\r\n3\r\n_\r\n*2\r\n_\r\n:1\r\n")
+	w := &bytes.Buffer{}
+
+	p := NewParser(r)
+	e := NewEmitter(w)
+	d := objconv.NewDecoder(p)
+	x := objconv.NewEncoder(e)
+
+	var cmd []string
+
+	if err := d.Decode(&cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := HelloVersion(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := x.Encode(nil); err != nil { // reply before the upgrade
+		t.Fatal(err)
+	}
+
+	if err := Upgrade(p, e, version); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Version() != 3 || e.Version() != 3 {
+		t.Fatalf("bad versions: parser=%d emitter=%d", p.Version(), e.Version())
+	}
+
+	var v1 interface{}
+	var v2 []interface{}
+
+	if err := d.Decode(&v1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Decode(&v2); err != nil {
+		t.Fatal(err)
+	}
+
+	if v1 != nil || !reflect.DeepEqual(v2, []interface{}{nil, int64(1)}) {
+		t.Errorf("bad values: %#v %#v", v1, v2)
+	}
+
+	if err := x.Encode([]interface{}{nil}); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := w.String(); s != "$-1\r\n*1\r\n_\r\n" {
+		t.Errorf("bad output: %q", s)
+	}
+}
+
+func TestParserVersion(t *testing.T) {
+	var v interface{}
+
+	if err := Unmarshal([]byte("_\r\n"), &v); !errors.Is(err, objconv.ErrSyntax) {
+		t.Errorf("expected a syntax error decoding a RESP3 null in RESP2 but got %v", err)
+	}
+
+	p := NewParser(strings.NewReader(""))
+
+	if err := p.SetVersion(1); !errors.Is(err, objconv.ErrRange) {
+		t.Errorf("expected a range error setting an unsupported version but got %v", err)
+	}
+
+	if p.Version() != 2 {
+		t.Errorf("bad default version: %d", p.Version())
+	}
+}
+
+func TestEmitterVersion(t *testing.T) {
+	e := NewEmitter(&bytes.Buffer{})
+
+	if err := e.EmitArrayBegin(-1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.SetVersion(3); err == nil {
+		t.Error("expected an error changing the version in the middle of an array")
+	}
+
+	if err := e.EmitArrayEnd(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.SetVersion(3); err != nil {
+		t.Error(err)
+	}
+
+	e.Reset(&bytes.Buffer{})
+
+	if e.Version() != 2 {
+		t.Errorf("the version was not reset: %d", e.Version())
+	}
+}
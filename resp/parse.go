@@ -2,9 +2,12 @@ package resp
 
 import (
 	"bytes"
-	"errors"
-	"fmt"
+	gocontext "context"
 	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/segmentio/objconv"
@@ -13,6 +16,10 @@ import (
 
 var (
 	null = [...]byte{'-', '1'}
+
+	bigIntType = reflect.TypeOf((*big.Int)(nil))
+	pushType   = reflect.TypeOf(Push(nil))
+	setType    = reflect.TypeOf(Set(nil))
 )
 
 type Parser struct {
@@ -22,22 +29,121 @@ type Parser struct {
 	s []byte    // buffer used for building strings
 	a [128]byte // initial backend array for s
 	b [128]byte // buffer where bytes are loaded from the reader
+
+	// offset in the input of the first byte in s
+	off int64
+
+	// protocol version, zero means RESP2
+	version int
+
+	// attributes received since the last call to Attributes
+	attrs map[string]interface{}
+
+	// maximum length of blob values, zero means no limit
+	maxStringLen int
 }
 
 func NewParser(r io.Reader) *Parser {
 	return &Parser{r: r}
 }
 
+// NewParserVersion returns a new parser of the given protocol version, which
+// must be 2 or 3, the function panics otherwise.
+func NewParserVersion(r io.Reader, version int) *Parser {
+	p := NewParser(r)
+	if err := p.SetVersion(version); err != nil {
+		panic(err)
+	}
+	return p
+}
+
 func (p *Parser) Reset(r io.Reader) {
 	p.r = r
+	p.i = 0
 	p.n = 0
 	p.s = nil
+	p.off = 0
+	p.version = 0
+	p.attrs = nil
+}
+
+// Version returns the version of the protocol that the parser expects, which
+// is 2 unless it was changed by a call to SetVersion.
+func (p *Parser) Version() int {
+	return versionOf(p.version)
+}
+
+// SetVersion changes the version of the protocol that the parser expects,
+// typically after a HELLO command was exchanged. The bytes that the parser has
+// already buffered are kept and parsed according to the new version.
+func (p *Parser) SetVersion(v int) error {
+	if err := checkVersion(v); err != nil {
+		return err
+	}
+	p.version = v
+	return nil
+}
+
+// Attributes returns the RESP3 attributes that the parser received since the
+// last call to Attributes, or nil if there were none. Attributes are parsed
+// with the values that they apply to, which are decoded normally.
+func (p *Parser) Attributes() map[string]interface{} {
+	attrs := p.attrs
+	p.attrs = nil
+	return attrs
+}
+
+// ValueType satisfies the objconv.ValueTypeParser interface, big numbers are
+// decoded to *big.Int values, and sets and push messages to values of type Set
+// and Push when the destination is an empty interface.
+func (p *Parser) ValueType() reflect.Type {
+	if p.i == 0 {
+		return nil
+	}
+	switch p.s[p.n] {
+	case '(':
+		return bigIntType
+	case '~':
+		return setType
+	case '>':
+		return pushType
+	}
+	return nil
+}
+
+// ParseContext satisfies the objconv.ParserV2 interface, the reads from the
+// underlying reader are aborted when ctx is canceled.
+func (p *Parser) ParseContext(ctx gocontext.Context) error {
+	p.r = objutil.WithContext(p.r, ctx)
+	return ctx.Err()
+}
+
+// ParseLengthHint satisfies the objconv.ParserV2 interface, the parser has no
+// hints.
+func (p *Parser) ParseLengthHint() int {
+	return -1
+}
+
+// Offset returns the number of bytes consumed by the parser.
+func (p *Parser) Offset() int64 {
+	return p.off + int64(p.n)
 }
 
+// Buffered satisfies the objconv.BufferedParser interface.
 func (p *Parser) Buffered() io.Reader {
 	return bytes.NewReader(p.s[p.n:])
 }
 
+// ParseRaw satisfies the objconv.RawParser interface, the value is re-encoded
+// by a RESP emitter.
+func (p *Parser) ParseRaw() ([]byte, error) {
+	b := &bytes.Buffer{}
+	e := NewEmitter(b)
+	e.version = p.version
+	err := objconv.Transcode(e, p)
+	return b.Bytes(), err
+}
+
 func (p *Parser) ParseType() (t objconv.Type, err error) {
 	var line []byte
 
@@ -46,7 +152,12 @@ func (p *Parser) ParseType() (t objconv.Type, err error) {
 	}
 
 	if len(line) == 0 {
-		err = errors.New("objconv/resp: invalid empty line at the beginning of the stream")
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: invalid empty line at the beginning of the stream")
+		return
+	}
+
+	if p.version < 3 && isRESP3(line[0]) {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: the type token %q is only supported by RESP3", line[0])
 		return
 	}
 
@@ -74,8 +185,34 @@ func (p *Parser) ParseType() (t objconv.Type, err error) {
 			t = objconv.Array
 		}
 
+	case '_':
+		t = objconv.Nil
+
+	case ',':
+		t = objconv.Float
+
+	case '#':
+		t = objconv.Bool
+
+	case '(', '=':
+		t = objconv.String
+
+	case '!':
+		t = objconv.Error
+
+	case '~', '>':
+		t = objconv.Array
+
+	case '%':
+		t = objconv.Map
+
+	case '|':
+		if err = p.parseAttributes(); err == nil {
+			t, err = p.ParseType()
+		}
+
 	default:
-		err = fmt.Errorf("objconv/resp: expected type token but found %#v", string(line))
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: expected type token but found %#v", string(line))
 	}
 
 	return
@@ -89,29 +226,55 @@ func (p *Parser) ParseNil() (err error) {
 	}
 
 	if len(line) == 0 {
-		err = errors.New("objconv/resp: invalid empty line at the beginning of a null value")
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: invalid empty line at the beginning of a null value")
 		return
 	}
 
 	switch line[0] {
 	case '$', '*':
+		if !bytes.Equal(line[1:], null[:]) {
+			goto failure
+		}
+	case '_':
+		if p.version < 3 || len(line) != 1 {
+			goto failure
+		}
 	default:
 		goto failure
 	}
 
-	if !bytes.Equal(line[1:], null[:]) {
-		goto failure
-	}
-
 	p.skipLine()
 	return
 failure:
-	err = fmt.Errorf("objconv/resp: expected null value but found %#v", string(line))
+	err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: expected null value but found %#v", string(line))
 	return
 }
 
 func (p *Parser) ParseBool() (v bool, err error) {
-	panic("objconv/resp: ParseBool should never be called because RESP has no boolean type, this is likely a bug in the decoder code")
+	var line []byte
+
+	if line, err = p.peekLine(); err != nil {
+		return
+	}
+
+	if len(line) != 2 || line[0] != '#' {
+		goto failure
+	}
+
+	switch line[1] {
+	case 't':
+		v = true
+	case 'f':
+		v = false
+	default:
+		goto failure
+	}
+
+	p.skipLine()
+	return
+failure:
+	err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: expected boolean value but found %#v", string(line))
+	return
 }
 
 func (p *Parser) ParseInt() (v int64, err error) {
@@ -122,7 +285,7 @@ func (p *Parser) ParseInt() (v int64, err error) {
 	}
 
 	if len(line) == 0 {
-		err = errors.New("objconv/resp: invalid empty line at the beginning of an integer value")
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: invalid empty line at the beginning of an integer value")
 		return
 	}
 
@@ -137,7 +300,7 @@ func (p *Parser) ParseInt() (v int64, err error) {
 	p.skipLine()
 	return
 failure:
-	err = fmt.Errorf("objconv/resp: expected integer value but found %#v", string(line))
+	err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: expected integer value but found %#v", string(line))
 	return
 }
 
@@ -146,7 +309,34 @@ func (p *Parser) ParseUint() (v uint64, err error) {
 }
 
 func (p *Parser) ParseFloat() (v float64, err error) {
-	panic("objconv/resp: ParseFloat should never be called because RESP has no floating point type, this is likely a bug in the decoder code")
+	var line []byte
+
+	if line, err = p.peekLine(); err != nil {
+		return
+	}
+
+	if len(line) == 0 || line[0] != ',' {
+		goto failure
+	}
+
+	switch s := string(line[1:]); s {
+	case "inf":
+		v = math.Inf(1)
+	case "-inf":
+		v = math.Inf(-1)
+	case "nan":
+		v = math.NaN()
+	default:
+		if v, err = strconv.ParseFloat(s, 64); err != nil {
+			goto failure
+		}
+	}
+
+	p.skipLine()
+	return
+failure:
+	err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: expected double value but found %#v", string(line))
+	return
 }
 
 func (p *Parser) ParseString() (v []byte, err error) {
@@ -157,51 +347,58 @@ func (p *Parser) ParseString() (v []byte, err error) {
 	}
 
 	if len(line) == 0 {
-		err = errors.New("objconv/resp: invalid empty line at the beginning of a simple string value")
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: invalid empty line at the beginning of a simple string value")
 		return
 	}
 
-	if line[0] != '+' {
+	switch line[0] {
+	case '+', '(':
+		v = line[1:]
+		p.skipLine()
+
+	case '=':
+		// Verbatim strings start with a three bytes format followed by a
+		// colon, like "txt:", which is not part of the value.
+		if v, err = p.parseChunk(line); err != nil {
+			return
+		}
+		if len(v) < 4 || v[3] != ':' {
+			err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: expected the format of a verbatim string but found %#v", string(v))
+			return
+		}
+		p.n += len(v) + 2
+		v = v[4:]
+
+	default:
 		goto failure
 	}
 
-	v = line[1:]
-	p.skipLine()
 	return
 failure:
-	err = fmt.Errorf("objconv/resp: expected simple string value but found %#v", string(line))
+	err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: expected simple string value but found %#v", string(line))
 	return
 }
 
 func (p *Parser) ParseBytes() (v []byte, err error) {
 	var line []byte
-	var size int64
 
 	if line, err = p.peekLine(); err != nil {
 		return
 	}
 
 	if len(line) == 0 {
-		err = errors.New("objconv/resp: invalid empty line at the beginning of a bulk string value")
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: invalid empty line at the beginning of a bulk string value")
 		return
 	}
 
 	if line[0] != '$' {
-		goto failure
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: expected bulk string value but found %#v", string(line))
+		return
 	}
 
-	if size, err = objutil.ParseInt(line[1:]); err != nil || size < 0 || size > int64(objutil.IntMax) {
-		goto failure
+	if v, err = p.parseChunk(line); err == nil {
+		p.n += len(v) + 2
 	}
-	p.skipLine()
-
-	if v, err = p.peekChunk(int(size)); err != nil {
-		return
-	}
-	p.n += len(v) + 2
-	return
-failure:
-	err = fmt.Errorf("objconv/resp: expected bulk string value but found %#v", string(line))
 	return
 }
 
@@ -221,19 +418,30 @@ func (p *Parser) ParseError() (v error, err error) {
 	}
 
 	if len(line) == 0 {
-		err = errors.New("objconv/resp: invalid empty line at the beginning of an error value")
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: invalid empty line at the beginning of an error value")
 		return
 	}
 
-	if line[0] != '-' {
+	switch line[0] {
+	case '-':
+		v = NewError(string(line[1:]))
+		p.skipLine()
+
+	case '!':
+		var b []byte
+		if b, err = p.parseChunk(line); err != nil {
+			return
+		}
+		p.n += len(b) + 2
+		v = NewError(string(b))
+
+	default:
 		goto failure
 	}
 
-	v = NewError(string(line[1:]))
-	p.skipLine()
 	return
 failure:
-	err = fmt.Errorf("objconv/resp: expected simple string value but found %#v", string(line))
+	err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: expected simple string value but found %#v", string(line))
 	return
 }
 
@@ -246,11 +454,13 @@ func (p *Parser) ParseArrayBegin() (n int, err error) {
 	}
 
 	if len(line) == 0 {
-		err = errors.New("objconv/resp: invalid empty line at the beginning of an array value")
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: invalid empty line at the beginning of an array value")
 		return
 	}
 
-	if line[0] != '*' {
+	switch line[0] {
+	case '*', '~', '>':
+	default:
 		goto failure
 	}
 
@@ -262,7 +472,7 @@ func (p *Parser) ParseArrayBegin() (n int, err error) {
 	n = int(size)
 	return
 failure:
-	err = fmt.Errorf("objconv/resp: expected bulk string value but found %#v", string(line))
+	err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: expected bulk string value but found %#v", string(line))
 	return
 }
 
@@ -275,19 +485,108 @@ func (p *Parser) ParseArrayNext(n int) (err error) {
 }
 
 func (p *Parser) ParseMapBegin() (n int, err error) {
-	panic("objconv/resp: ParseMapBegin should never be called because RESP has no map type, this is likely a bug in the decoder code")
+	var line []byte
+
+	if line, err = p.peekLine(); err != nil {
+		return
+	}
+
+	if len(line) == 0 || line[0] != '%' {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: expected map value but found %#v", string(line))
+		return
+	}
+
+	return p.parseSize(line)
 }
 
 func (p *Parser) ParseMapEnd(n int) (err error) {
-	panic("objconv/resp: ParseMapEnd should never be called because RESP has no map type, this is likely a bug in the decoder code")
+	return
 }
 
 func (p *Parser) ParseMapValue(n int) (err error) {
-	panic("objconv/resp: ParseMapValue should never be called because RESP has no map type, this is likely a bug in the decoder code")
+	return
 }
 
 func (p *Parser) ParseMapNext(n int) (err error) {
-	panic("objconv/resp: ParseMapNext should never be called because RESP has no map type, this is likely a bug in the decoder code")
+	return
+}
+
+// parseAttributes parses the attribute map at the beginning of line, and
+// merges its entries into the attributes of the parser.
+func (p *Parser) parseAttributes() (err error) {
+	var line []byte
+	var n int
+
+	if line, err = p.peekLine(); err != nil {
+		return
+	}
+
+	if n, err = p.parseSize(line); err != nil {
+		return
+	}
+
+	if p.attrs == nil {
+		p.attrs = make(map[string]interface{}, n)
+	}
+
+	d := objconv.Decoder{Parser: p}
+
+	for i := 0; i != n; i++ {
+		var k string
+		var v interface{}
+
+		if err = d.Decode(&k); err != nil {
+			return
+		}
+		if err = d.Decode(&v); err != nil {
+			return
+		}
+
+		p.attrs[k] = v
+	}
+
+	return
+}
+
+// parseSize parses the size of the aggregate value starting with line, and
+// skips the line.
+func (p *Parser) parseSize(line []byte) (n int, err error) {
+	size, err := objutil.ParseInt(line[1:])
+
+	if err != nil || size < 0 || size > int64(objutil.IntMax) {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: expected the size of an aggregate value but found %#v", string(line))
+		return
+	}
+
+	p.skipLine()
+	n = int(size)
+	return
+}
+
+// parseChunk parses the size of the blob value starting with line, and returns
+// its content. The caller is expected to advance the parser past the content
+// and its CRLF sequence.
+func (p *Parser) parseChunk(line []byte) (chunk []byte, err error) {
+	size, err := objutil.ParseInt(line[1:])
+
+	if err != nil || size < 0 || size > int64(objutil.IntMax) {
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: expected the size of a blob value but found %#v", string(line))
+		return
+	}
+
+	if p.maxStringLen > 0 && size > int64(p.maxStringLen) {
+		err = objutil.Errorf(objutil.ErrLimit, "objconv/resp: the input has a string of %d bytes, which exceeds the limit of %d bytes", size, p.maxStringLen)
+		return
+	}
+
+	p.skipLine()
+	return p.peekChunk(int(size))
+}
+
+// SetMaxStringLen satisfies the objconv.LimitParser interface, the sizes of
+// blob values are verified before they are loaded.
+func (p *Parser) SetMaxStringLen(n int) {
+	p.maxStringLen = n
 }
 
 func (p *Parser) peekLine() (line []byte, err error) {
@@ -307,6 +606,7 @@ func (p *Parser) peekLine() (line []byte, err error) {
 		}
 
 		if p.n != 0 { // pack
+			p.off += int64(p.n)
 			copy(p.s, p.s[p.n:])
 			p.s = p.s[:len(p.s)-p.n]
 			p.n = 0
@@ -349,7 +649,7 @@ func (p *Parser) peekChunk(size int) (chunk []byte, err error) {
 	chunk = p.s[p.n : p.n+size]
 
 	if !bytes.HasSuffix(chunk, crlfBytes[:]) {
-		err = fmt.Errorf("objconv/resp: expected a CRLF sequence at the end of a bulk string but found %#v", string(chunk))
+		err = objutil.Errorf(objutil.ErrSyntax, "objconv/resp: expected a CRLF sequence at the end of a bulk string but found %#v", string(chunk))
 	} else {
 		chunk = chunk[:len(chunk)-2]
 	}
@@ -379,3 +679,12 @@ func bytesIndexCRLF(b []byte) int {
 	}
 	return -1
 }
+
+// isRESP3 returns true if c is a type token which only exists in RESP3.
+func isRESP3(c byte) bool {
+	switch c {
+	case '_', ',', '#', '(', '=', '!', '%', '~', '>', '|':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package resp
+
+import (
+	"reflect"
+
+	"github.com/segmentio/objconv"
+)
+
+// Push represents RESP3 push messages, which servers send to clients out of
+// band, like the messages of pub/sub channels.
+//
+// Push values are encoded as push messages by RESP3 emitters, and as arrays by
+// the other emitters. RESP3 push messages are decoded to Push values when the
+// destination is an empty interface.
+type Push []interface{}
+
+// Set represents RESP3 sets, which are arrays of unordered and unique
+// elements.
+//
+// Set values are encoded as sets by RESP3 emitters, and as arrays by the other
+// emitters. RESP3 sets are decoded to Set values when the destination is an
+// empty interface.
+type Set []interface{}
+
+var sliceInterfaceType = reflect.TypeOf([]interface{}(nil))
+
+func init() {
+	objconv.Install(pushType, objconv.Adapter{
+		Encode: func(e objconv.Encoder, v reflect.Value) error {
+			if x, ok := e.Emitter.(interface{ EmitPush() error }); ok {
+				if err := x.EmitPush(); err != nil {
+					return err
+				}
+			}
+			return e.Encode(v.Convert(sliceInterfaceType).Interface())
+		},
+		Decode: decodeAggregate,
+	})
+	objconv.Install(setType, objconv.Adapter{
+		Encode: func(e objconv.Encoder, v reflect.Value) error {
+			if x, ok := e.Emitter.(interface{ EmitSet() error }); ok {
+				if err := x.EmitSet(); err != nil {
+					return err
+				}
+			}
+			return e.Encode(v.Convert(sliceInterfaceType).Interface())
+		},
+		Decode: decodeAggregate,
+	})
+}
+
+// decodeAggregate decodes an array to v, which is a Push or a Set value.
+func decodeAggregate(d objconv.Decoder, v reflect.Value) error {
+	var a []interface{}
+
+	if err := d.Decode(&a); err != nil {
+		return err
+	}
+
+	v.Set(reflect.ValueOf(a).Convert(v.Type()))
+	return nil
+}
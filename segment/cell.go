@@ -0,0 +1,187 @@
+package segment
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// format returns the text representation of v.
+func format(f *valueField, v reflect.Value) (string, error) {
+	e := &cellEmitter{f: f}
+
+	if err := (objconv.Encoder{Emitter: e}).Encode(v.Interface()); err != nil {
+		return "", err
+	}
+
+	return e.s, nil
+}
+
+// parse decodes the text representation s into v.
+func parse(f *valueField, s string, v reflect.Value) error {
+	p := &cellParser{f: f, s: s, t: typeOf(v.Type())}
+
+	if len(s) == 0 {
+		p.t = objconv.Nil
+	}
+
+	return (objconv.Decoder{Parser: p}).Decode(v.Addr().Interface())
+}
+
+// cellEmitter is an objconv.Emitter which captures the text representation of
+// a single value.
+type cellEmitter struct {
+	f *valueField
+	s string
+}
+
+func (e *cellEmitter) EmitNil() error {
+	e.s = ""
+	return nil
+}
+
+func (e *cellEmitter) EmitBool(v bool) error {
+	e.s = strconv.FormatBool(v)
+	return nil
+}
+
+func (e *cellEmitter) EmitInt(v int64, _ int) error {
+	e.s = strconv.FormatInt(v, 10)
+	return nil
+}
+
+func (e *cellEmitter) EmitUint(v uint64, _ int) error {
+	e.s = strconv.FormatUint(v, 10)
+	return nil
+}
+
+func (e *cellEmitter) EmitFloat(v float64, bitSize int) error {
+	e.s = strconv.FormatFloat(v, 'f', -1, bitSize)
+	return nil
+}
+
+func (e *cellEmitter) EmitString(v string) error {
+	e.s = v
+	return nil
+}
+
+func (e *cellEmitter) EmitBytes(v []byte) error {
+	e.s = string(v)
+	return nil
+}
+
+func (e *cellEmitter) EmitTime(v time.Time) error {
+	if !v.IsZero() {
+		e.s = v.Format(e.f.layout)
+	}
+	return nil
+}
+
+func (e *cellEmitter) EmitDuration(v time.Duration) error {
+	e.s = v.String()
+	return nil
+}
+
+func (e *cellEmitter) EmitError(v error) error {
+	e.s = v.Error()
+	return nil
+}
+
+func (e *cellEmitter) EmitArrayBegin(int) error { return nestingError() }
+func (e *cellEmitter) EmitArrayEnd() error      { return nil }
+func (e *cellEmitter) EmitArrayNext() error     { return nil }
+func (e *cellEmitter) EmitMapBegin(int) error   { return nestingError() }
+func (e *cellEmitter) EmitMapEnd() error        { return nil }
+func (e *cellEmitter) EmitMapNext() error       { return nil }
+func (e *cellEmitter) EmitMapValue() error      { return nil }
+
+// TextEmitter satisfies the objconv.textEmitter interface.
+func (e *cellEmitter) TextEmitter() bool { return true }
+
+var (
+	durationType             = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerInterface = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// typeOf returns the type of the values that fields of type t are decoded
+// from.
+func typeOf(t reflect.Type) objconv.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return objconv.Time
+	}
+
+	if _, ok := objconv.AdapterOf(t); ok || reflect.PtrTo(t).Implements(textUnmarshalerInterface) {
+		return objconv.String
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return objconv.Bool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t == durationType {
+			return objconv.String
+		}
+		return objconv.Int
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return objconv.Uint
+	case reflect.Float32, reflect.Float64:
+		return objconv.Float
+	}
+
+	return objconv.String
+}
+
+// cellParser is an objconv.Parser which presents the text of a field as a
+// single value of the type expected by the field.
+type cellParser struct {
+	f *valueField
+	s string
+	t objconv.Type
+}
+
+func (p *cellParser) ParseType() (objconv.Type, error) { return p.t, nil }
+
+func (p *cellParser) ParseNil() error { return nil }
+
+func (p *cellParser) ParseBool() (bool, error) { return strconv.ParseBool(p.s) }
+
+func (p *cellParser) ParseInt() (int64, error) { return strconv.ParseInt(p.s, 10, 64) }
+
+func (p *cellParser) ParseUint() (uint64, error) { return strconv.ParseUint(p.s, 10, 64) }
+
+func (p *cellParser) ParseFloat() (float64, error) { return strconv.ParseFloat(p.s, 64) }
+
+func (p *cellParser) ParseString() ([]byte, error) { return []byte(p.s), nil }
+
+func (p *cellParser) ParseBytes() ([]byte, error) { return []byte(p.s), nil }
+
+func (p *cellParser) ParseTime() (time.Time, error) { return time.Parse(p.f.layout, p.s) }
+
+func (p *cellParser) ParseDuration() (time.Duration, error) { return time.ParseDuration(p.s) }
+
+func (p *cellParser) ParseError() (error, error) {
+	panic("objconv/segment: ParseError should never be called because fields have no error type, this is likely a bug in the decoder code")
+}
+
+func (p *cellParser) ParseArrayBegin() (int, error) { return 0, nestingError() }
+func (p *cellParser) ParseArrayEnd(int) error       { return nil }
+func (p *cellParser) ParseArrayNext(int) error      { return nil }
+func (p *cellParser) ParseMapBegin() (int, error)   { return 0, nestingError() }
+func (p *cellParser) ParseMapEnd(int) error         { return nil }
+func (p *cellParser) ParseMapValue(int) error       { return nil }
+func (p *cellParser) ParseMapNext(int) error        { return nil }
+
+// TextParser satisfies the objconv.textParser interface.
+func (p *cellParser) TextParser() bool { return true }
+
+func nestingError() error {
+	return objutil.Errorf(objutil.ErrType, "fields cannot hold arrays or maps")
+}
@@ -0,0 +1,294 @@
+package segment
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Decoder reads messages of a segmented text format from an input stream.
+type Decoder struct {
+	f    Format
+	r    *bufio.Reader
+	next string // segment read ahead of time, empty if none
+	init bool   // set when the beginning of the input was read
+}
+
+// NewDecoder returns a new decoder that reads messages of format f from r.
+func (f Format) NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{f: f, r: bufio.NewReader(r)}
+}
+
+// Unmarshal decodes the message in b, represented in format f, into v.
+func (f Format) Unmarshal(b []byte, v interface{}) error {
+	return f.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+// Decode reads the next message from the input stream into v, which must be a
+// pointer to a message struct.
+//
+// A message starts with the segment held by the first field of the struct,
+// and ends at the end of the input or where the same segment appears again,
+// so streams of messages are decoded by calling Decode until it returns
+// io.EOF.
+//
+// When the format has a header or advice segment, the separators that they
+// declare are used to decode the rest of the input.
+func (d *Decoder) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return objutil.Errorf(objutil.ErrType, "objconv/segment: cannot decode into a value of type %T", v)
+	}
+
+	rv = rv.Elem()
+
+	l, err := messageLayoutOf(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	if !d.init {
+		d.init = true
+
+		if err = d.readAdvice(); err != nil {
+			return err
+		}
+	}
+
+	seen := make([]bool, len(l.segments))
+
+	for n := 0; ; n++ {
+		s, err := d.readSegment()
+
+		if err != nil {
+			if err == io.EOF && n != 0 {
+				err = checkRequired(l, seen)
+			}
+			return err
+		}
+
+		tag := s
+
+		if h := d.f.Header; len(h) != 0 && strings.HasPrefix(s, h) {
+			// The header may declare a different field separator.
+			tag = h
+		} else if i := strings.IndexByte(s, d.f.Field); i >= 0 {
+			tag = s[:i]
+		}
+
+		if n != 0 && tag == l.segments[0].tag {
+			d.next = s
+			return checkRequired(l, seen)
+		}
+
+		i, ok := l.tags[tag]
+
+		if !ok {
+			continue
+		}
+
+		f := &l.segments[i]
+		fv := rv.Field(f.index)
+
+		if f.kind != repeated && seen[i] {
+			return objutil.Errorf(objutil.ErrSyntax, "objconv/segment: the %s segment appears multiple times in the message", tag)
+		}
+
+		seen[i] = true
+
+		switch f.kind {
+		case optional:
+			fv.Set(reflect.New(fv.Type().Elem()))
+			fv = fv.Elem()
+
+		case repeated:
+			fv.Set(reflect.Append(fv, reflect.New(fv.Type().Elem()).Elem()))
+			fv = fv.Index(fv.Len() - 1)
+		}
+
+		if err = d.decodeSegment(s, f, fv); err != nil {
+			return err
+		}
+	}
+}
+
+func checkRequired(l *messageLayout, seen []bool) error {
+	for i := range l.segments {
+		if s := &l.segments[i]; s.kind == required && !seen[i] {
+			return objutil.Errorf(objutil.ErrSyntax, "objconv/segment: the %s segment is missing from the message", s.tag)
+		}
+	}
+	return nil
+}
+
+// readAdvice reads the advice segment at the beginning of the input, if any,
+// and configures the separators of the decoder.
+func (d *Decoder) readAdvice() error {
+	n := len(d.f.Advice)
+
+	if n == 0 {
+		return nil
+	}
+
+	b, _ := d.r.Peek(n + 6)
+
+	if len(b) != n+6 || string(b[:n]) != d.f.Advice {
+		return nil
+	}
+
+	d.f.Component = b[n]
+	d.f.Field = b[n+1]
+	d.f.Release = b[n+3]
+	d.f.Segment = b[n+5]
+	_, err := d.r.Discard(n + 6)
+	return err
+}
+
+// readSegment returns the next segment of the input, without its terminator.
+func (d *Decoder) readSegment() (string, error) {
+	if s := d.next; len(s) != 0 {
+		d.next = ""
+		return s, nil
+	}
+
+	var b []byte
+
+	for {
+		c, err := d.r.ReadByte()
+
+		if err != nil {
+			if err == io.EOF && len(b) != 0 {
+				err = nil
+				break
+			}
+			return "", err
+		}
+
+		switch {
+		case c == d.f.Segment:
+			if len(b) != 0 {
+				return string(b), nil
+			}
+
+		case (c == '\r' || c == '\n') && len(b) == 0:
+			// line breaks between segments
+
+		case c == d.f.Release && d.f.Release != 0:
+			b = append(b, c)
+
+			if c, err = d.r.ReadByte(); err == nil {
+				b = append(b, c)
+			}
+
+		default:
+			b = append(b, c)
+		}
+	}
+
+	return string(b), nil
+}
+
+func (d *Decoder) decodeSegment(s string, seg *segmentField, v reflect.Value) error {
+	header := seg.tag == d.f.Header
+
+	if header {
+		d.readHeader(s)
+	}
+
+	values := d.f.split(s, d.f.Field)
+
+	if header {
+		// Position 1 of the header is the field separator, the other
+		// positions are shifted by one.
+		values = append([]string{values[0], string(d.f.Field)}, values[1:]...)
+	}
+
+	for i := range seg.layout.fields {
+		f := &seg.layout.fields[i]
+
+		if f.pos >= len(values) || len(values[f.pos]) == 0 {
+			continue
+		}
+
+		var err error
+
+		if header && f.pos <= 2 {
+			err = parse(f, values[f.pos], v.Field(f.index))
+		} else {
+			err = d.decodeField(f, values[f.pos], v.Field(f.index))
+		}
+
+		if err != nil {
+			return objutil.Errorf(kindOf(err), "objconv/segment: %s: %s", position(seg.tag, f.pos), err)
+		}
+	}
+
+	return nil
+}
+
+// readHeader configures the separators of the decoder from the header segment
+// s.
+func (d *Decoder) readHeader(s string) {
+	s = s[len(d.f.Header):]
+
+	if len(s) == 0 {
+		return
+	}
+
+	d.f.Field, s = s[0], s[1:]
+
+	if i := strings.IndexByte(s, d.f.Field); i >= 0 {
+		s = s[:i]
+	}
+
+	for i, c := range []*byte{&d.f.Component, &d.f.Repetition, &d.f.Escape, &d.f.Subcomponent} {
+		if i < len(s) {
+			*c = s[i]
+		}
+	}
+}
+
+func (d *Decoder) decodeField(f *valueField, s string, v reflect.Value) error {
+	reps := d.f.split(s, d.f.Repetition)
+
+	if !f.repeated {
+		return d.decodeValue(f, reps[0], v)
+	}
+
+	v.Set(reflect.MakeSlice(v.Type(), len(reps), len(reps)))
+
+	for i, r := range reps {
+		if err := d.decodeValue(f, r, v.Index(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Decoder) decodeValue(f *valueField, s string, v reflect.Value) error {
+	comps := d.f.split(s, d.f.Component)
+
+	if f.components == nil {
+		return parse(f, d.f.unescape(comps[0]), v)
+	}
+
+	for i := range f.components {
+		c := &f.components[i]
+
+		if c.pos > len(comps) {
+			continue
+		}
+
+		if err := parse(c, d.f.unescape(comps[c.pos-1]), v.Field(c.index)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
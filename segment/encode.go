@@ -0,0 +1,209 @@
+package segment
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Encoder writes messages of a segmented text format to an output stream.
+type Encoder struct {
+	f      Format
+	w      io.Writer
+	b      []byte
+	advice bool // set when the advice segment was written
+}
+
+// NewEncoder returns a new encoder that writes messages of format f to w.
+func (f Format) NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{f: f, w: w}
+}
+
+// Marshal returns the representation of the message v in format f.
+func (f Format) Marshal(v interface{}) (b []byte, err error) {
+	buf := &bytes.Buffer{}
+
+	if err = f.NewEncoder(buf).Encode(v); err == nil {
+		b = buf.Bytes()
+	}
+
+	return
+}
+
+// Encode writes the message v to the output stream of the encoder. When the
+// format has an advice segment, it is written before the first message.
+func (e *Encoder) Encode(v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+
+	if !rv.IsValid() {
+		return objutil.Errorf(objutil.ErrType, "objconv/segment: cannot encode a nil message")
+	}
+
+	l, err := messageLayoutOf(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	b := e.b[:0]
+
+	if len(e.f.Advice) != 0 && !e.advice {
+		e.advice = true
+		b = append(b, e.f.Advice...)
+		b = append(b, e.f.Component, e.f.Field, '.', e.f.Release, ' ', e.f.Segment)
+		b = e.lineBreak(b)
+	}
+
+	for i := range l.segments {
+		s := &l.segments[i]
+		fv := rv.Field(s.index)
+
+		switch s.kind {
+		case required:
+			b, err = e.encodeSegment(b, s, fv)
+
+		case optional:
+			if !fv.IsNil() {
+				b, err = e.encodeSegment(b, s, fv.Elem())
+			}
+
+		case repeated:
+			for j, n := 0, fv.Len(); j != n && err == nil; j++ {
+				b, err = e.encodeSegment(b, s, fv.Index(j))
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	e.b = b
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *Encoder) lineBreak(b []byte) []byte {
+	if e.f.LineBreaks {
+		b = append(b, '\n')
+	}
+	return b
+}
+
+func (e *Encoder) encodeSegment(b []byte, s *segmentField, v reflect.Value) ([]byte, error) {
+	header := s.tag == e.f.Header
+	values := []string{s.tag}
+
+	for i := range s.layout.fields {
+		f := &s.layout.fields[i]
+
+		for len(values) <= f.pos {
+			values = append(values, "")
+		}
+
+		var x string
+		var err error
+
+		switch {
+		case header && f.pos == 1:
+			continue
+		case header && f.pos == 2:
+			// The encoding characters are written verbatim.
+			x, err = format(f, v.Field(f.index))
+		default:
+			x, err = e.encodeField(f, v.Field(f.index))
+		}
+
+		if err != nil {
+			return b, objutil.Errorf(kindOf(err), "objconv/segment: %s: %s", position(s.tag, f.pos), err)
+		}
+
+		values[f.pos] = x
+	}
+
+	if header {
+		// The first field of the header is the field separator itself, which
+		// is written by joining the values.
+		for len(values) <= 2 {
+			values = append(values, "")
+		}
+		if len(values[2]) == 0 {
+			values[2] = e.f.encodingCharacters()
+		}
+		values = append(values[:1], values[2:]...)
+	}
+
+	b = append(b, join(values, e.f.Field)...)
+	b = append(b, e.f.Segment)
+	return e.lineBreak(b), nil
+}
+
+func (e *Encoder) encodeField(f *valueField, v reflect.Value) (string, error) {
+	if !f.repeated {
+		return e.encodeValue(f, v)
+	}
+
+	n := v.Len()
+
+	if n > 1 && e.f.Repetition == 0 {
+		return "", objutil.Errorf(objutil.ErrType, "the format has no repetition separator, found %d values", n)
+	}
+
+	reps := make([]string, n)
+
+	for i := range reps {
+		s, err := e.encodeValue(f, v.Index(i))
+		if err != nil {
+			return "", err
+		}
+		reps[i] = s
+	}
+
+	return join(reps, e.f.Repetition), nil
+}
+
+func (e *Encoder) encodeValue(f *valueField, v reflect.Value) (s string, err error) {
+	if f.components == nil {
+		if s, err = format(f, v); err == nil {
+			s, err = e.f.escape(s)
+		}
+		return
+	}
+
+	var comps []string
+
+	for i := range f.components {
+		c := &f.components[i]
+
+		for len(comps) < c.pos {
+			comps = append(comps, "")
+		}
+
+		if s, err = format(c, v.Field(c.index)); err != nil {
+			return
+		}
+
+		if comps[c.pos-1], err = e.f.escape(s); err != nil {
+			return
+		}
+	}
+
+	return join(comps, e.f.Component), nil
+}
+
+// position formats the HL7-style reference to a field of a segment.
+func position(tag string, pos int) string {
+	return tag + "-" + strconv.Itoa(pos)
+}
+
+func kindOf(err error) error {
+	for _, kind := range [...]error{objutil.ErrSyntax, objutil.ErrType, objutil.ErrRange} {
+		if errors.Is(err, kind) {
+			return kind
+		}
+	}
+	return objutil.ErrSyntax
+}
@@ -0,0 +1,184 @@
+package segment
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// messageLayout describes how a message struct maps to segments.
+type messageLayout struct {
+	segments []segmentField
+	tags     map[string]int // segment identifiers => index in segments
+}
+
+type segmentKind int
+
+const (
+	required segmentKind = iota
+	optional
+	repeated
+)
+
+type segmentField struct {
+	tag    string
+	index  int
+	kind   segmentKind
+	layout *segmentLayout
+}
+
+// segmentLayout describes how a segment struct maps to fields.
+type segmentLayout struct {
+	typ    reflect.Type
+	fields []valueField
+}
+
+type valueField struct {
+	pos        int
+	index      int
+	name       string
+	repeated   bool
+	typ        reflect.Type // the type of values, or elements of repeated fields
+	components []valueField // set when the values are structs of components
+	layout     string       // layout of time values
+}
+
+var (
+	messageLayouts sync.Map // reflect.Type => *messageLayout
+	segmentLayouts sync.Map // reflect.Type => *segmentLayout
+
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+func messageLayoutOf(t reflect.Type) (*messageLayout, error) {
+	if l, ok := messageLayouts.Load(t); ok {
+		return l.(*messageLayout), nil
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, objutil.Errorf(objutil.ErrType, "objconv/segment: messages must be structs, found %s", t)
+	}
+
+	l := &messageLayout{tags: make(map[string]int)}
+
+	for i, n := 0, t.NumField(); i != n; i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("segment")
+
+		if !ok || tag == "-" || len(sf.PkgPath) != 0 {
+			continue
+		}
+
+		f := segmentField{tag: tag, index: i}
+		st := sf.Type
+
+		switch st.Kind() {
+		case reflect.Ptr:
+			f.kind, st = optional, st.Elem()
+		case reflect.Slice:
+			f.kind, st = repeated, st.Elem()
+		}
+
+		if _, dup := l.tags[tag]; dup {
+			return nil, objutil.Errorf(objutil.ErrType, "objconv/segment: %s.%s: the %s segment is held by multiple fields", t, sf.Name, tag)
+		}
+
+		s, err := segmentLayoutOf(st)
+		if err != nil {
+			return nil, err
+		}
+
+		f.layout = s
+		l.tags[tag] = len(l.segments)
+		l.segments = append(l.segments, f)
+	}
+
+	if len(l.segments) == 0 {
+		return nil, objutil.Errorf(objutil.ErrType, "objconv/segment: %s has no fields with a segment tag", t)
+	}
+
+	v, _ := messageLayouts.LoadOrStore(t, l)
+	return v.(*messageLayout), nil
+}
+
+func segmentLayoutOf(t reflect.Type) (*segmentLayout, error) {
+	if l, ok := segmentLayouts.Load(t); ok {
+		return l.(*segmentLayout), nil
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, objutil.Errorf(objutil.ErrType, "objconv/segment: segments must be structs, found %s", t)
+	}
+
+	fields, err := valueFieldsOf(t, true)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &segmentLayout{typ: t, fields: fields}
+	v, _ := segmentLayouts.LoadOrStore(t, l)
+	return v.(*segmentLayout), nil
+}
+
+// valueFieldsOf returns the fields of the struct type t, which is either a
+// segment when composite is true, or a struct of components.
+func valueFieldsOf(t reflect.Type, composite bool) (fields []valueField, err error) {
+	for i, n := 0, t.NumField(); i != n; i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("segment")
+
+		if !ok || tag == "-" || len(sf.PkgPath) != 0 {
+			continue
+		}
+
+		f := valueField{index: i, name: sf.Name, typ: sf.Type, layout: time.RFC3339}
+		pos, opts, _ := strings.Cut(tag, ",")
+
+		if f.pos, err = strconv.Atoi(pos); err != nil || f.pos <= 0 {
+			return nil, objutil.Errorf(objutil.ErrType, "objconv/segment: %s.%s: invalid position: %q", t, sf.Name, pos)
+		}
+
+		for len(opts) != 0 {
+			var opt string
+
+			if opt, opts, _ = strings.Cut(opts, ","); strings.HasPrefix(opt, "layout=") {
+				f.layout = opt[7:]
+			} else {
+				return nil, objutil.Errorf(objutil.ErrType, "objconv/segment: %s.%s: unknown tag option: %q", t, sf.Name, opt)
+			}
+		}
+
+		if composite && f.typ.Kind() == reflect.Slice && f.typ.Elem().Kind() != reflect.Uint8 {
+			f.repeated, f.typ = true, f.typ.Elem()
+		}
+
+		if composite && isComponents(f.typ) {
+			if f.components, err = valueFieldsOf(f.typ, false); err != nil {
+				return nil, err
+			}
+		}
+
+		fields = append(fields, f)
+	}
+
+	return
+}
+
+// isComponents returns true if values of type t are structs of components.
+func isComponents(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+
+	for i, n := 0, t.NumField(); i != n; i++ {
+		if _, ok := t.Field(i).Tag.Lookup("segment"); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,319 @@
+// Package segment implements a framework for segmented text formats, the
+// family of EDI formats like HL7 v2, EDIFACT or X12 where messages are
+// sequences of segments made of fields, themselves made of components.
+//
+// A format is a configuration of the separators and escaping rules, the
+// package provides the HL7, EDIFACT and X12 formats, others can be declared
+// as Format values. Messages are mapped to struct types with `segment` tags,
+// fields of a message struct are tagged with the identifier of the segment
+// they hold, and fields of segment structs with their positions:
+//
+//	type PatientName struct {
+//		Family string `segment:"1"`
+//		Given  string `segment:"2"`
+//	}
+//
+//	type PID struct {
+//		ID    []string    `segment:"3"`
+//		Name  PatientName `segment:"5"`
+//		Birth time.Time   `segment:"7,layout=20060102"`
+//	}
+//
+//	type Message struct {
+//		MSH MSH   `segment:"MSH"`
+//		PID *PID  `segment:"PID"`
+//		OBX []OBX `segment:"OBX"`
+//	}
+//
+//	var m Message
+//	err := segment.HL7.Unmarshal(b, &m)
+//
+// Fields of message structs are either structs (required segments), pointers
+// to structs (optional segments), or slices of structs (repeated segments).
+// Segments that the message struct has no fields for are ignored.
+//
+// Positions of segment fields start at 1, the segment identifier being at
+// position 0. Fields of segment structs are either values, structs of
+// components tagged with their positions within the field, or slices of those
+// when the field repeats. Values are converted with the objconv algorithms, so
+// adapters and types implementing encoding.TextMarshaler can be used. A value
+// read from a field made of multiple components receives the first one.
+package segment
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Format describes the syntax of a segmented text format.
+type Format struct {
+	// Segment is the character terminating segments.
+	Segment byte
+
+	// Field is the character separating the fields of segments.
+	Field byte
+
+	// Component is the character separating the components of fields.
+	Component byte
+
+	// Repetition is the character separating the repetitions of fields, zero
+	// if the format has no repeated fields.
+	Repetition byte
+
+	// Subcomponent is the character separating the subcomponents of
+	// components, zero if the format has no subcomponents. Subcomponents are
+	// not mapped to struct fields, the character is only escaped in values.
+	Subcomponent byte
+
+	// Release is the character which makes the following character part of
+	// a value instead of a separator, like '?' in EDIFACT.
+	Release byte
+
+	// Escape is the character delimiting the escape sequences of HL7, where
+	// \F\, \S\, \R\, \E\ and \T\ stand for the field, component, repetition,
+	// escape and subcomponent characters, and \Xhh\ for hexadecimal characters. Other escape
+	// sequences are left as-is.
+	Escape byte
+
+	// Header is the identifier of a segment which declares the separators in
+	// its first field, like the MSH segment of HL7. Position 1 of the header
+	// is the field separator and position 2 holds the other separators, it is
+	// written automatically when the struct field is empty.
+	Header string
+
+	// Advice is the identifier of an optional segment at the beginning of
+	// messages which redefines the separators, like the UNA service string
+	// advice of EDIFACT. The advice has a fixed layout: the identifier is
+	// followed by the component, field, decimal, release, reserved and
+	// segment characters.
+	Advice string
+
+	// LineBreaks makes encoders write a line break after each segment, which
+	// eases reading messages in text editors. Decoders always ignore line
+	// breaks at the beginning of segments.
+	LineBreaks bool
+}
+
+var (
+	// HL7 is the format of HL7 v2 messages.
+	HL7 = Format{
+		Segment:      '\r',
+		Field:        '|',
+		Component:    '^',
+		Repetition:   '~',
+		Escape:       '\\',
+		Subcomponent: '&',
+		Header:       "MSH",
+	}
+
+	// EDIFACT is the format of UN/EDIFACT interchanges.
+	EDIFACT = Format{
+		Segment:   '\'',
+		Field:     '+',
+		Component: ':',
+		Release:   '?',
+		Advice:    "UNA",
+	}
+
+	// X12 is the format of ANSI X12 interchanges.
+	X12 = Format{
+		Segment:    '~',
+		Field:      '*',
+		Component:  ':',
+		Repetition: '^',
+	}
+)
+
+// encodingCharacters returns the value of the field of header segments which
+// declares the separators.
+func (f *Format) encodingCharacters() string {
+	b := []byte{f.Component}
+
+	if f.Repetition != 0 {
+		b = append(b, f.Repetition)
+	}
+
+	if f.Escape != 0 {
+		b = append(b, f.Escape)
+	}
+
+	if f.Subcomponent != 0 {
+		b = append(b, f.Subcomponent)
+	}
+
+	return string(b)
+}
+
+// split splits s around the unreleased occurrences of sep.
+func (f *Format) split(s string, sep byte) []string {
+	if sep == 0 {
+		return []string{s}
+	}
+
+	var parts []string
+	var i, j int
+
+	for j < len(s) {
+		switch s[j] {
+		case f.Release:
+			if f.Release != 0 {
+				j++
+			}
+		case sep:
+			parts = append(parts, s[i:j])
+			i = j + 1
+		}
+		j++
+	}
+
+	if i > len(s) {
+		i = len(s)
+	}
+
+	return append(parts, s[i:])
+}
+
+// join joins the parts with sep, discarding empty trailing parts.
+func join(parts []string, sep byte) string {
+	n := len(parts)
+
+	for n != 0 && len(parts[n-1]) == 0 {
+		n--
+	}
+
+	return strings.Join(parts[:n], string(sep))
+}
+
+// unescape returns the value represented by s.
+func (f *Format) unescape(s string) string {
+	switch {
+	case f.Release != 0 && strings.IndexByte(s, f.Release) >= 0:
+		b := make([]byte, 0, len(s))
+
+		for i := 0; i < len(s); i++ {
+			if s[i] == f.Release && i+1 < len(s) {
+				i++
+			}
+			b = append(b, s[i])
+		}
+
+		return string(b)
+
+	case f.Escape != 0 && strings.IndexByte(s, f.Escape) >= 0:
+		b := make([]byte, 0, len(s))
+
+		for i := 0; i < len(s); i++ {
+			if s[i] == f.Escape {
+				if n := strings.IndexByte(s[i+1:], f.Escape); n > 0 {
+					if c, ok := f.unescapeSequence(s[i+1 : i+1+n]); ok {
+						b = append(b, c...)
+						i += n + 1
+						continue
+					}
+				}
+			}
+			b = append(b, s[i])
+		}
+
+		return string(b)
+	}
+
+	return s
+}
+
+// unescapeSequence returns the characters represented by the escape sequence
+// seq, which has its delimiters removed.
+func (f *Format) unescapeSequence(seq string) ([]byte, bool) {
+	switch seq {
+	case "F":
+		return []byte{f.Field}, true
+	case "S":
+		return []byte{f.Component}, true
+	case "R":
+		return []byte{f.Repetition}, true
+	case "E":
+		return []byte{f.Escape}, true
+	case "T":
+		return []byte{f.Subcomponent}, true
+	}
+
+	if seq[0] == 'X' {
+		if b, err := hex.DecodeString(seq[1:]); err == nil {
+			return b, true
+		}
+	}
+
+	return nil, false
+}
+
+// escape returns the representation of the value s, the value cannot contain
+// separators if the format has neither escape nor release characters.
+func (f *Format) escape(s string) (string, error) {
+	if !f.special(s) {
+		return s, nil
+	}
+
+	if f.Escape == 0 && f.Release == 0 {
+		return "", objutil.Errorf(objutil.ErrSyntax, "%q contains separators and the format has no escape or release characters", s)
+	}
+
+	b := bytes.Buffer{}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if !f.isSeparator(c) {
+			b.WriteByte(c)
+			continue
+		}
+
+		if f.Escape != 0 {
+			b.WriteByte(f.Escape)
+			b.WriteString(f.escapeSequence(c))
+			b.WriteByte(f.Escape)
+		} else {
+			b.WriteByte(f.Release)
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String(), nil
+}
+
+func (f *Format) escapeSequence(c byte) string {
+	switch c {
+	case f.Field:
+		return "F"
+	case f.Component:
+		return "S"
+	case f.Repetition:
+		return "R"
+	case f.Escape:
+		return "E"
+	case f.Subcomponent:
+		return "T"
+	}
+	return "X" + strings.ToUpper(hex.EncodeToString([]byte{c}))
+}
+
+func (f *Format) special(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if f.isSeparator(s[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Format) isSeparator(c byte) bool {
+	switch c {
+	case 0:
+		return false
+	case f.Segment, f.Field, f.Component, f.Repetition, f.Subcomponent, f.Release, f.Escape:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,286 @@
+package segment
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+type msh struct {
+	Separator string `segment:"1"`
+	Encoding  string `segment:"2"`
+	App       string `segment:"3"`
+	Type      string `segment:"9"`
+	ControlID string `segment:"10"`
+	Version   string `segment:"12"`
+}
+
+type name struct {
+	Family string `segment:"1"`
+	Given  string `segment:"2"`
+}
+
+type pid struct {
+	ID    []string  `segment:"3"`
+	Name  name      `segment:"5"`
+	Birth time.Time `segment:"7,layout=20060102"`
+	Sex   string    `segment:"8"`
+}
+
+type obx struct {
+	ID    int     `segment:"1"`
+	Value float64 `segment:"5"`
+	Note  string  `segment:"6"`
+}
+
+type hl7Message struct {
+	MSH msh   `segment:"MSH"`
+	PID *pid  `segment:"PID"`
+	OBX []obx `segment:"OBX"`
+}
+
+func TestHL7(t *testing.T) {
+	m1 := hl7Message{
+		MSH: msh{App: "LAB", Type: "ORU", ControlID: "42", Version: "2.5"},
+		PID: &pid{
+			ID:    []string{"123", "456"},
+			Name:  name{Family: "O|Brien", Given: "Ann^Marie"},
+			Birth: time.Date(1980, 5, 17, 0, 0, 0, 0, time.UTC),
+			Sex:   "F",
+		},
+		OBX: []obx{{ID: 1, Value: 5.5, Note: `a\b`}, {ID: 2, Value: 7}},
+	}
+
+	b, err := HL7.Marshal(m1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = "" +
+		"MSH|^~\\&|LAB||||||ORU|42||2.5\r" +
+		"PID|||123~456||O\\F\\Brien^Ann\\S\\Marie||19800517|F\r" +
+		"OBX|1||||5.5|a\\E\\b\r" +
+		"OBX|2||||7\r"
+
+	if s := string(b); s != expected {
+		t.Errorf("bad message:\n%q\n%q", s, expected)
+	}
+
+	var m2 hl7Message
+	if err := HL7.Unmarshal(b, &m2); err != nil {
+		t.Fatal(err)
+	}
+
+	m1.MSH.Separator, m1.MSH.Encoding = "|", "^~\\&"
+
+	if !reflect.DeepEqual(m1, m2) {
+		t.Errorf("%#v != %#v", m1, m2)
+	}
+}
+
+func TestHL7Separators(t *testing.T) {
+	// The header declares different separators, which are used to decode the
+	// rest of the message, and the PID segment is split across lines.
+	const input = "MSH#*!@#LAB\r\nPID###1!2##Doe*John@X41@\r\n"
+
+	var m hl7Message
+	if err := HL7.Unmarshal([]byte(input), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.MSH.Separator != "#" || m.MSH.Encoding != "*!@" || m.MSH.App != "LAB" {
+		t.Errorf("bad header: %#v", m.MSH)
+	}
+
+	if m.PID == nil {
+		t.Fatal("missing PID segment")
+	}
+
+	if p := *m.PID; !reflect.DeepEqual(p.ID, []string{"1", "2"}) || p.Name != (name{Family: "Doe", Given: "JohnA"}) {
+		t.Errorf("bad patient: %#v", p)
+	}
+}
+
+func TestHL7Stream(t *testing.T) {
+	const input = "" +
+		"MSH|^~\\&|A\rPID|||1\rOBX|1\r" +
+		"MSH|^~\\&|B\rZZZ|unknown\r" +
+		"MSH|^~\\&|C\rPID|||3\r"
+
+	d := HL7.NewDecoder(strings.NewReader(input))
+	apps := []string{}
+	pids := 0
+
+	for {
+		var m hl7Message
+
+		if err := d.Decode(&m); err != nil {
+			if err != io.EOF {
+				t.Fatal(err)
+			}
+			break
+		}
+
+		apps = append(apps, m.MSH.App)
+
+		if m.PID != nil {
+			pids++
+		}
+	}
+
+	if !reflect.DeepEqual(apps, []string{"A", "B", "C"}) || pids != 2 {
+		t.Errorf("bad messages: %v, %d patients", apps, pids)
+	}
+}
+
+type unh struct {
+	Reference string `segment:"1"`
+	Type      struct {
+		ID      string `segment:"1"`
+		Version string `segment:"2"`
+	} `segment:"2"`
+}
+
+type ftx struct {
+	Text string `segment:"4"`
+}
+
+type edifactMessage struct {
+	UNH unh   `segment:"UNH"`
+	FTX []ftx `segment:"FTX"`
+	UNT struct {
+		Count int `segment:"1"`
+	} `segment:"UNT"`
+}
+
+func TestEDIFACT(t *testing.T) {
+	m1 := edifactMessage{FTX: []ftx{{Text: "Is it 5+2? Yes: it's 7"}}}
+	m1.UNH.Reference = "1"
+	m1.UNH.Type.ID = "ORDERS"
+	m1.UNH.Type.Version = "D"
+	m1.UNT.Count = 3
+
+	f := EDIFACT
+	f.LineBreaks = true
+
+	b, err := f.Marshal(m1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const expected = "" +
+		"UNA:+.? '\n" +
+		"UNH+1+ORDERS:D'\n" +
+		"FTX++++Is it 5?+2?? Yes?: it?'s 7'\n" +
+		"UNT+3'\n"
+
+	if s := string(b); s != expected {
+		t.Errorf("bad message:\n%q\n%q", s, expected)
+	}
+
+	var m2 edifactMessage
+	if err := EDIFACT.Unmarshal(b, &m2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(m1, m2) {
+		t.Errorf("%#v != %#v", m1, m2)
+	}
+}
+
+func TestEDIFACTAdvice(t *testing.T) {
+	var m edifactMessage
+
+	if err := EDIFACT.Unmarshal([]byte("UNA;*,! ~UNH*9*INVOIC;D~UNT*2~"), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.UNH.Reference != "9" || m.UNH.Type.ID != "INVOIC" || m.UNT.Count != 2 {
+		t.Errorf("bad message: %#v", m)
+	}
+}
+
+func TestX12(t *testing.T) {
+	type st struct {
+		ID      string `segment:"1"`
+		Control string `segment:"2"`
+	}
+
+	type message struct {
+		ST st `segment:"ST"`
+	}
+
+	b, err := X12.Marshal(message{ST: st{ID: "850", Control: "0001"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(b); s != "ST*850*0001~" {
+		t.Errorf("bad message: %q", s)
+	}
+
+	_, err = X12.Marshal(message{ST: st{ID: "8*50"}})
+
+	if !errors.Is(err, objutil.ErrSyntax) || !strings.Contains(err.Error(), "ST-1") {
+		t.Errorf("bad error: %v", err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  error
+		match string
+	}{
+		{
+			input: "MSH|^~\\&\rPID|||1||Doe||1980\r",
+			kind:  objutil.ErrSyntax,
+			match: "PID-7",
+		},
+		{
+			input: "MSH|^~\\&\rOBX|one\r",
+			kind:  objutil.ErrSyntax,
+			match: "OBX-1",
+		},
+		{
+			input: "MSH|^~\\&\rPID\rPID\r",
+			kind:  objutil.ErrSyntax,
+			match: "appears multiple times",
+		},
+		{
+			input: "PID|||1\r",
+			kind:  objutil.ErrSyntax,
+			match: "MSH segment is missing",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.match, func(t *testing.T) {
+			var m hl7Message
+			err := HL7.Unmarshal([]byte(test.input), &m)
+
+			if !errors.Is(err, test.kind) || !strings.Contains(err.Error(), test.match) {
+				t.Errorf("bad error: %v", err)
+			}
+		})
+	}
+}
+
+func TestInvalidLayout(t *testing.T) {
+	type bad struct {
+		Value string `segment:"zero"`
+	}
+
+	type message struct {
+		BAD bad `segment:"BAD"`
+	}
+
+	if _, err := HL7.Marshal(message{}); !errors.Is(err, objutil.ErrType) {
+		t.Errorf("bad error: %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package objconv
+
+import (
+	"errors"
+	"reflect"
+)
+
+// seqTypes returns the types of the values produced by iterators of type t,
+// which are functions with the signature of iter.Seq (one value) or iter.Seq2
+// (two values). The types are matched structurally so iterators are supported
+// without requiring the iter package, which is only available since Go 1.23.
+func seqTypes(t reflect.Type) (types []reflect.Type, ok bool) {
+	if t.Kind() != reflect.Func || t.NumIn() != 1 || t.NumOut() != 0 {
+		return
+	}
+
+	y := t.In(0)
+
+	if y.Kind() != reflect.Func || y.NumOut() != 1 || y.Out(0).Kind() != reflect.Bool {
+		return
+	}
+
+	switch n := y.NumIn(); n {
+	case 1, 2:
+		for i := 0; i != n; i++ {
+			types = append(types, y.In(i))
+		}
+		ok = true
+	}
+
+	return
+}
+
+// makeEncodeSeqFunc returns the function encoding iterators of type t as
+// arrays. The elements are encoded as the iterator produces them, in an array
+// of unknown length, unless the emitter needs to know the number of elements
+// ahead of time, in which case they are collected first.
+//
+// The functions encoding elements are looked up when the iterator is encoded
+// since iterator types may be recursive.
+func makeEncodeSeqFunc(t reflect.Type, elem reflect.Type) encodeFunc {
+	s := reflect.SliceOf(elem)
+
+	return func(e Encoder, v reflect.Value) error {
+		if v.IsNil() {
+			return e.Emitter.EmitNil()
+		}
+
+		if isFixedLengthEmitter(e.Emitter) {
+			a := reflect.MakeSlice(s, 0, 16)
+
+			v.Call([]reflect.Value{reflect.MakeFunc(t.In(0), func(args []reflect.Value) []reflect.Value {
+				a = reflect.Append(a, args[0])
+				return []reflect.Value{reflect.ValueOf(true)}
+			})})
+
+			return e.encodeArray(a)
+		}
+
+		f := encodeFuncOf(elem)
+
+		// The whole iterator is consumed by the first call to the element
+		// function, which returns End when the iterator is exhausted.
+		return e.EncodeArray(-1, func(e Encoder) (err error) {
+			i := 0
+
+			v.Call([]reflect.Value{reflect.MakeFunc(t.In(0), func(args []reflect.Value) []reflect.Value {
+				if i != 0 {
+					err = e.Emitter.EmitArrayNext()
+				}
+				if err == nil {
+					err = f(e, args[0])
+				}
+				i++
+				return []reflect.Value{reflect.ValueOf(err == nil)}
+			})})
+
+			if err == nil {
+				err = End
+			}
+			return
+		})
+	}
+}
+
+// makeEncodeSeq2Func returns the function encoding iterators of type t as maps
+// of key/value pairs. The pairs are encoded in the order that the iterator
+// produced them, unless the encoder sorts map keys. Like arrays, the pairs are
+// only collected when the emitter needs the length of the map or the keys are
+// sorted.
+func makeEncodeSeq2Func(t reflect.Type, key reflect.Type, val reflect.Type) encodeFunc {
+	ks := reflect.SliceOf(key)
+	vs := reflect.SliceOf(val)
+
+	return func(e Encoder, v reflect.Value) error {
+		if v.IsNil() {
+			return e.Emitter.EmitNil()
+		}
+
+		kf := encodeFuncOf(key)
+		vf := encodeFuncOf(val)
+		sortKeys := e.SortMapKeys && key.Comparable()
+
+		if !sortKeys && !isFixedLengthEmitter(e.Emitter) {
+			return e.EncodeMap(-1, func(ke Encoder, ve Encoder) (err error) {
+				i := 0
+
+				v.Call([]reflect.Value{reflect.MakeFunc(t.In(0), func(args []reflect.Value) []reflect.Value {
+					if i != 0 {
+						err = e.Emitter.EmitMapNext()
+					}
+					if err == nil {
+						err = kf(e, args[0])
+					}
+					if err == nil {
+						err = e.Emitter.EmitMapValue()
+					}
+					if err == nil {
+						err = vf(e, args[1])
+					}
+					i++
+					return []reflect.Value{reflect.ValueOf(err == nil)}
+				})})
+
+				if err == nil {
+					err = End
+				}
+				return
+			})
+		}
+
+		k := reflect.MakeSlice(ks, 0, 16)
+		x := reflect.MakeSlice(vs, 0, 16)
+
+		v.Call([]reflect.Value{reflect.MakeFunc(t.In(0), func(args []reflect.Value) []reflect.Value {
+			k = reflect.Append(k, args[0])
+			x = reflect.Append(x, args[1])
+			return []reflect.Value{reflect.ValueOf(true)}
+		})})
+
+		if sortKeys {
+			m := reflect.MakeMapWithSize(reflect.MapOf(key, val), k.Len())
+
+			for i, n := 0, k.Len(); i != n; i++ {
+				m.SetMapIndex(k.Index(i), x.Index(i))
+			}
+
+			return e.encodeMapWith(m, kf, vf)
+		}
+
+		i := 0
+		return e.EncodeMap(k.Len(), func(ke Encoder, ve Encoder) (err error) {
+			if err = kf(e, k.Index(i)); err != nil {
+				return
+			}
+			if err = e.Emitter.EmitMapValue(); err != nil {
+				return
+			}
+			if err = vf(e, x.Index(i)); err != nil {
+				return
+			}
+			i++
+			return
+		})
+	}
+}
+
+// Iter returns an iterator over the elements of the array read by d, each
+// element is decoded to a value of type T when the iterator yields it, so the
+// array doesn't need to be loaded in memory.
+//
+// The function returned by Iter has the signature of iter.Seq2[T, error]. If
+// decoding fails the iterator yields the zero-value of T and the error, then
+// stops. Breaking out of the loop leaves the rest of the array unread, the
+// decoder should not be used afterward.
+func Iter[T any](d Decoder) func(yield func(T, error) bool) {
+	return func(yield func(T, error) bool) {
+		i := 0
+
+		err := d.DecodeArray(func(d Decoder) error {
+			var v T
+
+			if err := d.Decode(&v); err != nil {
+				return d.prefixFieldPath(err, indexPath(i))
+			}
+			if !yield(v, nil) {
+				return errIterStop
+			}
+			i++
+			return nil
+		})
+
+		if err != nil && err != errIterStop {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+// errIterStop is used to interrupt the decoding of arrays when the loop over
+// an iterator returned by Iter is interrupted.
+var errIterStop = errors.New("objconv: iteration stopped")
@@ -0,0 +1,223 @@
+package objconv
+
+import (
+	"reflect"
+	"testing"
+)
+
+// seq and seq2 have the definitions of iter.Seq and iter.Seq2.
+type seq[V any] func(yield func(V) bool)
+
+type seq2[K, V any] func(yield func(K, V) bool)
+
+func TestEncodeSeq(t *testing.T) {
+	count := func(n int) seq[int] {
+		return func(yield func(int) bool) {
+			for i := 0; i != n; i++ {
+				if !yield(i) {
+					return
+				}
+			}
+		}
+	}
+
+	pairs := func(keys ...string) seq2[string, int] {
+		return func(yield func(string, int) bool) {
+			for i, k := range keys {
+				if !yield(k, i) {
+					return
+				}
+			}
+		}
+	}
+
+	tests := []struct {
+		in   interface{}
+		out  interface{}
+		sort bool
+	}{
+		{
+			in:  count(3),
+			out: []interface{}{int64(0), int64(1), int64(2)},
+		},
+		{
+			in:  seq[int](nil),
+			out: nil,
+		},
+		{
+			in: func(yield func(string) bool) {
+				yield("A")
+				yield("B")
+			},
+			out: []interface{}{"A", "B"},
+		},
+		{
+			in:  pairs("b", "a"),
+			out: map[interface{}]interface{}{"b": int64(0), "a": int64(1)},
+		},
+		{
+			in:   pairs("b", "a", "b"),
+			out:  map[interface{}]interface{}{"a": int64(1), "b": int64(2)},
+			sort: true,
+		},
+		{
+			in:  map[string]seq[int]{"x": count(2)},
+			out: map[interface{}]interface{}{"x": []interface{}{int64(0), int64(1)}},
+		},
+	}
+
+	for _, test := range tests {
+		e := NewValueEmitter()
+
+		if err := (Encoder{Emitter: e, SortMapKeys: test.sort}).Encode(test.in); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if v := e.Value(); !reflect.DeepEqual(v, test.out) {
+			t.Errorf("bad encoding: %#v != %#v", v, test.out)
+		}
+	}
+}
+
+func TestEncodeSeqOrder(t *testing.T) {
+	var keys []string
+
+	e := &orderEmitter{ValueEmitter: NewValueEmitter(), keys: &keys}
+	s := seq2[string, int](func(yield func(string, int) bool) {
+		yield("c", 1)
+		yield("a", 2)
+		yield("b", 3)
+	})
+
+	if err := (Encoder{Emitter: e}).Encode(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(keys, []string{"c", "a", "b"}) {
+		t.Error("the pairs of the iterator were not encoded in order:", keys)
+	}
+}
+
+type orderEmitter struct {
+	*ValueEmitter
+	keys *[]string
+	next bool
+}
+
+func (e *orderEmitter) EmitMapBegin(n int) error {
+	e.next = true
+	return e.ValueEmitter.EmitMapBegin(n)
+}
+func (e *orderEmitter) EmitMapNext() error { e.next = true; return e.ValueEmitter.EmitMapNext() }
+
+func (e *orderEmitter) EmitString(s string) error {
+	if e.next {
+		*e.keys, e.next = append(*e.keys, s), false
+	}
+	return e.ValueEmitter.EmitString(s)
+}
+
+func TestEncodeSeqIncremental(t *testing.T) {
+	e := &lengthEmitter{ValueEmitter: NewValueEmitter()}
+	n := 0
+
+	s := seq[int](func(yield func(int) bool) {
+		for i := 0; i != 3; i++ {
+			if e.ints != i {
+				t.Errorf("%d elements were written before the iterator produced element %d", e.ints, i)
+			}
+			if !yield(i) {
+				return
+			}
+			n++
+		}
+	})
+
+	if err := (Encoder{Emitter: e}).Encode(s); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(e.lengths, []int{-1}) {
+		t.Error("iterators must be encoded as arrays of unknown length:", e.lengths)
+	}
+
+	if v := e.Value(); !reflect.DeepEqual(v, []interface{}{int64(0), int64(1), int64(2)}) {
+		t.Errorf("bad encoding: %#v", v)
+	}
+
+	if n != 3 {
+		t.Error("the iterator was not exhausted:", n)
+	}
+
+	// Emitters which need to know the lengths receive them after the values
+	// were collected.
+	e = &lengthEmitter{ValueEmitter: NewValueEmitter(), fixed: true}
+
+	if err := (Encoder{Emitter: e}).Encode(map[string]seq2[string, int]{
+		"a": func(yield func(string, int) bool) { _ = yield("x", 1) && yield("y", 2) },
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(e.lengths, []int{1, 2}) {
+		t.Error("bad lengths received by the emitter:", e.lengths)
+	}
+}
+
+// lengthEmitter records the lengths of arrays and maps, and counts the
+// integers which were written.
+type lengthEmitter struct {
+	*ValueEmitter
+	fixed   bool
+	lengths []int
+	ints    int
+}
+
+func (e *lengthEmitter) FixedLengthEmitter() bool { return e.fixed }
+
+func (e *lengthEmitter) EmitArrayBegin(n int) error {
+	e.lengths = append(e.lengths, n)
+	return e.ValueEmitter.EmitArrayBegin(n)
+}
+
+func (e *lengthEmitter) EmitMapBegin(n int) error {
+	e.lengths = append(e.lengths, n)
+	return e.ValueEmitter.EmitMapBegin(n)
+}
+
+func (e *lengthEmitter) EmitInt(v int64, bitSize int) error {
+	e.ints++
+	return e.ValueEmitter.EmitInt(v, bitSize)
+}
+
+func TestIter(t *testing.T) {
+	var values []int
+
+	Iter[int](Decoder{Parser: NewValueParser([]interface{}{1, 2, 3, 4})})(func(v int, err error) bool {
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, v)
+		return v != 3
+	})
+
+	if !reflect.DeepEqual(values, []int{1, 2, 3}) {
+		t.Error("bad values yielded by the iterator:", values)
+	}
+
+	var errs []error
+
+	Iter[int](Decoder{Parser: NewValueParser([]interface{}{1, "A", 3})})(func(v int, err error) bool {
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return true
+	})
+
+	if len(errs) != 1 {
+		t.Error("the iterator should have yielded one error but got:", errs)
+	} else if e, ok := errs[0].(*FieldError); !ok || e.Path != "[1]" {
+		t.Error("bad error path:", errs[0])
+	}
+}
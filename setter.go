@@ -0,0 +1,115 @@
+package objconv
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// InstallSetter registers a setter function for the field of the struct type
+// typ that has the given Go name.
+//
+// When decoding the field, the value is first decoded into a temporary
+// variable which is then passed to the setter, this lets types with
+// invariants (validated emails, bounded quantities, ...) enforce them at
+// deserialization time. The setter must have the signature func(*T, V) error
+// where T is typ and V is the type of the field, errors returned by setters
+// are wrapped in a *FieldError with the serialized name of the field.
+//
+// Setters don't have to be installed for types that declare methods named
+// after their fields with a Set prefix, like SetEmail for a field named Email,
+// accepting a value of the field type and returning nothing or an error, these
+// methods are used automatically.
+//
+// The function panics if typ has no field with the given name, or if setter
+// doesn't have the expected signature. Like Install, it is intended to be
+// called during the package initialization phase.
+func InstallSetter(typ reflect.Type, field string, setter interface{}) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("objconv: cannot install a setter on %s because it is not a struct type", typ))
+	}
+
+	f, ok := typ.FieldByName(field)
+	if !ok || len(f.Index) != 1 {
+		panic(fmt.Sprintf("objconv: cannot install a setter on %s because it has no field named %s", typ, field))
+	}
+
+	fn := reflect.ValueOf(setter)
+
+	if !isSetter(fn.Type(), reflect.PtrTo(typ), f.Type) || fn.Type().NumOut() != 1 {
+		panic(fmt.Sprintf("objconv: the setter of %s.%s must have the signature func(*%s, %s) error but has %s", typ, field, typ, f.Type, fn.Type()))
+	}
+
+	setterMutex.Lock()
+	if setterStore[typ] == nil {
+		setterStore[typ] = make(map[string]reflect.Value)
+	}
+	setterStore[typ][field] = fn
+	setterMutex.Unlock()
+
+	// Same as Install, the struct cache may have become invalid.
+	clearStructCaches()
+}
+
+// setterOf returns the setter function for field f of t, or an invalid value if
+// there are none.
+func setterOf(t reflect.Type, f reflect.StructField) reflect.Value {
+	setterMutex.RLock()
+	fn := setterStore[t][f.Name]
+	setterMutex.RUnlock()
+
+	if fn.IsValid() {
+		return fn
+	}
+
+	r, n := utf8.DecodeRuneInString(f.Name)
+	m, ok := reflect.PtrTo(t).MethodByName("Set" + string(unicode.ToUpper(r)) + f.Name[n:])
+
+	if !ok || !isSetter(m.Type, reflect.PtrTo(t), f.Type) {
+		return reflect.Value{}
+	}
+
+	return m.Func
+}
+
+// isSetter returns true if fn is a function of the form func(recv, arg) or
+// func(recv, arg) error.
+func isSetter(fn reflect.Type, recv reflect.Type, arg reflect.Type) bool {
+	if fn.Kind() != reflect.Func || fn.NumIn() != 2 || fn.In(0) != recv || fn.In(1) != arg {
+		return false
+	}
+	switch fn.NumOut() {
+	case 0:
+		return true
+	case 1:
+		return fn.Out(0) == errorInterface
+	default:
+		return false
+	}
+}
+
+// set decodes the value of f with its setter, v is the struct value.
+func (f *structField) set(d Decoder, v reflect.Value) (err error) {
+	x := reflect.New(f.setter.Type().In(1)).Elem()
+
+	if _, err = f.decode(d, x); err != nil {
+		return
+	}
+
+	if out := f.setter.Call([]reflect.Value{v.Addr(), x}); len(out) != 0 && !out[0].IsNil() {
+		err = &FieldError{Err: out[0].Interface().(error)} // the decoder sets the path
+	}
+
+	return
+}
+
+var (
+	setterMutex sync.RWMutex
+	setterStore = make(map[reflect.Type]map[string]reflect.Value)
+)
@@ -0,0 +1,81 @@
+package objconv
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type setterContact struct {
+	Email string `objconv:"email"`
+	Count int    `objconv:"count"`
+	Name  string `objconv:"name"`
+}
+
+func (c *setterContact) SetEmail(email string) error {
+	if !strings.Contains(email, "@") {
+		return errors.New("invalid email address")
+	}
+	c.Email = strings.ToLower(email)
+	return nil
+}
+
+func (c *setterContact) SetName(name string) { c.Name = strings.TrimSpace(name) }
+
+func init() {
+	InstallSetter(reflect.TypeOf(setterContact{}), "Count", func(c *setterContact, n int) error {
+		if n < 0 || n > 10 {
+			return errors.New("count out of bounds")
+		}
+		c.Count = n
+		return nil
+	})
+}
+
+func TestSetters(t *testing.T) {
+	var c setterContact
+
+	d := NewDecoder(NewValueParser(map[string]interface{}{"email": "Me@Example.com", "count": 3, "name": " Luke "}))
+
+	if err := d.Decode(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c != (setterContact{Email: "me@example.com", Count: 3, Name: "Luke"}) {
+		t.Errorf("bad value: %#v", c)
+	}
+
+	for _, test := range []map[string]interface{}{
+		{"email": "nope"},
+		{"count": 42},
+	} {
+		err := NewDecoder(NewValueParser(test)).Decode(&c)
+
+		if _, ok := err.(*FieldError); !ok {
+			t.Errorf("decoding %v: expected a field error but got %v", test, err)
+		}
+	}
+}
+
+func TestInstallSetterPanics(t *testing.T) {
+	tests := []struct {
+		field  string
+		setter interface{}
+	}{
+		{"Missing", func(*setterContact, int) error { return nil }},
+		{"Count", func(*setterContact, string) error { return nil }},
+		{"Count", func(setterContact, int) error { return nil }},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("no panic raised when installing %T on %s", test.setter, test.field)
+				}
+			}()
+			InstallSetter(reflect.TypeOf(setterContact{}), test.field, test.setter)
+		}()
+	}
+}
@@ -0,0 +1,272 @@
+// Package snapshot persists an in-memory value as periodic full snapshots plus
+// a journal of the changes applied since the last snapshot, and reconstructs
+// the value when a program starts.
+//
+// The journal is an objconv/logfile log, snapshots are files holding the
+// value encoded with the same codec. A directory managed by a Store looks like
+// this:
+//
+//	dir/journal/00000000000000000000.log
+//	dir/snapshot-00000000000000001000
+//
+// where 1000 is the number of journal records that the snapshot includes.
+package snapshot
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/logfile"
+)
+
+// DefaultSnapshotEvery is the default number of journal records after which
+// a store takes a new snapshot.
+const DefaultSnapshotEvery = 10000
+
+const (
+	snapshotPrefix = "snapshot-"
+	snapshotTemp   = "snapshot.tmp"
+)
+
+// State is the interface that must be implemented by the values persisted by
+// a Store.
+//
+// The state itself is encoded and decoded to produce and load snapshots, so it
+// usually is a pointer to a struct or a map.
+type State interface {
+	// Replay is called with a decoder positioned on a journal record when a
+	// store is opened, it must apply the change to the state the same way
+	// the program did when the record was appended.
+	Replay(objconv.Decoder) error
+}
+
+// Config carries the configuration of a Store.
+type Config struct {
+	// SnapshotEvery is the number of journal records after which a snapshot
+	// is taken, DefaultSnapshotEvery is used if it is zero. Setting it to a
+	// negative value disables automatic snapshots.
+	SnapshotEvery int
+
+	// Journal is the configuration of the journal log.
+	Journal logfile.Config
+}
+
+// Store maintains the snapshots and journal of a state.
+//
+// Stores are not safe to use from multiple goroutines, and changes to the
+// state must not happen concurrently with calls to the store's methods.
+type Store struct {
+	dir     string
+	codec   objconv.Codec
+	state   State
+	config  Config
+	journal *logfile.Log
+	last    int64 // journal record number of the last snapshot
+}
+
+// Open loads the state stored in dir, creating the directory if it doesn't
+// exist.
+//
+// The latest snapshot is decoded into state, then the changes recorded in the
+// journal after it are replayed.
+func Open(dir string, codec objconv.Codec, state State, config Config) (*Store, error) {
+	if config.SnapshotEvery == 0 {
+		config.SnapshotEvery = DefaultSnapshotEvery
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		dir:    dir,
+		codec:  codec,
+		state:  state,
+		config: config,
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	journal, err := logfile.Open(filepath.Join(dir, "journal"), codec, config.Journal)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.replay(journal); err != nil {
+		journal.Close()
+		return nil, err
+	}
+
+	s.journal = journal
+	return s, nil
+}
+
+// Append records change in the journal, the change must already have been
+// applied to the state.
+//
+// A snapshot is taken when enough changes were recorded since the last one.
+func (s *Store) Append(change interface{}) error {
+	if _, err := s.journal.Append(change); err != nil {
+		return err
+	}
+
+	if n := s.config.SnapshotEvery; n > 0 && (s.journal.Next()-s.last) >= int64(n) {
+		return s.Snapshot()
+	}
+
+	return nil
+}
+
+// Snapshot writes a full snapshot of the state, and discards the snapshots
+// and journal records that it makes obsolete.
+func (s *Store) Snapshot() error {
+	record := s.journal.Next()
+
+	if err := s.journal.Sync(); err != nil {
+		return err
+	}
+
+	if err := s.write(record); err != nil {
+		return err
+	}
+
+	old, err := listSnapshots(s.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range old {
+		if r < record {
+			if err := os.Remove(s.path(r)); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.last = record
+	return s.journal.Trim(record)
+}
+
+// Close closes the store, it doesn't take a snapshot.
+func (s *Store) Close() error {
+	return s.journal.Close()
+}
+
+func (s *Store) path(record int64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s%020d", snapshotPrefix, record))
+}
+
+// load decodes the latest snapshot into the state.
+func (s *Store) load() error {
+	snapshots, err := listSnapshots(s.dir)
+	if err != nil || len(snapshots) == 0 {
+		return err
+	}
+
+	record := snapshots[len(snapshots)-1]
+
+	f, err := os.Open(s.path(record))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := s.codec.NewDecoder(bufio.NewReader(f)).Decode(s.state); err != nil {
+		return fmt.Errorf("objconv/snapshot: decoding %s: %s", f.Name(), err)
+	}
+
+	s.last = record
+	return nil
+}
+
+// replay applies the journal records that follow the last snapshot.
+func (s *Store) replay(journal *logfile.Log) error {
+	if first, next := journal.First(), journal.Next(); s.last < first || s.last > next {
+		return fmt.Errorf("objconv/snapshot: the journal [%d:%d] doesn't contain the records that follow the snapshot at %d", first, next, s.last)
+	}
+
+	r, err := journal.Reader(s.last)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for {
+		n := r.Record()
+
+		switch err := r.Decode(objconv.ValueDecoderFunc(s.state.Replay)); err {
+		case nil:
+		case objconv.End:
+			return nil
+		default:
+			return fmt.Errorf("objconv/snapshot: replaying journal record %d: %s", n, err)
+		}
+	}
+}
+
+// write atomically creates the snapshot at record.
+func (s *Store) write(record int64) error {
+	tmp := filepath.Join(s.dir, snapshotTemp)
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	err = s.codec.NewEncoder(w).Encode(s.state)
+
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, s.path(record))
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	// Syncing the directory makes the rename durable, not all platforms
+	// support it so errors are ignored.
+	if d, err := os.Open(s.dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+
+	return nil
+}
+
+func listSnapshots(dir string) ([]int64, error) {
+	files, err := filepath.Glob(filepath.Join(dir, snapshotPrefix+"*"))
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]int64, 0, len(files))
+
+	for _, file := range files {
+		r, err := strconv.ParseInt(strings.TrimPrefix(filepath.Base(file), snapshotPrefix), 10, 64)
+		if err != nil {
+			continue // not a snapshot
+		}
+		records = append(records, r)
+	}
+
+	sort.Slice(records, func(i int, j int) bool { return records[i] < records[j] })
+	return records, nil
+}
@@ -0,0 +1,102 @@
+package snapshot
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/msgpack"
+)
+
+type change struct {
+	Key   string `objconv:"key"`
+	Value int    `objconv:"value"`
+}
+
+type counters struct {
+	Values map[string]int `objconv:"values"`
+}
+
+func (c *counters) apply(x change) {
+	if c.Values == nil {
+		c.Values = map[string]int{}
+	}
+	c.Values[x.Key] += x.Value
+}
+
+func (c *counters) Replay(d objconv.Decoder) error {
+	var x change
+	if err := d.Decode(&x); err != nil {
+		return err
+	}
+	c.apply(x)
+	return nil
+}
+
+func update(t *testing.T, s *Store, c *counters, x change) {
+	c.apply(x)
+
+	if err := s.Append(x); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "objconv-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := Config{SnapshotEvery: 7}
+
+	c1 := &counters{}
+	s1, err := Open(dir, msgpack.Codec, c1, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i != 100; i++ {
+		update(t, s1, c1, change{Key: string(rune('A' + i%5)), Value: i})
+	}
+
+	if err := s1.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshots, _ := listSnapshots(dir)
+
+	if !reflect.DeepEqual(snapshots, []int64{98}) {
+		t.Error("bad snapshots:", snapshots)
+	}
+
+	c2 := &counters{}
+	s2, err := Open(dir, msgpack.Codec, c2, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s2.Close()
+
+	if !reflect.DeepEqual(c1, c2) {
+		t.Errorf("%#v != %#v", c1, c2)
+	}
+
+	update(t, s2, c2, change{Key: "A", Value: 1})
+
+	if err := s2.Snapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	c3 := &counters{}
+	s3, err := Open(dir, msgpack.Codec, c3, Config{SnapshotEvery: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s3.Close()
+
+	if !reflect.DeepEqual(c2, c3) {
+		t.Errorf("%#v != %#v", c2, c3)
+	}
+}
@@ -38,6 +38,55 @@ func (s sortBytesValues) Less(i int, j int) bool {
 	return bytes.Compare(s[i].Bytes(), s[j].Bytes()) < 0
 }
 
+// sortInterfaceValues orders dynamically typed values by kind first, then by
+// value for the kinds that sortValues supports.
+type sortInterfaceValues []reflect.Value
+
+func (s sortInterfaceValues) Len() int          { return len(s) }
+func (s sortInterfaceValues) Swap(i int, j int) { s[i], s[j] = s[j], s[i] }
+func (s sortInterfaceValues) Less(i int, j int) bool {
+	v1, v2 := s[i].Elem(), s[j].Elem()
+	k1, k2 := sortKindOf(v1), sortKindOf(v2)
+
+	if k1 != k2 {
+		return k1 < k2
+	}
+
+	switch k1 {
+	case reflect.Bool:
+		return !v1.Bool() && v2.Bool()
+	case reflect.Int:
+		return v1.Int() < v2.Int()
+	case reflect.Uint:
+		return v1.Uint() < v2.Uint()
+	case reflect.Float64:
+		return v1.Float() < v2.Float()
+	case reflect.String:
+		return v1.String() < v2.String()
+	}
+
+	return false
+}
+
+func sortKindOf(v reflect.Value) reflect.Kind {
+	if !v.IsValid() {
+		return reflect.Invalid
+	}
+
+	switch k := v.Kind(); k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.Int
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.Uint
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	case reflect.Bool, reflect.String:
+		return k
+	default: // other types are kept in their original order, after the others
+		return reflect.UnsafePointer
+	}
+}
+
 func sortValues(typ reflect.Type, v []reflect.Value) {
 	switch typ.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
@@ -56,6 +105,9 @@ func sortValues(typ reflect.Type, v []reflect.Value) {
 		if typ.Elem().Kind() == reflect.Uint8 {
 			sort.Sort(sortBytesValues(v))
 		}
+
+	case reflect.Interface:
+		sort.Stable(sortInterfaceValues(v))
 	}
 
 	// For all other types we give up on trying to sort the values,
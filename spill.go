@@ -0,0 +1,213 @@
+package objconv
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// SpillConfig configures decoders to write the strings and byte sequences
+// decoded into Blob values to temporary files when they are large, instead of
+// holding them in memory.
+type SpillConfig struct {
+	// Threshold is the length in bytes above which values are written to
+	// temporary files, values are always kept in memory when it is zero.
+	Threshold int
+
+	// Dir is the directory where the temporary files are created, the default
+	// directory for temporary files is used if it is empty.
+	Dir string
+}
+
+// The CopyParser interface may be implemented by parsers which can read
+// strings and byte sequences from their input without loading them in memory.
+//
+// Decoders use it when decoding values into Blob values, so the values that
+// spill to temporary files are never fully held in memory.
+type CopyParser interface {
+	// CopyBytes is called instead of ParseString or ParseBytes to parse the
+	// next value, it writes the content of the value to w and returns the
+	// number of bytes written.
+	CopyBytes(w io.Writer) (int64, error)
+}
+
+// Blob is a string or byte sequence decoded by a decoder, which is backed by a
+// temporary file when its length exceeds the threshold configured by the Spill
+// field of the decoder.
+//
+// Blob values implement io.ReadSeeker to access their content, and must be
+// closed to remove their temporary file. Encoding a blob writes its content as
+// a byte sequence.
+//
+// The zero-value is an empty blob.
+type Blob struct {
+	r bytes.Reader
+	f *os.File
+	n int64
+}
+
+// NewBlob returns a blob holding a copy of b in memory.
+func NewBlob(b []byte) *Blob {
+	x := &Blob{n: int64(len(b))}
+	x.r.Reset(append([]byte(nil), b...))
+	return x
+}
+
+// Len returns the length of the blob's content.
+func (b *Blob) Len() int64 {
+	return b.n
+}
+
+// Spilled returns true if the content of the blob is in a temporary file.
+func (b *Blob) Spilled() bool {
+	return b.f != nil
+}
+
+// Read satisfies the io.Reader interface.
+func (b *Blob) Read(p []byte) (int, error) {
+	if b.f != nil {
+		return b.f.Read(p)
+	}
+	return b.r.Read(p)
+}
+
+// Seek satisfies the io.Seeker interface.
+func (b *Blob) Seek(offset int64, whence int) (int64, error) {
+	if b.f != nil {
+		return b.f.Seek(offset, whence)
+	}
+	return b.r.Seek(offset, whence)
+}
+
+// Close releases the content of the blob, removing its temporary file if it
+// has one. The blob is empty after it was closed.
+func (b *Blob) Close() (err error) {
+	if f := b.f; f != nil {
+		err = f.Close()
+		if rerr := os.Remove(f.Name()); err == nil {
+			err = rerr
+		}
+	}
+	b.r.Reset(nil)
+	b.f = nil
+	b.n = 0
+	return
+}
+
+// EncodeValue satisfies the ValueEncoder interface, the content of the blob is
+// read from the beginning.
+func (b *Blob) EncodeValue(e Encoder) error {
+	if _, err := b.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	v, err := objutil.ReadFull(nil, b, int(b.n))
+	if err != nil {
+		return err
+	}
+
+	return e.Emitter.EmitBytes(v)
+}
+
+// DecodeValue satisfies the ValueDecoder interface, the previous content of the
+// blob is released and replaced by the decoded value.
+func (b *Blob) DecodeValue(d Decoder) error {
+	if err := b.Close(); err != nil {
+		return err
+	}
+
+	t, err := d.Parser.ParseType()
+	if err != nil {
+		return err
+	}
+
+	switch t {
+	case Nil:
+		return d.Parser.ParseNil()
+	case String, Bytes:
+	default:
+		return objutil.Errorf(objutil.ErrType, "objconv: cannot decode %s into a blob", t)
+	}
+
+	w := spillWriter{config: d.Spill, max: d.Limits.MaxStringLen}
+
+	if p, ok := d.Parser.(CopyParser); ok {
+		_, err = p.CopyBytes(&w)
+	} else {
+		var v []byte
+
+		if t == String {
+			v, err = d.Parser.ParseString()
+		} else {
+			v, err = d.Parser.ParseBytes()
+		}
+
+		if err == nil {
+			_, err = w.Write(v)
+		}
+	}
+
+	if err == nil && w.f != nil {
+		_, err = w.f.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		w.discard()
+		return err
+	}
+
+	b.r.Reset(w.b)
+	b.f = w.f
+	b.n = w.n
+	return nil
+}
+
+// spillWriter buffers the bytes written to it in memory until they exceed the
+// threshold of its configuration, then moves them to a temporary file.
+type spillWriter struct {
+	config SpillConfig
+	max    int // maximum length of the value, from the decoder's limits
+	b      []byte
+	f      *os.File
+	n      int64
+}
+
+func (w *spillWriter) Write(b []byte) (int, error) {
+	if w.max > 0 && w.n+int64(len(b)) > int64(w.max) {
+		return 0, objutil.Errorf(objutil.ErrLimit, "objconv: the input has a string of more than %d bytes, which exceeds the limit", w.max)
+	}
+
+	if w.f == nil && w.config.Threshold > 0 && w.n+int64(len(b)) > int64(w.config.Threshold) {
+		f, err := os.CreateTemp(w.config.Dir, "objconv-blob-*")
+		if err != nil {
+			return 0, err
+		}
+		w.f = f
+
+		if _, err := f.Write(w.b); err != nil {
+			return 0, err
+		}
+		w.b = nil
+	}
+
+	if w.f != nil {
+		n, err := w.f.Write(b)
+		w.n += int64(n)
+		return n, err
+	}
+
+	w.b = append(w.b, b...)
+	w.n += int64(len(b))
+	return len(b), nil
+}
+
+// discard removes the temporary file of w, if it created one.
+func (w *spillWriter) discard() {
+	if w.f != nil {
+		w.f.Close()
+		os.Remove(w.f.Name())
+		w.f = nil
+	}
+}
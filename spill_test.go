@@ -0,0 +1,105 @@
+package objconv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestBlob(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		value   interface{}
+		spilled bool
+	}{
+		{value: "hello", spilled: false},
+		{value: []byte("hello"), spilled: false},
+		{value: "hello world!", spilled: true},
+		{value: []byte("hello world!"), spilled: true},
+	}
+
+	for _, test := range tests {
+		var b Blob
+
+		d := Decoder{Parser: NewValueParser(test.value), Spill: SpillConfig{Threshold: 8, Dir: dir}}
+
+		if err := d.Decode(&b); err != nil {
+			t.Fatal(err)
+		}
+
+		if b.Spilled() != test.spilled {
+			t.Errorf("%#v: bad spilled state: %t", test.value, b.Spilled())
+		}
+
+		content, err := io.ReadAll(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if s, _ := test.value.(string); s != "" && string(content) != s {
+			t.Errorf("bad content: %q", content)
+		}
+		if s, _ := test.value.([]byte); s != nil && !bytes.Equal(content, s) {
+			t.Errorf("bad content: %q", content)
+		}
+
+		if b.Len() != int64(len(content)) {
+			t.Errorf("bad length: %d", b.Len())
+		}
+
+		if err := b.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("%d temporary files were not removed", len(files))
+	}
+}
+
+func TestBlobEncode(t *testing.T) {
+	b := NewBlob([]byte("hello"))
+	e := NewValueEmitter()
+
+	if err := NewEncoder(e).Encode(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := e.Value().([]byte); string(v) != "hello" {
+		t.Errorf("bad value: %#v", e.Value())
+	}
+}
+
+func TestBlobErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		value interface{}
+		limit int
+		kind  error
+	}{
+		{value: 42, kind: ErrType},
+		{value: "hello world!", limit: 10, kind: ErrLimit},
+	}
+
+	for _, test := range tests {
+		var b Blob
+
+		d := Decoder{
+			Parser: NewValueParser(test.value),
+			Limits: ParserConfig{MaxStringLen: test.limit},
+			Spill:  SpillConfig{Threshold: 4, Dir: dir},
+		}
+
+		if err := d.Decode(&b); !errors.Is(err, test.kind) {
+			t.Errorf("%#v: bad error: %v", test.value, err)
+		}
+	}
+
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("%d temporary files were not removed", len(files))
+	}
+}
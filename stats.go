@@ -0,0 +1,68 @@
+package objconv
+
+import "github.com/segmentio/objconv/objutil"
+
+// DecodeStats carries the accounting of a call to the Decode method of a
+// decoder, services decoding inputs on behalf of multiple tenants use it to
+// charge each of them for the work done.
+type DecodeStats struct {
+	// Bytes is the number of bytes consumed from the input, it is only counted
+	// when the parser implements OffsetParser.
+	Bytes int64
+
+	// Values is the number of values decoded, which are the top-level value,
+	// the elements of arrays, and the keys and values of maps.
+	Values int64
+
+	// Allocs is an estimate of the memory allocated to hold the decoded
+	// values, in bytes. It is the sum of the lengths of strings and byte
+	// sequences, and of a fixed cost for each value.
+	Allocs int64
+}
+
+// valueAllocSize is the fixed cost of each value in the estimates of memory
+// allocations, which is the size of an empty interface.
+const valueAllocSize = 16
+
+// startStats resets the stats of d when a top-level value is decoded, the
+// returned function must be called when the value has been decoded. The stats
+// are created if d has none but the limits require them.
+func (d *Decoder) startStats() (func(), error) {
+	if d.Stats == nil {
+		if d.Limits.MaxValues <= 0 && d.Limits.MaxAllocs <= 0 {
+			return func() {}, nil
+		}
+		d.Stats = new(DecodeStats)
+	}
+
+	*d.Stats = DecodeStats{}
+	stats, done := d.Stats, func() {}
+
+	if p, ok := d.Parser.(OffsetParser); ok {
+		off := p.Offset()
+		done = func() { stats.Bytes = p.Offset() - off }
+	}
+
+	return done, d.count(1, 0)
+}
+
+// count adds values and allocs to the stats of d, and verifies that they
+// don't exceed the limits.
+func (d Decoder) count(values int, allocs int) error {
+	if d.Stats == nil {
+		return nil
+	}
+
+	d.Stats.Values += int64(values)
+	d.Stats.Allocs += int64(values)*valueAllocSize + int64(allocs)
+
+	if max := d.Limits.MaxValues; max > 0 && d.Stats.Values > max {
+		return objutil.Errorf(objutil.ErrLimit, "objconv: the input has more values than the limit of %d", max)
+	}
+
+	if max := d.Limits.MaxAllocs; max > 0 && d.Stats.Allocs > max {
+		return objutil.Errorf(objutil.ErrLimit, "objconv: decoding the input allocates more memory than the limit of %d bytes", max)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package objconv
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeStats(t *testing.T) {
+	var stats DecodeStats
+	var v struct {
+		Name string
+		Tags []string
+	}
+
+	input := map[string]interface{}{
+		"Name": "Luke",
+		"Tags": []interface{}{"a", "bc"},
+	}
+
+	d := Decoder{Parser: NewValueParser(input), Stats: &stats}
+
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	// top-level map + 2 keys + 2 values + 2 array elements
+	if stats.Values != 7 {
+		t.Errorf("bad number of values: %d", stats.Values)
+	}
+
+	// 7 values + "Name" + "Luke" + "Tags" + "a" + "bc"
+	if expect := int64(7*valueAllocSize + 4 + 4 + 4 + 1 + 2); stats.Allocs != expect {
+		t.Errorf("bad estimate of allocations: %d != %d", stats.Allocs, expect)
+	}
+
+	// The stats are reset by each call to Decode.
+	d.Parser = NewValueParser("")
+
+	if err := d.Decode(&v.Name); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats != (DecodeStats{Values: 1, Allocs: valueAllocSize}) {
+		t.Errorf("bad stats: %+v", stats)
+	}
+}
+
+func TestDecodeStatsLimits(t *testing.T) {
+	input := []interface{}{"hello", "world", "!"}
+
+	tests := []struct {
+		limits ParserConfig
+		fail   bool
+	}{
+		{limits: ParserConfig{MaxValues: 4}},
+		{limits: ParserConfig{MaxValues: 3}, fail: true},
+		{limits: ParserConfig{MaxAllocs: 4*valueAllocSize + 11}},
+		{limits: ParserConfig{MaxAllocs: 4*valueAllocSize + 10}, fail: true},
+	}
+
+	for _, test := range tests {
+		var v []string
+		var stats DecodeStats
+
+		err := (Decoder{Parser: NewValueParser(input), Limits: test.limits, Stats: &stats}).Decode(&v)
+
+		if test.fail {
+			if !errors.Is(err, ErrLimit) {
+				t.Errorf("%+v: expected a limit error but got %v", test.limits, err)
+			}
+		} else if err != nil {
+			t.Errorf("%+v: %v", test.limits, err)
+		}
+
+		// The stats are available with or without limits.
+		if err == nil && stats.Values != 4 {
+			t.Errorf("%+v: bad number of values: %d", test.limits, stats.Values)
+		}
+
+		// Decoders without stats enforce the limits as well.
+		err = (Decoder{Parser: NewValueParser(input), Limits: test.limits}).Decode(&v)
+
+		if test.fail != (err != nil) {
+			t.Errorf("%+v: bad error without stats: %v", test.limits, err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package objconv
+
+import (
+	"errors"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// The key of the map representing the terminal record of streams closed with
+// an error. Because it is written as a regular value the records don't depend
+// on the format having a native error type.
+const streamErrorKey = "objconv:stream-error"
+
+type streamErrorRecord struct {
+	message string
+}
+
+func (r streamErrorRecord) EncodeValue(e Encoder) error {
+	return e.EncodeMap(1, func(k Encoder, v Encoder) (err error) {
+		if err = k.EncodeString(streamErrorKey); err != nil {
+			return
+		}
+		return v.EncodeString(r.message)
+	})
+}
+
+// streamRecord tracks the first key of the map elements of streams, which are
+// terminal error records when the key is streamErrorKey.
+//
+// The key is checked by the decoder when it reads it, instead of being read
+// ahead, so the elements are decoded with the parser of the stream and all
+// the optional interfaces that it implements.
+type streamRecord struct {
+	state int // one of the record* constants
+	n     int // length of the map, when state is recordFound
+}
+
+const (
+	recordWait  = iota // the element hasn't begun yet
+	recordKey          // the next string that is read is the first key
+	recordDone         // the element is not an error record
+	recordFound        // the first key is streamErrorKey
+)
+
+// errStreamRecord interrupts the decoding of a stream element which is an
+// error record, it is never returned to the program.
+var errStreamRecord = errors.New("objconv: stream error record")
+
+// beginMap is called when the decoder of a stream element begins a map of
+// length n, only the first map is the top-level value of the element.
+func (r *streamRecord) beginMap(n int) {
+	if r.state == recordWait {
+		r.state, r.n = recordDone, n
+	}
+}
+
+// checkKey is called with the strings read by the decoder of a stream element.
+func (r *streamRecord) checkKey(b []byte) error {
+	if r.state != recordKey {
+		return nil
+	}
+	if r.state = recordDone; string(b) == streamErrorKey {
+		r.state = recordFound
+		return errStreamRecord
+	}
+	return nil
+}
+
+// decodeElement decodes the next element of a stream into v, or returns a
+// *StreamError if the element is a terminal error record.
+//
+// Elements decoded into values which capture their representation, like
+// RawMessage, are not checked for error records.
+func (d *StreamDecoder) decodeElement(dec Decoder, v interface{}) (err error) {
+	if _, err = dec.Parser.ParseType(); err != nil {
+		return
+	}
+
+	d.pos = d.offset()
+	d.record = streamRecord{}
+	dec.record = &d.record
+
+	if err = dec.Decode(v); d.record.state == recordFound {
+		dec.record = nil
+		err = d.decodeStreamError(dec, d.record.n)
+	}
+
+	return
+}
+
+func (d *StreamDecoder) decodeStreamError(dec Decoder, n int) (err error) {
+	var msg string
+
+	if err = dec.Parser.ParseMapValue(0); err != nil {
+		return
+	}
+
+	if err = dec.decodeStringPtr(&msg); err != nil {
+		return
+	}
+
+	if n < 0 {
+		if err = dec.Parser.ParseMapNext(1); err != End {
+			if err == nil {
+				err = objutil.Errorf(objutil.ErrSyntax, "objconv: invalid stream error record with more than one key")
+			}
+			return
+		}
+	}
+
+	if err = dec.Parser.ParseMapEnd(1); err != nil {
+		return
+	}
+
+	if d.typ == Array {
+		// Error records are the last elements of their stream, the error from
+		// parsing the end of the array is ignored because it's less relevant
+		// than the one reported by the producer of the stream.
+		dec.Parser.ParseArrayEnd(d.cnt + 1)
+	}
+
+	return &StreamError{Message: msg}
+}
@@ -2,7 +2,11 @@ package objconv
 
 import (
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/segmentio/objconv/objutil"
 )
@@ -13,6 +17,11 @@ type structField struct {
 	// The index of the field in the structure.
 	index []int
 
+	// The index of the inline struct field holding the field, nil for fields
+	// of the structure itself. The other properties of the field are relative
+	// to the inline struct.
+	inline []int
+
 	// The name of the field in the structure.
 	name string
 
@@ -24,27 +33,53 @@ type structField struct {
 	// value.
 	omitzero bool
 
+	// Hidden is set to true for unexported fields that have the `export` tag
+	// option, their value is read with the getter method if the struct has
+	// one, or by forcing access to the field.
+	hidden bool
+
+	// Virtual is set to true for fields that are the result of a method call
+	// installed with InstallMethodField, or of the link builders installed
+	// with InstallLinks, they are only used when encoding.
+	virtual bool
+
+	// The link builders of the struct type, only set on the virtual field
+	// holding the links of values.
+	links []LinkBuilder
+
+	// Alias is set on the copies of fields renamed by a field policy, which
+	// carry their original names. Aliases are only used when decoding.
+	alias bool
+
+	// Encrypted is set on fields with the encrypt tag option, their encoder
+	// and decoder encrypt and decrypt the values.
+	encrypted bool
+
+	// Omitted and redacted are set on the fields omitted and redacted by a
+	// field policy, redacted fields are encoded as the placeholder string.
+	// Decoders discard the values of both.
+	omitted     bool
+	redacted    bool
+	placeholder string
+
+	// The setter function used to decode the field, either a Set<Field>
+	// method or a function installed with InstallSetter. The value is
+	// invalid if the field has no setter.
+	setter reflect.Value
+
+	// The index of the getter method of hidden fields in the method set of
+	// the struct pointer type, or -1 if there are none. Only meaningful when
+	// hidden is true.
+	getter int
+
 	// cache for the encoder and decoder methods
 	encode encodeFunc
 	decode decodeFunc
 }
 
-func makeStructField(f reflect.StructField, c map[reflect.Type]*structType) structField {
-	var t objutil.Tag
-
-	if tag := f.Tag.Get("objconv"); len(tag) != 0 {
-		t = objutil.ParseTag(tag)
-	} else {
-		// To maximize compatibility with existing code we fallback to checking
-		// if the field has a `json` tag.
-		//
-		// This tag doesn't support any of the extra features that are supported
-		// by the `objconv` tag, and it should stay this way. It has to match
-		// the behavior of the standard encoding/json package to avoid any
-		// implicit changes in what would be intuitively expected.
-		t = objutil.ParseTagJSON(f.Tag.Get("json"))
-	}
-
+// makeStructField makes the field of f, t is the tag of f parsed by
+// parseFieldTag.
+func makeStructField(f reflect.StructField, t objutil.Tag, c *structTypes) structField {
 	s := structField{
 		index:     f.Index,
 		name:      f.Name,
@@ -64,11 +99,121 @@ func makeStructField(f reflect.StructField, c map[reflect.Type]*structType) stru
 
 	if len(t.Name) != 0 {
 		s.name = t.Name
+	} else {
+		s.name = c.naming.Apply(f.Name)
+	}
+
+	// Attributes and character data are represented by the "@name" and
+	// "#text" keys, the conventions of the XML codec, which other formats
+	// encode as regular map keys.
+	switch {
+	case s.name == "-":
+	case t.Chardata:
+		s.name = "#text"
+	case t.Attr:
+		s.name = "@" + s.name
 	}
 
 	return s
 }
 
+// parseFieldTag parses the first of the tags of f with the given names, or the
+// objconv and json tags when names is empty.
+func parseFieldTag(f reflect.StructField, names []string) objutil.Tag {
+	if len(names) != 0 {
+		for _, name := range names {
+			if tag := f.Tag.Get(name); len(tag) != 0 {
+				if name == "objconv" {
+					return objutil.ParseTag(tag)
+				}
+				return objutil.ParseTagJSON(tag)
+			}
+		}
+		return objutil.Tag{}
+	}
+	if tag := f.Tag.Get("objconv"); len(tag) != 0 {
+		return objutil.ParseTag(tag)
+	}
+	// To maximize compatibility with existing code we fallback to checking
+	// if the field has a `json` tag.
+	//
+	// This tag doesn't support any of the extra features that are supported
+	// by the `objconv` tag, and it should stay this way. It has to match
+	// the behavior of the standard encoding/json package to avoid any
+	// implicit changes in what would be intuitively expected.
+	return objutil.ParseTagJSON(f.Tag.Get("json"))
+}
+
+// getterOf returns the index of the getter method of field f in the method set
+// of *t, or -1 if there are none. Getters are named after the field with the
+// first letter capitalized, and return a value of the field type.
+func getterOf(t reflect.Type, f reflect.StructField) int {
+	r, n := utf8.DecodeRuneInString(f.Name)
+	m, ok := reflect.PtrTo(t).MethodByName(string(unicode.ToUpper(r)) + f.Name[n:])
+
+	if !ok || m.Type.NumIn() != 1 || m.Type.NumOut() != 1 || m.Type.Out(0) != f.Type {
+		return -1
+	}
+
+	return m.Index
+}
+
+// parent returns the struct holding f in v, which is v itself unless f was
+// inlined from a nested struct.
+func (f *structField) parent(v reflect.Value) reflect.Value {
+	if f.inline != nil {
+		v = v.FieldByIndex(f.inline)
+	}
+	return v
+}
+
+// value returns the value of f in v, which must be addressable if the field is
+// hidden.
+func (f *structField) value(v reflect.Value) reflect.Value {
+	v = f.parent(v)
+
+	if !f.hidden {
+		return v.FieldByIndex(f.index)
+	}
+	if f.links != nil {
+		return buildLinks(nil, f.links, v)
+	}
+	if f.getter >= 0 {
+		return v.Addr().Method(f.getter).Call(nil)[0]
+	}
+	return exportValue(v.FieldByIndex(f.index))
+}
+
+// decodeInto decodes the value of f in v, which must be addressable.
+func (f *structField) decodeInto(d Decoder, v reflect.Value) (err error) {
+	if f.setter.IsValid() {
+		return f.set(d, f.parent(v))
+	}
+	_, err = f.decode(d, f.target(v))
+	return
+}
+
+// target returns the value that f is decoded into in v, which must be
+// addressable.
+func (f *structField) target(v reflect.Value) reflect.Value {
+	v = f.parent(v)
+
+	if !f.hidden {
+		return v.FieldByIndex(f.index)
+	}
+	return exportValue(v.FieldByIndex(f.index))
+}
+
+// encoded returns true if f is written to the output of encoders.
+func (f *structField) encoded() bool {
+	return !f.alias && !f.omitted
+}
+
+// discarded returns true if decoders discard the values of f.
+func (f *structField) discarded() bool {
+	return f.virtual || f.omitted || f.redacted
+}
+
 func (f *structField) omit(v reflect.Value) bool {
 	return (f.omitempty && objutil.IsEmptyValue(v)) || (f.omitzero && objutil.IsZeroValue(v))
 }
@@ -77,49 +222,367 @@ func (f *structField) omit(v reflect.Value) bool {
 // that cache meta information to make field lookups faster and avoid having to
 // use reflection to lookup the same type information over and over again.
 type structType struct {
-	fields       []structField           // the serializable fields of the struct
-	fieldsByName map[string]*structField // cache of fields by name
+	typ    reflect.Type  // the Go type of the struct
+	fields []structField // the serializable fields of the struct
+	lookup fieldLookup   // index of fields by name
+	rest   *structField  // inline map holding the keys matching no fields
+	hidden bool          // whether some fields are hidden
+	opaque bool          // whether the struct only has unexported fields
+	err    error         // error found in the inline, encrypted, or hidden fields of the struct
+}
+
+// addressable returns v, or an addressable copy of v if the struct has hidden
+// fields, which can only be accessed through addressable values.
+func (s *structType) addressable(v reflect.Value) reflect.Value {
+	if s.hidden && !v.CanAddr() {
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		v = c
+	}
+	return v
+}
+
+// structTypes holds the struct types made while extracting information from a
+// Go type, which may reference itself, the names of the tags that the field
+// names and options are read from, and the naming convention of the fields
+// which have no name in their tag.
+type structTypes struct {
+	tags   []string
+	naming FieldNaming
+	types  map[reflect.Type]*structType
+	raw    bool // whether field policies are ignored
+}
+
+func makeStructTypes(tags []string, naming FieldNaming) *structTypes {
+	return &structTypes{tags: tags, naming: naming, types: map[reflect.Type]*structType{}}
 }
 
 // newStructType takes a Go type as argument and extract information to make a
 // new structType value.
 // The type has to be a struct type or a panic will be raised.
-func newStructType(t reflect.Type, c map[reflect.Type]*structType) *structType {
-	if s := c[t]; s != nil {
+func newStructType(t reflect.Type, c *structTypes) *structType {
+	if s := c.types[t]; s != nil {
 		return s
 	}
 
 	n := t.NumField()
 	s := &structType{
-		fields:       make([]structField, 0, n),
-		fieldsByName: make(map[string]*structField),
+		typ:    t,
+		fields: make([]structField, 0, n),
 	}
-	c[t] = s
+	c.types[t] = s
+
+	unexported := false
+	inline := false
 
 	for i := 0; i != n; i++ {
 		ft := t.Field(i)
 
-		if ft.Anonymous || len(ft.PkgPath) != 0 { // anonymous or non-exported
+		if ft.Anonymous { // anonymous
+			continue
+		}
+
+		if isIgnoredType(ft.Type) {
 			continue
 		}
 
-		sf := makeStructField(ft, c)
+		tag := parseFieldTag(ft, c.tags)
+
+		if len(ft.PkgPath) != 0 && !tag.Export { // non-exported
+			unexported = true
+			continue
+		}
+
+		if tag.Inline {
+			s.addInline(t, ft, c)
+			inline = true
+			continue
+		}
+
+		sf := makeStructField(ft, tag, c)
 
 		if sf.name == "-" { // skip
 			continue
 		}
 
+		if len(ft.PkgPath) != 0 {
+			sf.hidden, sf.getter = true, getterOf(t, ft)
+			s.hidden = true
+		}
+
+		sf.setter = setterOf(t, ft)
+
+		if sf.hidden && purego && (sf.getter < 0 || !sf.setter.IsValid()) {
+			s.setErr(objutil.Errorf(objutil.ErrType, "objconv: the unexported field %s of %s must have getter and setter methods when building with the purego tag", ft.Name, t))
+		}
+
+		sf.encrypted = tag.Encrypt
+
+		if tag.Encrypt && !makeEncryptedField(&sf, t, ft.Type) {
+			s.setErr(objutil.Errorf(objutil.ErrType, "objconv: the encrypted field %s of %s must hold strings, byte slices, or values implementing encoding.BinaryMarshaler or encoding.TextMarshaler, not %s", ft.Name, t, ft.Type))
+		}
+
 		s.fields = append(s.fields, sf)
-		s.fieldsByName[sf.name] = &s.fields[len(s.fields)-1]
 	}
 
+	s.opaque = unexported && len(s.fields) == 0
+
+	for _, f := range virtualFieldsOf(t) {
+		s.fields = append(s.fields, makeVirtualField(f, c))
+		s.hidden = true
+	}
+
+	if builders := linkBuildersOf(t); len(builders) != 0 {
+		s.fields = append(s.fields, makeLinksField(builders, c))
+		s.hidden = true
+	}
+
+	if inline && s.err == nil {
+		s.err = inlineConflict(t, s.fields)
+	}
+
+	if p := fieldPolicyOf(t); p != nil && !c.raw {
+		s.applyPolicy(p)
+	}
+
+	s.lookup = makeFieldLookup(s.fields)
 	return s
 }
 
+// addInline merges the fields of the struct, or the map, held by the inline
+// field f of t into s.
+func (s *structType) addInline(t reflect.Type, f reflect.StructField, c *structTypes) {
+	switch {
+	case len(f.PkgPath) != 0:
+		s.setErr(objutil.Errorf(objutil.ErrType, "objconv: the inline field %s of %s must be exported", f.Name, t))
+
+	case f.Type.Kind() == reflect.Struct:
+		in := newStructType(f.Type, c)
+		s.setErr(in.err)
+
+		for _, sf := range in.fields {
+			sf.inline = append(append([]int{}, f.Index...), sf.inline...)
+			s.fields = append(s.fields, sf)
+		}
+
+		if in.rest != nil {
+			rest := *in.rest
+			rest.inline = append(append([]int{}, f.Index...), rest.inline...)
+			s.setRest(t, &rest)
+		}
+
+		s.hidden = s.hidden || in.hidden
+
+	case f.Type.Kind() == reflect.Map && f.Type.Key().Kind() == reflect.String:
+		s.setRest(t, &structField{
+			index:  f.Index,
+			name:   f.Name,
+			getter: -1,
+
+			encode: makeEncodeFunc(f.Type.Elem(), encodeFuncOpts{
+				recurse: true,
+				structs: c,
+			}),
+
+			decode: makeDecodeFunc(f.Type.Elem(), decodeFuncOpts{
+				recurse: true,
+				structs: c,
+			}),
+		})
+
+	default:
+		s.setErr(objutil.Errorf(objutil.ErrType, "objconv: the inline field %s of %s must be a struct or a map with string keys, not %s", f.Name, t, f.Type))
+	}
+}
+
+func (s *structType) setRest(t reflect.Type, rest *structField) {
+	if s.rest != nil {
+		s.setErr(objutil.Errorf(objutil.ErrType, "objconv: %s cannot have multiple inline maps", t))
+		return
+	}
+	s.rest = rest
+}
+
+// setErr records err if s has no errors yet, the first error is reported.
+func (s *structType) setErr(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// inlineConflict returns an error if fields has an inline field with the same
+// name as another field. Fields of the struct itself are not conflicts, the
+// last one with a name wins.
+func inlineConflict(t reflect.Type, fields []structField) error {
+	names := make(map[string]*structField, len(fields))
+
+	for i := range fields {
+		f := &fields[i]
+
+		if g := names[f.name]; g != nil && (g.inline != nil || f.inline != nil) {
+			return objutil.Errorf(objutil.ErrType, "objconv: %s has multiple fields named %q because of inline fields", t, f.name)
+		}
+
+		names[f.name] = f
+	}
+
+	return nil
+}
+
+// field returns a pointer to the field of s with the given name, or nil if
+// there are none.
+func (s *structType) field(name []byte) *structField {
+	if i := s.lookup.index(name); i >= 0 {
+		return &s.fields[i]
+	}
+	return nil
+}
+
+// fieldLookup is the data structure used to find struct fields by name when
+// decoding, which profiles show is one of the most expensive operations when
+// decoding structs with many fields.
+//
+// Instead of using a map, a perfect hash table is generated for each struct
+// type, the hash function only looks at the length and a few bytes of the
+// names which makes it much cheaper than hashing the full key. In the rare
+// cases where no perfect hash function can be found a binary search is done on
+// the sorted list of names.
+type fieldLookup struct {
+	names  []string // sorted field names
+	fields []int    // indexes of the fields in the struct, parallel to names
+	table  []int8   // perfect hash table, -1 marks empty slots
+	seed   uint32   // seed of the perfect hash function
+	shift  uint32   // shift of the perfect hash function
+}
+
+func makeFieldLookup(fields []structField) fieldLookup {
+	// When multiple fields have the same name the last one wins.
+	byName := make(map[string]int, len(fields))
+	for i := range fields {
+		byName[fields[i].name] = i
+	}
+
+	l := fieldLookup{
+		names:  make([]string, 0, len(byName)),
+		fields: make([]int, 0, len(byName)),
+	}
+
+	for name := range byName {
+		l.names = append(l.names, name)
+	}
+	sort.Strings(l.names)
+
+	for _, name := range l.names {
+		l.fields = append(l.fields, byName[name])
+	}
+
+	if len(l.names) <= maxPerfectHashFields {
+		l.generatePerfectHash()
+	}
+
+	return l
+}
+
+// The perfect hash tables store indexes as int8.
+const maxPerfectHashFields = 127
+
+func (l *fieldLookup) generatePerfectHash() {
+	n := len(l.names)
+
+	for bits := uint32(1); bits <= 16; bits++ {
+		size := 1 << bits
+
+		if size < 2*n {
+			continue
+		}
+
+		if size > 8*n && size > 16 {
+			break
+		}
+
+		table := make([]int8, size)
+		shift := 32 - bits
+
+		for seed, attempt := uint32(0x9E3779B1), 0; attempt != 100; attempt++ {
+			for i := range table {
+				table[i] = -1
+			}
+
+			ok := true
+
+			for i, name := range l.names {
+				h := fieldHash(name, seed, shift)
+
+				if table[h] >= 0 {
+					ok = false
+					break
+				}
+
+				table[h] = int8(i)
+			}
+
+			if ok {
+				l.table, l.seed, l.shift = table, seed, shift
+				return
+			}
+
+			seed += 0x6C8E9CF6 // keeps the seed odd
+		}
+	}
+}
+
+func fieldHash(name string, seed uint32, shift uint32) uint32 {
+	n := len(name)
+	k := uint32(n)
+
+	if n != 0 {
+		k |= uint32(name[0])<<8 | uint32(name[n/2])<<16 | uint32(name[n-1])<<24
+	}
+
+	return (k * seed) >> shift
+}
+
+func (l *fieldLookup) index(name []byte) int {
+	if l.table != nil {
+		n := len(name)
+		k := uint32(n)
+
+		if n != 0 {
+			k |= uint32(name[0])<<8 | uint32(name[n/2])<<16 | uint32(name[n-1])<<24
+		}
+
+		if i := l.table[(k*l.seed)>>l.shift]; i >= 0 && l.names[i] == string(name) {
+			return l.fields[i]
+		}
+
+		return -1
+	}
+
+	i, j := 0, len(l.names)
+
+	for i < j {
+		h := int(uint(i+j) >> 1)
+
+		if l.names[h] < string(name) {
+			i = h + 1
+		} else {
+			j = h
+		}
+	}
+
+	if i < len(l.names) && l.names[i] == string(name) {
+		return l.fields[i]
+	}
+
+	return -1
+}
+
 // structTypeCache is a simple cache for mapping Go types to Struct values.
 type structTypeCache struct {
-	mutex sync.RWMutex
-	store map[reflect.Type]*structType
+	mutex  sync.RWMutex
+	store  map[reflect.Type]*structType
+	tags   []string    // names of the tags read by the struct types of the cache
+	naming FieldNaming // naming convention of the fields without tag names
 }
 
 // lookup takes a Go type as argument and returns the matching structType value,
@@ -137,7 +600,7 @@ func (cache *structTypeCache) lookup(t reflect.Type) (s *structType) {
 		// often, we take the approach of keeping the logic simple and avoid
 		// a more complex synchronization logic required to solve this edge
 		// case.
-		s = newStructType(t, map[reflect.Type]*structType{})
+		s = newStructType(t, makeStructTypes(cache.tags, cache.naming))
 		cache.mutex.Lock()
 		cache.store[t] = s
 		cache.mutex.Unlock()
@@ -162,4 +625,86 @@ var (
 	structCache = structTypeCache{
 		store: make(map[reflect.Type]*structType),
 	}
+
+	// Caches of the struct types made for encoders and decoders configured
+	// with a list of tag names or a field naming convention.
+	taggedStructCaches struct {
+		mutex sync.RWMutex
+		store map[structCacheKey]*structTypeCache
+	}
 )
+
+// maxCacheKeyTags is the number of tag names held by the keys of the caches of
+// struct types, the names beyond the last one are joined with it.
+const maxCacheKeyTags = 4
+
+// structCacheKey is the key of the caches of struct types in taggedStructCaches,
+// it is comparable so finding the cache of encoders and decoders doesn't need
+// to build a string from their tag names.
+type structCacheKey struct {
+	tags   [maxCacheKeyTags]string
+	n      int
+	naming FieldNaming
+}
+
+func makeStructCacheKey(names []string, naming FieldNaming) structCacheKey {
+	k := structCacheKey{n: len(names), naming: naming}
+
+	if len(names) > maxCacheKeyTags {
+		copy(k.tags[:], names[:maxCacheKeyTags-1])
+		// Tag names are made of printable characters, they can't contain
+		// the separator.
+		k.tags[maxCacheKeyTags-1] = strings.Join(names[maxCacheKeyTags-1:], "\x00")
+	} else {
+		copy(k.tags[:], names)
+	}
+
+	return k
+}
+
+// structCacheOf returns the cache of struct types which read the tags with the
+// given names and apply the naming convention, the default cache is returned
+// when names is empty and the naming is GoNaming.
+func structCacheOf(names []string, naming FieldNaming) *structTypeCache {
+	if len(names) == 0 && naming == GoNaming {
+		return &structCache
+	}
+
+	key := makeStructCacheKey(names, naming)
+
+	taggedStructCaches.mutex.RLock()
+	cache := taggedStructCaches.store[key]
+	taggedStructCaches.mutex.RUnlock()
+
+	if cache != nil {
+		return cache
+	}
+
+	taggedStructCaches.mutex.Lock()
+	defer taggedStructCaches.mutex.Unlock()
+
+	if cache = taggedStructCaches.store[key]; cache == nil {
+		if taggedStructCaches.store == nil {
+			taggedStructCaches.store = make(map[structCacheKey]*structTypeCache)
+		}
+		cache = &structTypeCache{
+			store:  make(map[reflect.Type]*structType),
+			tags:   append([]string{}, names...),
+			naming: naming,
+		}
+		taggedStructCaches.store[key] = cache
+	}
+
+	return cache
+}
+
+// clearStructCaches empties all the caches of struct types, which is needed
+// when the configuration that they were made from changes.
+func clearStructCaches() {
+	structCache.clear()
+	taggedStructCaches.mutex.RLock()
+	for _, cache := range taggedStructCaches.store {
+		cache.clear()
+	}
+	taggedStructCaches.mutex.RUnlock()
+}
@@ -50,7 +50,7 @@ func TestMakeStructField(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run("", func(t *testing.T) {
-			f := makeStructField(test.s, map[reflect.Type]*structType{})
+			f := makeStructField(test.s, parseFieldTag(test.s, nil), makeStructTypes(nil, GoNaming))
 			f.decode = nil // function types are not comparable
 			f.encode = nil
 
@@ -60,3 +60,166 @@ func TestMakeStructField(t *testing.T) {
 		})
 	}
 }
+
+func TestStructTypeField(t *testing.T) {
+	type Small struct {
+		A int
+		B int `objconv:"b"`
+		C int `objconv:"-"`
+	}
+
+	type Wide struct {
+		F00, F01, F02, F03, F04, F05, F06, F07, F08, F09 int
+		F10, F11, F12, F13, F14, F15, F16, F17, F18, F19 int
+		Dup1                                             int `objconv:"F00"`
+	}
+
+	tests := []struct {
+		typ   reflect.Type
+		name  string
+		index []int
+	}{
+		{reflect.TypeOf(Small{}), "A", []int{0}},
+		{reflect.TypeOf(Small{}), "b", []int{1}},
+		{reflect.TypeOf(Small{}), "B", nil},
+		{reflect.TypeOf(Small{}), "C", nil},
+		{reflect.TypeOf(Wide{}), "F00", []int{20}}, // the last field wins
+		{reflect.TypeOf(Wide{}), "F01", []int{1}},
+		{reflect.TypeOf(Wide{}), "F13", []int{13}},
+		{reflect.TypeOf(Wide{}), "F19", []int{19}},
+		{reflect.TypeOf(Wide{}), "F20", nil},
+		{reflect.TypeOf(Wide{}), "", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.typ.Name()+"."+test.name, func(t *testing.T) {
+			f := structCache.lookup(test.typ).field([]byte(test.name))
+
+			switch {
+			case f == nil && test.index != nil:
+				t.Error("field not found")
+			case f != nil && test.index == nil:
+				t.Error("unexpected field found:", f.name)
+			case f != nil && !reflect.DeepEqual(f.index, test.index):
+				t.Error("bad field index:", f.index)
+			}
+		})
+	}
+}
+
+func TestFieldLookupBinarySearch(t *testing.T) {
+	fields := []structField{{name: "A"}, {name: "C"}, {name: "B"}, {name: "A"}}
+
+	l := makeFieldLookup(fields)
+	l.table = nil // force the fallback on binary search
+
+	for _, test := range []struct {
+		name  string
+		index int
+	}{
+		{"A", 3},
+		{"B", 2},
+		{"C", 1},
+		{"D", -1},
+		{"", -1},
+	} {
+		if i := l.index([]byte(test.name)); i != test.index {
+			t.Errorf("%q: bad field index: %d != %d", test.name, test.index, i)
+		}
+	}
+}
+
+func BenchmarkStructTypeField(b *testing.B) {
+	type Wide struct {
+		F00, F01, F02, F03, F04, F05, F06, F07, F08, F09 int
+		F10, F11, F12, F13, F14, F15, F16, F17, F18, F19 int
+	}
+
+	s := structCache.lookup(reflect.TypeOf(Wide{}))
+	k := []byte("F13")
+	b.ReportAllocs()
+
+	for i := 0; i != b.N; i++ {
+		s.field(k)
+	}
+}
+
+func TestTagNames(t *testing.T) {
+	type inner struct {
+		Value int `yaml:"v" json:"value"`
+	}
+
+	type outer struct {
+		Name  string  `yaml:"name" json:"n"`
+		Inner inner   `json:"inner"`
+		List  []inner `yaml:"list,omitempty"`
+		Skip  string  `yaml:"-" objconv:"skip"`
+	}
+
+	e := NewValueEmitter()
+	enc := Encoder{Emitter: e, TagNames: []string{"yaml", "json"}}
+
+	if err := enc.Encode(outer{Name: "A", Inner: inner{Value: 1}, Skip: "?"}); err != nil {
+		t.Fatal(err)
+	}
+
+	m := map[interface{}]interface{}{
+		"name":  "A",
+		"inner": map[interface{}]interface{}{"v": int64(1)},
+	}
+
+	if !reflect.DeepEqual(e.Value(), m) {
+		t.Errorf("%#v != %#v", e.Value(), m)
+	}
+
+	var v outer
+	dec := Decoder{Parser: NewValueParser(m), TagNames: []string{"yaml", "json"}}
+
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(v, outer{Name: "A", Inner: inner{Value: 1}}) {
+		t.Errorf("bad value: %#v", v)
+	}
+
+	// The default tags are still used by encoders without tag names.
+	e = NewValueEmitter()
+
+	if err := (Encoder{Emitter: e}).Encode(inner{Value: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if x := e.Value(); !reflect.DeepEqual(x, map[interface{}]interface{}{"value": int64(2)}) {
+		t.Errorf("bad value: %#v", x)
+	}
+}
+
+func TestStructCacheOf(t *testing.T) {
+	tags := []string{"yaml", "json"}
+	c := structCacheOf(tags, SnakeCase)
+
+	if structCacheOf([]string{"yaml", "json"}, SnakeCase) != c {
+		t.Error("encoders and decoders with the same configuration must share their cache")
+	}
+
+	for _, other := range []*structTypeCache{
+		structCacheOf([]string{"yaml,json"}, SnakeCase),
+		structCacheOf([]string{"json", "yaml"}, SnakeCase),
+		structCacheOf(tags, CamelCase),
+		structCacheOf([]string{"a", "b", "c", "d", "e"}, SnakeCase),
+		structCacheOf(nil, GoNaming),
+	} {
+		if other == c {
+			t.Errorf("different configurations must have different caches: %q", other.tags)
+		}
+	}
+
+	if structCacheOf([]string{"a", "b", "c", "d,e", "f"}, SnakeCase) == structCacheOf([]string{"a", "b", "c", "d", "e,f"}, SnakeCase) {
+		t.Error("different configurations must have different caches")
+	}
+
+	if n := testing.AllocsPerRun(100, func() { structCacheOf(tags, SnakeCase) }); n != 0 {
+		t.Errorf("%v allocations made when looking up the struct cache", n)
+	}
+}
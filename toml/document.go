@@ -0,0 +1,372 @@
+package toml
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Document is a model of a TOML document which preserves its comments and
+// blank lines, it is intended to be used by programs editing configuration
+// files written by people.
+//
+// The lines of the document that are not modified are written back exactly as
+// they were read. The document is represented as a list of sections, which are
+// the top-level table and the tables declared by headers, holding key/value
+// pairs that can be modified with Set and Delete. Values are replaced as a
+// whole, including arrays and inline tables.
+type Document struct {
+	sections []*docSection // the first section is the top-level table
+	tail     []string      // comments and blank lines after the last entry
+}
+
+type docSection struct {
+	before  []string    // comments and blank lines preceding the header
+	line    string      // line of the header, empty for the top-level table
+	path    []string    // decoded keys of the header
+	array   bool        // set for the headers of arrays of tables
+	entries []*docEntry // key/value pairs of the section
+}
+
+type docEntry struct {
+	before  []string // comments and blank lines preceding the entry
+	key     []string // decoded keys of the entry, relative to its section
+	prefix  string   // indentation, key and separator as written in the document
+	value   string   // value as written in the document, may span multiple lines
+	comment string   // spaces and comment following the value
+}
+
+// ParseDocument loads the TOML document in b into a Document value.
+func ParseDocument(b []byte) (*Document, error) {
+	d := &Document{}
+
+	if err := d.parse(b); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Decode decodes the document into v.
+func (d *Document) Decode(v interface{}) error {
+	return Unmarshal(d.Bytes(), v)
+}
+
+// Set encodes v and writes it in the document at the key found by following
+// path, the tables leading to the key are created when they don't exist. The
+// whole document is replaced when path is empty. Since TOML has no nil values,
+// setting a nil value deletes the key.
+//
+// The comments preceding the entry and at the end of its line are preserved
+// when the key already had a value that isn't a table. Maps and arrays of maps
+// are written as new tables and arrays of tables at the end of the document,
+// other values are added after the existing entries of the table at path, or
+// with dotted keys in the closest table that the document declares.
+//
+// The method returns an error without modifying the document if the value
+// cannot be written at path, for example because a value of path is not a
+// table.
+func (d *Document) Set(v interface{}, path ...string) error {
+	x, err := encodeValue(v)
+	if err != nil {
+		return err
+	}
+
+	if len(path) == 0 {
+		t, ok := x.(*table)
+		if !ok {
+			return objutil.Errorf(objutil.ErrType, "objconv/toml: only tables can be set at the top level, not values of type %T", v)
+		}
+
+		b, err := defaultLayout.appendTable(nil, nil, t)
+		if err != nil {
+			return err
+		}
+
+		return d.parse(b)
+	}
+
+	// The changes are made to a copy of the document, which replaces it once
+	// its output is known to be valid.
+	c, err := ParseDocument(d.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if err := c.set(x, path); err != nil {
+		return err
+	}
+
+	return d.parse(c.Bytes())
+}
+
+func (d *Document) set(x interface{}, path []string) error {
+	for _, s := range d.sections {
+		for _, e := range s.entries {
+			if full := e.path(s); len(full) < len(path) && hasPrefix(path, full) {
+				return objutil.Errorf(objutil.ErrType, "objconv/toml: %s is not a table which can be extended", joinKeys(full))
+			}
+		}
+	}
+
+	if x != nil && !isSection(x) {
+		if e := d.lookup(path); e != nil {
+			b, err := defaultLayout.appendValue(nil, x, 0, false)
+			if err != nil {
+				return err
+			}
+			e.value = string(b)
+			return nil
+		}
+	}
+
+	d.Delete(path...)
+
+	switch {
+	case x == nil:
+		return nil
+
+	case isSection(x):
+		n := len(path) - 1
+		t := &table{keys: []string{path[n]}, values: []interface{}{x}}
+
+		b, err := defaultLayout.appendTable(nil, path[:n], t)
+		if err != nil {
+			return err
+		}
+
+		// The lines are loaded as sections when Set parses the output of the
+		// document, they are kept after a blank line in the meantime.
+		d.tail = append(append(d.tail, ""), splitLines(b)...)
+		return nil
+	}
+
+	b, err := defaultLayout.appendValue(nil, x, 0, false)
+	if err != nil {
+		return err
+	}
+
+	s := d.sections[0]
+
+	for _, section := range d.sections {
+		if !section.array && len(section.path) > len(s.path) && len(section.path) < len(path) && hasPrefix(path, section.path) {
+			s = section
+		}
+	}
+
+	indent := ""
+	if n := len(s.entries); n != 0 {
+		p := s.entries[n-1].prefix
+		indent = p[:len(p)-len(strings.TrimLeft(p, " \t"))]
+	}
+
+	key := path[len(s.path):]
+	s.entries = append(s.entries, &docEntry{
+		key:    key,
+		prefix: indent + string(appendKeys(nil, key)) + " = ",
+		value:  string(b),
+	})
+	return nil
+}
+
+// Delete removes the key found by following path from the document, along
+// with the comments preceding it. When the key is a table, the entries and the
+// headers of the table and of its sub-tables are removed. The method returns
+// false if no values existed at path.
+func (d *Document) Delete(path ...string) bool {
+	if len(path) == 0 {
+		return false
+	}
+
+	found := false
+	sections := d.sections[:1]
+
+	for i, s := range d.sections {
+		if i != 0 && hasPrefix(s.path, path) {
+			found = true
+			continue
+		}
+
+		entries := s.entries[:0]
+
+		for _, e := range s.entries {
+			if hasPrefix(e.path(s), path) {
+				found = true
+				continue
+			}
+			entries = append(entries, e)
+		}
+
+		s.entries = entries
+
+		if i != 0 {
+			sections = append(sections, s)
+		}
+	}
+
+	d.sections = sections
+	return found
+}
+
+// Bytes returns the TOML representation of the document.
+func (d *Document) Bytes() []byte {
+	var b []byte
+
+	for i, s := range d.sections {
+		b = appendLines(b, s.before)
+
+		if i != 0 {
+			b = appendLine(b, s.line)
+		}
+
+		for _, e := range s.entries {
+			b = appendLines(b, e.before)
+			b = appendLine(b, e.prefix+e.value+e.comment)
+		}
+	}
+
+	return appendLines(b, d.tail)
+}
+
+// WriteTo writes the TOML representation of the document to w.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(d.Bytes())
+	return int64(n), err
+}
+
+func (d *Document) parse(b []byte) error {
+	// The document is loaded first to report its errors, the loader can then
+	// be used to find the bounds of the keys and values without failing.
+	if _, err := load(b); err != nil {
+		return err
+	}
+
+	var trivia []string
+
+	l := &loader{s: b, line: 1}
+	root := &docSection{}
+	sections := []*docSection{root}
+	s := root
+
+	for !l.eof() {
+		start := l.i
+		l.skipSpace()
+
+		switch c := l.peek(); {
+		case l.eof() || c == '#' || c == '\n' || c == '\r':
+			trivia = append(trivia, l.restOfLine(start))
+
+		case c == '[':
+			array := l.hasPrefix("[[")
+			l.i++
+			if array {
+				l.i++
+			}
+			l.skipSpace()
+			keys, _, _ := l.key()
+			s = &docSection{before: trivia, line: l.restOfLine(start), path: keys, array: array}
+			sections = append(sections, s)
+			trivia = nil
+
+		default:
+			keys, _, _ := l.key()
+			l.i++ // =
+			l.skipSpace()
+			vstart := l.i
+			l.value()
+			vend := l.i
+			e := &docEntry{before: trivia, key: keys, prefix: string(b[start:vstart]), value: string(b[vstart:vend])}
+			e.comment = l.restOfLine(vend)
+			s.entries = append(s.entries, e)
+			trivia = nil
+		}
+	}
+
+	d.sections, d.tail = sections, trivia
+	return nil
+}
+
+// restOfLine returns the text from start to the end of the current line, and
+// moves the loader to the beginning of the next line.
+func (l *loader) restOfLine(start int) string {
+	for !l.eof() && l.s[l.i] != '\n' {
+		l.i++
+	}
+	end := l.i
+	l.newline()
+	return string(l.s[start:end])
+}
+
+func (d *Document) lookup(path []string) *docEntry {
+	for _, s := range d.sections {
+		for _, e := range s.entries {
+			if full := e.path(s); len(full) == len(path) && hasPrefix(full, path) {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// path returns the keys of the entry from the top-level table.
+func (e *docEntry) path(s *docSection) []string {
+	return append(s.path[:len(s.path):len(s.path)], e.key...)
+}
+
+func hasPrefix(path []string, prefix []string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i, k := range prefix {
+		if path[i] != k {
+			return false
+		}
+	}
+	return true
+}
+
+func appendKeys(b []byte, keys []string) []byte {
+	for i, k := range keys {
+		if i != 0 {
+			b = append(b, '.')
+		}
+		b = appendKey(b, k)
+	}
+	return b
+}
+
+func appendLine(b []byte, line string) []byte {
+	return append(append(b, line...), '\n')
+}
+
+func appendLines(b []byte, lines []string) []byte {
+	for _, line := range lines {
+		b = appendLine(b, line)
+	}
+	return b
+}
+
+func splitLines(b []byte) []string {
+	return strings.Split(string(bytes.TrimSuffix(b, []byte("\n"))), "\n")
+}
+
+// encodeValue returns the in-memory representation of v used by the emitter,
+// which is nil if v is encoded as a nil value.
+func encodeValue(v interface{}) (interface{}, error) {
+	e := &Emitter{layout: defaultLayout}
+	t := &tableEmitter{self: &table{}, val: true}
+	e.push(t)
+
+	if err := (objconv.Encoder{Emitter: e}).Encode(v); err != nil {
+		return nil, err
+	}
+
+	if len(t.self.values) == 0 {
+		return nil, nil
+	}
+
+	return t.self.values[0], nil
+}
@@ -0,0 +1,151 @@
+package toml
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/objconv"
+)
+
+const document = `# Service configuration.
+name = "api" # the name of the service
+tags = [
+  "a", # first
+  "b",
+]
+
+[server]
+# Address to listen on.
+address = ":8080"
+timeout = "5s"
+
+[server.tls]
+enabled = false
+
+[[backend]]
+host = "a.local"
+
+[[backend]]
+host = "b.local"
+# End of file.
+`
+
+func TestDocumentRoundTrip(t *testing.T) {
+	d, err := ParseDocument([]byte(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(d.Bytes()); s != document {
+		t.Errorf("bad document:\n%s", s)
+	}
+
+	var v struct {
+		Name   string   `objconv:"name"`
+		Tags   []string `objconv:"tags"`
+		Server struct {
+			Address string `objconv:"address"`
+			TLS     struct {
+				Enabled bool `objconv:"enabled"`
+			} `objconv:"tls"`
+		} `objconv:"server"`
+		Backend []struct {
+			Host string `objconv:"host"`
+		} `objconv:"backend"`
+	}
+
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Name != "api" || v.Server.Address != ":8080" || !reflect.DeepEqual(v.Tags, []string{"a", "b"}) || len(v.Backend) != 2 || v.Backend[1].Host != "b.local" {
+		t.Errorf("bad value: %+v", v)
+	}
+}
+
+func TestDocumentEdit(t *testing.T) {
+	d, err := ParseDocument([]byte(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edits := []struct {
+		v    interface{}
+		path []string
+	}{
+		{"web", []string{"name"}},
+		{true, []string{"server", "tls", "enabled"}},
+		{"/etc/tls/cert.pem", []string{"server", "tls", "cert"}},
+		{[]string{"c"}, []string{"tags"}},
+		{map[string]int{"max": 10}, []string{"limits"}},
+		{3, []string{"limits", "min"}},
+		{1, []string{"server", "workers", "max"}},
+		{nil, []string{"server", "timeout"}},
+	}
+
+	for _, edit := range edits {
+		if err := d.Set(edit.v, edit.path...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !d.Delete("backend") {
+		t.Error("the backend tables were not deleted")
+	}
+
+	if d.Delete("server", "missing") {
+		t.Error("a missing entry was reported as deleted")
+	}
+
+	const s = `# Service configuration.
+name = "web" # the name of the service
+tags = ["c"]
+
+[server]
+# Address to listen on.
+address = ":8080"
+workers.max = 1
+
+[server.tls]
+enabled = true
+cert = "/etc/tls/cert.pem"
+# End of file.
+
+[limits]
+max = 10
+min = 3
+`
+
+	if b := string(d.Bytes()); b != s {
+		t.Errorf("bad document:\n%s", b)
+	}
+}
+
+func TestDocumentErrors(t *testing.T) {
+	d, err := ParseDocument([]byte(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Set(1, "name", "first"); !errors.Is(err, objconv.ErrType) {
+		t.Error("bad error:", err)
+	}
+
+	if err := d.Set(1); !errors.Is(err, objconv.ErrType) {
+		t.Error("bad error:", err)
+	}
+
+	// The document is left unchanged when the output would be invalid.
+	if err := d.Set(1, "backend", "port"); !errors.Is(err, objconv.ErrSyntax) {
+		t.Error("bad error:", err)
+	}
+
+	if s := string(d.Bytes()); s != document {
+		t.Errorf("bad document:\n%s", s)
+	}
+
+	if _, err := ParseDocument([]byte("a = [")); !errors.Is(err, objconv.ErrSyntax) {
+		t.Error("bad error:", err)
+	}
+}
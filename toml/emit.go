@@ -0,0 +1,539 @@
+package toml
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Emitter implements a TOML emitter that satisfies the objconv.Emitter
+// interface.
+//
+// The key/value pairs of TOML tables must be written before their sub-tables,
+// so the emitter builds each document in memory and writes it once the
+// top-level table is complete.
+type Emitter struct {
+	w io.Writer
+	b []byte
+	// The stack is used to keep track of the container being built by the
+	// emitter, which may be an arrayEmitter or tableEmitter.
+	stack []emitter
+	// Layout of the output, the default one writes arrays on a single line
+	// and doesn't indent tables.
+	layout layout
+}
+
+func NewEmitter(w io.Writer) *Emitter {
+	return &Emitter{w: w, layout: defaultLayout}
+}
+
+// NewFormatEmitter returns a new emitter that writes TOML documents to w in the
+// layout configured by f.
+//
+// The key/value pairs of tables are indented once per level of nesting of the
+// tables, and the headers of sub-tables are aligned with the key/value pairs of
+// their parent. Keys and values are separated by an equal sign surrounded by
+// f.Space. Since TOML inline tables must be written on a single line, only the
+// arrays which aren't elements of inline tables can be wrapped.
+func NewFormatEmitter(w io.Writer, f objconv.Format) *Emitter {
+	return &Emitter{w: w, layout: newLayout(f)}
+}
+
+// FormatEmitter satisfies the objconv.FormatEmitter interface.
+func (e *Emitter) FormatEmitter(f objconv.Format) objconv.Emitter {
+	return NewFormatEmitter(e.w, f)
+}
+
+func (e *Emitter) Reset(w io.Writer) {
+	e.w = w
+	e.b = e.b[:0]
+	e.stack = e.stack[:0]
+}
+
+func (e *Emitter) EmitNil() error {
+	return e.emit(nil)
+}
+
+func (e *Emitter) EmitBool(v bool) error {
+	return e.emit(v)
+}
+
+func (e *Emitter) EmitInt(v int64, _ int) error {
+	return e.emit(v)
+}
+
+func (e *Emitter) EmitUint(v uint64, _ int) error {
+	if v > objutil.Int64Max {
+		return objutil.Errorf(objutil.ErrRange, "objconv/toml: %d cannot be represented by a signed 64 bits integer", v)
+	}
+	return e.emit(int64(v))
+}
+
+func (e *Emitter) EmitFloat(v float64, _ int) error {
+	return e.emit(v)
+}
+
+func (e *Emitter) EmitString(v string) error {
+	return e.emit(v)
+}
+
+func (e *Emitter) EmitBytes(v []byte) error {
+	return e.emit(base64.StdEncoding.EncodeToString(v))
+}
+
+func (e *Emitter) EmitTime(v time.Time) error {
+	return e.emit(v)
+}
+
+func (e *Emitter) EmitDuration(v time.Duration) error {
+	return e.emit(string(objutil.AppendDuration(nil, v)))
+}
+
+func (e *Emitter) EmitError(v error) error {
+	return e.emit(v.Error())
+}
+
+func (e *Emitter) EmitArrayBegin(_ int) (err error) {
+	e.push(&arrayEmitter{self: []interface{}{}})
+	return
+}
+
+func (e *Emitter) EmitArrayEnd() (err error) {
+	return e.emit(e.pop().value())
+}
+
+func (e *Emitter) EmitArrayNext() (err error) {
+	return
+}
+
+func (e *Emitter) EmitMapBegin(_ int) (err error) {
+	e.push(&tableEmitter{self: &table{}})
+	return
+}
+
+func (e *Emitter) EmitMapEnd() (err error) {
+	return e.emit(e.pop().value())
+}
+
+func (e *Emitter) EmitMapValue() (err error) {
+	return
+}
+
+func (e *Emitter) EmitMapNext() (err error) {
+	return
+}
+
+func (e *Emitter) TextEmitter() bool {
+	return true
+}
+
+func (e *Emitter) emit(v interface{}) (err error) {
+	if n := len(e.stack); n != 0 {
+		return e.stack[n-1].emit(v)
+	}
+
+	t, ok := v.(*table)
+	if !ok {
+		return objutil.Errorf(objutil.ErrType, "objconv/toml: only tables can be encoded at the top level, not values of type %T", v)
+	}
+
+	if e.b, err = e.layout.appendTable(e.b[:0], nil, t); err != nil {
+		return
+	}
+
+	if e.layout.prefix != "" {
+		e.b = e.layout.prefixLines(e.b)
+	}
+
+	_, err = e.w.Write(e.b)
+	return
+}
+
+func (e *Emitter) push(v emitter) {
+	e.stack = append(e.stack, v)
+}
+
+func (e *Emitter) pop() emitter {
+	i := len(e.stack) - 1
+	v := e.stack[i]
+	e.stack = e.stack[:i]
+	return v
+}
+
+type emitter interface {
+	emit(interface{}) error
+	value() interface{}
+}
+
+type arrayEmitter struct {
+	self []interface{}
+}
+
+func (e *arrayEmitter) emit(v interface{}) error {
+	if v == nil {
+		return objutil.Errorf(objutil.ErrType, "objconv/toml: cannot encode nil values in arrays")
+	}
+	e.self = append(e.self, v)
+	return nil
+}
+
+func (e *arrayEmitter) value() interface{} {
+	return e.self
+}
+
+type tableEmitter struct {
+	self *table
+	key  string
+	val  bool
+}
+
+func (e *tableEmitter) emit(v interface{}) error {
+	if e.val {
+		// TOML has no nil values, the keys of nil values are omitted.
+		if e.val = false; v != nil {
+			e.self.set(e.key, v)
+		}
+		return nil
+	}
+
+	switch k := v.(type) {
+	case string:
+		e.key = k
+	case int64:
+		e.key = strconv.FormatInt(k, 10)
+	default:
+		return objutil.Errorf(objutil.ErrType, "objconv/toml: table keys must be strings or integers, not values of type %T", v)
+	}
+
+	e.val = true
+	return nil
+}
+
+func (e *tableEmitter) value() interface{} {
+	return e.self
+}
+
+// layout is the representation of formatting policies used by the emitter.
+type layout struct {
+	prefix string // prefix of each line
+	indent string // indentation of each nesting level, empty for single lines
+	equal  string // separator of keys and values
+	comma  string // separator of the elements of inline arrays and tables
+	space  string // spacing within the braces of inline tables
+	width  int    // maximum length of arrays written on a single line
+	p      []byte // output with prefixed lines
+}
+
+var defaultLayout = layout{
+	equal: " = ",
+	comma: ", ",
+	space: " ",
+}
+
+func newLayout(f objconv.Format) layout {
+	return layout{
+		prefix: f.Prefix,
+		indent: f.Indent,
+		equal:  f.Space + "=" + f.Space,
+		comma:  "," + f.Space,
+		space:  f.Space,
+		width:  f.Width,
+	}
+}
+
+// appendTable writes the entries of t under the header of path, key/value
+// pairs come first, then sub-tables and arrays of tables.
+func (l *layout) appendTable(b []byte, path []string, t *table) ([]byte, error) {
+	var err error
+
+	for i, k := range t.keys {
+		if v := t.values[i]; !isSection(v) {
+			b = l.appendIndent(b, len(path))
+			b = appendKey(b, k)
+			b = append(b, l.equal...)
+
+			if b, err = l.appendValue(b, v, len(path), false); err != nil {
+				return b, err
+			}
+
+			b = append(b, '\n')
+		}
+	}
+
+	for i, k := range t.keys {
+		switch v := t.values[i].(type) {
+		case *table:
+			sub := append(path[:len(path):len(path)], k)
+			b = l.appendHeader(b, "[", sub, "]")
+
+			if b, err = l.appendTable(b, sub, v); err != nil {
+				return b, err
+			}
+
+		case []interface{}:
+			if !isSection(v) {
+				continue
+			}
+
+			sub := append(path[:len(path):len(path)], k)
+
+			for _, elem := range v {
+				b = l.appendHeader(b, "[[", sub, "]]")
+
+				if b, err = l.appendTable(b, sub, elem.(*table)); err != nil {
+					return b, err
+				}
+			}
+		}
+	}
+
+	return b, nil
+}
+
+// isSection returns true if v is written as a table or an array of tables.
+func isSection(v interface{}) bool {
+	switch x := v.(type) {
+	case *table:
+		return true
+	case []interface{}:
+		for _, elem := range x {
+			if _, ok := elem.(*table); !ok {
+				return false
+			}
+		}
+		return len(x) != 0
+	default:
+		return false
+	}
+}
+
+func (l *layout) appendHeader(b []byte, open string, path []string, close string) []byte {
+	if len(b) != 0 {
+		b = append(b, '\n')
+	}
+
+	b = l.appendIndent(b, len(path)-1)
+	b = append(b, open...)
+
+	for i, k := range path {
+		if i != 0 {
+			b = append(b, '.')
+		}
+		b = appendKey(b, k)
+	}
+
+	b = append(b, close...)
+	return append(b, '\n')
+}
+
+func (l *layout) appendIndent(b []byte, n int) []byte {
+	if l.indent != "" {
+		for i := 0; i != n; i++ {
+			b = append(b, l.indent...)
+		}
+	}
+	return b
+}
+
+func appendKey(b []byte, k string) []byte {
+	if isBareKey(k) {
+		return append(b, k...)
+	}
+	return appendString(b, k)
+}
+
+// appendValue writes v at the indentation level n, inline is true for the
+// values of inline tables, which must be written on a single line.
+func (l *layout) appendValue(b []byte, v interface{}, n int, inline bool) ([]byte, error) {
+	var err error
+
+	switch x := v.(type) {
+	case bool:
+		b = strconv.AppendBool(b, x)
+
+	case int64:
+		b = strconv.AppendInt(b, x, 10)
+
+	case float64:
+		b = appendFloat(b, x)
+
+	case string:
+		b = appendString(b, x)
+
+	case time.Time:
+		b = x.AppendFormat(b, time.RFC3339Nano)
+
+	case []interface{}:
+		if !inline && l.wrap(b, x) {
+			b = append(b, '[', '\n')
+
+			for _, elem := range x {
+				b = l.appendIndent(b, n+1)
+
+				if b, err = l.appendValue(b, elem, n+1, false); err != nil {
+					return b, err
+				}
+
+				b = append(b, ',', '\n')
+			}
+
+			b = l.appendIndent(b, n)
+			return append(b, ']'), nil
+		}
+
+		b = append(b, '[')
+
+		for i, elem := range x {
+			if i != 0 {
+				b = append(b, l.comma...)
+			}
+			if b, err = l.appendValue(b, elem, n, inline); err != nil {
+				return b, err
+			}
+		}
+
+		b = append(b, ']')
+
+	case *table:
+		if len(x.keys) == 0 {
+			return append(b, "{}"...), nil
+		}
+
+		b = append(b, '{')
+		b = append(b, l.space...)
+
+		for i, k := range x.keys {
+			if i != 0 {
+				b = append(b, l.comma...)
+			}
+			b = appendKey(b, k)
+			b = append(b, l.equal...)
+
+			if b, err = l.appendValue(b, x.values[i], n, true); err != nil {
+				return b, err
+			}
+		}
+
+		b = append(b, l.space...)
+		b = append(b, '}')
+	}
+
+	return b, nil
+}
+
+// wrap returns true if the array a must be written on multiple lines, which is
+// the case of non-empty arrays when the layout has an indentation, unless they
+// only contain scalar values and fit within the width of the layout.
+func (l *layout) wrap(b []byte, a []interface{}) bool {
+	if l.indent == "" || len(a) == 0 {
+		return false
+	}
+
+	if l.width == 0 {
+		return true
+	}
+
+	for _, elem := range a {
+		switch elem.(type) {
+		case []interface{}, *table:
+			return true
+		}
+	}
+
+	c, err := l.appendValue(b[len(b):], a, 0, true)
+	return err != nil || len(c) > l.width
+}
+
+// prefixLines returns a copy of b where each line begins with the prefix of the
+// layout, which is only valid until the next call to prefixLines.
+func (l *layout) prefixLines(b []byte) []byte {
+	l.p = l.p[:0]
+
+	for len(b) != 0 {
+		i := bytes.IndexByte(b, '\n') + 1
+		if i == 0 {
+			i = len(b)
+		}
+		l.p = append(append(l.p, l.prefix...), b[:i]...)
+		b = b[i:]
+	}
+
+	return l.p
+}
+
+func appendFloat(b []byte, f float64) []byte {
+	switch {
+	case math.IsNaN(f):
+		return append(b, "nan"...)
+	case math.IsInf(f, +1):
+		return append(b, "inf"...)
+	case math.IsInf(f, -1):
+		return append(b, "-inf"...)
+	}
+
+	i := len(b)
+	b = strconv.AppendFloat(b, f, 'g', -1, 64)
+
+	// TOML floats must have a fractional part or an exponent.
+	for _, c := range b[i:] {
+		if c == '.' || c == 'e' {
+			return b
+		}
+	}
+
+	return append(b, ".0"...)
+}
+
+// appendString writes s as a TOML basic string.
+func appendString(b []byte, s string) []byte {
+	b = append(b, '"')
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		if c < utf8.RuneSelf {
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\b':
+				b = append(b, '\\', 'b')
+			case '\t':
+				b = append(b, '\\', 't')
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\f':
+				b = append(b, '\\', 'f')
+			case '\r':
+				b = append(b, '\\', 'r')
+			default:
+				if isControl(c) {
+					b = append(b, `\u00`...)
+					b = append(b, "0123456789ABCDEF"[c>>4], "0123456789ABCDEF"[c&0xF])
+				} else {
+					b = append(b, c)
+				}
+			}
+			i++
+			continue
+		}
+
+		r, n := utf8.DecodeRuneInString(s[i:])
+
+		if r == utf8.RuneError && n == 1 {
+			b = append(b, `�`...)
+		} else {
+			b = append(b, s[i:i+n]...)
+		}
+
+		i += n
+	}
+
+	return append(b, '"')
+}
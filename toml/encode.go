@@ -0,0 +1,60 @@
+package toml
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/segmentio/objconv"
+)
+
+// NewEncoder returns a new TOML encoder that writes to w.
+func NewEncoder(w io.Writer) *objconv.Encoder {
+	return objconv.NewEncoder(NewEmitter(w))
+}
+
+// Marshal writes the TOML representation of v to a byte slice returned in b.
+func Marshal(v interface{}) (b []byte, err error) {
+	m := marshalerPool.Get().(*marshaler)
+	m.b.Truncate(0)
+
+	if err = (objconv.Encoder{Emitter: m}).Encode(v); err == nil {
+		b = make([]byte, m.b.Len())
+		copy(b, m.b.Bytes())
+	}
+
+	marshalerPool.Put(m)
+	return
+}
+
+// Append appends the TOML representation of v to dst and returns the extended
+// slice, dst is returned unchanged if an error occurs. Unlike Marshal, it
+// doesn't allocate memory when dst has enough capacity.
+func Append(dst []byte, v interface{}) (b []byte, err error) {
+	m := marshalerPool.Get().(*marshaler)
+	m.b.Truncate(0)
+	b = dst
+
+	if err = (objconv.Encoder{Emitter: m}).Encode(v); err == nil {
+		b = append(dst, m.b.Bytes()...)
+	}
+
+	marshalerPool.Put(m)
+	return
+}
+
+var marshalerPool = sync.Pool{
+	New: func() interface{} { return newMarshaler() },
+}
+
+type marshaler struct {
+	Emitter
+	b bytes.Buffer
+}
+
+func newMarshaler() *marshaler {
+	m := &marshaler{}
+	m.w = &m.b
+	m.layout = defaultLayout
+	return m
+}
@@ -0,0 +1,811 @@
+package toml
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// loader parses TOML documents into trees of tables.
+type loader struct {
+	s    []byte
+	i    int
+	line int
+	bol  int // offset of the first byte of the current line
+	root *table
+	cur  *table // table of the last header
+}
+
+func load(b []byte) (*table, error) {
+	l := &loader{s: b, line: 1, root: &table{}}
+	l.cur = l.root
+
+	if err := l.document(); err != nil {
+		return nil, err
+	}
+
+	return l.root, nil
+}
+
+func (l *loader) errorf(kind error, msg string, args ...interface{}) error {
+	return objutil.Errorf(kind, "objconv/toml: line %d: "+msg, append([]interface{}{l.line}, args...)...)
+}
+
+func (l *loader) syntaxError(msg string, args ...interface{}) error {
+	return l.errorf(objutil.ErrSyntax, msg, args...)
+}
+
+func (l *loader) eof() bool {
+	return l.i >= len(l.s)
+}
+
+func (l *loader) peek() byte {
+	if l.eof() {
+		return 0
+	}
+	return l.s[l.i]
+}
+
+func (l *loader) hasPrefix(s string) bool {
+	return len(l.s)-l.i >= len(s) && string(l.s[l.i:l.i+len(s)]) == s
+}
+
+func (l *loader) document() error {
+	for {
+		if err := l.skipBlank(); err != nil {
+			return err
+		}
+
+		if l.eof() {
+			return nil
+		}
+
+		var err error
+
+		if l.peek() == '[' {
+			err = l.header()
+		} else {
+			err = l.keyValue(l.cur)
+		}
+
+		if err == nil {
+			err = l.endOfLine()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// skipSpace skips spaces and tabs.
+func (l *loader) skipSpace() {
+	for !l.eof() && (l.s[l.i] == ' ' || l.s[l.i] == '\t') {
+		l.i++
+	}
+}
+
+// skipComment skips a comment up to the end of the line.
+func (l *loader) skipComment() error {
+	if l.peek() != '#' {
+		return nil
+	}
+	for ; !l.eof() && l.s[l.i] != '\n'; l.i++ {
+		if c := l.s[l.i]; isControl(c) && c != '\t' && !(c == '\r' && l.i+1 < len(l.s) && l.s[l.i+1] == '\n') {
+			return l.syntaxError("control characters are not allowed in comments")
+		}
+	}
+	return nil
+}
+
+// newline consumes a line break and returns true, or returns false if there is
+// none at the current position.
+func (l *loader) newline() bool {
+	switch {
+	case l.hasPrefix("\n"):
+		l.i++
+	case l.hasPrefix("\r\n"):
+		l.i += 2
+	default:
+		return false
+	}
+	l.line++
+	l.bol = l.i
+	return true
+}
+
+func (l *loader) pos() objconv.Position {
+	return objconv.Position{Line: l.line, Column: l.i - l.bol + 1}
+}
+
+// skipBlank skips spaces, comments, and line breaks.
+func (l *loader) skipBlank() error {
+	for {
+		l.skipSpace()
+
+		if err := l.skipComment(); err != nil {
+			return err
+		}
+
+		if !l.newline() {
+			return nil
+		}
+	}
+}
+
+func (l *loader) endOfLine() error {
+	l.skipSpace()
+
+	if err := l.skipComment(); err != nil {
+		return err
+	}
+
+	if !l.eof() && !l.newline() {
+		return l.syntaxError("expected a line break but found %q", l.peek())
+	}
+
+	return nil
+}
+
+func (l *loader) header() error {
+	array := l.hasPrefix("[[")
+	hpos := l.pos()
+
+	if array {
+		l.i += 2
+	} else {
+		l.i++
+	}
+
+	l.skipSpace()
+
+	keys, kpos, err := l.key()
+	if err != nil {
+		return err
+	}
+
+	if array {
+		if !l.hasPrefix("]]") {
+			return l.syntaxError("expected ]] at the end of the header of an array of tables")
+		}
+		l.i += 2
+	} else {
+		if l.peek() != ']' {
+			return l.syntaxError("expected ] at the end of the header of a table")
+		}
+		l.i++
+	}
+
+	t := l.root
+
+	for i, key := range keys[:len(keys)-1] {
+		if t, err = l.subTable(t, key, keys, kpos[i]); err != nil {
+			return err
+		}
+	}
+
+	last := keys[len(keys)-1]
+	lpos := kpos[len(kpos)-1]
+	v, exists := t.get(last)
+
+	if array {
+		a, ok := v.(*tableArray)
+
+		if !exists {
+			a, ok = &tableArray{}, true
+			t.setAt(last, a, lpos, hpos)
+		}
+
+		if !ok {
+			return l.syntaxError("%s is not an array of tables", joinKeys(keys))
+		}
+
+		l.cur = &table{defined: true}
+		a.append(l.cur, hpos)
+		return nil
+	}
+
+	switch x := v.(type) {
+	case nil:
+		if !exists {
+			l.cur = &table{defined: true}
+			t.setAt(last, l.cur, lpos, hpos)
+			return nil
+		}
+	case *table:
+		if !x.defined && !x.dotted && !x.inline {
+			x.defined = true
+			l.cur = x
+			return nil
+		}
+	}
+
+	return l.syntaxError("the table %s is defined more than once", joinKeys(keys))
+}
+
+// subTable returns the table under key in t, creating it if it doesn't exist.
+// Arrays of tables resolve to their last table.
+func (l *loader) subTable(t *table, key string, keys []string, pos objconv.Position) (*table, error) {
+	v, exists := t.get(key)
+
+	if !exists {
+		sub := &table{}
+		t.setAt(key, sub, pos, pos)
+		return sub, nil
+	}
+
+	switch x := v.(type) {
+	case *table:
+		if !x.inline {
+			return x, nil
+		}
+	case *tableArray:
+		return x.values[len(x.values)-1].(*table), nil
+	}
+
+	return nil, l.syntaxError("%s cannot be extended because it is not a table", joinKeys(keys))
+}
+
+func (l *loader) keyValue(t *table) error {
+	keys, kpos, err := l.key()
+	if err != nil {
+		return err
+	}
+
+	if l.peek() != '=' {
+		return l.syntaxError("expected = after the key %s", joinKeys(keys))
+	}
+
+	l.i++
+	l.skipSpace()
+
+	vpos := l.pos()
+
+	v, err := l.value()
+	if err != nil {
+		return err
+	}
+
+	for i, key := range keys[:len(keys)-1] {
+		sub, exists := t.get(key)
+
+		if !exists {
+			next := &table{dotted: true, inline: t.inline}
+			t.setAt(key, next, kpos[i], kpos[i])
+			t = next
+			continue
+		}
+
+		if x, ok := sub.(*table); ok && x.dotted {
+			t = x
+			continue
+		}
+
+		return l.syntaxError("the key %s cannot be extended with dotted keys", joinKeys(keys))
+	}
+
+	last := keys[len(keys)-1]
+
+	if _, exists := t.get(last); exists {
+		return l.syntaxError("the key %s is defined more than once", joinKeys(keys))
+	}
+
+	t.setAt(last, v, kpos[len(kpos)-1], vpos)
+	return nil
+}
+
+// key parses a simple or dotted key, and the spaces that follow it.
+func (l *loader) key() (keys []string, pos []objconv.Position, err error) {
+	for {
+		var k string
+		var p = l.pos()
+
+		switch c := l.peek(); {
+		case c == '"':
+			if l.hasPrefix(`"""`) {
+				return nil, nil, l.syntaxError("keys cannot be multi-line strings")
+			}
+			k, err = l.basicString()
+		case c == '\'':
+			if l.hasPrefix(`'''`) {
+				return nil, nil, l.syntaxError("keys cannot be multi-line strings")
+			}
+			k, err = l.literalString()
+		case isBareKeyChar(c):
+			j := l.i
+			for !l.eof() && isBareKeyChar(l.s[l.i]) {
+				l.i++
+			}
+			k = string(l.s[j:l.i])
+		default:
+			err = l.syntaxError("expected a key but found %q", c)
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		keys = append(keys, k)
+		pos = append(pos, p)
+		l.skipSpace()
+
+		if l.peek() != '.' {
+			return keys, pos, nil
+		}
+
+		l.i++
+		l.skipSpace()
+	}
+}
+
+func (l *loader) value() (interface{}, error) {
+	switch c := l.peek(); {
+	case l.hasPrefix(`"""`):
+		return l.multiLineBasicString()
+	case c == '"':
+		return l.basicString()
+	case l.hasPrefix(`'''`):
+		return l.multiLineLiteralString()
+	case c == '\'':
+		return l.literalString()
+	case c == '[':
+		return l.array()
+	case c == '{':
+		return l.inlineTable()
+	case l.keyword("true"):
+		return true, nil
+	case l.keyword("false"):
+		return false, nil
+	case c == '+' || c == '-' || c == 'i' || c == 'n' || (c >= '0' && c <= '9'):
+		return l.scalar()
+	case l.eof():
+		return nil, l.syntaxError("expected a value but reached the end of the document")
+	default:
+		return nil, l.syntaxError("expected a value but found %q", c)
+	}
+}
+
+func (l *loader) keyword(s string) bool {
+	if !l.hasPrefix(s) {
+		return false
+	}
+	if j := l.i + len(s); j < len(l.s) && isBareKeyChar(l.s[j]) {
+		return false
+	}
+	l.i += len(s)
+	return true
+}
+
+func (l *loader) array() (interface{}, error) {
+	a := &array{values: []interface{}{}}
+	l.i++
+
+	for {
+		if err := l.skipBlank(); err != nil {
+			return nil, err
+		}
+
+		if l.peek() == ']' {
+			l.i++
+			return a, nil
+		}
+
+		pos := l.pos()
+
+		v, err := l.value()
+		if err != nil {
+			return nil, err
+		}
+
+		a.append(v, pos)
+
+		if err := l.skipBlank(); err != nil {
+			return nil, err
+		}
+
+		switch l.peek() {
+		case ',':
+			l.i++
+		case ']':
+			l.i++
+			return a, nil
+		default:
+			return nil, l.syntaxError("expected , or ] after an array element")
+		}
+	}
+}
+
+func (l *loader) inlineTable() (interface{}, error) {
+	t := &table{inline: true}
+	l.i++
+	l.skipSpace()
+
+	if l.peek() == '}' {
+		l.i++
+		return t, nil
+	}
+
+	for {
+		if err := l.keyValue(t); err != nil {
+			return nil, err
+		}
+
+		l.skipSpace()
+
+		switch l.peek() {
+		case ',':
+			l.i++
+			l.skipSpace()
+		case '}':
+			l.i++
+			return t, nil
+		default:
+			return nil, l.syntaxError("expected , or } after an inline table entry")
+		}
+	}
+}
+
+func (l *loader) basicString() (string, error) {
+	var b []byte
+	l.i++
+
+	for {
+		if l.eof() {
+			return "", l.syntaxError("unterminated string")
+		}
+
+		switch c := l.s[l.i]; {
+		case c == '"':
+			l.i++
+			return string(b), nil
+		case c == '\\':
+			var err error
+			if b, err = l.escape(b); err != nil {
+				return "", err
+			}
+		case c == '\n' || (isControl(c) && c != '\t'):
+			return "", l.syntaxError("control characters must be escaped in basic strings")
+		default:
+			b = append(b, c)
+			l.i++
+		}
+	}
+}
+
+func (l *loader) multiLineBasicString() (string, error) {
+	var b []byte
+	l.i += 3
+	l.newline()
+
+	for {
+		if l.eof() {
+			return "", l.syntaxError("unterminated multi-line string")
+		}
+
+		switch c := l.s[l.i]; {
+		case l.hasPrefix(`"""`):
+			return l.closeMultiLine(b, '"')
+		case c == '\\':
+			// A backslash at the end of a line trims the line break and the
+			// whitespace that follows.
+			j := l.i + 1
+			for j < len(l.s) && (l.s[j] == ' ' || l.s[j] == '\t') {
+				j++
+			}
+			if j < len(l.s) && (l.s[j] == '\n' || l.s[j] == '\r') {
+				for l.i = j; l.newline() || l.peek() == ' ' || l.peek() == '\t'; {
+					if c := l.peek(); c == ' ' || c == '\t' {
+						l.i++
+					}
+				}
+				continue
+			}
+			var err error
+			if b, err = l.escape(b); err != nil {
+				return "", err
+			}
+		case l.newline():
+			b = append(b, '\n')
+		case isControl(c) && c != '\t':
+			return "", l.syntaxError("control characters must be escaped in basic strings")
+		default:
+			b = append(b, c)
+			l.i++
+		}
+	}
+}
+
+func (l *loader) literalString() (string, error) {
+	j := l.i + 1
+
+	for l.i = j; !l.eof(); l.i++ {
+		switch c := l.s[l.i]; {
+		case c == '\'':
+			l.i++
+			return string(l.s[j : l.i-1]), nil
+		case c == '\n' || (isControl(c) && c != '\t'):
+			return "", l.syntaxError("control characters are not allowed in literal strings")
+		}
+	}
+
+	return "", l.syntaxError("unterminated string")
+}
+
+func (l *loader) multiLineLiteralString() (string, error) {
+	var b []byte
+	l.i += 3
+	l.newline()
+
+	for {
+		if l.eof() {
+			return "", l.syntaxError("unterminated multi-line string")
+		}
+
+		switch c := l.s[l.i]; {
+		case l.hasPrefix(`'''`):
+			return l.closeMultiLine(b, '\'')
+		case l.newline():
+			b = append(b, '\n')
+		case isControl(c) && c != '\t':
+			return "", l.syntaxError("control characters are not allowed in literal strings")
+		default:
+			b = append(b, c)
+			l.i++
+		}
+	}
+}
+
+// closeMultiLine consumes the delimiter of a multi-line string, up to two
+// quotes preceding it are part of the string.
+func (l *loader) closeMultiLine(b []byte, q byte) (string, error) {
+	n := 0
+
+	for !l.eof() && l.s[l.i] == q && n != 5 {
+		l.i++
+		n++
+	}
+
+	for ; n > 3; n-- {
+		b = append(b, q)
+	}
+
+	return string(b), nil
+}
+
+func (l *loader) escape(b []byte) ([]byte, error) {
+	if l.i+1 >= len(l.s) {
+		return b, l.syntaxError("unterminated escape sequence")
+	}
+
+	c := l.s[l.i+1]
+	l.i += 2
+
+	switch c {
+	case 'b':
+		return append(b, '\b'), nil
+	case 't':
+		return append(b, '\t'), nil
+	case 'n':
+		return append(b, '\n'), nil
+	case 'f':
+		return append(b, '\f'), nil
+	case 'r':
+		return append(b, '\r'), nil
+	case '"':
+		return append(b, '"'), nil
+	case '\\':
+		return append(b, '\\'), nil
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+
+		if l.i+n > len(l.s) {
+			return b, l.syntaxError("truncated unicode escape sequence")
+		}
+
+		x, err := strconv.ParseUint(string(l.s[l.i:l.i+n]), 16, 32)
+		if err != nil || !utf8.ValidRune(rune(x)) {
+			return b, l.syntaxError("invalid unicode escape sequence: \\%c%s", c, l.s[l.i:l.i+n])
+		}
+
+		l.i += n
+		return utf8.AppendRune(b, rune(x)), nil
+	default:
+		return b, l.syntaxError("invalid escape sequence: \\%c", c)
+	}
+}
+
+// scalar parses numbers, date-times, dates, and times.
+func (l *loader) scalar() (interface{}, error) {
+	j := l.i
+
+	for !l.eof() && isScalarChar(l.s[l.i]) {
+		l.i++
+	}
+
+	// The date and time of date-times may be separated by a space.
+	if l.i-j == 10 && l.hasPrefix(" ") && l.i+3 < len(l.s) && isDigit(l.s[l.i+1]) && isDigit(l.s[l.i+2]) && l.s[l.i+3] == ':' {
+		for l.i++; !l.eof() && isScalarChar(l.s[l.i]); l.i++ {
+		}
+	}
+
+	s := string(l.s[j:l.i])
+
+	switch {
+	case len(s) >= 10 && s[4] == '-' && s[7] == '-':
+		return l.dateTime(s)
+	case len(s) >= 8 && s[2] == ':':
+		if _, err := time.Parse("15:04:05.999999999", s); err != nil {
+			return nil, l.syntaxError("invalid local time: %s", s)
+		}
+		return s, nil
+	}
+
+	switch s {
+	case "inf", "+inf":
+		return math.Inf(+1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	case "nan", "+nan", "-nan":
+		return math.NaN(), nil
+	}
+
+	if len(s) > 2 && s[0] == '0' {
+		base := 0
+
+		switch s[1] {
+		case 'x':
+			base = 16
+		case 'o':
+			base = 8
+		case 'b':
+			base = 2
+		}
+
+		if base != 0 {
+			digits, ok := stripUnderscores(s[2:], base)
+			if !ok {
+				return nil, l.syntaxError("invalid integer: %s", s)
+			}
+
+			v, err := strconv.ParseInt(digits, base, 64)
+			if err != nil {
+				return nil, l.numError(s, err)
+			}
+
+			return v, nil
+		}
+	}
+
+	unsigned := strings.TrimLeft(s, "+-")
+
+	if len(s)-len(unsigned) > 1 || unsigned == "" {
+		return nil, l.syntaxError("invalid number: %s", s)
+	}
+
+	if strings.ContainsAny(unsigned, ".eE") {
+		mantissa := unsigned
+		if i := strings.IndexAny(unsigned, "eE"); i >= 0 {
+			mantissa = unsigned[:i]
+		}
+
+		if i := strings.IndexByte(mantissa, '.'); i >= 0 && (i == 0 || i == len(mantissa)-1 || !isDigit(mantissa[i+1])) {
+			return nil, l.syntaxError("invalid float: %s", s)
+		}
+
+		if hasLeadingZero(mantissa) {
+			return nil, l.syntaxError("invalid float: %s", s)
+		}
+
+		digits, ok := stripUnderscores(s, 10)
+		if !ok {
+			return nil, l.syntaxError("invalid float: %s", s)
+		}
+
+		v, err := strconv.ParseFloat(digits, 64)
+		if err != nil {
+			return nil, l.numError(s, err)
+		}
+
+		return v, nil
+	}
+
+	digits, ok := stripUnderscores(s, 10)
+	if !ok || hasLeadingZero(unsigned) {
+		return nil, l.syntaxError("invalid integer: %s", s)
+	}
+
+	v, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return nil, l.numError(s, err)
+	}
+
+	return v, nil
+}
+
+func (l *loader) numError(s string, err error) error {
+	if e, ok := err.(*strconv.NumError); ok && e.Err == strconv.ErrRange {
+		return l.errorf(objutil.ErrRange, "number out of range: %s", s)
+	}
+	return l.syntaxError("invalid number: %s", s)
+}
+
+func (l *loader) dateTime(s string) (interface{}, error) {
+	t := []byte(s)
+
+	if len(t) > 10 && (t[10] == ' ' || t[10] == 't') {
+		t[10] = 'T'
+	}
+
+	if n := len(t); t[n-1] == 'z' {
+		t[n-1] = 'Z'
+	}
+
+	s = string(t)
+
+	if v, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return v, nil
+	}
+
+	for _, layout := range [...]string{"2006-01-02T15:04:05.999999999", "2006-01-02"} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return s, nil
+		}
+	}
+
+	return nil, l.syntaxError("invalid date-time: %s", s)
+}
+
+// stripUnderscores removes the underscores between the digits of s, ok is
+// false if an underscore is not surrounded by digits.
+func stripUnderscores(s string, base int) (digits string, ok bool) {
+	if strings.IndexByte(s, '_') < 0 {
+		return s, true
+	}
+
+	b := make([]byte, 0, len(s))
+
+	for i := 0; i != len(s); i++ {
+		if s[i] != '_' {
+			b = append(b, s[i])
+			continue
+		}
+		if i == 0 || i == len(s)-1 || !isBaseDigit(s[i-1], base) || !isBaseDigit(s[i+1], base) {
+			return "", false
+		}
+	}
+
+	return string(b), true
+}
+
+func hasLeadingZero(s string) bool {
+	return len(s) > 1 && s[0] == '0' && isDigit(s[1])
+}
+
+func isScalarChar(c byte) bool {
+	return isBareKeyChar(c) || c == '+' || c == '.' || c == ':'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isBaseDigit(c byte, base int) bool {
+	switch base {
+	case 16:
+		return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+	default:
+		return c >= '0' && c < '0'+byte(base)
+	}
+}
+
+func isControl(c byte) bool {
+	return c < 0x20 || c == 0x7f
+}
@@ -0,0 +1,93 @@
+// Package toml implements a codec for the TOML format, it is registered under
+// the "application/toml" and "toml" names.
+//
+// TOML documents are tables, so only maps and structs can be encoded at the
+// top level. Nested maps are written as tables, or as inline tables when they
+// are elements of arrays that aren't only made of tables, and arrays of maps
+// are written as arrays of tables. TOML has no representation for nil values,
+// the keys of nil values are omitted from tables.
+//
+// Offset date-times are decoded as time values, local date-times, dates and
+// times are decoded as strings since they don't designate an instant.
+package toml
+
+import (
+	"strings"
+
+	"github.com/segmentio/objconv"
+)
+
+// table is the in-memory representation of TOML tables used by the emitter
+// and the parser, entries are kept in the order they were written in.
+type table struct {
+	keys   []string
+	values []interface{}
+	// Positions of the keys and values in the document, they are only set by
+	// the loader. The position of a table declared by a header is the position
+	// of the header.
+	kpos []objconv.Position
+	vpos []objconv.Position
+
+	defined bool // set when the table was declared by a header
+	dotted  bool // set when the table was created by a dotted key
+	inline  bool // set for inline tables, which cannot be extended
+}
+
+func (t *table) get(key string) (interface{}, bool) {
+	for i, k := range t.keys {
+		if k == key {
+			return t.values[i], true
+		}
+	}
+	return nil, false
+}
+
+func (t *table) set(key string, value interface{}) {
+	t.keys = append(t.keys, key)
+	t.values = append(t.values, value)
+}
+
+func (t *table) setAt(key string, value interface{}, kpos, vpos objconv.Position) {
+	t.set(key, value)
+	t.kpos = append(t.kpos, kpos)
+	t.vpos = append(t.vpos, vpos)
+}
+
+// array is the representation of the arrays loaded from documents, which
+// carry the positions of their elements.
+type array struct {
+	values []interface{}
+	pos    []objconv.Position
+}
+
+func (a *array) append(value interface{}, pos objconv.Position) {
+	a.values = append(a.values, value)
+	a.pos = append(a.pos, pos)
+}
+
+// tableArray is the representation of arrays of tables declared by [[...]]
+// headers, which can be extended unlike arrays of inline tables. The positions
+// of the tables are the positions of their headers.
+type tableArray struct {
+	array
+}
+
+func isBareKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i != len(key); i++ {
+		if !isBareKeyChar(key[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isBareKeyChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '-'
+}
+
+func joinKeys(keys []string) string {
+	return strings.Join(keys, ".")
+}
@@ -0,0 +1,448 @@
+package toml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+type database struct {
+	Host    string        `objconv:"host"`
+	Ports   []int         `objconv:"ports"`
+	Timeout time.Duration `objconv:"timeout"`
+	Enabled bool          `objconv:"enabled"`
+}
+
+type product struct {
+	Name  string  `objconv:"name"`
+	Price float64 `objconv:"price"`
+}
+
+type config struct {
+	Title    string            `objconv:"title"`
+	Updated  time.Time         `objconv:"updated"`
+	Database database          `objconv:"database"`
+	Labels   map[string]string `objconv:"labels"`
+	Products []product         `objconv:"products"`
+	Matrix   [][]int           `objconv:"matrix"`
+	Missing  *string           `objconv:"missing"`
+}
+
+var testConfig = config{
+	Title:   "TOML \"Example\"",
+	Updated: time.Date(2017, 7, 14, 2, 40, 0, 500000000, time.UTC),
+	Database: database{
+		Host:    "192.168.1.1",
+		Ports:   []int{8000, 8001},
+		Timeout: 5 * time.Second,
+		Enabled: true,
+	},
+	Labels:   map[string]string{"app.name": "example"},
+	Products: []product{{"Hammer", 9.5}, {"Nail", 1}},
+	Matrix:   [][]int{{1, 2}, {3}},
+}
+
+const testDocument = `title = "TOML \"Example\""
+updated = 2017-07-14T02:40:00.5Z
+matrix = [[1, 2], [3]]
+
+[database]
+host = "192.168.1.1"
+ports = [8000, 8001]
+timeout = "5s"
+enabled = true
+
+[labels]
+"app.name" = "example"
+
+[[products]]
+name = "Hammer"
+price = 9.5
+
+[[products]]
+name = "Nail"
+price = 1.0
+`
+
+func TestMarshal(t *testing.T) {
+	b, err := Marshal(testConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(b); s != testDocument {
+		t.Errorf("bad document:\n%s", s)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	var c config
+
+	if err := Unmarshal([]byte(testDocument), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(c, testConfig) {
+		t.Errorf("bad value:\n%#v\n%#v", c, testConfig)
+	}
+}
+
+func TestUnmarshalValues(t *testing.T) {
+	const doc = `
+# Comments and blank lines are ignored.
+int1 = +99
+int2 = -17
+int3 = 5_349_221
+hex = 0xDEAD_beef
+oct = 0o755
+bin = 0b1101
+flt1 = 3.1415
+flt2 = -0.01
+flt3 = 5e+22
+flt4 = 6.626e-34
+flt5 = 224_617.445_991
+inf = -inf
+str1 = "tab\tquote\" \u00e9 \U0001F600"
+str2 = 'C:\Users\nodejs'
+str3 = """
+Roses are red
+Violets are blue"""
+str4 = """\
+       The quick brown \
+       fox."""
+str5 = '''
+raw \n text'''
+str6 = """quotes: "" """
+odt1 = 1979-05-27T07:32:00Z
+odt2 = 1979-05-27 00:32:00.999999-07:00
+ldt = 1979-05-27T07:32:00
+ld = 1979-05-27
+lt = 07:32:00
+arr = [
+  1,
+  2, # comment
+]
+point = { x = 1, y.z = 2 }
+a.b.c = true
+
+[table."quoted key"]
+x = 1
+
+[table.sub]
+y = 2
+
+[[fruits]]
+name = "apple"
+
+[[fruits.varieties]]
+name = "red delicious"
+
+[[fruits]]
+name = "banana"
+`
+
+	var v map[string]interface{}
+
+	if err := Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"int1": int64(99),
+		"int2": int64(-17),
+		"int3": int64(5349221),
+		"hex":  int64(0xdeadbeef),
+		"oct":  int64(0755),
+		"bin":  int64(13),
+		"flt1": 3.1415,
+		"flt2": -0.01,
+		"flt3": 5e+22,
+		"flt4": 6.626e-34,
+		"flt5": 224617.445991,
+		"inf":  math.Inf(-1),
+		"str1": "tab\tquote\" é 😀",
+		"str2": `C:\Users\nodejs`,
+		"str3": "Roses are red\nViolets are blue",
+		"str4": "The quick brown fox.",
+		"str5": `raw \n text`,
+		"str6": `quotes: "" `,
+		"odt1": time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+		"odt2": time.Date(1979, 5, 27, 7, 32, 0, 999999000, time.UTC),
+		"ldt":  "1979-05-27T07:32:00",
+		"ld":   "1979-05-27",
+		"lt":   "07:32:00",
+		"arr":  []interface{}{int64(1), int64(2)},
+		"point": map[interface{}]interface{}{
+			"x": int64(1),
+			"y": map[interface{}]interface{}{"z": int64(2)},
+		},
+		"a": map[interface{}]interface{}{
+			"b": map[interface{}]interface{}{"c": true},
+		},
+		"table": map[interface{}]interface{}{
+			"quoted key": map[interface{}]interface{}{"x": int64(1)},
+			"sub":        map[interface{}]interface{}{"y": int64(2)},
+		},
+		"fruits": []interface{}{
+			map[interface{}]interface{}{
+				"name":      "apple",
+				"varieties": []interface{}{map[interface{}]interface{}{"name": "red delicious"}},
+			},
+			map[interface{}]interface{}{"name": "banana"},
+		},
+	}
+
+	for k, x := range expected {
+		y := v[k]
+
+		if t1, ok := y.(time.Time); ok {
+			if !t1.Equal(x.(time.Time)) {
+				t.Errorf("%s: bad time: %v", k, t1)
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(x, y) {
+			t.Errorf("%s: bad value: %#v", k, y)
+		}
+	}
+
+	if len(v) != len(expected) {
+		t.Errorf("bad number of keys: %d", len(v))
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		s    string
+		kind error
+	}{
+		{"a = 1\na = 2", objconv.ErrSyntax},
+		{"[a]\n[a]", objconv.ErrSyntax},
+		{"a = {}\n[a]", objconv.ErrSyntax},
+		{"a = [1]\n[[a]]", objconv.ErrSyntax},
+		{"a.b = 1\n[a.b]", objconv.ErrSyntax},
+		{"a = 1 b = 2", objconv.ErrSyntax},
+		{"a = ", objconv.ErrSyntax},
+		{`a = "unterminated`, objconv.ErrSyntax},
+		{`a = "\q"`, objconv.ErrSyntax},
+		{"a = 01", objconv.ErrSyntax},
+		{"a = 1__0", objconv.ErrSyntax},
+		{"a = 1.", objconv.ErrSyntax},
+		{"a = .5", objconv.ErrSyntax},
+		{"a = 1979-13-27", objconv.ErrSyntax},
+		{"a = { b = 1, }", objconv.ErrSyntax},
+		{"a = 9223372036854775808", objconv.ErrRange},
+	}
+
+	for _, test := range tests {
+		var v interface{}
+
+		if err := Unmarshal([]byte(test.s), &v); !errors.Is(err, test.kind) {
+			t.Errorf("%q: bad error: %v", test.s, err)
+		}
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		kind error
+	}{
+		{42, objconv.ErrType},
+		{[]int{1, 2}, objconv.ErrType},
+		{map[string]interface{}{"a": []interface{}{nil}}, objconv.ErrType},
+		{map[bool]int{true: 1}, objconv.ErrType},
+		{map[string]uint64{"a": objutil.Uint64Max}, objconv.ErrRange},
+	}
+
+	for _, test := range tests {
+		if _, err := Marshal(test.v); !errors.Is(err, test.kind) {
+			t.Errorf("%#v: bad error: %v", test.v, err)
+		}
+	}
+}
+
+func TestMarshalInlineTables(t *testing.T) {
+	v := map[string]interface{}{
+		"mixed": []interface{}{map[string]int{"a": 1}, 2},
+		"empty": map[string]int{},
+		"float": math.NaN(),
+	}
+
+	b := &bytes.Buffer{}
+	e := objconv.Encoder{Emitter: NewEmitter(b), SortMapKeys: true}
+
+	if err := e.Encode(v); err != nil {
+		t.Fatal(err)
+	}
+
+	const s = `float = nan
+mixed = [{ a = 1 }, 2]
+
+[empty]
+`
+
+	if b.String() != s {
+		t.Errorf("bad document:\n%s", b.String())
+	}
+}
+
+func TestDecoderPositions(t *testing.T) {
+	type server struct {
+		Host  string `objconv:"host"`
+		Ports []int  `objconv:"ports"`
+	}
+
+	type config struct {
+		Name    string   `objconv:"name"`
+		Servers []server `objconv:"servers"`
+	}
+
+	const doc = `name = "example"
+
+[[servers]]
+host = "a"
+ports = [
+  80,
+  443,
+]
+
+[[servers]]
+  prot = 8080
+`
+
+	positions := map[string]objconv.Position{}
+	d := NewDecoder(strings.NewReader(doc))
+	d.Positions = positions
+
+	var c config
+
+	if err := d.Decode(&c); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]objconv.Position{
+		"name":                {Line: 1, Column: 8},
+		"servers":             {Line: 3, Column: 1},
+		"servers[0]":          {Line: 3, Column: 1},
+		"servers[0].host":     {Line: 4, Column: 8},
+		"servers[0].ports":    {Line: 5, Column: 9},
+		"servers[0].ports[0]": {Line: 6, Column: 3},
+		"servers[0].ports[1]": {Line: 7, Column: 3},
+		"servers[1]":          {Line: 10, Column: 1},
+		"servers[1].prot":     {Line: 11, Column: 3},
+	}
+
+	if !reflect.DeepEqual(positions, expected) {
+		t.Errorf("bad positions:\n%v\n%v", positions, expected)
+	}
+}
+
+func TestFormatEmitter(t *testing.T) {
+	v := map[string]interface{}{
+		"a": []int{1, 2, 3},
+		"b": []interface{}{map[string]interface{}{"c": []int{4, 5}}, []int{6}},
+		"d": map[string]interface{}{"e": "f", "g": map[string]int{"h": 7}},
+		"i": []map[string]int{{"j": 8}},
+	}
+
+	tests := []struct {
+		f objconv.Format
+		s string
+	}{
+		{
+			f: objconv.DefaultFormat,
+			s: `a = [
+  1,
+  2,
+  3,
+]
+b = [
+  { c = [4, 5] },
+  [
+    6,
+  ],
+]
+
+[d]
+  e = "f"
+
+  [d.g]
+    h = 7
+
+[[i]]
+  j = 8
+`,
+		},
+		{
+			f: objconv.Format{Prefix: "# ", Indent: "\t", Width: 10},
+			s: "# a=[1,2,3]\n# b=[\n# \t{c=[4,5]},\n# \t[6],\n# ]\n# \n# [d]\n# \te=\"f\"\n# \n# \t[d.g]\n# \t\th=7\n# \n# [[i]]\n# \tj=8\n",
+		},
+		{
+			f: objconv.Format{Space: "  "},
+			s: "a  =  [1,  2,  3]\nb  =  [{  c  =  [4,  5]  },  [6]]\n\n[d]\ne  =  \"f\"\n\n[d.g]\nh  =  7\n\n[[i]]\nj  =  8\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%q", test.f.Indent), func(t *testing.T) {
+			b := &bytes.Buffer{}
+			e := objconv.Encoder{Emitter: NewEmitter(b).FormatEmitter(test.f), SortMapKeys: true}
+
+			if err := e.Encode(v); err != nil {
+				t.Fatal(err)
+			}
+
+			if s := b.String(); s != test.s {
+				t.Errorf("bad output:\n%s", s)
+			}
+
+			if test.f.Prefix != "" {
+				return
+			}
+
+			var x map[string]interface{}
+
+			if err := Unmarshal(b.Bytes(), &x); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(x) != len(v) {
+				t.Errorf("bad value: %v", x)
+			}
+		})
+	}
+}
+
+func TestEncoderIndent(t *testing.T) {
+	b := &bytes.Buffer{}
+	e := NewEncoder(b)
+	e.Indent("", "    ")
+
+	if err := e.Encode(testConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(b.String(), "[database]\n    host = \"192.168.1.1\"\n    ports = [\n        8000,\n        8001,\n    ]\n") {
+		t.Errorf("bad output:\n%s", b.String())
+	}
+
+	var c config
+
+	if err := Unmarshal(b.Bytes(), &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(c, testConfig) {
+		t.Errorf("bad value:\n%#v\n%#v", c, testConfig)
+	}
+}
@@ -0,0 +1,228 @@
+package objconv
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Transcode reads the next value from p and writes it to e, without decoding
+// it into an intermediate Go value.
+//
+// Arrays and maps are transcoded element by element, their lengths are passed
+// through to the emitter, so when the parser doesn't know the length of an
+// array or map (like the JSON parser), the emitter must support values of
+// unknown lengths. Values keep the type that the parser reported them as, for
+// example times and bytes parsed from a CBOR input are emitted as times and
+// bytes by the emitter.
+//
+// The function returns io.EOF when the parser reached the end of its input
+// before the value, and an error of the ErrSyntax kind if the input ends in
+// the middle of the value. Streams of values are transcoded by calling it until
+// it returns io.EOF:
+//
+//	for {
+//		if err := objconv.Transcode(e, p); err != nil {
+//			if err == io.EOF {
+//				break
+//			}
+//			return err
+//		}
+//	}
+//
+// If the emitter implements Flusher, it is flushed after each value.
+func Transcode(e Emitter, p Parser) (err error) {
+	if _, err = p.ParseType(); err != nil {
+		return
+	}
+
+	if err = transcode(e, p); err != nil {
+		if err == io.EOF {
+			err = errUnexpectedEOF
+		}
+		return
+	}
+	return flush(e, nil)
+}
+
+func transcode(e Emitter, p Parser) (err error) {
+	var t Type
+
+	if t, err = p.ParseType(); err != nil {
+		return
+	}
+
+	switch t {
+	case Nil:
+		if err = p.ParseNil(); err == nil {
+			err = e.EmitNil()
+		}
+
+	case Bool:
+		var v bool
+		if v, err = p.ParseBool(); err == nil {
+			err = e.EmitBool(v)
+		}
+
+	case Int:
+		var v int64
+		if v, err = p.ParseInt(); err == nil {
+			err = e.EmitInt(v, 64)
+		}
+
+	case Uint:
+		var v uint64
+		if v, err = p.ParseUint(); err == nil {
+			err = e.EmitUint(v, 64)
+		}
+
+	case Float:
+		var v float64
+		if v, err = p.ParseFloat(); err == nil {
+			err = e.EmitFloat(v, 64)
+		}
+
+	case String:
+		var v []byte
+		if v, err = p.ParseString(); err == nil {
+			err = e.EmitString(string(v))
+		}
+
+	case Bytes:
+		var v []byte
+		// Parsers may reuse the buffer that the value was returned in, it is
+		// copied in case the emitter retains it.
+		if v, err = p.ParseBytes(); err == nil {
+			err = e.EmitBytes(append([]byte(nil), v...))
+		}
+
+	case Time:
+		var v time.Time
+		if v, err = p.ParseTime(); err == nil {
+			err = e.EmitTime(v)
+		}
+
+	case Duration:
+		var v time.Duration
+		if v, err = p.ParseDuration(); err == nil {
+			err = e.EmitDuration(v)
+		}
+
+	case Error:
+		var v error
+		if v, err = p.ParseError(); err == nil {
+			err = e.EmitError(v)
+		}
+
+	case Array:
+		err = transcodeArray(e, p)
+
+	case Map:
+		err = transcodeMap(e, p)
+
+	default:
+		err = fmt.Errorf("objconv: cannot transcode values of type %s", t)
+	}
+
+	return
+}
+
+func transcodeArray(e Emitter, p Parser) (err error) {
+	var n int
+
+	if n, err = p.ParseArrayBegin(); err != nil {
+		return
+	}
+
+	if err = e.EmitArrayBegin(n); err != nil {
+		return
+	}
+
+	i := 0
+
+	for n < 0 || i < n {
+		if n < 0 || i != 0 {
+			if err = p.ParseArrayNext(i); err != nil {
+				if err == End {
+					err = nil
+					break
+				}
+				return
+			}
+		}
+
+		if i != 0 {
+			if err = e.EmitArrayNext(); err != nil {
+				return
+			}
+		}
+
+		if err = transcode(e, p); err != nil {
+			return
+		}
+
+		i++
+	}
+
+	if err = p.ParseArrayEnd(i); err != nil {
+		return
+	}
+
+	return e.EmitArrayEnd()
+}
+
+func transcodeMap(e Emitter, p Parser) (err error) {
+	var n int
+
+	if n, err = p.ParseMapBegin(); err != nil {
+		return
+	}
+
+	if err = e.EmitMapBegin(n); err != nil {
+		return
+	}
+
+	i := 0
+
+	for n < 0 || i < n {
+		if n < 0 || i != 0 {
+			if err = p.ParseMapNext(i); err != nil {
+				if err == End {
+					err = nil
+					break
+				}
+				return
+			}
+		}
+
+		if i != 0 {
+			if err = e.EmitMapNext(); err != nil {
+				return
+			}
+		}
+
+		if err = transcode(e, p); err != nil {
+			return
+		}
+
+		if err = p.ParseMapValue(i); err != nil {
+			return
+		}
+
+		if err = e.EmitMapValue(); err != nil {
+			return
+		}
+
+		if err = transcode(e, p); err != nil {
+			return
+		}
+
+		i++
+	}
+
+	if err = p.ParseMapEnd(i); err != nil {
+		return
+	}
+
+	return e.EmitMapEnd()
+}
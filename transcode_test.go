@@ -0,0 +1,37 @@
+package objconv
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTranscode(t *testing.T) {
+	now := time.Now()
+
+	v := map[interface{}]interface{}{
+		"nil":      nil,
+		"bool":     true,
+		"int":      int64(-1),
+		"uint":     uint64(1),
+		"float":    0.5,
+		"string":   "hello",
+		"bytes":    []byte("world"),
+		"time":     now,
+		"duration": time.Second,
+		"error":    errors.New("oops"),
+		"array":    []interface{}{int64(1), []interface{}{}, map[interface{}]interface{}{}},
+	}
+
+	e := NewValueEmitter()
+	p := NewValueParser(v)
+
+	if err := Transcode(e, p); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(e.Value(), v) {
+		t.Errorf("%#v != %#v", e.Value(), v)
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !purego
+// +build !purego
+
+package objconv
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// purego is true when unexported fields can only be accessed with their getter
+// and setter methods.
+const purego = false
+
+// stringNoCopy returns a string that shares its memory with b, the program
+// must ensure that b is not modified for as long as the string is in use.
+func stringNoCopy(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+// unsafeString returns a string that is only safe to use under the following conditions:
+// - b points to data on the heap
+// - the bytes pointed to by b will not be modified while the returned string exists
+// - the returned string will not be stored past the lifetime of b
+// if the method being called with an unsafe returns an error, that error may
+// contain a reference to unsafe string. if the method produces consistent results,
+// calling it again with a safe string should return an error that can be safely
+// returned to the caller.
+func unsafeString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+// exportValue returns a value of the unexported struct field v which can be
+// used like the value of an exported field, v must be addressable.
+func exportValue(v reflect.Value) reflect.Value {
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
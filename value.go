@@ -2,11 +2,11 @@ package objconv
 
 import (
 	"encoding"
-	"errors"
 	"reflect"
 	"sync"
 	"time"
-	"unsafe"
+
+	"github.com/segmentio/objconv/objutil"
 )
 
 // Type is an enumeration that represent all the base types supported by the
@@ -126,17 +126,6 @@ func elemTypeOf(v interface{}) reflect.Type {
 	return reflect.TypeOf(v).Elem()
 }
 
-func stringNoCopy(b []byte) string {
-	n := len(b)
-	if n == 0 {
-		return ""
-	}
-	return *(*string)(unsafe.Pointer(&reflect.StringHeader{
-		Data: uintptr(unsafe.Pointer(&b[0])),
-		Len:  n,
-	}))
-}
-
 // ValueParser is parser that uses "natural" in-memory representation of data
 // structures.
 //
@@ -150,6 +139,17 @@ type valueParserContext struct {
 	value  reflect.Value
 	keys   []reflect.Value
 	fields []structField
+	rest   reflect.Value   // inline map of the struct
+	more   []reflect.Value // keys of the inline map, after the fields
+}
+
+// name returns the key of the n-th entry of the struct of c, the fields come
+// before the keys of the inline map.
+func (c *valueParserContext) name(n int) reflect.Value {
+	if n < len(c.fields) {
+		return reflect.ValueOf(c.fields[n].name)
+	}
+	return reflect.ValueOf(c.more[n-len(c.fields)].String())
 }
 
 // NewValueParser creates a new parser that exposes the value v.
@@ -214,7 +214,7 @@ func (p *ValueParser) ParseType() (Type, error) {
 		}
 	}
 
-	return Nil, errors.New("objconv: unsupported type found in value parser: " + v.Type().String())
+	return Nil, objutil.Errorf(objutil.ErrType, "objconv: unsupported type found in value parser: %s", v.Type().String())
 }
 
 func (p *ValueParser) ParseNil() (err error) {
@@ -304,19 +304,34 @@ func (p *ValueParser) ParseMapBegin() (n int, err error) {
 			p.push(k[0])
 		}
 	} else {
-		c := valueParserContext{value: v}
 		s := structCache.lookup(v.Type())
 
+		if s.err != nil {
+			return 0, s.err
+		}
+
+		v = s.addressable(v)
+		c := valueParserContext{value: v}
+
 		for _, f := range s.fields {
-			if !f.omit(v.FieldByIndex(f.index)) {
+			if f.encrypted && f.encoded() && !f.redacted {
+				return 0, objutil.Errorf(objutil.ErrType, "objconv: the encrypted field %s of %s cannot be exposed by a value parser", f.name, v.Type())
+			}
+			if f.encoded() && !f.omit(f.value(v)) {
 				c.fields = append(c.fields, f)
 				n++
 			}
 		}
 
+		if s.rest != nil {
+			c.rest = s.rest.value(v)
+			c.more = c.rest.MapKeys()
+			n += len(c.more)
+		}
+
 		p.pushContext(c)
 		if n != 0 {
-			p.push(reflect.ValueOf(c.fields[0].name))
+			p.push(c.name(0))
 		}
 	}
 
@@ -335,10 +350,15 @@ func (p *ValueParser) ParseMapValue(n int) (err error) {
 	ctx := p.context()
 	p.pop()
 
-	if ctx.keys != nil {
+	switch {
+	case ctx.keys != nil:
 		p.push(ctx.value.MapIndex(ctx.keys[n]))
-	} else {
-		p.push(ctx.value.FieldByIndex(ctx.fields[n].index))
+	case n >= len(ctx.fields):
+		p.push(ctx.rest.MapIndex(ctx.more[n-len(ctx.fields)]))
+	case ctx.fields[n].redacted:
+		p.push(reflect.ValueOf(ctx.fields[n].placeholder))
+	default:
+		p.push(ctx.fields[n].value(ctx.value))
 	}
 
 	return
@@ -351,7 +371,7 @@ func (p *ValueParser) ParseMapNext(n int) (err error) {
 	if ctx.keys != nil {
 		p.push(ctx.keys[n])
 	} else {
-		p.push(reflect.ValueOf(ctx.fields[n].name))
+		p.push(ctx.name(n))
 	}
 
 	return
@@ -0,0 +1,98 @@
+package objconv
+
+import "context"
+
+// The Emitter and Parser interfaces are implemented by many programs outside
+// of objconv, adding methods to them would break these implementations. New
+// capabilities are instead added to versioned interfaces which extend the
+// previous version; encoders and decoders detect which version an emitter or
+// parser implements and fall back to the behavior of the first version when
+// the new methods are missing.
+
+// EmitterV2 is the second version of the Emitter interface.
+type EmitterV2 interface {
+	Emitter
+
+	// EmitContext is called by Encoder.EncodeContext before writing the value,
+	// with the context the operation is bound to. The emitter may retain the
+	// context to abort blocking writes when it is canceled, returning an error
+	// aborts the encoding of the value.
+	EmitContext(ctx context.Context) error
+}
+
+// ParserV2 is the second version of the Parser interface.
+type ParserV2 interface {
+	Parser
+
+	// ParseContext is called by Decoder.DecodeContext before reading the
+	// value, see EmitterV2.EmitContext.
+	ParseContext(ctx context.Context) error
+
+	// ParseLengthHint is called after ParseType returned Array, it returns an
+	// estimate of the number of elements of the array, or a negative value if
+	// the parser has none. Decoders use it to size the slices they decode
+	// into, the hint doesn't have to be accurate.
+	ParseLengthHint() int
+}
+
+// UpgradeEmitter returns e as an EmitterV2. If e only implements the first
+// version of the interface, it is wrapped in an emitter which implements the
+// new methods with the default behavior of encoders.
+//
+// The wrapper only exposes the methods of EmitterV2, programs that need to
+// detect the optional interfaces implemented by e must use e directly.
+func UpgradeEmitter(e Emitter) EmitterV2 {
+	if v2, ok := e.(EmitterV2); ok {
+		return v2
+	}
+	return emitterV1{e}
+}
+
+// UpgradeParser returns p as a ParserV2, see UpgradeEmitter.
+func UpgradeParser(p Parser) ParserV2 {
+	if v2, ok := p.(ParserV2); ok {
+		return v2
+	}
+	return parserV1{p}
+}
+
+type emitterV1 struct{ Emitter }
+
+func (e emitterV1) EmitContext(ctx context.Context) error { return ctx.Err() }
+
+type parserV1 struct{ Parser }
+
+func (p parserV1) ParseContext(ctx context.Context) error { return ctx.Err() }
+
+func (p parserV1) ParseLengthHint() int { return -1 }
+
+// Each of the functions below calls the method of the newest version of the
+// interface implemented by its argument, without the cost of wrapping it.
+
+func emitContext(e Emitter, ctx context.Context) error {
+	if v2, ok := e.(EmitterV2); ok {
+		return v2.EmitContext(ctx)
+	}
+	return ctx.Err()
+}
+
+func parseContext(p Parser, ctx context.Context) error {
+	if v2, ok := p.(ParserV2); ok {
+		return v2.ParseContext(ctx)
+	}
+	return ctx.Err()
+}
+
+// Length hints are not trusted beyond this number of elements, so malformed
+// input cannot make decoders allocate large amounts of memory.
+const maxLengthHint = 1024
+
+func parseLengthHint(p Parser) int {
+	if v2, ok := p.(ParserV2); ok {
+		if n := v2.ParseLengthHint(); n < maxLengthHint {
+			return n
+		}
+		return maxLengthHint
+	}
+	return -1
+}
@@ -0,0 +1,125 @@
+package objconv
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type contextEmitter struct {
+	*ValueEmitter
+	ctx context.Context
+}
+
+func (e *contextEmitter) EmitContext(ctx context.Context) error {
+	e.ctx = ctx
+	return nil
+}
+
+type hintParser struct {
+	*ValueParser
+	ctx  context.Context
+	hint int
+}
+
+func (p *hintParser) ParseContext(ctx context.Context) error {
+	p.ctx = ctx
+	return nil
+}
+
+func (p *hintParser) ParseLengthHint() int { return p.hint }
+
+type contextKey struct{}
+
+func TestEncodeContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextKey{}, 1)
+	e := &contextEmitter{ValueEmitter: NewValueEmitter()}
+
+	if err := NewEncoder(e).EncodeContext(ctx, 42); err != nil {
+		t.Fatal(err)
+	}
+
+	if e.ctx != ctx {
+		t.Error("the context was not given to the emitter")
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := NewEncoder(NewValueEmitter()).EncodeContext(canceled, 42); !errors.Is(err, context.Canceled) {
+		t.Errorf("bad error: %v", err)
+	}
+}
+
+func TestDecodeContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), contextKey{}, 1)
+	p := &hintParser{ValueParser: NewValueParser([]int{1, 2, 3})}
+
+	var v []int
+
+	if err := NewDecoder(p).DecodeContext(ctx, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.ctx != ctx {
+		t.Error("the context was not given to the parser")
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := NewDecoder(NewValueParser(1)).DecodeContext(canceled, &v); !errors.Is(err, context.Canceled) {
+		t.Errorf("bad error: %v", err)
+	}
+}
+
+func TestDecoderLengthHint(t *testing.T) {
+	tests := []struct {
+		hint int
+		cap  int
+	}{
+		{hint: -1, cap: 10},
+		{hint: 3, cap: 3},
+		{hint: 2, cap: 10}, // inaccurate hints grow like before
+		{hint: 1 << 30, cap: maxLengthHint},
+	}
+
+	for _, test := range tests {
+		var v []int
+		p := &hintParser{ValueParser: NewValueParser([]int{1, 2, 3}), hint: test.hint}
+
+		if err := NewDecoder(p).Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(v, []int{1, 2, 3}) {
+			t.Errorf("hint %d: bad value: %v", test.hint, v)
+		}
+
+		if cap(v) != test.cap {
+			t.Errorf("hint %d: bad capacity: %d", test.hint, cap(v))
+		}
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	e := NewValueEmitter()
+	p := NewValueParser(nil)
+
+	if _, ok := UpgradeEmitter(e).(emitterV1); !ok {
+		t.Error("first version emitters must be wrapped")
+	}
+
+	if v2 := (&contextEmitter{ValueEmitter: e}); UpgradeEmitter(v2) != EmitterV2(v2) {
+		t.Error("second version emitters must not be wrapped")
+	}
+
+	if n := UpgradeParser(p).ParseLengthHint(); n >= 0 {
+		t.Errorf("first version parsers must have no length hints, found %d", n)
+	}
+
+	if v2 := (&hintParser{ValueParser: p}); UpgradeParser(v2) != ParserV2(v2) {
+		t.Error("second version parsers must not be wrapped")
+	}
+}
@@ -0,0 +1,90 @@
+package objconv
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// InstallMethodField registers a method of the struct type typ as a virtual
+// field, which lets computed properties be part of the output of encoders
+// without having to declare shadow structs.
+//
+// The name argument has the syntax of objconv struct tags, it is the
+// serialized name of the field followed by options like omitempty. The method
+// must take no arguments and return a single value, it may have a pointer
+// receiver. Virtual fields are encoded after the regular fields of the struct
+// and are ignored by decoders.
+//
+//	objconv.InstallMethodField(reflect.TypeOf(User{}), "display_name,omitempty", "DisplayName")
+//
+// The function panics if typ is not a struct type or if the method doesn't
+// exist or has the wrong signature. Like Install, it is intended to be called
+// during the package initialization phase.
+func InstallMethodField(typ reflect.Type, name string, method string) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("objconv: cannot install a method field on %s because it is not a struct type", typ))
+	}
+
+	m, ok := reflect.PtrTo(typ).MethodByName(method)
+
+	if !ok {
+		panic(fmt.Sprintf("objconv: cannot install a method field on %s because it has no method named %s", typ, method))
+	}
+
+	if m.Type.NumIn() != 1 || m.Type.NumOut() != 1 {
+		panic(fmt.Sprintf("objconv: cannot install a method field on %s because %s must take no arguments and return one value", typ, method))
+	}
+
+	tag := objutil.ParseTag(name)
+
+	if len(tag.Name) == 0 || tag.Name == "-" {
+		panic(fmt.Sprintf("objconv: invalid name of method field %s.%s: %q", typ, method, name))
+	}
+
+	virtualMutex.Lock()
+	virtualStore[typ] = append(virtualStore[typ], virtualField{tag: tag, method: m})
+	virtualMutex.Unlock()
+
+	// Same as Install, the struct cache may have become invalid.
+	clearStructCaches()
+}
+
+type virtualField struct {
+	tag    objutil.Tag
+	method reflect.Method
+}
+
+func virtualFieldsOf(typ reflect.Type) []virtualField {
+	virtualMutex.RLock()
+	fields := virtualStore[typ]
+	virtualMutex.RUnlock()
+	return fields
+}
+
+func makeVirtualField(f virtualField, c *structTypes) structField {
+	return structField{
+		name:      f.tag.Name,
+		omitempty: f.tag.Omitempty,
+		omitzero:  f.tag.Omitzero,
+		hidden:    true,
+		virtual:   true,
+		getter:    f.method.Index,
+
+		encode: makeEncodeFunc(f.method.Type.Out(0), encodeFuncOpts{
+			recurse: true,
+			structs: c,
+		}),
+	}
+}
+
+var (
+	virtualMutex sync.RWMutex
+	virtualStore = make(map[reflect.Type][]virtualField)
+)
@@ -0,0 +1,84 @@
+package objconv
+
+import (
+	"reflect"
+	"testing"
+)
+
+type virtualUser struct {
+	First string `objconv:"first"`
+	Last  string `objconv:"last"`
+}
+
+func (u virtualUser) DisplayName() string { return u.First + " " + u.Last }
+
+func (u *virtualUser) Initials() string {
+	if len(u.First) == 0 || len(u.Last) == 0 {
+		return ""
+	}
+	return u.First[:1] + u.Last[:1]
+}
+
+func init() {
+	InstallMethodField(reflect.TypeOf(virtualUser{}), "display_name", "DisplayName")
+	InstallMethodField(reflect.TypeOf(&virtualUser{}), "initials,omitempty", "Initials")
+}
+
+func TestMethodFields(t *testing.T) {
+	e := NewValueEmitter()
+
+	if err := NewEncoder(e).Encode(virtualUser{First: "Luke", Last: "Skywalker"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := e.Value(); !reflect.DeepEqual(v, map[interface{}]interface{}{
+		"first":        "Luke",
+		"last":         "Skywalker",
+		"display_name": "Luke Skywalker",
+		"initials":     "LS",
+	}) {
+		t.Errorf("bad value: %#v", v)
+	}
+
+	e = NewValueEmitter()
+
+	if err := NewEncoder(e).Encode(&virtualUser{First: "Luke"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := e.Value().(map[interface{}]interface{}); len(v) != 3 {
+		t.Errorf("the omitempty option of method fields was ignored: %#v", v)
+	}
+
+	var u virtualUser
+	d := NewDecoder(NewValueParser(map[string]interface{}{"first": "Han", "display_name": "?"}))
+
+	if err := d.Decode(&u); err != nil {
+		t.Fatal(err)
+	}
+
+	if u != (virtualUser{First: "Han"}) {
+		t.Errorf("bad decoded value: %#v", u)
+	}
+}
+
+func TestInstallMethodFieldPanics(t *testing.T) {
+	tests := []struct {
+		typ    reflect.Type
+		method string
+	}{
+		{reflect.TypeOf(0), "String"},
+		{reflect.TypeOf(virtualUser{}), "Missing"},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("no panic raised when installing %s.%s", test.typ, test.method)
+				}
+			}()
+			InstallMethodField(test.typ, "x", test.method)
+		}()
+	}
+}
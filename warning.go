@@ -0,0 +1,71 @@
+package objconv
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// WarningKind is an enumeration of the kinds of warnings that decoders report.
+type WarningKind int
+
+const (
+	// UnknownField is reported when the input has a field that doesn't exist
+	// in the destination struct, its value is discarded.
+	UnknownField WarningKind = iota + 1
+
+	// UnusedField is reported for the fields of a destination struct that were
+	// absent from the input, they keep their previous value.
+	UnusedField
+
+	// LossyConversion is reported when a number was decoded into a type that
+	// cannot represent it exactly, for example a float64 into a float32.
+	LossyConversion
+)
+
+// String returns a human-readable representation of k.
+func (k WarningKind) String() string {
+	switch k {
+	case UnknownField:
+		return "unknown field"
+	case UnusedField:
+		return "unused field"
+	case LossyConversion:
+		return "lossy conversion"
+	default:
+		return "<unknown warning>"
+	}
+}
+
+// Warning describes a suspicious situation that a decoder ran into, which is
+// not an error but may indicate that the program and the producer of the input
+// disagree on the schema of the payloads.
+type Warning struct {
+	Kind  WarningKind  // kind of the warning
+	Type  reflect.Type // type of the destination value
+	Field string       // name of the field, empty for lossy conversions outside of structs
+	Value interface{}  // value that was decoded, only set for lossy conversions
+}
+
+// String returns a human-readable representation of w.
+func (w Warning) String() string {
+	switch w.Kind {
+	case UnknownField:
+		return fmt.Sprintf("objconv: %s: the input has a field named %q which doesn't exist in %s", w.Kind, w.Field, w.Type)
+	case UnusedField:
+		return fmt.Sprintf("objconv: %s: field %q of %s was absent from the input", w.Kind, w.Field, w.Type)
+	case LossyConversion:
+		if len(w.Field) != 0 {
+			return fmt.Sprintf("objconv: %s: %v cannot be represented exactly as %s in field %q", w.Kind, w.Value, w.Type, w.Field)
+		}
+		return fmt.Sprintf("objconv: %s: %v cannot be represented exactly as %s", w.Kind, w.Value, w.Type)
+	default:
+		return fmt.Sprintf("objconv: %s", w.Kind)
+	}
+}
+
+func (d Decoder) warn(w Warning) {
+	if len(w.Field) == 0 {
+		w.Field = d.field
+	}
+	d.Warn(w)
+}
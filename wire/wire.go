@@ -0,0 +1,121 @@
+// Package wire exposes the low-level primitives of binary formats, varints,
+// zigzag encoding of signed integers and length-prefixed byte sequences, for
+// the authors of codecs built on the objconv Emitter and Parser interfaces.
+//
+// Varints are encoded like the varints of protocol buffers and of the
+// encoding/binary package: 7 bits per byte, least significant group first,
+// with the high bit of each byte set when more bytes follow.
+//
+// The Append functions append the encoding of a value to a byte slice and
+// return the extended slice. The Consume functions decode a value from the
+// beginning of a byte slice and return it with the number of bytes it used,
+// or an error matching objconv.ErrSyntax when the input is truncated and
+// objconv.ErrRange when a varint overflows 64 bits.
+package wire
+
+import (
+	"encoding/binary"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+// MaxVarintLen is the maximum number of bytes of a varint.
+const MaxVarintLen = binary.MaxVarintLen64
+
+// AppendUvarint appends the varint encoding of v to b.
+func AppendUvarint(b []byte, v uint64) []byte {
+	return binary.AppendUvarint(b, v)
+}
+
+// ConsumeUvarint decodes the varint at the beginning of b.
+func ConsumeUvarint(b []byte) (uint64, int, error) {
+	v, n := binary.Uvarint(b)
+
+	switch {
+	case n == 0:
+		return 0, 0, objutil.Errorf(objutil.ErrSyntax, "objconv/wire: truncated varint")
+	case n < 0:
+		return 0, 0, objutil.Errorf(objutil.ErrRange, "objconv/wire: varint overflows 64 bits")
+	}
+
+	return v, n, nil
+}
+
+// SizeUvarint returns the number of bytes of the varint encoding of v.
+func SizeUvarint(v uint64) int {
+	n := 1
+
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+
+	return n
+}
+
+// AppendVarint appends the varint encoding of the zigzag representation of v
+// to b, which keeps small negative numbers short.
+func AppendVarint(b []byte, v int64) []byte {
+	return AppendUvarint(b, EncodeZigZag(v))
+}
+
+// ConsumeVarint decodes the zigzag varint at the beginning of b.
+func ConsumeVarint(b []byte) (int64, int, error) {
+	u, n, err := ConsumeUvarint(b)
+	return DecodeZigZag(u), n, err
+}
+
+// SizeVarint returns the number of bytes of the zigzag varint encoding of v.
+func SizeVarint(v int64) int {
+	return SizeUvarint(EncodeZigZag(v))
+}
+
+// EncodeZigZag maps signed integers to unsigned integers so that numbers of
+// small magnitudes have small representations: 0, -1, 1, -2, 2 ... become 0,
+// 1, 2, 3, 4 ...
+func EncodeZigZag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+// DecodeZigZag is the inverse of EncodeZigZag.
+func DecodeZigZag(u uint64) int64 {
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+// AppendBytes appends v to b, prefixed with its length encoded as a varint.
+func AppendBytes(b []byte, v []byte) []byte {
+	return append(AppendUvarint(b, uint64(len(v))), v...)
+}
+
+// ConsumeBytes decodes the length-prefixed byte sequence at the beginning of
+// b. The returned slice references the memory of b.
+func ConsumeBytes(b []byte) ([]byte, int, error) {
+	size, n, err := ConsumeUvarint(b)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if size > uint64(len(b)-n) {
+		return nil, 0, objutil.Errorf(objutil.ErrSyntax, "objconv/wire: truncated byte sequence, expected %d bytes but only %d remain", size, len(b)-n)
+	}
+
+	end := n + int(size)
+	return b[n:end:end], end, nil
+}
+
+// AppendString appends v to b, prefixed with its length encoded as a varint.
+func AppendString(b []byte, v string) []byte {
+	return append(AppendUvarint(b, uint64(len(v))), v...)
+}
+
+// ConsumeString decodes the length-prefixed string at the beginning of b.
+func ConsumeString(b []byte) (string, int, error) {
+	v, n, err := ConsumeBytes(b)
+	return string(v), n, err
+}
+
+// SizeBytes returns the number of bytes of the length-prefixed encoding of a
+// byte sequence of length n.
+func SizeBytes(n int) int {
+	return SizeUvarint(uint64(n)) + n
+}
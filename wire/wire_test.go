@@ -0,0 +1,104 @@
+package wire
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/segmentio/objconv/objutil"
+)
+
+func TestUvarint(t *testing.T) {
+	for _, v := range []uint64{0, 1, 127, 128, 300, math.MaxUint32, math.MaxUint64} {
+		b := AppendUvarint([]byte{0xff}, v)
+
+		if len(b) != 1+SizeUvarint(v) {
+			t.Errorf("%d: bad size: %d != %d", v, len(b)-1, SizeUvarint(v))
+		}
+
+		x, n, err := ConsumeUvarint(b[1:])
+
+		if err != nil || x != v || n != len(b)-1 {
+			t.Errorf("%d: bad varint: %d (%d bytes, %v)", v, x, n, err)
+		}
+	}
+}
+
+func TestVarint(t *testing.T) {
+	for _, v := range []int64{0, -1, 1, -64, 64, math.MinInt64, math.MaxInt64} {
+		b := AppendVarint(nil, v)
+
+		if len(b) != SizeVarint(v) {
+			t.Errorf("%d: bad size: %d != %d", v, len(b), SizeVarint(v))
+		}
+
+		if x, n, err := ConsumeVarint(b); err != nil || x != v || n != len(b) {
+			t.Errorf("%d: bad varint: %d (%d bytes, %v)", v, x, n, err)
+		}
+	}
+
+	if b := AppendVarint(nil, -1); len(b) != 1 || b[0] != 1 {
+		t.Errorf("bad zigzag encoding of -1: %v", b)
+	}
+}
+
+func TestZigZag(t *testing.T) {
+	tests := []struct {
+		v int64
+		u uint64
+	}{
+		{0, 0}, {-1, 1}, {1, 2}, {-2, 3}, {2, 4},
+		{math.MaxInt64, math.MaxUint64 - 1},
+		{math.MinInt64, math.MaxUint64},
+	}
+
+	for _, test := range tests {
+		if u := EncodeZigZag(test.v); u != test.u {
+			t.Errorf("EncodeZigZag(%d) = %d, expected %d", test.v, u, test.u)
+		}
+		if v := DecodeZigZag(test.u); v != test.v {
+			t.Errorf("DecodeZigZag(%d) = %d, expected %d", test.u, v, test.v)
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	b := AppendString(AppendBytes(nil, []byte("hello")), "world!")
+
+	if len(b) != SizeBytes(5)+SizeBytes(6) {
+		t.Errorf("bad size: %d", len(b))
+	}
+
+	v, n, err := ConsumeBytes(b)
+
+	if err != nil || string(v) != "hello" || n != 6 {
+		t.Fatalf("bad bytes: %q (%d bytes, %v)", v, n, err)
+	}
+
+	s, m, err := ConsumeString(b[n:])
+
+	if err != nil || s != "world!" || n+m != len(b) {
+		t.Fatalf("bad string: %q (%d bytes, %v)", s, m, err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		b    []byte
+		kind error
+	}{
+		{nil, objutil.ErrSyntax},
+		{[]byte{0x80, 0x80}, objutil.ErrSyntax},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}, objutil.ErrRange},
+	}
+
+	for _, test := range tests {
+		if _, _, err := ConsumeUvarint(test.b); !errors.Is(err, test.kind) {
+			t.Errorf("%v: bad error: %v", test.b, err)
+		}
+	}
+
+	if _, _, err := ConsumeBytes([]byte{3, 'a', 'b'}); !errors.Is(err, objutil.ErrSyntax) {
+		t.Errorf("bad error: %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package wkt
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Struct mirrors google.protobuf.Struct, it is encoded as a map.
+//
+// Protobuf represents all numbers in structs as doubles, numbers are decoded as
+// float64 values.
+type Struct map[string]interface{}
+
+// ListValue mirrors google.protobuf.ListValue, it is encoded as an array.
+//
+// Like Struct, numbers in lists are decoded as float64 values.
+type ListValue []interface{}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (s *Struct) DecodeValue(d objconv.Decoder) error {
+	var m map[string]interface{}
+
+	if err := d.Decode(&m); err != nil {
+		return err
+	}
+
+	for k, v := range m {
+		m[k] = normalize(v)
+	}
+
+	*s = m
+	return nil
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (l *ListValue) DecodeValue(d objconv.Decoder) error {
+	var a []interface{}
+
+	if err := d.Decode(&a); err != nil {
+		return err
+	}
+
+	for i, v := range a {
+		a[i] = normalize(v)
+	}
+
+	*l = a
+	return nil
+}
+
+// normalize converts v to a value that google.protobuf.Value can represent.
+func normalize(v interface{}) interface{} {
+	switch x := v.(type) {
+	case int64:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case objconv.Number:
+		if f, err := x.Float64(); err == nil {
+			return f
+		}
+	case map[string]interface{}:
+		for k, v := range x {
+			x[k] = normalize(v)
+		}
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, v := range x {
+			m[fmt.Sprint(k)] = normalize(v)
+		}
+		return m
+	case []interface{}:
+		for i, v := range x {
+			x[i] = normalize(v)
+		}
+	}
+	return v
+}
+
+// Any mirrors google.protobuf.Any, it holds a value and the URL identifying its
+// type.
+//
+// Following the proto3 JSON mapping, the fields of the value are encoded in the
+// same map as the "@type" key. Values of well-known types, or values that are
+// not encoded as maps, are encoded under a "value" key instead.
+//
+// When decoding, the type URL is looked up in the types registered with
+// RegisterType to decode the value into its original type, values of unknown
+// types are decoded as maps.
+type Any struct {
+	TypeURL string
+	Value   interface{}
+}
+
+// RegisterType associates url with typ, so values of Any with this type URL
+// are decoded into values of typ.
+//
+// The types of this package are registered as their protobuf message names
+// prefixed with "type.googleapis.com/", like
+// "type.googleapis.com/google.protobuf.Timestamp".
+func RegisterType(url string, typ reflect.Type) {
+	typeMutex.Lock()
+	typeStore[url] = typ
+	typeMutex.Unlock()
+}
+
+func lookupType(url string) (typ reflect.Type, ok bool) {
+	typeMutex.RLock()
+	typ, ok = typeStore[url]
+	typeMutex.RUnlock()
+	return
+}
+
+var (
+	typeMutex sync.RWMutex
+	typeStore = make(map[string]reflect.Type)
+)
+
+// wellKnown is the set of types of this package which are encoded under the
+// "value" key of Any.
+var wellKnown = map[reflect.Type]string{}
+
+func init() {
+	for name, v := range map[string]interface{}{
+		"Timestamp":   Timestamp{},
+		"Duration":    Duration{},
+		"Struct":      Struct{},
+		"ListValue":   ListValue{},
+		"BoolValue":   BoolValue{},
+		"Int32Value":  Int32Value{},
+		"Int64Value":  Int64Value{},
+		"UInt32Value": UInt32Value{},
+		"UInt64Value": UInt64Value{},
+		"FloatValue":  FloatValue{},
+		"DoubleValue": DoubleValue{},
+		"StringValue": StringValue{},
+		"BytesValue":  BytesValue{},
+	} {
+		t := reflect.TypeOf(v)
+		wellKnown[t] = name
+		RegisterType("type.googleapis.com/google.protobuf."+name, t)
+	}
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (a Any) EncodeValue(e objconv.Encoder) error {
+	if len(a.TypeURL) == 0 {
+		return objutil.Errorf(objutil.ErrType, "objconv/wkt: the type URL of Any values cannot be empty")
+	}
+
+	var fields map[interface{}]interface{}
+
+	if a.Value != nil && wellKnown[reflect.TypeOf(a.Value)] == "" {
+		// The value is encoded with an emitter that has the same text property
+		// as e so types that depend on it produce the right representation.
+		v := &valueEmitter{ValueEmitter: objconv.NewValueEmitter(), text: isTextEmitter(e.Emitter)}
+
+		if err := (objconv.Encoder{Emitter: v, SortMapKeys: e.SortMapKeys}).Encode(a.Value); err != nil {
+			return err
+		}
+
+		fields, _ = v.Value().(map[interface{}]interface{})
+	}
+
+	if fields == nil {
+		return e.Encode(anyRecord{Type: a.TypeURL, Value: a.Value})
+	}
+
+	keys := make([]string, 0, len(fields))
+
+	for k := range fields {
+		if s := fmt.Sprint(k); s != "@type" {
+			keys = append(keys, s)
+		}
+	}
+
+	sort.Strings(keys)
+	i := -1
+
+	return e.EncodeMap(len(keys)+1, func(ke objconv.Encoder, ve objconv.Encoder) error {
+		if i < 0 {
+			i++
+			if err := ke.Encode("@type"); err != nil {
+				return err
+			}
+			return ve.Encode(a.TypeURL)
+		}
+		k := keys[i]
+		i++
+		if err := ke.Encode(k); err != nil {
+			return err
+		}
+		return ve.Encode(fields[k])
+	})
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (a *Any) DecodeValue(d objconv.Decoder) error {
+	var m map[string]interface{}
+
+	if err := d.Decode(&m); err != nil {
+		return err
+	}
+
+	url, _ := m["@type"].(string)
+
+	if len(url) == 0 {
+		return objutil.Errorf(objutil.ErrType, "objconv/wkt: Any value has no \"@type\" key")
+	}
+
+	delete(m, "@type")
+	var v interface{} = m
+
+	if typ, ok := lookupType(url); ok {
+		if wellKnown[typ] != "" {
+			v = m["value"]
+		}
+
+		p := reflect.New(typ)
+
+		if err := objconv.NewDecoder(objconv.NewValueParser(v)).Decode(p.Interface()); err != nil {
+			return err
+		}
+
+		v = p.Elem().Interface()
+	}
+
+	*a = Any{TypeURL: url, Value: v}
+	return nil
+}
+
+type anyRecord struct {
+	Type  string      `objconv:"@type"`
+	Value interface{} `objconv:"value"`
+}
+
+type valueEmitter struct {
+	*objconv.ValueEmitter
+	text bool
+}
+
+func (e *valueEmitter) TextEmitter() bool { return e.text }
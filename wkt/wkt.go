@@ -0,0 +1,520 @@
+// Package wkt provides the protobuf well-known types, encoded according to the
+// proto3 JSON mapping so payloads interoperate with services using protojson.
+//
+// The mapping only applies to text formats like JSON, where 64 bits integers
+// are written as strings, timestamps as RFC 3339 strings, and durations as
+// strings of seconds with a "s" suffix. Binary formats use the native
+// representations of the codecs (integers, times and durations). Decoders
+// accept both representations.
+//
+// The types don't depend on the protobuf runtime, they mirror the fields of the
+// messages defined in google/protobuf/*.proto.
+package wkt
+
+import (
+	"encoding/base64"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+const (
+	minTimestamp = -62135596800 // 0001-01-01T00:00:00Z
+	maxTimestamp = 253402300799 // 9999-12-31T23:59:59Z
+	maxDuration  = 315576000000 // 10000 years
+)
+
+// Timestamp mirrors google.protobuf.Timestamp.
+type Timestamp struct {
+	Seconds int64
+	Nanos   int32
+}
+
+// NewTimestamp returns the timestamp of t.
+func NewTimestamp(t time.Time) Timestamp {
+	return Timestamp{Seconds: t.Unix(), Nanos: int32(t.Nanosecond())}
+}
+
+// AsTime returns the time of ts, in UTC.
+func (ts Timestamp) AsTime() time.Time {
+	return time.Unix(ts.Seconds, int64(ts.Nanos)).UTC()
+}
+
+// Validate returns an error if ts is out of the range of timestamps supported
+// by the proto3 JSON mapping.
+func (ts Timestamp) Validate() error {
+	if ts.Seconds < minTimestamp || ts.Seconds > maxTimestamp || ts.Nanos < 0 || ts.Nanos >= 1e9 {
+		return objutil.Errorf(objutil.ErrRange, "objconv/wkt: timestamp out of range: %ds %dns", ts.Seconds, ts.Nanos)
+	}
+	return nil
+}
+
+// String returns the RFC 3339 representation of ts, with 0, 3, 6, or 9
+// fractional digits as required by the proto3 JSON mapping.
+func (ts Timestamp) String() string {
+	s := ts.AsTime().Format("2006-01-02T15:04:05")
+
+	if ts.Nanos != 0 {
+		s += "." + fractional(ts.Nanos)
+	}
+
+	return s + "Z"
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (ts Timestamp) EncodeValue(e objconv.Encoder) error {
+	if err := ts.Validate(); err != nil {
+		return err
+	}
+	if isTextEmitter(e.Emitter) {
+		return e.Encode(ts.String())
+	}
+	return e.Encode(ts.AsTime())
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (ts *Timestamp) DecodeValue(d objconv.Decoder) error {
+	var t time.Time
+
+	if err := d.Decode(&t); err != nil {
+		return err
+	}
+
+	x := NewTimestamp(t)
+
+	if err := x.Validate(); err != nil {
+		return err
+	}
+
+	*ts = x
+	return nil
+}
+
+// Duration mirrors google.protobuf.Duration.
+type Duration struct {
+	Seconds int64
+	Nanos   int32
+}
+
+// NewDuration returns the protobuf duration of d.
+func NewDuration(d time.Duration) Duration {
+	return Duration{Seconds: int64(d / time.Second), Nanos: int32(d % time.Second)}
+}
+
+// AsDuration returns the value of d as a time.Duration, saturating at the
+// limits of the type.
+func (d Duration) AsDuration() time.Duration {
+	const max = int64(math.MaxInt64 / time.Second)
+
+	switch {
+	case d.Seconds > max:
+		return math.MaxInt64
+	case d.Seconds < -max:
+		return math.MinInt64
+	}
+
+	return time.Duration(d.Seconds)*time.Second + time.Duration(d.Nanos)
+}
+
+// Validate returns an error if d is out of the range of durations supported by
+// the proto3 JSON mapping, or if its seconds and nanoseconds have different
+// signs.
+func (d Duration) Validate() error {
+	switch {
+	case d.Seconds < -maxDuration || d.Seconds > maxDuration, d.Nanos <= -1e9 || d.Nanos >= 1e9:
+		return objutil.Errorf(objutil.ErrRange, "objconv/wkt: duration out of range: %ds %dns", d.Seconds, d.Nanos)
+	case (d.Seconds < 0 && d.Nanos > 0) || (d.Seconds > 0 && d.Nanos < 0):
+		return objutil.Errorf(objutil.ErrRange, "objconv/wkt: duration has seconds and nanoseconds of different signs: %ds %dns", d.Seconds, d.Nanos)
+	}
+	return nil
+}
+
+// String returns the proto3 JSON representation of d, like "1.5s".
+func (d Duration) String() string {
+	s, n := d.Seconds, d.Nanos
+	sign := ""
+
+	if s < 0 || n < 0 {
+		s, n, sign = -s, -n, "-"
+	}
+
+	str := sign + strconv.FormatInt(s, 10)
+
+	if n != 0 {
+		str += "." + fractional(n)
+	}
+
+	return str + "s"
+}
+
+// ParseDuration parses s in the proto3 JSON representation of durations.
+func ParseDuration(s string) (Duration, error) {
+	if !strings.HasSuffix(s, "s") {
+		return Duration{}, objutil.Errorf(objutil.ErrSyntax, "objconv/wkt: invalid duration: %q", s)
+	}
+
+	num := s[:len(s)-1]
+	neg := strings.HasPrefix(num, "-")
+	num = strings.TrimPrefix(num, "-")
+	sec, frac := num, ""
+
+	if i := strings.IndexByte(num, '.'); i >= 0 {
+		sec, frac = num[:i], num[i+1:]
+	}
+
+	if len(sec) == 0 || len(frac) > 9 || !isDigits(sec) || !isDigits(frac) {
+		return Duration{}, objutil.Errorf(objutil.ErrSyntax, "objconv/wkt: invalid duration: %q", s)
+	}
+
+	seconds, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return Duration{}, objutil.Errorf(objutil.ErrRange, "objconv/wkt: duration out of range: %q", s)
+	}
+
+	nanos, _ := strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 32)
+
+	if neg {
+		seconds, nanos = -seconds, -nanos
+	}
+
+	d := Duration{Seconds: seconds, Nanos: int32(nanos)}
+	return d, d.Validate()
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (d Duration) EncodeValue(e objconv.Encoder) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	if isTextEmitter(e.Emitter) {
+		return e.Encode(d.String())
+	}
+	return e.Encode(d.AsDuration())
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (d *Duration) DecodeValue(dec objconv.Decoder) error {
+	var v interface{}
+
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+
+	switch x := v.(type) {
+	case string:
+		r, err := ParseDuration(x)
+		if err != nil {
+			return err
+		}
+		*d = r
+	case time.Duration:
+		*d = NewDuration(x)
+	case int64: // nanoseconds, like time.Duration
+		*d = NewDuration(time.Duration(x))
+	case objconv.Number:
+		n, err := x.Int64()
+		if err != nil {
+			return err
+		}
+		*d = NewDuration(time.Duration(n))
+	default:
+		return objutil.Errorf(objutil.ErrType, "objconv/wkt: cannot decode a duration from a value of type %T", v)
+	}
+
+	return nil
+}
+
+// The wrapper types mirror the messages of google/protobuf/wrappers.proto, they
+// are encoded as the values they wrap. Pointers to wrappers are used to
+// represent nullable fields, nil pointers are encoded as null.
+type (
+	BoolValue   struct{ Value bool }
+	Int32Value  struct{ Value int32 }
+	Int64Value  struct{ Value int64 }
+	UInt32Value struct{ Value uint32 }
+	UInt64Value struct{ Value uint64 }
+	FloatValue  struct{ Value float32 }
+	DoubleValue struct{ Value float64 }
+	StringValue struct{ Value string }
+	BytesValue  struct{ Value []byte }
+)
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (w BoolValue) EncodeValue(e objconv.Encoder) error { return e.Encode(w.Value) }
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (w Int32Value) EncodeValue(e objconv.Encoder) error { return e.Encode(w.Value) }
+
+// EncodeValue satisfies the objconv.ValueEncoder interface, 64 bits integers
+// are encoded as strings in text formats.
+func (w Int64Value) EncodeValue(e objconv.Encoder) error {
+	if isTextEmitter(e.Emitter) {
+		return e.Encode(strconv.FormatInt(w.Value, 10))
+	}
+	return e.Encode(w.Value)
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (w UInt32Value) EncodeValue(e objconv.Encoder) error { return e.Encode(w.Value) }
+
+// EncodeValue satisfies the objconv.ValueEncoder interface, 64 bits integers
+// are encoded as strings in text formats.
+func (w UInt64Value) EncodeValue(e objconv.Encoder) error {
+	if isTextEmitter(e.Emitter) {
+		return e.Encode(strconv.FormatUint(w.Value, 10))
+	}
+	return e.Encode(w.Value)
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface, non-finite numbers
+// are encoded as "NaN", "Infinity" or "-Infinity" in text formats.
+func (w FloatValue) EncodeValue(e objconv.Encoder) error {
+	return encodeFloat(e, float64(w.Value))
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface, non-finite numbers
+// are encoded as "NaN", "Infinity" or "-Infinity" in text formats.
+func (w DoubleValue) EncodeValue(e objconv.Encoder) error {
+	return encodeFloat(e, w.Value)
+}
+
+// EncodeValue satisfies the objconv.ValueEncoder interface.
+func (w StringValue) EncodeValue(e objconv.Encoder) error { return e.Encode(w.Value) }
+
+// EncodeValue satisfies the objconv.ValueEncoder interface, bytes are encoded
+// as base64 strings in text formats.
+func (w BytesValue) EncodeValue(e objconv.Encoder) error {
+	if isTextEmitter(e.Emitter) {
+		return e.Encode(base64.StdEncoding.EncodeToString(w.Value))
+	}
+	return e.Encode(w.Value)
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (w *BoolValue) DecodeValue(d objconv.Decoder) error { return d.Decode(&w.Value) }
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (w *Int32Value) DecodeValue(d objconv.Decoder) error {
+	n, err := decodeInt(d, 32)
+	w.Value = int32(n)
+	return err
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface, the value may be
+// a number or a string.
+func (w *Int64Value) DecodeValue(d objconv.Decoder) (err error) {
+	w.Value, err = decodeInt(d, 64)
+	return
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (w *UInt32Value) DecodeValue(d objconv.Decoder) error {
+	n, err := decodeUint(d, 32)
+	w.Value = uint32(n)
+	return err
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface, the value may be
+// a number or a string.
+func (w *UInt64Value) DecodeValue(d objconv.Decoder) (err error) {
+	w.Value, err = decodeUint(d, 64)
+	return
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (w *FloatValue) DecodeValue(d objconv.Decoder) error {
+	f, err := decodeFloat(d, 32)
+	w.Value = float32(f)
+	return err
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (w *DoubleValue) DecodeValue(d objconv.Decoder) (err error) {
+	w.Value, err = decodeFloat(d, 64)
+	return
+}
+
+// DecodeValue satisfies the objconv.ValueDecoder interface.
+func (w *StringValue) DecodeValue(d objconv.Decoder) error { return d.Decode(&w.Value) }
+
+// DecodeValue satisfies the objconv.ValueDecoder interface, the value may be
+// a base64 string or a byte sequence.
+func (w *BytesValue) DecodeValue(d objconv.Decoder) error {
+	var v interface{}
+
+	if err := d.Decode(&v); err != nil {
+		return err
+	}
+
+	switch x := v.(type) {
+	case []byte:
+		w.Value = x
+	case string:
+		b, err := base64.StdEncoding.DecodeString(x)
+		if err != nil {
+			if b, err = base64.URLEncoding.DecodeString(x); err != nil {
+				return objutil.Errorf(objutil.ErrSyntax, "objconv/wkt: invalid base64 string: %q", x)
+			}
+		}
+		w.Value = b
+	default:
+		return objutil.Errorf(objutil.ErrType, "objconv/wkt: cannot decode bytes from a value of type %T", v)
+	}
+
+	return nil
+}
+
+func encodeFloat(e objconv.Encoder, f float64) error {
+	if isTextEmitter(e.Emitter) {
+		switch {
+		case math.IsNaN(f):
+			return e.Encode("NaN")
+		case math.IsInf(f, 1):
+			return e.Encode("Infinity")
+		case math.IsInf(f, -1):
+			return e.Encode("-Infinity")
+		}
+	}
+	return e.Encode(f)
+}
+
+func decodeInt(d objconv.Decoder, bits int) (int64, error) {
+	var v interface{}
+
+	if err := d.Decode(&v); err != nil {
+		return 0, err
+	}
+
+	var n int64
+	var err error
+
+	switch x := v.(type) {
+	case int64:
+		n = x
+	case uint64:
+		if x > math.MaxInt64 {
+			err = strconv.ErrRange
+		}
+		n = int64(x)
+	case objconv.Number:
+		n, err = x.Int64()
+	case string:
+		n, err = strconv.ParseInt(x, 10, 64)
+	default:
+		return 0, objutil.Errorf(objutil.ErrType, "objconv/wkt: cannot decode an integer from a value of type %T", v)
+	}
+
+	if err == nil && bits == 32 && (n < math.MinInt32 || n > math.MaxInt32) {
+		err = strconv.ErrRange
+	}
+
+	if err != nil {
+		return 0, objutil.Errorf(objutil.ErrRange, "objconv/wkt: invalid %d bits integer: %v", bits, v)
+	}
+
+	return n, nil
+}
+
+func decodeUint(d objconv.Decoder, bits int) (uint64, error) {
+	var v interface{}
+
+	if err := d.Decode(&v); err != nil {
+		return 0, err
+	}
+
+	var n uint64
+	var err error
+
+	switch x := v.(type) {
+	case int64:
+		if x < 0 {
+			err = strconv.ErrRange
+		}
+		n = uint64(x)
+	case uint64:
+		n = x
+	case objconv.Number:
+		n, err = x.Uint64()
+	case string:
+		n, err = strconv.ParseUint(x, 10, 64)
+	default:
+		return 0, objutil.Errorf(objutil.ErrType, "objconv/wkt: cannot decode an integer from a value of type %T", v)
+	}
+
+	if err == nil && bits == 32 && n > math.MaxUint32 {
+		err = strconv.ErrRange
+	}
+
+	if err != nil {
+		return 0, objutil.Errorf(objutil.ErrRange, "objconv/wkt: invalid %d bits unsigned integer: %v", bits, v)
+	}
+
+	return n, nil
+}
+
+func decodeFloat(d objconv.Decoder, bits int) (float64, error) {
+	var v interface{}
+
+	if err := d.Decode(&v); err != nil {
+		return 0, err
+	}
+
+	switch x := v.(type) {
+	case float64:
+		return x, nil
+	case int64:
+		return float64(x), nil
+	case uint64:
+		return float64(x), nil
+	case objconv.Number:
+		return x.Float64()
+	case string:
+		switch x {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		f, err := strconv.ParseFloat(x, bits)
+		if err != nil {
+			return 0, objutil.Errorf(objutil.ErrSyntax, "objconv/wkt: invalid number: %q", x)
+		}
+		return f, nil
+	}
+
+	return 0, objutil.Errorf(objutil.ErrType, "objconv/wkt: cannot decode a number from a value of type %T", v)
+}
+
+// fractional formats n nanoseconds with 3, 6, or 9 digits.
+func fractional(n int32) string {
+	switch {
+	case n%1e6 == 0:
+		return strconv.FormatInt(int64(n/1e6)+1e3, 10)[1:]
+	case n%1e3 == 0:
+		return strconv.FormatInt(int64(n/1e3)+1e6, 10)[1:]
+	default:
+		return strconv.FormatInt(int64(n)+1e9, 10)[1:]
+	}
+}
+
+func isDigits(s string) bool {
+	for i := range s {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isTextEmitter(emitter objconv.Emitter) bool {
+	e, _ := emitter.(interface {
+		TextEmitter() bool
+	})
+	return e != nil && e.TextEmitter()
+}
@@ -0,0 +1,154 @@
+package wkt
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/json"
+	"github.com/segmentio/objconv/msgpack"
+)
+
+type message struct {
+	Name  string `objconv:"name"`
+	Count int64  `objconv:"count"`
+}
+
+func init() {
+	RegisterType("type.googleapis.com/test.Message", reflect.TypeOf(message{}))
+}
+
+func TestJSONMapping(t *testing.T) {
+	tests := []struct {
+		in  interface{}
+		out string
+	}{
+		{NewTimestamp(time.Date(1972, 1, 1, 10, 0, 20, 21e6, time.UTC)), `"1972-01-01T10:00:20.021Z"`},
+		{Timestamp{Seconds: 0}, `"1970-01-01T00:00:00Z"`},
+		{Duration{Seconds: 1, Nanos: 340012}, `"1.000340012s"`},
+		{Duration{Seconds: -1, Nanos: -500e6}, `"-1.500s"`},
+		{Duration{Seconds: 3}, `"3s"`},
+		{Int64Value{Value: 1 << 60}, `"1152921504606846976"`},
+		{UInt64Value{Value: 42}, `"42"`},
+		{Int32Value{Value: -1}, `-1`},
+		{BoolValue{Value: true}, `true`},
+		{StringValue{Value: "A"}, `"A"`},
+		{BytesValue{Value: []byte("hi")}, `"aGk="`},
+		{DoubleValue{Value: 1.5}, `1.5`},
+		{Struct{"a": 1.0}, `{"a":1}`},
+		{Struct{"b": []interface{}{"x", true}}, `{"b":["x",true]}`},
+		{Any{TypeURL: "type.googleapis.com/test.Message", Value: message{Name: "A", Count: 2}}, `{"@type":"type.googleapis.com/test.Message","count":2,"name":"A"}`},
+		{Any{TypeURL: "type.googleapis.com/google.protobuf.Duration", Value: Duration{Seconds: 1}}, `{"@type":"type.googleapis.com/google.protobuf.Duration","value":"1s"}`},
+	}
+
+	for _, test := range tests {
+		b, err := json.Marshal(test.in)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if s := string(b); s != test.out {
+			t.Errorf("bad JSON: %s != %s", s, test.out)
+		}
+
+		for _, codec := range []struct {
+			marshal   func(interface{}) ([]byte, error)
+			unmarshal func([]byte, interface{}) error
+		}{
+			{json.Marshal, json.Unmarshal},
+			{msgpack.Marshal, msgpack.Unmarshal},
+		} {
+			b, err := codec.marshal(test.in)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+
+			v := reflect.New(reflect.TypeOf(test.in))
+
+			if err := codec.unmarshal(b, v.Interface()); err != nil {
+				t.Errorf("%s: %s", b, err)
+				continue
+			}
+
+			if !reflect.DeepEqual(v.Elem().Interface(), test.in) {
+				t.Errorf("%#v != %#v", v.Elem().Interface(), test.in)
+			}
+		}
+	}
+}
+
+func TestNonFiniteNumbers(t *testing.T) {
+	b, _ := json.Marshal([]DoubleValue{{math.Inf(1)}, {math.Inf(-1)}})
+
+	if s := string(b); s != `["Infinity","-Infinity"]` {
+		t.Error("bad JSON:", s)
+	}
+
+	var v DoubleValue
+	if err := json.Unmarshal([]byte(`"NaN"`), &v); err != nil || !math.IsNaN(v.Value) {
+		t.Errorf("bad value: %v (%v)", v.Value, err)
+	}
+}
+
+func TestInvalidValues(t *testing.T) {
+	for _, v := range []interface{}{
+		Timestamp{Seconds: maxTimestamp + 1},
+		Duration{Seconds: 1, Nanos: -1},
+		Any{Value: 1},
+	} {
+		if _, err := json.Marshal(v); err == nil {
+			t.Errorf("no error returned when encoding %#v", v)
+		}
+	}
+
+	for _, s := range []string{"1", "1.5", "s", "1.1234567890s", "1.-5s"} {
+		if _, err := ParseDuration(s); err == nil {
+			t.Errorf("no error returned when parsing %q", s)
+		}
+	}
+}
+
+func TestUseNumber(t *testing.T) {
+	tests := []struct {
+		in  string
+		out interface{}
+	}{
+		{`-1`, Int32Value{Value: -1}},
+		{`1152921504606846976`, Int64Value{Value: 1 << 60}},
+		{`42`, UInt32Value{Value: 42}},
+		{`18446744073709551615`, UInt64Value{Value: math.MaxUint64}},
+		{`1.5`, DoubleValue{Value: 1.5}},
+		{`2`, FloatValue{Value: 2}},
+		{`{"a":1}`, Struct{"a": 1.0}},
+		{`["x",2.5]`, ListValue{"x", 2.5}},
+	}
+
+	for _, test := range tests {
+		d := json.NewDecoder(strings.NewReader(test.in))
+		d.UseNumber = true
+
+		v := reflect.New(reflect.TypeOf(test.out))
+
+		if err := d.Decode(v.Interface()); err != nil {
+			t.Errorf("%s: %v", test.in, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(v.Elem().Interface(), test.out) {
+			t.Errorf("%s: %#v != %#v", test.in, v.Elem().Interface(), test.out)
+		}
+	}
+
+	d := json.NewDecoder(strings.NewReader(`1e40`))
+	d.UseNumber = true
+
+	if err := d.Decode(&Int64Value{}); !errors.Is(err, objconv.ErrRange) {
+		t.Errorf("bad error: %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package xml
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/segmentio/objconv"
+)
+
+// NewDecoder returns a new XML decoder that parses values from r.
+func NewDecoder(r io.Reader) *objconv.Decoder {
+	return objconv.NewDecoder(NewParser(r))
+}
+
+// Unmarshal decodes a XML representation of v from b, an error is returned
+// if b has data after the value.
+func Unmarshal(b []byte, v interface{}) error {
+	u := unmarshalerPool.Get().(*unmarshaler)
+	u.reset(b)
+
+	err := (objconv.Decoder{Parser: u, DisallowTrailingData: true}).Decode(v)
+
+	u.reset(nil)
+	unmarshalerPool.Put(u)
+	return err
+}
+
+var unmarshalerPool = sync.Pool{
+	New: func() interface{} { return newUnmarshaler() },
+}
+
+type unmarshaler struct {
+	Parser
+	b bytes.Buffer
+}
+
+func newUnmarshaler() *unmarshaler {
+	u := &unmarshaler{}
+	u.r = &u.b
+	return u
+}
+
+func (u *unmarshaler) reset(b []byte) {
+	u.b = *bytes.NewBuffer(b)
+	u.Reset(&u.b)
+}
@@ -0,0 +1,421 @@
+package xml
+
+import (
+	"encoding/base64"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Emitter implements an XML emitter that satisfies the objconv.Emitter
+// interface.
+//
+// The attributes of elements must be written before their content, so the
+// emitter builds each document in memory and writes it once the top-level
+// value is complete.
+type Emitter struct {
+	// Root is the name of the root elements of the documents written by the
+	// emitter, they are called "root" when it is empty.
+	Root string
+
+	w io.Writer
+	b []byte
+	// The stack is used to keep track of the container being built by the
+	// emitter, which may be an arrayEmitter or mapEmitter.
+	stack []emitter
+	// Layout of the output, elements are written on a single line when the
+	// indentation is empty.
+	layout layout
+}
+
+func NewEmitter(w io.Writer) *Emitter {
+	return &Emitter{w: w}
+}
+
+// NewFormatEmitter returns a new emitter that writes XML documents to w in the
+// layout configured by f.
+//
+// Child elements are written on their own lines, indented once per level of
+// nesting, except within the elements that hold character data, where white
+// spaces would change the content. Since XML has no separators, f.Space and
+// f.Width are not used.
+func NewFormatEmitter(w io.Writer, f objconv.Format) *Emitter {
+	return &Emitter{w: w, layout: layout{prefix: f.Prefix, indent: f.Indent}}
+}
+
+// FormatEmitter satisfies the objconv.FormatEmitter interface, the emitter
+// that it returns writes root elements with the same name as e.
+func (e *Emitter) FormatEmitter(f objconv.Format) objconv.Emitter {
+	x := NewFormatEmitter(e.w, f)
+	x.Root = e.Root
+	return x
+}
+
+func (e *Emitter) Reset(w io.Writer) {
+	e.w = w
+	e.b = e.b[:0]
+	e.stack = e.stack[:0]
+}
+
+func (e *Emitter) EmitNil() error {
+	return e.emit(nil)
+}
+
+func (e *Emitter) EmitBool(v bool) error {
+	return e.emit(strconv.FormatBool(v))
+}
+
+func (e *Emitter) EmitInt(v int64, _ int) error {
+	return e.emit(strconv.FormatInt(v, 10))
+}
+
+func (e *Emitter) EmitUint(v uint64, _ int) error {
+	return e.emit(strconv.FormatUint(v, 10))
+}
+
+func (e *Emitter) EmitFloat(v float64, bitSize int) error {
+	return e.emit(strconv.FormatFloat(v, 'g', -1, bitSize))
+}
+
+func (e *Emitter) EmitString(v string) error {
+	return e.emit(v)
+}
+
+func (e *Emitter) EmitBytes(v []byte) error {
+	return e.emit(base64.StdEncoding.EncodeToString(v))
+}
+
+func (e *Emitter) EmitTime(v time.Time) error {
+	return e.emit(v.Format(time.RFC3339Nano))
+}
+
+func (e *Emitter) EmitDuration(v time.Duration) error {
+	return e.emit(string(objutil.AppendDuration(nil, v)))
+}
+
+func (e *Emitter) EmitError(v error) error {
+	return e.emit(v.Error())
+}
+
+func (e *Emitter) EmitArrayBegin(_ int) (err error) {
+	e.push(&arrayEmitter{self: &array{}})
+	return
+}
+
+func (e *Emitter) EmitArrayEnd() (err error) {
+	return e.emit(e.pop().value())
+}
+
+func (e *Emitter) EmitArrayNext() (err error) {
+	return
+}
+
+func (e *Emitter) EmitMapBegin(_ int) (err error) {
+	e.push(&mapEmitter{self: &object{}})
+	return
+}
+
+func (e *Emitter) EmitMapEnd() (err error) {
+	return e.emit(e.pop().value())
+}
+
+func (e *Emitter) EmitMapValue() (err error) {
+	return
+}
+
+func (e *Emitter) EmitMapNext() (err error) {
+	return
+}
+
+func (e *Emitter) TextEmitter() bool {
+	return true
+}
+
+func (e *Emitter) emit(v interface{}) (err error) {
+	if n := len(e.stack); n != 0 {
+		return e.stack[n-1].emit(v)
+	}
+
+	root := e.Root
+	if len(root) == 0 {
+		root = defaultRoot
+	}
+
+	if e.b, err = e.layout.appendElement(e.b[:0], root, v, 0); err != nil {
+		return
+	}
+
+	_, err = e.w.Write(e.b)
+	return
+}
+
+func (e *Emitter) push(v emitter) {
+	e.stack = append(e.stack, v)
+}
+
+func (e *Emitter) pop() emitter {
+	i := len(e.stack) - 1
+	v := e.stack[i]
+	e.stack = e.stack[:i]
+	return v
+}
+
+type emitter interface {
+	emit(interface{}) error
+	value() interface{}
+}
+
+type arrayEmitter struct {
+	self *array
+}
+
+func (e *arrayEmitter) emit(v interface{}) error {
+	e.self.values = append(e.self.values, v)
+	return nil
+}
+
+func (e *arrayEmitter) value() interface{} {
+	return e.self
+}
+
+type mapEmitter struct {
+	self *object
+	key  string
+	val  bool
+}
+
+func (e *mapEmitter) emit(v interface{}) error {
+	if e.val {
+		e.self.keys = append(e.self.keys, e.key)
+		e.self.values = append(e.self.values, v)
+		e.val = false
+		return nil
+	}
+
+	k, ok := v.(string)
+	if !ok {
+		return objutil.Errorf(objutil.ErrType, "objconv/xml: map keys must be scalar values, not values of type %T", v)
+	}
+
+	e.key = k
+	e.val = true
+	return nil
+}
+
+func (e *mapEmitter) value() interface{} {
+	return e.self
+}
+
+// layout is the representation of formatting policies used by the emitter.
+type layout struct {
+	prefix string // prefix of each line following a line break
+	indent string // indentation of each nesting level, empty for single lines
+}
+
+// newline starts a new line indented n times, unless the layout has no
+// indentation.
+func (l layout) newline(b []byte, n int) []byte {
+	if l.indent == "" {
+		return b
+	}
+
+	b = append(b, '\n')
+	b = append(b, l.prefix...)
+
+	for i := 0; i != n; i++ {
+		b = append(b, l.indent...)
+	}
+
+	return b
+}
+
+// appendElement writes v as an element called name at the nesting level n.
+// Arrays are written as an element holding an "item" element for each of their
+// values.
+func (l layout) appendElement(b []byte, name string, v interface{}, n int) ([]byte, error) {
+	var err error
+
+	if !isName(name) {
+		return b, objutil.Errorf(objutil.ErrType, "objconv/xml: %q is not a valid element name", name)
+	}
+
+	b = append(b, '<')
+	b = append(b, name...)
+
+	switch x := v.(type) {
+	case nil:
+		return append(b, "/>"...), nil
+
+	case string:
+		b = append(b, '>')
+		b = appendText(b, x, false)
+
+	case *array:
+		if len(x.values) == 0 {
+			return append(b, "/>"...), nil
+		}
+
+		b = append(b, '>')
+
+		for _, item := range x.values {
+			b = l.newline(b, n+1)
+
+			if b, err = l.appendElement(b, itemName, item, n+1); err != nil {
+				return b, err
+			}
+		}
+
+		b = l.newline(b, n)
+
+	case *object:
+		content := false
+
+		for i, k := range x.keys {
+			if !strings.HasPrefix(k, attrPrefix) {
+				content = true
+				continue
+			}
+			if b, err = appendAttr(b, name, k[len(attrPrefix):], x.values[i]); err != nil {
+				return b, err
+			}
+		}
+
+		if !content {
+			return append(b, "/>"...), nil
+		}
+
+		b = append(b, '>')
+
+		if x.hasText() {
+			l = layout{}
+		}
+
+		children := false
+
+		for i, k := range x.keys {
+			switch {
+			case strings.HasPrefix(k, attrPrefix):
+			case k == textKey:
+				switch s := x.values[i].(type) {
+				case nil:
+				case string:
+					b = appendText(b, s, false)
+				default:
+					return b, objutil.Errorf(objutil.ErrType, "objconv/xml: the character data of <%s> must be a scalar value", name)
+				}
+			default:
+				if b, err = l.appendElements(b, k, x.values[i], n+1); err != nil {
+					return b, err
+				}
+				children = true
+			}
+		}
+
+		if children {
+			b = l.newline(b, n)
+		}
+	}
+
+	b = append(b, "</"...)
+	b = append(b, name...)
+	b = append(b, '>')
+	return b, nil
+}
+
+// hasText returns true if o holds character data, which is written as given
+// rather than indented.
+func (o *object) hasText() bool {
+	for i, k := range o.keys {
+		if k == textKey && o.values[i] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// appendElements writes v on a new line as an element called name at the
+// nesting level n, or as an element for each value when v is an array.
+func (l layout) appendElements(b []byte, name string, v interface{}, n int) ([]byte, error) {
+	a, ok := v.(*array)
+	if !ok {
+		return l.appendElement(l.newline(b, n), name, v, n)
+	}
+
+	var err error
+
+	for _, item := range a.values {
+		if b, err = l.appendElement(l.newline(b, n), name, item, n); err != nil {
+			break
+		}
+	}
+
+	return b, err
+}
+
+// appendAttr writes the attribute called name of the element elem, the
+// attribute is omitted when v is nil.
+func appendAttr(b []byte, elem string, name string, v interface{}) ([]byte, error) {
+	if !isName(name) {
+		return b, objutil.Errorf(objutil.ErrType, "objconv/xml: %q is not a valid attribute name of <%s>", name, elem)
+	}
+
+	switch s := v.(type) {
+	case nil:
+	case string:
+		b = append(b, ' ')
+		b = append(b, name...)
+		b = append(b, '=', '"')
+		b = appendText(b, s, true)
+		b = append(b, '"')
+	default:
+		return b, objutil.Errorf(objutil.ErrType, "objconv/xml: the attribute %q of <%s> must be a scalar value", name, elem)
+	}
+
+	return b, nil
+}
+
+// appendText writes s escaped as character data, or as the value of an
+// attribute if attr is true. Characters which are not allowed in XML documents
+// are replaced by U+FFFD.
+func appendText(b []byte, s string, attr bool) []byte {
+	i := 0
+
+	for j := 0; j < len(s); {
+		r, n := utf8.DecodeRuneInString(s[j:])
+		esc := ""
+
+		switch {
+		case r == '&':
+			esc = "&amp;"
+		case r == '<':
+			esc = "&lt;"
+		case r == '>':
+			esc = "&gt;"
+		case r == '\r':
+			esc = "&#xD;"
+		case r == '"' && attr:
+			esc = "&#34;"
+		case r == '\n' && attr:
+			esc = "&#xA;"
+		case r == '\t' && attr:
+			esc = "&#x9;"
+		case (r == utf8.RuneError && n == 1) || !isChar(r):
+			esc = "\uFFFD"
+		}
+
+		if len(esc) != 0 {
+			b = append(b, s[i:j]...)
+			b = append(b, esc...)
+			i = j + n
+		}
+
+		j += n
+	}
+
+	return append(b, s[i:]...)
+}
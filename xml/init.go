@@ -0,0 +1,23 @@
+package xml
+
+import (
+	"io"
+
+	"github.com/segmentio/objconv"
+)
+
+// Codec for the XML format.
+var Codec = objconv.Codec{
+	NewEmitter: func(w io.Writer) objconv.Emitter { return NewEmitter(w) },
+	NewParser:  func(r io.Reader) objconv.Parser { return NewParser(r) },
+}
+
+func init() {
+	for _, name := range [...]string{
+		"application/xml",
+		"text/xml",
+		"xml",
+	} {
+		objconv.Register(name, Codec)
+	}
+}
@@ -0,0 +1,457 @@
+package xml
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+// Parser implements an XML parser that satisfies the objconv.Parser
+// interface.
+//
+// Elements can only be told apart from arrays once all their children were
+// seen, so the parser loads each document in memory before producing values.
+type Parser struct {
+	r io.Reader    // reader to load documents from
+	d *xml.Decoder // tokenizer of r, created when the first document is loaded
+	s []byte       // string buffer
+	// This stack is used to iterate over the maps and arrays of the loaded
+	// document.
+	stack []parser
+}
+
+func NewParser(r io.Reader) *Parser {
+	return &Parser{r: r}
+}
+
+func (p *Parser) Reset(r io.Reader) {
+	p.r = r
+	p.d = nil
+	p.s = p.s[:0]
+	p.stack = nil
+}
+
+func (p *Parser) ParseType() (typ objconv.Type, err error) {
+	if len(p.stack) == 0 {
+		var v interface{}
+		var pos objconv.Position
+
+		if v, pos, err = p.load(); err != nil {
+			return
+		}
+		p.push(newParser(v, pos))
+	}
+
+	switch v := p.value(); v.(type) {
+	case nil:
+		typ = objconv.Nil
+
+	case string:
+		typ = objconv.String
+
+	case *object:
+		typ = objconv.Map
+
+	case *array:
+		typ = objconv.Array
+
+	case eof:
+		err = io.EOF
+	}
+
+	return
+}
+
+func (p *Parser) ParseNil() (err error) {
+	p.pop()
+	return
+}
+
+func (p *Parser) ParseBool() (v bool, err error) {
+	panic("objconv/xml: ParseBool should never be called because XML has no boolean type, this is likely a bug in the decoder code")
+}
+
+func (p *Parser) ParseInt() (v int64, err error) {
+	panic("objconv/xml: ParseInt should never be called because XML has no integer type, this is likely a bug in the decoder code")
+}
+
+func (p *Parser) ParseUint() (v uint64, err error) {
+	panic("objconv/xml: ParseUint should never be called because XML has no unsigned integer type, this is likely a bug in the decoder code")
+}
+
+func (p *Parser) ParseFloat() (v float64, err error) {
+	panic("objconv/xml: ParseFloat should never be called because XML has no floating point type, this is likely a bug in the decoder code")
+}
+
+func (p *Parser) ParseString() (v []byte, err error) {
+	s := p.pop().value().(string)
+	n := len(s)
+
+	if cap(p.s) < n {
+		p.s = make([]byte, 0, ((n/1024)+1)*1024)
+	}
+
+	v = p.s[:n]
+	copy(v, s)
+	return
+}
+
+func (p *Parser) ParseBytes() (v []byte, err error) {
+	panic("objconv/xml: ParseBytes should never be called because XML has no bytes type, this is likely a bug in the decoder code")
+}
+
+func (p *Parser) ParseTime() (v time.Time, err error) {
+	panic("objconv/xml: ParseTime should never be called because XML has no time type, this is likely a bug in the decoder code")
+}
+
+func (p *Parser) ParseDuration() (v time.Duration, err error) {
+	panic("objconv/xml: ParseDuration should never be called because XML has no duration type, this is likely a bug in the decoder code")
+}
+
+func (p *Parser) ParseError() (v error, err error) {
+	panic("objconv/xml: ParseError should never be called because XML has no error type, this is likely a bug in the decoder code")
+}
+
+func (p *Parser) ParseArrayBegin() (n int, err error) {
+	if n = p.top().len(); n != 0 {
+		p.push(p.top().next())
+	}
+	return
+}
+
+func (p *Parser) ParseArrayEnd(n int) (err error) {
+	p.pop()
+	return
+}
+
+func (p *Parser) ParseArrayNext(n int) (err error) {
+	p.push(p.top().next())
+	return
+}
+
+func (p *Parser) ParseMapBegin() (n int, err error) {
+	if n = p.top().len(); n != 0 {
+		p.push(p.top().next())
+	}
+	return
+}
+
+func (p *Parser) ParseMapEnd(n int) (err error) {
+	p.pop()
+	return
+}
+
+func (p *Parser) ParseMapValue(n int) (err error) {
+	p.push(p.top().next())
+	return
+}
+
+func (p *Parser) ParseMapNext(n int) (err error) {
+	p.push(p.top().next())
+	return
+}
+
+// Position satisfies the objconv.PositionParser interface.
+func (p *Parser) Position() objconv.Position {
+	if len(p.stack) == 0 {
+		return objconv.Position{Line: 1, Column: 1}
+	}
+	return p.top().position()
+}
+
+func (p *Parser) TextParser() bool {
+	return true
+}
+
+// UntypedParser satisfies the objconv.UntypedParser interface.
+func (p *Parser) UntypedParser() bool {
+	return true
+}
+
+func (p *Parser) DecodeBytes(b []byte) (v []byte, err error) {
+	var n int
+	if n, err = base64.StdEncoding.Decode(b, b); err != nil {
+		return
+	}
+	v = b[:n]
+	return
+}
+
+// load reads the next document from the input, it returns an eof value when
+// there are no more documents.
+func (p *Parser) load() (v interface{}, pos objconv.Position, err error) {
+	if p.d == nil {
+		p.d = xml.NewDecoder(p.r)
+	}
+
+	// The stack holds the elements being loaded, the first one is the root
+	// element of the document.
+	var stack []*element
+
+	for {
+		// The position of the decoder is at the beginning of the next token,
+		// since character data is always returned as separate tokens.
+		line, column := p.d.InputPos()
+		var tok xml.Token
+
+		if tok, err = p.d.Token(); err != nil {
+			if err == io.EOF {
+				if len(stack) == 0 {
+					return eof{}, pos, nil
+				}
+				err = io.ErrUnexpectedEOF
+			}
+			err = syntaxError(err)
+			return
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &element{name: t.Name.Local, pos: objconv.Position{Line: line, Column: column}}
+
+			for _, a := range t.Attr {
+				if a.Name.Space != "xmlns" && (a.Name.Space != "" || a.Name.Local != "xmlns") {
+					e.attrs = append(e.attrs, a)
+				}
+			}
+
+			stack = append(stack, e)
+
+		case xml.EndElement:
+			i := len(stack) - 1
+			e := stack[i]
+			stack = stack[:i]
+
+			if i == 0 {
+				return e.value(), e.pos, nil
+			}
+
+			stack[i-1].add(e)
+
+		case xml.CharData:
+			if len(stack) != 0 {
+				stack[len(stack)-1].text = append(stack[len(stack)-1].text, t...)
+			} else if len(bytes.TrimSpace(t)) != 0 {
+				err = objutil.Errorf(objutil.ErrSyntax, "objconv/xml: %d:%d: the document has character data outside of its root element", line, column)
+				return
+			}
+		}
+	}
+}
+
+func syntaxError(err error) error {
+	var e *xml.SyntaxError
+
+	switch {
+	case errors.As(err, &e):
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/xml: line %d: %s", e.Line, e.Msg)
+	case err == io.ErrUnexpectedEOF:
+		return objutil.Errorf(objutil.ErrSyntax, "objconv/xml: the document ends before its root element is closed")
+	default:
+		return err
+	}
+}
+
+// element is an element being loaded by the parser.
+type element struct {
+	name     string
+	attrs    []xml.Attr
+	children *object
+	repeated []bool // whether the children are arrays of repeated elements
+	text     []byte
+	pos      objconv.Position
+}
+
+// add adds the child element c to e, repeated children are grouped in arrays.
+func (e *element) add(c *element) {
+	v := c.value()
+
+	if e.children == nil {
+		e.children = &object{}
+	}
+
+	for i, k := range e.children.keys {
+		if k != c.name {
+			continue
+		}
+		if !e.repeated[i] {
+			a := &array{}
+			a.append(e.children.values[i], e.children.pos[i])
+			e.children.values[i] = a
+			e.repeated[i] = true
+		}
+		e.children.values[i].(*array).append(v, c.pos)
+		return
+	}
+
+	e.children.set(c.name, v, c.pos)
+	e.repeated = append(e.repeated, false)
+}
+
+// value returns the value represented by e, which is a string or nil if the
+// element has no attributes nor children, an array if its only children are
+// "item" elements, or an object otherwise.
+func (e *element) value() interface{} {
+	if len(e.attrs) == 0 && e.children == nil {
+		if len(e.text) == 0 {
+			return nil
+		}
+		return string(e.text)
+	}
+
+	if len(e.attrs) == 0 && len(e.children.keys) == 1 && e.children.keys[0] == itemName && len(bytes.TrimSpace(e.text)) == 0 {
+		if e.repeated[0] {
+			return e.children.values[0]
+		}
+		a := &array{}
+		a.append(e.children.values[0], e.children.pos[0])
+		return a
+	}
+
+	o := &object{}
+
+	for _, a := range e.attrs {
+		o.set(attrPrefix+a.Name.Local, a.Value, e.pos)
+	}
+
+	if e.children != nil {
+		for i, k := range e.children.keys {
+			o.set(k, e.children.values[i], e.children.pos[i])
+		}
+	}
+
+	if text := bytes.TrimSpace(e.text); len(text) != 0 {
+		o.set(textKey, string(text), e.pos)
+	}
+
+	return o
+}
+
+func (p *Parser) push(v parser) {
+	p.stack = append(p.stack, v)
+}
+
+func (p *Parser) pop() parser {
+	i := len(p.stack) - 1
+	v := p.stack[i]
+	p.stack = p.stack[:i]
+	return v
+}
+
+func (p *Parser) top() parser {
+	return p.stack[len(p.stack)-1]
+}
+
+func (p *Parser) value() interface{} {
+	n := len(p.stack)
+	if n == 0 {
+		return eof{}
+	}
+	return p.stack[n-1].value()
+}
+
+type parser interface {
+	value() interface{}
+	next() parser
+	len() int
+	position() objconv.Position
+}
+
+type valueParser struct {
+	self interface{}
+	pos  objconv.Position
+}
+
+func (p *valueParser) value() interface{} {
+	return p.self
+}
+
+func (p *valueParser) next() parser {
+	panic("objconv/xml: invalid call of next method on simple value parser")
+}
+
+func (p *valueParser) len() int {
+	panic("objconv/xml: invalid call of len method on simple value parser")
+}
+
+func (p *valueParser) position() objconv.Position {
+	return p.pos
+}
+
+type arrayParser struct {
+	self *array
+	off  int
+	pos  objconv.Position
+}
+
+func (p *arrayParser) value() interface{} {
+	return p.self
+}
+
+func (p *arrayParser) next() parser {
+	i := p.off
+	p.off++
+	return newParser(p.self.values[i], p.self.pos[i])
+}
+
+func (p *arrayParser) len() int {
+	return len(p.self.values)
+}
+
+func (p *arrayParser) position() objconv.Position {
+	return p.pos
+}
+
+type objectParser struct {
+	self *object
+	off  int
+	val  bool
+	pos  objconv.Position
+}
+
+func (p *objectParser) value() interface{} {
+	return p.self
+}
+
+func (p *objectParser) next() (v parser) {
+	if p.val {
+		v = newParser(p.self.values[p.off], p.self.pos[p.off])
+		p.val = false
+		p.off++
+	} else {
+		v = newParser(p.self.keys[p.off], p.self.pos[p.off])
+		p.val = true
+	}
+	return
+}
+
+func (p *objectParser) len() int {
+	return len(p.self.keys)
+}
+
+func (p *objectParser) position() objconv.Position {
+	return p.pos
+}
+
+func newParser(v interface{}, pos objconv.Position) parser {
+	switch x := v.(type) {
+	case *object:
+		return &objectParser{self: x, pos: pos}
+
+	case *array:
+		return &arrayParser{self: x, pos: pos}
+
+	default:
+		return &valueParser{self: x, pos: pos}
+	}
+}
+
+// eof values are returned by the top method to indicate that all values have
+// already been consumed.
+type eof struct{}
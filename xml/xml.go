@@ -0,0 +1,110 @@
+// Package xml implements a codec for the XML format, it is registered under
+// the "application/xml", "text/xml" and "xml" names.
+//
+// Documents are made of a root element, which is called "root" by default and
+// whose name is ignored by parsers. The entries of maps and the fields of
+// structs are written as child elements named after their keys, except for
+// keys starting with "@" which are written as attributes, and the "#text" key
+// which holds the character data of the element. The `attr` and `chardata`
+// options of struct tags give these names to the fields:
+//
+//	type Book struct {
+//		ID     string   `objconv:"id,attr"`
+//		Title  string   `objconv:"title"`
+//		Author []string `objconv:"author"`
+//	}
+//
+//	// <root id="1"><title>Dune</title><author>Frank Herbert</author></root>
+//
+// The elements of arrays are written as repeated elements with the name of
+// the key that the array is the value of, nested arrays and arrays at the top
+// level have elements named "item", elements which only have "item" children
+// are parsed as arrays. Nil values are written as empty elements.
+//
+// XML has no types, so all values are parsed as strings, empty elements are
+// parsed as nil values. The parser implements objconv.UntypedParser, so they
+// are decoded into booleans, numbers and times, and into slices of one element
+// when an element isn't repeated. Namespaces are ignored, elements and
+// attributes are identified by their local names only.
+package xml
+
+import (
+	"unicode"
+
+	"github.com/segmentio/objconv"
+)
+
+const (
+	// defaultRoot is the name of root elements when the emitter has no Root.
+	defaultRoot = "root"
+
+	// itemName is the name of the elements of arrays which aren't the value
+	// of a map key.
+	itemName = "item"
+
+	// attrPrefix starts the keys of maps which represent attributes.
+	attrPrefix = "@"
+
+	// textKey is the key of maps which represents character data.
+	textKey = "#text"
+)
+
+// object is the in-memory representation of elements with attributes or
+// children used by the emitter and the parser, entries are kept in the order
+// they were written in.
+type object struct {
+	keys   []string
+	values []interface{}
+	// Positions of the entries in the document, they are only set by the
+	// parser and are the positions of child elements, or of the element
+	// itself for attributes and character data.
+	pos []objconv.Position
+}
+
+func (o *object) set(k string, v interface{}, pos objconv.Position) {
+	o.keys = append(o.keys, k)
+	o.values = append(o.values, v)
+	o.pos = append(o.pos, pos)
+}
+
+// array is the in-memory representation of arrays, which are repeated
+// elements in documents.
+type array struct {
+	values []interface{}
+	pos    []objconv.Position
+}
+
+func (a *array) append(v interface{}, pos objconv.Position) {
+	a.values = append(a.values, v)
+	a.pos = append(a.pos, pos)
+}
+
+// isName returns true if s is a valid name of XML element or attribute.
+func isName(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+
+	for i, r := range s {
+		switch {
+		case r == '_' || r == ':' || unicode.IsLetter(r):
+		case i != 0 && (r == '-' || r == '.' || unicode.IsDigit(r)):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// isChar returns true if r is allowed in XML documents.
+func isChar(r rune) bool {
+	switch {
+	case r == 0x09 || r == 0x0A || r == 0x0D:
+		return true
+	case r >= 0x20 && r <= 0xD7FF, r >= 0xE000 && r <= 0xFFFD:
+		return true
+	default:
+		return r >= 0x10000 && r <= 0x10FFFF
+	}
+}
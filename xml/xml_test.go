@@ -0,0 +1,326 @@
+package xml
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
+)
+
+type note struct {
+	Kind string `objconv:"kind,attr"`
+	Text string `objconv:",chardata"`
+}
+
+type book struct {
+	ID        string        `objconv:"id,attr"`
+	Lang      string        `objconv:"lang,attr,omitempty"`
+	Title     string        `objconv:"title"`
+	Authors   []string      `objconv:"author"`
+	Price     float64       `objconv:"price"`
+	Available bool          `objconv:"available"`
+	Published time.Time     `objconv:"published"`
+	Loan      time.Duration `objconv:"loan"`
+	Note      *note         `objconv:"note"`
+	Tags      [][]string    `objconv:"tags"`
+}
+
+var testBook = book{
+	ID:        "42",
+	Title:     "Dune <&> \"Messiah\"",
+	Authors:   []string{"Frank Herbert", "Brian Herbert"},
+	Price:     9.5,
+	Available: true,
+	Published: time.Date(1969, 10, 15, 0, 0, 0, 0, time.UTC),
+	Loan:      72 * time.Hour,
+	Note:      &note{Kind: "a \"quote\"", Text: "first edition"},
+	Tags:      [][]string{{"sf", "classic"}, {"desert"}},
+}
+
+const testDocument = `<root id="42">` +
+	`<title>Dune &lt;&amp;&gt; "Messiah"</title>` +
+	`<author>Frank Herbert</author><author>Brian Herbert</author>` +
+	`<price>9.5</price>` +
+	`<available>true</available>` +
+	`<published>1969-10-15T00:00:00Z</published>` +
+	`<loan>72h0m0s</loan>` +
+	`<note kind="a &#34;quote&#34;">first edition</note>` +
+	`<tags><item>sf</item><item>classic</item></tags><tags><item>desert</item></tags>` +
+	`</root>`
+
+func TestMarshal(t *testing.T) {
+	b, err := Marshal(testBook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(b); s != testDocument {
+		t.Errorf("bad document:\n%s\n%s", testDocument, s)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	var v book
+
+	if err := Unmarshal([]byte(testDocument), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(v, testBook) {
+		t.Errorf("bad value:\n%#v\n%#v", testBook, v)
+	}
+}
+
+func TestUnmarshalSingleElements(t *testing.T) {
+	// Unlike the authors of testDocument, the single author isn't repeated
+	// so it is parsed as a string, which is decoded into a slice.
+	const doc = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- library export -->
+<book xmlns="urn:library" id="7">
+  <title>Emma</title>
+  <author>Jane Austen</author>
+  <note/>
+</book>
+`
+	var v book
+
+	if err := Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := book{ID: "7", Title: "Emma", Authors: []string{"Jane Austen"}}
+
+	if !reflect.DeepEqual(v, expect) {
+		t.Errorf("bad value:\n%#v\n%#v", expect, v)
+	}
+}
+
+func TestUnmarshalInterface(t *testing.T) {
+	var v interface{}
+
+	if err := Unmarshal([]byte(`<root a="1"><b>2</b><b/><c> text </c><d><item>3</item></d> x </root>`), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[interface{}]interface{}{
+		"@a":    "1",
+		"b":     []interface{}{"2", nil},
+		"c":     " text ",
+		"d":     []interface{}{"3"},
+		"#text": "x",
+	}
+
+	if !reflect.DeepEqual(v, expect) {
+		t.Errorf("bad value:\n%#v\n%#v", expect, v)
+	}
+}
+
+func TestMarshalValues(t *testing.T) {
+	tests := []struct {
+		v interface{}
+		s string
+	}{
+		{v: nil, s: `<root/>`},
+		{v: "", s: `<root></root>`},
+		{v: "a\r\nb\tc", s: "<root>a&#xD;\nb\tc</root>"},
+		{v: "\x00\xff", s: "<root>��</root>"},
+		{v: 1, s: `<root>1</root>`},
+		{v: uint8(2), s: `<root>2</root>`},
+		{v: float32(0.1), s: `<root>0.1</root>`},
+		{v: []byte("hi"), s: `<root>aGk=</root>`},
+		{v: errors.New("oops"), s: `<root>oops</root>`},
+		{v: []int{}, s: `<root/>`},
+		{v: []interface{}{1, nil, []int{2}}, s: `<root><item>1</item><item/><item><item>2</item></item></root>`},
+		{v: map[string]interface{}{"@a": "x\n\"y\"", "@b": nil}, s: `<root a="x&#xA;&#34;y&#34;"/>`},
+		{v: map[string][]int{"a": {}}, s: `<root></root>`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			b, err := Marshal(test.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s := string(b); s != test.s {
+				t.Errorf("bad document:\n%s\n%s", test.s, s)
+			}
+		})
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{name: "invalid element name", v: map[string]int{"1st": 1}},
+		{name: "invalid attribute name", v: map[string]int{"@a b": 1}},
+		{name: "map attribute", v: map[string]interface{}{"@a": map[string]int{"b": 1}}},
+		{name: "array attribute", v: map[string]interface{}{"@a": []int{1}}},
+		{name: "map chardata", v: map[string]interface{}{"#text": map[string]int{"b": 1}}},
+		{name: "map key", v: map[[1]int]int{{1}: 1}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Marshal(test.v); !errors.Is(err, objutil.ErrType) {
+				t.Errorf("bad error: %v", err)
+			}
+		})
+	}
+}
+
+func TestEmitterRoot(t *testing.T) {
+	b := &bytes.Buffer{}
+	e := NewEmitter(b)
+	e.Root = "book"
+
+	if err := objconv.NewEncoder(e).Encode(note{Kind: "k"}); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); s != `<book kind="k"></book>` {
+		t.Errorf("bad document: %s", s)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		kind error
+	}{
+		{name: "unclosed element", doc: `<root><a>1</a>`, kind: objutil.ErrSyntax},
+		{name: "mismatched element", doc: `<root><a>1</b></root>`, kind: objutil.ErrSyntax},
+		{name: "text outside root", doc: `hello <root/>`, kind: objutil.ErrSyntax},
+		{name: "trailing data", doc: `<root/><root/>`, kind: objutil.ErrSyntax},
+		{name: "invalid boolean", doc: `<root><available>yes</available></root>`, kind: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var v book
+			err := Unmarshal([]byte(test.doc), &v)
+
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if test.kind != nil && !errors.Is(err, test.kind) {
+				t.Errorf("bad error: %v", err)
+			}
+		})
+	}
+}
+
+func TestStreamDecoder(t *testing.T) {
+	d := &objconv.StreamDecoder{Parser: NewParser(strings.NewReader("<a>1</a>\n<a>2</a>\n<a/>\n")), Sequence: true}
+
+	var values []int
+
+	for {
+		var v int
+		if err := d.Decode(&v); err != nil {
+			if err != objconv.End {
+				t.Fatal(err)
+			}
+			break
+		}
+		values = append(values, v)
+	}
+
+	if !reflect.DeepEqual(values, []int{1, 2, 0}) {
+		t.Errorf("bad values: %v", values)
+	}
+}
+
+func TestPositions(t *testing.T) {
+	const doc = "<root id=\"1\">\n  <title>Emma</title>\n  <author>A</author><author>B</author>\n</root>"
+
+	var v book
+	d := objconv.Decoder{Parser: NewParser(strings.NewReader(doc)), Positions: map[string]objconv.Position{}}
+
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[string]objconv.Position{
+		"@id":       {Line: 1, Column: 1},
+		"title":     {Line: 2, Column: 3},
+		"author":    {Line: 3, Column: 3},
+		"author[0]": {Line: 3, Column: 3},
+		"author[1]": {Line: 3, Column: 21},
+	}
+
+	for path, pos := range expect {
+		if d.Positions[path] != pos {
+			t.Errorf("%s: bad position: %v != %v", path, pos, d.Positions[path])
+		}
+	}
+}
+
+func TestCodec(t *testing.T) {
+	for _, name := range []string{"application/xml", "text/xml", "xml"} {
+		if _, ok := objconv.Lookup(name); !ok {
+			t.Errorf("%s: codec not registered", name)
+		}
+	}
+}
+
+func TestFormatEmitter(t *testing.T) {
+	b := &bytes.Buffer{}
+	e := NewEmitter(b)
+	e.Root = "book"
+
+	enc := objconv.NewEncoder(e)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(testBook); err != nil {
+		t.Fatal(err)
+	}
+
+	const s = `<book id="42">
+  <title>Dune &lt;&amp;&gt; "Messiah"</title>
+  <author>Frank Herbert</author>
+  <author>Brian Herbert</author>
+  <price>9.5</price>
+  <available>true</available>
+  <published>1969-10-15T00:00:00Z</published>
+  <loan>72h0m0s</loan>
+  <note kind="a &#34;quote&#34;">first edition</note>
+  <tags>
+    <item>sf</item>
+    <item>classic</item>
+  </tags>
+  <tags>
+    <item>desert</item>
+  </tags>
+</book>`
+
+	if b.String() != s {
+		t.Errorf("bad document:\n%s", b.String())
+	}
+
+	var v book
+
+	if err := Unmarshal(b.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(v, testBook) {
+		t.Errorf("bad value:\n%#v\n%#v", v, testBook)
+	}
+
+	b.Reset()
+	f := NewEmitter(b).FormatEmitter(objconv.Format{Prefix: "\t", Indent: " "})
+
+	if err := objconv.NewEncoder(f).Encode(map[string]interface{}{"a": [][]int{{1}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := b.String(); s != "<root>\n\t <a>\n\t  <item>1</item>\n\t </a>\n\t</root>" {
+		t.Errorf("bad document: %q", s)
+	}
+}
@@ -0,0 +1,400 @@
+package yaml
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/objconv/objutil"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Document is a model of a YAML document which preserves its comments and
+// blank lines, it is intended to be used by programs editing configuration
+// files written by people.
+//
+// The lines of the document that are not modified are written back exactly as
+// they were read. Mappings are represented as trees of entries which can be
+// modified with Set and Delete, other values like sequences or block scalars
+// are kept as opaque blocks of lines that are replaced as a whole.
+type Document struct {
+	root docNode
+	tail []string // comments and blank lines after the last value
+}
+
+type docNode struct {
+	before   []string   // comments and blank lines preceding the entry
+	line     string     // line of the entry, empty for the root node
+	indent   int        // indentation of the entry, -1 for the root node
+	key      string     // decoded key of the entry
+	keyText  string     // key of the entry as written in the document
+	value    string     // inline value, empty if the value is on the following lines
+	comment  string     // comment at the end of the line, with leading spaces
+	body     []string   // lines of values that are not mappings
+	children []*docNode // entries of mapping values
+}
+
+// ParseDocument loads the YAML document in b into a Document value.
+func ParseDocument(b []byte) (*Document, error) {
+	var v interface{}
+
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, syntaxError(err)
+	}
+
+	d := &Document{}
+
+	if err := d.parse(string(b)); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Decode decodes the document into v.
+func (d *Document) Decode(v interface{}) error {
+	return Unmarshal(d.Bytes(), v)
+}
+
+// Set encodes v and writes it in the document at the entry of the mappings
+// found by following path, which are created when they don't exist. The whole
+// document is replaced when path is empty.
+//
+// The comments preceding the entry and at the end of its line are preserved,
+// and new entries are added after the existing ones of their mapping.
+func (d *Document) Set(v interface{}, path ...string) error {
+	b, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	node := &d.root
+
+	for i, key := range path {
+		if node.value != "" || len(node.body) != 0 {
+			return objutil.Errorf(objutil.ErrType, "objconv/yaml: the value at %q is not a mapping", strings.Join(path[:i], "."))
+		}
+
+		child := node.lookup(key)
+
+		if child == nil {
+			child = &docNode{indent: node.childIndent(), key: key, keyText: formatKey(key)}
+			child.line = strings.Repeat(" ", child.indent) + child.keyText + ":"
+			node.children = append(node.children, child)
+		}
+
+		node = child
+	}
+
+	node.set(lines)
+
+	// The tree is rebuilt from the output so new mapping values can be edited
+	// like the ones loaded from the original document.
+	return d.parse(string(d.Bytes()))
+}
+
+// Delete removes the entry found by following path from the document, along
+// with the comments preceding it. The method returns false if no entries
+// existed at path.
+func (d *Document) Delete(path ...string) bool {
+	if len(path) == 0 {
+		return false
+	}
+
+	node := &d.root
+
+	for _, key := range path[:len(path)-1] {
+		if node = node.lookup(key); node == nil {
+			return false
+		}
+	}
+
+	for i, child := range node.children {
+		if child.key == path[len(path)-1] {
+			node.children = append(node.children[:i], node.children[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Bytes returns the YAML representation of the document.
+func (d *Document) Bytes() []byte {
+	b := d.root.append(nil)
+
+	for _, line := range d.tail {
+		b = appendLine(b, line)
+	}
+
+	return b
+}
+
+// WriteTo writes the YAML representation of the document to w.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(d.Bytes())
+	return int64(n), err
+}
+
+func (d *Document) parse(s string) error {
+	var trivia []string
+	var raw *docNode  // node of the opaque value being read
+	var last *docNode // last entry read
+
+	d.root = docNode{indent: -1}
+	d.tail = nil
+	stack := []*docNode{&d.root}
+
+	lines := strings.Split(s, "\n")
+
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		content := strings.TrimLeft(line, " ")
+		indent := len(line) - len(content)
+
+		if content == "" || content[0] == '#' || (line == "---" && last == nil && raw == nil) {
+			trivia = append(trivia, line)
+			continue
+		}
+
+		if raw != nil && (indent > raw.indent || (indent == raw.indent && raw.value == "" && isSequenceItem(content))) {
+			raw.body = append(append(raw.body, trivia...), line)
+			trivia = nil
+			continue
+		}
+
+		raw = nil
+
+		if keyText, value, comment, ok := splitEntry(content); ok {
+			for stack[len(stack)-1].indent >= indent {
+				stack = stack[:len(stack)-1]
+			}
+
+			parent := stack[len(stack)-1]
+
+			if parent.value != "" || len(parent.body) != 0 {
+				return objutil.Errorf(objutil.ErrSyntax, "objconv/yaml: the mapping entry on line %d cannot be represented in a document", i+1)
+			}
+
+			last = &docNode{
+				before:  trivia,
+				line:    line,
+				indent:  indent,
+				key:     parseKey(keyText),
+				keyText: keyText,
+				value:   value,
+				comment: comment,
+			}
+
+			parent.children = append(parent.children, last)
+			stack = append(stack, last)
+			trivia = nil
+
+			if value != "" {
+				raw = last
+			}
+			continue
+		}
+
+		switch {
+		case last != nil && last.value == "" && len(last.children) == 0 && (indent > last.indent || (indent == last.indent && isSequenceItem(content))):
+			raw = last
+		case last == nil:
+			raw = &d.root
+		default:
+			return objutil.Errorf(objutil.ErrSyntax, "objconv/yaml: the value on line %d cannot be represented in a document", i+1)
+		}
+
+		raw.body = append(append(raw.body, trivia...), line)
+		trivia = nil
+	}
+
+	d.tail = trivia
+	return nil
+}
+
+func (n *docNode) lookup(key string) *docNode {
+	for _, child := range n.children {
+		if child.key == key {
+			return child
+		}
+	}
+	return nil
+}
+
+func (n *docNode) childIndent() int {
+	if len(n.children) != 0 {
+		return n.children[0].indent
+	}
+	return n.indent + 2
+}
+
+// set replaces the value of the node with the YAML lines of a value.
+func (n *docNode) set(lines []string) {
+	n.value, n.body, n.children = "", nil, nil
+
+	if n.indent < 0 {
+		n.body = lines
+		return
+	}
+
+	if len(lines) == 1 && !isSequenceItem(lines[0]) {
+		if _, _, _, ok := splitEntry(lines[0]); !ok {
+			n.value = lines[0]
+		}
+	}
+
+	if n.value != "" {
+		n.line = strings.Repeat(" ", n.indent) + n.keyText + ": " + n.value + n.comment
+		return
+	}
+
+	n.line = strings.Repeat(" ", n.indent) + n.keyText + ":" + n.comment
+	prefix := strings.Repeat(" ", n.indent+2)
+
+	for _, line := range lines {
+		n.body = append(n.body, prefix+line)
+	}
+}
+
+func (n *docNode) append(b []byte) []byte {
+	for _, line := range n.before {
+		b = appendLine(b, line)
+	}
+
+	if n.indent >= 0 {
+		b = appendLine(b, n.line)
+	}
+
+	for _, line := range n.body {
+		b = appendLine(b, line)
+	}
+
+	for _, child := range n.children {
+		b = child.append(b)
+	}
+
+	return b
+}
+
+func appendLine(b []byte, line string) []byte {
+	return append(append(b, line...), '\n')
+}
+
+func isSequenceItem(s string) bool {
+	return s == "-" || strings.HasPrefix(s, "- ")
+}
+
+// splitEntry splits the content of a line into the key, value, and comment of
+// a mapping entry, ok is false if the line is not a mapping entry.
+func splitEntry(s string) (key string, value string, comment string, ok bool) {
+	if s == "" || isSequenceItem(s) || strings.IndexByte("[{|>?&*!%@`#", s[0]) >= 0 {
+		return
+	}
+
+	i := 0
+
+	if s[0] == '"' || s[0] == '\'' {
+		if i = skipQuoted(s); i < 0 {
+			return
+		}
+	} else if i = indexSeparator(s); i < 0 {
+		return
+	}
+
+	if i == len(s) || s[i] != ':' || (i+1 != len(s) && s[i+1] != ' ') {
+		return
+	}
+
+	key, s = s[:i], s[i+1:]
+	j := 0
+
+	if v := strings.TrimLeft(s, " "); v != "" && (v[0] == '"' || v[0] == '\'') {
+		if j = skipQuoted(v); j < 0 {
+			j = len(v)
+		}
+		j += len(s) - len(v)
+	}
+
+	if k := strings.Index(s[j:], " #"); k >= 0 {
+		comment, s = s[j+k:], s[:j+k]
+	}
+
+	value = strings.TrimSpace(s)
+
+	if comment != "" {
+		// Spaces between the value and the comment are kept with the comment
+		// so they are preserved when the value changes.
+		comment = s[len(strings.TrimRight(s, " ")):] + comment
+	}
+
+	ok = true
+	return
+}
+
+// indexSeparator returns the index of the colon separating a plain key from
+// its value, or -1 if s has none.
+func indexSeparator(s string) int {
+	for i := 0; i != len(s); i++ {
+		switch s[i] {
+		case ':':
+			if i+1 == len(s) || s[i+1] == ' ' {
+				return i
+			}
+		case '#':
+			if i != 0 && s[i-1] == ' ' {
+				return -1
+			}
+		}
+	}
+	return -1
+}
+
+// skipQuoted returns the index following the quoted string at the beginning of
+// s, or -1 if it is not terminated.
+func skipQuoted(s string) int {
+	q := s[0]
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if q == '"' {
+				i++
+			}
+		case q:
+			if q == '\'' && i+1 < len(s) && s[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+
+	return -1
+}
+
+func parseKey(s string) string {
+	switch {
+	case strings.HasPrefix(s, "\""):
+		if k, err := strconv.Unquote(s); err == nil {
+			return k
+		}
+	case strings.HasPrefix(s, "'"):
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	return s
+}
+
+func formatKey(key string) string {
+	b, err := yaml.Marshal(key)
+	if b = bytes.TrimSuffix(b, []byte("\n")); err != nil || bytes.IndexByte(b, '\n') >= 0 {
+		return strconv.Quote(key)
+	}
+	return string(b)
+}
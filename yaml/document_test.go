@@ -0,0 +1,138 @@
+package yaml
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/objconv"
+)
+
+const config = `# Service configuration.
+name: api # the name of the service
+
+server:
+  # Address to listen on.
+  address: ":8080"
+  timeout: 5s
+
+  tls:
+    enabled: false
+
+tags:
+- a
+- b
+
+motd: |
+  Hello
+
+  World!
+# End of file.
+`
+
+func TestDocumentRoundTrip(t *testing.T) {
+	d, err := ParseDocument([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := string(d.Bytes()); s != config {
+		t.Errorf("bad document:\n%s", s)
+	}
+
+	var v struct {
+		Name   string `objconv:"name"`
+		Server struct {
+			Address string `objconv:"address"`
+			TLS     struct {
+				Enabled bool `objconv:"enabled"`
+			} `objconv:"tls"`
+		} `objconv:"server"`
+		Tags []string `objconv:"tags"`
+		Motd string   `objconv:"motd"`
+	}
+
+	if err := d.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Name != "api" || v.Server.Address != ":8080" || !reflect.DeepEqual(v.Tags, []string{"a", "b"}) || v.Motd != "Hello\n\nWorld!\n" {
+		t.Errorf("bad value: %+v", v)
+	}
+}
+
+func TestDocumentEdit(t *testing.T) {
+	d, err := ParseDocument([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edits := []struct {
+		v    interface{}
+		path []string
+	}{
+		{"web", []string{"name"}},
+		{true, []string{"server", "tls", "enabled"}},
+		{"/etc/tls/cert.pem", []string{"server", "tls", "cert"}},
+		{[]string{"c"}, []string{"tags"}},
+		{map[string]int{"max": 10}, []string{"limits"}},
+		{3, []string{"limits", "min"}},
+	}
+
+	for _, edit := range edits {
+		if err := d.Set(edit.v, edit.path...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !d.Delete("server", "timeout") {
+		t.Error("the timeout entry was not deleted")
+	}
+
+	if d.Delete("server", "missing") {
+		t.Error("a missing entry was reported as deleted")
+	}
+
+	const s = `# Service configuration.
+name: web # the name of the service
+
+server:
+  # Address to listen on.
+  address: ":8080"
+
+  tls:
+    enabled: true
+    cert: /etc/tls/cert.pem
+
+tags:
+  - c
+
+motd: |
+  Hello
+
+  World!
+limits:
+  max: 10
+  min: 3
+# End of file.
+`
+
+	if b := string(d.Bytes()); b != s {
+		t.Errorf("bad document:\n%s", b)
+	}
+}
+
+func TestDocumentErrors(t *testing.T) {
+	d, err := ParseDocument([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Set(1, "name", "first"); !errors.Is(err, objconv.ErrType) {
+		t.Error("bad error:", err)
+	}
+
+	if _, err := ParseDocument([]byte("a: [")); err == nil {
+		t.Error("no error was returned for an invalid document")
+	}
+}
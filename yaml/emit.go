@@ -5,6 +5,8 @@ import (
 	"io"
 	"time"
 
+	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -15,12 +17,34 @@ type Emitter struct {
 	// The stack is used to keep track of the container being built by the
 	// emitter, which may be an arrayEmitter or mapEmitter.
 	stack []emitter
+	// Cache of the last formatted time value, batches of records often have
+	// many identical timestamps.
+	times objutil.TimeCache
+	// Layout of the output when the emitter was created with a formatting
+	// policy, the yaml package lays out values otherwise.
+	format *formatter
 }
 
 func NewEmitter(w io.Writer) *Emitter {
 	return &Emitter{w: w}
 }
 
+// NewFormatEmitter returns a new emitter that writes YAML values to w in the
+// layout configured by f.
+//
+// Since YAML indentations cannot contain tabs, the emitter indents the values
+// of mappings with as many spaces as there are bytes in f.Indent, and the
+// elements of sequences after their "- " marker. The separators of keys and
+// values are always followed by at least one space.
+func NewFormatEmitter(w io.Writer, f objconv.Format) *Emitter {
+	return &Emitter{w: w, format: newFormatter(f)}
+}
+
+// FormatEmitter satisfies the objconv.FormatEmitter interface.
+func (e *Emitter) FormatEmitter(f objconv.Format) objconv.Emitter {
+	return NewFormatEmitter(e.w, f)
+}
+
 func (e *Emitter) Reset(w io.Writer) {
 	e.w = w
 	e.stack = e.stack[:0]
@@ -55,7 +79,7 @@ func (e *Emitter) EmitBytes(v []byte) error {
 }
 
 func (e *Emitter) EmitTime(v time.Time) error {
-	return e.emit(v.Format(time.RFC3339Nano))
+	return e.emit(e.times.FormatTime(v))
 }
 
 func (e *Emitter) EmitDuration(v time.Duration) error {
@@ -102,6 +126,18 @@ func (e *Emitter) TextEmitter() bool {
 	return true
 }
 
+// EmitRaw satisfies the objconv.RawEmitter interface, the YAML document b is
+// loaded and emitted as part of the output.
+func (e *Emitter) EmitRaw(b []byte) error {
+	var v interface{}
+
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return syntaxError(err)
+	}
+
+	return e.emit(v)
+}
+
 func (e *Emitter) emit(v interface{}) (err error) {
 	var b []byte
 
@@ -110,7 +146,13 @@ func (e *Emitter) emit(v interface{}) (err error) {
 		return
 	}
 
-	if b, err = yaml.Marshal(v); err != nil {
+	if e.format != nil {
+		b, err = e.format.format(v)
+	} else {
+		b, err = yaml.Marshal(v)
+	}
+
+	if err != nil {
 		return
 	}
 
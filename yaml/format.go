@@ -0,0 +1,250 @@
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/objconv"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// formatter writes YAML values in the layout configured by a formatting policy.
+//
+// The yaml package has no options to configure the layout of its output, so
+// the formatter writes block and flow collections itself, and only delegates
+// the formatting of scalars to the yaml package to reuse its quoting rules.
+type formatter struct {
+	prefix string // prefix of each line
+	tab    int    // number of spaces of each indentation level, zero for flow style
+	space  string // spacing after separators
+	width  int    // maximum length of arrays written in flow style
+	b      []byte
+	p      []byte // output with prefixed lines
+	err    error
+}
+
+func newFormatter(f objconv.Format) *formatter {
+	// YAML forbids tabs in indentations and requires separators to be followed
+	// by spaces, the policy only configures how many are used.
+	space := len(f.Space)
+	if space == 0 {
+		space = 1
+	}
+
+	return &formatter{
+		prefix: f.Prefix,
+		tab:    len(f.Indent),
+		space:  strings.Repeat(" ", space),
+		width:  f.Width,
+	}
+}
+
+// format returns the YAML representation of v, which is only valid until the
+// next call to format.
+func (f *formatter) format(v interface{}) ([]byte, error) {
+	f.b, f.err = f.b[:0], nil
+
+	switch b, ok := f.inline(f.b, v); {
+	case ok:
+		f.b = append(b, '\n')
+	case f.tab == 0:
+		f.b = append(f.flow(f.b, v), '\n')
+	default:
+		f.b = f.block(f.b, v, 0)
+	}
+
+	if f.prefix == "" {
+		return f.b, f.err
+	}
+
+	f.p = f.p[:0]
+
+	for b := f.b; len(b) != 0; {
+		i := bytes.IndexByte(b, '\n') + 1
+		if i == 0 {
+			i = len(b)
+		}
+		f.p = append(append(f.p, f.prefix...), b[:i]...)
+		b = b[i:]
+	}
+
+	return f.p, f.err
+}
+
+// block writes v in block style, the first line must already be indented and
+// the following ones are indented by n spaces.
+func (f *formatter) block(b []byte, v interface{}, n int) []byte {
+	switch x := v.(type) {
+	case yaml.MapSlice:
+		for i, item := range x {
+			if i != 0 {
+				b = indent(b, n)
+			}
+			b = f.flow(b, item.Key)
+			b = append(b, ':')
+
+			if c, ok := f.inline(append(b, f.space...), item.Value); ok {
+				b = append(c, '\n')
+			} else {
+				b = indent(append(b, '\n'), n+f.tab)
+				b = f.block(b, item.Value, n+f.tab)
+			}
+		}
+
+	case []interface{}:
+		for i, elem := range x {
+			if i != 0 {
+				b = indent(b, n)
+			}
+			b = append(b, '-', ' ')
+
+			if c, ok := f.inline(b, elem); ok {
+				b = append(c, '\n')
+			} else {
+				b = f.block(b, elem, n+2) // aligned after the "- " marker
+			}
+		}
+
+	case map[interface{}]interface{}:
+		b = f.block(b, mapSlice(x), n)
+
+	default:
+		b = append(f.flow(b, v), '\n')
+	}
+
+	return b
+}
+
+// inline appends v to b in flow style if it is a scalar, an empty collection,
+// or an array of scalars fitting within the width of the policy, and returns b
+// unchanged and false otherwise.
+func (f *formatter) inline(b []byte, v interface{}) ([]byte, bool) {
+	switch x := v.(type) {
+	case yaml.MapSlice:
+		if len(x) != 0 {
+			return b, false
+		}
+
+	case map[interface{}]interface{}:
+		if len(x) != 0 {
+			return b, false
+		}
+
+	case []interface{}:
+		if len(x) != 0 && f.width == 0 {
+			return b, false
+		}
+		for _, elem := range x {
+			if !isScalar(elem) {
+				return b, false
+			}
+		}
+		if c := f.flow(b, x); len(x) == 0 || len(c)-len(b) <= f.width {
+			return c, true
+		}
+		return b, false
+	}
+
+	return f.flow(b, v), true
+}
+
+// flow appends v to b in flow style.
+func (f *formatter) flow(b []byte, v interface{}) []byte {
+	switch x := v.(type) {
+	case yaml.MapSlice:
+		b = append(b, '{')
+		for i, item := range x {
+			if i != 0 {
+				b = append(append(b, ','), f.space...)
+			}
+			b = f.flow(b, item.Key)
+			b = append(append(b, ':'), f.space...)
+			b = f.flow(b, item.Value)
+		}
+		b = append(b, '}')
+
+	case []interface{}:
+		b = append(b, '[')
+		for i, elem := range x {
+			if i != 0 {
+				b = append(append(b, ','), f.space...)
+			}
+			b = f.flow(b, elem)
+		}
+		b = append(b, ']')
+
+	case map[interface{}]interface{}:
+		b = f.flow(b, mapSlice(x))
+
+	default:
+		b = f.scalar(b, v)
+	}
+
+	return b
+}
+
+// scalar appends the scalar value v to b, strings are double-quoted when the
+// representation produced by the yaml package spans multiple lines or would be
+// ambiguous in a flow collection.
+func (f *formatter) scalar(b []byte, v interface{}) []byte {
+	s, err := yaml.Marshal(v)
+	if err != nil {
+		if f.err == nil {
+			f.err = err
+		}
+		return b
+	}
+
+	s = s[:len(s)-1] // trailing newline
+
+	if str, ok := v.(string); ok && needsQuotes(string(s)) {
+		return strconv.AppendQuote(b, str)
+	}
+
+	return append(b, s...)
+}
+
+func needsQuotes(s string) bool {
+	if strings.IndexByte(s, '\n') >= 0 {
+		return true
+	}
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		return false
+	}
+	return strings.ContainsAny(s, ",[]{}")
+}
+
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case yaml.MapSlice, []interface{}, map[interface{}]interface{}:
+		return false
+	default:
+		return true
+	}
+}
+
+func indent(b []byte, n int) []byte {
+	for i := 0; i != n; i++ {
+		b = append(b, ' ')
+	}
+	return b
+}
+
+// mapSlice converts maps loaded by the yaml package to map slices with keys
+// in a deterministic order.
+func mapSlice(m map[interface{}]interface{}) yaml.MapSlice {
+	s := make(yaml.MapSlice, 0, len(m))
+
+	for k, v := range m {
+		s = append(s, yaml.MapItem{Key: k, Value: v})
+	}
+
+	sort.Slice(s, func(i int, j int) bool {
+		return fmt.Sprint(s[i].Key) < fmt.Sprint(s[j].Key)
+	})
+
+	return s
+}
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"strings"
 	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
 	"github.com/segmentio/objconv"
+	"github.com/segmentio/objconv/objutil"
 )
 
 type Parser struct {
@@ -31,22 +33,32 @@ func (p *Parser) Reset(r io.Reader) {
 	p.stack = nil
 }
 
+// Buffered satisfies the objconv.BufferedParser interface.
 func (p *Parser) Buffered() io.Reader {
 	return bytes.NewReader(nil)
 }
 
+// ParseRaw satisfies the objconv.RawParser interface, the value is re-encoded
+// by a YAML emitter.
+func (p *Parser) ParseRaw() ([]byte, error) {
+	b := &bytes.Buffer{}
+	err := objconv.Transcode(NewEmitter(b), p)
+	return b.Bytes(), err
+}
+
 func (p *Parser) ParseType() (typ objconv.Type, err error) {
 	if p.stack == nil {
 		var b []byte
-		var v interface{}
+		var v node
 
 		if b, err = ioutil.ReadAll(p.r); err != nil {
 			return
 		}
 		if err = yaml.Unmarshal(b, &v); err != nil {
+			err = syntaxError(err)
 			return
 		}
-		p.push(newParser(v))
+		p.push(newParser(v.value))
 	}
 
 	switch v := p.value(); v.(type) {
@@ -195,6 +207,12 @@ func (p *Parser) DecodeBytes(b []byte) (v []byte, err error) {
 	return
 }
 
+// syntaxError converts err, returned by gopkg.in/yaml.v2 for a malformed
+// input, to an error of the objutil.ErrSyntax kind.
+func syntaxError(err error) error {
+	return objutil.Errorf(objutil.ErrSyntax, "objconv/yaml: %s", strings.TrimPrefix(err.Error(), "yaml: "))
+}
+
 func (p *Parser) push(v parser) {
 	p.stack = append(p.stack, v)
 }
@@ -287,6 +305,9 @@ func (p *mapParser) len() int {
 
 func newParser(v interface{}) parser {
 	switch x := v.(type) {
+	case yaml.MapSlice:
+		return &mapParser{self: x}
+
 	case map[interface{}]interface{}:
 		return &mapParser{self: makeMapSlice(x)}
 
@@ -311,6 +332,43 @@ func makeMapSlice(m map[interface{}]interface{}) yaml.MapSlice {
 	return s
 }
 
+// node is used to unmarshal YAML documents, maps are loaded as yaml.MapSlice
+// values, which retain the order of the keys and keys that appear multiple
+// times, so the decoder sees the same sequence of values as with other formats.
+type node struct {
+	value interface{}
+}
+
+func (n *node) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&n.value); err != nil {
+		return err
+	}
+
+	switch n.value.(type) {
+	case map[interface{}]interface{}:
+		// Maps nested in a yaml.MapSlice are also loaded as yaml.MapSlice
+		// values by gopkg.in/yaml.v2.
+		var m yaml.MapSlice
+		if err := unmarshal(&m); err != nil {
+			return err
+		}
+		n.value = m
+
+	case []interface{}:
+		var a []node
+		if err := unmarshal(&a); err != nil {
+			return err
+		}
+		s := make([]interface{}, len(a))
+		for i := range a {
+			s[i] = a[i].value
+		}
+		n.value = s
+	}
+
+	return nil
+}
+
 // eof values are returned by the top method to indicate that all values have
 // already been consumed.
 type eof struct{}
@@ -1,8 +1,13 @@
 package yaml
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"testing"
 
+	"github.com/segmentio/objconv"
 	"github.com/segmentio/objconv/objtests"
 )
 
@@ -10,6 +15,110 @@ func TestCodec(t *testing.T) {
 	objtests.TestCodec(t, Codec)
 }
 
+func TestAppend(t *testing.T) {
+	objtests.TestAppend(t, Codec, Append)
+}
+
 func BenchmarkCodec(b *testing.B) {
 	objtests.BenchmarkCodec(b, Codec)
 }
+
+func TestRawMessage(t *testing.T) {
+	objtests.TestRawMessage(t, Codec)
+}
+
+func TestFormatCodec(t *testing.T) {
+	for _, f := range []objconv.Format{
+		objconv.DefaultFormat,
+		{Indent: "    ", Space: " ", Width: 40},
+		{},
+	} {
+		t.Run(fmt.Sprintf("%q", f.Indent), func(t *testing.T) {
+			objtests.TestCodec(t, objconv.Codec{
+				NewEmitter: func(w io.Writer) objconv.Emitter { return NewFormatEmitter(w, f) },
+				NewParser:  Codec.NewParser,
+			})
+		})
+	}
+}
+
+func TestFormatEmitter(t *testing.T) {
+	v := map[string]interface{}{
+		"A": []int{1, 2, 3},
+		"B": []interface{}{"Hello, World!", []int{}, map[string]int{"C": 42}, []string{"x", "y"}},
+		"D": map[string]interface{}{"E": "line 1\nline 2", "F": map[string]int{}},
+	}
+
+	tests := []struct {
+		f objconv.Format
+		s string
+	}{
+		{
+			f: objconv.DefaultFormat,
+			s: "A:\n  - 1\n  - 2\n  - 3\nB:\n  - \"Hello, World!\"\n  - []\n  - C: 42\n  - - x\n    - \"y\"\nD:\n  E: \"line 1\\nline 2\"\n  F: {}\n",
+		},
+		{
+			f: objconv.Format{Indent: "\t", Width: 10},
+			s: "A: [1, 2, 3]\nB:\n - \"Hello, World!\"\n - []\n - C: 42\n - [x, \"y\"]\nD:\n E: \"line 1\\nline 2\"\n F: {}\n",
+		},
+		{
+			f: objconv.Format{Space: " "},
+			s: "{A: [1, 2, 3], B: [\"Hello, World!\", [], {C: 42}, [x, \"y\"]], D: {E: \"line 1\\nline 2\", F: {}}}\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%q", test.f.Indent), func(t *testing.T) {
+			b := &bytes.Buffer{}
+			e := objconv.Encoder{Emitter: NewEmitter(b).FormatEmitter(test.f), SortMapKeys: true}
+
+			if err := e.Encode(v); err != nil {
+				t.Fatal(err)
+			}
+
+			if s := b.String(); s != test.s {
+				t.Errorf("bad output:\n%s", s)
+			}
+		})
+	}
+}
+
+func TestEncoderIndent(t *testing.T) {
+	b := &bytes.Buffer{}
+	e := NewEncoder(b)
+	e.Indent("  ", "    ")
+
+	if err := e.Encode(map[string][]int{"A": {1, 2}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := b.String(); s != "  A:\n      - 1\n      - 2\n" {
+		t.Errorf("bad output:\n%s", s)
+	}
+
+	var v map[string][]int
+
+	if err := Unmarshal(b.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(v["A"]) != 2 {
+		t.Errorf("bad value: %v", v)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	objtests.TestConformance(t, Codec)
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	var v interface{}
+
+	if err := Unmarshal([]byte("a: [1"), &v); !errors.Is(err, objconv.ErrSyntax) {
+		t.Errorf("bad error for a malformed document: %v", err)
+	}
+
+	if _, err := ParseDocument([]byte("a: [1")); !errors.Is(err, objconv.ErrSyntax) {
+		t.Errorf("bad error for a malformed document: %v", err)
+	}
+}